@@ -0,0 +1,172 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// clfTimeFormat is the time layout of the NCSA log formats.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogFormat selects the line format of an access log.
+type AccessLogFormat int
+
+const (
+	// CommonLog is the NCSA Common Log Format:
+	//	host ident authuser [date] "request" status bytes
+	CommonLog AccessLogFormat = iota
+	// CombinedLog is CommonLog followed by the quoted referer and user
+	// agent.
+	CombinedLog
+)
+
+// accessLogger writes one access log line per request to w.
+type accessLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format AccessLogFormat
+	clock  Clock
+	buf    []byte
+}
+
+// AccessLog returns HTTP middleware writing an NCSA Common or Combined Log
+// Format line to w for every request, for tools that only read those
+// formats. Lines are written whole, one Write per request, when the
+// handler returns. Middleware does the same with MiddlewareConfig.AccessLog.
+func AccessLog(w io.Writer, format AccessLogFormat) func(http.Handler) http.Handler {
+	return newAccessLogger(w, format, time.Now).middleware
+}
+
+func newAccessLogger(w io.Writer, format AccessLogFormat, clock Clock) *accessLogger {
+	return &accessLogger{w: w, format: format, clock: clock}
+}
+
+func (a *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := a.clock()
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() { a.write(r, sw, start) }()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+func (a *accessLogger) write(r *http.Request, sw *statusWriter, start time.Time) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user, _, _ := r.BasicAuth()
+	if user == "" && r.URL.User != nil {
+		user = r.URL.User.Username()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.buf[:0]
+	b = appendCLFField(b, host)
+	b = append(b, " - "...)
+	b = appendCLFField(b, user)
+	b = append(b, " ["...)
+	b = start.AppendFormat(b, clfTimeFormat)
+	b = append(b, "] \""...)
+	b = appendCLFEscaped(b, r.Method)
+	b = append(b, ' ')
+	b = appendCLFEscaped(b, r.RequestURI)
+	b = append(b, ' ')
+	b = appendCLFEscaped(b, r.Proto)
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(sw.Status()), 10)
+	b = append(b, ' ')
+	if sw.bytes == 0 {
+		b = append(b, '-')
+	} else {
+		b = strconv.AppendInt(b, sw.bytes, 10)
+	}
+	if a.format == CombinedLog {
+		b = append(b, " \""...)
+		b = appendCLFEscaped(b, r.Referer())
+		b = append(b, "\" \""...)
+		b = appendCLFEscaped(b, r.UserAgent())
+		b = append(b, '"')
+	}
+	b = append(b, '\n')
+	a.buf = b
+	a.w.Write(b)
+}
+
+// appendCLFField appends an unquoted field, "-" if it is empty.
+func appendCLFField(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	return appendCLFEscaped(b, s)
+}
+
+// appendCLFEscaped appends s with quotes, backslashes and non-printable
+// bytes escaped the way Apache does, so a client cannot break the line.
+func appendCLFEscaped(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < ' ' || c > '~':
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// statusWriter records the status code and body size written through a
+// ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status sent, 200 if the handler wrote nothing.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush passes flushes through for streaming handlers.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,55 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  AccessLogFormat
+		handler http.HandlerFunc
+		prep    func(r *http.Request)
+		want    string
+	}{
+		{
+			"common", CommonLog,
+			func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) },
+			func(r *http.Request) { r.SetBasicAuth("frank", "pw") },
+			`192.0.2.1 - frank [01/Mar/2024:12:30:45 +0000] "GET /a?b=1 HTTP/1.1" 200 5` + "\n",
+		},
+		{
+			"empty body", CommonLog,
+			func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			nil,
+			`192.0.2.1 - - [01/Mar/2024:12:30:45 +0000] "GET /a?b=1 HTTP/1.1" 204 -` + "\n",
+		},
+		{
+			"combined", CombinedLog,
+			func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			func(r *http.Request) {
+				r.Header.Set("Referer", "http://example.com/")
+				r.Header.Set("User-Agent", "evil\"agent\n")
+			},
+			`192.0.2.1 - - [01/Mar/2024:12:30:45 +0000] "GET /a?b=1 HTTP/1.1" 404 19 "http://example.com/" "evil\"agent\x0a"` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		l := newTestLogger(t, Info, nil)
+		h := Middleware(l, MiddlewareConfig{AccessLog: &buf, AccessLogFormat: tt.format})(tt.handler)
+		r := httptest.NewRequest(http.MethodGet, "/a?b=1", nil)
+		if tt.prep != nil {
+			tt.prep(r)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
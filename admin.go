@@ -0,0 +1,195 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultAdminEntries is the number of entries GET /entries returns
+	// without the n parameter.
+	DefaultAdminEntries = 100
+	// maxAdminBody bounds the level documents accepted by PUT /levels.
+	maxAdminBody = 64 << 10
+
+	AdminLevelsErrFmt = "Invalid levels document: %v"
+)
+
+// adminLevels is the document of GET and PUT /levels. In a PUT, a module
+// mapped to null or "" loses its override.
+type adminLevels struct {
+	Level   *LogLevel            `json:"level,omitempty"`
+	Modules map[string]*LogLevel `json:"modules,omitempty"`
+	Quiet   bool                 `json:"quiet,omitempty"`
+}
+
+// adminEntry is an element of GET /entries.
+type adminEntry struct {
+	Seq uint64 `json:"seq"`
+	jsonEntry
+}
+
+// AdminHandler returns a logging console for l to mount under a prefix
+// with http.StripPrefix:
+//
+//	GET  /         a page showing the levels and the recent entries
+//	GET  /levels   {"level": "INFO", "modules": {"db": "DEBUG"}}
+//	PUT  /levels   a document like the above; changes only what it lists
+//	GET  /entries  the last n (default 100) entries of b as JSON, filtered
+//	               by the level and name parameters like StreamFilter
+//
+// Module names are those of Named without the root logger name, see
+// SetModuleLevel. b is typically the Broadcaster also serving the live
+// streams; with a nil b, /entries is not found.
+func AdminHandler(l *CustomLogger, b *Broadcaster) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := strings.Trim(r.URL.Path, "/"); {
+		case path == "" && r.Method == http.MethodGet:
+			serveAdminPage(w, l, b)
+		case path == "levels" && r.Method == http.MethodGet:
+			writeAdminJSON(w, adminLevelsOf(l))
+		case path == "levels" && r.Method == http.MethodPut:
+			putAdminLevels(w, r, l)
+		case path == "entries" && r.Method == http.MethodGet && b != nil:
+			serveAdminEntries(w, r, b)
+		case path == "levels" || path == "entries" && b != nil || path == "":
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func adminLevelsOf(l *CustomLogger) adminLevels {
+	lvl := l.EffectiveLevel("")
+	doc := adminLevels{Level: &lvl, Quiet: l.Quiet()}
+	if mods := l.ModuleLevels(); len(mods) > 0 {
+		doc.Modules = make(map[string]*LogLevel, len(mods))
+		for m, lvl := range mods {
+			lvl := lvl
+			doc.Modules[m] = &lvl
+		}
+	}
+	return doc
+}
+
+func putAdminLevels(w http.ResponseWriter, r *http.Request, l *CustomLogger) {
+	var doc struct {
+		Level   *LogLevel                  `json:"level"`
+		Modules map[string]json.RawMessage `json:"modules"`
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAdminBody))
+	if err == nil {
+		err = json.Unmarshal(body, &doc)
+	}
+	// Decode every module before applying anything, so a bad document
+	// changes nothing.
+	set := make(map[string]LogLevel)
+	var clear []string
+	for m, raw := range doc.Modules {
+		if err != nil {
+			break
+		}
+		if s := strings.TrimSpace(string(raw)); s == "null" || s == `""` {
+			clear = append(clear, m)
+			continue
+		}
+		var lvl LogLevel
+		if err = json.Unmarshal(raw, &lvl); err == nil {
+			set[m] = lvl
+		}
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(AdminLevelsErrFmt, err), http.StatusBadRequest)
+		return
+	}
+	if doc.Level != nil {
+		l.SetLevel(*doc.Level)
+	}
+	for m, lvl := range set {
+		l.SetModuleLevel(m, lvl)
+	}
+	for _, m := range clear {
+		l.ClearModuleLevel(m)
+	}
+	writeAdminJSON(w, adminLevelsOf(l))
+}
+
+func serveAdminEntries(w http.ResponseWriter, r *http.Request, b *Broadcaster) {
+	filter, ok := filterFromQuery(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf(StreamLevelErrFmt, r.URL.Query().Get("level")), http.StatusBadRequest)
+		return
+	}
+	n := DefaultAdminEntries
+	if s := r.URL.Query().Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			http.Error(w, "Invalid entry count", http.StatusBadRequest)
+			return
+		}
+		n = v
+	}
+	recent := b.Recent(filter, n)
+	out := make([]adminEntry, len(recent))
+	for i, se := range recent {
+		out[i] = adminEntry{Seq: se.Seq, jsonEntry: newJSONEntry(se.Entry)}
+	}
+	writeAdminJSON(w, out)
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// serveAdminPage renders a static page, so the console works without
+// scripts; reload it to refresh.
+func serveAdminPage(w http.ResponseWriter, l *CustomLogger, b *Broadcaster) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<title>Logging</title>\n<style>body{font-family:sans-serif}pre{white-space:pre-wrap}</style>\n")
+	doc := adminLevelsOf(l)
+	fmt.Fprintf(&sb, "<h1>Levels</h1>\n<p>Level: <b>%s</b>", html.EscapeString(doc.Level.String()))
+	if doc.Quiet {
+		sb.WriteString(" (quiet)")
+	}
+	sb.WriteString("</p>\n")
+	if len(doc.Modules) > 0 {
+		sb.WriteString("<table>\n")
+		mods := make([]string, 0, len(doc.Modules))
+		for m := range doc.Modules {
+			mods = append(mods, m)
+		}
+		sort.Strings(mods)
+		for _, m := range mods {
+			fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(m), html.EscapeString(doc.Modules[m].String()))
+		}
+		sb.WriteString("</table>\n")
+	}
+	if b != nil {
+		sb.WriteString("<h1>Recent entries</h1>\n<pre>")
+		buf := GetBuffer()
+		for _, se := range b.Recent(AllEntries, DefaultAdminEntries) {
+			buf.Reset()
+			(TextEncoder{}).Encode(buf, se.Entry)
+			sb.WriteString(html.EscapeString(buf.String()))
+		}
+		PutBuffer(buf)
+		sb.WriteString("</pre>\n")
+	}
+	io.WriteString(w, sb.String())
+}
@@ -0,0 +1,77 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminLevels(t *testing.T) {
+	l := newTestLogger(t, Info, io.Discard)
+	h := AdminHandler(l, nil)
+	do := func(method, body string) (int, adminLevels) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/levels", strings.NewReader(body)))
+		var doc adminLevels
+		json.Unmarshal(w.Body.Bytes(), &doc)
+		return w.Code, doc
+	}
+
+	code, doc := do(http.MethodPut, `{"level":"warn","modules":{"db":"debug","http":"error"}}`)
+	if code != http.StatusOK || *doc.Level != Warn || *doc.Modules["db"] != Debug {
+		t.Fatalf("PUT: %d %+v", code, doc)
+	}
+	if !l.Named("db").enabled(Debug) || l.enabled(Info) {
+		t.Error("levels not applied")
+	}
+	if code, doc = do(http.MethodPut, `{"modules":{"http":null}}`); code != http.StatusOK || doc.Modules["http"] != nil {
+		t.Errorf("clear: %d %+v", code, doc)
+	}
+	if code, _ := do(http.MethodPut, `{"level":"warn","modules":{"db":"loud"}}`); code != http.StatusBadRequest {
+		t.Errorf("bad level: %d", code)
+	}
+	if _, doc = do(http.MethodGet, ""); *doc.Level != Warn || len(doc.Modules) != 1 {
+		t.Errorf("GET after bad PUT: %+v", doc)
+	}
+	if code, _ := do(http.MethodPost, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: %d", code)
+	}
+}
+
+func TestAdminEntries(t *testing.T) {
+	b := NewBroadcaster(BroadcastConfig{})
+	l := newTestLogger(t, Debug, io.Discard, WithSinks(b))
+	l.Info("one")
+	l.Log(Warn, "two <b>", Int("n", 2))
+	l.Log(Error, "three")
+	h := AdminHandler(l, b)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/entries?n=2&level=warn", nil))
+	var got []struct {
+		Seq uint64 `json:"seq"`
+		Msg string `json:"msg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err, w.Body.String())
+	}
+	if len(got) != 2 || got[0].Seq != 2 || got[0].Msg != "two <b>" || got[1].Msg != "three" {
+		t.Errorf("entries = %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if page := w.Body.String(); !strings.Contains(page, "two &lt;b&gt; n=2") || !strings.Contains(page, "DEBUG") {
+		t.Errorf("page = %s", page)
+	}
+	w = httptest.NewRecorder()
+	AdminHandler(l, nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/entries", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("entries without broadcaster: %d", w.Code)
+	}
+}
@@ -0,0 +1,149 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAlertThreshold is the number of entries tolerated per window.
+	DefaultAlertThreshold = 10
+	// DefaultAlertWindow is the interval the entries are counted over.
+	DefaultAlertWindow = 5 * time.Minute
+
+	// AlertFmt is the message of the entry an AlertSink writes to its
+	// notifier sink.
+	AlertFmt = "%d entries at %s or above within %s"
+)
+
+// AlertReport describes a tripped threshold.
+type AlertReport struct {
+	// Count is the number of matching entries in the window.
+	Count int
+	// Window is the configured window.
+	Window time.Duration
+	// First and Last are the times of the oldest and newest entry counted.
+	First, Last time.Time
+	// Entry is a copy of the entry that tripped the threshold.
+	Entry *Entry
+}
+
+// AlertConfig configures an AlertSink.
+type AlertConfig struct {
+	// Threshold is the number of entries tolerated per window; one more
+	// trips the alert. Zero selects DefaultAlertThreshold.
+	Threshold int
+	// Window is the interval the entries are counted over; zero selects
+	// DefaultAlertWindow.
+	Window time.Duration
+	// MinLevel is the lowest level counted; zero means Error.
+	MinLevel LogLevel
+	// ExplicitLevels uses MinLevel as given, so Debug (zero) can be
+	// selected.
+	ExplicitLevels bool
+	// Match, if set, further selects the entries counted, see
+	// ParseMatcher.
+	Match Matcher
+	// Cooldown is how long after an alert no other fires; zero means
+	// Window.
+	Cooldown time.Duration
+	// Notify is called with every alert.
+	Notify func(AlertReport)
+	// Notifier, if set, receives an entry at level Alert for every alert,
+	// e.g. a webhook or mail sink.
+	Notifier Sink
+}
+
+// AlertSink counts the entries written to it and calls back once more
+// than Threshold matching entries fall within Window, then stays quiet for
+// Cooldown. It writes nothing itself; add it with WithSinks. Entries are
+// counted by their time, so replayed or clock-faked entries alert the same
+// as live ones.
+type AlertSink struct {
+	cfg AlertConfig
+
+	mu    sync.Mutex
+	times []time.Time // of the counted entries in the window, oldest first
+	quiet time.Time   // end of the cooldown
+}
+
+// NewAlertSink returns an AlertSink alerting as cfg says.
+func NewAlertSink(cfg AlertConfig) *AlertSink {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultAlertThreshold
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultAlertWindow
+	}
+	if cfg.MinLevel == 0 && !cfg.ExplicitLevels {
+		cfg.MinLevel = Error
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = cfg.Window
+	}
+	return &AlertSink{cfg: cfg}
+}
+
+// WriteEntry implements Sink. The notifications run on the calling
+// goroutine, after the sink's lock is released, so they may log.
+func (s *AlertSink) WriteEntry(e *Entry) error {
+	if e.Level < s.cfg.MinLevel || (s.cfg.Match != nil && !s.cfg.Match(e)) {
+		return nil
+	}
+	a, ok := s.count(e)
+	if !ok {
+		return nil
+	}
+	if s.cfg.Notify != nil {
+		s.cfg.Notify(a)
+	}
+	if s.cfg.Notifier != nil {
+		n := &Entry{
+			Time:    e.Time,
+			Level:   Alert,
+			Name:    e.Name,
+			Message: fmt.Sprintf(AlertFmt, a.Count, s.cfg.MinLevel, s.cfg.Window),
+			Fields: []Field{
+				Int("count", a.Count),
+				Time("first", a.First),
+				String("last_msg", e.Message),
+			},
+		}
+		return s.cfg.Notifier.WriteEntry(n)
+	}
+	return nil
+}
+
+// count records e and reports an alert if it trips the threshold.
+func (s *AlertSink) count(e *Entry) (AlertReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := e.Time
+	// Entries during the cooldown are not counted, so the next alert
+	// needs a fresh window of them.
+	if now.Before(s.quiet) {
+		return AlertReport{}, false
+	}
+	start := now.Add(-s.cfg.Window)
+	i := 0
+	for i < len(s.times) && !s.times[i].After(start) {
+		i++
+	}
+	s.times = append(s.times[i:], now)
+	if len(s.times) <= s.cfg.Threshold {
+		return AlertReport{}, false
+	}
+	a := AlertReport{
+		Count:  len(s.times),
+		Window: s.cfg.Window,
+		First:  s.times[0],
+		Last:   now,
+		Entry:  e.Clone(),
+	}
+	s.quiet = now.Add(s.cfg.Cooldown)
+	s.times = s.times[:0]
+	return a, true
+}
@@ -0,0 +1,68 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertSink(t *testing.T) {
+	var notified bytes.Buffer
+	var reports []AlertReport
+	now := testTime
+	match, err := ParseMatcher("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := NewAlertSink(AlertConfig{
+		Threshold: 2,
+		Window:    time.Minute,
+		Cooldown:  10 * time.Minute,
+		Match:     match,
+		Notify:    func(a AlertReport) { reports = append(reports, a) },
+		Notifier:  NewWriterSink(&notified, TextEncoder{}),
+	})
+	l := newTestLogger(t, Debug, &bytes.Buffer{},
+		WithClock(func() time.Time { return now }),
+		WithSinks(alerts))
+	step := func(d time.Duration, level LogLevel, msg string, fields ...Field) {
+		now = now.Add(d)
+		l.Log(level, msg, fields...)
+	}
+
+	step(0, Error, "query failed", String("db", "main"))
+	step(time.Second, Warn, "slow", String("db", "main"))
+	step(time.Second, Error, "unrelated")
+	step(time.Minute, Error, "query failed", String("db", "main")) // first one out of the window
+	step(time.Second, Error, "query failed", String("db", "main"))
+	if len(reports) != 0 {
+		t.Fatalf("alerted early: %+v", reports)
+	}
+	step(time.Second, Error, "query failed again", String("db", "main"))
+	if len(reports) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(reports))
+	}
+	a := reports[0]
+	if a.Count != 3 || a.Entry.Message != "query failed again" || a.Last.Sub(a.First) != 2*time.Second {
+		t.Errorf("report = %+v", a)
+	}
+	if !strings.Contains(notified.String(), "ALERT") || !strings.Contains(notified.String(), "3 entries at ERROR or above within 1m0s") {
+		t.Errorf("notifier got %q", notified.String())
+	}
+
+	for i := 0; i < 5; i++ {
+		step(time.Second, Error, "query failed", String("db", "main"))
+	}
+	if len(reports) != 1 {
+		t.Errorf("alerted during the cooldown")
+	}
+	step(10*time.Minute, Error, "query failed", String("db", "main"))
+	step(time.Second, Error, "query failed", String("db", "main"))
+	step(time.Second, Error, "query failed", String("db", "main"))
+	if len(reports) != 2 {
+		t.Errorf("got %d alerts after the cooldown, want 2", len(reports))
+	}
+}
@@ -0,0 +1,387 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRoutingKey routes entries by their lower-case level.
+const DefaultRoutingKey = "{level}"
+
+const AMQPErrFmt = "AMQP: %s"
+
+const (
+	amqpFrameMethod    = 1
+	amqpFrameHeader    = 2
+	amqpFrameBody      = 3
+	amqpFrameHeartbeat = 8
+	amqpFrameEnd       = 0xCE
+	amqpFrameMin       = 4096
+	amqpFrameMax       = 128 << 10
+)
+
+// AMQPConfig configures an AMQPSender.
+type AMQPConfig struct {
+	// Addr is the host:port of the broker, e.g. "localhost:5672".
+	Addr     string
+	Username string
+	Password string
+	// VHost defaults to "/".
+	VHost string
+	// Exchange and RoutingKey are templates expanded per entry; {level}
+	// (lower case), {name}, {host} and {pid} are replaced.
+	Exchange   string
+	RoutingKey string
+	// Transient disables persistent delivery mode.
+	Transient   bool
+	TLS         *tls.Config
+	DialTimeout time.Duration
+}
+
+// AMQPSender is a BatchSender that publishes entries as JSON messages to
+// an AMQP 0-9-1 broker such as RabbitMQ. Publisher confirms are enabled so
+// that SendBatch only succeeds once the broker has taken responsibility
+// for every message; the connection is re-established after a failure.
+type AMQPSender struct {
+	cfg      AMQPConfig
+	exchange string
+	key      string
+
+	mu        sync.Mutex
+	conn      net.Conn
+	r         *bufio.Reader
+	w         *bufio.Writer
+	frameMax  int
+	heartbeat time.Duration
+	lastIO    time.Time
+	// tag is the delivery tag of the last message published on the
+	// current channel.
+	tag uint64
+}
+
+// NewAMQPSender returns a sender for cfg. The connection is made on first
+// use.
+func NewAMQPSender(cfg AMQPConfig) *AMQPSender {
+	if cfg.VHost == "" {
+		cfg.VHost = "/"
+	}
+	if cfg.RoutingKey == "" {
+		cfg.RoutingKey = DefaultRoutingKey
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	return &AMQPSender{
+		cfg:      cfg,
+		exchange: expandTemplate(cfg.Exchange),
+		key:      expandTemplate(cfg.RoutingKey),
+	}
+}
+
+// amqpMessage is the JSON body of a published entry.
+type amqpMessage struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Name    string                 `json:"name,omitempty"`
+	Message string                 `json:"msg"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Acknowledges implements Acknowledger: publisher confirms acknowledge
+// every batch.
+func (s *AMQPSender) Acknowledges() bool {
+	return true
+}
+
+// SendBatch implements BatchSender.
+func (s *AMQPSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The broker drops connections that miss heartbeats; rather than run
+	// a heartbeat goroutine, reconnect when the link has been idle too long.
+	if s.conn != nil && s.heartbeat > 0 && time.Since(s.lastIO) > s.heartbeat {
+		s.closeLocked()
+	}
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	// Encode every entry before publishing so a failure cannot leave part
+	// of the batch buffered or advance the delivery tags.
+	bodies := make([][]byte, len(batch))
+	for i, e := range batch {
+		body, err := json.Marshal(amqpMessage{
+			Time:    e.Time,
+			Level:   e.Level.String(),
+			Name:    e.Name,
+			Message: e.Message,
+			Fields:  e.FieldMap(),
+		})
+		if err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		bodies[i] = body
+	}
+	s.setDeadline(ctx)
+
+	for i, e := range batch {
+		r := strings.NewReplacer("{level}", strings.ToLower(e.Level.String()), "{name}", e.Name)
+		s.publish(r.Replace(s.exchange), r.Replace(s.key), bodies[i], e.Time)
+	}
+	if err := s.w.Flush(); err != nil {
+		s.closeLocked()
+		return err
+	}
+
+	// Wait for all confirms, even after a nack, so none is left for the
+	// next batch to mistake for its own; one may cover several deliveries.
+	first := s.tag - uint64(len(batch))
+	done := make([]bool, len(batch))
+	nacked := false
+	for remaining := len(batch); remaining > 0; {
+		class, method, args, err := s.readMethod()
+		if err != nil {
+			s.closeLocked()
+			return err
+		}
+		if class != 60 || (method != 80 && method != 120) || len(args) < 9 {
+			s.closeLocked()
+			return amqpCloseError(class, method, args)
+		}
+		if method == 120 {
+			nacked = true
+		}
+		tag, multiple := binary.BigEndian.Uint64(args), args[8]&1 != 0
+		for i := range done {
+			t := first + uint64(i) + 1
+			if !done[i] && (t == tag || multiple && t < tag) {
+				done[i] = true
+				remaining--
+			}
+		}
+	}
+	if nacked {
+		return fmt.Errorf(AMQPErrFmt, "broker rejected message")
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *AMQPSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.writeMethod(0, 10, 50, amqpArgs{}.short(200).shortstr("").short(0).short(0))
+		s.w.Flush()
+	}
+	return s.closeLocked()
+}
+
+func (s *AMQPSender) closeLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *AMQPSender) setDeadline(ctx context.Context) {
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(dl)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+}
+
+func (s *AMQPSender) connectLocked(ctx context.Context) error {
+	conn, err := dialNetwork(ctx, s.cfg.Addr, s.cfg.DialTimeout, s.cfg.TLS)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.r = bufio.NewReader(conn)
+	s.w = bufio.NewWriter(conn)
+	s.frameMax = amqpFrameMin
+	s.tag = 0
+	s.setDeadline(ctx)
+	if err := s.handshake(); err != nil {
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// handshake opens the connection, channel 1 and confirm mode.
+func (s *AMQPSender) handshake() error {
+	s.w.WriteString("AMQP\x00\x00\x09\x01")
+	s.w.Flush()
+
+	if err := s.expect(10, 10); err != nil { // connection.start
+		return err
+	}
+	props := amqpArgs{}.shortstr("product").byte('S').longstr("peter-bird.com/logger")
+	s.writeMethod(0, 10, 11, amqpArgs{}.longstr(string(props)).
+		shortstr("PLAIN").
+		longstr("\x00"+s.cfg.Username+"\x00"+s.cfg.Password).
+		shortstr("en_US"))
+	s.w.Flush()
+
+	class, method, args, err := s.readMethod() // connection.tune
+	if err != nil {
+		return err
+	}
+	if class != 10 || method != 30 || len(args) < 8 {
+		return amqpCloseError(class, method, args)
+	}
+	channelMax := binary.BigEndian.Uint16(args)
+	frameMax := int(binary.BigEndian.Uint32(args[2:]))
+	heartbeat := binary.BigEndian.Uint16(args[6:])
+	if frameMax == 0 || frameMax > amqpFrameMax {
+		frameMax = amqpFrameMax
+	}
+	s.frameMax = frameMax
+	s.heartbeat = time.Duration(heartbeat) * time.Second
+	s.writeMethod(0, 10, 31, amqpArgs{}.short(channelMax).long(uint32(frameMax)).short(heartbeat))
+	s.writeMethod(0, 10, 40, amqpArgs{}.shortstr(s.cfg.VHost).shortstr("").byte(0))
+	s.w.Flush()
+	if err := s.expect(10, 41); err != nil { // connection.open-ok
+		return err
+	}
+
+	s.writeMethod(1, 20, 10, amqpArgs{}.shortstr(""))
+	s.writeMethod(1, 85, 10, amqpArgs{}.byte(0))
+	s.w.Flush()
+	if err := s.expect(20, 11); err != nil { // channel.open-ok
+		return err
+	}
+	return s.expect(85, 11) // confirm.select-ok
+}
+
+func (s *AMQPSender) expect(class, method uint16) error {
+	c, m, args, err := s.readMethod()
+	if err != nil {
+		return err
+	}
+	if c != class || m != method {
+		return amqpCloseError(c, m, args)
+	}
+	return nil
+}
+
+// publish buffers a basic.publish with its content header and body.
+func (s *AMQPSender) publish(exchange, key string, body []byte, ts time.Time) {
+	s.writeMethod(1, 60, 40, amqpArgs{}.short(0).shortstr(exchange).shortstr(key).byte(0))
+
+	mode := byte(2)
+	if s.cfg.Transient {
+		mode = 1
+	}
+	// content-type, delivery-mode and timestamp properties.
+	hdr := amqpArgs{}.short(60).short(0).longlong(uint64(len(body))).short(0x8000 | 0x1000 | 0x0040).
+		shortstr("application/json").byte(mode).longlong(uint64(ts.Unix()))
+	s.writeFrame(amqpFrameHeader, 1, hdr)
+
+	for max := s.frameMax - 8; len(body) > 0; {
+		n := len(body)
+		if n > max {
+			n = max
+		}
+		s.writeFrame(amqpFrameBody, 1, body[:n])
+		body = body[n:]
+	}
+	s.tag++
+}
+
+func (s *AMQPSender) writeMethod(channel, class, method uint16, args amqpArgs) {
+	s.writeFrame(amqpFrameMethod, channel, append(amqpArgs{}.short(class).short(method), args...))
+}
+
+func (s *AMQPSender) writeFrame(kind byte, channel uint16, payload []byte) {
+	var hdr [7]byte
+	hdr[0] = kind
+	binary.BigEndian.PutUint16(hdr[1:], channel)
+	binary.BigEndian.PutUint32(hdr[3:], uint32(len(payload)))
+	s.w.Write(hdr[:])
+	s.w.Write(payload)
+	s.w.WriteByte(amqpFrameEnd)
+	s.lastIO = time.Now()
+}
+
+// readMethod returns the next method frame, answering heartbeats.
+func (s *AMQPSender) readMethod() (class, method uint16, args []byte, err error) {
+	for {
+		var hdr [7]byte
+		if _, err = io.ReadFull(s.r, hdr[:]); err != nil {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(hdr[3:]))
+		if size > amqpFrameMax {
+			return 0, 0, nil, fmt.Errorf(AMQPErrFmt, "frame too large")
+		}
+		payload := make([]byte, size+1)
+		if _, err = io.ReadFull(s.r, payload); err != nil {
+			return
+		}
+		if payload[size] != amqpFrameEnd {
+			return 0, 0, nil, fmt.Errorf(AMQPErrFmt, "malformed frame")
+		}
+		s.lastIO = time.Now()
+		switch hdr[0] {
+		case amqpFrameHeartbeat:
+			s.writeFrame(amqpFrameHeartbeat, 0, nil)
+			s.w.Flush()
+			continue
+		case amqpFrameMethod:
+			if size < 4 {
+				return 0, 0, nil, fmt.Errorf(AMQPErrFmt, "malformed frame")
+			}
+			return binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:]), payload[4:size], nil
+		}
+	}
+}
+
+// amqpCloseError describes an unexpected method, using the reply text of
+// connection.close and channel.close.
+func amqpCloseError(class, method uint16, args []byte) error {
+	if method == 40 && (class == 10 || class == 20) && len(args) >= 3 {
+		code := binary.BigEndian.Uint16(args)
+		n := int(args[2])
+		if len(args) >= 3+n {
+			return fmt.Errorf(AMQPErrFmt, fmt.Sprintf("%d %s", code, args[3:3+n]))
+		}
+	}
+	return fmt.Errorf(AMQPErrFmt, fmt.Sprintf("unexpected method %d.%d", class, method))
+}
+
+// amqpArgs builds method arguments in network byte order.
+type amqpArgs []byte
+
+func (a amqpArgs) byte(b byte) amqpArgs       { return append(a, b) }
+func (a amqpArgs) short(v uint16) amqpArgs    { return binary.BigEndian.AppendUint16(a, v) }
+func (a amqpArgs) long(v uint32) amqpArgs     { return binary.BigEndian.AppendUint32(a, v) }
+func (a amqpArgs) longlong(v uint64) amqpArgs { return binary.BigEndian.AppendUint64(a, v) }
+
+func (a amqpArgs) shortstr(s string) amqpArgs {
+	if len(s) > 255 {
+		s = s[:255]
+	}
+	return append(append(a, byte(len(s))), s...)
+}
+
+func (a amqpArgs) longstr(s string) amqpArgs {
+	return append(a.long(uint32(len(s))), s...)
+}
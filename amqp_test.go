@@ -0,0 +1,238 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker is an AMQP 0-9-1 broker that accepts the handshake and
+// confirms each published message as decided by confirm.
+type fakeBroker struct {
+	ln net.Listener
+	// confirm returns the method (80 ack, 120 nack) for a delivery tag, or
+	// 0 to confirm it later with a multiple ack.
+	confirm func(tag uint64) uint16
+
+	mu       sync.Mutex
+	bodies   []string
+	keys     []string
+	sessions int
+}
+
+func newFakeBroker(t *testing.T, confirm func(tag uint64) uint16) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{ln: ln, confirm: confirm}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func brokerFrame(w *bufio.Writer, kind byte, channel uint16, payload []byte) {
+	var hdr [7]byte
+	hdr[0] = kind
+	binary.BigEndian.PutUint16(hdr[1:], channel)
+	binary.BigEndian.PutUint32(hdr[3:], uint32(len(payload)))
+	w.Write(hdr[:])
+	w.Write(payload)
+	w.WriteByte(amqpFrameEnd)
+}
+
+func brokerMethod(w *bufio.Writer, channel, class, method uint16, args amqpArgs) {
+	brokerFrame(w, amqpFrameMethod, channel, append(amqpArgs{}.short(class).short(method), args...))
+	w.Flush()
+}
+
+func readBrokerFrame(r *bufio.Reader) (kind byte, payload []byte, err error) {
+	var hdr [7]byte
+	if _, err = io.ReadFull(r, hdr[:]); err != nil {
+		return
+	}
+	payload = make([]byte, binary.BigEndian.Uint32(hdr[3:])+1)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	return hdr[0], payload[:len(payload)-1], nil
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	b.mu.Lock()
+	b.sessions++
+	b.mu.Unlock()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	var proto [8]byte
+	if _, err := io.ReadFull(r, proto[:]); err != nil || string(proto[:]) != "AMQP\x00\x00\x09\x01" {
+		return
+	}
+	brokerMethod(w, 0, 10, 10, amqpArgs{}.byte(0).byte(9).long(0).longstr("PLAIN").longstr("en_US"))
+
+	var tag, unconfirmed uint64
+	var body []byte
+	var size uint64
+	var key string
+	for {
+		kind, p, err := readBrokerFrame(r)
+		if err != nil {
+			return
+		}
+		switch kind {
+		case amqpFrameMethod:
+			class, method := binary.BigEndian.Uint16(p), binary.BigEndian.Uint16(p[2:])
+			switch {
+			case class == 10 && method == 11: // start-ok
+				brokerMethod(w, 0, 10, 30, amqpArgs{}.short(1).long(amqpFrameMin).short(0))
+			case class == 10 && method == 40: // open
+				brokerMethod(w, 0, 10, 41, amqpArgs{}.shortstr(""))
+			case class == 20 && method == 10:
+				brokerMethod(w, 1, 20, 11, amqpArgs{}.longstr(""))
+			case class == 85 && method == 10:
+				brokerMethod(w, 1, 85, 11, nil)
+			case class == 60 && method == 40: // publish
+				args := p[4+2:]
+				args = args[1+int(args[0]):] // exchange
+				key = string(args[1 : 1+int(args[0])])
+			}
+		case amqpFrameHeader:
+			size = binary.BigEndian.Uint64(p[4:])
+			body = body[:0]
+		case amqpFrameBody:
+			body = append(body, p...)
+			if uint64(len(body)) < size {
+				continue
+			}
+			tag++
+			b.mu.Lock()
+			b.bodies = append(b.bodies, string(body))
+			b.keys = append(b.keys, key)
+			b.mu.Unlock()
+			switch m := b.confirm(tag); m {
+			case 0:
+				unconfirmed = tag
+			default:
+				if unconfirmed > 0 {
+					brokerMethod(w, 1, 60, 80, amqpArgs{}.longlong(unconfirmed).byte(1))
+					unconfirmed = 0
+				}
+				brokerMethod(w, 1, 60, m, amqpArgs{}.longlong(tag).byte(0))
+			}
+		}
+	}
+}
+
+func TestAMQPSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm func(tag uint64) uint16
+		wantErr []bool
+	}{
+		{"acks", func(uint64) uint16 { return 80 }, []bool{false, false}},
+		{"multiple ack", func(tag uint64) uint16 {
+			if tag%3 == 0 {
+				return 80
+			}
+			return 0
+		}, []bool{false, false}},
+		// A nack fails its batch, and the next batch must not read the
+		// confirms left over from it.
+		{"nack", func(tag uint64) uint16 {
+			if tag == 1 {
+				return 120
+			}
+			return 80
+		}, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		b := newFakeBroker(t, tt.confirm)
+		s := NewAMQPSender(AMQPConfig{Addr: b.ln.Addr().String(), Exchange: "logs"})
+		for i, wantErr := range tt.wantErr {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			batch := []*Entry{
+				{Time: testTime, Level: Info, Message: "a"},
+				{Time: testTime, Level: Error, Message: strings.Repeat("x", 3*amqpFrameMin)},
+				{Time: testTime, Level: Warn, Message: "c", Fields: []Field{Int("n", i)}},
+			}
+			err := s.SendBatch(ctx, batch)
+			cancel()
+			if (err != nil) != wantErr {
+				t.Errorf("%s: batch %d error = %v, want error %v", tt.name, i, err, wantErr)
+			}
+		}
+		s.Close()
+
+		b.mu.Lock()
+		if b.sessions != 1 {
+			t.Errorf("%s: %d connections", tt.name, b.sessions)
+		}
+		if len(b.keys) != 6 || b.keys[1] != "error" || b.keys[2] != "warn" {
+			t.Errorf("%s: routing keys %v", tt.name, b.keys)
+		}
+		var msg amqpMessage
+		if err := json.Unmarshal([]byte(b.bodies[5]), &msg); err != nil || msg.Message != "c" || msg.Fields["n"] != 1.0 {
+			t.Errorf("%s: body %s: %v", tt.name, b.bodies[5], err)
+		}
+		if len(b.bodies[1]) < 3*amqpFrameMin {
+			t.Errorf("%s: large body has %d bytes", tt.name, len(b.bodies[1]))
+		}
+		b.mu.Unlock()
+	}
+}
+
+func TestAMQPArgs(t *testing.T) {
+	tests := []struct {
+		args amqpArgs
+		want string
+	}{
+		{amqpArgs{}.byte(1), "\x01"},
+		{amqpArgs{}.short(0x0102), "\x01\x02"},
+		{amqpArgs{}.long(1), "\x00\x00\x00\x01"},
+		{amqpArgs{}.longlong(1), "\x00\x00\x00\x00\x00\x00\x00\x01"},
+		{amqpArgs{}.shortstr("ab"), "\x02ab"},
+		{amqpArgs{}.shortstr(strings.Repeat("x", 300)), "\xff" + strings.Repeat("x", 255)},
+		{amqpArgs{}.longstr("ab"), "\x00\x00\x00\x02ab"},
+	}
+	for i, tt := range tests {
+		if string(tt.args) != tt.want {
+			t.Errorf("%d: got %q, want %q", i, tt.args, tt.want)
+		}
+	}
+}
+
+func TestAMQPCloseError(t *testing.T) {
+	tests := []struct {
+		class, method uint16
+		args          []byte
+		want          string
+	}{
+		{10, 40, amqpArgs{}.short(403).shortstr("ACCESS_REFUSED").short(0).short(0), "AMQP: 403 ACCESS_REFUSED"},
+		{20, 40, amqpArgs{}.short(404).shortstr("NOT_FOUND"), "AMQP: 404 NOT_FOUND"},
+		{20, 40, []byte{1}, "AMQP: unexpected method 20.40"},
+		{60, 80, nil, "AMQP: unexpected method 60.80"},
+	}
+	for _, tt := range tests {
+		if got := amqpCloseError(tt.class, tt.method, tt.args).Error(); got != tt.want {
+			t.Errorf("amqpCloseError(%d, %d) = %q, want %q", tt.class, tt.method, got, tt.want)
+		}
+	}
+}
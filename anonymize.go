@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// DefaultIPFields are the fields AnonymizeIP rewrites when none are
+// configured, covering the remote address Recoverer logs.
+var DefaultIPFields = []string{"ip", "client_ip", "remote_addr"}
+
+// IPAnonymizeConfig configures AnonymizeIP.
+type IPAnonymizeConfig struct {
+	// Fields are the keys of the fields holding addresses, dotted for the
+	// members of groups; nil selects DefaultIPFields.
+	Fields []string
+	// IPv4Bits is the prefix of IPv4 addresses kept; zero selects 24,
+	// which zeroes the last octet.
+	IPv4Bits int
+	// IPv6Bits is the prefix of IPv6 addresses kept; zero selects 48,
+	// which keeps the site and drops the subnet and interface.
+	IPv6Bits int
+}
+
+// AnonymizeIP returns a processor that zeroes the host part of the IPv4
+// and IPv6 addresses in the configured fields, e.g. 203.0.113.42 becomes
+// 203.0.113.0, keeping the network for geolocation and troubleshooting
+// while the address no longer identifies a person. Fields may hold an
+// address with a port, as in http.Request.RemoteAddr, a comma separated
+// list, as in X-Forwarded-For, a net.IP or a netip.Addr; other values are
+// left alone.
+func AnonymizeIP(cfg IPAnonymizeConfig) Processor {
+	if cfg.Fields == nil {
+		cfg.Fields = DefaultIPFields
+	}
+	if cfg.IPv4Bits == 0 {
+		cfg.IPv4Bits = 24
+	}
+	if cfg.IPv6Bits == 0 {
+		cfg.IPv6Bits = 48
+	}
+	keys := make(map[string]bool, len(cfg.Fields))
+	for _, k := range cfg.Fields {
+		keys[k] = true
+	}
+	return ProcessorFunc(func(e *Entry) {
+		anonymizeFields(e.Fields, "", keys, cfg)
+	})
+}
+
+// WithIPAnonymization anonymizes the addresses in every entry, see
+// AnonymizeIP.
+func WithIPAnonymization(cfg IPAnonymizeConfig) Option {
+	return WithProcessors(AnonymizeIP(cfg))
+}
+
+// anonymizeFields rewrites fields in place, copying groups that change
+// since they may be shared with the caller or the logger.
+func anonymizeFields(fields []Field, prefix string, keys map[string]bool, cfg IPAnonymizeConfig) bool {
+	changed := false
+	for i := range fields {
+		f := &fields[i]
+		key := prefix + f.Key
+		if f.Type == GroupType {
+			members, _ := f.Value.([]Field)
+			members = append([]Field(nil), members...)
+			if anonymizeFields(members, key+".", keys, cfg) {
+				f.Value, changed = members, true
+			}
+			continue
+		}
+		if !keys[key] {
+			continue
+		}
+		var s string
+		switch v := f.Interface().(type) {
+		case string:
+			s = v
+		case net.IP:
+			s = v.String()
+		case netip.Addr:
+			s = v.String()
+		default:
+			continue
+		}
+		if a, ok := anonymizeAddrs(s, cfg); ok {
+			*f, changed = String(f.Key, a), true
+		}
+	}
+	return changed
+}
+
+// anonymizeAddrs anonymizes each address of a comma separated list.
+func anonymizeAddrs(s string, cfg IPAnonymizeConfig) (string, bool) {
+	parts := strings.Split(s, ",")
+	changed := false
+	for i, p := range parts {
+		if a, ok := anonymizeAddr(strings.TrimSpace(p), cfg); ok {
+			if strings.HasPrefix(p, " ") {
+				a = " " + a
+			}
+			parts[i], changed = a, true
+		}
+	}
+	return strings.Join(parts, ","), changed
+}
+
+func anonymizeAddr(s string, cfg IPAnonymizeConfig) (string, bool) {
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		a := maskAddr(ap.Addr(), cfg)
+		return netip.AddrPortFrom(a, ap.Port()).String(), true
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", false
+	}
+	return maskAddr(a, cfg).String(), true
+}
+
+func maskAddr(a netip.Addr, cfg IPAnonymizeConfig) netip.Addr {
+	bits := cfg.IPv6Bits
+	if a.Is4() || a.Is4In6() {
+		a, bits = a.Unmap(), cfg.IPv4Bits
+	}
+	p, err := a.WithZone("").Prefix(bits)
+	if err != nil {
+		return a
+	}
+	return p.Addr()
+}
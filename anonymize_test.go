@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAnonymizeIP(t *testing.T) {
+	e := &Entry{Fields: []Field{
+		String("ip", "203.0.113.42"),
+		String("remote_addr", "[2001:db8:85a3:8d3:1319:8a2e:370:7348]:443"),
+		String("client_ip", "198.51.100.7, 10.1.2.3"),
+		Any("peer", net.ParseIP("192.0.2.99")),
+		Group("http", String("ip", "192.0.2.1")),
+		String("host", "web-1"),
+	}}
+	AnonymizeIP(IPAnonymizeConfig{Fields: []string{"ip", "remote_addr", "client_ip", "peer", "host"}}).Process(e)
+	m := e.FieldMap()
+	for k, want := range map[string]string{
+		"ip":          "203.0.113.0",
+		"remote_addr": "[2001:db8:85a3::]:443",
+		"client_ip":   "198.51.100.0, 10.1.2.0",
+		"peer":        "192.0.2.0",
+		"host":        "web-1",
+	} {
+		if m[k] != want {
+			t.Errorf("%s = %v, want %s", k, m[k], want)
+		}
+	}
+	if v, _ := lookupField(e.Fields, "http.ip"); v != "192.0.2.1" {
+		t.Errorf("http.ip = %v, want it untouched", v)
+	}
+
+	e = &Entry{Fields: []Field{Group("http", String("ip", "192.0.2.1"))}}
+	AnonymizeIP(IPAnonymizeConfig{Fields: []string{"http.ip"}, IPv4Bits: 16}).Process(e)
+	if v, _ := lookupField(e.Fields, "http.ip"); v != "192.0.0.0" {
+		t.Errorf("http.ip = %v", v)
+	}
+}
@@ -0,0 +1,169 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	CompressErrFmt = "Failed to compress log archive %s: %w"
+	UploadErrFmt   = "Failed to upload log archive %s: %w"
+)
+
+// archiver processes rotated files in the background so rotation never
+// waits for compression or network transfers. Its queue is unbounded:
+// rotations are rare, and writers must not block behind a slow upload.
+type archiver struct {
+	cfg     ArchiveConfig
+	handler ErrorHandler
+	mode    os.FileMode
+	mu      sync.Mutex
+	queue   []string
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// startArchiver starts an archiver creating files such as signatures with
+// mode.
+func startArchiver(cfg ArchiveConfig, mode os.FileMode, handler ErrorHandler) *archiver {
+	if cfg.UploadTimeout <= 0 {
+		cfg.UploadTimeout = DefaultUploadTimeout
+	}
+	a := &archiver{
+		cfg:     cfg,
+		handler: handler,
+		mode:    mode,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// add queues path for processing without blocking.
+func (a *archiver) add(path string) {
+	a.mu.Lock()
+	a.queue = append(a.queue, path)
+	a.mu.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (a *archiver) run() {
+	defer close(a.done)
+	for {
+		a.mu.Lock()
+		queue, closed := a.queue, a.closed
+		a.queue = nil
+		a.mu.Unlock()
+
+		for _, path := range queue {
+			a.process(path)
+		}
+		if closed && len(queue) == 0 {
+			return
+		}
+		if len(queue) == 0 {
+			<-a.wake
+		}
+	}
+}
+
+func (a *archiver) process(path string) {
+	if a.cfg.Compress {
+		gz, err := compressFile(path)
+		if err != nil {
+			a.handler(fmt.Errorf(CompressErrFmt, path, err))
+			return
+		}
+		path = gz
+	}
+
+	files := []string{path}
+	if a.cfg.SigningKey != nil {
+		sig, err := signArchive(path, a.cfg.SigningKey, a.mode)
+		if err != nil {
+			a.handler(fmt.Errorf(SignErrFmt, path, err))
+			return
+		}
+		files = append(files, sig)
+	}
+
+	if a.cfg.Uploader == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.UploadTimeout)
+	defer cancel()
+	for _, f := range files {
+		if err := a.cfg.Uploader.Upload(ctx, f); err != nil {
+			a.handler(fmt.Errorf(UploadErrFmt, f, err))
+			return
+		}
+	}
+	if a.cfg.DeleteAfterUpload {
+		for _, f := range files {
+			if err := os.Remove(f); err != nil {
+				a.handler(err)
+			}
+		}
+	}
+}
+
+// close waits for the queued archives to be processed.
+func (a *archiver) close() {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	<-a.done
+}
+
+// compressFile gzips path to path+".gz" and removes the original.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	gzPath := path + ".gz"
+	dst, err := os.OpenFile(gzPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = info.Name()
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(gzPath)
+		return "", err
+	}
+
+	src.Close()
+	return gzPath, os.Remove(path)
+}
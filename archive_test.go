@@ -0,0 +1,130 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordUploader records the uploaded paths with their contents, failing
+// the uploads of files holding fail.
+type recordUploader struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	fail  string
+}
+
+var errUpload = errors.New("bucket unavailable")
+
+func (u *recordUploader) Upload(_ context.Context, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.files[filepath.Base(path)] = b
+	if u.fail != "" && string(b) == u.fail {
+		return errUpload
+	}
+	return nil
+}
+
+func TestArchiveCompressUpload(t *testing.T) {
+	dir := t.TempDir()
+	up := &recordUploader{files: make(map[string][]byte)}
+	f, err := OpenFile(filepath.Join(dir, "app.log"), FileConfig{Archive: ArchiveConfig{Compress: true, Uploader: up}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("one\n"))
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	archives, _ := filepath.Glob(filepath.Join(dir, "app-*.log*"))
+	if len(archives) != 1 || !strings.HasSuffix(archives[0], ".log.gz") {
+		t.Fatalf("archives %v, want one compressed", archives)
+	}
+	gz, err := os.Open(archives[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "one\n" {
+		t.Errorf("archive holds %q", b)
+	}
+	if len(up.files) != 1 || up.files[filepath.Base(archives[0])] == nil {
+		t.Errorf("uploaded %v", up.files)
+	}
+}
+
+func TestArchiveDeleteAfterUpload(t *testing.T) {
+	dir := t.TempDir()
+	var errs []error
+	up := &recordUploader{files: make(map[string][]byte), fail: "two\n"}
+	cfg := FileConfig{
+		Archive:      ArchiveConfig{Uploader: up, DeleteAfterUpload: true},
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	}
+	f, err := OpenFile(filepath.Join(dir, "app.log"), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("one\n"))
+	f.Rotate()
+	// A failed upload keeps the archive.
+	f.Write([]byte("two\n"))
+	f.Rotate()
+	f.Close()
+
+	archives, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(archives) != 1 {
+		t.Fatalf("kept %v, want the archive that failed to upload", archives)
+	}
+	if b, _ := os.ReadFile(archives[0]); string(b) != "two\n" {
+		t.Errorf("kept archive holds %q", b)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errUpload) {
+		t.Errorf("errors %v", errs)
+	}
+}
+
+func TestArchiveSign(t *testing.T) {
+	dir := t.TempDir()
+	pub, key, _ := ed25519.GenerateKey(nil)
+	up := &recordUploader{files: make(map[string][]byte)}
+	f, err := OpenFile(filepath.Join(dir, "app.log"), FileConfig{Archive: ArchiveConfig{Compress: true, SigningKey: key, Uploader: up}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("one\n"))
+	f.Rotate()
+	f.Close()
+
+	// The compressed archive is signed and both are uploaded.
+	archives, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("archives %v", archives)
+	}
+	if err := VerifyArchive(archives[0], "", pub); err != nil {
+		t.Error(err)
+	}
+	if base := filepath.Base(archives[0]); len(up.files) != 2 || up.files[base+SignatureExt] == nil {
+		t.Errorf("uploaded %d files", len(up.files))
+	}
+}
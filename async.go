@@ -0,0 +1,358 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultQueueSize = 1024
+
+// DropPolicy decides what an AsyncSink does when its queue is full.
+type DropPolicy int
+
+const (
+	// BlockWhenFull makes the caller wait for room in the queue.
+	BlockWhenFull DropPolicy = iota
+	// DropNewest discards the entry being logged.
+	DropNewest
+	// DropOldest discards the oldest queued entry to make room.
+	DropOldest
+	// DropBelowLevel discards entries below AsyncConfig.DropLevel and makes
+	// room for the others by discarding the oldest queued entry.
+	DropBelowLevel
+	// DropLowestLevel makes room by discarding the oldest of the queued
+	// entries with the lowest level, or the entry being logged if its level
+	// is lower than all of them, so debug output goes before errors. The
+	// queue takes a lock instead of being lock-free.
+	DropLowestLevel
+)
+
+// AsyncConfig configures an AsyncSink.
+type AsyncConfig struct {
+	// QueueSize is the number of entries buffered, rounded up to a power of
+	// two; zero selects DefaultQueueSize.
+	QueueSize int
+	// MaxBytes, if positive, also caps the estimated size of the buffered
+	// entries, so large entries cannot grow the queue to many times the
+	// memory QueueSize suggests during an outage. The queue is full when
+	// either limit is reached, though a lone entry is always taken. Around
+	// a BatchSink, which blocks when its own batches back up, it keeps
+	// logging from stalling with the memory used bounded.
+	MaxBytes int64
+	// Policy is applied when the queue is full.
+	Policy DropPolicy
+	// DropLevel is the level below which DropBelowLevel discards entries.
+	DropLevel LogLevel
+	// ErrorHandler receives write errors of the wrapped sink; nil selects
+	// DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+// AsyncSink hands entries to a background goroutine that writes them to the
+// wrapped sink, so a slow destination does not stall the caller. Entries
+// pass through a lock-free ring, except with DropLowestLevel, keeping the
+// cost per call low under heavy parallel logging. Unless the policy is BlockWhenFull, logging never blocks
+// and entries that do not fit are counted as dropped.
+type AsyncSink struct {
+	sink    Sink
+	cfg     AsyncConfig
+	queue   entryQueue
+	bytes   atomic.Int64
+	waiting atomic.Bool
+	closed  atomic.Bool
+	// inflight counts WriteEntry calls in progress; the writer goroutine
+	// only finishes after Close once it is zero, so no push is lost.
+	inflight atomic.Int64
+	wake     chan struct{}
+	done     chan struct{}
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	health   healthTracker
+	// queued counts successful pushes and settled counts entries that were
+	// written or evicted, so Flush can wait for everything queued before it.
+	queued  atomic.Uint64
+	settled atomic.Uint64
+}
+
+// entryQueue is the queue of an AsyncSink: a ring, or a levelQueue for
+// DropLowestLevel.
+type entryQueue interface {
+	push(e *Entry) bool
+	pop() (*Entry, bool)
+	len() int
+}
+
+// NewAsyncSink starts an AsyncSink writing to sink.
+func NewAsyncSink(sink Sink, cfg AsyncConfig) *AsyncSink {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+
+	s := &AsyncSink{
+		sink: sink,
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if cfg.Policy == DropLowestLevel {
+		s.queue = newLevelQueue(cfg.QueueSize)
+	} else {
+		s.queue = newRing(cfg.QueueSize)
+	}
+	go s.run()
+	return s
+}
+
+// WriteEntry implements Sink. After Close it returns ErrSinkClosed.
+func (s *AsyncSink) WriteEntry(e *Entry) error {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	if s.closed.Load() {
+		return ErrSinkClosed
+	}
+	e = e.Clone()
+	switch s.cfg.Policy {
+	case BlockWhenFull:
+		s.block(e)
+	case DropNewest:
+		s.offer(e)
+	case DropOldest:
+		s.evict(e)
+	case DropBelowLevel:
+		if e.Level < s.cfg.DropLevel {
+			s.offer(e)
+		} else {
+			s.evict(e)
+		}
+	case DropLowestLevel:
+		s.evictLowest(e)
+	}
+	s.signal()
+	return nil
+}
+
+// WriteBlock implements BlockWriter. The block is queued as one item and
+// written as a block by the wrapped sink, if it supports that.
+func (s *AsyncSink) WriteBlock(entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	carrier := entries[len(entries)-1].Clone()
+	carrier.block = make([]*Entry, len(entries))
+	for i, e := range entries {
+		carrier.block[i] = e.Clone()
+	}
+	return s.WriteEntry(carrier)
+}
+
+// offer queues e if there is room and drops it otherwise.
+func (s *AsyncSink) offer(e *Entry) {
+	if s.push(e) {
+		return
+	}
+	s.dropped.Add(1)
+}
+
+// push adds e to the queue if it fits, counting it for Flush.
+func (s *AsyncSink) push(e *Entry) bool {
+	size := int64(queuedSize(e))
+	if s.cfg.MaxBytes > 0 {
+		if n := s.bytes.Load(); n > 0 && n+size > s.cfg.MaxBytes {
+			return false
+		}
+	}
+	if !s.queue.push(e) {
+		return false
+	}
+	s.bytes.Add(size)
+	s.queued.Add(1)
+	return true
+}
+
+// pop takes the oldest entry off the queue.
+func (s *AsyncSink) pop() (*Entry, bool) {
+	e, ok := s.queue.pop()
+	if ok {
+		s.bytes.Add(-int64(queuedSize(e)))
+	}
+	return e, ok
+}
+
+// discard counts an entry evicted from the queue.
+func (s *AsyncSink) discard(e *Entry) {
+	s.bytes.Add(-int64(queuedSize(e)))
+	s.dropped.Add(1)
+	s.settled.Add(1)
+}
+
+// queuedSize estimates the memory an entry takes in the queue.
+func queuedSize(e *Entry) int {
+	n := entrySize(e)
+	for _, b := range e.block {
+		n += entrySize(b)
+	}
+	return n
+}
+
+// evict queues e, discarding the oldest queued entries until it fits.
+func (s *AsyncSink) evict(e *Entry) {
+	for !s.push(e) {
+		if victim, ok := s.queue.pop(); ok {
+			s.discard(victim)
+		}
+	}
+}
+
+// evictLowest queues e, discarding lower-level entries until it fits, or
+// drops e if none is left.
+func (s *AsyncSink) evictLowest(e *Entry) {
+	q := s.queue.(*levelQueue)
+	for !s.push(e) {
+		victim, ok := q.evictLowest(e.Level)
+		if !ok {
+			s.dropped.Add(1)
+			return
+		}
+		s.discard(victim)
+	}
+}
+
+// block queues e, waiting for the writer to make room.
+func (s *AsyncSink) block(e *Entry) {
+	for spins := 0; !s.push(e); spins++ {
+		s.signal()
+		if spins < 64 {
+			runtime.Gosched()
+		} else {
+			time.Sleep(50 * time.Microsecond)
+		}
+	}
+}
+
+// signal wakes the writer goroutine if it is waiting for entries.
+func (s *AsyncSink) signal() {
+	if s.waiting.Load() {
+		s.nudge()
+	}
+}
+
+// nudge wakes the writer goroutine unconditionally.
+func (s *AsyncSink) nudge() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+	for {
+		if e, ok := s.pop(); ok {
+			s.write(e)
+			continue
+		}
+		if s.closed.Load() {
+			// Writers that got past the closed check may still push.
+			if s.inflight.Load() > 0 {
+				runtime.Gosched()
+				continue
+			}
+			for e, ok := s.pop(); ok; e, ok = s.pop() {
+				s.write(e)
+			}
+			return
+		}
+
+		s.waiting.Store(true)
+		if e, ok := s.pop(); ok {
+			s.waiting.Store(false)
+			s.write(e)
+			continue
+		}
+		<-s.wake
+		s.waiting.Store(false)
+	}
+}
+
+func (s *AsyncSink) write(e *Entry) {
+	var err error
+	if e.block != nil {
+		err = writeBlock(s.sink, e.block)
+	} else {
+		err = s.sink.WriteEntry(e)
+	}
+	s.health.record(time.Now(), err)
+	if err != nil {
+		s.failed.Add(1)
+		s.cfg.ErrorHandler(err)
+	}
+	s.settled.Add(1)
+}
+
+// Flush waits until every entry queued before the call has been written and
+// then flushes the wrapped sink.
+func (s *AsyncSink) Flush() error {
+	target := s.queued.Load()
+	for s.settled.Load() < target {
+		select {
+		case <-s.done:
+			return flushSink(s.sink)
+		default:
+		}
+		s.nudge()
+		time.Sleep(100 * time.Microsecond)
+	}
+	return flushSink(s.sink)
+}
+
+// Len returns the number of entries waiting to be written.
+func (s *AsyncSink) Len() int {
+	return s.queue.len()
+}
+
+// Health implements HealthReporter. If the wrapped sink reports its own
+// health, that is returned with this sink's queue added to its depth.
+func (s *AsyncSink) Health() Health {
+	var h Health
+	if r, ok := s.sink.(HealthReporter); ok {
+		h = r.Health()
+	} else {
+		h = s.health.health(s.sink)
+	}
+	h.QueueDepth += s.Len()
+	return h
+}
+
+// Close stops accepting entries, waits until the queued ones, including
+// those of writes in progress, are written and then closes the wrapped
+// sink, or flushes it if it cannot be closed. If entries were dropped or
+// failed to be written, the error reports how many, see UndeliveredErrFmt.
+func (s *AsyncSink) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		<-s.done
+		return nil
+	}
+	s.nudge()
+	<-s.done
+	var err error
+	if c, ok := s.sink.(io.Closer); ok {
+		err = c.Close()
+	} else {
+		err = flushSink(s.sink)
+	}
+	return errors.Join(err, undelivered(s.Stats(), s.health.lastError()))
+}
+
+// Stats implements StatsReporter.
+func (s *AsyncSink) Stats() Stats {
+	return Stats{
+		Dropped: s.dropped.Load(),
+		Failed:  s.failed.Load(),
+	}
+}
@@ -0,0 +1,209 @@
+package logger
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countSink counts the entries written to it and, with keep, retains
+// copies of them, since the logger reuses its entries.
+type countSink struct {
+	mu      sync.Mutex
+	n       atomic.Int64
+	entries []*Entry
+	keep    bool
+}
+
+func (s *countSink) WriteEntry(e *Entry) error {
+	s.n.Add(1)
+	if s.keep {
+		s.mu.Lock()
+		s.entries = append(s.entries, e.Clone())
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func TestAsyncSinkPolicies(t *testing.T) {
+	tests := []struct {
+		policy      DropPolicy
+		wantWritten int64
+	}{
+		{BlockWhenFull, 100},
+		{DropNewest, 100},
+		{DropOldest, 100},
+	}
+	for _, tt := range tests {
+		dst := &countSink{keep: true}
+		s := NewAsyncSink(dst, AsyncConfig{QueueSize: 256, Policy: tt.policy})
+		e := &Entry{Message: "m", Fields: []Field{Int("n", 0)}}
+		for i := 0; i < 100; i++ {
+			e.Fields[0] = Int("n", i)
+			if err := s.WriteEntry(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.n.Load(); got != tt.wantWritten {
+			t.Errorf("policy %d: wrote %d, want %d", tt.policy, got, tt.wantWritten)
+		}
+		// The sink must have cloned the entry, not kept the caller's.
+		for i, got := range dst.entries {
+			if got == e || got.Fields[0].Integer != int64(i) {
+				t.Fatalf("policy %d: entry %d not cloned in order", tt.policy, i)
+			}
+		}
+		s.Close()
+		if err := s.WriteEntry(e); err != ErrSinkClosed {
+			t.Errorf("write after Close = %v, want ErrSinkClosed", err)
+		}
+	}
+}
+
+// TestAsyncSinkClose checks that Close delivers the final batch of a
+// wrapped BatchSink and reports entries dropped on the way.
+func TestAsyncSinkClose(t *testing.T) {
+	sender := new(recordSender)
+	s := NewAsyncSink(NewBatchSink(sender, BatchConfig{FlushInterval: time.Hour}), AsyncConfig{})
+	for i := 0; i < 3; i++ {
+		s.WriteEntry(&Entry{Message: "m"})
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sender.total.Load(); got != 3 {
+		t.Errorf("delivered %d entries after Close, want 3", got)
+	}
+
+	s = NewAsyncSink(&countSink{}, AsyncConfig{QueueSize: 1, Policy: DropNewest})
+	s.dropped.Add(2)
+	if err := s.Close(); !errors.Is(err, ErrUndelivered) || !strings.Contains(err.Error(), "2 entries dropped") {
+		t.Errorf("Close = %v, want the dropped entries", err)
+	}
+}
+
+// TestAsyncSinkCloseRace checks that every entry accepted while Close runs
+// concurrently is written.
+func TestAsyncSinkCloseRace(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		dst := new(countSink)
+		s := NewAsyncSink(dst, AsyncConfig{QueueSize: 16, Policy: BlockWhenFull})
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				e := &Entry{Message: "m"}
+				for i := 0; i < 200; i++ {
+					if s.WriteEntry(e) == nil {
+						accepted.Add(1)
+					}
+				}
+			}()
+		}
+		s.Close()
+		wg.Wait()
+		if got, want := dst.n.Load(), accepted.Load(); got != want {
+			t.Fatalf("round %d: wrote %d of %d accepted entries", round, got, want)
+		}
+	}
+}
+
+func BenchmarkAsyncSink(b *testing.B) {
+	s := NewAsyncSink(new(countSink), AsyncConfig{QueueSize: 4096, Policy: DropNewest})
+	defer s.Close()
+	e := &Entry{Message: "hello", Fields: []Field{Int("n", 1)}}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.WriteEntry(e)
+		}
+	})
+}
+
+// gateSink blocks writes until open is closed.
+type gateSink struct {
+	open chan struct{}
+	countSink
+}
+
+func (s *gateSink) WriteEntry(e *Entry) error {
+	<-s.open
+	return s.countSink.WriteEntry(e)
+}
+
+func TestAsyncSinkMaxBytes(t *testing.T) {
+	dst := &gateSink{open: make(chan struct{})}
+	dst.keep = true
+	s := NewAsyncSink(dst, AsyncConfig{MaxBytes: 1000, Policy: DropOldest})
+	big := &Entry{Message: strings.Repeat("x", 200)}
+	for i := 0; i < 50; i++ {
+		s.WriteEntry(big)
+		if n := s.bytes.Load(); n > 1000 {
+			t.Fatalf("queue holds %d bytes, cap 1000", n)
+		}
+	}
+	if s.Stats().Dropped == 0 {
+		t.Error("nothing dropped over the byte cap")
+	}
+	close(dst.open)
+	s.Close()
+	if got := dst.n.Load() + int64(s.Stats().Dropped); got != 50 {
+		t.Errorf("written and dropped %d of 50", got)
+	}
+	if s.bytes.Load() != 0 {
+		t.Errorf("%d bytes left after Close", s.bytes.Load())
+	}
+
+	// A lone entry over the cap is still taken.
+	dst = &gateSink{open: make(chan struct{})}
+	close(dst.open)
+	s = NewAsyncSink(dst, AsyncConfig{MaxBytes: 10, Policy: BlockWhenFull})
+	s.WriteEntry(big)
+	s.Close()
+	if dst.n.Load() != 1 {
+		t.Errorf("oversized entry: wrote %d", dst.n.Load())
+	}
+}
+
+func TestAsyncSinkDropLowestLevel(t *testing.T) {
+	dst := &gateSink{open: make(chan struct{})}
+	dst.keep = true
+	s := NewAsyncSink(dst, AsyncConfig{QueueSize: 4, Policy: DropLowestLevel})
+	// The writer takes the first entry and waits on the gate with it.
+	s.WriteEntry(&Entry{Level: Error, Message: "held"})
+	for s.Len() > 0 {
+		runtime.Gosched()
+	}
+	for _, e := range []*Entry{
+		{Level: Info, Message: "info1"},
+		{Level: Debug, Message: "debug"},
+		{Level: Error, Message: "error1"},
+		{Level: Info, Message: "info2"},
+		{Level: Warn, Message: "warn"},    // evicts debug
+		{Level: Error, Message: "error2"}, // evicts info1
+		{Level: Debug, Message: "late"},   // lower than all queued
+	} {
+		s.WriteEntry(e)
+	}
+	close(dst.open)
+	s.Close()
+	var got []string
+	for _, e := range dst.entries {
+		got = append(got, e.Message)
+	}
+	if want := "held error1 info2 warn error2"; strings.Join(got, " ") != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	if s.Stats().Dropped != 3 {
+		t.Errorf("Dropped = %d, want 3", s.Stats().Dropped)
+	}
+}
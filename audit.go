@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// AuditLevel is the level of audit records. Level settings never filter
+// them; it only tells them apart from diagnostic entries where an audit
+// output is shared.
+const AuditLevel = Notice
+
+// AuditConfig configures the audit channel, see WithAudit.
+type AuditConfig struct {
+	// Path is the audit log file, opened with File; empty for none.
+	Path string
+	// File configures the audit log file. Its Sync policy decides how
+	// durable a returned Audit call is.
+	File FileConfig
+	// Encoder encodes records to Path; JSONEncoder if nil.
+	Encoder Encoder
+	// Sinks receive every record in addition to Path. They are written
+	// synchronously, never through WithAsync.
+	Sinks []Sink
+}
+
+// channel is an output separate from the log sinks, the audit or event
+// output, shared by a logger and its children.
+type channel struct {
+	sinks []Sink
+	file  *File
+}
+
+// WithAudit sets the output of Audit, separate from the log output.
+func WithAudit(cfg AuditConfig) Option {
+	return func(l *CustomLogger) {
+		l.auditCfg = &cfg
+	}
+}
+
+// openChannel opens a channel writing to sinks and, if path is set, to a
+// file encoded with enc, structuredEncoder if nil.
+func (l *CustomLogger) openChannel(path string, cfg FileConfig, enc Encoder, sinks []Sink) (*channel, error) {
+	c := &channel{sinks: append([]Sink(nil), sinks...)}
+	if path != "" {
+		if cfg.ErrorHandler == nil {
+			cfg.ErrorHandler = l.errorHandler
+		}
+		f, err := OpenFile(path, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if enc == nil {
+			enc = structuredEncoder()
+		}
+		c.file = f
+		c.sinks = append([]Sink{NewWriterSink(f, enc)}, c.sinks...)
+	}
+	return c, nil
+}
+
+// Audit records event with fields at AuditLevel, bypassing level
+// filtering, sampling and asynchronous queues: it returns once every audit
+// sink has the record, with the errors of those that failed. The logger's
+// fields and processors apply as for any entry. Without WithAudit the
+// record goes to the log sinks instead, so it is still never dropped by
+// verbosity settings. After Close it returns ErrSinkClosed.
+func (l *CustomLogger) Audit(event string, fields ...Field) error {
+	if l.closed.Load() {
+		l.counters.closed.Add(1)
+		return ErrSinkClosed
+	}
+	e := l.entry(AuditLevel, event, fields)
+	defer putEntry(e)
+	if l.audit == nil {
+		return l.writeAll(e)
+	}
+	return l.audit.write(e, &l.counters.failed)
+}
+
+// writeAll writes e to the log sinks like write, but returns the errors
+// instead of passing them to the error handler.
+func (l *CustomLogger) writeAll(e *Entry) error {
+	l.counters.countLevel(e.Level)
+	var errs []error
+	for i, s := range l.sinks {
+		err := s.WriteEntry(e)
+		l.health[i].record(e.Time, err)
+		if err != nil {
+			l.counters.failed.Add(1)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// write writes e to every sink of the channel, counting the failures.
+func (c *channel) write(e *Entry, failed *atomic.Uint64) error {
+	var errs []error
+	for _, s := range c.sinks {
+		if err := s.WriteEntry(e); err != nil {
+			failed.Add(1)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close closes the sinks and file of the channel.
+func (c *channel) close() error {
+	var errs []error
+	for _, s := range c.sinks {
+		if cl, ok := s.(io.Closer); ok {
+			errs = append(errs, cl.Close())
+		} else {
+			errs = append(errs, flushSink(s))
+		}
+	}
+	if c.file != nil {
+		errs = append(errs, c.file.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// flush flushes the sinks and syncs the file of the channel.
+func (c *channel) flush() error {
+	var errs []error
+	for _, s := range c.sinks {
+		errs = append(errs, flushSink(s))
+	}
+	if c.file != nil {
+		errs = append(errs, c.file.Sync())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	var logs bytes.Buffer
+	l := newTestLogger(t, Emergency, &logs,
+		WithAdaptiveSampling(SamplerConfig{Target: 1}),
+		WithAudit(AuditConfig{Path: path}))
+	l.SetQuiet(Silent)
+	for i := 0; i < 3; i++ {
+		if err := l.With(String("user", "ann")).Audit("login", Int("n", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("audit records reached the log output: %q", logs.String())
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d records: %q", len(lines), b)
+	}
+	var rec jsonEntry
+	if err := json.Unmarshal([]byte(lines[2]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Message != "login" || rec.Level != AuditLevel.String() || rec.Fields["user"] != "ann" || rec.Fields["n"] != 2.0 {
+		t.Errorf("record = %+v", rec)
+	}
+
+	if err := l.Audit("late"); err != ErrSinkClosed {
+		t.Errorf("Audit after Close = %v, want ErrSinkClosed", err)
+	}
+}
+
+func TestAuditFallback(t *testing.T) {
+	var logs bytes.Buffer
+	l := newTestLogger(t, Emergency, &logs, WithSinks(failSink{}))
+	err := l.Audit("export", String("table", "users"))
+	if err == nil || !strings.Contains(err.Error(), "sink down") {
+		t.Errorf("Audit error = %v, want the failing sink's", err)
+	}
+	if !strings.Contains(logs.String(), "export table=users") {
+		t.Errorf("log output = %q", logs.String())
+	}
+}
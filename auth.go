@@ -0,0 +1,90 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before expiry a cached token is renewed.
+const tokenRefreshMargin = time.Minute
+
+// StaticToken returns a TokenFunc that always yields token.
+func StaticToken(token string) TokenFunc {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
+// CachedToken returns a TokenFunc that calls fetch on first use and again
+// shortly before the returned expiry; a zero expiry never expires. Fetch
+// errors are returned and retried on the next call.
+func CachedToken(fetch func(ctx context.Context) (token string, expires time.Time, err error)) TokenFunc {
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if token != "" && (expires.IsZero() || time.Until(expires) > tokenRefreshMargin) {
+			return token, nil
+		}
+		t, exp, err := fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		token, expires = t, exp
+		return token, nil
+	}
+}
+
+// authTransport adds a token header to every request.
+type authTransport struct {
+	base   http.RoundTripper
+	header string
+	prefix string
+	token  TokenFunc
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, t.prefix+token)
+	return t.base.RoundTrip(req)
+}
+
+// WithBearerToken returns a copy of client (nil for http.DefaultClient)
+// that sends "Authorization: Bearer <token>" on every request, asking
+// token for the current value each time so it can be refreshed.
+func WithBearerToken(client *http.Client, token TokenFunc) *http.Client {
+	return withAuth(client, "Authorization", "Bearer ", token)
+}
+
+// WithTokenHeader is like WithBearerToken but sends the raw token in the
+// named header, e.g. "X-API-Key".
+func WithTokenHeader(client *http.Client, header string, token TokenFunc) *http.Client {
+	return withAuth(client, header, "", token)
+}
+
+func withAuth(client *http.Client, header, prefix string, token TokenFunc) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *client
+	c.Transport = &authTransport{base: base, header: header, prefix: prefix, token: token}
+	return &c
+}
@@ -0,0 +1,86 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachedToken(t *testing.T) {
+	var calls int
+	var fail error
+	newToken := func(expires time.Time) TokenFunc {
+		calls = 0
+		return CachedToken(func(context.Context) (string, time.Time, error) {
+			calls++
+			return fmt.Sprint("t", calls), expires, fail
+		})
+	}
+	token := newToken(time.Now().Add(time.Hour))
+	for i := 0; i < 2; i++ {
+		if tok, err := token(context.Background()); tok != "t1" || err != nil {
+			t.Fatalf("token %q, %v", tok, err)
+		}
+	}
+
+	// A token about to expire is fetched again.
+	token = newToken(time.Now().Add(tokenRefreshMargin / 2))
+	token(context.Background())
+	if tok, _ := token(context.Background()); tok != "t2" {
+		t.Errorf("token %q", tok)
+	}
+
+	// Errors are returned and the next call tries again.
+	fail = errors.New("unavailable")
+	if _, err := token(context.Background()); err != fail {
+		t.Errorf("error %v", err)
+	}
+	fail = nil
+	if tok, _ := token(context.Background()); tok != "t4" {
+		t.Errorf("token %q after an error", tok)
+	}
+}
+
+func TestWithBearerToken(t *testing.T) {
+	var auth, apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, apiKey = r.Header.Get("Authorization"), r.Header.Get("X-API-Key")
+	}))
+	defer srv.Close()
+
+	base := srv.Client()
+	client := WithBearerToken(base, StaticToken("tok"))
+	if client == base || base.Transport == client.Transport {
+		t.Error("client modified in place")
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if auth != "Bearer tok" {
+		t.Errorf("authorization %q", auth)
+	}
+
+	resp, err = WithTokenHeader(nil, "X-API-Key", StaticToken("key")).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if apiKey != "key" || auth != "" {
+		t.Errorf("X-API-Key %q, authorization %q", apiKey, auth)
+	}
+
+	// A token error fails the request without sending it.
+	fail := errors.New("no token")
+	_, err = WithBearerToken(nil, func(context.Context) (string, error) { return "", fail }).Get(srv.URL)
+	if !errors.Is(err, fail) {
+		t.Errorf("error %v", err)
+	}
+}
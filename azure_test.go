@@ -0,0 +1,103 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestAzureDataCollector(t *testing.T) {
+	key := []byte("shared key")
+	var records []azureRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sign := "POST\n" + strconv.Itoa(len(body)) + "\napplication/json\nx-ms-date:" + r.Header.Get("x-ms-date") + "\n/api/logs"
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(sign))
+		if auth := r.Header.Get("Authorization"); auth != "SharedKey ws:"+base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("authorization %q", auth)
+		}
+		if r.Host != "ws.ods.opinsights.azure.com" || r.Header.Get("Log-Type") != "App" || r.Header.Get("time-generated-field") != "TimeGenerated" {
+			t.Errorf("host %q, headers %v", r.Host, r.Header)
+		}
+		json.Unmarshal(body, &records)
+	}))
+	defer srv.Close()
+
+	if _, err := NewAzureSender(AzureConfig{SharedKey: "not base64!"}); err == nil {
+		t.Error("accepted an invalid shared key")
+	}
+	s, err := NewAzureSender(AzureConfig{
+		WorkspaceID: "ws", SharedKey: base64.StdEncoding.EncodeToString(key), LogType: "App", Client: redirectClient(srv),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{Time: testTime, Level: Warn, Name: "api", Message: "slow", Fields: []Field{Int("ms", 900)}}
+	if err := s.SendBatch(context.Background(), []*Entry{e}); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records %+v", records)
+	}
+	if r := records[0]; r.TimeGenerated != "2024-03-01T12:30:45Z" || r.Level != "WARN" || r.Logger != "api" || r.Message != "slow" || r.Fields["ms"] != float64(900) {
+		t.Errorf("record %+v", r)
+	}
+}
+
+func TestAzureLogsIngestion(t *testing.T) {
+	var tokens int
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "login.microsoftonline.com" {
+			tokens++
+			r.ParseForm()
+			if r.URL.Path != "/tenant/oauth2/v2.0/token" || r.Form.Get("client_id") != "id" || r.Form.Get("client_secret") != "secret" || r.Form.Get("scope") != azureMonitorScope {
+				t.Errorf("token request %s %v", r.URL.Path, r.Form)
+			}
+			w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+			return
+		}
+		path, auth = r.URL.RequestURI(), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := redirectClient(srv)
+	s, err := NewAzureSender(AzureConfig{
+		Endpoint: "https://dce.ingest.monitor.azure.com/", RuleID: "dcr-1", Stream: "Custom-App",
+		Token: AzureClientCredentials("tenant", "id", "secret", client), Client: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := []*Entry{{Time: testTime, Message: "m"}}
+	for i := 0; i < 2; i++ {
+		if err := s.SendBatch(context.Background(), batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if path != "/dataCollectionRules/dcr-1/streams/Custom-App?api-version=2023-01-01" || auth != "Bearer tok" || tokens != 1 {
+		t.Errorf("path %q, auth %q, %d token requests", path, auth, tokens)
+	}
+}
+
+func TestAzureTokenStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	token := AzureClientCredentials("tenant", "id", "wrong", redirectClient(srv))
+	if _, err := token(context.Background()); err == nil {
+		t.Error("401 accepted")
+	}
+}
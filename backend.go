@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrWriterWithPath is returned by New when WithWriter is combined with a
+// file path.
+var ErrWriterWithPath = errors.New("WithWriter cannot be combined with a file path")
+
+// Rotator is implemented by writers that can start a new file on demand,
+// such as File and lumberjack.Logger.
+type Rotator interface {
+	Rotate() error
+}
+
+// Syncer is implemented by writers that can commit written data to stable
+// storage, such as *os.File.
+type Syncer interface {
+	Sync() error
+}
+
+// WithWriter writes the default output to w instead of a file opened by
+// the logger, e.g. a lumberjack.Logger for teams with rotation of their
+// own. The path passed to New must be empty, and the file options, such
+// as WithRotation and WithSyncPolicy, do not apply. Close closes w, Flush
+// syncs it if it is a Syncer or flushes it if it is a Flusher, and Rotate
+// rotates it if it is a Rotator.
+func WithWriter(w io.WriteCloser) Option {
+	return func(l *CustomLogger) {
+		l.backend = w
+	}
+}
+
+// Rotate writes out the output buffer and starts a new log file, through
+// File.Rotate or the Rotator given to WithWriter, e.g. from a SIGHUP
+// handler. It does nothing for outputs that do not rotate.
+func (l *CustomLogger) Rotate() error {
+	if l.buffered != nil {
+		if err := l.buffered.Flush(); err != nil {
+			return err
+		}
+	}
+	if l.file != nil {
+		return l.file.Rotate()
+	}
+	if r, ok := l.backend.(Rotator); ok {
+		return r.Rotate()
+	}
+	return nil
+}
+
+// syncBackend commits what was written to the WithWriter backend.
+func (l *CustomLogger) syncBackend() error {
+	switch w := l.backend.(type) {
+	case Syncer:
+		return w.Sync()
+	case Flusher:
+		return w.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rotatingWriter stands in for lumberjack.Logger, starting a new buffer on
+// every rotation.
+type rotatingWriter struct {
+	files  []*bytes.Buffer
+	synced int
+	closed bool
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if len(w.files) == 0 {
+		w.files = append(w.files, new(bytes.Buffer))
+	}
+	return w.files[len(w.files)-1].Write(p)
+}
+
+func (w *rotatingWriter) Rotate() error {
+	w.files = append(w.files, new(bytes.Buffer))
+	return nil
+}
+
+func (w *rotatingWriter) Sync() error {
+	w.synced++
+	return nil
+}
+
+func (w *rotatingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestWithWriter(t *testing.T) {
+	w := &rotatingWriter{}
+	l, err := New(Info, "app", "", WithWriter(w), WithBufferedOutput(BufferConfig{Size: 1 << 10}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("before")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	if err := l.Flush(); err != nil || w.synced != 1 {
+		t.Errorf("Flush = %v, %d syncs", err, w.synced)
+	}
+	if err := l.Close(context.Background()); err != nil || !w.closed {
+		t.Errorf("Close = %v, closed %v", err, w.closed)
+	}
+	if len(w.files) != 2 || !strings.Contains(w.files[0].String(), "before") || !strings.Contains(w.files[1].String(), "after") {
+		t.Errorf("files = %q", w.files)
+	}
+
+	if _, err := New(Info, "app", filepath.Join(t.TempDir(), "app.log"), WithWriter(w)); err != ErrWriterWithPath {
+		t.Errorf("path with WithWriter: %v", err)
+	}
+}
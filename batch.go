@@ -0,0 +1,378 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultBufferSize    = 64 * 1024
+	DefaultFlushInterval = time.Second
+	DefaultBatchEntries  = 500
+	DefaultBatchBytes    = 1024 * 1024
+)
+
+// BufferConfig configures a BufferedWriter.
+type BufferConfig struct {
+	// Size is the number of bytes buffered before a write is issued; zero
+	// selects DefaultBufferSize.
+	Size int
+	// FlushInterval is the longest time data stays in the buffer; zero
+	// selects DefaultFlushInterval.
+	FlushInterval time.Duration
+}
+
+// BufferedWriter coalesces small writes into larger ones, writing to the
+// underlying writer when the buffer is full or the flush interval elapses.
+// Unlike bufio.Writer it is safe for concurrent use and flushes on its own.
+type BufferedWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	buf    []byte
+	size   int
+	stop   chan struct{}
+	done   chan struct{}
+	closed bool
+}
+
+// NewBufferedWriter creates a BufferedWriter around w.
+func NewBufferedWriter(w io.Writer, cfg BufferConfig) *BufferedWriter {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+
+	b := &BufferedWriter{
+		w:    w,
+		buf:  make([]byte, 0, cfg.Size),
+		size: cfg.Size,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.run(cfg.FlushInterval)
+	return b
+}
+
+// Write implements io.Writer. Data larger than the buffer is written through.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return b.w.Write(p)
+	}
+	if len(b.buf)+len(p) > b.size {
+		if err := b.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) >= b.size {
+		return b.w.Write(p)
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (b *BufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+func (b *BufferedWriter) flushLocked() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+func (b *BufferedWriter) run(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.Flush()
+		}
+	}
+}
+
+// Close flushes the buffer and stops the background flusher. Later writes go
+// straight to the underlying writer, which is not closed.
+func (b *BufferedWriter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	err := b.flushLocked()
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+	return err
+}
+
+// BatchConfig configures a BatchSink.
+type BatchConfig struct {
+	// MaxEntries triggers a flush once this many entries are pending; zero
+	// selects DefaultBatchEntries.
+	MaxEntries int
+	// MaxBytes triggers a flush once the pending entries reach roughly this
+	// size; zero selects DefaultBatchBytes.
+	MaxBytes int
+	// FlushInterval is the longest time an entry stays pending; zero selects
+	// DefaultFlushInterval.
+	FlushInterval time.Duration
+	// SendTimeout bounds the delivery of one batch; zero selects
+	// DefaultSendTimeout.
+	SendTimeout time.Duration
+	// ErrorHandler receives delivery errors; nil selects DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+	// AtLeastOnce keeps a batch whose delivery failed and sends it again
+	// until the sender acknowledges it, instead of dropping it, so no entry
+	// is discarded unconfirmed while the process runs. Later batches wait
+	// behind it and writers block once four are waiting; a DiskQueue
+	// rides out longer outages. Batches rejected as permanent are still
+	// dropped, and so are those failing when Close gives up.
+	AtLeastOnce bool
+	// Retry sets the backoff between the attempts of AtLeastOnce;
+	// MaxAttempts is ignored. The zero value selects DefaultRetryPolicy.
+	Retry RetryPolicy
+}
+
+// BatchSink collects entries and delivers them through a BatchSender when
+// the count or size threshold is reached or the flush interval elapses.
+// Delivery happens on a background goroutine.
+type BatchSink struct {
+	sender  BatchSender
+	cfg     BatchConfig
+	mu      sync.Mutex
+	pending []*Entry
+	// closeMu is held shared by writers and exclusively by Close, so no
+	// batch is sent after the channel is closed.
+	closeMu sync.RWMutex
+	closed  bool
+	bytes   int
+	batches chan []*Entry
+	flush   chan chan struct{}
+	closing chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	failed  atomic.Uint64
+	health  healthTracker
+}
+
+// NewBatchSink starts a BatchSink delivering to sender.
+func NewBatchSink(sender BatchSender, cfg BatchConfig) *BatchSink {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultBatchEntries
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultBatchBytes
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = DefaultSendTimeout
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+	if cfg.Retry.InitialBackoff <= 0 {
+		cfg.Retry = DefaultRetryPolicy
+	}
+	if cfg.AtLeastOnce && !acknowledges(sender) {
+		cfg.ErrorHandler(ErrNoAcks)
+	}
+
+	s := &BatchSink{
+		sender:  sender,
+		cfg:     cfg,
+		batches: make(chan []*Entry, 4),
+		flush:   make(chan chan struct{}),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// WriteEntry implements Sink. After Close it returns ErrSinkClosed.
+func (s *BatchSink) WriteEntry(e *Entry) error {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, e.Clone())
+	s.bytes += entrySize(e)
+	var full []*Entry
+	if len(s.pending) >= s.cfg.MaxEntries || s.bytes >= s.cfg.MaxBytes {
+		full = s.take()
+	}
+	s.mu.Unlock()
+
+	if full != nil {
+		s.batches <- full
+	}
+	return nil
+}
+
+// take removes and returns the pending entries. s.mu must be held.
+func (s *BatchSink) take() []*Entry {
+	batch := s.pending
+	s.pending = nil
+	s.bytes = 0
+	return batch
+}
+
+func (s *BatchSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-s.batches:
+			if !ok {
+				s.mu.Lock()
+				batch = s.take()
+				s.mu.Unlock()
+				s.send(batch)
+				return
+			}
+			s.send(batch)
+		case ack := <-s.flush:
+			s.drain()
+			close(ack)
+		case <-ticker.C:
+			s.drain()
+		}
+	}
+}
+
+// drain sends every queued batch and then the pending entries.
+func (s *BatchSink) drain() {
+	for len(s.batches) > 0 {
+		s.send(<-s.batches)
+	}
+	s.mu.Lock()
+	batch := s.take()
+	s.mu.Unlock()
+	s.send(batch)
+}
+
+func (s *BatchSink) send(batch []*Entry) {
+	if len(batch) == 0 {
+		return
+	}
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.SendTimeout)
+		err := s.sender.SendBatch(ctx, batch)
+		cancel()
+		s.health.record(time.Now(), err)
+		if err == nil {
+			return
+		}
+		s.cfg.ErrorHandler(err)
+		if !s.cfg.AtLeastOnce || IsPermanent(err) || s.isClosing() {
+			s.failed.Add(uint64(len(batch)))
+			return
+		}
+		timer := time.NewTimer(s.cfg.Retry.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-s.closing:
+			// One more attempt, then Close gives up.
+			timer.Stop()
+		}
+	}
+}
+
+func (s *BatchSink) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Flush delivers everything pending and waits for it to be sent.
+func (s *BatchSink) Flush() error {
+	ack := make(chan struct{})
+	select {
+	case s.flush <- ack:
+		<-ack
+	case <-s.done:
+	}
+	return nil
+}
+
+// Close waits for writes in progress, delivers everything pending and
+// stops the background goroutine. With AtLeastOnce every batch still
+// failing gets one last attempt. If entries failed to be delivered, the
+// error reports how many and wraps the last send error, see
+// UndeliveredErrFmt.
+func (s *BatchSink) Close() error {
+	first := false
+	s.once.Do(func() {
+		first = true
+		close(s.closing)
+		s.closeMu.Lock()
+		s.closed = true
+		close(s.batches)
+		s.closeMu.Unlock()
+	})
+	<-s.done
+	if !first {
+		return nil
+	}
+	return undelivered(s.Stats(), s.health.lastError())
+}
+
+// Stats implements StatsReporter.
+func (s *BatchSink) Stats() Stats {
+	return Stats{Failed: s.failed.Load()}
+}
+
+// Health implements HealthReporter. QueueDepth counts pending entries plus
+// an estimate for the full batches awaiting delivery.
+func (s *BatchSink) Health() Health {
+	h := s.health.health(s)
+	s.mu.Lock()
+	h.QueueDepth = len(s.pending)
+	s.mu.Unlock()
+	h.QueueDepth += len(s.batches) * s.cfg.MaxEntries
+	return h
+}
+
+// entrySize estimates the encoded size of an entry for batching thresholds.
+func entrySize(e *Entry) int {
+	n := len(e.Name) + len(e.Message) + 32
+	for _, f := range e.Fields {
+		n += len(f.Key) + 16
+		n += len(f.String)
+		if s, ok := f.Value.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSender records the sizes of the batches it receives.
+type recordSender struct {
+	mu    sync.Mutex
+	sizes []int
+	total atomic.Int64
+	err   error
+}
+
+func (s *recordSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	s.sizes = append(s.sizes, len(batch))
+	s.mu.Unlock()
+	s.total.Add(int64(len(batch)))
+	return s.err
+}
+
+func TestBatchSinkThresholds(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   BatchConfig
+		n     int
+		sizes []int
+	}{
+		{"entries", BatchConfig{MaxEntries: 3, FlushInterval: time.Hour}, 7, []int{3, 3, 1}},
+		{"bytes", BatchConfig{MaxBytes: 1, FlushInterval: time.Hour}, 2, []int{1, 1}},
+		{"close only", BatchConfig{FlushInterval: time.Hour}, 5, []int{5}},
+	}
+	for _, tt := range tests {
+		sender := new(recordSender)
+		s := NewBatchSink(sender, tt.cfg)
+		for i := 0; i < tt.n; i++ {
+			if err := s.WriteEntry(&Entry{Message: "m"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s.Close()
+		if len(sender.sizes) != len(tt.sizes) {
+			t.Errorf("%s: batches %v, want %v", tt.name, sender.sizes, tt.sizes)
+			continue
+		}
+		for i := range tt.sizes {
+			if sender.sizes[i] != tt.sizes[i] {
+				t.Errorf("%s: batches %v, want %v", tt.name, sender.sizes, tt.sizes)
+				break
+			}
+		}
+		if err := s.WriteEntry(&Entry{}); err != ErrSinkClosed {
+			t.Errorf("%s: write after Close = %v, want ErrSinkClosed", tt.name, err)
+		}
+	}
+}
+
+func TestBatchSinkFailures(t *testing.T) {
+	var handled atomic.Int64
+	sender := &recordSender{err: errors.New("down")}
+	s := NewBatchSink(sender, BatchConfig{
+		FlushInterval: time.Hour,
+		ErrorHandler:  func(error) { handled.Add(1) },
+	})
+	for i := 0; i < 4; i++ {
+		s.WriteEntry(&Entry{})
+	}
+	s.Flush()
+	if got := s.Stats().Failed; got != 4 {
+		t.Errorf("Failed = %d, want 4", got)
+	}
+	if handled.Load() != 1 {
+		t.Errorf("error handler called %d times", handled.Load())
+	}
+	if err := s.Close(); !errors.Is(err, sender.err) || !strings.Contains(err.Error(), "4 failed") {
+		t.Errorf("Close = %v, want the failures and the send error", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
+
+// TestBatchSinkCloseRace checks that every entry accepted while Close runs
+// concurrently is delivered.
+func TestBatchSinkCloseRace(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		sender := new(recordSender)
+		s := NewBatchSink(sender, BatchConfig{MaxEntries: 8, FlushInterval: time.Hour})
+		var accepted atomic.Int64
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				e := &Entry{Message: "m"}
+				for i := 0; i < 200; i++ {
+					if s.WriteEntry(e) == nil {
+						accepted.Add(1)
+					}
+				}
+			}()
+		}
+		s.Close()
+		wg.Wait()
+		if got, want := sender.total.Load(), accepted.Load(); got != want {
+			t.Fatalf("round %d: delivered %d of %d accepted entries", round, got, want)
+		}
+	}
+}
+
+func BenchmarkBatchSink(b *testing.B) {
+	s := NewBatchSink(BatchSenderFunc(func(context.Context, []*Entry) error { return nil }), BatchConfig{})
+	defer s.Close()
+	e := &Entry{Message: "hello", Fields: []Field{Int("n", 1)}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.WriteEntry(e)
+	}
+}
+
+// flakySender fails the first n batches.
+type flakySender struct {
+	mu   sync.Mutex
+	fail int
+	got  []string
+}
+
+func (s *flakySender) SendBatch(_ context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("collector unavailable")
+	}
+	for _, e := range batch {
+		s.got = append(s.got, e.Message)
+	}
+	return nil
+}
+
+func TestBatchSinkAtLeastOnce(t *testing.T) {
+	sender := &flakySender{fail: 3}
+	s := NewBatchSink(sender, BatchConfig{
+		MaxEntries: 2, FlushInterval: time.Hour, AtLeastOnce: true,
+		Retry:        RetryPolicy{InitialBackoff: time.Millisecond},
+		ErrorHandler: func(error) {},
+	})
+	for _, msg := range []string{"a", "b", "c"} {
+		s.WriteEntry(&Entry{Message: msg})
+	}
+	s.Flush()
+	sender.mu.Lock()
+	got := append([]string(nil), sender.got...)
+	sender.mu.Unlock()
+	if len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("delivered %q", got)
+	}
+	s.Close()
+	if s.Stats().Failed != 0 {
+		t.Errorf("Failed = %d", s.Stats().Failed)
+	}
+}
+
+func TestBatchSinkAtLeastOnceClose(t *testing.T) {
+	sender := &flakySender{fail: 1 << 30}
+	s := NewBatchSink(sender, BatchConfig{
+		MaxEntries: 1, AtLeastOnce: true,
+		Retry:        RetryPolicy{InitialBackoff: time.Hour},
+		ErrorHandler: func(error) {},
+	})
+	s.WriteEntry(&Entry{Message: "a"})
+	s.WriteEntry(&Entry{Message: "b"})
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the retries")
+	}
+	if s.Stats().Failed != 2 {
+		t.Errorf("Failed = %d, want 2", s.Stats().Failed)
+	}
+}
+
+// ackSender is a sender declaring whether it acknowledges delivery.
+type ackSender bool
+
+func (ackSender) SendBatch(context.Context, []*Entry) error { return nil }
+func (s ackSender) Acknowledges() bool                      { return bool(s) }
+
+func TestBatchSinkAtLeastOnceAcks(t *testing.T) {
+	var reported []error
+	handler := func(err error) { reported = append(reported, err) }
+	NewBatchSink(ackSender(false), BatchConfig{AtLeastOnce: true, ErrorHandler: handler}).Close()
+	NewBatchSink(WithRetry(ackSender(true), DefaultRetryPolicy), BatchConfig{AtLeastOnce: true, ErrorHandler: handler}).Close()
+	NewBatchSink(&recordSender{}, BatchConfig{AtLeastOnce: true, ErrorHandler: handler}).Close()
+	if len(reported) != 1 || reported[0] != ErrNoAcks {
+		t.Errorf("reported %v, want ErrNoAcks for the sender without acks only", reported)
+	}
+}
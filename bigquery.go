@@ -0,0 +1,491 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBigQueryURL is the Storage Write API used when none is
+	// configured.
+	DefaultBigQueryURL = "https://bigquerystorage.googleapis.com"
+	// DefaultBigQueryFieldsColumn holds the fields without a column.
+	DefaultBigQueryFieldsColumn = "fields"
+
+	BigQueryTokenErrFmt  = "Failed to get a BigQuery access token: %w"
+	BigQueryInsertErrFmt = "BigQuery rejected %d of %d rows, first at row %d: %s"
+	BigQueryAppendErrFmt = "BigQuery append failed with gRPC status %d: %s"
+	BigQueryHTTP2ErrFmt  = "BigQuery Storage Write API needs HTTP/2, got %s"
+
+	bigQueryAppendPath = "/google.cloud.bigquery.storage.v1.BigQueryWrite/AppendRows"
+	bigQueryStreamFmt  = "projects/%s/datasets/%s/tables/%s/streams/_default"
+	bigQueryMaxBatch   = 500
+	// bigQueryMaxRequest keeps requests below the 10 MB limit of
+	// AppendRows.
+	bigQueryMaxRequest = 9 << 20
+)
+
+// Protobuf field types of the row descriptor.
+const (
+	protoTypeDouble = 1
+	protoTypeInt64  = 3
+	protoTypeBool   = 8
+	protoTypeString = 9
+)
+
+var errGRPCFrame = errors.New("malformed gRPC response")
+
+// BigQueryConfig configures a BigQuerySender.
+type BigQueryConfig struct {
+	// Project, Dataset and Table name the destination table, which must
+	// have the columns time (TIMESTAMP), level, logger and message
+	// (STRING), those of Columns and FieldsColumn.
+	Project string
+	Dataset string
+	Table   string
+	// URL is the API, e.g. for an emulator; empty selects
+	// DefaultBigQueryURL.
+	URL string
+	// Columns maps field keys, dotted for groups, to columns of their own.
+	// Mapped top-level fields are left out of FieldsColumn. A column is
+	// sent with the type of its fields: integers and durations (in
+	// nanoseconds) as INT64, floats, bools, times as TIMESTAMP and the
+	// rest as STRING, or as STRING for a batch mixing types.
+	Columns map[string]string
+	// FieldsColumn is a STRING or JSON column receiving the other fields
+	// as a JSON object; empty selects DefaultBigQueryFieldsColumn and "-"
+	// drops them.
+	FieldsColumn string
+	// SkipInvalidRows appends the valid rows of a batch again when
+	// BigQuery rejects some, instead of dropping the whole batch.
+	SkipInvalidRows bool
+	// Token authorizes requests; nil uses the GCE metadata server unless
+	// ClientAuth is set.
+	Token TokenFunc
+	// ClientAuth declares that Client adds credentials itself, e.g. through
+	// WithBearerToken, so no token is sent.
+	ClientAuth bool
+	// Client is the HTTP client used, which must speak HTTP/2; nil
+	// selects http.DefaultClient.
+	Client *http.Client
+}
+
+// BigQuerySender is a BatchSender streaming entries into a BigQuery table
+// through the Storage Write API: every batch is an AppendRows call to the
+// default stream of the table, gRPC over HTTP/2 with the rows encoded as
+// protocol buffers. The default stream delivers at least once, so a
+// retried batch may be appended twice.
+type BigQuerySender struct {
+	cfg     BigQueryConfig
+	url     string
+	stream  string
+	columns []bigQueryColumn
+}
+
+// bigQueryColumn is a column mapped from fields, filled from the first of
+// its keys an entry has.
+type bigQueryColumn struct {
+	name string
+	keys []string
+}
+
+// NewBigQuerySender creates a BigQuerySender.
+func NewBigQuerySender(cfg BigQueryConfig) *BigQuerySender {
+	if cfg.URL == "" {
+		cfg.URL = DefaultBigQueryURL
+	}
+	if cfg.FieldsColumn == "" {
+		cfg.FieldsColumn = DefaultBigQueryFieldsColumn
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Token == nil && !cfg.ClientAuth {
+		cfg.Token = MetadataServerToken(nil)
+	}
+	keys := make([]string, 0, len(cfg.Columns))
+	for key := range cfg.Columns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var columns []bigQueryColumn
+	index := make(map[string]int)
+	for _, key := range keys {
+		name := cfg.Columns[key]
+		i, ok := index[name]
+		if !ok {
+			i = len(columns)
+			index[name] = i
+			columns = append(columns, bigQueryColumn{name: name})
+		}
+		columns[i].keys = append(columns[i].keys, key)
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].name < columns[j].name })
+	return &BigQuerySender{
+		cfg:     cfg,
+		url:     strings.TrimSuffix(cfg.URL, "/") + bigQueryAppendPath,
+		stream:  fmt.Sprintf(bigQueryStreamFmt, cfg.Project, cfg.Dataset, cfg.Table),
+		columns: columns,
+	}
+}
+
+// SendBatch implements BatchSender.
+func (s *BigQuerySender) SendBatch(ctx context.Context, batch []*Entry) error {
+	for start := 0; start < len(batch); start += bigQueryMaxBatch {
+		end := start + bigQueryMaxBatch
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := s.send(ctx, batch[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send appends batch in requests below bigQueryMaxRequest.
+func (s *BigQuerySender) send(ctx context.Context, batch []*Entry) error {
+	types := s.types(batch)
+	schema := s.descriptor(types)
+	var rows [][]byte
+	size := 0
+	for _, e := range batch {
+		row, err := s.row(e, types)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 && size+len(row) > bigQueryMaxRequest {
+			if err := s.appendRows(ctx, schema, rows); err != nil {
+				return err
+			}
+			rows, size = nil, 0
+		}
+		rows = append(rows, row)
+		size += len(row) + binary.MaxVarintLen32 + 1
+	}
+	return s.appendRows(ctx, schema, rows)
+}
+
+// column returns the field filling column c of e.
+func (s *BigQuerySender) column(e *Entry, c bigQueryColumn) (Field, bool) {
+	for _, key := range c.keys {
+		if f, ok := findField(e.Fields, key); ok {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// types returns the protobuf types of the mapped columns for batch.
+func (s *BigQuerySender) types(batch []*Entry) []int {
+	types := make([]int, len(s.columns))
+	for i, c := range s.columns {
+		for _, e := range batch {
+			f, ok := s.column(e, c)
+			if !ok {
+				continue
+			}
+			typ := protoTypeString
+			switch f.Type {
+			case Int64Type, DurationType, TimeType:
+				typ = protoTypeInt64
+			case Float64Type:
+				typ = protoTypeDouble
+			case BoolType:
+				typ = protoTypeBool
+			}
+			if types[i] != 0 && types[i] != typ {
+				types[i] = protoTypeString
+				break
+			}
+			types[i] = typ
+		}
+		if types[i] == 0 {
+			types[i] = protoTypeString
+		}
+	}
+	return types
+}
+
+// descriptor returns the DescriptorProto of the rows: time, level, logger
+// and message numbered 1 to 4, the fields column 5 and the mapped columns
+// from 6.
+func (s *BigQuerySender) descriptor(types []int) []byte {
+	d := protoString(nil, 1, "LogRow")
+	field := func(name string, num, typ int) {
+		f := protoString(nil, 1, name)
+		f = binary.AppendUvarint(protoTag(f, 3, protoVarint), uint64(num))
+		f = binary.AppendUvarint(protoTag(f, 4, protoVarint), 1) // optional
+		f = binary.AppendUvarint(protoTag(f, 5, protoVarint), uint64(typ))
+		d = protoBytes(d, 2, f)
+	}
+	field("time", 1, protoTypeInt64)
+	field("level", 2, protoTypeString)
+	field("logger", 3, protoTypeString)
+	field("message", 4, protoTypeString)
+	if s.cfg.FieldsColumn != "-" {
+		field(s.cfg.FieldsColumn, 5, protoTypeString)
+	}
+	for i, c := range s.columns {
+		field(c.name, 6+i, types[i])
+	}
+	return d
+}
+
+// row encodes e as a message of the row descriptor.
+func (s *BigQuerySender) row(e *Entry, types []int) ([]byte, error) {
+	b := binary.AppendUvarint(protoTag(nil, 1, protoVarint), uint64(e.Time.UnixMicro()))
+	b = protoString(b, 2, e.Level.String())
+	if e.Name != "" {
+		b = protoString(b, 3, e.Name)
+	}
+	b = protoString(b, 4, e.Message)
+	var rest []Field
+	for _, f := range e.Fields {
+		if _, ok := s.cfg.Columns[f.Key]; !ok {
+			rest = append(rest, f)
+		}
+	}
+	if s.cfg.FieldsColumn != "-" && len(rest) > 0 {
+		js, err := json.Marshal((&Entry{Fields: rest}).FieldMap())
+		if err != nil {
+			return nil, fmt.Errorf(EncodeErrFmt, err)
+		}
+		b = protoString(b, 5, string(js))
+	}
+	for i, c := range s.columns {
+		f, ok := s.column(e, c)
+		if !ok {
+			continue
+		}
+		var err error
+		if b, err = bigQueryValue(b, 6+i, types[i], f); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// bigQueryValue appends the value of f as field num of type typ; times
+// are microseconds since the epoch, as BigQuery takes TIMESTAMPs.
+func bigQueryValue(b []byte, num, typ int, f Field) ([]byte, error) {
+	switch typ {
+	case protoTypeInt64:
+		v := f.Integer
+		if f.Type == TimeType {
+			v = time.Unix(0, f.Integer).UnixMicro()
+		}
+		return binary.AppendUvarint(protoTag(b, num, protoVarint), uint64(v)), nil
+	case protoTypeDouble:
+		return binary.LittleEndian.AppendUint64(protoTag(b, num, protoI64), uint64(f.Integer)), nil
+	case protoTypeBool:
+		return binary.AppendUvarint(protoTag(b, num, protoVarint), uint64(f.Integer)), nil
+	}
+	var s string
+	switch v := f.Interface().(type) {
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case time.Duration:
+		s = strconv.FormatInt(int64(v), 10)
+	case time.Time:
+		s = v.UTC().Format(time.RFC3339Nano)
+	case error:
+		s = v.Error()
+	default:
+		js, err := json.Marshal(fieldMap([]Field{f})[f.Key])
+		if err != nil {
+			return nil, fmt.Errorf(EncodeErrFmt, err)
+		}
+		s = string(js)
+	}
+	return protoString(b, num, s), nil
+}
+
+// appendRows appends rows in one request. With SkipInvalidRows the rows
+// BigQuery rejects are left out and the others sent again; the rejection
+// is still returned.
+func (s *BigQuerySender) appendRows(ctx context.Context, schema []byte, rows [][]byte) error {
+	rowErrs, err := s.append(ctx, schema, rows)
+	if len(rowErrs) == 0 {
+		return err
+	}
+	first := rowErrs[0]
+	rejected := Permanent(fmt.Errorf(BigQueryInsertErrFmt, len(rowErrs), len(rows), first.index, first.message))
+	if !s.cfg.SkipInvalidRows {
+		return rejected
+	}
+	bad := make(map[int]bool, len(rowErrs))
+	for _, re := range rowErrs {
+		bad[re.index] = true
+	}
+	var valid [][]byte
+	for i, row := range rows {
+		if !bad[i] {
+			valid = append(valid, row)
+		}
+	}
+	if len(valid) == 0 || len(valid) == len(rows) {
+		return rejected
+	}
+	if err := s.appendRows(ctx, schema, valid); err != nil {
+		return err
+	}
+	return rejected
+}
+
+// bigQueryRowError is a row BigQuery rejected, by its index in the
+// request.
+type bigQueryRowError struct {
+	index   int
+	message string
+}
+
+// append makes one AppendRows call, returning the rows rejected or the
+// error of the call.
+func (s *BigQuerySender) append(ctx context.Context, schema []byte, rows [][]byte) ([]bigQueryRowError, error) {
+	var protoRows []byte
+	for _, row := range rows {
+		protoRows = protoBytes(protoRows, 1, row)
+	}
+	data := protoBytes(nil, 1, protoBytes(nil, 1, schema))
+	data = protoBytes(data, 2, protoRows)
+	msg := protoBytes(protoString(nil, 1, s.stream), 4, data)
+
+	// A gRPC message is framed by a compression flag and its length.
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+	hreq, err := newPostRequest(ctx, s.url, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Content-Type", "application/grpc")
+	hreq.Header.Set("TE", "trailers")
+	hreq.Header.Set("x-goog-request-params", "write_stream="+url.QueryEscape(s.stream))
+	if s.cfg.Token != nil {
+		token, err := s.cfg.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(BigQueryTokenErrFmt, err)
+		}
+		hreq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.cfg.Client.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf(HTTPStatusErrFmt, hreq.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+		if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, Permanent(err)
+		}
+		return nil, err
+	}
+	if resp.ProtoMajor != 2 {
+		return nil, Permanent(fmt.Errorf(BigQueryHTTP2ErrFmt, resp.Proto))
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	rowErrs, err := decodeAppendRowsResponse(b)
+	if len(rowErrs) > 0 || err != nil {
+		return rowErrs, err
+	}
+	// A call failing before any response has its status in the headers.
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if status == "0" {
+		return nil, nil
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, fmt.Errorf(BigQueryAppendErrFmt, 2, "no grpc-status")
+	}
+	if m, err := url.PathUnescape(message); err == nil {
+		message = m
+	}
+	return nil, grpcError(code, message)
+}
+
+// decodeAppendRowsResponse returns the rejected rows or the error status
+// of the AppendRowsResponse messages in b.
+func decodeAppendRowsResponse(b []byte) ([]bigQueryRowError, error) {
+	var rowErrs []bigQueryRowError
+	var status error
+	for len(b) > 0 {
+		if len(b) < 5 || b[0] != 0 || uint64(len(b)-5) < uint64(binary.BigEndian.Uint32(b[1:])) {
+			return nil, errGRPCFrame
+		}
+		n := 5 + int(binary.BigEndian.Uint32(b[1:]))
+		err := protoMessage(b[5:n], func(num int, typ byte, _ uint64, data []byte) error {
+			switch {
+			case num == 2 && typ == protoLen:
+				code, message := 0, ""
+				err := protoMessage(data, func(num int, typ byte, v uint64, data []byte) error {
+					switch {
+					case num == 1 && typ == protoVarint:
+						code = int(v)
+					case num == 2 && typ == protoLen:
+						message = string(data)
+					}
+					return nil
+				})
+				if code != 0 {
+					status = grpcError(code, message)
+				}
+				return err
+			case num == 4 && typ == protoLen:
+				var re bigQueryRowError
+				err := protoMessage(data, func(num int, typ byte, v uint64, data []byte) error {
+					switch {
+					case num == 1 && typ == protoVarint:
+						re.index = int(v)
+					case num == 3 && typ == protoLen:
+						re.message = string(data)
+					}
+					return nil
+				})
+				rowErrs = append(rowErrs, re)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errGRPCFrame
+		}
+		b = b[n:]
+	}
+	return rowErrs, status
+}
+
+// grpcError returns the error of a gRPC status code, permanent for the
+// codes resending cannot fix: invalid argument, not found, already
+// exists, permission denied, failed precondition, out of range and
+// unimplemented.
+func grpcError(code int, message string) error {
+	err := fmt.Errorf(BigQueryAppendErrFmt, code, message)
+	switch code {
+	case 3, 5, 6, 7, 9, 11, 12:
+		return Permanent(err)
+	}
+	return err
+}
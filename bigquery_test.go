@@ -0,0 +1,216 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// appendRequest is an AppendRows request as a test server sees it: the
+// stream, the descriptor fields by name and the rows by field number.
+type appendRequest struct {
+	stream string
+	fields map[string][2]int
+	rows   []map[int][]byte
+}
+
+func decodeAppendRequest(t *testing.T, body []byte) appendRequest {
+	t.Helper()
+	if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:])) != len(body)-5 {
+		t.Fatalf("gRPC frame % x", body)
+	}
+	req := appendRequest{fields: make(map[string][2]int)}
+	err := protoMessage(body[5:], func(num int, typ byte, _ uint64, data []byte) error {
+		switch num {
+		case 1:
+			req.stream = string(data)
+		case 4:
+			return protoMessage(data, func(num int, _ byte, _ uint64, data []byte) error {
+				if num == 2 {
+					return protoMessage(data, func(_ int, _ byte, _ uint64, row []byte) error {
+						cols := make(map[int][]byte)
+						req.rows = append(req.rows, cols)
+						return protoMessage(row, func(num int, typ byte, v uint64, data []byte) error {
+							if typ != protoLen {
+								data = binary.AppendUvarint(nil, v)
+							}
+							cols[num] = data
+							return nil
+						})
+					})
+				}
+				// ProtoSchema, then the DescriptorProto of the rows.
+				return protoMessage(data, func(_ int, _ byte, _ uint64, desc []byte) error {
+					return protoMessage(desc, func(num int, _ byte, _ uint64, data []byte) error {
+						if num != 2 {
+							return nil
+						}
+						var name string
+						var f [2]int
+						err := protoMessage(data, func(num int, _ byte, v uint64, data []byte) error {
+							switch num {
+							case 1:
+								name = string(data)
+							case 3:
+								f[0] = int(v)
+							case 5:
+								f[1] = int(v)
+							}
+							return nil
+						})
+						req.fields[name] = f
+						return err
+					})
+				})
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+// grpcFrame frames an AppendRowsResponse.
+func grpcFrame(msg []byte) []byte {
+	b := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+func TestBigQuerySender(t *testing.T) {
+	var mu sync.Mutex
+	var reqs []appendRequest
+	var path, auth, params string
+	reject := false
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Header.Get("Content-Type") != "application/grpc" {
+			t.Errorf("%s request with content type %q", r.Proto, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := decodeAppendRequest(t, body)
+		mu.Lock()
+		path, auth, params = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Goog-Request-Params")
+		reqs = append(reqs, req)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		if reject && len(req.rows) > 1 {
+			status := binary.AppendUvarint(protoTag(nil, 1, protoVarint), 3)
+			rowErr := binary.AppendUvarint(protoTag(nil, 1, protoVarint), 1)
+			rowErr = protoString(rowErr, 3, "no such field")
+			w.Write(grpcFrame(protoBytes(protoBytes(nil, 2, status), 4, rowErr)))
+			w.Header().Set("Grpc-Status", "3")
+			w.Header().Set("Grpc-Message", "rows%20rejected")
+			return
+		}
+		w.Write(grpcFrame(protoBytes(nil, 1, nil)))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	s := NewBigQuerySender(BigQueryConfig{
+		Project: "p", Dataset: "d", Table: "logs", URL: srv.URL, Client: srv.Client(),
+		Columns: map[string]string{"user": "user_id", "http.status": "status", "ratio": "ratio"},
+		Token:   StaticToken("tok"),
+	})
+	e := &Entry{Time: testTime, Level: Warn, Name: "api", Message: "slow",
+		Fields: []Field{String("user", "u1"), Group("http", Int("status", 503)), Bool("cached", false), Float("ratio", 0.5)}}
+	if err := s.SendBatch(context.Background(), []*Entry{e, e}); err != nil {
+		t.Fatal(err)
+	}
+	if path != bigQueryAppendPath || auth != "Bearer tok" || params != "write_stream=projects%2Fp%2Fdatasets%2Fd%2Ftables%2Flogs%2Fstreams%2F_default" {
+		t.Errorf("path %q, auth %q, params %q", path, auth, params)
+	}
+	req := reqs[0]
+	if req.stream != "projects/p/datasets/d/tables/logs/streams/_default" || len(req.rows) != 2 {
+		t.Fatalf("request %+v", req)
+	}
+	for name, want := range map[string][2]int{
+		"time": {1, protoTypeInt64}, "level": {2, protoTypeString}, "message": {4, protoTypeString}, "fields": {5, protoTypeString},
+		"ratio": {6, protoTypeDouble}, "status": {7, protoTypeInt64}, "user_id": {8, protoTypeString},
+	} {
+		if req.fields[name] != want {
+			t.Errorf("descriptor field %s = %v, want %v", name, req.fields[name], want)
+		}
+	}
+	row := req.rows[0]
+	micros, _ := binary.Uvarint(row[1])
+	status, _ := binary.Uvarint(row[7])
+	if int64(micros) != testTime.UnixMicro() || status != 503 {
+		t.Errorf("time %d, status %d", micros, status)
+	}
+	if string(row[2]) != "WARN" || string(row[3]) != "api" || string(row[8]) != "u1" || string(row[5]) != `{"cached":false,"http":{"status":503}}` {
+		t.Errorf("row %q", row)
+	}
+	if ratio, _ := binary.Uvarint(row[6]); math.Float64frombits(ratio) != 0.5 {
+		t.Errorf("ratio %v", math.Float64frombits(ratio))
+	}
+
+	// A column with fields of different types in one batch is sent as a
+	// string.
+	if err := s.SendBatch(context.Background(), []*Entry{e, {Time: testTime, Fields: []Field{String("ratio", "half")}}}); err != nil {
+		t.Fatal(err)
+	}
+	if req := reqs[1]; req.fields["ratio"][1] != protoTypeString || string(req.rows[0][6]) != "0.5" || string(req.rows[1][6]) != "half" {
+		t.Errorf("mixed column %v: %q, %q", req.fields["ratio"], req.rows[0][6], req.rows[1][6])
+	}
+
+	reject = true
+	err := s.SendBatch(context.Background(), []*Entry{e, e})
+	if !IsPermanent(err) || !strings.Contains(err.Error(), "1 of 2 rows, first at row 1: no such field") {
+		t.Errorf("rejected rows: %v", err)
+	}
+
+	// SkipInvalidRows sends the valid rows again.
+	s.cfg.SkipInvalidRows = true
+	reqs = nil
+	if err := s.SendBatch(context.Background(), []*Entry{e, e}); !IsPermanent(err) {
+		t.Errorf("rejected rows: %v", err)
+	}
+	if len(reqs) != 2 || len(reqs[1].rows) != 1 {
+		t.Errorf("%d requests after a rejection", len(reqs))
+	}
+}
+
+func TestBigQueryStatus(t *testing.T) {
+	status := "14"
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A trailers-only response carries the status in its headers.
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", status)
+		w.Header().Set("Grpc-Message", "table%20not%20found")
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	s := NewBigQuerySender(BigQueryConfig{Project: "p", Dataset: "d", Table: "t", URL: srv.URL, Client: srv.Client(), ClientAuth: true})
+	batch := []*Entry{{Time: testTime, Message: "m"}}
+	if err := s.SendBatch(context.Background(), batch); err == nil || IsPermanent(err) {
+		t.Errorf("unavailable: %v", err)
+	}
+	status = "5"
+	err := s.SendBatch(context.Background(), batch)
+	if !IsPermanent(err) || !strings.Contains(err.Error(), "status 5: table not found") {
+		t.Errorf("not found: %v", err)
+	}
+
+	// gRPC needs HTTP/2.
+	h1 := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer h1.Close()
+	s = NewBigQuerySender(BigQueryConfig{URL: h1.URL, Client: h1.Client(), ClientAuth: true})
+	if err := s.SendBatch(context.Background(), batch); !IsPermanent(err) || !strings.Contains(err.Error(), "HTTP/2") {
+		t.Errorf("HTTP/1.1: %v", err)
+	}
+}
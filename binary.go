@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+)
+
+// BytesFormat selects how []byte field values are written as text.
+type BytesFormat int
+
+const (
+	// Base64Bytes writes standard base64, as JSON does by default.
+	Base64Bytes BytesFormat = iota
+	// HexBytes writes lower case hex, easier to read for short binary
+	// values such as hashes and packet headers.
+	HexBytes
+)
+
+// BytesConfig configures WithBytesEncoding.
+type BytesConfig struct {
+	// Format is the encoding of the bytes.
+	Format BytesFormat
+	// Max caps the bytes written, the rest being summarized by the total
+	// length, e.g. "00ff12... (4096 bytes)"; zero writes them all.
+	Max int
+}
+
+// Binary returns a field holding binary data. Without WithBytesEncoding
+// every encoder writes it as base64.
+func Binary(key string, val []byte) Field {
+	return Field{Key: key, Value: val}
+}
+
+// WithBytesEncoding writes []byte field values, including those of groups,
+// in the configured format and length in every encoder, instead of the
+// base64 each encoder writes by default.
+func WithBytesEncoding(cfg BytesConfig) Option {
+	return WithProcessors(ProcessorFunc(func(e *Entry) {
+		encodeBytesFields(e.Fields, cfg)
+	}))
+}
+
+// encodeBytesFields replaces []byte values in place by their encoding.
+// Groups may be shared with the caller or the logger, so a group that
+// changes is copied.
+func encodeBytesFields(fields []Field, cfg BytesConfig) bool {
+	changed := false
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case []byte:
+			if f.Type == AnyType {
+				fields[i], changed = String(f.Key, cfg.encode(v)), true
+			}
+		case []Field:
+			if f.Type == GroupType {
+				members := append([]Field(nil), v...)
+				if encodeBytesFields(members, cfg) {
+					fields[i].Value, changed = members, true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+func (cfg BytesConfig) encode(b []byte) string {
+	n := len(b)
+	if cfg.Max > 0 && n > cfg.Max {
+		b = b[:cfg.Max]
+	}
+	var s string
+	if cfg.Format == HexBytes {
+		s = hex.EncodeToString(b)
+	} else {
+		s = base64.StdEncoding.EncodeToString(b)
+	}
+	if len(b) < n {
+		s += "... (" + strconv.Itoa(n) + " bytes)"
+	}
+	return s
+}
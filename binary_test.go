@@ -0,0 +1,40 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryDefault(t *testing.T) {
+	e := &Entry{Message: "m", Fields: []Field{Binary("b", []byte("hi\x00"))}}
+	for _, enc := range []Encoder{TextEncoder{}, LogfmtEncoder{}, JSONEncoder{}} {
+		var buf bytes.Buffer
+		enc.Encode(&buf, e)
+		if !strings.Contains(buf.String(), "aGkA") {
+			t.Errorf("%T: %q", enc, buf.String())
+		}
+	}
+}
+
+func TestWithBytesEncoding(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(JSONEncoder{}), WithBytesEncoding(BytesConfig{Format: HexBytes, Max: 2}))
+	group := []Field{Binary("hash", []byte{0xde, 0xad})}
+	l.Log(Info, "packet", Binary("header", []byte{0x00, 0xff, 0x12, 0x34}), Group("sum", group...))
+	e, err := DecodeJSON(bytes.TrimSpace(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := e.FieldMap()["header"]; v != "00ff... (4 bytes)" {
+		t.Errorf("header = %v", v)
+	}
+	if v, _ := lookupField(e.Fields, "sum.hash"); v != "dead" {
+		t.Errorf("sum.hash = %v", v)
+	}
+	if _, ok := group[0].Value.([]byte); !ok {
+		t.Error("the caller's group was modified")
+	}
+}
@@ -0,0 +1,237 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultBroadcastBuffer is the number of entries queued per
+	// subscriber.
+	DefaultBroadcastBuffer = 256
+	// DefaultBroadcastHistory is the number of recent entries kept for
+	// replay.
+	DefaultBroadcastHistory = 1000
+)
+
+const StreamLevelErrFmt = "Unknown level %q"
+
+// StreamFilter selects the entries delivered to a subscriber.
+type StreamFilter struct {
+	// Level is the minimum level delivered.
+	Level LogLevel
+	// Name, if set, delivers only entries of that logger and its named
+	// children, so "app.db" matches "app.db" and "app.db.pool".
+	Name string
+}
+
+// Match reports whether e passes the filter.
+func (f StreamFilter) Match(e *Entry) bool {
+	if e.Level < f.Level {
+		return false
+	}
+	return f.Name == "" || e.Name == f.Name ||
+		strings.HasPrefix(e.Name, f.Name) && e.Name[len(f.Name)] == '.'
+}
+
+// AllEntries is the StreamFilter passing every entry.
+var AllEntries = StreamFilter{Level: math.MinInt16}
+
+// filterFromQuery reads a StreamFilter from the level and name query
+// parameters; without level every entry passes.
+func filterFromQuery(r *http.Request) (StreamFilter, bool) {
+	f := AllEntries
+	f.Name = r.URL.Query().Get("name")
+	if s := r.URL.Query().Get("level"); s != "" {
+		lvl, ok := ParseLevel(s)
+		if !ok {
+			return f, false
+		}
+		f.Level = lvl
+	}
+	return f, true
+}
+
+// BroadcastConfig configures a Broadcaster.
+type BroadcastConfig struct {
+	// Buffer is the number of entries queued for each subscriber; zero
+	// selects DefaultBroadcastBuffer. Entries for a subscriber whose queue
+	// is full are dropped and counted in Stats.Dropped.
+	Buffer int
+	// History is the number of recent entries kept for replay, see
+	// SubscribeSince and Recent; zero selects DefaultBroadcastHistory,
+	// negative keeps none.
+	History int
+}
+
+// StreamEntry is an entry delivered by a Broadcaster with its sequence
+// number, which increases by one for every entry written.
+type StreamEntry struct {
+	Seq   uint64
+	Entry *Entry
+}
+
+// Broadcaster is a sink fanning entries out to live subscribers, such as
+// the log streaming handlers, and keeping the most recent ones in a ring
+// for replay. It never blocks the logger: slow subscribers lose entries
+// instead.
+type Broadcaster struct {
+	cfg     BroadcastConfig
+	mu      sync.Mutex
+	subs    map[*subscriber]struct{}
+	closed  bool
+	dropped atomic.Uint64
+
+	seq     uint64
+	history []StreamEntry
+	next    int
+}
+
+type subscriber struct {
+	filter StreamFilter
+	ch     chan StreamEntry
+}
+
+// NewBroadcaster creates a Broadcaster; add it with WithSinks.
+func NewBroadcaster(cfg BroadcastConfig) *Broadcaster {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = DefaultBroadcastBuffer
+	}
+	if cfg.History == 0 {
+		cfg.History = DefaultBroadcastHistory
+	}
+	b := &Broadcaster{cfg: cfg, subs: make(map[*subscriber]struct{})}
+	if cfg.History > 0 {
+		b.history = make([]StreamEntry, 0, cfg.History)
+	}
+	return b
+}
+
+// WriteEntry implements Sink. The entry is cloned once and shared by the
+// history and the subscribers, which must not modify it.
+func (b *Broadcaster) WriteEntry(e *Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	se := StreamEntry{Seq: b.seq}
+	if cap(b.history) > 0 {
+		se.Entry = e.Clone()
+		if len(b.history) < cap(b.history) {
+			b.history = append(b.history, se)
+		} else {
+			b.history[b.next] = se
+			b.next = (b.next + 1) % len(b.history)
+		}
+	}
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		if se.Entry == nil {
+			se.Entry = e.Clone()
+		}
+		select {
+		case s.ch <- se:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the entries matching f from now on
+// and a function ending the subscription. The channel is closed when the
+// subscription ends or the Broadcaster is closed.
+func (b *Broadcaster) Subscribe(f StreamFilter) (<-chan StreamEntry, func()) {
+	_, ch, cancel := b.subscribe(f, 0, false)
+	return ch, cancel
+}
+
+// SubscribeSince is like Subscribe but also returns the kept entries
+// matching f with a sequence number above seq, oldest first, so a client
+// reconnecting with the last number it saw misses nothing still in the
+// history. No entry is both replayed and delivered on the channel.
+func (b *Broadcaster) SubscribeSince(f StreamFilter, seq uint64) ([]StreamEntry, <-chan StreamEntry, func()) {
+	return b.subscribe(f, seq, true)
+}
+
+func (b *Broadcaster) subscribe(f StreamFilter, seq uint64, replay bool) ([]StreamEntry, <-chan StreamEntry, func()) {
+	s := &subscriber{filter: f, ch: make(chan StreamEntry, b.cfg.Buffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var past []StreamEntry
+	if replay {
+		past = b.recentLocked(f, seq, len(b.history))
+	}
+	if b.closed {
+		close(s.ch)
+		return past, s.ch, func() {}
+	}
+	b.subs[s] = struct{}{}
+	return past, s.ch, func() { b.unsubscribe(s) }
+}
+
+// Recent returns up to n of the most recent kept entries matching f,
+// oldest first.
+func (b *Broadcaster) Recent(f StreamFilter, n int) []StreamEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.recentLocked(f, 0, n)
+}
+
+// recentLocked walks the history from the newest entry back, collecting
+// up to n entries matching f above seq.
+func (b *Broadcaster) recentLocked(f StreamFilter, seq uint64, n int) []StreamEntry {
+	var out []StreamEntry
+	for i := 0; i < len(b.history) && len(out) < n; i++ {
+		se := b.history[(b.next+len(b.history)-1-i)%len(b.history)]
+		if se.Seq <= seq {
+			break
+		}
+		if f.Match(se.Entry) {
+			out = append(out, se)
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+func (b *Broadcaster) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Subscribers returns the number of live subscriptions.
+func (b *Broadcaster) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Stats implements StatsReporter.
+func (b *Broadcaster) Stats() Stats {
+	return Stats{Dropped: b.dropped.Load()}
+}
+
+// Close ends every subscription.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+	return nil
+}
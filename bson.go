@@ -0,0 +1,248 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// bsonDoc is an ordered BSON document used by the MongoDB sink.
+type bsonDoc []bsonElem
+
+type bsonElem struct {
+	Key   string
+	Value interface{}
+}
+
+// bsonBinary is a BSON generic binary value.
+type bsonBinary []byte
+
+var errBSON = errors.New("malformed BSON document")
+
+// appendBSON appends the encoding of doc to b.
+func appendBSON(b []byte, doc bsonDoc) []byte {
+	start := len(b)
+	b = append(b, 0, 0, 0, 0)
+	for _, el := range doc {
+		b = appendBSONValue(b, el.Key, el.Value)
+	}
+	b = append(b, 0)
+	binary.LittleEndian.PutUint32(b[start:], uint32(len(b)-start))
+	return b
+}
+
+func appendBSONKey(b []byte, kind byte, key string) []byte {
+	b = append(b, kind)
+	b = append(b, key...)
+	return append(b, 0)
+}
+
+func appendBSONString(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s)+1))
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+// appendBSONValue appends one element. Types without a BSON counterpart are
+// stored as their fmt representation.
+func appendBSONValue(b []byte, key string, v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return appendBSONKey(b, 0x0A, key)
+	case string:
+		return appendBSONString(appendBSONKey(b, 0x02, key), x)
+	case bool:
+		b = appendBSONKey(b, 0x08, key)
+		if x {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case int:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x12, key), uint64(x))
+	case int32:
+		return binary.LittleEndian.AppendUint32(appendBSONKey(b, 0x10, key), uint32(x))
+	case int64:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x12, key), uint64(x))
+	case uint32:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x12, key), uint64(x))
+	case uint64:
+		// BSON has no unsigned type; values beyond int64 keep their digits
+		// as a string rather than wrapping negative.
+		if x > math.MaxInt64 {
+			return appendBSONValue(b, key, strconv.FormatUint(x, 10))
+		}
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x12, key), x)
+	case uint:
+		return appendBSONValue(b, key, uint64(x))
+	case float32:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x01, key), math.Float64bits(float64(x)))
+	case float64:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x01, key), math.Float64bits(x))
+	case time.Time:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x09, key), uint64(x.UnixMilli()))
+	case time.Duration:
+		return binary.LittleEndian.AppendUint64(appendBSONKey(b, 0x12, key), uint64(x))
+	case bsonBinary:
+		b = appendBSONKey(b, 0x05, key)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(x)))
+		b = append(b, 0)
+		return append(b, x...)
+	case []byte:
+		return appendBSONValue(b, key, bsonBinary(x))
+	case bsonDoc:
+		return appendBSON(appendBSONKey(b, 0x03, key), x)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		doc := make(bsonDoc, 0, len(x))
+		for _, k := range keys {
+			doc = append(doc, bsonElem{k, x[k]})
+		}
+		return appendBSON(appendBSONKey(b, 0x03, key), doc)
+	case []interface{}:
+		doc := make(bsonDoc, len(x))
+		for i, el := range x {
+			doc[i] = bsonElem{fmt.Sprint(i), el}
+		}
+		return appendBSON(appendBSONKey(b, 0x04, key), doc)
+	case []bsonDoc:
+		doc := make(bsonDoc, len(x))
+		for i, el := range x {
+			doc[i] = bsonElem{fmt.Sprint(i), el}
+		}
+		return appendBSON(appendBSONKey(b, 0x04, key), doc)
+	case error:
+		return appendBSONValue(b, key, x.Error())
+	case fmt.Stringer:
+		return appendBSONValue(b, key, x.String())
+	default:
+		return appendBSONValue(b, key, fmt.Sprint(v))
+	}
+}
+
+// decodeBSON decodes a document into a map. Arrays decode to []interface{},
+// embedded documents to maps; unsupported types are an error.
+func decodeBSON(b []byte) (map[string]interface{}, error) {
+	if len(b) < 5 || int(binary.LittleEndian.Uint32(b)) != len(b) || b[len(b)-1] != 0 {
+		return nil, errBSON
+	}
+	m := make(map[string]interface{})
+	body := b[4 : len(b)-1]
+	for len(body) > 0 {
+		kind := body[0]
+		end := 1
+		for end < len(body) && body[end] != 0 {
+			end++
+		}
+		if end == len(body) {
+			return nil, errBSON
+		}
+		key := string(body[1:end])
+		v, n, err := decodeBSONValue(kind, body[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+		body = body[end+1+n:]
+	}
+	return m, nil
+}
+
+// decodeBSONValue decodes one value and returns it with its length.
+func decodeBSONValue(kind byte, b []byte) (interface{}, int, error) {
+	need := func(n int) error {
+		if len(b) < n {
+			return errBSON
+		}
+		return nil
+	}
+
+	switch kind {
+	case 0x01:
+		if err := need(8); err != nil {
+			return nil, 0, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), 8, nil
+	case 0x02:
+		if err := need(4); err != nil {
+			return nil, 0, err
+		}
+		n := int(binary.LittleEndian.Uint32(b))
+		if n < 1 || len(b) < 4+n {
+			return nil, 0, errBSON
+		}
+		return string(b[4 : 4+n-1]), 4 + n, nil
+	case 0x03, 0x04:
+		if err := need(4); err != nil {
+			return nil, 0, err
+		}
+		n := int(binary.LittleEndian.Uint32(b))
+		if n < 5 || len(b) < n {
+			return nil, 0, errBSON
+		}
+		doc, err := decodeBSON(b[:n])
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind == 0x03 {
+			return doc, n, nil
+		}
+		arr := make([]interface{}, len(doc))
+		for i := range arr {
+			arr[i] = doc[fmt.Sprint(i)]
+		}
+		return arr, n, nil
+	case 0x05:
+		if err := need(5); err != nil {
+			return nil, 0, err
+		}
+		n := int(binary.LittleEndian.Uint32(b))
+		if len(b) < 5+n {
+			return nil, 0, errBSON
+		}
+		return bsonBinary(append([]byte(nil), b[5:5+n]...)), 5 + n, nil
+	case 0x07:
+		if err := need(12); err != nil {
+			return nil, 0, err
+		}
+		return fmt.Sprintf("%x", b[:12]), 12, nil
+	case 0x08:
+		if err := need(1); err != nil {
+			return nil, 0, err
+		}
+		return b[0] != 0, 1, nil
+	case 0x09:
+		if err := need(8); err != nil {
+			return nil, 0, err
+		}
+		return time.UnixMilli(int64(binary.LittleEndian.Uint64(b))), 8, nil
+	case 0x0A:
+		return nil, 0, nil
+	case 0x10:
+		if err := need(4); err != nil {
+			return nil, 0, err
+		}
+		return int32(binary.LittleEndian.Uint32(b)), 4, nil
+	case 0x11, 0x12:
+		if err := need(8); err != nil {
+			return nil, 0, err
+		}
+		return int64(binary.LittleEndian.Uint64(b)), 8, nil
+	case 0x13:
+		if err := need(16); err != nil {
+			return nil, 0, err
+		}
+		return nil, 16, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unsupported type 0x%02x", errBSON, kind)
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBSONRoundTrip(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+	doc := bsonDoc{
+		{"s", "text"},
+		{"b", true},
+		{"i32", int32(-5)},
+		{"i64", int64(math.MinInt64)},
+		{"int", 42},
+		{"u32", uint32(math.MaxUint32)},
+		{"u64", uint64(math.MaxInt64)},
+		{"big", uint64(math.MaxUint64)},
+		{"f", 2.5},
+		{"t", ts},
+		{"d", time.Second},
+		{"nil", nil},
+		{"bin", bsonBinary{1, 2, 3}},
+		{"err", errors.New("boom")},
+		{"doc", bsonDoc{{"x", int32(1)}}},
+		{"map", map[string]interface{}{"k": "v"}},
+		{"arr", []interface{}{"a", int32(2)}},
+	}
+	want := map[string]interface{}{
+		"s":   "text",
+		"b":   true,
+		"i32": int32(-5),
+		"i64": int64(math.MinInt64),
+		"int": int64(42),
+		"u32": int64(math.MaxUint32),
+		"u64": int64(math.MaxInt64),
+		"big": "18446744073709551615",
+		"f":   2.5,
+		"d":   int64(time.Second),
+		"nil": nil,
+		"err": "boom",
+	}
+
+	got, err := decodeBSON(appendBSON(nil, doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, got[k], v)
+		}
+	}
+	if tm, _ := got["t"].(time.Time); !tm.Equal(ts) {
+		t.Errorf("t = %v, want %v", got["t"], ts)
+	}
+	if bin, _ := got["bin"].(bsonBinary); !bytes.Equal(bin, []byte{1, 2, 3}) {
+		t.Errorf("bin = %v", got["bin"])
+	}
+	if sub, _ := got["doc"].(map[string]interface{}); sub["x"] != int32(1) {
+		t.Errorf("doc = %v", got["doc"])
+	}
+	if sub, _ := got["map"].(map[string]interface{}); sub["k"] != "v" {
+		t.Errorf("map = %v", got["map"])
+	}
+	if arr, _ := got["arr"].([]interface{}); len(arr) != 2 || arr[0] != "a" || arr[1] != int32(2) {
+		t.Errorf("arr = %v", got["arr"])
+	}
+}
+
+func TestBSONEncoding(t *testing.T) {
+	// {"a": "b"} from the BSON specification examples.
+	want := []byte{0x0e, 0, 0, 0, 0x02, 'a', 0, 0x02, 0, 0, 0, 'b', 0, 0}
+	if got := appendBSON(nil, bsonDoc{{"a", "b"}}); !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+func TestDecodeBSONMalformed(t *testing.T) {
+	valid := appendBSON(nil, bsonDoc{{"a", "b"}, {"n", int32(1)}})
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty", nil},
+		{"short", valid[:4]},
+		{"length", append([]byte{0xff}, valid[1:]...)},
+		{"terminator", append(append([]byte(nil), valid[:len(valid)-1]...), 1)},
+		{"truncated", valid[:len(valid)-3]},
+		{"unsupported", []byte{0x08, 0, 0, 0, 0x7f, 'a', 0, 0}},
+	}
+	for _, tt := range tests {
+		if _, err := decodeBSON(tt.b); err == nil {
+			t.Errorf("%s: decoded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EntryBuilder collects the fields of an entry in a chain of calls ending
+// in Msg, Msgf or Send, e.g.
+//
+//	log.ErrorE().Str("user", u).Int("attempt", n).Err(err).Msg("login failed")
+//
+// When the level is disabled the chain runs on a nil builder whose methods
+// do nothing, so it costs no allocation. Builders are pooled: one must not
+// be used after the call ending its chain.
+type EntryBuilder struct {
+	l      *CustomLogger
+	level  LogLevel
+	fields []Field
+}
+
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return &EntryBuilder{fields: make([]Field, 0, 8)}
+	},
+}
+
+// LogE starts an entry at the given level, see EntryBuilder.
+func (l *CustomLogger) LogE(level LogLevel) *EntryBuilder {
+	if !l.enabled(level) {
+		return nil
+	}
+	b := builderPool.Get().(*EntryBuilder)
+	b.l, b.level = l, level
+	return b
+}
+
+// DebugE starts an entry at Debug, see EntryBuilder.
+func (l *CustomLogger) DebugE() *EntryBuilder {
+	return l.LogE(Debug)
+}
+
+// InfoE starts an entry at Info, see EntryBuilder.
+func (l *CustomLogger) InfoE() *EntryBuilder {
+	return l.LogE(Info)
+}
+
+// NoticeE starts an entry at Notice, see EntryBuilder.
+func (l *CustomLogger) NoticeE() *EntryBuilder {
+	return l.LogE(Notice)
+}
+
+// WarnE starts an entry at Warn, see EntryBuilder.
+func (l *CustomLogger) WarnE() *EntryBuilder {
+	return l.LogE(Warn)
+}
+
+// ErrorE starts an entry at Error, see EntryBuilder.
+func (l *CustomLogger) ErrorE() *EntryBuilder {
+	return l.LogE(Error)
+}
+
+// Enabled reports whether the entry will be written, for values worth
+// computing only then.
+func (b *EntryBuilder) Enabled() bool {
+	return b != nil
+}
+
+// Field adds f.
+func (b *EntryBuilder) Field(f Field) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, f)
+	}
+	return b
+}
+
+// Fields adds fs.
+func (b *EntryBuilder) Fields(fs ...Field) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, fs...)
+	}
+	return b
+}
+
+// Str adds a string field.
+func (b *EntryBuilder) Str(key, val string) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, String(key, val))
+	}
+	return b
+}
+
+// Int adds an int field.
+func (b *EntryBuilder) Int(key string, val int) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Int(key, val))
+	}
+	return b
+}
+
+// Int64 adds an int64 field.
+func (b *EntryBuilder) Int64(key string, val int64) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Int64(key, val))
+	}
+	return b
+}
+
+// Float adds a float64 field.
+func (b *EntryBuilder) Float(key string, val float64) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Float(key, val))
+	}
+	return b
+}
+
+// Bool adds a bool field.
+func (b *EntryBuilder) Bool(key string, val bool) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Bool(key, val))
+	}
+	return b
+}
+
+// Dur adds a duration field.
+func (b *EntryBuilder) Dur(key string, val time.Duration) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Duration(key, val))
+	}
+	return b
+}
+
+// Time adds a time field.
+func (b *EntryBuilder) Time(key string, val time.Time) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Time(key, val))
+	}
+	return b
+}
+
+// Err adds an "error" field holding err, unless err is nil.
+func (b *EntryBuilder) Err(err error) *EntryBuilder {
+	if b != nil && err != nil {
+		b.fields = append(b.fields, Err(err))
+	}
+	return b
+}
+
+// Any adds a field of any value, see Any.
+func (b *EntryBuilder) Any(key string, val interface{}) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Any(key, val))
+	}
+	return b
+}
+
+// Group adds a group of fields, see Group.
+func (b *EntryBuilder) Group(key string, fields ...Field) *EntryBuilder {
+	if b != nil {
+		b.fields = append(b.fields, Group(key, fields...))
+	}
+	return b
+}
+
+// Msg writes the entry with msg and ends the chain.
+func (b *EntryBuilder) Msg(msg string) {
+	if b == nil {
+		return
+	}
+	b.l.log(b.level, msg, b.fields...)
+	b.release()
+}
+
+// Msgf writes the entry with a formatted message and ends the chain. The
+// message is only formatted when the level is enabled.
+func (b *EntryBuilder) Msgf(format string, v ...interface{}) {
+	if b == nil {
+		return
+	}
+	b.Msg(fmt.Sprintf(format, v...))
+}
+
+// Send writes the entry without a message and ends the chain.
+func (b *EntryBuilder) Send() {
+	b.Msg("")
+}
+
+// release returns b to the pool, dropping the references it holds.
+func (b *EntryBuilder) release() {
+	clear(b.fields)
+	b.fields, b.l = b.fields[:0], nil
+	builderPool.Put(b)
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryBuilder(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}))
+	l.ErrorE().Str("user", "ann").Int("attempt", 3).Dur("after", time.Second).Err(errors.New("bad password")).Msg("login failed")
+	want := ` msg="login failed" user=ann attempt=3 after=1s error="bad password"` + "\n"
+	if !strings.HasSuffix(buf.String(), want) || !strings.Contains(buf.String(), "level=error") {
+		t.Errorf("output = %q", buf.String())
+	}
+
+	// A reused builder starts without the fields of the last entry.
+	buf.Reset()
+	l.InfoE().Err(nil).Msgf("%d users", 2)
+	if !strings.HasSuffix(buf.String(), ` msg="2 users"`+"\n") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestEntryBuilderDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Warn, &buf)
+	if b := l.DebugE(); b.Enabled() {
+		t.Error("Debug builder enabled at Warn")
+	}
+	l.InfoE().Str("user", "ann").Err(errors.New("e")).Msg("login failed")
+	if buf.Len() != 0 {
+		t.Errorf("output %q", buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CallerKey is the field holding the file and line of a log call.
+	CallerKey = "caller"
+
+	// loggerPackage prefixes the functions of this package, which are
+	// skipped when walking the stack of a log call.
+	loggerPackage = "peter-bird.com/logger."
+)
+
+// Caller returns a processor that tags entries with the file and line of
+// the log call, shortened to the file's directory, e.g. "db/pool.go:42".
+// Walking the stack costs a runtime.Callers call per entry.
+func Caller() Processor {
+	return ProcessorFunc(func(e *Entry) {
+		if c := caller(); c != "" {
+			e.AddFields(String(CallerKey, c))
+		}
+	})
+}
+
+// WithCaller tags every entry with the file and line of its log call, see
+// Caller.
+func WithCaller() Option {
+	return WithProcessors(Caller())
+}
+
+// caller returns the position of the first frame outside this package;
+// its tests count as callers.
+func caller() string {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, loggerPackage) || strings.HasSuffix(f.File, "_test.go") {
+			// Runtime paths use forward slashes on every platform.
+			dir, file := path.Split(f.File)
+			return path.Join(path.Base(dir), file) + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithCaller())
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("here")
+	want := "/caller_test.go:" + strconv.Itoa(line+1)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("got %q, want %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	chainField   = " chain="
+	anchorPrefix = "#anchor "
+
+	ChainErrFmt = "Hash chain broken at line %d: %s"
+)
+
+// Anchor is a periodic checkpoint of a hash chain. Publishing anchors to a
+// separate system makes truncation of the log detectable as well.
+type Anchor struct {
+	Seq  uint64
+	Hash []byte
+	Time time.Time
+}
+
+// ChainConfig configures a HashChainWriter.
+type ChainConfig struct {
+	// AnchorEvery writes an anchor record after this many entries; zero
+	// disables anchors.
+	AnchorEvery int
+	// OnAnchor, if set, is called with every anchor written.
+	OnAnchor func(Anchor)
+	// PrevHash and PrevSeq continue an existing chain, see ResumeHashChain.
+	PrevHash []byte
+	PrevSeq  uint64
+}
+
+// HashChainWriter makes a line based log tamper-evident. Each line gets a
+// " chain=<seq>:<hash>" suffix where hash is SHA-256 over the previous hash,
+// the sequence number and the line, so inserting, deleting or modifying a
+// line breaks every later link. VerifyHashChain checks a file.
+type HashChainWriter struct {
+	w    io.Writer
+	cfg  ChainConfig
+	prev [sha256.Size]byte
+	seq  uint64
+	out  bytes.Buffer
+}
+
+// NewHashChainWriter creates a HashChainWriter around w. It expects writes
+// to be serialized, as WriterSink does.
+func NewHashChainWriter(w io.Writer, cfg ChainConfig) *HashChainWriter {
+	cw := &HashChainWriter{w: w, cfg: cfg, seq: cfg.PrevSeq}
+	copy(cw.prev[:], cfg.PrevHash)
+	return cw
+}
+
+// Write implements io.Writer. Every complete line in p is chained.
+func (cw *HashChainWriter) Write(p []byte) (int, error) {
+	cw.out.Reset()
+	for rest := p; len(rest) > 0; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = nil
+		}
+
+		cw.seq++
+		cw.prev = chainHash(cw.prev[:], cw.seq, line)
+		cw.out.Write(line)
+		cw.out.WriteString(chainField)
+		cw.out.Write(strconv.AppendUint(cw.out.AvailableBuffer(), cw.seq, 10))
+		cw.out.WriteByte(':')
+		cw.out.WriteString(hex.EncodeToString(cw.prev[:]))
+		cw.out.WriteByte('\n')
+
+		if cw.cfg.AnchorEvery > 0 && cw.seq%uint64(cw.cfg.AnchorEvery) == 0 {
+			cw.writeAnchor()
+		}
+	}
+
+	if _, err := cw.w.Write(cw.out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (cw *HashChainWriter) writeAnchor() {
+	a := Anchor{Seq: cw.seq, Hash: append([]byte(nil), cw.prev[:]...), Time: time.Now()}
+	fmt.Fprintf(&cw.out, "%sseq=%d hash=%x time=%s\n", anchorPrefix, a.Seq, a.Hash, a.Time.UTC().Format(time.RFC3339))
+	if cw.cfg.OnAnchor != nil {
+		cw.cfg.OnAnchor(a)
+	}
+}
+
+// Flush flushes the underlying writer if it buffers data.
+func (cw *HashChainWriter) Flush() error {
+	if f, ok := cw.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func chainHash(prev []byte, seq uint64, line []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(prev)
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], seq)
+	h.Write(n[:])
+	h.Write(line)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// parseChainLine splits a chained line into content, sequence and hash.
+func parseChainLine(line []byte) (content []byte, seq uint64, hash []byte, ok bool) {
+	i := bytes.LastIndex(line, []byte(chainField))
+	if i < 0 {
+		return nil, 0, nil, false
+	}
+	tail := line[i+len(chainField):]
+	j := bytes.IndexByte(tail, ':')
+	if j < 0 {
+		return nil, 0, nil, false
+	}
+	seq, err := strconv.ParseUint(string(tail[:j]), 10, 64)
+	if err != nil {
+		return nil, 0, nil, false
+	}
+	hash, err = hex.DecodeString(string(tail[j+1:]))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, 0, nil, false
+	}
+	return line[:i], seq, hash, true
+}
+
+// ChainReport summarizes a verified hash chain.
+type ChainReport struct {
+	Entries  int
+	Anchors  int
+	FirstSeq uint64
+	LastSeq  uint64
+	LastHash []byte
+}
+
+// VerifyHashChain checks every link of a chained log. With a nil prevHash
+// the first line is trusted as the starting point, which allows verifying
+// a rotated file on its own; pass the last hash of the previous file to
+// verify across files. Any insertion, deletion or modification is reported
+// as an error naming the first offending line.
+func VerifyHashChain(r io.Reader, prevHash []byte, prevSeq uint64) (ChainReport, error) {
+	var rep ChainReport
+	var prev []byte
+	if prevHash != nil {
+		prev = append([]byte(nil), prevHash...)
+	}
+	seq := prevSeq
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxChunkSize)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		if bytes.HasPrefix(line, []byte(anchorPrefix)) {
+			var aseq uint64
+			var ahash string
+			if _, err := fmt.Sscanf(string(line[len(anchorPrefix):]), "seq=%d hash=%s", &aseq, &ahash); err != nil {
+				return rep, fmt.Errorf(ChainErrFmt, n, "malformed anchor")
+			}
+			if aseq != seq || ahash != hex.EncodeToString(prev) {
+				return rep, fmt.Errorf(ChainErrFmt, n, "anchor does not match chain")
+			}
+			rep.Anchors++
+			continue
+		}
+
+		content, lseq, hash, ok := parseChainLine(line)
+		if !ok {
+			return rep, fmt.Errorf(ChainErrFmt, n, "missing chain field")
+		}
+		if prev == nil {
+			prev, seq = hash, lseq
+			rep.FirstSeq = lseq
+		} else {
+			if lseq != seq+1 {
+				return rep, fmt.Errorf(ChainErrFmt, n, fmt.Sprintf("sequence %d follows %d", lseq, seq))
+			}
+			want := chainHash(prev, lseq, content)
+			if !bytes.Equal(want[:], hash) {
+				return rep, fmt.Errorf(ChainErrFmt, n, "hash mismatch")
+			}
+			if rep.Entries == 0 {
+				rep.FirstSeq = lseq
+			}
+			prev, seq = hash, lseq
+		}
+		rep.Entries++
+	}
+	if err := sc.Err(); err != nil {
+		return rep, err
+	}
+
+	rep.LastSeq = seq
+	rep.LastHash = prev
+	return rep, nil
+}
+
+// ResumeHashChain returns the last hash and sequence number found in the
+// chained log at path, so a restarted process can continue the chain. A
+// missing or empty file starts a new chain. Gzipped files (.gz) are read
+// decompressed.
+func ResumeHashChain(path string) ([]byte, uint64, error) {
+	return resumeChainFile(path, nil)
+}
+
+// ResumeEncryptedHashChain is ResumeHashChain for a log written with
+// WithEncryption. A final chunk is not required, since the file is
+// usually still open.
+func ResumeEncryptedHashChain(path string, key []byte) ([]byte, uint64, error) {
+	return resumeChainFile(path, key)
+}
+
+func resumeChainFile(path string, key []byte) ([]byte, uint64, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err == io.EOF {
+			return nil, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		r = zr
+	}
+	if key != nil {
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, 0, err
+		}
+		r = &decryptingReader{r: bufio.NewReader(r), aead: aead, lenient: true}
+	}
+
+	var hash []byte
+	var seq uint64
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxChunkSize)
+	for sc.Scan() {
+		if _, s, h, ok := parseChainLine(sc.Bytes()); ok {
+			hash, seq = h, s
+		}
+	}
+	return hash, seq, sc.Err()
+}
+
+// resumeFileChain finds where the chain of the logger's file left off: in
+// the current file or, if that holds no chain yet because it was just
+// rotated, in the newest archive.
+func resumeFileChain(lf *File) ([]byte, uint64, error) {
+	key := lf.cfg.EncryptionKey
+	if len(key) == 0 {
+		key = nil
+	}
+	current := lf.CurrentPath()
+	hash, seq, err := resumeChainFile(current, key)
+	if err != nil || hash != nil || !lf.cfg.Rotation.enabled() {
+		return hash, seq, err
+	}
+	if archive := latestArchive(lf.path, lf.cfg.Rotation.ArchiveTemplate, current); archive != "" {
+		return resumeChainFile(archive, key)
+	}
+	return nil, 0, nil
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCircuitFailures = 5
+	DefaultCircuitCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker that is open and has no
+// fallback. It is not permanent: the batch may be sent once the circuit
+// closes again.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed passes batches to the sender.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen diverts batches without calling the sender.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe batch through to the sender.
+	CircuitHalfOpen
+)
+
+// String returns the lower-case state name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitConfig configures a CircuitBreaker.
+type CircuitConfig struct {
+	// Failures opens the circuit after this many failed batches in a row;
+	// zero selects DefaultCircuitFailures.
+	Failures int
+	// Cooldown is how long the circuit stays open before a probe; zero
+	// selects DefaultCircuitCooldown.
+	Cooldown time.Duration
+	// Fallback, if set, receives the batches diverted while the circuit is
+	// open, e.g. a BatchSenderFunc spilling them to a DeadLetter.
+	Fallback BatchSender
+	// OnStateChange, if set, is called on every transition.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker is a BatchSender that stops calling a failing sender.
+// After Failures failed batches in a row it opens and diverts batches to
+// the fallback for Cooldown, then lets one batch through as a probe: if it
+// is delivered the circuit closes, otherwise it opens for another
+// Cooldown. A dead collector then costs one attempt per cooldown instead
+// of a retry loop per batch. Errors marked Permanent mean the collector
+// answered and do not count as failures.
+//
+// Wrap the retrying sender, WithCircuitBreaker(WithRetry(s, p), cfg), so an
+// open circuit is not retried.
+type CircuitBreaker struct {
+	sender BatchSender
+	cfg    CircuitConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	opened   time.Time
+	probing  bool
+}
+
+// WithCircuitBreaker wraps sender in a CircuitBreaker.
+func WithCircuitBreaker(sender BatchSender, cfg CircuitConfig) *CircuitBreaker {
+	if cfg.Failures <= 0 {
+		cfg.Failures = DefaultCircuitFailures
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCircuitCooldown
+	}
+	return &CircuitBreaker{sender: sender, cfg: cfg, now: time.Now}
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Acknowledges implements Acknowledger for the wrapped sender; batches
+// accepted by the fallback count as delivered.
+func (b *CircuitBreaker) Acknowledges() bool {
+	return acknowledges(b.sender)
+}
+
+// SendBatch implements BatchSender.
+func (b *CircuitBreaker) SendBatch(ctx context.Context, batch []*Entry) error {
+	if !b.allow() {
+		if b.cfg.Fallback != nil {
+			return b.cfg.Fallback.SendBatch(ctx, batch)
+		}
+		return ErrCircuitOpen
+	}
+	err := b.sender.SendBatch(ctx, batch)
+	if b.record(err) && b.cfg.Fallback != nil {
+		// The batch that opened the circuit goes where the next ones will.
+		return b.cfg.Fallback.SendBatch(ctx, batch)
+	}
+	return err
+}
+
+// allow reports whether a batch may go to the sender, turning an open
+// circuit half-open once the cooldown passed.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	from := b.state
+	ok := true
+	switch b.state {
+	case CircuitOpen:
+		if ok = b.now().Sub(b.opened) >= b.cfg.Cooldown; ok {
+			b.state, b.probing = CircuitHalfOpen, true
+		}
+	case CircuitHalfOpen:
+		ok = !b.probing
+		b.probing = true
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+	return ok
+}
+
+// record counts the outcome of a batch and reports whether it opened the
+// circuit.
+func (b *CircuitBreaker) record(err error) bool {
+	b.mu.Lock()
+	from := b.state
+	b.probing = false
+	if err == nil || IsPermanent(err) {
+		b.failures = 0
+		b.state = CircuitClosed
+	} else if b.failures++; b.state == CircuitHalfOpen || b.failures >= b.cfg.Failures {
+		b.opened = b.now()
+		b.state = CircuitOpen
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.changed(from, to)
+	return to == CircuitOpen
+}
+
+// changed calls OnStateChange for a transition.
+func (b *CircuitBreaker) changed(from, to CircuitState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
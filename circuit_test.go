@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	calls := 0
+	var fail error = errors.New("connection refused")
+	sender := BatchSenderFunc(func(context.Context, []*Entry) error {
+		calls++
+		return fail
+	})
+	var diverted int
+	var changes []string
+	now := testTime
+	b := WithCircuitBreaker(sender, CircuitConfig{
+		Failures: 2,
+		Cooldown: time.Minute,
+		Fallback: BatchSenderFunc(func(_ context.Context, batch []*Entry) error {
+			diverted += len(batch)
+			return nil
+		}),
+		OnStateChange: func(from, to CircuitState) { changes = append(changes, from.String()+">"+to.String()) },
+	})
+	b.now = func() time.Time { return now }
+	batch := []*Entry{{Message: "m"}}
+	ctx := context.Background()
+
+	if err := b.SendBatch(ctx, batch); err != fail || b.State() != CircuitClosed {
+		t.Fatalf("first failure: %v, %v", err, b.State())
+	}
+	// The second failure opens the circuit and its batch is diverted.
+	if err := b.SendBatch(ctx, batch); err != nil || b.State() != CircuitOpen || diverted != 1 {
+		t.Fatalf("second failure: %v, %v, diverted %d", err, b.State(), diverted)
+	}
+	for i := 0; i < 10; i++ {
+		b.SendBatch(ctx, batch)
+	}
+	if calls != 2 || diverted != 11 {
+		t.Errorf("open circuit: %d calls, %d diverted", calls, diverted)
+	}
+
+	// A failed probe opens it for another cooldown.
+	now = now.Add(time.Minute)
+	b.SendBatch(ctx, batch)
+	if calls != 3 || b.State() != CircuitOpen {
+		t.Errorf("failed probe: %d calls, %v", calls, b.State())
+	}
+	now = now.Add(30 * time.Second)
+	b.SendBatch(ctx, batch)
+	if calls != 3 {
+		t.Errorf("probed before the cooldown")
+	}
+
+	fail = nil
+	now = now.Add(30 * time.Second)
+	if err := b.SendBatch(ctx, batch); err != nil || b.State() != CircuitClosed || calls != 4 {
+		t.Errorf("probe: %v, %v, %d calls", err, b.State(), calls)
+	}
+	want := "closed>open open>half-open half-open>open open>half-open half-open>closed"
+	if got := strings.Join(changes, " "); got != want {
+		t.Errorf("transitions %s\nwant %s", got, want)
+	}
+}
+
+func TestCircuitBreakerPermanent(t *testing.T) {
+	rejected := Permanent(errors.New("400 Bad Request"))
+	b := WithCircuitBreaker(BatchSenderFunc(func(context.Context, []*Entry) error { return rejected }), CircuitConfig{Failures: 1})
+	for i := 0; i < 3; i++ {
+		if err := b.SendBatch(context.Background(), nil); err != rejected {
+			t.Fatalf("got %v", err)
+		}
+	}
+	if b.State() != CircuitClosed {
+		t.Errorf("permanent errors opened the circuit")
+	}
+
+	b = WithCircuitBreaker(BatchSenderFunc(func(context.Context, []*Entry) error { return errors.New("down") }), CircuitConfig{Failures: 1})
+	b.SendBatch(context.Background(), nil)
+	if err := b.SendBatch(context.Background(), nil); err != ErrCircuitOpen || IsPermanent(err) {
+		t.Errorf("open circuit without fallback: %v", err)
+	}
+}
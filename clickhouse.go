@@ -0,0 +1,111 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ClickHouseConfig configures a ClickHouseSender.
+type ClickHouseConfig struct {
+	// URL is the HTTP interface, e.g. "http://localhost:8123".
+	URL string
+	// Table receives the entries, optionally qualified by database.
+	Table    string
+	Username string
+	Password string
+	// CreateTable creates a MergeTree table ordered by time if missing.
+	CreateTable bool
+	// Compression, if set, compresses request bodies, e.g. Gzip;
+	// ClickHouse also accepts zstd when a zstd Compressor is supplied.
+	Compression Compressor
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// ClickHouseSender is a BatchSender that inserts each batch with a single
+// INSERT ... FORMAT JSONEachRow over the ClickHouse HTTP interface. Use it
+// behind a BatchSink with large batches; ClickHouse favours few big
+// inserts over many small ones.
+type ClickHouseSender struct {
+	cfg ClickHouseConfig
+}
+
+const clickHouseSchema = `CREATE TABLE IF NOT EXISTS %s (
+	ts DateTime64(9, 'UTC'),
+	level LowCardinality(String),
+	name LowCardinality(String),
+	message String,
+	fields String
+) ENGINE = MergeTree ORDER BY ts`
+
+// clickHouseRow is one JSONEachRow line.
+type clickHouseRow struct {
+	TS      string `json:"ts"`
+	Level   string `json:"level"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Fields  string `json:"fields"`
+}
+
+// NewClickHouseSender validates the configuration and creates the table
+// if requested.
+func NewClickHouseSender(ctx context.Context, cfg ClickHouseConfig) (*ClickHouseSender, error) {
+	if !tableNameRE.MatchString(cfg.Table) {
+		return nil, fmt.Errorf(SQLTableErrFmt, cfg.Table)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	s := &ClickHouseSender{cfg: cfg}
+	if cfg.CreateTable {
+		if err := s.exec(ctx, fmt.Sprintf(clickHouseSchema, cfg.Table), nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SendBatch implements BatchSender.
+func (s *ClickHouseSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	// Not pooled: the transport may read the body after Do returns.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range batch {
+		fields, err := json.Marshal(e.FieldMap())
+		if err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		err = enc.Encode(clickHouseRow{
+			TS:      e.Time.UTC().Format("2006-01-02 15:04:05.000000000"),
+			Level:   e.Level.String(),
+			Name:    e.Name,
+			Message: e.Message,
+			Fields:  string(fields),
+		})
+		if err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+	}
+	return s.exec(ctx, "INSERT INTO "+s.cfg.Table+" FORMAT JSONEachRow", buf.Bytes(), s.cfg.Compression)
+}
+
+// exec runs query, streaming data after it in the request body.
+func (s *ClickHouseSender) exec(ctx context.Context, query string, data []byte, c Compressor) error {
+	u := strings.TrimSuffix(s.cfg.URL, "/") + "/?query=" + url.QueryEscape(query)
+	req, err := newPostRequest(ctx, u, data, c)
+	if err != nil {
+		return err
+	}
+	if s.cfg.Username != "" {
+		req.Header.Set("X-ClickHouse-User", s.cfg.Username)
+		req.Header.Set("X-ClickHouse-Key", s.cfg.Password)
+	}
+	return doHTTP(s.cfg.Client, req)
+}
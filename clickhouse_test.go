@@ -0,0 +1,64 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClickHouseSender(t *testing.T) {
+	var queries []string
+	var rows []clickHouseRow
+	var user, key string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		user, key = r.Header.Get("X-ClickHouse-User"), r.Header.Get("X-ClickHouse-Key")
+		dec := json.NewDecoder(r.Body)
+		for {
+			var row clickHouseRow
+			if dec.Decode(&row) != nil {
+				break
+			}
+			rows = append(rows, row)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClickHouseSender(context.Background(), ClickHouseConfig{URL: srv.URL, Table: "logs x"}); err == nil {
+		t.Error("accepted an invalid table name")
+	}
+	s, err := NewClickHouseSender(context.Background(), ClickHouseConfig{
+		URL: srv.URL + "/", Table: "db.logs", Username: "u", Password: "p", CreateTable: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{Time: testTime, Level: Error, Name: "api", Message: "boom", Fields: []Field{Int("n", 1)}}
+	if err := s.SendBatch(context.Background(), []*Entry{e, e}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || !strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS db.logs (") ||
+		queries[1] != "INSERT INTO db.logs FORMAT JSONEachRow" || user != "u" || key != "p" {
+		t.Fatalf("queries %q as %q/%q", queries, user, key)
+	}
+	want := clickHouseRow{TS: "2024-03-01 12:30:45.000000000", Level: "ERROR", Name: "api", Message: "boom", Fields: `{"n":1}`}
+	if len(rows) != 2 || rows[1] != want {
+		t.Errorf("rows %+v", rows)
+	}
+
+	status = http.StatusBadRequest
+	if err := s.SendBatch(context.Background(), []*Entry{e}); !IsPermanent(err) {
+		t.Errorf("400: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := s.SendBatch(context.Background(), []*Entry{e}); err == nil || IsPermanent(err) {
+		t.Errorf("503: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const CloseTimeoutErrFmt = "Logger closed with %d entries undelivered: %w"
+
+// Close stops accepting entries, drains asynchronous queues and pending
+// batches, and then closes the sinks, the output buffer, the log file or
+// the writer given to WithWriter, and the audit and event outputs.
+// If ctx ends first, Close returns an error reporting how many entries were
+// still queued; shutdown then continues in the background. Entries logged
+// after Close are discarded and counted in Stats.Closed. Calling Close again returns nil.
+func (l *CustomLogger) Close(ctx context.Context) error {
+	if !l.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if l.diskGuard != nil {
+		l.diskGuard.close()
+	}
+	if l.signals != nil {
+		l.signals.close()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.closeAll() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		var pending int
+		for _, s := range l.sinks {
+			if r, ok := s.(HealthReporter); ok {
+				pending += r.Health().QueueDepth
+			}
+		}
+		return fmt.Errorf(CloseTimeoutErrFmt, pending, ctx.Err())
+	}
+}
+
+// closeAll closes the pipeline from the sinks down to the file, so that
+// everything a sink drains still reaches the output.
+func (l *CustomLogger) closeAll() error {
+	var errs []error
+	for _, s := range l.sinks {
+		if c, ok := s.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		} else {
+			errs = append(errs, flushSink(s))
+		}
+	}
+	if l.buffered != nil {
+		errs = append(errs, l.buffered.Close())
+	}
+	if l.file != nil {
+		errs = append(errs, l.file.Close())
+	}
+	if l.backend != nil {
+		errs = append(errs, l.backend.Close())
+	}
+	for _, c := range []*channel{l.audit, l.events} {
+		if c != nil {
+			errs = append(errs, c.close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush writes out everything buffered: it waits for asynchronous queues,
+// sends pending batches, flushes the output buffer and syncs the log file
+// to stable storage. Use it before risky operations and in crash handlers.
+func (l *CustomLogger) Flush() error {
+	var errs []error
+	for _, s := range l.sinks {
+		errs = append(errs, flushSink(s))
+	}
+	if l.file != nil {
+		errs = append(errs, l.file.Sync())
+	}
+	if l.backend != nil {
+		errs = append(errs, l.syncBackend())
+	}
+	for _, c := range []*channel{l.audit, l.events} {
+		if c != nil {
+			errs = append(errs, c.flush())
+		}
+	}
+	return errors.Join(errs...)
+}
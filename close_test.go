@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	gate := &gateSink{open: make(chan struct{})}
+	async := NewAsyncSink(gate, AsyncConfig{})
+	sender := &recordSender{}
+	l, err := New(Info, "test", path,
+		WithBufferedOutput(BufferConfig{FlushInterval: time.Hour}),
+		WithSinks(async, NewBatchSink(sender, BatchConfig{FlushInterval: time.Hour})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+
+	l.Info("pending")
+	if b, _ := os.ReadFile(path); len(b) != 0 || sender.total.Load() != 0 {
+		t.Fatalf("written before Flush: %q, %d sent", b, sender.total.Load())
+	}
+	// Flush waits for the queued entry to be written.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(gate.open)
+	}()
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "pending") {
+		t.Errorf("file %q after Flush", b)
+	}
+	if gate.n.Load() != 1 || sender.total.Load() != 1 {
+		t.Errorf("%d written, %d sent after Flush", gate.n.Load(), sender.total.Load())
+	}
+}
+
+func TestClose(t *testing.T) {
+	gate := &gateSink{open: make(chan struct{})}
+	l, err := New(Info, "test", "", WithOutputLevel(Emergency+1), WithSinks(NewAsyncSink(gate, AsyncConfig{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("one")
+	l.Info("two")
+
+	// A context ending first reports the entries still queued.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = l.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "entries undelivered") {
+		t.Errorf("Close: %v", err)
+	}
+	if err := l.Close(context.Background()); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	l.Info("late")
+	if st := l.Stats(); st.Closed != 1 {
+		t.Errorf("stats %+v", st)
+	}
+	close(gate.open)
+}
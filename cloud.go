@@ -0,0 +1,172 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudKey is the group holding the cloud instance fields.
+	CloudKey = "cloud"
+	// DefaultCloudTimeout bounds the metadata queries of CloudFields.
+	DefaultCloudTimeout = 2 * time.Second
+)
+
+// cloudMetadataURL is the link-local metadata service of EC2, GCE and
+// Azure; tests point it elsewhere.
+var cloudMetadataURL = "http://169.254.169.254"
+
+// cloudInstance is what a metadata service reports about the instance.
+type cloudInstance struct {
+	provider, id, region, zone string
+}
+
+// CloudFields returns a cloud group with the provider ("aws", "gcp" or
+// "azure"), instance ID, region and availability zone of the instance the
+// process runs on, or nil if no metadata service answers before ctx is
+// done. The three services are queried concurrently: EC2 through IMDSv2,
+// falling back to IMDSv1, GCE with the Metadata-Flavor header and Azure
+// through the instance metadata service.
+func CloudFields(ctx context.Context) []Field {
+	client := &http.Client{
+		// The metadata services answer directly; a proxy from the
+		// environment would only delay the failure outside a cloud.
+		Transport: &http.Transport{Proxy: nil},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer client.CloseIdleConnections()
+	queries := []func(context.Context, *http.Client) (cloudInstance, bool){queryEC2, queryGCE, queryAzure}
+	results := make(chan cloudInstance, len(queries))
+	for _, q := range queries {
+		go func(q func(context.Context, *http.Client) (cloudInstance, bool)) {
+			inst, _ := q(ctx, client)
+			results <- inst
+		}(q)
+	}
+	var inst cloudInstance
+	for range queries {
+		if r := <-results; r.provider != "" && inst.provider == "" {
+			inst = r
+		}
+	}
+	if inst.provider == "" {
+		return nil
+	}
+
+	fields := []Field{String("provider", inst.provider)}
+	for _, f := range []Field{
+		String("instance_id", inst.id),
+		String("region", inst.region),
+		String("zone", inst.zone),
+	} {
+		if f.String != "" {
+			fields = append(fields, f)
+		}
+	}
+	return []Field{Group(CloudKey, fields...)}
+}
+
+// WithCloudMetadata attaches CloudFields, queried once within
+// DefaultCloudTimeout, to every entry. Outside a cloud it adds nothing
+// but the startup delay of the timeout.
+func WithCloudMetadata() Option {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCloudTimeout)
+	fields := CloudFields(ctx)
+	cancel()
+	return func(l *CustomLogger) {
+		if fields != nil {
+			l.processors = append(l.processors, StaticFields(fields...))
+		}
+	}
+}
+
+// cloudGet performs a metadata request and returns the body of a 200
+// response, at most 64 KiB.
+func cloudGet(ctx context.Context, client *http.Client, method, path string, header http.Header) ([]byte, http.Header, bool) {
+	req, err := http.NewRequestWithContext(ctx, method, cloudMetadataURL+path, nil)
+	if err != nil {
+		return nil, nil, false
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, false
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, nil, false
+	}
+	return body, resp.Header, true
+}
+
+func queryEC2(ctx context.Context, client *http.Client) (cloudInstance, bool) {
+	header := make(http.Header)
+	token, _, ok := cloudGet(ctx, client, http.MethodPut, "/latest/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"60"}})
+	if ok {
+		header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+	}
+	body, _, ok := cloudGet(ctx, client, http.MethodGet, "/latest/dynamic/instance-identity/document", header)
+	if !ok {
+		return cloudInstance{}, false
+	}
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.InstanceID == "" {
+		return cloudInstance{}, false
+	}
+	return cloudInstance{"aws", doc.InstanceID, doc.Region, doc.AvailabilityZone}, true
+}
+
+func queryGCE(ctx context.Context, client *http.Client) (cloudInstance, bool) {
+	header := http.Header{"Metadata-Flavor": {"Google"}}
+	body, h, ok := cloudGet(ctx, client, http.MethodGet, "/computeMetadata/v1/instance/?recursive=true", header)
+	if !ok || h.Get("Metadata-Flavor") != "Google" {
+		return cloudInstance{}, false
+	}
+	var doc struct {
+		ID   json.Number `json:"id"`
+		Zone string      `json:"zone"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.ID == "" {
+		return cloudInstance{}, false
+	}
+	// The zone is given as projects/<number>/zones/<zone>.
+	zone := doc.Zone[strings.LastIndexByte(doc.Zone, '/')+1:]
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+	return cloudInstance{"gcp", doc.ID.String(), region, zone}, true
+}
+
+func queryAzure(ctx context.Context, client *http.Client) (cloudInstance, bool) {
+	body, _, ok := cloudGet(ctx, client, http.MethodGet, "/metadata/instance/compute?api-version=2021-02-01&format=json",
+		http.Header{"Metadata": {"true"}})
+	if !ok {
+		return cloudInstance{}, false
+	}
+	var doc struct {
+		VMID     string `json:"vmId"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+	}
+	if json.Unmarshal(body, &doc) != nil || doc.VMID == "" {
+		return cloudInstance{}, false
+	}
+	return cloudInstance{"azure", doc.VMID, doc.Location, doc.Zone}, true
+}
@@ -0,0 +1,79 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeMetadata serves the metadata endpoints of one provider.
+func fakeMetadata(provider string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case provider == "aws" && r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("tok"))
+		case provider == "aws" && r.URL.Path == "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-Aws-Ec2-Metadata-Token") != "tok" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"instanceId":"i-123","region":"eu-west-1","availabilityZone":"eu-west-1b"}`))
+		case provider == "gcp" && r.URL.Path == "/computeMetadata/v1/instance/":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Metadata-Flavor", "Google")
+			w.Write([]byte(`{"id":4520031799277581759,"zone":"projects/42/zones/us-central1-a"}`))
+		case provider == "azure" && r.URL.Path == "/metadata/instance/compute":
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("api-version") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"vmId":"02aab8a4","location":"westeurope","zone":"2"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestCloudFields(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     map[string]interface{}
+	}{
+		{"aws", map[string]interface{}{"provider": "aws", "instance_id": "i-123", "region": "eu-west-1", "zone": "eu-west-1b"}},
+		{"gcp", map[string]interface{}{"provider": "gcp", "instance_id": "4520031799277581759", "region": "us-central1", "zone": "us-central1-a"}},
+		{"azure", map[string]interface{}{"provider": "azure", "instance_id": "02aab8a4", "region": "westeurope", "zone": "2"}},
+		{"none", nil},
+	}
+	defer func(u string) { cloudMetadataURL = u }(cloudMetadataURL)
+	for _, tt := range tests {
+		srv := httptest.NewServer(fakeMetadata(tt.provider))
+		cloudMetadataURL = srv.URL
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		fields := CloudFields(ctx)
+		cancel()
+		srv.Close()
+
+		if tt.want == nil {
+			if fields != nil {
+				t.Errorf("%s: got %v", tt.provider, fields)
+			}
+			continue
+		}
+		if len(fields) != 1 || fields[0].Key != CloudKey {
+			t.Fatalf("%s: got %v", tt.provider, fields)
+		}
+		got, _ := fields[0].Interface().(map[string]interface{})
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("%s: %s = %v, want %v", tt.provider, k, got[k], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+//go:build !logger_minimal
+
+/*
+   logconvert re-encodes log files between the formats of this package:
+   text, logfmt, JSON and GELF (one document per line) and MessagePack, so
+   archived logs can be re-ingested by tools needing a specific one, and
+   writes length-prefixed protobuf records, see logger.ProtobufSchema. The
+   input format is detected unless given. Lines that are no entry in the input
+   format are left out and counted on stderr, except that lines after a
+   text entry continue its message, see package parse.
+
+   Usage:
+
+	logconvert -to json [-from auto|text|logfmt|json|gelf|msgpack] [-tz UTC] [app.log ...] > app.json
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+const (
+	UsageErrFmt   = "logconvert: %s\n"
+	ConvertErrFmt = "logconvert: %s: %s\n"
+	SkippedFmt    = "logconvert: skipped %d lines that are no entries\n"
+)
+
+// formats are the input formats by name.
+var formats = map[string]parse.Format{
+	"auto":    parse.Auto,
+	"text":    parse.Text,
+	"logfmt":  parse.Logfmt,
+	"json":    parse.JSON,
+	"gelf":    parse.GELF,
+	"msgpack": parse.Msgpack,
+}
+
+var encoders = map[string]logger.Encoder{
+	"text":     logger.TextEncoder{},
+	"logfmt":   logger.LogfmtEncoder{},
+	"json":     logger.JSONEncoder{},
+	"gelf":     logger.NewGELFEncoder(""),
+	"msgpack":  logger.MsgpackEncoder{},
+	"protobuf": logger.ProtobufEncoder{},
+}
+
+func main() {
+	from := flag.String("from", "auto", "input format: auto, text, logfmt, json, gelf or msgpack")
+	to := flag.String("to", "", "output format: text, logfmt, json, gelf, msgpack or protobuf")
+	tz := flag.String("tz", "Local", "time zone of text timestamps, which carry none")
+	flag.Parse()
+
+	format, ok := formats[*from]
+	c := &converter{enc: encoders[*to]}
+	loc, err := time.LoadLocation(*tz)
+	switch {
+	case err != nil:
+	case !ok:
+		err = fmt.Errorf("unknown input format %q", *from)
+	case c.enc == nil:
+		err = fmt.Errorf("unknown output format %q, use -to", *to)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(2)
+	}
+	c.cfg = parse.Config{Format: format, Location: loc}
+
+	out := bufio.NewWriter(os.Stdout)
+	status := 0
+	if flag.NArg() == 0 {
+		if err := c.convert(out, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, ConvertErrFmt, "stdin", err)
+			status = 1
+		}
+	}
+	for _, path := range flag.Args() {
+		if err := c.convertFile(out, path); err != nil {
+			fmt.Fprintf(os.Stderr, ConvertErrFmt, path, err)
+			status = 1
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		status = 1
+	}
+	if c.skipped > 0 {
+		fmt.Fprintf(os.Stderr, SkippedFmt, c.skipped)
+	}
+	os.Exit(status)
+}
+
+type converter struct {
+	cfg     parse.Config
+	enc     logger.Encoder
+	buf     bytes.Buffer
+	skipped int
+}
+
+func (c *converter) convertFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.convert(w, f)
+}
+
+func (c *converter) convert(w io.Writer, r io.Reader) error {
+	pr := parse.NewReader(r, c.cfg)
+	defer func() { c.skipped += pr.Skipped() }()
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.buf.Reset()
+		if err := c.enc.Encode(&c.buf, e); err != nil {
+			return err
+		}
+		// GELF documents have no delimiter of their own; the binary
+		// formats need none.
+		switch c.enc.(type) {
+		case logger.MsgpackEncoder, logger.ProtobufEncoder:
+		default:
+			if b := c.buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' {
+				c.buf.WriteByte('\n')
+			}
+		}
+		if _, err := w.Write(c.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
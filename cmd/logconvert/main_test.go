@@ -0,0 +1,78 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+func TestConvert(t *testing.T) {
+	input := "stray line\n" +
+		"app WARN : 2024/03/01 12:30:45 disk low free=3\n" +
+		`time=2024-03-01T12:30:46Z level=info logger=app msg=up` + "\n" +
+		`{"time":"2024-03-01T12:30:47Z","level":"ERROR","name":"app","msg":"failed","fields":{"code":7}}` + "\n" +
+		`{"version":"1.1","host":"h","short_message":"gelf","timestamp":1709296248,"level":4,"_logger":"gw"}` + "\n"
+	want := `time=2024-03-01T12:30:45Z level=warn logger=app msg="disk low" free=3` + "\n" +
+		`time=2024-03-01T12:30:46Z level=info logger=app msg=up` + "\n" +
+		`time=2024-03-01T12:30:47Z level=error logger=app msg=failed code=7` + "\n" +
+		`time=2024-03-01T12:30:48Z level=warn logger=gw msg=gelf` + "\n"
+
+	c := &converter{cfg: parse.Config{Location: time.UTC}, enc: logger.LogfmtEncoder{}}
+	var out bytes.Buffer
+	if err := c.convert(&out, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if c.skipped != 1 {
+		t.Errorf("skipped %d lines, want 1", c.skipped)
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	input := `{"time":"2024-03-01T12:30:45Z","level":"NOTICE","name":"app","msg":"a b","fields":{"n":1,"s":"x y"}}` + "\n"
+	var mid, out bytes.Buffer
+	(&converter{cfg: parse.Config{Format: parse.JSON}, enc: encoders["gelf"]}).convert(&mid, strings.NewReader(input))
+	(&converter{cfg: parse.Config{Format: parse.GELF}, enc: encoders["json"]}).convert(&out, &mid)
+	if out.String() != input {
+		t.Errorf("JSON → GELF → JSON:\n got %s\nwant %s", out.String(), input)
+	}
+}
+
+func TestConvertMsgpack(t *testing.T) {
+	input := `{"time":"2024-03-01T12:30:45.5Z","level":"WARN","name":"app","msg":"a","fields":{"n":1}}` + "\n" +
+		`{"time":"2024-03-01T12:30:46Z","level":"INFO","msg":"b"}` + "\n"
+	var mid, out bytes.Buffer
+	(&converter{cfg: parse.Config{Format: parse.JSON}, enc: encoders["msgpack"]}).convert(&mid, strings.NewReader(input))
+	if mid.Len() >= len(input) {
+		t.Errorf("MessagePack output % x", mid.Bytes())
+	}
+	(&converter{cfg: parse.Config{Location: time.UTC}, enc: encoders["json"]}).convert(&out, &mid)
+	if out.String() != input {
+		t.Errorf("JSON → MessagePack → JSON:\n got %s\nwant %s", out.String(), input)
+	}
+}
+
+func TestConvertProtobuf(t *testing.T) {
+	input := `{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":"a"}` + "\n" + `{"time":"2024-03-01T12:30:46Z","level":"WARN","msg":"b"}` + "\n"
+	var out bytes.Buffer
+	(&converter{cfg: parse.Config{Format: parse.JSON}, enc: encoders["protobuf"]}).convert(&out, strings.NewReader(input))
+	b := out.Bytes()
+	for _, want := range []string{"a", "b"} {
+		e, n, err := logger.DecodeProtobuf(b)
+		if err != nil || e.Message != want {
+			t.Fatalf("record %+v, %v", e, err)
+		}
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		t.Errorf("%d bytes after the records", len(b))
+	}
+}
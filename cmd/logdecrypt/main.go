@@ -0,0 +1,110 @@
+//go:build !logger_minimal
+
+/*
+   logdecrypt writes the plaintext of log files encrypted with
+   logger.WithEncryption to stdout.
+
+   Usage:
+
+	logdecrypt -key-file app.key app.log [more.log ...]
+	LOG_KEY=<hex key> logdecrypt < app.log
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"peter-bird.com/logger"
+)
+
+const (
+	KeyEnv = "LOG_KEY"
+
+	KeyErrFmt     = "logdecrypt: %s\n"
+	DecryptErrFmt = "logdecrypt: %s: %s\n"
+)
+
+var (
+	errKeySize = errors.New("key must be 16, 24 or 32 bytes, hex or base64 encoded")
+	errNoKey   = errors.New("no key given, use -key-file or " + KeyEnv)
+)
+
+func main() {
+	keyFile := flag.String("key-file", "", "file holding the key as hex, base64 or raw bytes")
+	flag.Parse()
+
+	key, err := loadKey(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, KeyErrFmt, err)
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		if err := logger.DecryptTo(os.Stdout, os.Stdin, key); err != nil {
+			fmt.Fprintf(os.Stderr, DecryptErrFmt, "stdin", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	status := 0
+	for _, path := range flag.Args() {
+		if err := decryptFile(path, key); err != nil {
+			fmt.Fprintf(os.Stderr, DecryptErrFmt, path, err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func decryptFile(path string, key []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return logger.DecryptTo(os.Stdout, f, key)
+}
+
+// loadKey reads the key from the file or the LOG_KEY environment variable.
+func loadKey(path string) ([]byte, error) {
+	var raw []byte
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	} else if v := os.Getenv(KeyEnv); v != "" {
+		raw = []byte(v)
+	} else {
+		return nil, errNoKey
+	}
+	return decodeKey(raw)
+}
+
+// decodeKey accepts a hex or base64 encoded key, or the raw key bytes.
+func decodeKey(raw []byte) ([]byte, error) {
+	text := bytes.TrimSpace(raw)
+	if k, err := hex.DecodeString(string(text)); err == nil && validKeySize(len(k)) {
+		return k, nil
+	}
+	if k, err := base64.StdEncoding.DecodeString(string(text)); err == nil && validKeySize(len(k)) {
+		return k, nil
+	}
+	if validKeySize(len(raw)) {
+		return raw, nil
+	}
+	return nil, errKeySize
+}
+
+func validKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
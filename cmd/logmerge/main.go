@@ -0,0 +1,241 @@
+//go:build !logger_minimal
+
+/*
+   logmerge merges log files into one chronologically ordered stream, for
+   timelines across services. Each file is read as written by this
+   package, in any of its formats: text lines, JSON (logger.JSONEncoder),
+   GELF or any line carrying an RFC 3339 timestamp. The format is detected
+   per line. Lines without a timestamp, such as stack traces, stay with the
+   entry before them. Every file must be in order itself, which log files
+   are; the merge then streams and needs little memory.
+
+   Usage:
+
+	logmerge [-label] [-tz UTC] api.log worker.json gateway.gelf
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+const (
+	UsageErrFmt = "logmerge: %s\n"
+	MergeErrFmt = "logmerge: %s: %s\n"
+)
+
+var (
+	// textTime finds the timestamp of the text format.
+	textTime = regexp.MustCompile(`\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`)
+	// rfc3339Time finds an RFC 3339 timestamp anywhere in a line.
+	rfc3339Time = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+func main() {
+	label := flag.Bool("label", false, "prefix every line with the base name of its file")
+	tz := flag.String("tz", "Local", "time zone of text timestamps, which carry none")
+	flag.Parse()
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, "no files given")
+		os.Exit(2)
+	}
+
+	var inputs []*input
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, MergeErrFmt, path, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in := newInput(f, loc)
+		if *label {
+			in.label = filepath.Base(path) + " "
+		}
+		in.name = path
+		inputs = append(inputs, in)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	err = merge(out, inputs)
+	out.Flush()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(1)
+	}
+}
+
+// record is an entry line with the lines without a timestamp after it.
+type record struct {
+	t     time.Time
+	lines [][]byte
+}
+
+// input reads the records of one file.
+type input struct {
+	name  string
+	label string
+	sc    *bufio.Scanner
+	loc   *time.Location
+	index int
+	// next holds the timestamped line starting the following record.
+	next    []byte
+	nextT   time.Time
+	hasNext bool
+	cur     record
+}
+
+func newInput(r io.Reader, loc *time.Location) *input {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	return &input{sc: sc, loc: loc}
+}
+
+// advance reads the next record into cur and reports whether there is
+// one. Lines before the first timestamp form a record at the zero time.
+func (in *input) advance() (bool, error) {
+	in.cur = record{}
+	if in.hasNext {
+		in.cur = record{t: in.nextT, lines: [][]byte{in.next}}
+		in.hasNext = false
+	}
+	for in.sc.Scan() {
+		line := append([]byte(nil), in.sc.Bytes()...)
+		t, ok := lineTime(line, in.loc)
+		if ok && len(in.cur.lines) > 0 {
+			in.next, in.nextT, in.hasNext = line, t, true
+			return true, nil
+		}
+		if ok {
+			in.cur.t = t
+		}
+		in.cur.lines = append(in.cur.lines, line)
+	}
+	if err := in.sc.Err(); err != nil {
+		return false, fmt.Errorf("%s: %w", in.name, err)
+	}
+	return len(in.cur.lines) > 0, nil
+}
+
+// lineTime finds the timestamp of a line in any format of this package.
+func lineTime(line []byte, loc *time.Location) (time.Time, bool) {
+	if t, ok := jsonTime(line); ok {
+		return t, true
+	}
+	if m := rfc3339Time.Find(line); m != nil {
+		if m[10] == ' ' {
+			m = append(append([]byte(nil), m[:10]...), append([]byte{'T'}, m[11:]...)...)
+		}
+		if t, err := time.Parse(time.RFC3339Nano, string(m)); err == nil {
+			return t, true
+		}
+	}
+	if m := textTime.Find(line); m != nil {
+		if t, err := time.ParseInLocation(logger.TimeFormat, string(m), loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonTime reads the time of JSON and GELF documents and of documents
+// with a ts key, as the Redis and ClickHouse senders write.
+func jsonTime(line []byte) (time.Time, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return time.Time{}, false
+	}
+	var doc struct {
+		Time      string          `json:"time"`
+		TS        string          `json:"ts"`
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	if json.Unmarshal(line, &doc) != nil {
+		return time.Time{}, false
+	}
+	for _, s := range []string{doc.Time, doc.TS} {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+	}
+	var secs float64
+	if json.Unmarshal(doc.Timestamp, &secs) == nil && secs > 0 {
+		whole, frac := math.Modf(secs)
+		return time.Unix(int64(whole), int64(math.Round(frac*1e6))*1e3), true
+	}
+	return time.Time{}, false
+}
+
+// inputHeap orders inputs by the time of their current record, then by
+// their position on the command line.
+type inputHeap []*input
+
+func (h inputHeap) Len() int { return len(h) }
+func (h inputHeap) Less(i, j int) bool {
+	if !h[i].cur.t.Equal(h[j].cur.t) {
+		return h[i].cur.t.Before(h[j].cur.t)
+	}
+	return h[i].index < h[j].index
+}
+func (h inputHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *inputHeap) Push(x interface{}) { *h = append(*h, x.(*input)) }
+func (h *inputHeap) Pop() interface{} {
+	old := *h
+	in := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return in
+}
+
+// merge writes the records of all inputs to w in time order.
+func merge(w io.Writer, inputs []*input) error {
+	h := make(inputHeap, 0, len(inputs))
+	for i, in := range inputs {
+		in.index = i
+		ok, err := in.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, in)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		in := h[0]
+		for _, line := range in.cur.lines {
+			io.WriteString(w, in.label)
+			w.Write(line)
+			if _, err := w.Write([]byte{'\n'}); err != nil {
+				return err
+			}
+		}
+		ok, err := in.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	text := "api INFO : 2024/03/01 12:00:01 started\n" +
+		"api ERROR: 2024/03/01 12:00:04 panic: boom\n" +
+		"goroutine 1 [running]:\n" +
+		"api INFO : 2024/03/01 12:00:06 recovered\n"
+	jsonl := `{"time":"2024-03-01T12:00:02.5Z","level":"INFO","msg":"worker up"}` + "\n" +
+		`{"time":"2024-03-01T12:00:04Z","level":"WARN","msg":"same second"}` + "\n"
+	gelf := `{"version":"1.1","host":"h","short_message":"gateway","timestamp":1709294403.25,"level":6}` + "\n"
+
+	inputs := []*input{
+		newInput(strings.NewReader(text), time.UTC),
+		newInput(strings.NewReader(jsonl), time.UTC),
+		newInput(strings.NewReader(gelf), time.UTC),
+	}
+	inputs[0].label = "a "
+	var out bytes.Buffer
+	if err := merge(&out, inputs); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a api INFO : 2024/03/01 12:00:01 started",
+		`{"time":"2024-03-01T12:00:02.5Z","level":"INFO","msg":"worker up"}`,
+		`{"version":"1.1","host":"h","short_message":"gateway","timestamp":1709294403.25,"level":6}`,
+		"a api ERROR: 2024/03/01 12:00:04 panic: boom",
+		"a goroutine 1 [running]:",
+		`{"time":"2024-03-01T12:00:04Z","level":"WARN","msg":"same second"}`,
+		"a api INFO : 2024/03/01 12:00:06 recovered",
+	}
+	if got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("merged:\n%s\nwant:\n%s", out.String(), strings.Join(want, "\n"))
+	}
+}
+
+func TestLineTime(t *testing.T) {
+	tests := []struct {
+		line string
+		want time.Time
+	}{
+		{`{"ts":"2024-03-01T12:00:00.123Z"}`, time.Date(2024, 3, 1, 12, 0, 0, 123e6, time.UTC)},
+		{"level=info time=2024-03-01T13:00:00+01:00 msg=x", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2024-03-01 12:00:00Z something", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got, ok := lineTime([]byte(tt.line), time.UTC); !ok || !got.Equal(tt.want) {
+			t.Errorf("lineTime(%q) = %v, %v", tt.line, got, ok)
+		}
+	}
+	if _, ok := lineTime([]byte("\tat main.go:12"), time.UTC); ok {
+		t.Error("continuation line has a time")
+	}
+}
@@ -0,0 +1,178 @@
+//go:build !logger_minimal
+
+/*
+   logparquet converts log files, such as rotated archives, into Parquet
+   files with the columns ts, level, name, message and fields (a JSON
+   object), so archived logs can be queried in place with DuckDB, Athena or
+   Spark:
+
+	SELECT level, count(*) FROM 'app-*.parquet' GROUP BY level;
+
+   Each input is written next to itself with .parquet appended, after a
+   .gz suffix is dropped; gzipped inputs are decompressed. With -o all
+   inputs go into one file, and without inputs stdin is read and -o is
+   required. The input format is detected per line unless given, see
+   package parse.
+
+   Usage:
+
+	logparquet [-from auto|text|logfmt|json|gelf|msgpack] [-tz UTC] [-gzip] [-o all.parquet] [app.log.1.gz ...]
+*/
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+const (
+	UsageErrFmt   = "logparquet: %s\n"
+	ConvertErrFmt = "logparquet: %s: %s\n"
+	SkippedFmt    = "logparquet: skipped %d lines that are no entries\n"
+)
+
+// formats are the input formats by name.
+var formats = map[string]parse.Format{
+	"auto":    parse.Auto,
+	"text":    parse.Text,
+	"logfmt":  parse.Logfmt,
+	"json":    parse.JSON,
+	"gelf":    parse.GELF,
+	"msgpack": parse.Msgpack,
+}
+
+func main() {
+	from := flag.String("from", "auto", "input format: auto, text, logfmt, json, gelf or msgpack")
+	tz := flag.String("tz", "Local", "time zone of text timestamps, which carry none")
+	zip := flag.Bool("gzip", false, "compress the column pages")
+	out := flag.String("o", "", "write all inputs to this file")
+	flag.Parse()
+
+	format, ok := formats[*from]
+	loc, err := time.LoadLocation(*tz)
+	switch {
+	case err != nil:
+	case !ok:
+		err = fmt.Errorf("unknown input format %q", *from)
+	case flag.NArg() == 0 && *out == "":
+		err = fmt.Errorf("reading stdin needs -o")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(2)
+	}
+	c := &converter{
+		cfg: parse.Config{Format: format, Location: loc},
+		pq:  logger.ParquetConfig{Gzip: *zip},
+	}
+
+	status := 0
+	if *out != "" {
+		if err := c.convertAll(*out, flag.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, ConvertErrFmt, *out, err)
+			status = 1
+		}
+	}
+	for _, path := range flag.Args() {
+		if *out != "" {
+			break
+		}
+		if err := c.convertAll(outputPath(path), []string{path}); err != nil {
+			fmt.Fprintf(os.Stderr, ConvertErrFmt, path, err)
+			status = 1
+		}
+	}
+	if c.skipped > 0 {
+		fmt.Fprintf(os.Stderr, SkippedFmt, c.skipped)
+	}
+	os.Exit(status)
+}
+
+// outputPath is the Parquet file written for the input path.
+func outputPath(path string) string {
+	return strings.TrimSuffix(path, ".gz") + ".parquet"
+}
+
+type converter struct {
+	cfg     parse.Config
+	pq      logger.ParquetConfig
+	skipped int
+}
+
+// convertAll writes the entries of the inputs, stdin if none, to the
+// Parquet file out, which is removed again on failure.
+func (c *converter) convertAll(out string, inputs []string) (err error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(out)
+		}
+	}()
+	bw := bufio.NewWriter(f)
+	p := logger.NewParquetWriter(bw, c.pq)
+	if len(inputs) == 0 {
+		if err := c.convert(p, os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, path := range inputs {
+		if err := c.convertFile(p, path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (c *converter) convertFile(p *logger.ParquetWriter, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return c.convert(p, r)
+}
+
+func (c *converter) convert(p *logger.ParquetWriter, r io.Reader) error {
+	pr := parse.NewReader(r, c.cfg)
+	defer func() { c.skipped += pr.Skipped() }()
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.WriteEntry(e); err != nil {
+			return err
+		}
+	}
+}
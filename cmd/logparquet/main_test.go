@@ -0,0 +1,61 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"peter-bird.com/logger/parse"
+)
+
+func TestConvertAll(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "app.log")
+	zipped := filepath.Join(dir, "app.log.1.gz")
+	if err := os.WriteFile(plain, []byte("app INFO : 2024/03/01 12:30:45 up\nnoise\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"time":"2024-03-01T12:30:46Z","level":"ERROR","msg":"down","fields":{"code":7}}` + "\n"))
+	zw.Close()
+	if err := os.WriteFile(zipped, gz.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &converter{cfg: parse.Config{Location: time.UTC}}
+	out := outputPath(zipped)
+	if out != filepath.Join(dir, "app.log.1.parquet") {
+		t.Errorf("outputPath = %q", out)
+	}
+	if err := c.convertAll(out, []string{plain, zipped}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) < 12 || string(b[:4]) != "PAR1" || string(b[len(b)-4:]) != "PAR1" {
+		t.Errorf("not a Parquet file: %q", b)
+	}
+	for _, want := range []string{"up", "down", `{"code":7}`} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if c.skipped != 0 {
+		t.Errorf("skipped = %d", c.skipped)
+	}
+
+	if err := c.convertAll(filepath.Join(dir, "bad.parquet"), []string{filepath.Join(dir, "missing.log")}); err == nil {
+		t.Error("converted a missing file")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.parquet")); !os.IsNotExist(err) {
+		t.Errorf("failed output left behind: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+//go:build !logger_minimal
+
+/*
+   logquery prints the entries of log files that match a time range,
+   level, logger name and filter expression, see logger.ParseMatcher. The
+   files may be in any format of this package, see package parse; lines
+   that are not entries are skipped.
+
+   Usage:
+
+	logquery [-since 1h|2024-03-01T12:00:00Z] [-until ...] [-level warn]
+	         [-name app.db] [-e 'user_id=42 && msg~timeout']
+	         [-format text|logfmt|json|gelf] [-tz UTC] [app.log ...]
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+const (
+	UsageErrFmt = "logquery: %s\n"
+	QueryErrFmt = "logquery: %s: %s\n"
+)
+
+// encoders are the output formats by name.
+var encoders = map[string]logger.Encoder{
+	"text":   logger.TextEncoder{},
+	"logfmt": logger.LogfmtEncoder{},
+	"json":   logger.JSONEncoder{},
+	"gelf":   logger.NewGELFEncoder(""),
+}
+
+func main() {
+	since := flag.String("since", "", "only entries at or after this RFC 3339 time, or this long ago")
+	until := flag.String("until", "", "only entries before this RFC 3339 time, or this long ago")
+	level := flag.String("level", "", "only entries at or above this level")
+	name := flag.String("name", "", "only entries of this logger and its named children")
+	expr := flag.String("e", "", "only entries matching this filter expression")
+	format := flag.String("format", "text", "output format: text, logfmt, json or gelf")
+	tz := flag.String("tz", "Local", "time zone of text timestamps, which carry none")
+	flag.Parse()
+
+	q, err := newQuery(time.Now(), *since, *until, *level, *name, *expr)
+	if err == nil {
+		if q.enc = encoders[*format]; q.enc == nil {
+			err = fmt.Errorf("unknown format %q", *format)
+		}
+	}
+	if err == nil {
+		q.loc, err = time.LoadLocation(*tz)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if flag.NArg() == 0 {
+		if err := q.run(out, os.Stdin); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, QueryErrFmt, "stdin", err)
+			os.Exit(1)
+		}
+		return
+	}
+	status := 0
+	for _, path := range flag.Args() {
+		if err := q.runFile(out, path); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, QueryErrFmt, path, err)
+			status = 1
+		}
+	}
+	out.Flush()
+	os.Exit(status)
+}
+
+type query struct {
+	matchers []logger.Matcher
+	enc      logger.Encoder
+	loc      *time.Location
+	buf      bytes.Buffer
+}
+
+func newQuery(now time.Time, since, until, level, name, expr string) (*query, error) {
+	q := new(query)
+	for _, t := range []struct {
+		s     string
+		since bool
+	}{{since, true}, {until, false}} {
+		if t.s == "" {
+			continue
+		}
+		at, err := parseTime(now, t.s)
+		if err != nil {
+			return nil, err
+		}
+		if t.since {
+			q.add(func(e *logger.Entry) bool { return !e.Time.Before(at) })
+		} else {
+			q.add(func(e *logger.Entry) bool { return e.Time.Before(at) })
+		}
+	}
+	if level != "" || name != "" {
+		f := logger.AllEntries
+		f.Name = name
+		if level != "" {
+			lvl, ok := logger.ParseLevel(level)
+			if !ok {
+				return nil, fmt.Errorf("unknown level %q", level)
+			}
+			f.Level = lvl
+		}
+		q.add(f.Match)
+	}
+	if expr != "" {
+		m, err := logger.ParseMatcher(expr)
+		if err != nil {
+			return nil, err
+		}
+		q.add(m)
+	}
+	return q, nil
+}
+
+func (q *query) add(m logger.Matcher) { q.matchers = append(q.matchers, m) }
+
+// parseTime reads an RFC 3339 time or a duration before now.
+func parseTime(now time.Time, s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339 or a duration", s)
+	}
+	return t, nil
+}
+
+func (q *query) match(e *logger.Entry) bool {
+	for _, m := range q.matchers {
+		if !m(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *query) runFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return q.run(w, f)
+}
+
+func (q *query) run(w io.Writer, r io.Reader) error {
+	pr := parse.NewReader(r, parse.Config{Location: q.loc})
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !q.match(e) {
+			continue
+		}
+		q.buf.Reset()
+		if err := q.enc.Encode(&q.buf, e); err != nil {
+			return err
+		}
+		// GELF documents have no delimiter of their own.
+		if b := q.buf.Bytes(); len(b) > 0 && b[len(b)-1] != '\n' {
+			q.buf.WriteByte('\n')
+		}
+		if _, err := w.Write(q.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
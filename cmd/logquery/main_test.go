@@ -0,0 +1,70 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+const input = `{"time":"2024-03-01T12:00:00Z","level":"INFO","name":"app","msg":"start"}
+not an entry
+app.db WARN : 2024/03/01 12:10:00 slow user_id=42
+{"time":"2024-03-01T12:20:00Z","level":"ERROR","name":"app.db.pool","msg":"timeout","fields":{"user_id":7}}
+{"time":"2024-03-01T12:30:00Z","level":"ERROR","name":"app.http","msg":"failed","fields":{"user_id":42}}
+`
+
+func TestQuery(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 35, 0, 0, time.UTC)
+	tests := []struct {
+		since, until, level, name, expr string
+		want                            []string
+	}{
+		{"", "", "", "", "", []string{"start", "slow", "timeout", "failed"}},
+		{"2024-03-01T12:10:00Z", "2024-03-01T12:30:00Z", "", "", "", []string{"slow", "timeout"}},
+		{"30m", "", "", "", "", []string{"slow", "timeout", "failed"}},
+		{"", "", "error", "", "", []string{"timeout", "failed"}},
+		{"", "", "", "app.db", "", []string{"slow", "timeout"}},
+		{"", "", "", "", "user_id=42 && level>=warn", []string{"slow", "failed"}},
+	}
+	for _, tt := range tests {
+		q, err := newQuery(now, tt.since, tt.until, tt.level, tt.name, tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.enc, q.loc = logger.JSONEncoder{}, time.UTC
+		var out bytes.Buffer
+		if err := q.run(&out, strings.NewReader(input)); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if e, err := logger.DecodeJSON([]byte(line)); err == nil {
+				got = append(got, e.Message)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%+v: got %v, want %v", tt, got, tt.want)
+		}
+	}
+
+	for _, bad := range [][5]string{{"yesterday"}, {"", "", "loud"}, {"", "", "", "", "a &&"}} {
+		if _, err := newQuery(now, bad[0], bad[1], bad[2], bad[3], bad[4]); err == nil {
+			t.Errorf("newQuery%q succeeded", bad)
+		}
+	}
+}
+
+func TestQueryGELF(t *testing.T) {
+	q, _ := newQuery(time.Now(), "", "", "error", "", "")
+	q.enc, q.loc = logger.NewGELFEncoder("h"), time.UTC
+	var out bytes.Buffer
+	q.run(&out, strings.NewReader(input))
+	if lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"short_message":"timeout"`) {
+		t.Errorf("GELF output %q", out.String())
+	}
+}
@@ -0,0 +1,505 @@
+//go:build !logger_minimal
+
+/*
+   logship follows log files like tail -F and forwards their entries, with
+   their original timestamps, to a collector, so the files of services that
+   do not log through this package join the same pipeline. The format of
+   every line is detected unless given, see package parse.
+
+   Entries are sent in batches. Once a batch is delivered the position
+   reached in every file is written to the checkpoint file, from which a
+   restarted logship resumes, so no entry is lost and few are sent twice. A
+   file replaced while logship was down is read from its start.
+
+   Destinations:
+
+	tcp://host:port                    JSON lines, e.g. to Vector or Logstash
+	fluent://host:port                 Fluentd or Fluent Bit forward input, with acks
+	http://host/path, https://...      JSON lines in POST requests
+	loki://host:port, loki+https://... Grafana Loki push API, labelled with -label and the level
+
+   Kafka is reached through Fluent Bit or Fluentd, or a Kafka REST proxy.
+
+   With -replay the files are not followed but sent once from start to end,
+   optionally only the entries from -since and before -until, to backfill a
+   new aggregator or recover after an outage; gzipped archives are read
+   decompressed, see parse.Replay.
+
+   Usage:
+
+	logship -to loki://localhost:3100 [-checkpoint /var/lib/logship/app.json] [-label job=app] [-from auto] [-from-start] app.log ...
+	logship -to https://collector/logs -replay [-since 2024-03-01T12:00:00Z] [-until 2024-03-01T14:00:00Z] app-*.log.gz
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+const (
+	// HeadSize is how much of the start of a file its checkpoint records,
+	// to tell a file that was replaced from the one checkpointed.
+	HeadSize = 1024
+	// ShutdownTimeout bounds the delivery of the last batch on exit.
+	ShutdownTimeout = 10 * time.Second
+
+	UsageErrFmt       = "logship: %s\n"
+	ShipErrFmt        = "logship: %s: %s\n"
+	DroppedErrFmt     = "logship: dropped %d entries the destination rejected: %s\n"
+	ReplayedFmt       = "logship: %s: replayed %d entries\n"
+	DestinationErrFmt = "unknown destination %q, use tcp://, fluent://, http(s):// or loki(+https)://"
+	LabelErrFmt       = "label %q is no name=value"
+)
+
+// formats are the input formats by name.
+var formats = map[string]parse.Format{
+	"auto":    parse.Auto,
+	"text":    parse.Text,
+	"logfmt":  parse.Logfmt,
+	"json":    parse.JSON,
+	"gelf":    parse.GELF,
+	"msgpack": parse.Msgpack,
+}
+
+// labels collects the -label flags.
+type labels map[string]string
+
+func (l labels) String() string {
+	return fmt.Sprint(map[string]string(l))
+}
+
+func (l labels) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf(LabelErrFmt, s)
+	}
+	l[k] = v
+	return nil
+}
+
+func main() {
+	to := flag.String("to", "", "destination: tcp://host:port, fluent://host:port, http(s)://url or loki(+https)://host:port")
+	from := flag.String("from", "auto", "input format: auto, text, logfmt, json, gelf or msgpack")
+	tz := flag.String("tz", "Local", "time zone of text timestamps, which carry none")
+	cpPath := flag.String("checkpoint", "", "file recording the positions shipped, to resume from")
+	fromStart := flag.Bool("from-start", false, "read files without a checkpoint from their start instead of their end")
+	tag := flag.String("tag", logger.DefaultFluentTag, "Fluent tag")
+	batch := flag.Int("batch", logger.DefaultBatchEntries, "most entries sent at once")
+	interval := flag.Duration("flush", logger.DefaultFlushInterval, "longest time an entry waits for its batch")
+	replay := flag.Bool("replay", false, "send the files once instead of following them")
+	since := flag.String("since", "", "with -replay, the RFC 3339 time of the first entry sent")
+	until := flag.String("until", "", "with -replay, the RFC 3339 time before which entries are sent")
+	lbls := labels{}
+	flag.Var(lbls, "label", "Loki stream label name=value, repeatable")
+	flag.Parse()
+
+	format, ok := formats[*from]
+	loc, err := time.LoadLocation(*tz)
+	var sender logger.BatchSender
+	var span [2]time.Time
+	for i, s := range []string{*since, *until} {
+		if s != "" && err == nil {
+			span[i], err = time.Parse(time.RFC3339, s)
+		}
+	}
+	switch {
+	case err != nil:
+	case !ok:
+		err = fmt.Errorf("unknown input format %q", *from)
+	case flag.NArg() == 0:
+		err = errors.New("no files to follow")
+	default:
+		sender, err = newSender(*to, *tag, lbls)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(2)
+	}
+	if *replay {
+		cfg := parse.ReplayConfig{Config: parse.Config{Format: format, Location: loc}, Since: span[0], Until: span[1]}
+		os.Exit(replayFiles(sender, logger.BatchConfig{MaxEntries: *batch, FlushInterval: *interval}, flag.Args(), cfg))
+	}
+	cps, err := loadCheckpoints(*cpPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	items := make(chan item)
+	var wg sync.WaitGroup
+	for _, path := range flag.Args() {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		cfg := parse.FollowConfig{Config: parse.Config{Format: format, Location: loc}, FromStart: *fromStart}
+		if cp, ok := cps[path]; ok {
+			cfg.FromStart, cfg.Offset = true, cp.resume(path)
+		}
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			if err := follow(ctx, path, cfg, items); err != nil {
+				fmt.Fprintf(os.Stderr, ShipErrFmt, path, err)
+			}
+		}(path)
+	}
+
+	retry := logger.DefaultRetryPolicy
+	retry.MaxAttempts = math.MaxInt32
+	s := &shipper{sender: sender, retry: retry, batch: *batch, interval: *interval, cpPath: *cpPath, cps: cps}
+	status := 0
+	if err := s.run(ctx, items); err != nil {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+		status = 1
+	}
+	stop()
+	wg.Wait()
+	if c, ok := sender.(io.Closer); ok {
+		c.Close()
+	}
+	os.Exit(status)
+}
+
+// replayFiles sends the entries of the files at paths through sender,
+// retrying failed batches, and returns the exit status.
+func replayFiles(sender logger.BatchSender, bcfg logger.BatchConfig, paths []string, cfg parse.ReplayConfig) int {
+	status := 0
+	bcfg.AtLeastOnce = true
+	bcfg.ErrorHandler = func(err error) {
+		fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+	}
+	sink := logger.NewBatchSink(sender, bcfg)
+	for _, path := range paths {
+		n, err := parse.Replay(path, sink, cfg)
+		fmt.Fprintf(os.Stderr, ReplayedFmt, path, n)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, UsageErrFmt, err)
+			status = 1
+		}
+	}
+	sink.Close()
+	if sink.Stats().Failed > 0 {
+		status = 1
+	}
+	return status
+}
+
+// item is an entry read with the position in its file after it.
+type item struct {
+	e    *logger.Entry
+	path string
+	pos  parse.Position
+}
+
+// follow sends the entries written to the file at path to items until ctx
+// is done.
+func follow(ctx context.Context, path string, cfg parse.FollowConfig, items chan<- item) error {
+	f := parse.Follow(ctx, path, cfg)
+	defer f.Close()
+	for {
+		e, err := f.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case items <- item{e: e, path: path, pos: f.Position()}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// shipper delivers the entries followed in batches and checkpoints the
+// positions of those delivered.
+type shipper struct {
+	sender   logger.BatchSender
+	retry    logger.RetryPolicy
+	batch    int
+	interval time.Duration
+	cpPath   string
+	cps      map[string]checkpoint
+}
+
+// run ships the items until ctx is done, then delivers those pending
+// within ShutdownTimeout.
+func (s *shipper) run(ctx context.Context, items <-chan item) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	var pending []item
+	for {
+		select {
+		case it := <-items:
+			pending = append(pending, it)
+			if len(pending) < s.batch {
+				continue
+			}
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+		case <-ctx.Done():
+			if len(pending) == 0 {
+				return nil
+			}
+			sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+			defer cancel()
+			return s.ship(sctx, pending)
+		}
+		if err := s.ship(ctx, pending); err != nil && ctx.Err() == nil {
+			return err
+		}
+		pending = nil
+	}
+}
+
+// ship delivers the entries of items, retrying until ctx is done, and
+// saves the positions after them. A batch rejected as permanent is
+// reported and passed over.
+func (s *shipper) ship(ctx context.Context, items []item) error {
+	batch := make([]*logger.Entry, len(items))
+	for i, it := range items {
+		batch[i] = it.e
+	}
+	err := s.retry.Do(ctx, func(ctx context.Context) error {
+		return s.sender.SendBatch(ctx, batch)
+	})
+	if err != nil {
+		if !logger.IsPermanent(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, DroppedErrFmt, len(batch), err)
+	}
+	if s.cpPath == "" {
+		return nil
+	}
+	for _, it := range items {
+		if cp, ok := mark(it.path, it.pos); ok {
+			s.cps[it.path] = cp
+		}
+	}
+	return saveCheckpoints(s.cpPath, s.cps)
+}
+
+// checkpoint is the position shipped in a file.
+type checkpoint struct {
+	Offset int64 `json:"offset"`
+	// Head is the SHA-256 of the first HeadSize bytes of the file, or of
+	// the first Offset bytes if fewer.
+	Head string `json:"head"`
+}
+
+// mark returns the checkpoint of pos in the file at path, or false if pos
+// is in a file rotated away from path, which is not resumed.
+func mark(path string, pos parse.Position) (checkpoint, bool) {
+	fi, err := os.Stat(path)
+	if pos.File == nil || err != nil || !os.SameFile(fi, pos.File) {
+		return checkpoint{}, false
+	}
+	head, err := headSum(path, pos.Offset)
+	if err != nil {
+		return checkpoint{}, false
+	}
+	return checkpoint{Offset: pos.Offset, Head: head}, true
+}
+
+// resume returns the offset to follow the file at path from: that of the
+// checkpoint if the file still starts the same and reaches it, else 0.
+func (cp checkpoint) resume(path string) int64 {
+	if head, err := headSum(path, cp.Offset); err != nil || head != cp.Head {
+		return 0
+	}
+	return cp.Offset
+}
+
+// headSum hashes the first HeadSize bytes of the file, or its first n if
+// fewer, failing if the file is shorter.
+func headSum(path string, n int64) (string, error) {
+	if n > HeadSize {
+		n = HeadSize
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, n); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadCheckpoints reads the checkpoints of the file at path, none if path
+// is empty or the file does not exist yet.
+func loadCheckpoints(path string) (map[string]checkpoint, error) {
+	cps := map[string]checkpoint{}
+	if path == "" {
+		return cps, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &cps); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cps, nil
+}
+
+// saveCheckpoints replaces the file at path so a crash leaves either the
+// old checkpoints or the new ones.
+func saveCheckpoints(path string, cps map[string]checkpoint) error {
+	b, err := json.MarshalIndent(cps, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newSender returns the sender for the destination URL dest.
+func newSender(dest, tag string, lbls labels) (logger.BatchSender, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp":
+		return logger.NewTCPSender(logger.TCPConfig{Addr: u.Host, Encoder: logger.JSONEncoder{}}), nil
+	case "fluent":
+		return logger.NewFluentSender(logger.FluentConfig{Addr: u.Host, Tag: tag, RequireAck: true}), nil
+	case "http", "https":
+		return jsonLinesSender(http.DefaultClient, dest), nil
+	case "loki", "loki+http", "loki+https":
+		scheme := "http"
+		if u.Scheme == "loki+https" {
+			scheme = "https"
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/loki/api/v1/push"
+		}
+		u.Scheme = scheme
+		return lokiSender(http.DefaultClient, u.String(), lbls), nil
+	}
+	return nil, fmt.Errorf(DestinationErrFmt, dest)
+}
+
+// jsonLinesSender posts every batch to url as JSON lines.
+func jsonLinesSender(client *http.Client, url string) logger.BatchSender {
+	return logger.BatchSenderFunc(func(ctx context.Context, batch []*logger.Entry) error {
+		var buf bytes.Buffer
+		for _, e := range batch {
+			if err := (logger.JSONEncoder{}).Encode(&buf, e); err != nil {
+				return logger.Permanent(err)
+			}
+		}
+		return post(ctx, client, url, "application/x-ndjson", buf.Bytes())
+	})
+}
+
+// lokiStream is a stream of the Loki push API: its labels and its values,
+// each a timestamp in nanoseconds and a line.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiSender pushes every batch to the Loki push API at url, one stream
+// per level labelled with lbls, the lines encoded as JSON.
+func lokiSender(client *http.Client, url string, lbls labels) logger.BatchSender {
+	return logger.BatchSenderFunc(func(ctx context.Context, batch []*logger.Entry) error {
+		streams := map[string]*lokiStream{}
+		var buf bytes.Buffer
+		for _, e := range batch {
+			level := strings.ToLower(e.Level.String())
+			s := streams[level]
+			if s == nil {
+				s = &lokiStream{Stream: map[string]string{"level": level}}
+				for k, v := range lbls {
+					s.Stream[k] = v
+				}
+				streams[level] = s
+			}
+			buf.Reset()
+			if err := (logger.JSONEncoder{}).Encode(&buf, e); err != nil {
+				return logger.Permanent(err)
+			}
+			line := strings.TrimSuffix(buf.String(), "\n")
+			s.Values = append(s.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), line})
+		}
+		var push struct {
+			Streams []*lokiStream `json:"streams"`
+		}
+		for _, s := range streams {
+			push.Streams = append(push.Streams, s)
+		}
+		sort.Slice(push.Streams, func(i, j int) bool {
+			return push.Streams[i].Stream["level"] < push.Streams[j].Stream["level"]
+		})
+		body, err := json.Marshal(push)
+		if err != nil {
+			return logger.Permanent(err)
+		}
+		return post(ctx, client, url, "application/json", body)
+	})
+}
+
+// post sends body to url. Non-2xx statuses become errors; client errors
+// other than 408 and 429 are marked Permanent since resending the same
+// batch cannot succeed.
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return logger.Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf(logger.HTTPStatusErrFmt, req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return logger.Permanent(err)
+	}
+	return err
+}
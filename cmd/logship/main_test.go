@@ -0,0 +1,193 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"peter-bird.com/logger"
+	"peter-bird.com/logger/parse"
+)
+
+func jsonLine(msg string) string {
+	return `{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":"` + msg + `"}` + "\n"
+}
+
+type recordSender struct {
+	mu   sync.Mutex
+	msgs []string
+	got  chan struct{}
+}
+
+func (s *recordSender) SendBatch(_ context.Context, batch []*logger.Entry) error {
+	s.mu.Lock()
+	for _, e := range batch {
+		s.msgs = append(s.msgs, e.Message)
+	}
+	s.mu.Unlock()
+	s.got <- struct{}{}
+	return nil
+}
+
+func TestShip(t *testing.T) {
+	dir := t.TempDir()
+	path, cpPath := filepath.Join(dir, "app.log"), filepath.Join(dir, "checkpoint.json")
+	os.WriteFile(path, []byte(jsonLine("one")+jsonLine("two")), 0o644)
+
+	// A first run ships the file from its start and checkpoints the end.
+	run := func(want ...string) map[string]checkpoint {
+		t.Helper()
+		cps, err := loadCheckpoints(cpPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := parse.FollowConfig{Poll: 5 * time.Millisecond, FromStart: true}
+		if cp, ok := cps[path]; ok {
+			cfg.Offset = cp.resume(path)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sender := &recordSender{got: make(chan struct{}, 10)}
+		s := &shipper{sender: sender, batch: len(want), interval: time.Hour, cpPath: cpPath, cps: cps}
+		items := make(chan item)
+		go follow(ctx, path, cfg, items)
+		done := make(chan error)
+		go func() { done <- s.run(ctx, items) }()
+		<-sender.got
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(sender.msgs, "|") != strings.Join(want, "|") {
+			t.Fatalf("shipped %q, want %q", sender.msgs, want)
+		}
+		cps, _ = loadCheckpoints(cpPath)
+		return cps
+	}
+	cps := run("one", "two")
+	if fi, _ := os.Stat(path); cps[path].Offset != fi.Size() {
+		t.Errorf("checkpoint %+v, size %d", cps[path], fi.Size())
+	}
+
+	// A restart resumes after the entries shipped.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(jsonLine("three"))
+	f.Close()
+	run("three")
+
+	// A file replaced while logship was down is read from its start.
+	os.Rename(path, path+".1")
+	os.WriteFile(path, []byte(jsonLine("four")+jsonLine("five")+jsonLine("six")+jsonLine("seven")), 0o644)
+	run("four", "five", "six", "seven")
+}
+
+func TestShipPermanent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	s := &shipper{
+		sender: logger.BatchSenderFunc(func(context.Context, []*logger.Entry) error {
+			return logger.Permanent(io.ErrUnexpectedEOF)
+		}),
+		cpPath: path,
+		cps:    map[string]checkpoint{},
+	}
+	// The rejected batch is passed over, so the checkpoint still moves.
+	if err := s.ship(context.Background(), []item{{e: &logger.Entry{}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLokiSender(t *testing.T) {
+	var push struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&push)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	at := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	sender := lokiSender(srv.Client(), srv.URL, labels{"job": "app"})
+	err := sender.SendBatch(context.Background(), []*logger.Entry{
+		{Time: at, Level: logger.Info, Message: "one"},
+		{Time: at.Add(time.Second), Level: logger.Error, Message: "two"},
+		{Time: at.Add(2 * time.Second), Level: logger.Info, Message: "three"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(push.Streams) != 2 {
+		t.Fatalf("streams %+v", push.Streams)
+	}
+	s := push.Streams[1]
+	if s.Stream["job"] != "app" || s.Stream["level"] != "info" || len(s.Values) != 2 {
+		t.Errorf("stream %+v", s)
+	}
+	if v := s.Values[1]; v[0] != "1709296247000000000" || !strings.Contains(v[1], `"msg":"three"`) {
+		t.Errorf("value %q", v)
+	}
+}
+
+func TestPostStatus(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	sender := jsonLinesSender(srv.Client(), srv.URL)
+	batch := []*logger.Entry{{Message: "m"}}
+	if err := sender.SendBatch(context.Background(), batch); !logger.IsPermanent(err) {
+		t.Errorf("400: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := sender.SendBatch(context.Background(), batch); err == nil || logger.IsPermanent(err) {
+		t.Errorf("503: %v", err)
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	for _, dest := range []string{"tcp://localhost:5170", "fluent://localhost:24224", "https://collector/logs", "loki+https://loki:3100"} {
+		if _, err := newSender(dest, "app", nil); err != nil {
+			t.Errorf("%s: %v", dest, err)
+		}
+	}
+	if _, err := newSender("kafka://localhost:9092", "app", nil); err == nil {
+		t.Error("kafka:// accepted")
+	}
+}
+
+func TestReplayFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte(jsonLine("one")+jsonLine("two")), 0o644)
+	var mu sync.Mutex
+	var msgs []string
+	sender := logger.BatchSenderFunc(func(_ context.Context, batch []*logger.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, e := range batch {
+			msgs = append(msgs, e.Message)
+		}
+		return nil
+	})
+	if status := replayFiles(sender, logger.BatchConfig{}, []string{path}, parse.ReplayConfig{}); status != 0 {
+		t.Errorf("status %d", status)
+	}
+	if strings.Join(msgs, "|") != "one|two" {
+		t.Errorf("replayed %q", msgs)
+	}
+	if status := replayFiles(sender, logger.BatchConfig{}, []string{path + ".missing"}, parse.ReplayConfig{}); status != 1 {
+		t.Errorf("missing file: status %d", status)
+	}
+}
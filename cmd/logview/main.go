@@ -0,0 +1,170 @@
+//go:build !logger_minimal
+
+/*
+   logview pretty-prints JSON log output written by logger.JSONEncoder in
+   the colored text format, optionally following the file as it grows.
+   Lines that are not JSON entries, such as panics, are passed through.
+
+   Usage:
+
+	logview [-f] [-n 100] [-level warn] [-no-color] [app.log]
+	kubectl logs app | logview -level error
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+const (
+	// PollInterval is how often a followed file is checked for new lines.
+	PollInterval = 250 * time.Millisecond
+
+	LevelErrFmt = "logview: unknown level %q\n"
+	ViewErrFmt  = "logview: %s\n"
+)
+
+func main() {
+	follow := flag.Bool("f", false, "keep reading as the file grows, like tail -f")
+	last := flag.Int("n", 0, "start with the last n lines instead of the whole file")
+	level := flag.String("level", "", "only show entries at or above this level")
+	noColor := flag.Bool("no-color", false, "do not color the level prefixes; also set by NO_COLOR")
+	flag.Parse()
+
+	v := &viewer{min: logger.LogLevel(-1 << 15)}
+	if *level != "" {
+		lvl, ok := logger.ParseLevel(*level)
+		if !ok {
+			fmt.Fprintf(os.Stderr, LevelErrFmt, *level)
+			os.Exit(2)
+		}
+		v.min = lvl
+	}
+	if !*noColor && os.Getenv("NO_COLOR") == "" {
+		v.enc.Colors = logger.DefaultColors
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	var err error
+	if flag.NArg() == 0 {
+		err = v.view(out, os.Stdin, *last)
+	} else {
+		err = v.viewFile(out, flag.Arg(0), *last, *follow)
+	}
+	if err != nil {
+		out.Flush()
+		fmt.Fprintf(os.Stderr, ViewErrFmt, err)
+		os.Exit(1)
+	}
+}
+
+type viewer struct {
+	min logger.LogLevel
+	enc logger.TextEncoder
+	buf bytes.Buffer
+}
+
+// render writes one input line to w.
+func (v *viewer) render(w io.Writer, line []byte) {
+	e, err := logger.DecodeJSON(line)
+	if err != nil {
+		w.Write(line)
+		w.Write([]byte{'\n'})
+		return
+	}
+	if e.Level < v.min {
+		return
+	}
+	v.buf.Reset()
+	v.enc.Encode(&v.buf, e)
+	w.Write(v.buf.Bytes())
+}
+
+// view renders r, only the last n lines if n > 0.
+func (v *viewer) view(w io.Writer, r io.Reader, n int) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	if n <= 0 {
+		for sc.Scan() {
+			v.render(w, sc.Bytes())
+		}
+		return sc.Err()
+	}
+	ring := make([][]byte, n)
+	var count int
+	for sc.Scan() {
+		ring[count%n] = append(ring[count%n][:0], sc.Bytes()...)
+		count++
+	}
+	start := 0
+	if count > n {
+		start = count - n
+	}
+	for i := start; i < count; i++ {
+		v.render(w, ring[i%n])
+	}
+	return sc.Err()
+}
+
+// viewFile renders path and, when following, the lines appended later. A
+// file truncated in place, as copytruncate rotation does, is read again
+// from the start.
+func (v *viewer) viewFile(w *bufio.Writer, path string, n int, follow bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !follow {
+		return v.view(w, f, n)
+	}
+
+	// Read whole lines only, keeping a partial last line for later.
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	offset := int64(len(data))
+	partial := data[bytes.LastIndexByte(data, '\n')+1:]
+	if err := v.view(w, bytes.NewReader(data[:len(data)-len(partial)]), n); err != nil {
+		return err
+	}
+	partial = append([]byte(nil), partial...)
+	chunk := make([]byte, 64<<10)
+	for {
+		w.Flush()
+		time.Sleep(PollInterval)
+		if fi, err := f.Stat(); err == nil && fi.Size() < offset {
+			offset, partial = 0, partial[:0]
+		}
+		for {
+			k, err := f.ReadAt(chunk, offset)
+			offset += int64(k)
+			partial = append(partial, chunk[:k]...)
+			for {
+				i := bytes.IndexByte(partial, '\n')
+				if i < 0 {
+					break
+				}
+				v.render(w, partial[:i])
+				partial = partial[i+1:]
+			}
+			if err == io.EOF || k == 0 {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
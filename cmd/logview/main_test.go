@@ -0,0 +1,39 @@
+//go:build !logger_minimal
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"peter-bird.com/logger"
+)
+
+const input = `{"time":"2024-03-01T12:30:45Z","level":"WARN","name":"app","msg":"disk low","fields":{"free":3}}
+panic: boom
+{"time":"2024-03-01T12:30:46Z","level":"DEBUG","name":"app","msg":"detail"}
+{"time":"2024-03-01T12:30:47Z","level":"ERROR","name":"app","msg":"failed"}
+`
+
+func TestView(t *testing.T) {
+	tests := []struct {
+		min  logger.LogLevel
+		n    int
+		want string
+	}{
+		{logger.Debug, 0, "app WARN : 2024/03/01 12:30:45 disk low free=3\npanic: boom\napp DEBUG: 2024/03/01 12:30:46 detail\napp ERROR: 2024/03/01 12:30:47 failed\n"},
+		{logger.Info, 0, "app WARN : 2024/03/01 12:30:45 disk low free=3\npanic: boom\napp ERROR: 2024/03/01 12:30:47 failed\n"},
+		{logger.Debug, 2, "app DEBUG: 2024/03/01 12:30:46 detail\napp ERROR: 2024/03/01 12:30:47 failed\n"},
+	}
+	for _, tt := range tests {
+		v := &viewer{min: tt.min}
+		var out bytes.Buffer
+		if err := v.view(&out, strings.NewReader(input), tt.n); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("min %s, n %d:\n got %q\nwant %q", tt.min, tt.n, out.String(), tt.want)
+		}
+	}
+}
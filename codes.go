@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// CodeKey is the field holding the code of an entry logged with Code.
+	CodeKey = "code"
+	// CodeDocKey is the field holding the documentation link of a code.
+	CodeDocKey = "doc"
+
+	CodeExistsErrFmt = "Error code %s is already registered"
+	// CodeUnknownFmt is the message of an entry logged with an
+	// unregistered code.
+	CodeUnknownFmt = "unknown error code %s"
+)
+
+// ErrCodeName is returned for an empty error code.
+var ErrCodeName = errors.New("error code needs a name")
+
+// CodeInfo documents an error code, see RegisterCode.
+type CodeInfo struct {
+	// Code identifies the error, e.g. "E1234".
+	Code string
+	// Message is the default message of entries with the code.
+	Message string
+	// Level is the level of entries with the code; zero means Error.
+	Level LogLevel
+	// ExplicitLevels uses Level as given, so Debug (zero) can be
+	// selected.
+	ExplicitLevels bool
+	// Description explains the error and its remedy, for generated
+	// documentation; it is not logged.
+	Description string
+	// Doc, if set, links the documentation and is logged as a doc field.
+	Doc string
+	// Fields are attached to every entry with the code, e.g. a component.
+	Fields []Field
+}
+
+// codes holds the registered error codes, like customLevels.
+var codes struct {
+	mu    sync.Mutex
+	codes sync.Map // string -> CodeInfo
+}
+
+// RegisterCode adds an error code to the process-wide catalog, so that
+//
+//	logger.RegisterCode(logger.CodeInfo{Code: "E1234", Message: "payment declined", Level: logger.Warn})
+//	l.Code("E1234", logger.String("order", id))
+//
+// logs the same message, level and fields wherever it happens. Codes
+// should be registered at startup.
+func RegisterCode(info CodeInfo) error {
+	if info.Code == "" {
+		return ErrCodeName
+	}
+	if info.Level == 0 && !info.ExplicitLevels {
+		info.Level = Error
+	}
+	info.Fields = append([]Field(nil), info.Fields...)
+
+	codes.mu.Lock()
+	defer codes.mu.Unlock()
+	if _, ok := codes.codes.Load(info.Code); ok {
+		return fmt.Errorf(CodeExistsErrFmt, info.Code)
+	}
+	codes.codes.Store(info.Code, info)
+	return nil
+}
+
+// LookupCode returns the registered error code.
+func LookupCode(code string) (CodeInfo, bool) {
+	v, ok := codes.codes.Load(code)
+	if !ok {
+		return CodeInfo{}, false
+	}
+	return v.(CodeInfo), true
+}
+
+// Codes returns the catalog sorted by code, e.g. to document it.
+func Codes() []CodeInfo {
+	var list []CodeInfo
+	codes.codes.Range(func(_, v interface{}) bool {
+		list = append(list, v.(CodeInfo))
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// Code logs the entry of a registered error code: its message at its
+// level, with a code field, the doc link, the code's fields and then
+// fields. On a grouped logger only fields are nested in the groups. An
+// unregistered code is logged at Error as CodeUnknownFmt, so the mistake
+// shows without losing the entry.
+func (l *CustomLogger) Code(code string, fields ...Field) {
+	info, ok := LookupCode(code)
+	if !ok {
+		info = CodeInfo{Level: Error, Message: fmt.Sprintf(CodeUnknownFmt, code)}
+	}
+	if !l.enabled(info.Level) {
+		return
+	}
+	if len(l.groups) > 0 {
+		fields = groupFields(l.groups, fields)
+		ungrouped := *l
+		ungrouped.groups = nil
+		l = &ungrouped
+	}
+	all := make([]Field, 0, 2+len(info.Fields)+len(fields))
+	all = append(all, String(CodeKey, code))
+	if info.Doc != "" {
+		all = append(all, String(CodeDocKey, info.Doc))
+	}
+	all = append(all, info.Fields...)
+	all = append(all, fields...)
+	l.log(info.Level, info.Message, all...)
+}
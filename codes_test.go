@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	// Codes are process-wide, so only the first run registers them.
+	if _, ok := LookupCode("TEST1"); !ok {
+		for _, info := range []CodeInfo{
+			{Code: "TEST1", Message: "payment declined", Level: Warn, Doc: "https://docs/E1", Fields: []Field{String("component", "billing")}},
+			{Code: "TEST2", Message: "cache warm", Level: Debug, ExplicitLevels: true},
+		} {
+			if err := RegisterCode(info); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := RegisterCode(CodeInfo{Code: "TEST1"}); err == nil {
+		t.Error("registered a code twice")
+	}
+	if err := RegisterCode(CodeInfo{}); err != ErrCodeName {
+		t.Errorf("empty code: %v", err)
+	}
+
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	l.Group("pay").Code("TEST1", String("order", "o1"))
+	l.Code("TEST2")
+	l.Code("NOPE")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"WARN : 2024/03/01 12:30:45 payment declined code=TEST1 doc=https://docs/E1 component=billing pay.order=o1",
+		"ERROR: 2024/03/01 12:30:45 unknown error code NOPE code=NOPE",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", buf.String())
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], want[i])
+		}
+	}
+
+	var found bool
+	for _, info := range Codes() {
+		found = found || info.Code == "TEST2" && info.Level == Debug
+	}
+	if !found {
+		t.Error("Codes() misses TEST2 at Debug")
+	}
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+const CompressPayloadErrFmt = "Failed to compress payload: %w"
+
+// Compressor compresses the payload of a remote batch. Gzip is built in;
+// other codecs such as zstd can be plugged in by implementing Compressor
+// around a third-party library.
+type Compressor interface {
+	// Encoding is the HTTP Content-Encoding token, e.g. "gzip" or "zstd".
+	Encoding() string
+	// Compress appends the compressed form of src to dst.
+	Compress(dst *bytes.Buffer, src []byte) error
+}
+
+// Gzip compresses payloads with gzip at the default level.
+var Gzip Compressor = gzipCompressor(gzip.DefaultCompression)
+
+// GzipLevel returns a gzip Compressor with the given compress/gzip level.
+func GzipLevel(level int) Compressor {
+	return gzipCompressor(level)
+}
+
+type gzipCompressor int
+
+func (gzipCompressor) Encoding() string { return "gzip" }
+
+func (c gzipCompressor) Compress(dst *bytes.Buffer, src []byte) error {
+	zw, err := gzip.NewWriterLevel(dst, int(c))
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(src); err != nil {
+		return err
+	}
+	return zw.Close()
+}
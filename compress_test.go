@@ -0,0 +1,148 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func gunzip(t *testing.T, b []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop after the first member, ignoring anything that follows it.
+	zr.Multistream(false)
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGzip(t *testing.T) {
+	for _, c := range []Compressor{Gzip, GzipLevel(gzip.BestSpeed)} {
+		var buf bytes.Buffer
+		if err := c.Compress(&buf, []byte("payload")); err != nil {
+			t.Fatal(err)
+		}
+		if c.Encoding() != "gzip" || gunzip(t, buf.Bytes()) != "payload" {
+			t.Errorf("%s: % x", c.Encoding(), buf.Bytes())
+		}
+	}
+	if err := GzipLevel(42).Compress(new(bytes.Buffer), nil); err == nil {
+		t.Error("accepted gzip level 42")
+	}
+}
+
+func TestNewPostRequest(t *testing.T) {
+	req, err := newPostRequest(context.Background(), "http://collector/logs", []byte("body"), Gzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(req.Body)
+	if req.Header.Get("Content-Encoding") != "gzip" || gunzip(t, b) != "body" {
+		t.Errorf("encoding %q, body % x", req.Header.Get("Content-Encoding"), b)
+	}
+	req, _ = newPostRequest(context.Background(), "http://collector/logs", []byte("body"), nil)
+	if b, _ := io.ReadAll(req.Body); req.Header.Get("Content-Encoding") != "" || string(b) != "body" {
+		t.Errorf("uncompressed: encoding %q, body %q", req.Header.Get("Content-Encoding"), b)
+	}
+}
+
+// TestTCPCompression checks that every batch is its own gzip member, so
+// the stream reads as one.
+func TestTCPCompression(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+
+	s := NewTCPSender(TCPConfig{Addr: ln.Addr().String(), Encoder: LogfmtEncoder{}, Compression: Gzip})
+	for _, msg := range []string{"one", "two"} {
+		if err := s.SendBatch(context.Background(), []*Entry{{Time: testTime, Level: Info, Message: msg}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+	b := <-received
+	if n := bytes.Count(b, []byte{0x1f, 0x8b, 8}); n != 2 {
+		t.Errorf("%d gzip members", n)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "msg=two") {
+		t.Errorf("stream %q", out)
+	}
+}
+
+// TestFluentCompression checks the CompressedPackedForward mode.
+func TestFluentCompression(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// The compressed option ends the message.
+		end := append(appendMsgpackString(nil, "compressed"), appendMsgpackString(nil, "gzip")...)
+		var msg []byte
+		buf := make([]byte, 4096)
+		for !bytes.HasSuffix(msg, end) {
+			n, err := conn.Read(buf)
+			msg = append(msg, buf[:n]...)
+			if err != nil {
+				return
+			}
+		}
+		received <- msg
+	}()
+
+	s := NewFluentSender(FluentConfig{Addr: ln.Addr().String(), Compress: true})
+	defer s.Close()
+	if err := s.SendBatch(context.Background(), []*Entry{{Time: testTime, Level: Info, Message: "hello"}}); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-received
+	if msg[0] != 0x93 {
+		t.Errorf("message has % x, want a 3-element array", msg[0])
+	}
+	i := bytes.Index(msg, []byte{0x1f, 0x8b})
+	if i < 0 {
+		t.Fatalf("message % x has no gzip payload", msg)
+	}
+	want := string(appendFluentEvent(nil, &Entry{Time: testTime, Level: Info, Message: "hello"}))
+	if got := gunzip(t, msg[i:]); got != want {
+		t.Errorf("events % x, want % x", got, want)
+	}
+}
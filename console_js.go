@@ -0,0 +1,55 @@
+//go:build js && wasm
+
+package logger
+
+import "syscall/js"
+
+// ConsoleSink writes entries to the browser console, Debug with
+// console.debug, Info and Notice with console.info, Warn with console.warn
+// and Error and above with console.error, so devtools filter them by
+// level. Fields are passed as an object that can be expanded, or as
+// key=value text in the logger_minimal build.
+//
+// The wasm runtime sends stdout to console.log as well; add the sink with
+// WithSinks and WithOutputLevel(Emergency+1) to keep the default output
+// from repeating every entry.
+type ConsoleSink struct {
+	console js.Value
+	json    js.Value
+}
+
+// NewConsoleSink creates a ConsoleSink.
+func NewConsoleSink() *ConsoleSink {
+	return &ConsoleSink{console: js.Global().Get("console"), json: js.Global().Get("JSON")}
+}
+
+// WriteEntry implements Sink.
+func (s *ConsoleSink) WriteEntry(e *Entry) error {
+	msg := e.Message
+	if e.Name != "" {
+		msg = "[" + e.Name + "] " + msg
+	}
+	args := []interface{}{msg}
+	if len(e.Fields) > 0 {
+		fields, err := s.fields(e)
+		if err != nil {
+			return err
+		}
+		args = append(args, fields)
+	}
+	s.console.Call(consoleMethod(e.Level), args...)
+	return nil
+}
+
+// consoleMethod returns the console method for a level.
+func consoleMethod(level LogLevel) string {
+	switch {
+	case level <= Debug:
+		return "debug"
+	case level < Warn:
+		return "info"
+	case level < Error:
+		return "warn"
+	}
+	return "error"
+}
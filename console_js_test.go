@@ -0,0 +1,43 @@
+//go:build js && wasm
+
+package logger
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestConsoleSink(t *testing.T) {
+	var calls [][]js.Value
+	console := js.Global().Get("Object").New()
+	for _, m := range []string{"debug", "info", "warn", "error"} {
+		m := m
+		f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			calls = append(calls, append([]js.Value{js.ValueOf(m)}, args...))
+			return nil
+		})
+		defer f.Release()
+		console.Set(m, f)
+	}
+	s := NewConsoleSink()
+	s.console = console
+
+	s.WriteEntry(&Entry{Level: Debug, Message: "probe"})
+	s.WriteEntry(&Entry{Level: Warn, Name: "db", Message: "slow", Fields: []Field{Int("ms", 250)}})
+	s.WriteEntry(&Entry{Level: Critical, Message: "down"})
+
+	if len(calls) != 3 {
+		t.Fatalf("%d console calls, want 3", len(calls))
+	}
+	if calls[0][0].String() != "debug" || calls[2][0].String() != "error" {
+		t.Errorf("methods %s, %s", calls[0][0], calls[2][0])
+	}
+	warn := calls[1]
+	fieldsOK := func(v js.Value) bool { return v.Get("ms").Int() == 250 }
+	if jsonEncoder() == nil {
+		fieldsOK = func(v js.Value) bool { return v.String() == "ms=250" }
+	}
+	if warn[0].String() != "warn" || warn[1].String() != "[db] slow" || !fieldsOK(warn[2]) {
+		t.Errorf("warn call = %v", warn)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build js && wasm && !logger_minimal
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// fields returns the fields of e as a JavaScript object.
+func (s *ConsoleSink) fields(e *Entry) (js.Value, error) {
+	b, err := json.Marshal(e.FieldMap())
+	if err != nil {
+		return js.Undefined(), fmt.Errorf(EncodeErrFmt, err)
+	}
+	return s.json.Call("parse", string(b)), nil
+}
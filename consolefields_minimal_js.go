@@ -0,0 +1,16 @@
+//go:build js && wasm && logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+)
+
+// fields returns the fields of e as key=value text, as TextEncoder writes
+// them.
+func (s *ConsoleSink) fields(e *Entry) (string, error) {
+	var buf bytes.Buffer
+	appendFields(&buf, e.Fields)
+	return strings.TrimPrefix(buf.String(), " "), nil
+}
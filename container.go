@@ -0,0 +1,129 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ContainerKey is the group holding the container metadata fields.
+const ContainerKey = "container"
+
+// ContainerImageEnv optionally names the image, which the kernel does not
+// expose to the container.
+const ContainerImageEnv = "CONTAINER_IMAGE"
+
+// Files describing the container.
+const (
+	procCgroup    = "/proc/self/cgroup"
+	procMountinfo = "/proc/self/mountinfo"
+	dockerEnv     = "/.dockerenv"
+	podmanEnv     = "/run/.containerenv"
+)
+
+// containerIDPattern matches the 64 hex digit IDs of Docker, containerd,
+// CRI-O and Podman in cgroup paths and mount sources.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// ContainerFields returns a container group with the ID, runtime and, if
+// known, image and name of the container the process runs in, or nil
+// outside a container. The ID is taken from the cgroup paths (cgroup v1)
+// or from the mounts of /etc/hostname (cgroup v2); Podman's
+// /run/.containerenv supplies the image and name.
+func ContainerFields() []Field {
+	var runtime, id, image, name string
+	if _, err := os.Stat(dockerEnv); err == nil {
+		runtime = "docker"
+	}
+	if info, err := readContainerEnv(podmanEnv); err == nil {
+		runtime = info["engine"]
+		if i := strings.IndexByte(runtime, '-'); i > 0 {
+			runtime = runtime[:i]
+		}
+		if runtime == "" {
+			runtime = "podman"
+		}
+		id, image, name = info["id"], info["image"], info["name"]
+	}
+	if id == "" {
+		id = findContainerID(procCgroup, "")
+	}
+	if id == "" {
+		id = findContainerID(procMountinfo, "/hostname")
+	}
+	if id == "" && runtime == "" {
+		return nil
+	}
+	if image == "" {
+		image = os.Getenv(ContainerImageEnv)
+	}
+
+	var fields []Field
+	for _, f := range []Field{
+		String("id", id),
+		String("runtime", runtime),
+		String("image", image),
+		String("name", name),
+	} {
+		if f.String != "" {
+			fields = append(fields, f)
+		}
+	}
+	return []Field{Group(ContainerKey, fields...)}
+}
+
+// findContainerID returns the first container ID in the lines of path that
+// contain substr.
+func findContainerID(path, substr string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.Contains(line, substr) {
+			continue
+		}
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// readContainerEnv parses the key="value" lines of /run/.containerenv.
+func readContainerEnv(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info := make(map[string]string)
+	for _, line := range strings.Split(string(b), "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if s, err := strconv.Unquote(v); err == nil {
+			v = s
+		}
+		info[k] = v
+	}
+	return info, nil
+}
+
+// WithContainer attaches ContainerFields, read once, to every entry.
+func WithContainer() Option {
+	fields := ContainerFields()
+	return func(l *CustomLogger) {
+		if fields != nil {
+			l.processors = append(l.processors, StaticFields(fields...))
+		}
+	}
+}
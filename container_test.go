@@ -0,0 +1,51 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindContainerID(t *testing.T) {
+	id := strings.Repeat("3f2a9c1b", 8)
+	other := strings.Repeat("0123abcd", 8)
+	dir := t.TempDir()
+	cgroup := filepath.Join(dir, "cgroup")
+	os.WriteFile(cgroup, []byte("12:pids:/\n11:memory:/docker/"+id+"\n10:cpu:/docker/"+other+"\n"), 0o644)
+	mountinfo := filepath.Join(dir, "mountinfo")
+	os.WriteFile(mountinfo, []byte(
+		"610 590 0:52 / / rw - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/"+other+"/diff\n"+
+			"620 610 254:1 /var/lib/docker/containers/"+id+"/hostname /etc/hostname rw - ext4 /dev/vda1 rw\n"), 0o644)
+
+	if got := findContainerID(cgroup, ""); got != id {
+		t.Errorf("cgroup v1: %q", got)
+	}
+	if got := findContainerID(mountinfo, "/hostname"); got != id {
+		t.Errorf("mountinfo: %q", got)
+	}
+	if got := findContainerID(filepath.Join(dir, "missing"), ""); got != "" {
+		t.Errorf("missing file: %q", got)
+	}
+	os.WriteFile(cgroup, []byte("0::/\n"), 0o644)
+	if got := findContainerID(cgroup, ""); got != "" {
+		t.Errorf("cgroup v2 outside a container: %q", got)
+	}
+}
+
+func TestReadContainerEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".containerenv")
+	os.WriteFile(path, []byte("engine=\"podman-4.9.3\"\nname=\"api\"\nimage=\"quay.io/acme/api:1.2\"\nrootless=1\n"), 0o644)
+	info, err := readContainerEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["engine"] != "podman-4.9.3" || info["name"] != "api" || info["image"] != "quay.io/acme/api:1.2" || info["rootless"] != "1" {
+		t.Errorf("info %v", info)
+	}
+	if _, err := readContainerEnv(path + ".missing"); err == nil {
+		t.Error("missing file read")
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, see FromContext.
+func NewContext(ctx context.Context, l *CustomLogger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or fallback
+// if there is none. HTTP handlers behind Middleware use it to get the
+// request-scoped logger:
+//
+//	log := logger.FromContext(r.Context(), base)
+func FromContext(ctx context.Context, fallback *CustomLogger) *CustomLogger {
+	if l, ok := ctx.Value(contextKey{}).(*CustomLogger); ok {
+		return l
+	}
+	return fallback
+}
+
+// ContextExtractor returns fields derived from a context, such as a tenant
+// or correlation ID. It returns nil when the context has none.
+type ContextExtractor func(ctx context.Context) []Field
+
+// ContextValue returns an extractor adding ctx.Value(key) as the field
+// name when it is set:
+//
+//	logger.WithContextFields(logger.ContextValue(tenantKey{}, "tenant"))
+func ContextValue(key interface{}, name string) ContextExtractor {
+	return func(ctx context.Context) []Field {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		return []Field{Any(name, v)}
+	}
+}
+
+// LogContext writes msg at level like Log, adding the fields of the
+// logger's context extractors for ctx before fields.
+func (l *CustomLogger) LogContext(ctx context.Context, level LogLevel, msg string, fields ...Field) {
+	if !l.levelEnabled(level) || !l.traceAllows(ctx, level) {
+		return
+	}
+	if extra := l.contextFields(ctx); len(extra) > 0 {
+		fields = append(extra, fields...)
+	}
+	l.log(level, msg, fields...)
+}
+
+// Ctx returns a child logger carrying the fields extracted from ctx, for
+// several calls within one context.
+func (l *CustomLogger) Ctx(ctx context.Context) *CustomLogger {
+	return l.With(l.contextFields(ctx)...)
+}
+
+func (l *CustomLogger) contextFields(ctx context.Context) []Field {
+	var fields []Field
+	for _, x := range l.extractors {
+		fields = append(fields, x(ctx)...)
+	}
+	return fields
+}
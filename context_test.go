@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	if FromContext(context.Background(), l) != l {
+		t.Error("no fallback for an empty context")
+	}
+	child := l.With(String("request_id", "r1"))
+	if l.With() != l {
+		t.Error("With without fields made a copy")
+	}
+	FromContext(NewContext(context.Background(), child), l).Log(Info, "handled", Int("status", 200))
+	l.Info("plain")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "handled request_id=r1 status=200") || strings.Contains(lines[1], "request_id") {
+		t.Errorf("log %q", lines)
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	LevelExistsErrFmt = "Level %d is already defined as %s"
+	LevelRangeErrFmt  = "Level %d is outside the supported range %d to %d"
+)
+
+// ErrLevelName is returned for an empty custom level name.
+var ErrLevelName = errors.New("custom level needs a name")
+
+// levelInfo describes a registered custom level.
+type levelInfo struct {
+	name   string
+	prefix string
+}
+
+// customLevels holds the registered levels. mu serializes registration;
+// lookups on the encoding path go through the sync.Map without locking.
+var customLevels struct {
+	mu     sync.Mutex
+	levels sync.Map // LogLevel -> levelInfo
+}
+
+// RegisterLevel defines a custom level with the given numeric severity,
+// name and text prefix, e.g.
+//
+//	const Audit logger.LogLevel = 10
+//	logger.RegisterLevel(Audit, "AUDIT", " AUDIT: ")
+//
+// Custom levels are filtered by severity like the built-in ones, so a
+// level above Error is written whatever the configured minimum. Log
+// entries at them with CustomLogger.Log. An empty prefix is derived from
+// the name. Levels are process-wide and should be registered at startup.
+// They must fit in 16 bits and lie below Silent.
+func RegisterLevel(level LogLevel, name, prefix string) error {
+	if name == "" {
+		return ErrLevelName
+	}
+	if level < math.MinInt16 || level >= Silent {
+		return fmt.Errorf(LevelRangeErrFmt, level, math.MinInt16, Silent-1)
+	}
+	if prefix == "" {
+		prefix = " " + strings.ToUpper(name) + ": "
+	}
+
+	customLevels.mu.Lock()
+	defer customLevels.mu.Unlock()
+	if s, ok := builtinLevelName(level); ok {
+		return fmt.Errorf(LevelExistsErrFmt, level, s)
+	}
+	if info, ok := customLevels.levels.Load(level); ok {
+		return fmt.Errorf(LevelExistsErrFmt, level, info.(levelInfo).name)
+	}
+	customLevels.levels.Store(level, levelInfo{name: name, prefix: prefix})
+	return nil
+}
+
+// lookupLevel returns the registered custom level.
+func lookupLevel(level LogLevel) (levelInfo, bool) {
+	info, ok := customLevels.levels.Load(level)
+	if !ok {
+		return levelInfo{}, false
+	}
+	return info.(levelInfo), true
+}
+
+// ParseLevel returns the built-in or registered level with the given name,
+// ignoring case.
+func ParseLevel(name string) (LogLevel, bool) {
+	for _, l := range builtinLevels {
+		if strings.EqualFold(l.String(), name) {
+			return l, true
+		}
+	}
+	var found LogLevel
+	var ok bool
+	customLevels.levels.Range(func(k, v interface{}) bool {
+		if strings.EqualFold(v.(levelInfo).name, name) {
+			found, ok = k.(LogLevel), true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
@@ -0,0 +1,75 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDatadogSender(t *testing.T) {
+	var docs [][]map[string]interface{}
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&batch)
+		docs = append(docs, batch)
+		key = r.Header.Get("DD-API-KEY")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := NewDatadogSender(DatadogConfig{
+		APIKey: "k", URL: srv.URL, Source: "go", Host: "h1",
+		Tags: []string{"env:prod"}, TagFields: []string{"region"},
+	})
+	e := &Entry{Time: testTime, Level: Critical, Name: "api", Message: "down",
+		Fields: []Field{String("region", "eu"), String("service", "billing"), Int("n", 2)}}
+	batch := make([]*Entry, datadogMaxBatch+1)
+	for i := range batch {
+		batch[i] = e
+	}
+	if err := s.SendBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || len(docs[0]) != datadogMaxBatch || len(docs[1]) != 1 || key != "k" {
+		t.Fatalf("%d requests with key %q", len(docs), key)
+	}
+	doc := docs[1][0]
+	want := map[string]interface{}{
+		"message": "down", "status": "critical", "timestamp": float64(testTime.UnixMilli()),
+		"hostname": "h1", "service": "billing", "ddsource": "go", "ddtags": "env:prod,region:eu",
+		"logger.name": "api", "n": float64(2),
+	}
+	if len(doc) != len(want) {
+		t.Errorf("doc %v", doc)
+	}
+	for k, v := range want {
+		if doc[k] != v {
+			t.Errorf("%s = %v, want %v", k, doc[k], v)
+		}
+	}
+
+	// The service defaults to the logger name.
+	docs = nil
+	if err := s.SendBatch(context.Background(), []*Entry{{Time: testTime, Level: Debug, Name: "worker"}}); err != nil {
+		t.Fatal(err)
+	}
+	if doc := docs[0][0]; doc["service"] != "worker" || doc["status"] != "debug" {
+		t.Errorf("doc %v", doc)
+	}
+}
+
+func TestDatadogStatus(t *testing.T) {
+	for level, want := range map[LogLevel]string{
+		Debug: "debug", Info: "info", Notice: "notice", Warn: "warn", Error: "error",
+		Critical: "critical", Alert: "alert", Emergency: "emergency",
+	} {
+		if got := datadogStatus(level); got != want {
+			t.Errorf("%v: %q, want %q", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	DefaultReplayBatchSize = 100
+
+	DeadLetterFmt        = "Delivery failed, %d entries written to dead-letter file %s: %w"
+	DeadLetterSpillFmt   = "Failed to write dead-letter file: %w"
+	DeadLetterReadErrFmt = "Failed to read dead-letter file: %w"
+)
+
+// DeadLetter is a local file that keeps batches a remote destination
+// ultimately refused, one JSON encoded entry per line, so they can be
+// replayed once the collector recovers.
+type DeadLetter struct {
+	// mu guards the file; replay serializes Replay calls, which send
+	// without holding mu so batches can be spilled meanwhile.
+	mu     sync.Mutex
+	replay sync.Mutex
+	path   string
+}
+
+// NewDeadLetter creates a dead-letter file at path. The file is created on
+// the first spill.
+func NewDeadLetter(path string) *DeadLetter {
+	return &DeadLetter{path: path}
+}
+
+// Path returns the location of the dead-letter file.
+func (d *DeadLetter) Path() string {
+	return d.path
+}
+
+// Spill appends the batch to the dead-letter file.
+func (d *DeadLetter) Spill(batch []*Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, FileModeRW)
+	if err != nil {
+		return fmt.Errorf(DeadLetterSpillFmt, err)
+	}
+
+	if err = writeEntries(f, batch); err != nil {
+		return fmt.Errorf(DeadLetterSpillFmt, err)
+	}
+	return nil
+}
+
+// Replay re-sends the spilled entries through sender in batches of
+// batchSize. Delivered entries are removed from the file; if a batch fails
+// the remaining entries are kept for the next attempt and the error is
+// returned. It returns the number of entries delivered. Batches spilled
+// while Replay runs are kept for the next one. A sender spilling to d, see
+// WithDeadLetter, is replayed through the sender it wraps, so a failed
+// batch is not spilled a second time.
+func (d *DeadLetter) Replay(ctx context.Context, sender BatchSender, batchSize int) (int, error) {
+	d.replay.Lock()
+	defer d.replay.Unlock()
+
+	if batchSize <= 0 {
+		batchSize = DefaultReplayBatchSize
+	}
+	if s, ok := sender.(*deadLetterSender); ok && s.dl == d {
+		sender = s.sender
+	}
+
+	d.mu.Lock()
+	entries, err := readDeadLetter(d.path)
+	d.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for sent < len(entries) {
+		end := sent + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err = sender.SendBatch(ctx, entries[sent:end]); err != nil {
+			break
+		}
+		sent = end
+	}
+	if sent == 0 {
+		return 0, err
+	}
+
+	// Spills only append, so the entries sent still lead the file.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	all, rerr := readDeadLetter(d.path)
+	if rerr != nil {
+		return sent, rerr
+	}
+	if sent >= len(all) {
+		if rerr := os.Remove(d.path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+			return sent, rerr
+		}
+		return sent, err
+	}
+	if werr := rewriteDeadLetter(d.path, all[sent:]); werr != nil {
+		return sent, werr
+	}
+	return sent, err
+}
+
+// readDeadLetter loads every entry from a dead-letter file. A missing file
+// holds no entries.
+func readDeadLetter(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(DeadLetterReadErrFmt, err)
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		e := new(Entry)
+		if err := dec.Decode(e); err != nil {
+			return nil, fmt.Errorf(DeadLetterReadErrFmt, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// rewriteDeadLetter atomically replaces the file with the given entries.
+func rewriteDeadLetter(path string, entries []*Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf(DeadLetterSpillFmt, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = writeEntries(tmp, entries); err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf(DeadLetterSpillFmt, err)
+	}
+	return nil
+}
+
+// writeEntries encodes the entries as JSON lines into f and closes it.
+func writeEntries(f *os.File, entries []*Entry) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	var err error
+	for _, e := range entries {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// deadLetterSender spills batches its sender could not deliver.
+type deadLetterSender struct {
+	sender BatchSender
+	dl     *DeadLetter
+}
+
+// WithDeadLetter wraps sender so batches that still fail, typically after
+// WithRetry has given up, are spilled to dl instead of being lost.
+func WithDeadLetter(sender BatchSender, dl *DeadLetter) BatchSender {
+	return &deadLetterSender{sender: sender, dl: dl}
+}
+
+// Acknowledges implements Acknowledger for the wrapped sender.
+func (s *deadLetterSender) Acknowledges() bool {
+	return acknowledges(s.sender)
+}
+
+// SendBatch implements BatchSender.
+func (s *deadLetterSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	err := s.sender.SendBatch(ctx, batch)
+	if err == nil {
+		return nil
+	}
+	if serr := s.dl.Spill(batch); serr != nil {
+		return errors.Join(err, serr)
+	}
+	return fmt.Errorf(DeadLetterFmt, len(batch), s.dl.path, err)
+}
@@ -0,0 +1,63 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterReplay(t *testing.T) {
+	dl := NewDeadLetter(filepath.Join(t.TempDir(), "dead.jsonl"))
+	remote := &recordSender{err: errors.New("down")}
+	sender := WithDeadLetter(remote, dl)
+	if err := sender.SendBatch(context.Background(), []*Entry{{Message: "a"}, {Message: "b"}, {Message: "c"}}); err == nil {
+		t.Fatal("failed batch reported as delivered")
+	}
+
+	// Replaying through the wrapping sender must neither deadlock nor
+	// spill the failed batch again.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if n, err := dl.Replay(context.Background(), sender, 2); n != 0 || err == nil {
+			t.Errorf("Replay = %d, %v while the remote is down", n, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Replay through WithDeadLetter deadlocked")
+	}
+	if entries, _ := readDeadLetter(dl.Path()); len(entries) != 3 {
+		t.Errorf("%d entries kept, want 3", len(entries))
+	}
+
+	remote.err = nil
+	remote.total.Store(0)
+	n, err := dl.Replay(context.Background(), sender, 2)
+	if err != nil || n != 3 || remote.total.Load() != 3 {
+		t.Errorf("Replay = %d, %v; delivered %d", n, err, remote.total.Load())
+	}
+	if entries, _ := readDeadLetter(dl.Path()); len(entries) != 0 {
+		t.Errorf("%d entries left after replay", len(entries))
+	}
+}
+
+func TestDeadLetterSpillDuringReplay(t *testing.T) {
+	dl := NewDeadLetter(filepath.Join(t.TempDir(), "dead.jsonl"))
+	dl.Spill([]*Entry{{Message: "old"}})
+	sender := BatchSenderFunc(func(context.Context, []*Entry) error {
+		return dl.Spill([]*Entry{{Message: "new"}})
+	})
+	if n, err := dl.Replay(context.Background(), sender, 0); n != 1 || err != nil {
+		t.Fatalf("Replay = %d, %v", n, err)
+	}
+	entries, err := readDeadLetter(dl.Path())
+	if err != nil || len(entries) != 1 || entries[0].Message != "new" {
+		t.Errorf("kept %v, %v; want the entry spilled during replay", entries, err)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDebugHeader is the request header asking for a request to be
+	// logged at Debug, see MiddlewareConfig.DebugTokens.
+	DefaultDebugHeader = "X-Debug-Log"
+	// DebugRequestKey marks the entries of a request logged at Debug.
+	DebugRequestKey = "debug_request"
+	// MaxDebugTokenAge bounds how far in the future a signed debug token
+	// may expire, so a leaked token is of little use.
+	MaxDebugTokenAge = 24 * time.Hour
+)
+
+// DebugToken returns a value of the debug header accepted by Middleware
+// configured with DebugKey until expires:
+//
+//	curl -H "X-Debug-Log: $(token)" https://api.example.com/orders/42
+//
+// It is the expiry in Unix seconds and its HMAC-SHA256 under key in hex,
+// joined by a dot.
+func DebugToken(key []byte, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + debugMAC(key, exp)
+}
+
+func debugMAC(key []byte, exp string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// debugRequested reports whether r carries a debug header that cfg
+// accepts: one of DebugTokens, or a token of DebugKey not yet expired.
+func (cfg *MiddlewareConfig) debugRequested(r *http.Request, now time.Time) bool {
+	v := r.Header.Get(cfg.DebugHeader)
+	if v == "" {
+		return false
+	}
+	for _, t := range cfg.DebugTokens {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(t)) == 1 {
+			return true
+		}
+	}
+	if cfg.DebugKey == nil {
+		return false
+	}
+	exp, mac, ok := strings.Cut(v, ".")
+	if !ok {
+		return false
+	}
+	sec, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return false
+	}
+	expires := time.Unix(sec, 0)
+	if !now.Before(expires) || expires.Sub(now) > MaxDebugTokenAge {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(debugMAC(cfg.DebugKey, exp)))
+}
@@ -0,0 +1,46 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareDebugHeader(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	key := []byte("debug secret")
+	h := Middleware(l, MiddlewareConfig{DebugTokens: []string{"let-me-see"}, DebugKey: key})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context(), l).Log(Debug, "cache miss")
+	}))
+	for _, tt := range []struct {
+		name, token string
+		debug       bool
+	}{
+		{"none", "", false},
+		{"allowed", "let-me-see", true},
+		{"unknown", "let-me-in", false},
+		{"signed", DebugToken(key, testTime.Add(time.Hour)), true},
+		{"expired", DebugToken(key, testTime.Add(-time.Second)), false},
+		{"too long", DebugToken(key, testTime.Add(48*time.Hour)), false},
+		{"other key", DebugToken([]byte("guess"), testTime.Add(time.Hour)), false},
+	} {
+		buf.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+		if tt.token != "" {
+			r.Header.Set(DefaultDebugHeader, tt.token)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if got := strings.Contains(buf.String(), "debug_request=true"); got != tt.debug {
+			t.Errorf("%s: output %q", tt.name, buf.String())
+		}
+	}
+	if l.enabled(Debug) {
+		t.Error("the debug request changed the level of the logger")
+	}
+}
@@ -0,0 +1,39 @@
+package logger
+
+import "time"
+
+// DeterministicTime is the timestamp of every entry in deterministic mode.
+var DeterministicTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DeterministicKeys are the volatile fields replaced in deterministic mode
+// when WithDeterministic is given no keys.
+var DeterministicKeys = []string{
+	"goroutine", "duration", "elapsed", "latency",
+	"seq", "id", "request_id", "trace_id", "span_id", "pid",
+}
+
+// WithDeterministic makes output stable for golden-file tests: every entry
+// is stamped with DeterministicTime and the values of the given fields, or
+// DeterministicKeys if none are given, are replaced by "<key>". The
+// replacement runs after all processors.
+func WithDeterministic(keys ...string) Option {
+	return func(l *CustomLogger) {
+		if len(keys) == 0 {
+			keys = DeterministicKeys
+		}
+		l.clock = func() time.Time { return DeterministicTime }
+		l.placeholders = make(map[string]string, len(keys))
+		for _, k := range keys {
+			l.placeholders[k] = "<" + k + ">"
+		}
+	}
+}
+
+// applyPlaceholders replaces volatile field values in deterministic mode.
+func (l *CustomLogger) applyPlaceholders(e *Entry) {
+	for i, f := range e.Fields {
+		if p, ok := l.placeholders[f.Key]; ok {
+			e.Fields[i] = String(f.Key, p)
+		}
+	}
+}
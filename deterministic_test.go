@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDeterministic(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}), WithProcessors(GoroutineID()), WithDeterministic())
+	l.Log(Info, "served", String("request_id", NewRequestID()), Duration("latency", 3*time.Millisecond), Int("status", 200))
+	want := "time=2000-01-01T00:00:00Z level=info logger=test msg=served request_id=<request_id> latency=<latency> status=200 goroutine=<goroutine>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// Given keys replace the defaults.
+	buf.Reset()
+	l = newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}), WithDeterministic("user"))
+	l.Log(Info, "login", String("user", "ann"), String("request_id", "r1"))
+	want = "time=2000-01-01T00:00:00Z level=info logger=test msg=login user=<user> request_id=r1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultDiskCheckInterval = 30 * time.Second
+
+	LowDiskFmt       = "Low disk space: %d bytes free below %d byte threshold, %s"
+	DiskRecoveredFmt = "Disk space recovered: %d bytes free, resuming normal logging"
+	DiskCheckErrFmt  = "Failed to check free disk space: %w"
+	PruneErrFmt      = "Failed to prune log archives: %w"
+)
+
+// LowDiskAction is what the logger does while free disk space is low.
+type LowDiskAction int
+
+const (
+	// LowDiskRaiseLevel raises the minimum level of every module to
+	// DiskGuardConfig.RaiseTo.
+	LowDiskRaiseLevel LowDiskAction = iota
+	// LowDiskStop stops writing to the log file; the entries are counted as
+	// dropped.
+	LowDiskStop
+	// LowDiskPrune rotates the log file and deletes archives, oldest
+	// first, until free space is back above MinFree, keeping the newest
+	// DiskGuardConfig.KeepArchives. It repeats on every check while space
+	// stays low. Archives still waiting for upload may be deleted.
+	LowDiskPrune
+)
+
+func (a LowDiskAction) String() string {
+	switch a {
+	case LowDiskStop:
+		return "writing to the log file stopped"
+	case LowDiskPrune:
+		return "pruning log archives"
+	}
+	return "minimum level raised"
+}
+
+// DiskGuardConfig configures the monitoring of free space on the log file's
+// filesystem.
+type DiskGuardConfig struct {
+	// MinFree is the threshold in bytes below which the action is taken.
+	MinFree uint64
+	// CheckInterval is the time between checks; zero selects
+	// DefaultDiskCheckInterval.
+	CheckInterval time.Duration
+	// Action is taken while free space stays below MinFree.
+	Action LowDiskAction
+	// RaiseTo is the minimum level used by LowDiskRaiseLevel; zero means Warn.
+	RaiseTo LogLevel
+	// KeepArchives is the number of newest archives LowDiskPrune never
+	// deletes.
+	KeepArchives int
+}
+
+// diskGuard periodically checks free space and degrades logging while it is
+// low, writing a warning when entering and leaving the degraded mode.
+type diskGuard struct {
+	l    *CustomLogger
+	file *File
+	cfg  DiskGuardConfig
+	low  atomic.Bool
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startDiskGuard returns nil if free space cannot be measured on this
+// platform, reporting that once.
+func startDiskGuard(l *CustomLogger, file *File, cfg DiskGuardConfig) *diskGuard {
+	if _, err := diskFree(filepath.Dir(file.Path())); errors.Is(err, errors.ErrUnsupported) {
+		l.errorHandler(fmt.Errorf(DiskCheckErrFmt, err))
+		return nil
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultDiskCheckInterval
+	}
+	if cfg.RaiseTo == 0 {
+		cfg.RaiseTo = Warn
+	}
+
+	g := &diskGuard{
+		l:    l,
+		file: file,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	g.check()
+	go g.run()
+	return g
+}
+
+func (g *diskGuard) run() {
+	defer close(g.done)
+
+	ticker := time.NewTicker(g.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.check()
+		}
+	}
+}
+
+func (g *diskGuard) check() {
+	free, err := diskFree(filepath.Dir(g.file.Path()))
+	if err != nil {
+		g.l.errorHandler(fmt.Errorf(DiskCheckErrFmt, err))
+		return
+	}
+
+	low := free < g.cfg.MinFree
+	if low && g.cfg.Action == LowDiskPrune {
+		if !g.low.Load() {
+			g.l.log(Warn, fmt.Sprintf(LowDiskFmt, free, g.cfg.MinFree, g.cfg.Action))
+		}
+		free = g.prune(free)
+		low = free < g.cfg.MinFree
+	}
+	if low == g.low.Load() {
+		return
+	}
+	g.low.Store(low)
+
+	if low {
+		if g.cfg.Action == LowDiskPrune {
+			return
+		}
+		// Warn first, while the file still takes the entry.
+		g.l.log(Warn, fmt.Sprintf(LowDiskFmt, free, g.cfg.MinFree, g.cfg.Action))
+		switch g.cfg.Action {
+		case LowDiskRaiseLevel:
+			g.l.levels.setFloor(&g.cfg.RaiseTo)
+		case LowDiskStop:
+			g.file.suspend(true)
+		}
+		return
+	}
+
+	g.l.levels.setFloor(nil)
+	g.file.suspend(false)
+	g.l.log(Warn, fmt.Sprintf(DiskRecoveredFmt, free))
+}
+
+// prune rotates the log file and deletes the oldest archives while free
+// space is below the threshold, returning the free space left.
+func (g *diskGuard) prune(free uint64) uint64 {
+	g.file.mu.Lock()
+	size := g.file.size
+	g.file.mu.Unlock()
+	if size > 0 {
+		if err := g.file.Rotate(); err != nil {
+			g.l.errorHandler(fmt.Errorf(PruneErrFmt, err))
+		}
+	}
+	dir := filepath.Dir(g.file.Path())
+	archives := listArchives(g.file.Path(), g.file.cfg.Rotation.ArchiveTemplate, g.file.CurrentPath())
+	for len(archives) > g.cfg.KeepArchives && free < g.cfg.MinFree {
+		if err := os.Remove(archives[0]); err != nil {
+			g.l.errorHandler(fmt.Errorf(PruneErrFmt, err))
+		}
+		os.Remove(archives[0] + SignatureExt)
+		archives = archives[1:]
+		var err error
+		if free, err = diskFree(dir); err != nil {
+			g.l.errorHandler(fmt.Errorf(DiskCheckErrFmt, err))
+			break
+		}
+	}
+	return free
+}
+
+func (g *diskGuard) close() {
+	close(g.stop)
+	<-g.done
+}
+
+// LowDisk reports whether the disk guard currently sees low free space.
+func (l *CustomLogger) LowDisk() bool {
+	return l.diskGuard != nil && l.diskGuard.low.Load()
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskGuardLowDisk(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("free space is not measured here")
+	}
+	tests := []struct {
+		action  LowDiskAction
+		written []string
+		missing []string
+	}{
+		{LowDiskStop, []string{"writing to the log file stopped"}, []string{"after"}},
+		{LowDiskRaiseLevel, []string{"minimum level raised", "kept"}, []string{"after"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "app.log")
+		// No disk has this much free, so the guard trips on its first check.
+		l, err := New(Info, "test", path, WithDiskGuard(DiskGuardConfig{
+			MinFree:       math.MaxUint64,
+			CheckInterval: time.Hour,
+			Action:        tt.action,
+			RaiseTo:       Error,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info("after")
+		l.Error("%s", "kept")
+		l.Close(context.Background())
+
+		data, _ := os.ReadFile(path)
+		for _, want := range tt.written {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s: %q missing from\n%s", tt.action, want, data)
+			}
+		}
+		for _, unwanted := range tt.missing {
+			if strings.Contains(string(data), unwanted) {
+				t.Errorf("%s: %q written to\n%s", tt.action, unwanted, data)
+			}
+		}
+	}
+}
@@ -0,0 +1,559 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDiskQueueBytes is the disk budget of a DiskQueue.
+	DefaultDiskQueueBytes = 256 << 20
+	// DefaultDiskQueueSegment is the size at which a DiskQueue starts a new
+	// segment file.
+	DefaultDiskQueueSegment = 16 << 20
+
+	DiskQueueOpenErrFmt  = "Failed to open disk queue %s: %w"
+	DiskQueueWriteErrFmt = "Failed to write to disk queue: %w"
+	DiskQueueEvictFmt    = "Disk queue over budget, dropped %d queued entries"
+
+	diskQueueExt    = ".wal"
+	diskQueueCursor = "cursor"
+)
+
+// DiskQueueConfig configures a DiskQueue.
+type DiskQueueConfig struct {
+	// Dir holds the segment files and the read position; it is created if
+	// missing and must not be shared by two queues.
+	Dir string
+	// MaxBytes is the disk budget. Once it is reached the oldest segment
+	// is dropped to make room; zero selects DefaultDiskQueueBytes.
+	MaxBytes int64
+	// SegmentBytes is the size of a segment file; zero selects
+	// DefaultDiskQueueSegment, or a quarter of MaxBytes if that is less.
+	SegmentBytes int64
+	// BatchEntries is the largest batch sent; zero selects
+	// DefaultBatchEntries.
+	BatchEntries int
+	// Sync calls fsync after every entry, so entries survive a crash of
+	// the machine and not only of the process, at a large cost in
+	// throughput.
+	Sync bool
+	// Retry sets the backoff between failed deliveries; MaxAttempts is
+	// ignored, as the queue retries until the batch is delivered. The zero
+	// value selects DefaultRetryPolicy.
+	Retry RetryPolicy
+	// SendTimeout bounds the delivery of one batch; zero selects
+	// DefaultSendTimeout.
+	SendTimeout time.Duration
+	// ErrorHandler receives delivery errors and evictions; nil selects
+	// DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+// diskSegment is a segment file of a DiskQueue.
+type diskSegment struct {
+	seq     uint64
+	size    int64
+	entries int
+}
+
+// DiskQueue is a Sink that appends entries to a write-ahead log on disk
+// and delivers them through a BatchSender from there, so they survive
+// process restarts and collector outages. Failed batches are retried with
+// backoff until they are delivered, the log drains by itself once the
+// collector is back, and a queue reopened on the same directory resumes
+// where the last one stopped. A batch is only removed from the log after
+// it was sent, so a crash between the two sends it again.
+//
+// Entries are stored as JSON lines in segment files that are deleted once
+// delivered; a batch the sender rejects as permanent is dropped.
+type DiskQueue struct {
+	sender BatchSender
+	cfg    DiskQueueConfig
+
+	mu     sync.Mutex
+	segs   []diskSegment // oldest first; the last is written to
+	w      *os.File
+	buf    bytes.Buffer
+	total  int64
+	rseq   uint64 // segment and offset of the next entry to send
+	roff   int64
+	closed bool
+
+	notify  chan struct{}
+	flush   chan chan error
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	health  healthTracker
+}
+
+// NewDiskQueue opens the queue in cfg.Dir, creating it if needed, and
+// starts delivering the entries it holds through sender.
+func NewDiskQueue(sender BatchSender, cfg DiskQueueConfig) (*DiskQueue, error) {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultDiskQueueBytes
+	}
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = DefaultDiskQueueSegment
+		if cfg.SegmentBytes > cfg.MaxBytes/4 {
+			cfg.SegmentBytes = cfg.MaxBytes / 4
+		}
+	}
+	if cfg.BatchEntries <= 0 {
+		cfg.BatchEntries = DefaultBatchEntries
+	}
+	if cfg.Retry.InitialBackoff <= 0 {
+		cfg.Retry = DefaultRetryPolicy
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = DefaultSendTimeout
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+	if !acknowledges(sender) {
+		cfg.ErrorHandler(ErrNoAcks)
+	}
+
+	q := &DiskQueue{
+		sender: sender,
+		cfg:    cfg,
+		notify: make(chan struct{}, 1),
+		flush:  make(chan chan error),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := q.open(); err != nil {
+		return nil, fmt.Errorf(DiskQueueOpenErrFmt, cfg.Dir, err)
+	}
+	go q.run()
+	return q, nil
+}
+
+// open loads the segments and the read position and starts a new segment,
+// so a line torn by a crash is never appended to.
+func (q *DiskQueue) open() error {
+	if err := os.MkdirAll(q.cfg.Dir, DirModeRWX); err != nil {
+		return err
+	}
+	names, err := filepath.Glob(filepath.Join(q.cfg.Dir, "*"+diskQueueExt))
+	if err != nil {
+		return err
+	}
+	var seqs []uint64
+	for _, name := range names {
+		if seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), diskQueueExt), 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	rseq, roff := q.readCursor()
+	for _, seq := range seqs {
+		if seq < rseq {
+			os.Remove(q.segmentPath(seq))
+			continue
+		}
+		seg := diskSegment{seq: seq}
+		off := int64(0)
+		if seq == rseq {
+			off = roff
+		}
+		if seg.size, seg.entries, err = countLines(q.segmentPath(seq), off); err != nil {
+			return err
+		}
+		q.segs = append(q.segs, seg)
+		q.total += seg.size
+	}
+	next := uint64(1)
+	if len(q.segs) > 0 {
+		next = q.segs[len(q.segs)-1].seq + 1
+	}
+	if len(q.segs) == 0 || q.segs[0].seq != rseq {
+		roff = 0
+	}
+	if err := q.rotate(next); err != nil {
+		return err
+	}
+	q.rseq, q.roff = q.segs[0].seq, roff
+	return nil
+}
+
+// countLines returns the size of a segment and the number of entries
+// after off.
+func countLines(path string, off int64) (int64, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	n := 0
+	buf := make([]byte, 64<<10)
+	for {
+		m, err := f.Read(buf)
+		n += bytes.Count(buf[:m], []byte{'\n'})
+		if err == io.EOF {
+			return info.Size(), n, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+func (q *DiskQueue) segmentPath(seq uint64) string {
+	return filepath.Join(q.cfg.Dir, fmt.Sprintf("%016d%s", seq, diskQueueExt))
+}
+
+// readCursor returns the saved read position, or the start if there is
+// none.
+func (q *DiskQueue) readCursor() (uint64, int64) {
+	b, err := os.ReadFile(filepath.Join(q.cfg.Dir, diskQueueCursor))
+	if err != nil {
+		return 0, 0
+	}
+	var seq uint64
+	var off int64
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seq, &off); err != nil {
+		return 0, 0
+	}
+	return seq, off
+}
+
+// saveCursor atomically replaces the saved read position. q.mu must be
+// held.
+func (q *DiskQueue) saveCursor() error {
+	path := filepath.Join(q.cfg.Dir, diskQueueCursor)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", q.rseq, q.roff)), FileModeRW); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// rotate closes the segment written to and starts segment seq. q.mu must
+// be held, except while opening.
+func (q *DiskQueue) rotate(seq uint64) error {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, FileModeRW)
+	if err != nil {
+		return err
+	}
+	if q.w != nil {
+		q.w.Close()
+	}
+	q.w = f
+	q.segs = append(q.segs, diskSegment{seq: seq})
+	return nil
+}
+
+// WriteEntry implements Sink, appending e to the log. After Close it
+// returns ErrSinkClosed.
+func (q *DiskQueue) WriteEntry(e *Entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrSinkClosed
+	}
+	q.buf.Reset()
+	if err := (JSONEncoder{}).Encode(&q.buf, e); err != nil {
+		return err
+	}
+	n := int64(q.buf.Len())
+	if n > q.cfg.MaxBytes {
+		q.dropped.Add(1)
+		return nil
+	}
+
+	active := &q.segs[len(q.segs)-1]
+	if active.size > 0 && active.size+n > q.cfg.SegmentBytes {
+		if err := q.rotate(active.seq + 1); err != nil {
+			return fmt.Errorf(DiskQueueWriteErrFmt, err)
+		}
+	}
+	evicted := 0
+	for q.total+n > q.cfg.MaxBytes && len(q.segs) > 1 {
+		evicted += q.evict()
+	}
+	if evicted > 0 {
+		q.dropped.Add(uint64(evicted))
+		q.cfg.ErrorHandler(fmt.Errorf(DiskQueueEvictFmt, evicted))
+	}
+	if q.total+n > q.cfg.MaxBytes {
+		// The segment being written alone is over budget.
+		q.dropped.Add(1)
+		return nil
+	}
+
+	if _, err := q.w.Write(q.buf.Bytes()); err != nil {
+		return fmt.Errorf(DiskQueueWriteErrFmt, err)
+	}
+	if q.cfg.Sync {
+		if err := q.w.Sync(); err != nil {
+			return fmt.Errorf(DiskQueueWriteErrFmt, err)
+		}
+	}
+	active = &q.segs[len(q.segs)-1]
+	active.size += n
+	active.entries++
+	q.total += n
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evict deletes the oldest segment and returns the number of undelivered
+// entries it held. q.mu must be held and there must be a later segment.
+func (q *DiskQueue) evict() int {
+	seg := q.segs[0]
+	os.Remove(q.segmentPath(seg.seq))
+	q.segs = q.segs[1:]
+	q.total -= seg.size
+	q.rseq, q.roff = q.segs[0].seq, 0
+	q.saveCursor()
+	return seg.entries
+}
+
+func (q *DiskQueue) run() {
+	defer close(q.done)
+
+	attempt := 0
+	for {
+		progress, err := q.sendNext()
+		if err == nil && progress {
+			attempt = 0
+			continue
+		}
+		notify := q.notify
+		var retry <-chan time.Time
+		var timer *time.Timer
+		if err != nil {
+			// Wait out the backoff rather than retrying on every write.
+			attempt++
+			notify = nil
+			timer = time.NewTimer(q.cfg.Retry.Backoff(attempt))
+			retry = timer.C
+		}
+		select {
+		case <-q.stop:
+			q.drain()
+			return
+		case ack := <-q.flush:
+			err := q.drain()
+			if err == nil {
+				attempt = 0
+			}
+			ack <- err
+		case <-notify:
+		case <-retry:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// drain sends until the log is empty or a delivery fails.
+func (q *DiskQueue) drain() error {
+	for {
+		progress, err := q.sendNext()
+		if err != nil || !progress {
+			return err
+		}
+	}
+}
+
+// sendNext sends the next batch and reports whether the read position
+// moved on.
+func (q *DiskQueue) sendNext() (bool, error) {
+	q.mu.Lock()
+	seq, off := q.rseq, q.roff
+	active := q.segs[len(q.segs)-1]
+	limit := int64(-1)
+	if seq == active.seq {
+		limit = active.size
+	}
+	q.mu.Unlock()
+	if limit >= 0 && off >= limit {
+		return false, nil
+	}
+
+	batch, next, skipped, err := q.read(seq, off, limit)
+	if err != nil {
+		// The segment was evicted while it was read.
+		q.mu.Lock()
+		moved := q.rseq != seq
+		q.mu.Unlock()
+		if moved {
+			return true, nil
+		}
+		return false, err
+	}
+	if len(batch) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), q.cfg.SendTimeout)
+		err = q.sender.SendBatch(ctx, batch)
+		cancel()
+		q.health.record(time.Now(), err)
+		if err != nil {
+			q.cfg.ErrorHandler(err)
+			if !IsPermanent(err) {
+				return false, err
+			}
+			q.failed.Add(uint64(len(batch)))
+		}
+	}
+	q.dropped.Add(uint64(skipped))
+	return q.advance(seq, off, next, len(batch)+skipped), nil
+}
+
+// read decodes up to BatchEntries entries of segment seq from off, and no
+// further than limit unless it is negative. It returns the offset after
+// them, or -1 if the segment was read to its end, and the number of lines
+// that were no entries.
+func (q *DiskQueue) read(seq uint64, off, limit int64) ([]*Entry, int64, int, error) {
+	f, err := os.Open(q.segmentPath(seq))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return nil, 0, 0, err
+	}
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, limit-off)
+	}
+	br := bufio.NewReaderSize(r, 64<<10)
+	var batch []*Entry
+	skipped := 0
+	for len(batch) < q.cfg.BatchEntries {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				// A line torn by a crash.
+				skipped++
+			}
+			if limit < 0 {
+				return batch, -1, skipped, nil
+			}
+			return batch, off, skipped, nil
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		off += int64(len(line))
+		e, derr := DecodeJSON(line)
+		if derr != nil {
+			skipped++
+			continue
+		}
+		batch = append(batch, e)
+	}
+	return batch, off, skipped, nil
+}
+
+// advance moves the read position from seq and off past n entries to
+// next, deleting the segment if next is -1, unless an eviction moved it
+// meanwhile. It reports whether the position moved.
+func (q *DiskQueue) advance(seq uint64, off, next int64, n int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.rseq != seq || q.roff != off {
+		return true
+	}
+	if q.segs[0].entries -= n; q.segs[0].entries < 0 {
+		q.segs[0].entries = 0
+	}
+	if next >= 0 {
+		q.roff = next
+		q.saveCursor()
+		return next != off
+	}
+	q.total -= q.segs[0].size
+	os.Remove(q.segmentPath(seq))
+	q.segs = q.segs[1:]
+	q.rseq, q.roff = q.segs[0].seq, 0
+	q.saveCursor()
+	return true
+}
+
+// Flush tries to deliver every queued entry and returns the error of the
+// delivery that failed, if any; the entries stay queued then.
+func (q *DiskQueue) Flush() error {
+	ack := make(chan error, 1)
+	select {
+	case q.flush <- ack:
+		return <-ack
+	case <-q.done:
+		return nil
+	}
+}
+
+// Close stops accepting entries, makes a last attempt to deliver the
+// queued ones and closes the log. Undelivered entries are sent by the next
+// queue opened on the directory.
+func (q *DiskQueue) Close() error {
+	var err error
+	q.once.Do(func() {
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+		close(q.stop)
+		<-q.done
+		q.mu.Lock()
+		err = q.w.Close()
+		q.mu.Unlock()
+	})
+	return err
+}
+
+// Stats implements StatsReporter: Dropped counts entries evicted over the
+// disk budget or unreadable in the log, Failed those in batches rejected
+// as permanent.
+func (q *DiskQueue) Stats() Stats {
+	return Stats{Dropped: q.dropped.Load(), Failed: q.failed.Load()}
+}
+
+// Health implements HealthReporter. QueueDepth is the number of queued
+// entries.
+func (q *DiskQueue) Health() Health {
+	h := q.health.health(q)
+	q.mu.Lock()
+	for _, seg := range q.segs {
+		h.QueueDepth += seg.entries
+	}
+	q.mu.Unlock()
+	return h
+}
+
+// Bytes returns the disk space the log takes.
+func (q *DiskQueue) Bytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.total
+}
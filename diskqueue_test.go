@@ -0,0 +1,186 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSender keeps the messages it received and fails while down.
+type recordingSender struct {
+	mu   sync.Mutex
+	msgs []string
+	down error
+}
+
+func (s *recordingSender) SendBatch(_ context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down != nil {
+		return s.down
+	}
+	for _, e := range batch {
+		s.msgs = append(s.msgs, e.Message)
+	}
+	return nil
+}
+
+func (s *recordingSender) setDown(err error) {
+	s.mu.Lock()
+	s.down = err
+	s.mu.Unlock()
+}
+
+func (s *recordingSender) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+var quickRetry = RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func TestDiskQueueDelivers(t *testing.T) {
+	sender := &recordingSender{}
+	q, err := NewDiskQueue(sender, DiskQueueConfig{Dir: t.TempDir(), BatchEntries: 2, Retry: quickRetry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for _, msg := range []string{"a", "b", "c"} {
+		q.WriteEntry(&Entry{Time: testTime, Level: Info, Message: msg})
+	}
+	if err := q.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sender.messages(); len(got) != 3 || got[2] != "c" {
+		t.Errorf("sent %q", got)
+	}
+	if h := q.Health(); h.QueueDepth != 0 {
+		t.Errorf("QueueDepth = %d", h.QueueDepth)
+	}
+}
+
+func TestDiskQueueOutage(t *testing.T) {
+	sender := &recordingSender{down: errors.New("collector down")}
+	var mu sync.Mutex
+	var errs []error
+	cfg := DiskQueueConfig{Dir: t.TempDir(), Retry: quickRetry, ErrorHandler: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}}
+	q, err := NewDiskQueue(sender, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.WriteEntry(&Entry{Time: testTime, Message: "a"})
+	q.WriteEntry(&Entry{Time: testTime, Message: "b"})
+	if err := q.Flush(); err == nil {
+		t.Error("Flush succeeded during the outage")
+	}
+	if h := q.Health(); h.QueueDepth != 2 || h.ConsecutiveFailures == 0 {
+		t.Errorf("health %+v", h)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process picks the entries up and delivers them once
+	// the collector is back.
+	sender.setDown(nil)
+	q, err = NewDiskQueue(sender, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if h := q.Health(); h.QueueDepth != 2 {
+		t.Errorf("reopened with %d entries", h.QueueDepth)
+	}
+	q.WriteEntry(&Entry{Time: testTime, Message: "c"})
+	q.Flush()
+	if got := sender.messages(); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Errorf("sent %q", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) == 0 {
+		t.Error("no delivery errors reported")
+	}
+}
+
+func TestDiskQueueResumes(t *testing.T) {
+	dir := t.TempDir()
+	sender := &recordingSender{}
+	q, _ := NewDiskQueue(sender, DiskQueueConfig{Dir: dir})
+	q.WriteEntry(&Entry{Time: testTime, Message: "sent"})
+	q.Flush()
+	q.Close()
+
+	// A delivered entry is not sent again, and a line torn by a crash is
+	// skipped.
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	os.WriteFile(filepath.Join(dir, "0000000000000100.wal"), []byte(`{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":"kept"}`+"\n"+`{"time":"2024-03`), FileModeRW)
+	q, err := NewDiskQueue(sender, DiskQueueConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Flush()
+	q.Close()
+	if got := sender.messages(); len(got) != 2 || got[1] != "kept" {
+		t.Errorf("sent %q", got)
+	}
+	if q.Stats().Dropped != 1 {
+		t.Errorf("Dropped = %d, want the torn line", q.Stats().Dropped)
+	}
+	for _, seg := range segs {
+		if _, err := os.Stat(seg); err == nil {
+			t.Errorf("delivered segment %s kept", seg)
+		}
+	}
+}
+
+func TestDiskQueueBudget(t *testing.T) {
+	sender := &recordingSender{down: errors.New("collector down")}
+	q, err := NewDiskQueue(sender, DiskQueueConfig{
+		Dir: t.TempDir(), MaxBytes: 1000, SegmentBytes: 300,
+		Retry:        RetryPolicy{InitialBackoff: time.Hour},
+		ErrorHandler: func(error) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for i := 0; i < 40; i++ {
+		q.WriteEntry(&Entry{Time: testTime, Level: Info, Message: "filler entry"})
+	}
+	if n := q.Bytes(); n > 1000 {
+		t.Errorf("queue takes %d bytes, budget 1000", n)
+	}
+	h := q.Health()
+	if q.Stats().Dropped == 0 || h.QueueDepth+int(q.Stats().Dropped) != 40 {
+		t.Errorf("dropped %d, queued %d of 40", q.Stats().Dropped, h.QueueDepth)
+	}
+}
+
+func TestDiskQueuePermanent(t *testing.T) {
+	sender := &recordingSender{down: Permanent(errors.New("bad request"))}
+	q, _ := NewDiskQueue(sender, DiskQueueConfig{Dir: t.TempDir(), ErrorHandler: func(error) {}})
+	defer q.Close()
+	q.WriteEntry(&Entry{Time: testTime, Message: "rejected"})
+	q.Flush()
+	if q.Stats().Failed != 1 || q.Health().QueueDepth != 0 {
+		t.Errorf("stats %+v, depth %d", q.Stats(), q.Health().QueueDepth)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.WriteEntry(&Entry{Message: "late"}); err != ErrSinkClosed {
+		t.Errorf("write after Close = %v", err)
+	}
+}
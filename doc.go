@@ -0,0 +1,17 @@
+// Package logger is a leveled, structured logger writing to files,
+// stdout and pluggable sinks.
+//
+// # Build tags
+//
+// Building with -tags logger_minimal compiles out the network sinks and
+// senders, the HTTP middleware and client transport, metrics and admin
+// handlers, the trace, cloud, container and GeoIP integrations, the JSON,
+// GELF, BSON, MessagePack, protobuf and Parquet encoders, log rotation and
+// archiving, the dead-letter file and disk queue, and the job, exec and
+// fingerprint helpers, for embedded and TinyGo targets where binary size
+// matters. The text and logfmt encoders, plain log files, and the
+// asynchronous, batching and filtering sinks remain; logfmt takes the
+// place of JSON as the default of audit channels and Production, and
+// OpenFile rejects a FileConfig with rotation or archiving. The parse
+// package and the commands need the full build.
+package logger
@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormat is the timestamp layout used by the text encoder.
+const TimeFormat = "2006/01/02 15:04:05"
+
+const DecodeTextErrFmt = "Invalid text log entry: %w"
+
+// Encoder turns an entry into bytes for a sink.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, e *Entry) error
+}
+
+// structuredEncoder returns the default encoder of machine-read outputs:
+// JSONEncoder, or LogfmtEncoder in the logger_minimal build.
+func structuredEncoder() Encoder {
+	if enc := jsonEncoder(); enc != nil {
+		return enc
+	}
+	return LogfmtEncoder{}
+}
+
+// ColorReset ends an ANSI color sequence.
+const ColorReset = "\x1b[0m"
+
+// DefaultColors are the ANSI colors of the level prefixes for terminals.
+var DefaultColors = map[LogLevel]string{
+	Debug:     "\x1b[90m",
+	Info:      "\x1b[36m",
+	Notice:    "\x1b[32m",
+	Warn:      "\x1b[33m",
+	Error:     "\x1b[31m",
+	Critical:  "\x1b[1;31m",
+	Alert:     "\x1b[1;35m",
+	Emergency: "\x1b[1;37;41m",
+}
+
+// TextEncoder writes entries in the classic "NAME LEVEL: date time message"
+// line format followed by any fields as key=value pairs.
+type TextEncoder struct {
+	// Prefixes overrides the level prefixes, e.g. {Warn: " W "} for short
+	// or localized tokens; levels not listed keep their default.
+	Prefixes map[LogLevel]string
+	// Colors wraps the prefix of the listed levels in the given ANSI
+	// sequence, see DefaultColors. Leave nil for files.
+	Colors map[LogLevel]string
+}
+
+// Encode implements Encoder. It appends straight into buf, so encoding a
+// plain message does not allocate once the buffer has grown.
+func (t TextEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	buf.WriteString(e.Name)
+	prefix, ok := t.Prefixes[e.Level]
+	if !ok {
+		prefix = levelPrefix(e.Level)
+	}
+	if color, ok := t.Colors[e.Level]; ok {
+		buf.WriteString(color)
+		buf.WriteString(prefix)
+		buf.WriteString(ColorReset)
+	} else {
+		buf.WriteString(prefix)
+	}
+	buf.Write(e.Time.AppendFormat(buf.AvailableBuffer(), TimeFormat))
+	buf.WriteByte(' ')
+	buf.WriteString(e.Message)
+	appendFields(buf, e.Fields)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// levelPrefix returns the text prefix written after the logger name.
+func levelPrefix(level LogLevel) string {
+	switch level {
+	case Debug:
+		return DebugPrefix
+	case Info:
+		return InfoPrefix
+	case Notice:
+		return NoticePrefix
+	case Warn:
+		return WarnPrefix
+	case Error:
+		return ErrorPrefix
+	case Critical:
+		return CriticalPrefix
+	case Alert:
+		return AlertPrefix
+	case Emergency:
+		return EmergencyPrefix
+	}
+	if info, ok := lookupLevel(level); ok {
+		return info.prefix
+	}
+	return ErrorPrefix
+}
+
+// appendFields renders the fields as " key=value" pairs; structs, maps and
+// slices are flattened into " key.path=value" pairs.
+func appendFields(buf *bytes.Buffer, fields []Field) {
+	appendPrefixedFields(buf, "", fields)
+}
+
+// appendPrefixedFields writes fields with prefix before every key; group
+// members are written as "group.key=value".
+func appendPrefixedFields(buf *bytes.Buffer, prefix string, fields []Field) {
+	for _, f := range fields {
+		if f.Type == GroupType {
+			group, _ := f.Value.([]Field)
+			appendPrefixedFields(buf, prefix+f.Key+".", group)
+			continue
+		}
+		if f.Type == AnyType && isComposite(f.Value) {
+			appendFlattened(buf, prefix+f.Key, expandValue(f.Value))
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(prefix)
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		switch f.Type {
+		case StringType:
+			appendTextString(buf, f.String)
+		case Int64Type:
+			buf.Write(strconv.AppendInt(buf.AvailableBuffer(), f.Integer, 10))
+		case Float64Type:
+			buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), math.Float64frombits(uint64(f.Integer)), 'g', -1, 64))
+		case BoolType:
+			buf.Write(strconv.AppendBool(buf.AvailableBuffer(), f.Integer != 0))
+		case AnyType:
+			appendTextValue(buf, f.Value)
+		default:
+			appendTextValue(buf, f.Interface())
+		}
+	}
+}
+
+// appendTextValue writes v, quoting strings that would be ambiguous in a
+// key=value list. Common types are formatted without going through fmt.
+func appendTextValue(buf *bytes.Buffer, v interface{}) {
+	switch x := v.(type) {
+	case string:
+		appendTextString(buf, x)
+	case int:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(x), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), x, 10))
+	case uint64:
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), x, 10))
+	case float64:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), x, 'g', -1, 64))
+	case bool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), x))
+	case time.Duration:
+		buf.WriteString(x.String())
+	case []byte:
+		// Base64 like JSON rather than a list of numbers.
+		buf.WriteString(base64.StdEncoding.EncodeToString(x))
+	case error:
+		appendTextString(buf, x.Error())
+	case fmt.Stringer:
+		appendTextString(buf, x.String())
+	default:
+		appendTextString(buf, fmt.Sprint(v))
+	}
+}
+
+func appendTextString(buf *bytes.Buffer, s string) {
+	if strings.ContainsAny(s, " \t\n\"=") {
+		buf.Write(strconv.AppendQuote(buf.AvailableBuffer(), s))
+		return
+	}
+	buf.WriteString(s)
+}
+
+var (
+	errNoTextPrefix = errors.New("no level prefix and timestamp")
+	errNoMessage    = errors.New("no msg key")
+	// textLine splits a text line into name, level word, time and rest.
+	textLine  = regexp.MustCompile(`^(\S*) ([A-Za-z][A-Za-z0-9_]*) ?: (\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})(?: (.*))?$`)
+	ansiColor = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// DecodeText parses a line written by TextEncoder back into an entry, with
+// the timestamp read in loc since the format carries no zone. Colors are
+// ignored. The format does not delimit the message, so the longest tail of
+// key=value pairs is taken as the fields, typed like DecodeLogfmt does;
+// groups come back as flat dotted keys. Levels are recognized by their
+// prefix, custom ones if their prefix has the form " NAME: ".
+func DecodeText(line []byte, loc *time.Location) (*Entry, error) {
+	s := strings.TrimRight(string(line), "\r\n")
+	if strings.Contains(s, "\x1b[") {
+		s = ansiColor.ReplaceAllString(s, "")
+	}
+	m := textLine.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf(DecodeTextErrFmt, errNoTextPrefix)
+	}
+	level, ok := levelFromPrefix(m[2])
+	if !ok {
+		return nil, fmt.Errorf(DecodeTextErrFmt, fmt.Errorf("unknown level %q", m[2]))
+	}
+	t, err := time.ParseInLocation(TimeFormat, m[3], loc)
+	if err != nil {
+		return nil, fmt.Errorf(DecodeTextErrFmt, err)
+	}
+	e := &Entry{Time: t, Level: level, Name: m[1], Message: m[4]}
+	rest := m[4]
+	for i := 0; i < len(rest); i++ {
+		if rest[i] != ' ' {
+			continue
+		}
+		if pairs, ok := parsePairs(rest[i:]); ok {
+			e.Message = rest[:i]
+			for _, p := range pairs {
+				e.Fields = append(e.Fields, p.field())
+			}
+			break
+		}
+	}
+	return e, nil
+}
+
+// levelFromPrefix returns the level whose text prefix holds word.
+func levelFromPrefix(word string) (LogLevel, bool) {
+	for _, l := range builtinLevels {
+		if strings.Trim(levelPrefix(l), " :") == word {
+			return l, true
+		}
+	}
+	var found LogLevel
+	var ok bool
+	customLevels.levels.Range(func(k, v interface{}) bool {
+		if strings.Trim(v.(levelInfo).prefix, " :") == word {
+			found, ok = k.(LogLevel), true
+			return false
+		}
+		return true
+	})
+	if !ok {
+		return ParseLevel(word)
+	}
+	return found, ok
+}
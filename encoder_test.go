@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTextEncoderPrefixes(t *testing.T) {
+	enc := TextEncoder{Prefixes: map[LogLevel]string{Warn: " W "}, Colors: map[LogLevel]string{Error: "\x1b[31m"}}
+	tests := []struct {
+		level LogLevel
+		want  string
+	}{
+		{Warn, "app W 2024/03/01 12:30:45 m\n"},
+		{Info, "app" + InfoPrefix + "2024/03/01 12:30:45 m\n"},
+		{Error, "app\x1b[31m" + ErrorPrefix + ColorReset + "2024/03/01 12:30:45 m\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, &Entry{Time: testTime, Level: tt.level, Name: "app", Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.level, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestWithColors(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithColors(nil), WithLevelPrefixes(map[LogLevel]string{Info: " I "}))
+	l.Info("colored")
+	if want := DefaultColors[Info] + " I " + ColorReset; !strings.Contains(buf.String(), want) {
+		t.Errorf("%q lacks %q", buf.String(), want)
+	}
+}
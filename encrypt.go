@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// EncryptedMagic starts every segment of an encrypted log file.
+const EncryptedMagic = "PBLOGAE1"
+
+const (
+	noncePrefixSize = 8
+	maxChunkSize    = 16 * 1024 * 1024
+
+	// Flags in the first byte of a chunk's associated data.
+	chunkFinal   = 1 // last chunk of its segment
+	chunkResumed = 2 // previous segment was not finished, e.g. after a crash
+
+	DecryptErrFmt = "Failed to decrypt log file: %w"
+)
+
+var (
+	// ErrNotEncrypted is returned when decrypting data without a segment
+	// header.
+	ErrNotEncrypted = errors.New("not an encrypted log segment")
+	// ErrChunkTooLarge is returned for a corrupt chunk length.
+	ErrChunkTooLarge = errors.New("encrypted chunk too large")
+	// ErrTruncated is returned when the last segment of an encrypted log
+	// has no final chunk: its tail was lost, or the file is still being
+	// written.
+	ErrTruncated = errors.New("encrypted log is truncated or still open")
+)
+
+// EncryptingWriter encrypts log data with AES-GCM in independently
+// authenticated chunks, one per Write. The stream starts with a segment
+// header of EncryptedMagic and a random nonce prefix; every chunk is a
+// 4-byte big-endian length followed by the sealed data, using the prefix
+// and a chunk counter as nonce so chunks cannot be reordered. Close seals
+// an empty final chunk so a lost tail is detected. The associated data of
+// every chunk binds the nonce prefix of the preceding segment, so a whole
+// segment cannot be removed unnoticed. Appending to an existing file
+// starts a new segment.
+type EncryptingWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  [noncePrefixSize]byte
+	prev    [noncePrefixSize]byte
+	counter uint32
+	started bool
+	// resumed is set while the segment follows an unfinished one.
+	resumed bool
+	buf     []byte
+}
+
+// NewEncryptingWriter creates an EncryptingWriter with a 16, 24 or 32 byte
+// AES key.
+func NewEncryptingWriter(w io.Writer, key []byte) (*EncryptingWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptingWriter{w: w, aead: aead}, nil
+}
+
+// appendEncryptingWriter creates an EncryptingWriter continuing the
+// encrypted file at path, whose last segment the new one is linked to.
+func appendEncryptingWriter(w io.Writer, path string, key []byte) (*EncryptingWriter, error) {
+	ew, err := NewEncryptingWriter(w, key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dr := &decryptingReader{r: bufio.NewReader(f), aead: ew.aead, lenient: true}
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		return nil, err
+	}
+	if dr.started {
+		ew.prev = dr.prefix
+		ew.resumed = !dr.final
+	}
+	return ew, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Write implements io.Writer, sealing p as one chunk.
+func (ew *EncryptingWriter) Write(p []byte) (int, error) {
+	// Keep one counter value for the final chunk.
+	if ew.started && ew.counter == math.MaxUint32-1 {
+		if err := ew.Close(); err != nil {
+			return 0, err
+		}
+	}
+	if !ew.started {
+		if err := ew.startSegment(); err != nil {
+			return 0, err
+		}
+	}
+	if err := ew.seal(p, 0); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close seals the final chunk of the current segment. It does not close
+// the underlying writer; a later Write starts a new segment.
+func (ew *EncryptingWriter) Close() error {
+	if !ew.started {
+		return nil
+	}
+	if err := ew.seal(nil, chunkFinal); err != nil {
+		return err
+	}
+	ew.started = false
+	ew.prev = ew.prefix
+	ew.resumed = false
+	return nil
+}
+
+func (ew *EncryptingWriter) seal(p []byte, flags byte) error {
+	var nonce [12]byte
+	copy(nonce[:], ew.prefix[:])
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], ew.counter)
+	ew.counter++
+
+	if ew.resumed {
+		flags |= chunkResumed
+	}
+	ad := chunkAD(flags, ew.prev)
+	ew.buf = append(ew.buf[:0], 0, 0, 0, 0)
+	ew.buf = ew.aead.Seal(ew.buf, nonce[:], p, ad[:])
+	binary.BigEndian.PutUint32(ew.buf, uint32(len(ew.buf)-4))
+	_, err := ew.w.Write(ew.buf)
+	return err
+}
+
+func (ew *EncryptingWriter) startSegment() error {
+	if _, err := rand.Read(ew.prefix[:]); err != nil {
+		return err
+	}
+	header := append([]byte(EncryptedMagic), ew.prefix[:]...)
+	if _, err := ew.w.Write(header); err != nil {
+		return err
+	}
+	ew.counter = 0
+	ew.started = true
+	return nil
+}
+
+// chunkAD returns the associated data of a chunk.
+func chunkAD(flags byte, prev [noncePrefixSize]byte) [1 + noncePrefixSize]byte {
+	var ad [1 + noncePrefixSize]byte
+	ad[0] = flags
+	copy(ad[1:], prev[:])
+	return ad
+}
+
+// decryptingReader reverses EncryptingWriter.
+type decryptingReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	prefix  [noncePrefixSize]byte
+	prev    [noncePrefixSize]byte
+	counter uint32
+	started bool
+	// final is set once the segment's final chunk was read; resumed is
+	// set while a segment follows an unfinished one.
+	final   bool
+	resumed bool
+	// lenient accepts a missing final chunk at the end, for files that
+	// are still being written.
+	lenient bool
+	plain   []byte
+	buf     []byte
+	out     []byte
+}
+
+// NewDecryptingReader returns a reader yielding the plaintext of an
+// encrypted log file. Tampered, reordered or missing chunks and segments
+// cause an error, as does a missing final chunk (ErrTruncated) after all
+// plaintext was returned.
+func NewDecryptingReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{r: bufio.NewReader(r), aead: aead}, nil
+}
+
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		if err := dr.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
+
+// next decrypts the following chunk, handling segment headers.
+func (dr *decryptingReader) next() error {
+	head, err := dr.r.Peek(len(EncryptedMagic))
+	if err == io.EOF && len(head) == 0 {
+		if dr.started && !dr.final && !dr.lenient {
+			return fmt.Errorf(DecryptErrFmt, ErrTruncated)
+		}
+		return io.EOF
+	}
+	if err == nil && bytes.Equal(head, []byte(EncryptedMagic)) {
+		dr.r.Discard(len(EncryptedMagic))
+		if dr.started {
+			dr.prev = dr.prefix
+			dr.resumed = !dr.final
+		}
+		if _, err := io.ReadFull(dr.r, dr.prefix[:]); err != nil {
+			return fmt.Errorf(DecryptErrFmt, io.ErrUnexpectedEOF)
+		}
+		dr.counter = 0
+		dr.started = true
+		dr.final = false
+		return nil
+	}
+	if !dr.started {
+		return fmt.Errorf(DecryptErrFmt, ErrNotEncrypted)
+	}
+	if dr.final {
+		return fmt.Errorf(DecryptErrFmt, errors.New("data after final chunk"))
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(dr.r, size[:]); err != nil {
+		return fmt.Errorf(DecryptErrFmt, io.ErrUnexpectedEOF)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxChunkSize {
+		return fmt.Errorf(DecryptErrFmt, ErrChunkTooLarge)
+	}
+	if cap(dr.buf) < int(n) {
+		dr.buf = make([]byte, n)
+	}
+	dr.buf = dr.buf[:n]
+	if _, err := io.ReadFull(dr.r, dr.buf); err != nil {
+		return fmt.Errorf(DecryptErrFmt, io.ErrUnexpectedEOF)
+	}
+
+	var nonce [12]byte
+	copy(nonce[:], dr.prefix[:])
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], dr.counter)
+	dr.counter++
+
+	var flags byte
+	if dr.resumed {
+		flags = chunkResumed
+	}
+	// A failed Open clears its output, so decrypt into a separate buffer
+	// to keep the ciphertext for the second try.
+	ad := chunkAD(flags, dr.prev)
+	plain, err := dr.aead.Open(dr.out[:0], nonce[:], dr.buf, ad[:])
+	if err != nil {
+		ad = chunkAD(flags|chunkFinal, dr.prev)
+		if plain, err = dr.aead.Open(dr.out[:0], nonce[:], dr.buf, ad[:]); err != nil {
+			return fmt.Errorf(DecryptErrFmt, err)
+		}
+		dr.final = true
+	}
+	dr.out = plain
+	dr.plain = plain
+	return nil
+}
+
+// DecryptTo writes the plaintext of the encrypted log r to w.
+func DecryptTo(w io.Writer, r io.Reader, key []byte) error {
+	dr, err := NewDecryptingReader(r, key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, dr)
+	return err
+}
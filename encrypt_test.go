@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+// encryptLines writes each line as a chunk, closing the segment if closed.
+func encryptLines(t *testing.T, w io.Writer, closed bool, lines ...string) {
+	t.Helper()
+	ew, err := NewEncryptingWriter(w, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		if _, err := ew.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if closed {
+		if err := ew.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func decrypt(data []byte, key []byte) (string, error) {
+	var out bytes.Buffer
+	err := DecryptTo(&out, bytes.NewReader(data), key)
+	return out.String(), err
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"empty", nil},
+		{"one", []string{"a\n"}},
+		{"many", []string{"a\n", "", "bc\n", strings.Repeat("x", 100000)}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		encryptLines(t, &buf, true, tt.lines...)
+		got, err := decrypt(buf.Bytes(), testKey)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := strings.Join(tt.lines, ""); got != want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestEncryptTampering(t *testing.T) {
+	var buf bytes.Buffer
+	encryptLines(t, &buf, true, "first\n", "second\n", "third\n")
+	data := buf.Bytes()
+	header := len(EncryptedMagic) + noncePrefixSize
+	chunk := 4 + len("first\n") + 16
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+		want   error
+	}{
+		{"flipped bit", func(b []byte) []byte { b[header+4] ^= 1; return b }, nil},
+		{"truncated tail", func(b []byte) []byte { return b[:len(b)-(4+16)] }, ErrTruncated},
+		{"partial chunk", func(b []byte) []byte { return b[:len(b)-3] }, io.ErrUnexpectedEOF},
+		{"dropped chunk", func(b []byte) []byte {
+			return append(b[:header:header], b[header+chunk:]...)
+		}, nil},
+		{"no header", func(b []byte) []byte { return b[header:] }, ErrNotEncrypted},
+	}
+	for _, tt := range tests {
+		data := tt.mutate(append([]byte(nil), data...))
+		_, err := decrypt(data, testKey)
+		if err == nil {
+			t.Errorf("%s: decrypted without error", tt.name)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := decrypt(data, bytes.Repeat([]byte{8}, 32)); err == nil {
+		t.Error("decrypted with the wrong key")
+	}
+}
+
+func TestEncryptSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.enc")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first process is killed without closing its segment.
+	encryptLines(t, f, false, "one\n")
+
+	ew, err := appendEncryptingWriter(f, path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ew.Write([]byte("two\n"))
+	ew.Close()
+	ew, err = appendEncryptingWriter(f, path, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ew.Write([]byte("three\n"))
+	ew.Close()
+	f.Close()
+
+	data, _ := os.ReadFile(path)
+	got, err := decrypt(data, testKey)
+	if err != nil || got != "one\ntwo\nthree\n" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	// Removing the middle segment breaks the link to the previous one.
+	second := bytes.Index(data[len(EncryptedMagic):], []byte(EncryptedMagic)) + len(EncryptedMagic)
+	third := bytes.LastIndex(data, []byte(EncryptedMagic))
+	cut := append(append([]byte(nil), data[:second]...), data[third:]...)
+	if _, err := decrypt(cut, testKey); err == nil {
+		t.Error("removed segment not detected")
+	}
+}
+
+func BenchmarkEncryptingWriter(b *testing.B) {
+	ew, err := NewEncryptingWriter(io.Discard, testKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+	line := []byte("test INFO: 2024/03/01 12:30:45 request served path=/ status=200\n")
+	b.ReportAllocs()
+	b.SetBytes(int64(len(line)))
+	for i := 0; i < b.N; i++ {
+		ew.Write(line)
+	}
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// maxPooledFields bounds the field capacity of entries returned to the pool.
+const maxPooledFields = 64
+
+// Field is a key/value pair attached to a log entry. Fields built with the
+// typed constructors such as String and Int keep scalar values unboxed;
+// use Interface to read the value of any field.
+type Field struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+
+	Type    FieldType `json:"-"`
+	Integer int64     `json:"-"`
+	String  string    `json:"-"`
+}
+
+// Entry is a single log record as it passes through the logger.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Name    string    `json:"name"`
+	Message string    `json:"msg"`
+	Fields  []Field   `json:"fields,omitempty"`
+
+	// block carries the entries of a request block through an AsyncSink.
+	block []*Entry
+}
+
+// AddFields appends fields to the entry.
+func (e *Entry) AddFields(fields ...Field) {
+	e.Fields = append(e.Fields, fields...)
+}
+
+// FieldMap returns the fields as a map suitable for JSON encoding. Error
+// values are replaced by their message and structs, maps and slices are
+// expanded into nested maps and slices within MaxFieldDepth and
+// MaxFieldElements; later fields win on duplicate keys.
+// Group fields become nested maps.
+func (e *Entry) FieldMap() map[string]interface{} {
+	return fieldMap(e.Fields)
+}
+
+func fieldMap(fields []Field) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		v := f.Interface()
+		if err, ok := v.(error); ok {
+			m[f.Key] = err.Error()
+			continue
+		}
+		if isComposite(v) {
+			v = expandValue(v)
+		}
+		m[f.Key] = v
+	}
+	return m
+}
+
+// inlineFields is the number of fields Clone copies into the same
+// allocation as the entry.
+const inlineFields = 4
+
+// clonedEntry lets Clone copy an entry with few fields in one allocation.
+type clonedEntry struct {
+	Entry
+	fields [inlineFields]Field
+}
+
+// Clone returns a copy of the entry that does not share its fields.
+func (e *Entry) Clone() *Entry {
+	if len(e.Fields) > inlineFields {
+		c := *e
+		c.Fields = append([]Field(nil), e.Fields...)
+		return &c
+	}
+	c := &clonedEntry{Entry: *e}
+	c.Fields = nil
+	if len(e.Fields) > 0 {
+		c.Fields = append(c.fields[:0:inlineFields], e.Fields...)
+	}
+	return &c.Entry
+}
+
+var entryPool = sync.Pool{
+	New: func() interface{} { return new(Entry) },
+}
+
+// getEntry returns an empty entry from the pool.
+func getEntry() *Entry {
+	return entryPool.Get().(*Entry)
+}
+
+// putEntry resets e and returns it to the pool.
+func putEntry(e *Entry) {
+	if cap(e.Fields) > maxPooledFields {
+		return
+	}
+	for i := range e.Fields {
+		e.Fields[i] = Field{}
+	}
+	*e = Entry{Fields: e.Fields[:0]}
+	entryPool.Put(e)
+}
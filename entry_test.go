@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEntryClone(t *testing.T) {
+	for _, n := range []int{0, 1, inlineFields, inlineFields + 1, 10} {
+		e := &Entry{Message: "m", Fields: make([]Field, 0, 16)}
+		for i := 0; i < n; i++ {
+			e.Fields = append(e.Fields, Int("n", i))
+		}
+		c := e.Clone()
+		if len(c.Fields) != n || c.Message != "m" {
+			t.Fatalf("%d fields: clone has %d fields", n, len(c.Fields))
+		}
+		if n == 0 && c.Fields != nil {
+			t.Errorf("0 fields: clone shares the fields slice")
+		}
+		// Appending to either must not affect the other.
+		c.Fields = append(c.Fields, String("c", "x"))
+		e.Fields = append(e.Fields, String("e", "y"))
+		if c.Fields[n].Key != "c" || e.Fields[n].Key != "e" {
+			t.Errorf("%d fields: clone shares storage", n)
+		}
+		for i := 0; i < n; i++ {
+			if c.Fields[i].Integer != int64(i) {
+				t.Errorf("%d fields: field %d = %v", n, i, c.Fields[i])
+			}
+		}
+	}
+}
+
+func TestEntryCloneAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	e := &Entry{Message: "m", Fields: []Field{String("a", "b"), Int("n", 1)}}
+	if n := testing.AllocsPerRun(100, func() { e.Clone() }); n > 1 {
+		t.Errorf("Clone: %v allocs, want 1", n)
+	}
+}
+
+func TestFieldMap(t *testing.T) {
+	e := &Entry{Fields: []Field{
+		String("s", "v"),
+		Int("n", 3),
+		Bool("b", true),
+		Err(errors.New("boom")),
+		Group("g", Int("x", 1)),
+		String("s", "later"),
+	}}
+	m := e.FieldMap()
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"s", "later"},
+		{"n", int64(3)},
+		{"b", true},
+		{"error", "boom"},
+	}
+	for _, tt := range tests {
+		if got := m[tt.key]; got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+	g, ok := m["g"].(map[string]interface{})
+	if !ok || g["x"] != int64(1) {
+		t.Errorf("g = %#v", m["g"])
+	}
+}
+
+func BenchmarkEntryClone(b *testing.B) {
+	e := &Entry{Message: "m", Fields: []Field{String("a", "b"), Int("n", 1)}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Clone()
+	}
+}
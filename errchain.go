@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxErrorCauses bounds the causes listed for one error.
+const maxErrorCauses = 32
+
+// ErrorCoder is implemented by errors that carry a stable code, such as
+// "ERR_TIMEOUT", which ErrorChains records for grouping.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorChains returns a processor that expands error fields wrapping other
+// errors, through Unwrap() error or Unwrap() []error, into a structured
+// value:
+//
+//	{"msg": ..., "type": ..., "code": ..., "causes": [...], "root": {...}}
+//
+// Causes are listed depth first, each with its own code; the top-level
+// code is the first found in the chain and root is the innermost cause of
+// the first branch, so aggregators can group by root cause. Errors that wrap nothing
+// are left unchanged.
+func ErrorChains() Processor {
+	return ProcessorFunc(func(e *Entry) {
+		for i, f := range e.Fields {
+			if f.Type != AnyType {
+				continue
+			}
+			err, ok := f.Value.(error)
+			if !ok || !wrapsErrors(err) {
+				continue
+			}
+			e.Fields[i].Value = expandErrorChain(err)
+		}
+	})
+}
+
+func wrapsErrors(err error) bool {
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return u.Unwrap() != nil
+	case interface{ Unwrap() []error }:
+		return len(u.Unwrap()) > 0
+	}
+	return false
+}
+
+func expandErrorChain(err error) map[string]interface{} {
+	out := describeError(err)
+	var coder ErrorCoder
+	if _, ok := out["code"]; !ok && errors.As(err, &coder) && coder.ErrorCode() != "" {
+		out["code"] = coder.ErrorCode()
+	}
+	var causes []interface{}
+	var root error
+	var walk func(err error)
+	walk = func(err error) {
+		var next []error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if c := u.Unwrap(); c != nil {
+				next = []error{c}
+			}
+		case interface{ Unwrap() []error }:
+			next = u.Unwrap()
+		}
+		if len(next) == 0 && root == nil {
+			root = err
+		}
+		for _, c := range next {
+			if c == nil || len(causes) >= maxErrorCauses {
+				continue
+			}
+			causes = append(causes, describeError(c))
+			walk(c)
+		}
+	}
+	walk(err)
+
+	out["causes"] = causes
+	if root != nil && root != err {
+		out["root"] = describeError(root)
+	}
+	return out
+}
+
+// describeError returns the message, dynamic type and own code of err.
+func describeError(err error) map[string]interface{} {
+	d := map[string]interface{}{
+		"msg":  err.Error(),
+		"type": fmt.Sprintf("%T", err),
+	}
+	if coder, ok := err.(ErrorCoder); ok && coder.ErrorCode() != "" {
+		d["code"] = coder.ErrorCode()
+	}
+	return d
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string     { return e.code + ": " + e.err.Error() }
+func (e *codedError) Unwrap() error     { return e.err }
+func (e *codedError) ErrorCode() string { return e.code }
+
+func TestErrorChains(t *testing.T) {
+	root := io.ErrUnexpectedEOF
+	err := fmt.Errorf("load config: %w", &codedError{code: "ERR_READ", err: root})
+	e := &Entry{Fields: []Field{Any("error", err), Any("plain", io.EOF), String("s", "x")}}
+	ErrorChains().Process(e)
+
+	got, ok := e.Fields[0].Value.(map[string]interface{})
+	if !ok {
+		t.Fatalf("error field %#v", e.Fields[0].Value)
+	}
+	if got["msg"] != err.Error() || got["type"] != "*fmt.wrapError" || got["code"] != "ERR_READ" {
+		t.Errorf("chain %v", got)
+	}
+	causes := got["causes"].([]interface{})
+	if len(causes) != 2 || causes[0].(map[string]interface{})["code"] != "ERR_READ" {
+		t.Errorf("causes %v", causes)
+	}
+	if r := got["root"].(map[string]interface{}); r["msg"] != root.Error() || r["code"] != nil {
+		t.Errorf("root %v", r)
+	}
+	if e.Fields[1].Value != io.EOF || e.Fields[2].String != "x" {
+		t.Errorf("other fields changed: %+v", e.Fields[1:])
+	}
+}
+
+func TestErrorChainsJoined(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	e := &Entry{Fields: []Field{Any("error", errors.Join(a, fmt.Errorf("wrap: %w", b)))}}
+	ErrorChains().Process(e)
+	got := e.Fields[0].Value.(map[string]interface{})
+	if causes := got["causes"].([]interface{}); len(causes) != 3 {
+		t.Errorf("causes %v", causes)
+	}
+	// The root is the end of the first branch.
+	if r := got["root"].(map[string]interface{}); r["msg"] != "a" {
+		t.Errorf("root %v", r)
+	}
+	if _, ok := got["code"]; ok {
+		t.Errorf("code without coder: %v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// EventIDKey is the field holding the unique ID of an event.
+	EventIDKey = "event_id"
+	// EventPropertiesKey is the group holding the fields of an event.
+	EventPropertiesKey = "properties"
+	// EventLevel is the level of event records, which level settings
+	// never filter.
+	EventLevel = Info
+
+	EventNameErrFmt = "Invalid event name %q: want lowercase words joined by dots or underscores"
+)
+
+// ErrNoEventOutput is returned by Event on a logger without WithEvents.
+var ErrNoEventOutput = errors.New("no event output configured")
+
+// EventConfig configures the event channel, see WithEvents.
+type EventConfig struct {
+	// Path is the event file, opened with File; empty for none.
+	Path string
+	// File configures the event file.
+	File FileConfig
+	// Encoder encodes events to Path; JSONEncoder if nil.
+	Encoder Encoder
+	// Sinks receive every event in addition to Path, e.g. a Kafka or
+	// HTTP sink; wrap them in an AsyncSink to decouple the caller.
+	Sinks []Sink
+	// Source is the name of every event, the logger name if empty.
+	Source string
+	// Fields are attached to every event beside its properties, e.g. the
+	// application version.
+	Fields []Field
+}
+
+// WithEvents sets the output of Event, separate from the log output.
+func WithEvents(cfg EventConfig) Option {
+	return func(l *CustomLogger) {
+		cfg.Fields = append([]Field(nil), cfg.Fields...)
+		l.eventCfg = &cfg
+	}
+}
+
+// Event records a business or analytics event, e.g.
+//
+//	l.Event("order.placed", logger.String("order", id), logger.Int("items", n))
+//
+// Events follow one schema whatever the logger: the message is the event
+// name, the entry name is EventConfig.Source, and the fields are a random
+// event_id, the EventConfig.Fields and a properties group holding fields.
+// The logger's own fields, groups and processors are diagnostic context and
+// are left out, as are level settings and sampling. name must be lowercase
+// letters, digits and underscores, in words joined by dots.
+func (l *CustomLogger) Event(name string, fields ...Field) error {
+	if !validEventName(name) {
+		return fmt.Errorf(EventNameErrFmt, name)
+	}
+	if l.events == nil {
+		return ErrNoEventOutput
+	}
+	if l.closed.Load() {
+		l.counters.closed.Add(1)
+		return ErrSinkClosed
+	}
+	cfg := l.eventCfg
+	e := getEntry()
+	defer putEntry(e)
+	e.Time = l.clock()
+	e.Level = EventLevel
+	e.Name = cfg.Source
+	if e.Name == "" {
+		e.Name = l.name
+	}
+	e.Message = name
+	e.Fields = append(e.Fields, String(EventIDKey, NewRequestID()))
+	e.Fields = append(e.Fields, cfg.Fields...)
+	if len(fields) > 0 {
+		e.Fields = append(e.Fields, Group(EventPropertiesKey, fields...))
+	}
+	resolveFields(e.Fields)
+	return l.events.write(e, &l.counters.failed)
+}
+
+// validEventName reports whether name is dot-separated words of lowercase
+// letters, digits and underscores.
+func validEventName(name string) bool {
+	word := 0
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_':
+			word++
+		case c == '.' && word > 0:
+			word = 0
+		default:
+			return false
+		}
+	}
+	return word > 0
+}
@@ -0,0 +1,56 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEvent(t *testing.T) {
+	var logs, events bytes.Buffer
+	l := newTestLogger(t, Error, &logs,
+		WithProcessors(StaticFields(String("host", "a"))),
+		WithEvents(EventConfig{
+			Source: "shop",
+			Fields: []Field{String("version", "1.2")},
+			Sinks:  []Sink{NewWriterSink(&events, JSONEncoder{})},
+		}))
+	if err := l.With(String("req", "r1")).Event("order.placed", Int("items", 3)); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("event reached the log output: %q", logs.String())
+	}
+
+	var rec jsonEntry
+	if err := json.Unmarshal(events.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Message != "order.placed" || rec.Name != "shop" || rec.Fields["version"] != "1.2" {
+		t.Errorf("event = %+v", rec)
+	}
+	if id, _ := rec.Fields[EventIDKey].(string); len(id) != 16 {
+		t.Errorf("event_id = %v", rec.Fields[EventIDKey])
+	}
+	props, _ := rec.Fields[EventPropertiesKey].(map[string]interface{})
+	if props["items"] != 3.0 {
+		t.Errorf("properties = %v", rec.Fields[EventPropertiesKey])
+	}
+	if rec.Fields["host"] != nil || rec.Fields["req"] != nil {
+		t.Errorf("diagnostic fields leaked into the event: %v", rec.Fields)
+	}
+}
+
+func TestEventErrors(t *testing.T) {
+	l := newTestLogger(t, Info, &bytes.Buffer{})
+	if err := l.Event("signup"); err != ErrNoEventOutput {
+		t.Errorf("without WithEvents: %v", err)
+	}
+	for _, name := range []string{"", "Signup", "user..created", ".x", "x.", "user-created"} {
+		if err := l.Event(name); err == nil || err == ErrNoEventOutput {
+			t.Errorf("Event(%q) = %v, want a name error", name, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	// DefaultCommandMaxLine bounds the length of one logged output line.
+	DefaultCommandMaxLine = 4096
+	// DefaultCommandMaxBytes bounds the output logged per stream.
+	DefaultCommandMaxBytes = 1 << 20
+	// CommandTruncatedFmt is the message logged once a stream exceeds
+	// MaxBytes.
+	CommandTruncatedFmt = "%s: output truncated after %d bytes"
+)
+
+// CommandConfig configures RunCommand.
+type CommandConfig struct {
+	// Prefix starts every message, the base name of the command if empty.
+	Prefix string
+	// StdoutLevel and StderrLevel are the levels of the lines of each
+	// stream; zero means Info and Warn.
+	StdoutLevel LogLevel
+	StderrLevel LogLevel
+	// ExplicitLevels uses StdoutLevel and StderrLevel as given, so Debug
+	// (zero) can be selected for either.
+	ExplicitLevels bool
+	// MaxLine cuts longer lines, which are marked truncated=true; zero
+	// selects DefaultCommandMaxLine.
+	MaxLine int
+	// MaxBytes is the output logged per stream before the rest is
+	// discarded; zero selects DefaultCommandMaxBytes, negative is
+	// unlimited.
+	MaxBytes int64
+}
+
+// RunCommand runs cmd with its stdout and stderr logged line by line, each
+// line as "prefix: line" with a stream field, and returns the error of
+// cmd.Run. Runaway output is cut per line and per stream, see
+// CommandConfig, but still drained so the command never blocks on it.
+func (l *CustomLogger) RunCommand(cmd *exec.Cmd, cfg CommandConfig) error {
+	if cfg.Prefix == "" {
+		cfg.Prefix = filepath.Base(cmd.Path)
+	}
+	if !cfg.ExplicitLevels {
+		if cfg.StdoutLevel == 0 {
+			cfg.StdoutLevel = Info
+		}
+		if cfg.StderrLevel == 0 {
+			cfg.StderrLevel = Warn
+		}
+	}
+	if cfg.MaxLine <= 0 {
+		cfg.MaxLine = DefaultCommandMaxLine
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = DefaultCommandMaxBytes
+	}
+	stdout := &lineWriter{l: l, cfg: &cfg, level: cfg.StdoutLevel, stream: "stdout"}
+	stderr := &lineWriter{l: l, cfg: &cfg, level: cfg.StderrLevel, stream: "stderr"}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
+	return err
+}
+
+// lineWriter logs what is written to it line by line. exec.Cmd copies each
+// stream from a single goroutine, so it needs no locking.
+type lineWriter struct {
+	l      *CustomLogger
+	cfg    *CommandConfig
+	level  LogLevel
+	stream string
+
+	buf       []byte
+	long      bool
+	total     int64
+	truncated bool
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.truncated {
+		return n, nil
+	}
+	if max := w.cfg.MaxBytes; max > 0 && w.total+int64(len(p)) > max {
+		p = p[:max-w.total]
+		w.truncated = true
+	}
+	w.total += int64(len(p))
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.add(p)
+			break
+		}
+		w.add(p[:i])
+		w.emit()
+		p = p[i+1:]
+	}
+	if w.truncated {
+		w.flush()
+		w.l.log(w.level, fmt.Sprintf(CommandTruncatedFmt, w.cfg.Prefix, w.total), String("stream", w.stream))
+	}
+	return n, nil
+}
+
+// add buffers part of the current line up to MaxLine.
+func (w *lineWriter) add(p []byte) {
+	if room := w.cfg.MaxLine - len(w.buf); len(p) > room {
+		p = p[:room]
+		w.long = true
+	}
+	w.buf = append(w.buf, p...)
+}
+
+func (w *lineWriter) emit() {
+	line := bytes.TrimSuffix(w.buf, []byte{'\r'})
+	fields := []Field{String("stream", w.stream)}
+	if w.long {
+		fields = append(fields, Bool("truncated", true))
+	}
+	w.l.log(w.level, w.cfg.Prefix+": "+string(line), fields...)
+	w.buf, w.long = w.buf[:0], false
+}
+
+// flush logs a final line without a newline.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 || w.long {
+		w.emit()
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	cmd := exec.Command(sh, "-c", `printf 'one\ntwo\r\n'; printf 'oops' >&2; exit 3`)
+	err = l.RunCommand(cmd, CommandConfig{Prefix: "job"})
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 3 {
+		t.Errorf("err = %v, want exit status 3", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"INFO", "job: one stream=stdout", "job: two stream=stdout",
+		"WARN", "job: oops stream=stderr",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q lacks %q", out, want)
+		}
+	}
+}
+
+func TestLineWriterTruncation(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	cfg := &CommandConfig{Prefix: "p", MaxLine: 4, MaxBytes: 16}
+	w := &lineWriter{l: l, cfg: cfg, level: Info, stream: "stdout"}
+	w.Write([]byte("abcdefgh\nxy"))
+	w.Write([]byte("z\n0123456789"))
+	if n, _ := w.Write([]byte("more\n")); n != 5 {
+		t.Errorf("Write after truncation = %d, want 5", n)
+	}
+	w.flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"p: abcd stream=stdout truncated=true",
+		"p: xyz stream=stdout",
+		"p: 012 stream=stdout",
+		"p: output truncated after 16 bytes stream=stdout",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFallbackThreshold     = 3
+	DefaultFallbackProbeInterval = 5 * time.Second
+
+	FallbackDivertFmt    = "Primary log output failing, diverting to fallback: %s"
+	FallbackRecoveredFmt = "Primary log output recovered after %d failed writes"
+)
+
+// FallbackSink writes to a primary sink and diverts entries to a secondary
+// sink (e.g. stderr or an emergency file) once the primary has failed
+// threshold times in a row. Entries the primary rejects are always copied to
+// the secondary so they are not lost. While diverted the primary is retried at most
+// once per probe interval by writing a recovery notice to it; once it
+// accepts the notice normal operation resumes with the entry.
+type FallbackSink struct {
+	mu            sync.Mutex
+	primary       Sink
+	secondary     Sink
+	threshold     int
+	probeInterval time.Duration
+	failures      int
+	diverted      bool
+	lastProbe     time.Time
+}
+
+// NewFallbackSink creates a FallbackSink. Non-positive threshold and
+// probeInterval select DefaultFallbackThreshold and
+// DefaultFallbackProbeInterval.
+func NewFallbackSink(primary, secondary Sink, threshold int, probeInterval time.Duration) *FallbackSink {
+	if threshold <= 0 {
+		threshold = DefaultFallbackThreshold
+	}
+	if probeInterval <= 0 {
+		probeInterval = DefaultFallbackProbeInterval
+	}
+	return &FallbackSink{
+		primary:       primary,
+		secondary:     secondary,
+		threshold:     threshold,
+		probeInterval: probeInterval,
+	}
+}
+
+// Diverted reports whether entries are currently going to the secondary sink.
+func (s *FallbackSink) Diverted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.diverted
+}
+
+// WriteEntry implements Sink. It only fails if the secondary sink rejected
+// an entry as well; primary failures are absorbed once the secondary has
+// the entry.
+func (s *FallbackSink) WriteEntry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.diverted {
+		if time.Since(s.lastProbe) < s.probeInterval {
+			return s.secondary.WriteEntry(e)
+		}
+		s.lastProbe = time.Now()
+		// The recovery notice is the probe, so it precedes the entry.
+		notice := s.notice(e, fmt.Sprintf(FallbackRecoveredFmt, s.failures))
+		if err := s.primary.WriteEntry(notice); err != nil {
+			s.failures++
+			return s.secondary.WriteEntry(e)
+		}
+		s.diverted = false
+		s.failures = 0
+	}
+
+	err := s.primary.WriteEntry(e)
+	if err == nil {
+		s.failures = 0
+		return nil
+	}
+
+	s.failures++
+	if s.failures < s.threshold {
+		return s.secondary.WriteEntry(e)
+	}
+
+	s.diverted = true
+	s.lastProbe = time.Now()
+	if serr := s.secondary.WriteEntry(s.notice(e, fmt.Sprintf(FallbackDivertFmt, err))); serr != nil {
+		return serr
+	}
+	return s.secondary.WriteEntry(e)
+}
+
+// notice builds a warning entry about the fallback state using the name of e.
+func (s *FallbackSink) notice(e *Entry, msg string) *Entry {
+	return &Entry{
+		Time:    time.Now(),
+		Level:   Warn,
+		Name:    e.Name,
+		Message: msg,
+	}
+}
+
+// Flush flushes both the primary and the secondary sink.
+func (s *FallbackSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := flushSink(s.primary)
+	if serr := flushSink(s.secondary); err == nil {
+		err = serr
+	}
+	return err
+}
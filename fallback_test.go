@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakySink records the messages it accepts and fails while fail is set.
+type flakySink struct {
+	fail bool
+	msgs []string
+}
+
+func (s *flakySink) WriteEntry(e *Entry) error {
+	if s.fail {
+		return errors.New("down")
+	}
+	s.msgs = append(s.msgs, e.Message)
+	return nil
+}
+
+func TestFallbackSink(t *testing.T) {
+	primary, secondary := &flakySink{fail: true}, &flakySink{}
+	s := NewFallbackSink(primary, secondary, 2, time.Nanosecond)
+	for _, msg := range []string{"a", "b"} {
+		if err := s.WriteEntry(&Entry{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !s.Diverted() {
+		t.Fatal("not diverted after the threshold")
+	}
+	if got := strings.Join(secondary.msgs, "|"); !strings.HasPrefix(got, "a|Primary log output failing") || !strings.HasSuffix(got, "|b") {
+		t.Errorf("secondary got %q", got)
+	}
+
+	primary.fail = false
+	time.Sleep(time.Millisecond)
+	if err := s.WriteEntry(&Entry{Message: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Diverted() {
+		t.Error("still diverted after the primary recovered")
+	}
+	if len(primary.msgs) != 2 || !strings.HasPrefix(primary.msgs[0], "Primary log output recovered") || primary.msgs[1] != "c" {
+		t.Errorf("primary got %q, want the recovery notice before the entry", primary.msgs)
+	}
+
+	primary.fail, secondary.fail = true, true
+	if err := s.WriteEntry(&Entry{Message: "d"}); err == nil {
+		t.Error("no error with both sinks failing")
+	}
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// FieldType tells encoders where a Field keeps its value.
+type FieldType uint8
+
+const (
+	// AnyType fields keep their value in Value.
+	AnyType FieldType = iota
+	// StringType fields keep their value in String.
+	StringType
+	// Int64Type, Float64Type (as IEEE 754 bits), BoolType (0 or 1) and
+	// DurationType fields keep their value in Integer.
+	Int64Type
+	Float64Type
+	BoolType
+	DurationType
+	// TimeType fields keep Unix nanoseconds in Integer and the
+	// *time.Location in Value.
+	TimeType
+	// GroupType fields keep their member fields as a []Field in Value.
+	GroupType
+)
+
+var fieldTypeNames = [...]string{"any", "string", "int64", "float64", "bool", "duration", "time", "group"}
+
+// String returns the name of the type, e.g. "string".
+func (t FieldType) String() string {
+	if int(t) < len(fieldTypeNames) {
+		return fieldTypeNames[t]
+	}
+	return "FieldType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// String returns a field holding a string.
+func String(key, val string) Field {
+	return Field{Key: key, Type: StringType, String: val}
+}
+
+// Int returns a field holding an int.
+func Int(key string, val int) Field {
+	return Field{Key: key, Type: Int64Type, Integer: int64(val)}
+}
+
+// Int64 returns a field holding an int64.
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Type: Int64Type, Integer: val}
+}
+
+// Float returns a field holding a float64.
+func Float(key string, val float64) Field {
+	return Field{Key: key, Type: Float64Type, Integer: int64(math.Float64bits(val))}
+}
+
+// Bool returns a field holding a bool.
+func Bool(key string, val bool) Field {
+	var n int64
+	if val {
+		n = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: n}
+}
+
+// Duration returns a field holding a time.Duration.
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(val)}
+}
+
+// Time returns a field holding a time.Time. The monotonic clock reading
+// is not kept.
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Type: TimeType, Integer: val.UnixNano(), Value: val.Location()}
+}
+
+// Err returns an "error" field holding err.
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// Group returns a field that nests fields under key: JSON output holds
+// them in an object and text output writes them as "key.member=value".
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, Type: GroupType, Value: fields}
+}
+
+// Any returns a typed field for the common scalar types and an AnyType
+// field for everything else.
+func Any(key string, val interface{}) Field {
+	switch v := val.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case float64:
+		return Float(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Duration(key, v)
+	case time.Time:
+		return Time(key, v)
+	default:
+		return Field{Key: key, Value: val}
+	}
+}
+
+// groupFields nests fields under the groups, outermost first.
+// The fields are copied so the caller's slice does not escape.
+func groupFields(groups []string, fields []Field) []Field {
+	nested := append([]Field(nil), fields...)
+	for i := len(groups) - 1; i >= 0; i-- {
+		nested = []Field{Group(groups[i], nested...)}
+	}
+	return nested
+}
+
+// Interface returns the field's value, boxing typed values.
+func (f Field) Interface() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int64Type:
+		return f.Integer
+	case Float64Type:
+		return math.Float64frombits(uint64(f.Integer))
+	case BoolType:
+		return f.Integer != 0
+	case DurationType:
+		return time.Duration(f.Integer)
+	case TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Value.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return t
+	case GroupType:
+		fields, _ := f.Value.([]Field)
+		return fieldMap(fields)
+	default:
+		return f.Value
+	}
+}
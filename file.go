@@ -0,0 +1,438 @@
+package logger
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PipeBuf is the largest write Linux guarantees not to interleave with
+// the writes of other processes to the same pipe.
+const PipeBuf = 4096
+
+const (
+	DefaultUploadTimeout = 5 * time.Minute
+
+	SyncErrFmt           = "Failed to sync log file: %w"
+	FileLockErrFmt       = "Failed to lock log file: %w"
+	FileLockConfigErrFmt = "File locking cannot be combined with %s"
+)
+
+// SyncPolicy controls how often a File is flushed to stable storage with
+// fsync. The zero value never syncs explicitly and leaves it to the OS.
+type SyncPolicy struct {
+	// Always syncs after every write.
+	Always bool
+	// EveryN syncs after this many writes.
+	EveryN int
+	// Interval syncs pending writes at least this often.
+	Interval time.Duration
+}
+
+// FileConfig configures a File.
+type FileConfig struct {
+	// Mode is the permission of newly created log files, before umask;
+	// zero selects FileModeRW.
+	Mode os.FileMode
+	// CreateDirs creates missing parent directories of the log path.
+	CreateDirs bool
+	// DirMode is the permission of directories made by CreateDirs; zero
+	// selects DirModeRWX.
+	DirMode os.FileMode
+	// Sync is the durability policy.
+	Sync SyncPolicy
+	// Rotation controls size and age based rotation.
+	Rotation RotationConfig
+	// Archive controls compression and upload of rotated files.
+	Archive ArchiveConfig
+	// EncryptionKey, when set, encrypts everything written with AES-GCM,
+	// see EncryptingWriter. It must be 16, 24 or 32 bytes long.
+	EncryptionKey []byte
+	// ReopenCheck, when positive, is how often writes check that the file
+	// still exists at its path and reopen it if it was deleted or moved.
+	ReopenCheck time.Duration
+	// Lock takes an exclusive advisory lock (flock, LockFileEx on Windows)
+	// around every write, so processes sharing the file never interleave,
+	// and makes each write first follow a rotation done by another
+	// process. It cannot be combined with EncryptionKey or
+	// Rotation.Symlink.
+	Lock bool
+	// Header, if set, writes a header entry whenever a file is opened,
+	// see HeaderConfig.
+	Header *HeaderConfig
+	// ErrorHandler receives errors from background work such as interval
+	// syncs; nil selects DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+}
+
+// RotationConfig controls when a File is rotated and how archives are named.
+// Rotation is disabled when both MaxSize and Interval are zero.
+type RotationConfig struct {
+	// MaxSize rotates the file before a write would grow it past this many
+	// bytes.
+	MaxSize int64
+	// Interval rotates the file once it has been open this long.
+	Interval time.Duration
+	// ArchiveTemplate names rotated files, relative to the log directory.
+	// Supported placeholders are {name} (file name without extension),
+	// {ext}, {date} (2006-01-02), {time} (150405), {seq}, {host} and {pid};
+	// empty selects DefaultArchiveTemplate.
+	ArchiveTemplate string
+	// Symlink writes entries straight to files named by ArchiveTemplate and
+	// keeps the configured path as a symlink to the current one, e.g.
+	// app.log -> app-2024-06-01-1.log, so tail -F always has a fixed path.
+	Symlink bool
+}
+
+func (c RotationConfig) enabled() bool {
+	return c.MaxSize > 0 || c.Interval > 0
+}
+
+// expandTemplate replaces the {host} and {pid} placeholders and any extra
+// placeholders given as name/value pairs.
+func expandTemplate(tmpl string, pairs ...string) string {
+	host, _ := os.Hostname()
+	args := append([]string{
+		"{host}", host,
+		"{pid}", strconv.Itoa(os.Getpid()),
+	}, pairs...)
+	return strings.NewReplacer(args...).Replace(tmpl)
+}
+
+// Uploader copies a finished log archive to off-host storage.
+type Uploader interface {
+	Upload(ctx context.Context, path string) error
+}
+
+// ArchiveConfig controls what happens to a log file after it is rotated.
+type ArchiveConfig struct {
+	// Compress gzips the archive and removes the uncompressed copy.
+	Compress bool
+	// SigningKey, if set, signs the finished archive and writes a detached
+	// signature next to it, see SignArchive.
+	SigningKey ed25519.PrivateKey
+	// Uploader, if set, receives every finished archive.
+	Uploader Uploader
+	// UploadTimeout bounds a single upload; zero selects
+	// DefaultUploadTimeout.
+	UploadTimeout time.Duration
+	// DeleteAfterUpload removes the local archive once it was uploaded.
+	DeleteAfterUpload bool
+}
+
+func (c ArchiveConfig) enabled() bool {
+	return c.Compress || c.SigningKey != nil || c.Uploader != nil
+}
+
+// File is the log file writer used by New. It is safe for concurrent use.
+//
+// The file is opened with O_APPEND and every Write is a single write
+// call, and the sinks write each entry, or each block, with one Write, so
+// entries from several processes appending to a local file do not mix
+// within a line. Entries of up to PipeBuf bytes are also written whole to
+// a shared pipe. Network filesystems and rotation by several processes
+// need FileConfig.Lock.
+type File struct {
+	mu      sync.Mutex
+	f       *os.File
+	w       io.Writer
+	path    string
+	current string
+	cfg     FileConfig
+	pending int
+	size    int64
+	opened  time.Time
+	// lastCheck is when the file was last checked for deletion.
+	lastCheck time.Time
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	dropped   atomic.Uint64
+	// suspended discards writes, e.g. while the disk is nearly full.
+	suspended atomic.Bool
+	archiver  *archiver
+}
+
+// OpenFile opens path for appending, creating it if needed. The {host}
+// and {pid} placeholders in path are replaced by the hostname and process
+// ID, e.g. app-{host}-{pid}.log, so instances sharing a volume write files
+// of their own; Path returns the expanded path.
+func OpenFile(path string, cfg FileConfig) (*File, error) {
+	path = expandTemplate(path)
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+	if cfg.Mode == 0 {
+		cfg.Mode = FileModeRW
+	}
+	if cfg.DirMode == 0 {
+		cfg.DirMode = DirModeRWX
+	}
+	if cfg.Header != nil {
+		cfg.Header = cfg.Header.withDefaults()
+	}
+	if err := checkRotation(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.CreateDirs {
+		if err := os.MkdirAll(filepath.Dir(path), cfg.DirMode); err != nil {
+			return nil, fmt.Errorf(MkdirErrFmt, err)
+		}
+	}
+
+	if cfg.Lock && len(cfg.EncryptionKey) > 0 {
+		return nil, fmt.Errorf(FileLockConfigErrFmt, "encryption")
+	}
+	if cfg.Lock && cfg.Rotation.Symlink {
+		return nil, fmt.Errorf(FileLockConfigErrFmt, "symlink rotation")
+	}
+
+	lf := &File{path: path, cfg: cfg}
+	if err := lf.openLocked(); err != nil {
+		return nil, fmt.Errorf(OpenLogErrFmt, err)
+	}
+	if cfg.Lock {
+		// Fail now on platforms without locking rather than on every write.
+		if err := lockFile(lf.f); err != nil {
+			lf.f.Close()
+			return nil, fmt.Errorf(FileLockErrFmt, err)
+		}
+		unlockFile(lf.f)
+	}
+
+	if cfg.Archive.enabled() {
+		lf.archiver = startArchiver(cfg.Archive, cfg.Mode, cfg.ErrorHandler)
+	}
+	if cfg.Sync.Interval > 0 {
+		lf.stop = make(chan struct{})
+		lf.done = make(chan struct{})
+		go lf.syncLoop()
+	}
+	return lf, nil
+}
+
+// openLocked opens the current file and records its size. lf.mu must be
+// held once the File is in use.
+func (lf *File) openLocked() error {
+	current := lf.path
+	if lf.cfg.Rotation.Symlink {
+		var err error
+		if current, err = lf.nextLinkedFile(); err != nil {
+			return err
+		}
+	}
+
+	f, err := lf.openPath(current)
+	if err != nil {
+		return err
+	}
+	if lf.cfg.Rotation.Symlink {
+		if err := updateSymlink(lf.path, current); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := lf.setFile(f, current, info.Size()); err != nil {
+		f.Close()
+		return err
+	}
+	lf.current = current
+	lf.size = info.Size()
+	lf.opened = time.Now()
+	lf.writeHeaderLocked()
+	return nil
+}
+
+// setFile makes f, opened at path with size bytes, the file being written,
+// layering encryption on top if configured. Encrypted files that already
+// hold data are continued with a segment linked to their last one.
+func (lf *File) setFile(f *os.File, path string, size int64) error {
+	lf.f = f
+	lf.w = f
+	if len(lf.cfg.EncryptionKey) > 0 {
+		var ew *EncryptingWriter
+		var err error
+		if size > 0 {
+			ew, err = appendEncryptingWriter(f, path, lf.cfg.EncryptionKey)
+		} else {
+			ew, err = NewEncryptingWriter(f, lf.cfg.EncryptionKey)
+		}
+		if err != nil {
+			return err
+		}
+		lf.w = ew
+	}
+	return nil
+}
+
+// finishLocked seals the final chunk of an encrypted file before it is
+// closed. lf.mu must be held.
+func (lf *File) finishLocked() error {
+	if ew, ok := lf.w.(*EncryptingWriter); ok {
+		lf.pending++
+		return ew.Close()
+	}
+	return nil
+}
+
+// openPath opens path for appending with the configured mode.
+func (lf *File) openPath(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, lf.cfg.Mode)
+}
+
+// Path returns the path the file was opened with.
+func (lf *File) Path() string {
+	return lf.path
+}
+
+// CurrentPath returns the path of the file being written, which differs
+// from Path when RotationConfig.Symlink is set.
+func (lf *File) CurrentPath() string {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.current
+}
+
+// Write implements io.Writer, syncing according to the policy.
+func (lf *File) Write(p []byte) (int, error) {
+	if lf.suspended.Load() {
+		lf.dropped.Add(1)
+		return len(p), nil
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.cfg.Lock {
+		if err := lf.lockLocked(); err != nil {
+			return 0, err
+		}
+		// Rotation replaces lf.f with a file locked in turn.
+		defer func() { unlockFile(lf.f) }()
+	} else if lf.cfg.ReopenCheck > 0 {
+		lf.checkMovedLocked()
+	}
+	for lf.cfg.Rotation.enabled() && lf.shouldRotate(len(p)) {
+		f := lf.f
+		err := lf.rotateLocked()
+		if err != nil {
+			lf.cfg.ErrorHandler(err)
+		}
+		if !lf.cfg.Lock || lf.f == f {
+			break
+		}
+		if err != nil {
+			// Lock the reopened file but do not retry the rotation.
+			if err := lf.lockLocked(); err != nil {
+				return 0, err
+			}
+			break
+		}
+		// Another process may have filled the new file before it was
+		// locked, so check again.
+		if err := lf.lockLocked(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := lf.w.Write(p)
+	lf.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+
+	lf.pending++
+	if lf.cfg.Sync.Always || (lf.cfg.Sync.EveryN > 0 && lf.pending >= lf.cfg.Sync.EveryN) {
+		err = lf.syncLocked()
+	}
+	return n, err
+}
+
+// suspend stops or resumes writing to the file.
+func (lf *File) suspend(on bool) {
+	lf.suspended.Store(on)
+}
+
+// Stats implements StatsReporter, counting writes discarded while suspended.
+func (lf *File) Stats() Stats {
+	return Stats{Dropped: lf.dropped.Load()}
+}
+
+// Sync commits the written data to stable storage.
+func (lf *File) Sync() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.syncLocked()
+}
+
+func (lf *File) syncLocked() error {
+	if lf.pending == 0 {
+		return nil
+	}
+	lf.pending = 0
+	if err := lf.f.Sync(); err != nil {
+		return fmt.Errorf(SyncErrFmt, err)
+	}
+	return nil
+}
+
+func (lf *File) syncLoop() {
+	defer close(lf.done)
+
+	ticker := time.NewTicker(lf.cfg.Sync.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lf.stop:
+			return
+		case <-ticker.C:
+			if err := lf.Sync(); err != nil {
+				lf.cfg.ErrorHandler(err)
+			}
+		}
+	}
+}
+
+// Close syncs and closes the file. Later calls return the first result.
+func (lf *File) Close() error {
+	lf.closeOnce.Do(func() { lf.closeErr = lf.close() })
+	return lf.closeErr
+}
+
+func (lf *File) close() error {
+	if lf.stop != nil {
+		close(lf.stop)
+		<-lf.done
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	err := lf.finishLocked()
+	if serr := lf.syncLocked(); err == nil {
+		err = serr
+	}
+	if cerr := lf.f.Close(); err == nil {
+		err = cerr
+	}
+	if lf.archiver != nil {
+		lf.archiver.close()
+		lf.archiver = nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOpenFilePlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	f, err := OpenFile(filepath.Join(dir, "app-{host}-{pid}.log"), FileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	host, _ := os.Hostname()
+	want := filepath.Join(dir, "app-"+host+"-"+strconv.Itoa(os.Getpid())+".log")
+	if f.Path() != want {
+		t.Errorf("Path = %q, want %q", f.Path(), want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileSyncPolicy(t *testing.T) {
+	pending := func(f *File) int {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.pending
+	}
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		policy SyncPolicy
+		want   []int
+	}{
+		{SyncPolicy{}, []int{1, 2, 3}},
+		{SyncPolicy{Always: true}, []int{0, 0, 0}},
+		{SyncPolicy{EveryN: 2}, []int{1, 0, 1}},
+	} {
+		f, err := OpenFile(filepath.Join(dir, "app.log"), FileConfig{Sync: tt.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range tt.want {
+			f.Write([]byte("line\n"))
+			if got := pending(f); got != want {
+				t.Errorf("%+v: %d writes pending after %d, want %d", tt.policy, got, i+1, want)
+			}
+		}
+		f.Close()
+	}
+
+	// An interval syncs pending writes in the background.
+	l, err := New(Info, "app", filepath.Join(dir, "app.log"), WithSyncPolicy(SyncPolicy{Interval: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+	l.Info("synced")
+	deadline := time.Now().Add(5 * time.Second)
+	for pending(l.file) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("write still pending")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	path := filepath.Join(dir, "app.log")
+	if _, err := OpenFile(path, FileConfig{}); err == nil {
+		t.Fatal("opened a file in a missing directory")
+	}
+	l, err := New(Info, "app", path, WithFileMode(0o600), WithCreateDirs(0o700))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close(context.Background())
+	for p, want := range map[string]os.FileMode{path: 0o600, dir: 0o700 | os.ModeDir} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != want {
+			t.Errorf("%s: mode %v, want %v", p, info.Mode(), want)
+		}
+	}
+}
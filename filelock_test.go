@@ -0,0 +1,87 @@
+//go:build (linux || darwin || freebsd) && !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestFileLock has two Files, standing in for two processes, append to and
+// rotate the same path: flock locks are per open file, so they contend as
+// processes would.
+func TestFileLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	cfg := FileConfig{Lock: true, Rotation: RotationConfig{MaxSize: 4 << 10}}
+	var files []*File
+	for i := 0; i < 2; i++ {
+		f, err := OpenFile(path, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	line := strings.Repeat("x", 100)
+	var wg sync.WaitGroup
+	for i, f := range files {
+		wg.Add(1)
+		go func(i int, f *File) {
+			defer wg.Done()
+			for n := 0; n < 200; n++ {
+				if _, err := fmt.Fprintf(f, "%d %03d %s\n", i, n, line); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i, f)
+	}
+	wg.Wait()
+	for _, f := range files {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(paths) < 2 {
+		t.Errorf("no rotation in %v", paths)
+	}
+	var lines int
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			lines++
+			if fields := strings.Fields(sc.Text()); len(fields) != 3 || fields[2] != line {
+				t.Errorf("%s: torn line %q", p, sc.Text())
+			}
+		}
+		if st, _ := f.Stat(); st.Size() > cfg.Rotation.MaxSize {
+			t.Errorf("%s is %d bytes, over MaxSize", p, st.Size())
+		}
+		f.Close()
+	}
+	if lines != 400 {
+		t.Errorf("%d lines in %d files, want 400", lines, len(paths))
+	}
+}
+
+func TestFileLockConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if _, err := OpenFile(path, FileConfig{Lock: true, EncryptionKey: make([]byte, 16)}); err == nil {
+		t.Error("want an error for Lock with encryption")
+	}
+	if _, err := OpenFile(path, FileConfig{Lock: true, Rotation: RotationConfig{Symlink: true}}); err == nil {
+		t.Error("want an error for Lock with symlink rotation")
+	}
+}
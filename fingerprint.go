@@ -0,0 +1,154 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	// FingerprintKey is the field holding the fingerprint of an entry.
+	FingerprintKey = "fingerprint"
+	// DefaultFingerprintFrames is the number of stack frames hashed.
+	DefaultFingerprintFrames = 3
+)
+
+// FingerprintConfig configures Fingerprints.
+type FingerprintConfig struct {
+	// MinLevel is the lowest level fingerprinted; zero means Error.
+	MinLevel LogLevel
+	// ExplicitLevels uses MinLevel as given, so Debug (zero) can be
+	// selected.
+	ExplicitLevels bool
+	// Frames is the number of stack frames hashed with the message; zero
+	// selects DefaultFingerprintFrames, negative hashes the message only.
+	Frames int
+}
+
+// fingerprintVolatile matches the parts of a message that differ between
+// occurrences of one failure: quoted strings, UUIDs, hex and decimal
+// numbers. Longer alternatives come first.
+var fingerprintVolatile = regexp.MustCompile(
+	`"[^"]*"|'[^']*'|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`)
+
+// stackFunc matches the function lines of a goroutine stack trace as
+// printed by runtime/debug.Stack: a function call without the leading
+// tab of the file lines.
+var stackFunc = regexp.MustCompile(`(?m)^([^\s].*)\(.*\)$`)
+
+// NormalizeMessage replaces the quoted strings, UUIDs and numbers in msg
+// by placeholders, so that occurrences of one failure read the same.
+func NormalizeMessage(msg string) string {
+	return fingerprintVolatile.ReplaceAllStringFunc(msg, func(s string) string {
+		switch s[0] {
+		case '"', '\'':
+			return "<str>"
+		}
+		if len(s) == 36 && s[8] == '-' {
+			return "<uuid>"
+		}
+		return "<num>"
+	})
+}
+
+// Fingerprints returns a processor attaching a fingerprint field to
+// entries at or above the configured level: 16 hex digits of a hash of the
+// normalized message and the functions of the top stack frames, so that
+// recurrences of one failure share it whatever IDs or counts they carry.
+// The frames are those of a stack field, as added by LogPanic, or else of
+// the code that logged the entry.
+func Fingerprints(cfg FingerprintConfig) Processor {
+	if cfg.MinLevel == 0 && !cfg.ExplicitLevels {
+		cfg.MinLevel = Error
+	}
+	if cfg.Frames == 0 {
+		cfg.Frames = DefaultFingerprintFrames
+	}
+	return ProcessorFunc(func(e *Entry) {
+		if e.Level < cfg.MinLevel {
+			return
+		}
+		var frames []string
+		if cfg.Frames > 0 {
+			frames = entryFrames(e, cfg.Frames)
+		}
+		e.AddFields(String(FingerprintKey, Fingerprint(e.Message, frames...)))
+	})
+}
+
+// Fingerprint returns the fingerprint of a message logged from frames,
+// the function names of the top stack frames.
+func Fingerprint(msg string, frames ...string) string {
+	h := sha256.New()
+	h.Write([]byte(NormalizeMessage(msg)))
+	for _, f := range frames {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(f))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// entryFrames returns the first n function names of the stack field of e,
+// or of the caller of the logger.
+func entryFrames(e *Entry, n int) []string {
+	for _, f := range e.Fields {
+		if f.Key != StackKey {
+			continue
+		}
+		if s, ok := f.Value.(string); ok {
+			return stackFrames(s, n)
+		}
+	}
+	return callerFrames(n)
+}
+
+// stackFrames returns the first n functions of a printed stack trace,
+// after those of the panic machinery and this package.
+func stackFrames(stack string, n int) []string {
+	var frames []string
+	for _, m := range stackFunc.FindAllStringSubmatch(stack, -1) {
+		fn := m[1]
+		if skipFrame(fn, "") {
+			continue
+		}
+		if frames = append(frames, fn); len(frames) == n {
+			break
+		}
+	}
+	return frames
+}
+
+// callerFrames returns the first n functions of the current stack outside
+// this package and the runtime.
+func callerFrames(n int) []string {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	it := runtime.CallersFrames(pcs)
+	var frames []string
+	for {
+		f, more := it.Next()
+		if !skipFrame(f.Function, f.File) {
+			if frames = append(frames, f.Function); len(frames) == n {
+				break
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// skipFrame reports whether the frame of fn in file belongs to the
+// logger or the runtime rather than to the code that failed. The tests
+// of this package count as callers.
+func skipFrame(fn, file string) bool {
+	if fn == "panic" || strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "runtime/debug.") {
+		return true
+	}
+	return strings.HasPrefix(fn, loggerPackage) && !strings.HasSuffix(file, "_test.go")
+}
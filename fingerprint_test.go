@@ -0,0 +1,84 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"timeout after 30s", "timeout after 30s"},
+		{"user 42 not found", "user <num> not found"},
+		{`open "/var/x": denied`, "open <str>: denied"},
+		{"order 0x1f and 3fa85f64-5717-4562-b3fc-2c963f66afa6", "order <num> and <uuid>"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMessage(tt.in); got != tt.want {
+			t.Errorf("NormalizeMessage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStackFrames(t *testing.T) {
+	stack := `goroutine 7 [running]:
+runtime/debug.Stack()
+	/go/src/runtime/debug/stack.go:24 +0x5e
+panic({0x6b2f40?, 0xc000012345?})
+	/go/src/runtime/panic.go:770 +0x132
+main.(*server).handle(0xc0000a2000, {0x7f0, 0x1})
+	/app/server.go:40 +0x3d
+main.serve(...)
+	/app/server.go:12
+created by main.main in goroutine 1
+	/app/main.go:9 +0x25
+`
+	want := []string{"main.(*server).handle", "main.serve"}
+	if got := stackFrames(stack, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("stackFrames = %q, want %q", got, want)
+	}
+}
+
+func fingerprintOf(t *testing.T, line string) string {
+	t.Helper()
+	i := strings.Index(line, FingerprintKey+"=")
+	if i < 0 {
+		t.Fatalf("no fingerprint in %q", line)
+	}
+	return line[i+len(FingerprintKey)+1 : i+len(FingerprintKey)+17]
+}
+
+func logFromA(l *CustomLogger, id int) { l.Log(Error, "lookup failed", Int("id", id)) }
+func logFromB(l *CustomLogger, id int) { l.Log(Error, "lookup failed", Int("id", id)) }
+
+func TestFingerprints(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithProcessors(Fingerprints(FingerprintConfig{})))
+	l.Info("fine")
+	if strings.Contains(buf.String(), FingerprintKey) {
+		t.Errorf("Info entry fingerprinted: %q", buf.String())
+	}
+
+	var prints []string
+	for i, log := range []func(*CustomLogger, int){logFromA, logFromA, logFromB} {
+		buf.Reset()
+		log(l, i)
+		prints = append(prints, fingerprintOf(t, buf.String()))
+	}
+	if prints[0] != prints[1] {
+		t.Errorf("recurrences differ: %v", prints)
+	}
+	if prints[0] == prints[2] {
+		t.Errorf("call sites share a fingerprint: %v", prints)
+	}
+
+	buf.Reset()
+	l.LogPanic("boom 17", []byte("goroutine 1 [running]:\nmain.work()\n\t/app/main.go:3\n"))
+	if got, want := fingerprintOf(t, buf.String()), Fingerprint(fmt.Sprintf(PanicFmt, "boom 17"), "main.work"); got != want {
+		t.Errorf("panic fingerprint = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const FlagFormatErrFmt = "Unknown log format %q, want text, json or logfmt"
+
+// Set implements flag.Value, parsing a level name such as "warn".
+func (l *LogLevel) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// Type names the value type for spf13/pflag.
+func (l *LogLevel) Type() string {
+	return "level"
+}
+
+// FlagValue is a flag.Value that also implements spf13/pflag's Value.
+type FlagValue interface {
+	flag.Value
+	Type() string
+}
+
+// Flag describes a logger flag, for registering it with flag libraries
+// other than package flag, e.g. pfs.Var(f.Value, f.Name, f.Usage) with
+// spf13/pflag.
+type Flag struct {
+	Name  string
+	Usage string
+	Value FlagValue
+}
+
+// Flags holds the logger settings of the command line, see BindFlags.
+type Flags struct {
+	// Level is the minimum level, Info by default.
+	Level LogLevel
+	// File is the log file; empty writes to stdout.
+	File string
+	// Format is text, json or logfmt; text by default.
+	Format string
+}
+
+// NewFlags returns the default settings.
+func NewFlags() *Flags {
+	return &Flags{Level: Info, Format: "text"}
+}
+
+// BindFlags registers -log-level, -log-file and -log-format on fs, or
+// flag.CommandLine if nil, and returns the settings they parse into:
+//
+//	flags := logger.BindFlags(nil)
+//	flag.Parse()
+//	l, err := flags.New("app")
+func BindFlags(fs *flag.FlagSet) *Flags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := NewFlags()
+	for _, fl := range f.Flags() {
+		fs.Var(fl.Value, fl.Name, fl.Usage)
+	}
+	return f
+}
+
+// Flags returns the flags setting f.
+func (f *Flags) Flags() []Flag {
+	return []Flag{
+		{"log-level", "minimum log `level`, e.g. debug or warn", &f.Level},
+		{"log-file", "log `file`; stdout if empty", &stringFlag{p: &f.File, typ: "string"}},
+		{"log-format", "log `format`: text, json or logfmt", &stringFlag{p: &f.Format, typ: "format", check: checkFormat}},
+	}
+}
+
+// New creates a logger with the settings; opts are applied after them.
+func (f *Flags) New(name string, opts ...Option) (*CustomLogger, error) {
+	return Config{Name: name, Level: f.Level, File: f.File, Format: f.Format, Options: opts}.New()
+}
+
+func checkFormat(s string) error {
+	_, err := flagEncoder(s)
+	return err
+}
+
+// flagEncoder returns the encoder of a format; nil for text, the default.
+func flagEncoder(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+		return nil, nil
+	case "json":
+		if enc := jsonEncoder(); enc != nil {
+			return enc, nil
+		}
+	case "logfmt":
+		return LogfmtEncoder{}, nil
+	}
+	return nil, fmt.Errorf(FlagFormatErrFmt, format)
+}
+
+// stringFlag is a string FlagValue, checked when set.
+type stringFlag struct {
+	p     *string
+	typ   string
+	check func(string) error
+}
+
+func (s *stringFlag) String() string {
+	if s.p == nil {
+		return ""
+	}
+	return *s.p
+}
+
+func (s *stringFlag) Set(v string) error {
+	if s.check != nil {
+		if err := s.check(v); err != nil {
+			return err
+		}
+	}
+	*s.p = v
+	return nil
+}
+
+func (s *stringFlag) Type() string {
+	return s.typ
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	// The logger_minimal build has no JSON.
+	format, loud := "json", `"msg":"loud"`
+	if jsonEncoder() == nil {
+		format, loud = "logfmt", "msg=loud"
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"-log-level=warn", "-log-file", path, "-log-format=" + format}); err != nil {
+		t.Fatal(err)
+	}
+	if flags.Level != Warn || flags.File != path || flags.Format != format {
+		t.Fatalf("flags = %+v", flags)
+	}
+	l, err := flags.New("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("quiet")
+	l.Warn("loud")
+	l.Close(context.Background())
+	b, _ := os.ReadFile(path)
+	if s := string(b); !strings.Contains(s, loud) || strings.Contains(s, "quiet") {
+		t.Errorf("file = %q", s)
+	}
+}
+
+func TestBindFlagsInvalid(t *testing.T) {
+	for _, args := range [][]string{{"-log-level=loud"}, {"-log-format=xml"}} {
+		fs := flag.NewFlagSet("app", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		BindFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("%v: want an error", args)
+		}
+	}
+}
+
+func TestFlagValues(t *testing.T) {
+	f := NewFlags()
+	for _, fl := range f.Flags() {
+		if fl.Value.Type() == "" {
+			t.Errorf("%s has no type", fl.Name)
+		}
+	}
+	var lvl LogLevel
+	if err := lvl.Set("notice"); err != nil || lvl != Notice || lvl.String() != "NOTICE" {
+		t.Errorf("Set(notice) = %v, %s", err, lvl)
+	}
+}
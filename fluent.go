@@ -0,0 +1,258 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFluentTag is the tag used when FluentConfig.Tag is empty.
+const DefaultFluentTag = "app"
+
+const FluentAckErrFmt = "Fluent forward: bad ack for chunk %s"
+
+// FluentConfig configures a FluentSender.
+type FluentConfig struct {
+	// Addr is the host:port of a Fluentd or Fluent Bit forward input.
+	Addr string
+	// Tag routes the events; the logger name, if any, is appended after a
+	// dot, e.g. "app.db". Characters Fluentd rejects become underscores.
+	Tag string
+	// RequireAck waits for the server to acknowledge every batch, giving
+	// at-least-once delivery.
+	RequireAck bool
+	// Compress sends batches in CompressedPackedForward mode (gzip).
+	Compress    bool
+	TLS         *tls.Config
+	DialTimeout time.Duration
+}
+
+// FluentSender is a BatchSender speaking the Fluent forward protocol:
+// each batch is sent as msgpack Forward mode messages, one per tag, with
+// nanosecond EventTime timestamps.
+type FluentSender struct {
+	cfg FluentConfig
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewFluentSender returns a sender for cfg. The connection is made on first
+// use and re-established after a failure.
+func NewFluentSender(cfg FluentConfig) *FluentSender {
+	if cfg.Tag == "" {
+		cfg.Tag = DefaultFluentTag
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	return &FluentSender{cfg: cfg}
+}
+
+// Acknowledges implements Acknowledger: batches are confirmed with
+// RequireAck.
+func (s *FluentSender) Acknowledges() bool {
+	return s.cfg.RequireAck
+}
+
+// SendBatch implements BatchSender.
+func (s *FluentSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(dl)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+
+	// Group consecutive entries by tag to keep one message per tag run.
+	for start := 0; start < len(batch); {
+		end := start + 1
+		for end < len(batch) && batch[end].Name == batch[start].Name {
+			end++
+		}
+		if err := s.forward(batch[start:end]); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// forward sends entries sharing a name as a single Forward mode message.
+func (s *FluentSender) forward(entries []*Entry) error {
+	tag := s.cfg.Tag
+	if name := entries[0].Name; name != "" {
+		tag += "." + name
+	}
+	tag = fluentTag(tag)
+
+	var chunk string
+	if s.cfg.RequireAck {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	var options int
+	if s.cfg.RequireAck {
+		options++
+	}
+	if s.cfg.Compress {
+		options++
+	}
+
+	b := make([]byte, 0, 256*len(entries))
+	if options > 0 {
+		b = appendMsgpackArrayHeader(b, 3)
+	} else {
+		b = appendMsgpackArrayHeader(b, 2)
+	}
+	b = appendMsgpackString(b, tag)
+	if s.cfg.Compress {
+		var packed []byte
+		for _, e := range entries {
+			packed = appendFluentEvent(packed, e)
+		}
+		var z bytes.Buffer
+		if err := Gzip.Compress(&z, packed); err != nil {
+			return fmt.Errorf(CompressPayloadErrFmt, err)
+		}
+		b = appendMsgpack(b, z.Bytes())
+	} else {
+		b = appendMsgpackArrayHeader(b, len(entries))
+		for _, e := range entries {
+			b = appendFluentEvent(b, e)
+		}
+	}
+	if options > 0 {
+		b = appendMsgpackMapHeader(b, options)
+		if s.cfg.RequireAck {
+			b = appendMsgpackString(b, "chunk")
+			b = appendMsgpackString(b, chunk)
+		}
+		if s.cfg.Compress {
+			b = appendMsgpackString(b, "compressed")
+			b = appendMsgpackString(b, "gzip")
+		}
+	}
+
+	if _, err := s.conn.Write(b); err != nil {
+		return err
+	}
+	if !s.cfg.RequireAck {
+		return nil
+	}
+	return s.readAck(chunk)
+}
+
+// readAck waits for {"ack": chunk}.
+func (s *FluentSender) readAck(chunk string) error {
+	var buf []byte
+	tmp := make([]byte, 256)
+	for len(buf) < 4096 {
+		n, err := s.conn.Read(tmp)
+		buf = append(buf, tmp[:n]...)
+		if m, derr := decodeMsgpackStringMap(buf); derr == nil {
+			if m["ack"] != chunk {
+				return fmt.Errorf(FluentAckErrFmt, chunk)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf(FluentAckErrFmt, chunk)
+}
+
+func (s *FluentSender) connectLocked(ctx context.Context) error {
+	conn, err := dialNetwork(ctx, s.cfg.Addr, s.cfg.DialTimeout, s.cfg.TLS)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// Close closes the connection.
+func (s *FluentSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// appendFluentEvent appends one [time, record] event.
+func appendFluentEvent(b []byte, e *Entry) []byte {
+	b = appendMsgpackArrayHeader(b, 2)
+	b = appendFluentTime(b, e.Time)
+	return appendMsgpack(b, fluentRecord(e))
+}
+
+// appendFluentTime appends t as the EventTime extension (type 0).
+func appendFluentTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// fluentRecord returns the record of e. Fields named like the level and
+// message keys are renamed with a "field_" prefix rather than overwritten.
+func fluentRecord(e *Entry) map[string]interface{} {
+	rec := e.FieldMap()
+	for _, k := range []string{"level", "msg"} {
+		if v, ok := rec[k]; ok {
+			rec["field_"+k] = v
+		}
+	}
+	rec["level"] = e.Level.String()
+	rec["msg"] = e.Message
+	return rec
+}
+
+// fluentTag replaces the characters Fluentd does not accept in tags, such
+// as the spaces of a logger name, with underscores; empty parts between
+// dots are dropped.
+func fluentTag(tag string) string {
+	parts := strings.Split(tag, ".")
+	kept := parts[:0]
+	for _, p := range parts {
+		p = strings.Map(func(r rune) rune {
+			if r < 0x80 && (r == '_' || r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+				return r
+			}
+			return '_'
+		}, p)
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return DefaultFluentTag
+	}
+	return strings.Join(kept, ".")
+}
@@ -0,0 +1,118 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFluentTag(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"app", "app"},
+		{"app.db", "app.db"},
+		{"app.A1-C0D3R MAIN", "app.A1-C0D3R_MAIN"},
+		{"app.ü/x", "app.__x"},
+		{"app..db.", "app.db"},
+		{"", DefaultFluentTag},
+		{"...", DefaultFluentTag},
+	}
+	for _, tt := range tests {
+		if got := fluentTag(tt.in); got != tt.want {
+			t.Errorf("fluentTag(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFluentRecord(t *testing.T) {
+	e := &Entry{Level: Error, Message: "failed", Fields: []Field{
+		String("level", "user"), String("msg", "body"), Int("n", 1),
+	}}
+	rec := fluentRecord(e)
+	want := map[string]interface{}{
+		"level":       "ERROR",
+		"msg":         "failed",
+		"field_level": "user",
+		"field_msg":   "body",
+		"n":           int64(1),
+	}
+	if len(rec) != len(want) {
+		t.Errorf("record = %v", rec)
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, rec[k], v)
+		}
+	}
+}
+
+func TestFluentTime(t *testing.T) {
+	ts := time.Unix(1700000000, 123456789)
+	want := []byte{0xd7, 0x00, 0x65, 0x53, 0xf1, 0x00, 0x07, 0x5b, 0xcd, 0x15}
+	if got := appendFluentTime(nil, ts); !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+// TestFluentSenderAck checks that forwarded messages carry the sanitized
+// tag and that the sender waits for the ack of its chunk.
+func TestFluentSenderAck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var msg []byte
+		buf := make([]byte, 4096)
+		// The chunk option ends the message: "chunk" and 24 base64 bytes.
+		marker := appendMsgpackString(nil, "chunk")
+		for {
+			n, err := conn.Read(buf)
+			msg = append(msg, buf[:n]...)
+			if i := bytes.Index(msg, marker); i >= 0 && len(msg) >= i+len(marker)+25 {
+				chunk := string(msg[i+len(marker)+1 : i+len(marker)+25])
+				conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": chunk}))
+				received <- msg
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	s := NewFluentSender(FluentConfig{Addr: ln.Addr().String(), RequireAck: true})
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	batch := []*Entry{{Time: testTime, Level: Info, Name: "A1 MAIN", Message: "hello"}}
+	if err := s.SendBatch(ctx, batch); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-received
+	if tag := appendMsgpackString(nil, "app.A1_MAIN"); !bytes.Contains(msg, tag) {
+		t.Errorf("message % x lacks the tag", msg)
+	}
+	if !bytes.Contains(msg, appendMsgpackString(nil, "hello")) {
+		t.Errorf("message % x lacks the record", msg)
+	}
+}
+
+func TestSenderAcknowledges(t *testing.T) {
+	if acknowledges(NewTCPSender(TCPConfig{})) {
+		t.Error("TCPSender acknowledges")
+	}
+	if acknowledges(NewFluentSender(FluentConfig{})) || !acknowledges(NewFluentSender(FluentConfig{RequireAck: true})) {
+		t.Error("FluentSender acknowledges without RequireAck or not with it")
+	}
+}
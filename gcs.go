@@ -0,0 +1,217 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultObjectTemplate = "{file}"
+
+	gcsUploadURL     = "https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=multipart"
+	gcsMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	GCSStatusErrFmt = "GCS upload of %s failed with status %s: %s"
+	GCSTokenErrFmt  = "Failed to get GCS access token: %w"
+	MetadataErrFmt  = "Metadata server returned status %s"
+)
+
+// TokenFunc returns an OAuth2 bearer token for a request.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// GCSConfig configures a GCSUploader.
+type GCSConfig struct {
+	// Bucket receives the archives.
+	Bucket string
+	// ObjectTemplate names the object. Supported placeholders are {file}
+	// (archive file name), {date} (2006-01-02), {year}, {month}, {day},
+	// {host} and {pid}; empty selects DefaultObjectTemplate.
+	ObjectTemplate string
+	// StorageClass, e.g. "NEARLINE", overrides the bucket default.
+	StorageClass string
+	// Metadata is attached to every object as custom metadata.
+	Metadata map[string]string
+	// CustomTime sets the object's customTime to the archive's
+	// modification time, for lifecycle rules based on daysSinceCustomTime.
+	CustomTime bool
+	// Token authorizes requests; nil uses the GCE metadata server unless
+	// ClientAuth is set.
+	Token TokenFunc
+	// ClientAuth declares that Client adds credentials itself, e.g. through
+	// WithBearerToken, so no token is sent.
+	ClientAuth bool
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// GCSUploader uploads log archives to a Google Cloud Storage bucket through
+// the JSON API.
+type GCSUploader struct {
+	cfg GCSConfig
+}
+
+// NewGCSUploader creates a GCSUploader.
+func NewGCSUploader(cfg GCSConfig) *GCSUploader {
+	if cfg.ObjectTemplate == "" {
+		cfg.ObjectTemplate = DefaultObjectTemplate
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Token == nil && !cfg.ClientAuth {
+		cfg.Token = MetadataServerToken(nil)
+	}
+	return &GCSUploader{cfg: cfg}
+}
+
+// gcsObject is the metadata part of a multipart upload.
+type gcsObject struct {
+	Name         string            `json:"name"`
+	ContentType  string            `json:"contentType"`
+	StorageClass string            `json:"storageClass,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CustomTime   string            `json:"customTime,omitempty"`
+}
+
+// Upload implements Uploader.
+func (u *GCSUploader) Upload(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	obj := gcsObject{
+		Name: expandTemplate(u.cfg.ObjectTemplate,
+			"{file}", filepath.Base(path),
+			"{date}", now.Format("2006-01-02"),
+			"{year}", now.Format("2006"),
+			"{month}", now.Format("01"),
+			"{day}", now.Format("02"),
+		),
+		ContentType:  archiveContentType(path),
+		StorageClass: u.cfg.StorageClass,
+		Metadata:     u.cfg.Metadata,
+	}
+	if u.cfg.CustomTime {
+		obj.CustomTime = info.ModTime().UTC().Format(time.RFC3339)
+	}
+	meta, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeGCSBody(mw, meta, obj.ContentType, f))
+	}()
+
+	endpoint := fmt.Sprintf(gcsUploadURL, url.PathEscape(u.cfg.Bucket))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", "multipart/related; boundary="+mw.Boundary())
+	if u.cfg.Token != nil {
+		token, err := u.cfg.Token(ctx)
+		if err != nil {
+			pr.Close()
+			return fmt.Errorf(GCSTokenErrFmt, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf(GCSStatusErrFmt, obj.Name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// writeGCSBody writes the metadata and media parts of a multipart upload.
+func writeGCSBody(mw *multipart.Writer, meta []byte, contentType string, media io.Reader) error {
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(meta); err != nil {
+		return err
+	}
+
+	part, err = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, media); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// archiveContentType guesses the content type of a log archive.
+func archiveContentType(path string) string {
+	if strings.HasSuffix(path, ".gz") {
+		return "application/gzip"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// MetadataServerToken returns a TokenFunc that fetches and caches access
+// tokens of the default service account from the GCE metadata server. A
+// nil client selects http.DefaultClient.
+func MetadataServerToken(client *http.Client) TokenFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return CachedToken(func(ctx context.Context) (string, time.Time, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcsMetadataToken, nil)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", time.Time{}, fmt.Errorf(MetadataErrFmt, resp.Status)
+		}
+
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", time.Time{}, err
+		}
+		return body.AccessToken, time.Now().Add(time.Duration(body.ExpiresIn) * time.Second), nil
+	})
+}
@@ -0,0 +1,112 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// redirectTransport sends every request to the test server at url.
+type redirectTransport struct {
+	url *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.url.Scheme, t.url.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func redirectClient(srv *httptest.Server) *http.Client {
+	u, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: redirectTransport{u}}
+}
+
+func TestGCSUploader(t *testing.T) {
+	var obj gcsObject
+	var query, auth, media, mediaType string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, auth = r.URL.RawQuery, r.Header.Get("Authorization")
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewDecoder(part).Decode(&obj)
+		if part, err = mr.NextPart(); err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := io.ReadAll(part)
+		media, mediaType = string(b), part.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "app-1.log.gz")
+	os.WriteFile(path, []byte("archive"), 0o644)
+	mtime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(path, mtime, mtime)
+	u := NewGCSUploader(GCSConfig{
+		Bucket: "logs", ObjectTemplate: "app/{file}", StorageClass: "NEARLINE",
+		Metadata: map[string]string{"env": "prod"}, CustomTime: true,
+		Token: StaticToken("tok"), Client: redirectClient(srv),
+	})
+	if err := u.Upload(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	if query != "uploadType=multipart" || auth != "Bearer tok" {
+		t.Errorf("query %q, auth %q", query, auth)
+	}
+	want := gcsObject{Name: "app/app-1.log.gz", ContentType: "application/gzip", StorageClass: "NEARLINE",
+		Metadata: map[string]string{"env": "prod"}, CustomTime: "2024-03-01T00:00:00Z"}
+	if obj.Name != want.Name || obj.ContentType != want.ContentType || obj.StorageClass != want.StorageClass ||
+		obj.Metadata["env"] != "prod" || obj.CustomTime != want.CustomTime {
+		t.Errorf("object %+v, want %+v", obj, want)
+	}
+	if media != "archive" || mediaType != "application/gzip" {
+		t.Errorf("media %q of type %q", media, mediaType)
+	}
+
+	status = http.StatusForbidden
+	if err := u.Upload(context.Background(), path); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("rejected upload: %v", err)
+	}
+}
+
+func TestMetadataServerToken(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+	}))
+	defer srv.Close()
+	token := MetadataServerToken(redirectClient(srv))
+	for i := 0; i < 2; i++ {
+		if tok, err := token(context.Background()); err != nil || tok != "tok" {
+			t.Fatalf("token %q, %v", tok, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%d metadata requests, want the token cached", calls)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DecodeGELFErrFmt = "Invalid GELF log entry: %w"
+
+// GELFEncoder writes entries as GELF 1.1 JSON documents, without a
+// delimiter. Fields become additional "_key" fields and the logger name is
+// sent as "_logger".
+type GELFEncoder struct {
+	// Host is the source host; empty uses os.Hostname, looked up once.
+	Host string
+}
+
+// NewGELFEncoder returns a GELFEncoder for host; an empty host is resolved
+// with os.Hostname.
+func NewGELFEncoder(host string) GELFEncoder {
+	if host == "" {
+		host = gelfHostname()
+	}
+	return GELFEncoder{Host: host}
+}
+
+var gelfHost struct {
+	once sync.Once
+	name string
+}
+
+// gelfHostname returns os.Hostname, resolved on first use.
+func gelfHostname() string {
+	gelfHost.once.Do(func() { gelfHost.name, _ = os.Hostname() })
+	return gelfHost.name
+}
+
+// Encode implements Encoder.
+func (g GELFEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	host := g.Host
+	if host == "" {
+		host = gelfHostname()
+	}
+	doc := make(map[string]interface{}, len(e.Fields)+6)
+	for k, v := range e.FieldMap() {
+		if k == "id" {
+			k = "field_id" // _id is reserved by GELF
+		}
+		doc["_"+k] = v
+	}
+	doc["version"] = "1.1"
+	doc["host"] = host
+	doc["short_message"] = e.Message
+	doc["timestamp"] = float64(e.Time.UnixMicro()) / 1e6
+	doc["level"] = gelfLevel(e.Level)
+	if e.Name != "" {
+		doc["_logger"] = e.Name
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+	buf.Write(b)
+	return nil
+}
+
+// DecodeGELF parses a document written by GELFEncoder back into an entry.
+// The additional fields become fields sorted by key, "_logger" the name
+// and "_field_id" the id field again; the host is dropped.
+func DecodeGELF(doc []byte) (*Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf(DecodeGELFErrFmt, err)
+	}
+	msg, ok := m["short_message"].(string)
+	if !ok {
+		return nil, fmt.Errorf(DecodeGELFErrFmt, errors.New("no short_message"))
+	}
+	e := &Entry{Message: msg, Level: Info}
+	if ts, ok := m["timestamp"].(json.Number); ok {
+		secs, _ := ts.Float64()
+		whole, frac := math.Modf(secs)
+		e.Time = time.Unix(int64(whole), int64(math.Round(frac*1e6))*1e3).UTC()
+	}
+	if n, ok := m["level"].(json.Number); ok {
+		if sev, err := n.Int64(); err == nil && sev >= 0 && sev < int64(len(syslogLevels)) {
+			e.Level = syslogLevels[sev]
+		}
+	}
+	e.Name, _ = m["_logger"].(string)
+	extra := make(map[string]interface{})
+	for k, v := range m {
+		if !strings.HasPrefix(k, "_") || k == "_logger" {
+			continue
+		}
+		if k = k[1:]; k == "field_id" {
+			k = "id"
+		}
+		extra[k] = v
+	}
+	e.Fields = decodeJSONFields(extra)
+	return e, nil
+}
+
+// syslogLevels maps syslog severities back to levels.
+var syslogLevels = []LogLevel{Emergency, Alert, Critical, Error, Warn, Notice, Info, Debug}
+
+// gelfLevel maps a level to its syslog severity.
+func gelfLevel(level LogLevel) int {
+	switch {
+	case level >= Emergency:
+		return 0
+	case level >= Alert:
+		return 1
+	case level >= Critical:
+		return 2
+	case level >= Error:
+		return 3
+	case level >= Warn:
+		return 4
+	case level >= Notice:
+		return 5
+	case level >= Info:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func wrapWriteErr(err error) error {
+	if err != nil {
+		return fmt.Errorf(WriteErrFmt, err)
+	}
+	return nil
+}
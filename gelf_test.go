@@ -0,0 +1,76 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGELFEncoder(t *testing.T) {
+	e := &Entry{Time: time.Unix(1700000000, 500000000), Level: Warn, Name: "db", Message: "slow",
+		Fields: []Field{Int("ms", 250), String("id", "x")}}
+	var buf bytes.Buffer
+	if err := NewGELFEncoder("web1").Encode(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web1",
+		"short_message": "slow",
+		"timestamp":     1700000000.5,
+		"level":         4.0,
+		"_logger":       "db",
+		"_ms":           250.0,
+		"_field_id":     "x",
+	}
+	for k, v := range want {
+		if doc[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, doc[k], v)
+		}
+	}
+	if _, ok := doc["_id"]; ok {
+		t.Error("reserved _id field written")
+	}
+	if NewGELFEncoder("").Host == "" {
+		t.Error("empty host not resolved")
+	}
+}
+
+func TestGELFLevel(t *testing.T) {
+	tests := []struct {
+		level LogLevel
+		want  int
+	}{
+		{LogLevel(-8), 7}, {Debug, 7}, {Info, 6}, {Notice, 5}, {Warn, 4},
+		{Error, 3}, {Critical, 2}, {Alert, 1}, {Emergency, 0},
+	}
+	for _, tt := range tests {
+		if got := gelfLevel(tt.level); got != tt.want {
+			t.Errorf("gelfLevel(%s) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeGELF(t *testing.T) {
+	e := &Entry{Time: testTime.Add(250 * time.Millisecond), Level: Error, Name: "app", Message: "boom",
+		Fields: []Field{String("id", "x1"), Int("n", 2)}}
+	var buf bytes.Buffer
+	NewGELFEncoder("h").Encode(&buf, e)
+	out, err := DecodeGELF(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Time.Equal(e.Time) || out.Level != Error || out.Name != "app" || out.Message != "boom" || len(out.Fields) != 2 || out.Fields[0].Key != "id" {
+		t.Errorf("decoded %+v", out)
+	}
+	if _, err := DecodeGELF([]byte(`{"version":"1.1"}`)); err == nil {
+		t.Error("document without short_message decoded")
+	}
+}
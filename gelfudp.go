@@ -0,0 +1,119 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+const (
+	// DefaultGELFChunkSize keeps datagrams below a typical WAN MTU.
+	DefaultGELFChunkSize = 1420
+	// GELFMaxChunks is the protocol limit on chunks per message.
+	GELFMaxChunks = 128
+)
+
+// ErrGELFTooLarge is returned for a message that needs more than
+// GELFMaxChunks chunks.
+var ErrGELFTooLarge = errors.New("GELF message exceeds 128 chunks")
+
+// GELFConfig configures a GELFUDPSink.
+type GELFConfig struct {
+	// Host overrides the source host.
+	Host string
+	// ChunkSize is the maximum datagram size; zero selects
+	// DefaultGELFChunkSize. Use 8154 on a LAN with jumbo-free paths.
+	ChunkSize int
+	// Compress zlib-compresses messages before chunking.
+	Compress bool
+}
+
+// GELFUDPSink sends entries to a Graylog GELF UDP input, splitting
+// messages larger than the chunk size into GELF chunks.
+type GELFUDPSink struct {
+	mu   sync.Mutex
+	conn net.Conn
+	enc  GELFEncoder
+	cfg  GELFConfig
+}
+
+// NewGELFUDPSink creates a sink sending to addr ("host:12201").
+func NewGELFUDPSink(addr string, cfg GELFConfig) (*GELFUDPSink, error) {
+	if cfg.ChunkSize <= 12 {
+		cfg.ChunkSize = DefaultGELFChunkSize
+	}
+	enc := NewGELFEncoder(cfg.Host)
+	cfg.Host = enc.Host
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &GELFUDPSink{conn: conn, enc: enc, cfg: cfg}, nil
+}
+
+// WriteEntry implements Sink.
+func (s *GELFUDPSink) WriteEntry(e *Entry) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	if err := s.enc.Encode(buf, e); err != nil {
+		return err
+	}
+	msg := buf.Bytes()
+	if s.cfg.Compress {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(msg); err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		msg = z.Bytes()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(msg) <= s.cfg.ChunkSize {
+		_, err := s.conn.Write(msg)
+		return wrapWriteErr(err)
+	}
+	return wrapWriteErr(s.writeChunks(msg))
+}
+
+// writeChunks sends msg as chunks of magic, message id, sequence number
+// and count followed by the payload.
+func (s *GELFUDPSink) writeChunks(msg []byte) error {
+	payload := s.cfg.ChunkSize - 12
+	count := (len(msg) + payload - 1) / payload
+	if count > GELFMaxChunks {
+		return ErrGELFTooLarge
+	}
+	chunk := make([]byte, 12, s.cfg.ChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * payload
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk[10] = byte(seq)
+		if _, err := s.conn.Write(append(chunk[:12], msg[seq*payload:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the socket.
+func (s *GELFUDPSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,95 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGELF reassembles one message from the datagrams read from conn.
+func readGELF(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 65536)
+	var chunks [][]byte
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := append([]byte(nil), buf[:n]...)
+		if p[0] != 0x1e || p[1] != 0x0f {
+			return p
+		}
+		if chunks == nil {
+			chunks = make([][]byte, p[11])
+		}
+		chunks[p[10]] = p[12:]
+		complete := true
+		for _, c := range chunks {
+			complete = complete && c != nil
+		}
+		if complete {
+			return bytes.Join(chunks, nil)
+		}
+	}
+}
+
+func TestGELFUDPSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GELFConfig
+		msg      string
+		wantErr  error
+		compress bool
+	}{
+		{"small", GELFConfig{}, "hello", nil, false},
+		{"chunked", GELFConfig{ChunkSize: 100}, strings.Repeat("x", 1000), nil, false},
+		{"compressed", GELFConfig{ChunkSize: 100, Compress: true}, strings.Repeat("ab", 2000), nil, true},
+		{"too large", GELFConfig{ChunkSize: 20}, strings.Repeat("y", 2000), ErrGELFTooLarge, false},
+	}
+	for _, tt := range tests {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.cfg.Host = "h"
+		s, err := NewGELFUDPSink(conn.LocalAddr().String(), tt.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.WriteEntry(&Entry{Time: testTime, Level: Info, Message: tt.msg})
+		if tt.wantErr != nil {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr.Error()) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			s.Close()
+			conn.Close()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		msg := readGELF(t, conn)
+		if tt.compress {
+			zr, err := zlib.NewReader(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			msg, _ = io.ReadAll(zr)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(msg, &doc); err != nil || doc["short_message"] != tt.msg || doc["host"] != "h" {
+			t.Errorf("%s: got %.80s: %v", tt.name, msg, err)
+		}
+		s.Close()
+		conn.Close()
+	}
+}
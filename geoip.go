@@ -0,0 +1,382 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+const (
+	// GeoIPKey is the group holding the fields attached by GeoIP.
+	GeoIPKey = "geo"
+
+	GeoIPOpenErrFmt = "Failed to open MaxMind database %s: %w"
+
+	geoIPMetadataMarker = "\xab\xcd\xefMaxMind.com"
+)
+
+// ErrGeoIPFormat is returned for a file that is not a valid MaxMind DB.
+var ErrGeoIPFormat = errors.New("malformed MaxMind database")
+
+// GeoIPDB is a MaxMind DB file, e.g. GeoLite2-City.mmdb or
+// GeoLite2-ASN.mmdb, read into memory and safe for concurrent lookups.
+type GeoIPDB struct {
+	data      []byte
+	tree      []byte
+	nodeCount uint32
+	recordLen uint32
+	ipVersion uint16
+	ipv4Start uint32
+	// Type is the database type of the metadata, e.g. "GeoLite2-City".
+	Type string
+}
+
+// OpenGeoIP reads the MaxMind DB at path.
+func OpenGeoIP(path string) (*GeoIPDB, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(GeoIPOpenErrFmt, path, err)
+	}
+	db, err := parseGeoIP(b)
+	if err != nil {
+		return nil, fmt.Errorf(GeoIPOpenErrFmt, path, err)
+	}
+	return db, nil
+}
+
+func parseGeoIP(b []byte) (*GeoIPDB, error) {
+	i := bytes.LastIndex(b, []byte(geoIPMetadataMarker))
+	if i < 0 {
+		return nil, ErrGeoIPFormat
+	}
+	meta, _, err := (&mmdbDecoder{b: b[i+len(geoIPMetadataMarker):]}).decode(0)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := meta.(map[string]interface{})
+	nodes, _ := m["node_count"].(uint64)
+	size, _ := m["record_size"].(uint64)
+	version, _ := m["ip_version"].(uint64)
+	if size != 24 && size != 28 && size != 32 || version != 4 && version != 6 {
+		return nil, ErrGeoIPFormat
+	}
+	treeLen := nodes * size / 4
+	if treeLen+16 > uint64(i) {
+		return nil, ErrGeoIPFormat
+	}
+	db := &GeoIPDB{
+		tree:      b[:treeLen],
+		data:      b[treeLen+16 : i],
+		nodeCount: uint32(nodes),
+		recordLen: uint32(size),
+		ipVersion: uint16(version),
+	}
+	db.Type, _ = m["database_type"].(string)
+	if version == 6 {
+		// IPv4 addresses live under ::/96.
+		for n := 0; n < 96 && db.ipv4Start < db.nodeCount; n++ {
+			if db.ipv4Start, err = db.record(db.ipv4Start, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return db, nil
+}
+
+// Lookup returns the record of addr, nil if the database has none.
+func (db *GeoIPDB) Lookup(addr netip.Addr) (map[string]interface{}, error) {
+	addr = addr.Unmap()
+	var ip []byte
+	node := uint32(0)
+	switch {
+	case addr.Is4():
+		a := addr.As4()
+		ip = a[:]
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	case db.ipVersion == 6:
+		a := addr.As16()
+		ip = a[:]
+	default:
+		return nil, nil
+	}
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		var err error
+		if node, err = db.record(node, ip[i/8]>>(7-i%8)&1); err != nil {
+			return nil, err
+		}
+	}
+	if node <= db.nodeCount {
+		return nil, nil
+	}
+	off := node - db.nodeCount - 16
+	if int(off) >= len(db.data) {
+		return nil, ErrGeoIPFormat
+	}
+	v, _, err := (&mmdbDecoder{b: db.data}).decode(int(off))
+	m, _ := v.(map[string]interface{})
+	return m, err
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node.
+func (db *GeoIPDB) record(node uint32, bit byte) (uint32, error) {
+	n := db.recordLen / 4
+	off := node * n
+	if off+n > uint32(len(db.tree)) {
+		return 0, ErrGeoIPFormat
+	}
+	b := db.tree[off : off+n]
+	switch db.recordLen {
+	case 24:
+		b = b[bit*3:]
+		return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+	case 28:
+		if bit == 0 {
+			return uint32(b[3]&0xf0)<<20 | uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+		}
+		return uint32(b[3]&0x0f)<<24 | uint32(b[4])<<16 | uint32(b[5])<<8 | uint32(b[6]), nil
+	}
+	return binary.BigEndian.Uint32(b[bit*4:]), nil
+}
+
+// mmdbDecoder decodes the data section of a MaxMind DB, with unsigned
+// integers as uint64, signed ones as int64 and both floats as float64.
+type mmdbDecoder struct {
+	b []byte
+}
+
+// decode decodes the value at off and returns the offset after it.
+func (d *mmdbDecoder) decode(off int) (interface{}, int, error) {
+	if off >= len(d.b) {
+		return nil, 0, ErrGeoIPFormat
+	}
+	ctrl := d.b[off]
+	off++
+	typ := int(ctrl >> 5)
+	if typ == 1 {
+		ptr, next, err := d.pointer(ctrl, off)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(ptr)
+		return v, next, err
+	}
+	if typ == 0 {
+		if off >= len(d.b) {
+			return nil, 0, ErrGeoIPFormat
+		}
+		typ = 7 + int(d.b[off])
+		off++
+	}
+	size := int(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if off+n > len(d.b) {
+			return nil, 0, ErrGeoIPFormat
+		}
+		v := 0
+		for _, c := range d.b[off : off+n] {
+			v = v<<8 | int(c)
+		}
+		size = v + [...]int{29, 285, 65821}[n-1]
+		off += n
+	}
+	switch typ {
+	case 7:
+		m := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			k, next, err := d.decode(off)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, ErrGeoIPFormat
+			}
+			if m[key], off, err = d.decode(next); err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, off, nil
+	case 11:
+		a := make([]interface{}, size)
+		for i := range a {
+			var err error
+			if a[i], off, err = d.decode(off); err != nil {
+				return nil, 0, err
+			}
+		}
+		return a, off, nil
+	case 14:
+		return size != 0, off, nil
+	}
+	if off+size > len(d.b) {
+		return nil, 0, ErrGeoIPFormat
+	}
+	b := d.b[off : off+size]
+	off += size
+	switch typ {
+	case 2:
+		return string(b), off, nil
+	case 3:
+		if size != 8 {
+			return nil, 0, ErrGeoIPFormat
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), off, nil
+	case 4:
+		return append([]byte(nil), b...), off, nil
+	case 5, 6, 9:
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, off, nil
+	case 8:
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), off, nil
+	case 10:
+		// 128 bit integers do not occur in the GeoIP databases.
+		return append([]byte(nil), b...), off, nil
+	case 15:
+		if size != 4 {
+			return nil, 0, ErrGeoIPFormat
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), off, nil
+	}
+	return nil, 0, ErrGeoIPFormat
+}
+
+func (d *mmdbDecoder) pointer(ctrl byte, off int) (int, int, error) {
+	n := int(ctrl>>3&3) + 1
+	if off+n > len(d.b) {
+		return 0, 0, ErrGeoIPFormat
+	}
+	v := 0
+	if n < 4 {
+		v = int(ctrl & 7)
+	}
+	for _, c := range d.b[off : off+n] {
+		v = v<<8 | int(c)
+	}
+	v += [...]int{0, 2048, 526336, 0}[n-1]
+	return v, off + n, nil
+}
+
+// GeoIPConfig configures GeoIP.
+type GeoIPConfig struct {
+	// Field is the key of the field holding the address, dotted for the
+	// member of a group; empty selects "ip".
+	Field string
+	// DBs are looked up in order, e.g. a City and an ASN database.
+	DBs []*GeoIPDB
+	// Language selects the names of places; empty selects "en".
+	Language string
+}
+
+// GeoIP returns a processor that looks the address in the configured field
+// up in local MaxMind databases and attaches a GeoIPKey group with the
+// country, region and city of City and Country databases and the asn and
+// as_org of ASN databases, so access logs carry geographic context without
+// a pipeline step downstream. It goes before AnonymizeIP, which would
+// truncate the address looked up. Addresses the databases do not know add
+// nothing.
+func GeoIP(cfg GeoIPConfig) Processor {
+	if cfg.Field == "" {
+		cfg.Field = "ip"
+	}
+	if cfg.Language == "" {
+		cfg.Language = "en"
+	}
+	return ProcessorFunc(func(e *Entry) {
+		v, ok := lookupField(e.Fields, cfg.Field)
+		if !ok {
+			return
+		}
+		addr, ok := geoIPAddr(v)
+		if !ok {
+			return
+		}
+		var geo []Field
+		for _, db := range cfg.DBs {
+			rec, err := db.Lookup(addr)
+			if err == nil && rec != nil {
+				geo = appendGeoFields(geo, rec, cfg.Language)
+			}
+		}
+		if len(geo) > 0 {
+			e.AddFields(Group(GeoIPKey, geo...))
+		}
+	})
+}
+
+// WithGeoIP enriches every entry with the location of its address, see
+// GeoIP.
+func WithGeoIP(cfg GeoIPConfig) Option {
+	return WithProcessors(GeoIP(cfg))
+}
+
+// geoIPAddr parses the address of a field, with or without a port.
+func geoIPAddr(v interface{}) (netip.Addr, bool) {
+	switch v := v.(type) {
+	case netip.Addr:
+		return v, v.IsValid()
+	case net.IP:
+		a, ok := netip.AddrFromSlice(v)
+		return a, ok
+	case string:
+		s, _, _ := strings.Cut(v, ",")
+		s = strings.TrimSpace(s)
+		if ap, err := netip.ParseAddrPort(s); err == nil {
+			return ap.Addr(), true
+		}
+		a, err := netip.ParseAddr(s)
+		return a, err == nil
+	}
+	return netip.Addr{}, false
+}
+
+func appendGeoFields(geo []Field, rec map[string]interface{}, lang string) []Field {
+	if s, ok := mmdbString(rec, "country", "iso_code"); ok {
+		geo = append(geo, String("country", s))
+	}
+	if subs, _ := rec["subdivisions"].([]interface{}); len(subs) > 0 {
+		if s, ok := mmdbString(subs[0], "iso_code"); ok {
+			geo = append(geo, String("region", s))
+		}
+	}
+	if s, ok := mmdbString(rec, "city", "names", lang); ok {
+		geo = append(geo, String("city", s))
+	}
+	if n, ok := rec["autonomous_system_number"].(uint64); ok {
+		geo = append(geo, Int64("asn", int64(n)))
+	}
+	if s, ok := rec["autonomous_system_organization"].(string); ok {
+		geo = append(geo, String("as_org", s))
+	}
+	return geo
+}
+
+// mmdbString returns the string at the path of maps below v.
+func mmdbString(v interface{}, path ...string) (string, bool) {
+	for _, k := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		v = m[k]
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,177 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mmdbPtr is written by mmdbValue as a pointer to a data offset.
+type mmdbPtr int
+
+// mmdbValue encodes v in the MaxMind DB data format.
+func mmdbValue(v interface{}) []byte {
+	head := func(typ, size int) []byte {
+		var ext []byte
+		if size >= 29 {
+			ext, size = []byte{byte(size - 29)}, 29
+		}
+		if typ < 8 {
+			return append([]byte{byte(typ<<5 | size)}, ext...)
+		}
+		return append([]byte{byte(size), byte(typ - 7)}, ext...)
+	}
+	switch v := v.(type) {
+	case mmdbPtr:
+		return []byte{1<<5 | byte(v>>8&7), byte(v)}
+	case string:
+		return append(head(2, len(v)), v...)
+	case uint64:
+		b := binary.BigEndian.AppendUint32(nil, uint32(v))
+		return append(head(6, 4), b...)
+	case []interface{}:
+		b := head(11, len(v))
+		for _, e := range v {
+			b = append(b, mmdbValue(e)...)
+		}
+		return b
+	case map[string]interface{}:
+		b := head(7, len(v))
+		for k, e := range v {
+			b = append(b, mmdbValue(k)...)
+			b = append(b, mmdbValue(e)...)
+		}
+		return b
+	}
+	panic("unsupported value")
+}
+
+// writeMMDB writes a MaxMind DB mapping prefixes to the data offsets of
+// records.
+func writeMMDB(t *testing.T, version, recordSize int, data []byte, prefixes map[netip.Prefix]int) string {
+	t.Helper()
+	const empty = -1
+	nodes := [][2]int{{empty, empty}}
+	leaves := map[[2]int]int{}
+	for p, off := range prefixes {
+		ip := p.Addr().AsSlice()
+		bits := p.Bits()
+		if version == 6 && p.Addr().Is4() {
+			ip, bits = append(make([]byte, 12), ip...), bits+96
+		}
+		n := 0
+		for i := 0; i < bits-1; i++ {
+			bit := int(ip[i/8] >> (7 - i%8) & 1)
+			if nodes[n][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[n][bit] = len(nodes) - 1
+			}
+			n = nodes[n][bit]
+		}
+		leaves[[2]int{n, int(ip[(bits-1)/8] >> (7 - (bits-1)%8) & 1)}] = off
+	}
+	var tree []byte
+	for n, children := range nodes {
+		var rec [2]uint32
+		for bit, c := range children {
+			switch off, ok := leaves[[2]int{n, bit}]; {
+			case ok:
+				rec[bit] = uint32(len(nodes) + 16 + off)
+			case c == empty:
+				rec[bit] = uint32(len(nodes))
+			default:
+				rec[bit] = uint32(c)
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		case 28:
+			tree = append(tree, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[0]>>20&0xf0|rec[1]>>24&0x0f), byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		}
+	}
+	b := append(tree, make([]byte, 16)...)
+	b = append(b, data...)
+	b = append(b, geoIPMetadataMarker...)
+	b = append(b, mmdbValue(map[string]interface{}{
+		"node_count":    uint64(len(nodes)),
+		"record_size":   uint64(recordSize),
+		"ip_version":    uint64(version),
+		"database_type": "Test-City",
+	})...)
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGeoIP(t *testing.T) {
+	de := mmdbValue("DE")
+	city := mmdbValue(map[string]interface{}{
+		"country":      map[string]interface{}{"iso_code": mmdbPtr(0)},
+		"subdivisions": []interface{}{map[string]interface{}{"iso_code": "BE"}},
+		"city":         map[string]interface{}{"names": map[string]interface{}{"en": "Berlin"}},
+	})
+	v6 := mmdbValue(map[string]interface{}{"country": map[string]interface{}{"iso_code": "NL"}})
+	data := append(append(append([]byte(nil), de...), city...), v6...)
+	path := writeMMDB(t, 6, 28, data, map[netip.Prefix]int{
+		netip.MustParsePrefix("203.0.113.0/24"):  len(de),
+		netip.MustParsePrefix("2001:db8::/32"):   len(de) + len(city),
+		netip.MustParsePrefix("198.51.100.0/25"): len(de),
+	})
+	cityDB, err := OpenGeoIP(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cityDB.Type != "Test-City" {
+		t.Errorf("Type = %q", cityDB.Type)
+	}
+	asnDB, err := OpenGeoIP(writeMMDB(t, 4, 24, mmdbValue(map[string]interface{}{
+		"autonomous_system_number":       uint64(64496),
+		"autonomous_system_organization": "Example Net",
+	}), map[netip.Prefix]int{netip.MustParsePrefix("203.0.0.0/8"): 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := GeoIP(GeoIPConfig{DBs: []*GeoIPDB{cityDB, asnDB}})
+	geo := func(ip string) map[string]interface{} {
+		e := &Entry{Fields: []Field{String("ip", ip)}}
+		p.Process(e)
+		m := map[string]interface{}{}
+		for _, k := range []string{"country", "region", "city", "asn", "as_org"} {
+			if v, ok := lookupField(e.Fields, GeoIPKey+"."+k); ok {
+				m[k] = v
+			}
+		}
+		return m
+	}
+	m := geo("203.0.113.42:8080")
+	if m["country"] != "DE" || m["region"] != "BE" || m["city"] != "Berlin" || m["asn"] != int64(64496) || m["as_org"] != "Example Net" {
+		t.Errorf("203.0.113.42: %v", m)
+	}
+	if m := geo("2001:db8::1"); m["country"] != "NL" || len(m) != 1 {
+		t.Errorf("2001:db8::1: %v", m)
+	}
+	if m := geo("198.51.100.200"); len(m) != 0 {
+		t.Errorf("198.51.100.200: %v", m)
+	}
+	if m := geo("not an address"); len(m) != 0 {
+		t.Errorf("invalid: %v", m)
+	}
+}
+
+func TestOpenGeoIPInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.mmdb")
+	os.WriteFile(path, []byte("not a database"), 0o644)
+	if _, err := OpenGeoIP(path); err == nil {
+		t.Error("want an error")
+	}
+}
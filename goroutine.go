@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// GoroutineKey is the field holding the calling goroutine's ID.
+const GoroutineKey = "goroutine"
+
+// GoroutineID returns a processor that tags entries with the ID of the
+// goroutine that logged them, as shown in panics and stack dumps. Reading
+// the ID costs a short runtime.Stack call per entry; use it for debugging
+// concurrent flows rather than in hot paths.
+func GoroutineID() Processor {
+	return ProcessorFunc(func(e *Entry) {
+		if id := goroutineID(); id > 0 {
+			e.AddFields(Int64(GoroutineKey, id))
+		}
+	})
+}
+
+// goroutineID parses "goroutine 42 [running]:" from the current stack.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	buf := make([]byte, 64)
+	want, _ := strconv.ParseInt(strings.Fields(string(buf[:runtime.Stack(buf, false)]))[1], 10, 64)
+	if id := goroutineID(); id != want || id == 0 {
+		t.Errorf("id %d, want %d", id, want)
+	}
+
+	ids := make(chan int64)
+	go func() { ids <- goroutineID() }()
+	if other := <-ids; other == want || other == 0 {
+		t.Errorf("other goroutine's id %d", other)
+	}
+
+	e := &Entry{}
+	GoroutineID().Process(e)
+	if len(e.Fields) != 1 || e.Fields[0].Key != GoroutineKey || e.Fields[0].Integer != want {
+		t.Errorf("fields %+v", e.Fields)
+	}
+}
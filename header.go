@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	// HeaderKey is the group holding the fields of a file header entry.
+	HeaderKey = "log_header"
+	// HeaderMessage is the message of a file header entry.
+	HeaderMessage = "log file opened"
+	// HeaderFormatVersion is the format version written when
+	// HeaderConfig.FormatVersion is empty.
+	HeaderFormatVersion = "1"
+
+	HeaderErrFmt = "Failed to write log file header: %w"
+)
+
+// ErrHeaderWithChain is returned by New when WithFileHeader is combined
+// with WithAuditChain, whose verification rejects unchained lines.
+var ErrHeaderWithChain = errors.New("file headers cannot be combined with an audit chain")
+
+// processStart is the start time written in file headers.
+var processStart = time.Now()
+
+// HeaderConfig configures the header entry a File writes first whenever it
+// opens a file, including after rotation, so every file names the process
+// and format that wrote it once archived:
+//
+//	log_header.app=api log_header.version=v1.4.2 log_header.pid=4711 log_header.host=web-1 log_header.started=... log_header.format=1
+type HeaderConfig struct {
+	// App is the application name; empty selects the executable name.
+	App string
+	// Version is the application version; empty selects the version of
+	// the main module from its build information, if any.
+	Version string
+	// FormatVersion identifies the format or schema of the entries;
+	// empty selects HeaderFormatVersion.
+	FormatVersion string
+	// Encoder encodes the header; nil selects TextEncoder. New uses the
+	// encoder of the log output.
+	Encoder Encoder
+	// Fields are added to the header group, e.g. the commit or region.
+	Fields []Field
+}
+
+// WithFileHeader writes a header entry at the start of every log file
+// opened, see HeaderConfig.
+func WithFileHeader(cfg HeaderConfig) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Header = &cfg
+	}
+}
+
+func (cfg *HeaderConfig) withDefaults() *HeaderConfig {
+	c := *cfg
+	if c.App == "" {
+		c.App = filepath.Base(os.Args[0])
+	}
+	if c.Version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+			c.Version = info.Main.Version
+		}
+	}
+	if c.FormatVersion == "" {
+		c.FormatVersion = HeaderFormatVersion
+	}
+	if c.Encoder == nil {
+		c.Encoder = TextEncoder{}
+	}
+	return &c
+}
+
+// writeHeaderLocked writes the header entry, if configured, reporting a
+// failure without failing the open. lf.mu must be held once the File is
+// in use.
+func (lf *File) writeHeaderLocked() {
+	cfg := lf.cfg.Header
+	if cfg == nil {
+		return
+	}
+	host, _ := os.Hostname()
+	fields := append([]Field{
+		String("app", cfg.App),
+		String("version", cfg.Version),
+		Int("pid", os.Getpid()),
+		String("host", host),
+		Time("started", processStart),
+		String("format", cfg.FormatVersion),
+	}, cfg.Fields...)
+	e := &Entry{Time: time.Now(), Level: Info, Name: cfg.App, Message: HeaderMessage, Fields: []Field{Group(HeaderKey, fields...)}}
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	err := cfg.Encoder.Encode(buf, e)
+	if err == nil {
+		var n int
+		n, err = lf.w.Write(buf.Bytes())
+		lf.size += int64(n)
+	}
+	if err != nil {
+		lf.cfg.ErrorHandler(fmt.Errorf(HeaderErrFmt, err))
+	}
+}
@@ -0,0 +1,81 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := New(Info, "api", path, WithEncoder(JSONEncoder{}),
+		WithFileHeader(HeaderConfig{App: "api", Version: "v1.4.2", Fields: []Field{String("region", "eu")}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("first")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("second")
+	l.Close(context.Background())
+
+	archives, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "app-*.log"))
+	if len(archives) != 1 {
+		t.Fatalf("archives %v", archives)
+	}
+	for _, p := range []string{archives[0], path} {
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := bufio.NewScanner(f)
+		sc.Scan()
+		e, err := DecodeJSON(sc.Bytes())
+		f.Close()
+		if err != nil || e.Message != HeaderMessage {
+			t.Fatalf("%s: first line %q (%v)", p, sc.Text(), err)
+		}
+		for key, want := range map[string]interface{}{
+			"log_header.app":     "api",
+			"log_header.version": "v1.4.2",
+			"log_header.pid":     int64(os.Getpid()),
+			"log_header.format":  HeaderFormatVersion,
+			"log_header.region":  "eu",
+		} {
+			if v, _ := lookupField(e.Fields, key); v != want {
+				t.Errorf("%s: %s = %v, want %v", p, key, v, want)
+			}
+		}
+	}
+}
+
+func TestFileHeaderWithChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	_, err := New(Info, "api", path, WithFileHeader(HeaderConfig{}), WithAuditChain(ChainConfig{}))
+	if err != ErrHeaderWithChain {
+		t.Errorf("New = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file created")
+	}
+}
+
+func TestFileHeaderDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenFile(path, FileConfig{Header: &HeaderConfig{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	b, _ := os.ReadFile(path)
+	app := filepath.Base(os.Args[0])
+	if !strings.Contains(string(b), "log_header.app="+app) || !strings.Contains(string(b), "log_header.format=1") {
+		t.Errorf("header %q", b)
+	}
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Health describes whether a sink is delivering entries.
+type Health struct {
+	// Sink identifies the sink by its type, e.g. "*logger.BatchSink".
+	Sink string
+	// LastSuccess and LastFailure are the times of the latest successful
+	// and failed writes; zero if none happened yet.
+	LastSuccess time.Time
+	LastFailure time.Time
+	// LastError is the error of the latest failure.
+	LastError error
+	// ConsecutiveFailures counts failures since the last success.
+	ConsecutiveFailures uint64
+	// QueueDepth is the number of entries buffered but not yet written.
+	QueueDepth int
+}
+
+// Healthy reports whether the latest write succeeded or none failed yet.
+func (h Health) Healthy() bool {
+	return h.ConsecutiveFailures == 0
+}
+
+// HealthReporter is implemented by sinks that track their own delivery,
+// such as the asynchronous and batching sinks whose WriteEntry returns
+// before the entry is written.
+type HealthReporter interface {
+	Health() Health
+}
+
+// healthTracker records the outcome of writes. Successes only touch
+// atomics so that tracking stays cheap on the logging path.
+type healthTracker struct {
+	lastSuccess atomic.Int64
+	failures    atomic.Uint64
+
+	mu          sync.Mutex
+	lastFailure time.Time
+	lastErr     error
+}
+
+func (t *healthTracker) record(now time.Time, err error) {
+	if err == nil {
+		t.lastSuccess.Store(now.UnixNano())
+		if t.failures.Load() != 0 {
+			t.failures.Store(0)
+		}
+		return
+	}
+	t.failures.Add(1)
+	t.mu.Lock()
+	t.lastFailure = now
+	t.lastErr = err
+	t.mu.Unlock()
+}
+
+// lastError returns the error of the latest failure, if any.
+func (t *healthTracker) lastError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastErr
+}
+
+func (t *healthTracker) health(sink interface{}) Health {
+	h := Health{
+		Sink:                fmt.Sprintf("%T", sink),
+		ConsecutiveFailures: t.failures.Load(),
+	}
+	if ns := t.lastSuccess.Load(); ns != 0 {
+		h.LastSuccess = time.Unix(0, ns)
+	}
+	t.mu.Lock()
+	h.LastFailure = t.lastFailure
+	h.LastError = t.lastErr
+	t.mu.Unlock()
+	return h
+}
+
+// Health returns the health of every sink, in the order they were
+// configured. Sinks implementing HealthReporter describe themselves; for
+// the others the logger tracks the results of WriteEntry.
+func (l *CustomLogger) Health() []Health {
+	hs := make([]Health, len(l.sinks))
+	for i, s := range l.sinks {
+		if r, ok := s.(HealthReporter); ok {
+			hs[i] = r.Health()
+		} else {
+			hs[i] = l.health[i].health(s)
+		}
+	}
+	return hs
+}
+
+// Healthy reports whether every sink is healthy, for readiness probes.
+func (l *CustomLogger) Healthy() bool {
+	for _, h := range l.Health() {
+		if !h.Healthy() {
+			return false
+		}
+	}
+	return true
+}
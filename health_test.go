@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// toggleWriter fails its writes while fail is set.
+type toggleWriter struct {
+	fail bool
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, io.ErrShortWrite
+	}
+	return len(p), nil
+}
+
+func TestHealth(t *testing.T) {
+	w := &toggleWriter{}
+	l := newTestLogger(t, Info, new(bytes.Buffer), WithSinks(NewWriterSink(w, TextEncoder{})), WithErrorHandler(nil))
+	if !l.Healthy() {
+		t.Error("unhealthy before any write")
+	}
+	l.Info("ok")
+	w.fail = true
+	l.Info("lost")
+	l.Info("lost")
+	hs := l.Health()
+	h := hs[len(hs)-1]
+	if l.Healthy() || h.ConsecutiveFailures != 2 || !errors.Is(h.LastError, io.ErrShortWrite) ||
+		h.LastSuccess.IsZero() || h.LastFailure.Before(h.LastSuccess) || h.Sink != "*logger.WriterSink" {
+		t.Errorf("health %+v", h)
+	}
+
+	// A success clears the failures but keeps the latest error.
+	w.fail = false
+	l.Info("ok")
+	if h := l.Health()[len(hs)-1]; !l.Healthy() || h.ConsecutiveFailures != 0 || h.LastError == nil {
+		t.Errorf("health %+v", h)
+	}
+}
+
+func TestHealthReporter(t *testing.T) {
+	w := &toggleWriter{fail: true}
+	async := NewAsyncSink(NewWriterSink(w, TextEncoder{}), AsyncConfig{ErrorHandler: func(error) {}})
+	defer async.Close()
+	async.WriteEntry(&Entry{Message: "lost"})
+	async.Flush()
+	if h := async.Health(); h.Healthy() || h.Sink != "*logger.WriterSink" || h.QueueDepth != 0 {
+		t.Errorf("async health %+v", h)
+	}
+
+	sender := &flakySender{fail: 1}
+	batch := NewBatchSink(sender, BatchConfig{ErrorHandler: func(error) {}})
+	defer batch.Close()
+	batch.WriteEntry(&Entry{Message: "lost"})
+	batch.WriteEntry(&Entry{Message: "pending"})
+	if h := batch.Health(); h.QueueDepth != 2 {
+		t.Errorf("batch health %+v", h)
+	}
+	batch.Flush()
+	if h := batch.Health(); h.Healthy() || h.QueueDepth != 0 {
+		t.Errorf("batch health %+v", h)
+	}
+	batch.WriteEntry(&Entry{Message: "sent"})
+	batch.Flush()
+	if h := batch.Health(); !h.Healthy() || h.LastSuccess.IsZero() {
+		t.Errorf("batch health %+v", h)
+	}
+}
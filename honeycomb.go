@@ -0,0 +1,156 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHoneycombURL is the Honeycomb API used when none is
+	// configured.
+	DefaultHoneycombURL = "https://api.honeycomb.io"
+	// SampleRateKey is the field giving the sample rate an entry was kept
+	// at, for sinks that weight sampled entries.
+	SampleRateKey = "samplerate"
+
+	honeycombBatchFmt = "%s/1/batch/%s"
+	honeycombMaxBatch = 1000
+)
+
+// HoneycombConfig configures a HoneycombSender.
+type HoneycombConfig struct {
+	// APIKey authenticates with the API.
+	APIKey string
+	// Dataset receives the events.
+	Dataset string
+	// URL is the API, e.g. for the EU region or a Refinery proxy; empty
+	// selects DefaultHoneycombURL.
+	URL string
+	// SampleRate sends one entry in SampleRate, weighted by that rate;
+	// zero or one sends all. Entries of one trace are kept or dropped
+	// together.
+	SampleRate uint
+	// Compression, if set, compresses request bodies, e.g. Gzip.
+	Compression Compressor
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// HoneycombSender is a BatchSender posting entries as events to the
+// Honeycomb batch API. The entry's fields become columns next to name,
+// level and message; trace_id and parent_id become trace.trace_id and
+// trace.parent_id so logs line up with traces. A samplerate field, as
+// set by an upstream sampler, is sent as the event's sample rate and the
+// entry is not sampled again.
+type HoneycombSender struct {
+	cfg  HoneycombConfig
+	url  string
+	seen atomic.Uint64
+}
+
+// NewHoneycombSender creates a HoneycombSender.
+func NewHoneycombSender(cfg HoneycombConfig) *HoneycombSender {
+	if cfg.URL == "" {
+		cfg.URL = DefaultHoneycombURL
+	}
+	if cfg.SampleRate == 0 {
+		cfg.SampleRate = 1
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &HoneycombSender{
+		cfg: cfg,
+		url: fmt.Sprintf(honeycombBatchFmt, cfg.URL, url.PathEscape(cfg.Dataset)),
+	}
+}
+
+// honeycombEvent is one event of a batch request.
+type honeycombEvent struct {
+	Time       string                 `json:"time"`
+	SampleRate uint                   `json:"samplerate,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// SendBatch implements BatchSender.
+func (s *HoneycombSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	events := make([]honeycombEvent, 0, len(batch))
+	for _, e := range batch {
+		if ev, ok := s.event(e); ok {
+			events = append(events, ev)
+		}
+	}
+	for start := 0; start < len(events); start += honeycombMaxBatch {
+		end := start + honeycombMaxBatch
+		if end > len(events) {
+			end = len(events)
+		}
+		if err := s.send(ctx, events[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// event converts e, reporting false if sampling drops it.
+func (s *HoneycombSender) event(e *Entry) (honeycombEvent, bool) {
+	data := e.FieldMap()
+	rate := s.cfg.SampleRate
+	if v, ok := toFloat(data[SampleRateKey]); ok && v >= 1 {
+		rate = uint(v)
+		delete(data, SampleRateKey)
+	} else if rate > 1 && !s.keep(data[TraceIDKey], rate) {
+		return honeycombEvent{}, false
+	}
+	for _, k := range []string{TraceIDKey, ParentIDKey} {
+		if v, ok := data[k]; ok {
+			data["trace."+k] = v
+			delete(data, k)
+		}
+	}
+	if e.Name != "" {
+		data["name"] = e.Name
+	}
+	data["level"] = datadogStatus(e.Level)
+	data["message"] = e.Message
+	ev := honeycombEvent{Time: e.Time.Format(time.RFC3339Nano), Data: data}
+	if rate > 1 {
+		ev.SampleRate = rate
+	}
+	return ev, true
+}
+
+// keep decides whether to send an entry sampled at rate: by its trace ID
+// if it has one, so a trace is kept whole, or else one in rate in turn.
+func (s *HoneycombSender) keep(traceID interface{}, rate uint) bool {
+	if id, ok := traceID.(string); ok && id != "" {
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		return h.Sum32()%uint32(rate) == 0
+	}
+	return s.seen.Add(1)%uint64(rate) == 1
+}
+
+func (s *HoneycombSender) send(ctx context.Context, events []honeycombEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+	req, err := newPostRequest(ctx, s.url, body, s.cfg.Compression)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.APIKey != "" {
+		req.Header.Set("X-Honeycomb-Team", s.cfg.APIKey)
+	}
+	return doHTTP(s.cfg.Client, req)
+}
@@ -0,0 +1,48 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHoneycombSender(t *testing.T) {
+	var got []honeycombEvent
+	var path, team string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, team = r.URL.Path, r.Header.Get("X-Honeycomb-Team")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewHoneycombSender(HoneycombConfig{APIKey: "k", Dataset: "my logs", URL: srv.URL, SampleRate: 2})
+	batch := []*Entry{
+		{Time: testTime, Level: Warn, Name: "api", Message: "slow", Fields: []Field{String(TraceIDKey, "t1"), Int(SampleRateKey, 10)}},
+		{Time: testTime, Level: Info, Message: "a"},
+		{Time: testTime, Level: Info, Message: "b"},
+		{Time: testTime, Level: Info, Message: "c"},
+	}
+	if err := s.SendBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/1/batch/my%20logs" && path != "/1/batch/my logs" || team != "k" {
+		t.Errorf("path %q, team %q", path, team)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(got), got)
+	}
+	first := got[0]
+	if first.SampleRate != 10 || first.Data["trace.trace_id"] != "t1" || first.Data["level"] != "warn" ||
+		first.Data["name"] != "api" || first.Data[SampleRateKey] != nil || first.Time != "2024-03-01T12:30:45Z" {
+		t.Errorf("first event = %+v", first)
+	}
+	if got[1].Data["message"] != "a" || got[1].SampleRate != 2 || got[2].Data["message"] != "c" {
+		t.Errorf("sampled events = %+v", got[1:])
+	}
+}
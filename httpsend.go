@@ -0,0 +1,55 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const HTTPStatusErrFmt = "%s returned status %s: %s"
+
+// doHTTP sends req and drains the response. Non-2xx statuses become
+// errors; client errors other than 408 and 429 are marked Permanent since
+// resending the same batch cannot succeed.
+func doHTTP(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf(HTTPStatusErrFmt, req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// newPostRequest builds a POST of body, compressed with c when non-nil.
+func newPostRequest(ctx context.Context, url string, body []byte, c Compressor) (*http.Request, error) {
+	if c != nil {
+		var buf bytes.Buffer
+		if err := c.Compress(&buf, body); err != nil {
+			return nil, fmt.Errorf(CompressPayloadErrFmt, err)
+		}
+		body = buf.Bytes()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		req.Header.Set("Content-Encoding", c.Encoding())
+	}
+	return req, nil
+}
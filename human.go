@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"strconv"
+	"time"
+)
+
+// Bytes returns a group holding a size in bytes twice: as a number under
+// "bytes" for dashboards and queries, and in IEC units under "human", e.g.
+//
+//	size.bytes=1503238553 size.human="1.4 GiB"
+func Bytes(key string, n int64) Field {
+	return Group(key, Int64("bytes", n), String("human", HumanBytes(n)))
+}
+
+// DurationHuman returns a group holding a duration twice: as a number of
+// seconds under "seconds" and rounded for reading under "human", e.g.
+//
+//	elapsed.seconds=123.456 elapsed.human=2m3s
+func DurationHuman(key string, d time.Duration) Field {
+	return Group(key, Float("seconds", d.Seconds()), String("human", HumanDuration(d)))
+}
+
+var byteUnits = [...]string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// HumanBytes formats n in bytes below 1 KiB and with one decimal in the
+// largest IEC unit it reaches above, e.g. "512 B" or "1.4 GiB".
+func HumanBytes(n int64) string {
+	if n > -1024 && n < 1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	v, unit := float64(n)/1024, 0
+	for (v >= 1024 || v <= -1024) && unit < len(byteUnits)-1 {
+		v /= 1024
+		unit++
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + byteUnits[unit]
+}
+
+// HumanDuration rounds d for reading: to the second from a minute on, to
+// the millisecond from a second and to the microsecond from a millisecond,
+// e.g. "2m3s", "1.235s" or "12.346ms".
+func HumanDuration(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= time.Minute:
+		d = d.Round(time.Second)
+	case abs >= time.Second:
+		d = d.Round(time.Millisecond)
+	case abs >= time.Millisecond:
+		d = d.Round(time.Microsecond)
+	}
+	return d.String()
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestHumanBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:          "0 B",
+		512:        "512 B",
+		1536:       "1.5 KiB",
+		1503238553: "1.4 GiB",
+		-2 << 20:   "-2.0 MiB",
+		1 << 62:    "4.0 EiB",
+	} {
+		if got := HumanBytes(n); got != want {
+			t.Errorf("%d: got %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		123456 * time.Millisecond:  "2m3s",
+		1234567 * time.Microsecond: "1.235s",
+		12345678 * time.Nanosecond: "12.346ms",
+		500 * time.Nanosecond:      "500ns",
+	} {
+		if got := HumanDuration(d); got != want {
+			t.Errorf("%v: got %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestHumanFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}))
+	l.Log(Info, "upload", Bytes("size", 1503238553), DurationHuman("elapsed", 123456*time.Millisecond))
+	want := ` size.bytes=1503238553 size.human="1.4 GiB" elapsed.seconds=123.456 elapsed.human=2m3s` + "\n"
+	if s := buf.String(); !bytes.HasSuffix(buf.Bytes(), []byte(want)) {
+		t.Errorf("output = %q", s)
+	}
+}
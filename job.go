@@ -0,0 +1,62 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+const (
+	// JobKey is the field naming the job of a RunLogger.
+	JobKey = "job"
+	// RunKey is the field numbering the runs of a job, from 1.
+	RunKey = "run"
+	// StatusKey is the field holding the outcome of a run: "success",
+	// "failure" or "panic".
+	StatusKey = "status"
+
+	JobPanicErrFmt = "job panicked: %v"
+)
+
+// RunLogger gives the runs of a scheduled job uniform operational logs:
+// start, end, duration and outcome, with panics recovered.
+type RunLogger struct {
+	l    *CustomLogger
+	job  string
+	runs atomic.Int64
+}
+
+// RunLogger returns a RunLogger for job whose entries carry fields.
+func (l *CustomLogger) RunLogger(job string, fields ...Field) *RunLogger {
+	return &RunLogger{l: l.With(append([]Field{String(JobKey, job)}, fields...)...), job: job}
+}
+
+// Run logs "<job> started" at Info, calls fn with a logger carrying the run
+// number, also stored in ctx (see FromContext), and logs the outcome with
+// the duration: "<job> succeeded" at Info, "<job> failed" at Error with
+// the error, or "<job> panicked" at Error with the value and stack. A panic
+// is not propagated; Run returns it as an error instead.
+func (r *RunLogger) Run(ctx context.Context, fn func(ctx context.Context, l *CustomLogger) error) (err error) {
+	l := r.l.With(Int64(RunKey, r.runs.Add(1)))
+	job := r.job
+	start := l.clock()
+	l.log(Info, job+" started")
+	defer func() {
+		fields := []Field{Duration(DurationKey, l.clock().Sub(start))}
+		if v := recover(); v != nil {
+			err = fmt.Errorf(JobPanicErrFmt, v)
+			l.log(Error, job+" panicked", append(fields, String(StatusKey, "panic"),
+				Any("panic", v), String(StackKey, string(debug.Stack())))...)
+			return
+		}
+		if err != nil {
+			l.log(Error, job+" failed", append(fields, String(StatusKey, "failure"), Err(err))...)
+			return
+		}
+		l.log(Info, job+" succeeded", append(fields, String(StatusKey, "success"))...)
+	}()
+	return fn(NewContext(ctx, l), l)
+}
@@ -0,0 +1,57 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	r := l.RunLogger("backup", String("target", "s3"))
+
+	tests := []struct {
+		fn      func(ctx context.Context, l *CustomLogger) error
+		wantErr string
+		want    []string
+	}{
+		{
+			func(ctx context.Context, l *CustomLogger) error {
+				if FromContext(ctx, nil) != l {
+					t.Error("run logger not in context")
+				}
+				l.Info("copying")
+				return nil
+			},
+			"",
+			[]string{"backup started job=backup target=s3 run=1", "copying job=backup target=s3 run=1", "backup succeeded job=backup target=s3 run=1 duration=0s status=success"},
+		},
+		{
+			func(context.Context, *CustomLogger) error { return errors.New("disk full") },
+			"disk full",
+			[]string{"backup started", "ERROR", "backup failed job=backup target=s3 run=2 duration=0s status=failure error=\"disk full\""},
+		},
+		{
+			func(context.Context, *CustomLogger) error { panic("nil map") },
+			"job panicked: nil map",
+			[]string{"backup panicked job=backup target=s3 run=3 duration=0s status=panic panic=\"nil map\" stack="},
+		},
+	}
+	for i, tt := range tests {
+		buf.Reset()
+		err := r.Run(context.Background(), tt.fn)
+		if (err == nil) != (tt.wantErr == "") || err != nil && err.Error() != tt.wantErr {
+			t.Errorf("run %d: err = %v, want %q", i+1, err, tt.wantErr)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("run %d: output %q lacks %q", i+1, buf.String(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const DecodeJSONErrFmt = "Invalid JSON log entry: %w"
+
+// jsonEncoder returns JSONEncoder; nil in the logger_minimal build.
+func jsonEncoder() Encoder { return JSONEncoder{} }
+
+// JSONEncoder writes entries as one JSON object per line with the keys
+// time (RFC 3339 with nanoseconds), level (by name), name, msg and fields,
+// the fields as an object, see Entry.FieldMap.
+type JSONEncoder struct {
+	// Order is the order of the fields, sorted by key by default.
+	Order FieldOrder
+}
+
+// jsonEntry is the document written by JSONEncoder.
+type jsonEntry struct {
+	Time    string                 `json:"time"`
+	Level   string                 `json:"level"`
+	Name    string                 `json:"name,omitempty"`
+	Message string                 `json:"msg"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// orderedJSONEntry is a jsonEntry with the fields in insertion order.
+type orderedJSONEntry struct {
+	jsonEntry
+	Fields orderedFields `json:"fields,omitempty"`
+}
+
+// newJSONHeader returns the document of e without its fields.
+func newJSONHeader(e *Entry) jsonEntry {
+	return jsonEntry{
+		Time:    e.Time.Format(time.RFC3339Nano),
+		Level:   e.Level.String(),
+		Name:    e.Name,
+		Message: e.Message,
+	}
+}
+
+func newJSONEntry(e *Entry) jsonEntry {
+	doc := newJSONHeader(e)
+	if len(e.Fields) > 0 {
+		doc.Fields = e.FieldMap()
+	}
+	return doc
+}
+
+// Encode implements Encoder.
+func (j JSONEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	var doc interface{}
+	if j.Order == InsertionOrder {
+		doc = orderedJSONEntry{jsonEntry: newJSONHeader(e), Fields: e.Fields}
+	} else {
+		doc = newJSONEntry(e)
+	}
+	// The encoder only writes once marshaling succeeded, and keeps <, >
+	// and & readable; it ends the document with a newline.
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+	return nil
+}
+
+// DecodeJSON parses a line written by JSONEncoder back into an entry. The
+// fields come back sorted by key, objects as groups, whole numbers as
+// int64 and other numbers as float64.
+func DecodeJSON(line []byte) (*Entry, error) {
+	var doc struct {
+		Time    string                 `json:"time"`
+		Level   LogLevel               `json:"level"`
+		Name    string                 `json:"name"`
+		Message *string                `json:"msg"`
+		Fields  map[string]interface{} `json:"fields"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf(DecodeJSONErrFmt, err)
+	}
+	if doc.Message == nil {
+		return nil, fmt.Errorf(DecodeJSONErrFmt, errNoMessage)
+	}
+	t, err := time.Parse(time.RFC3339Nano, doc.Time)
+	if err != nil {
+		return nil, fmt.Errorf(DecodeJSONErrFmt, err)
+	}
+	return &Entry{
+		Time:    t,
+		Level:   doc.Level,
+		Name:    doc.Name,
+		Message: *doc.Message,
+		Fields:  decodeJSONFields(doc.Fields),
+	}, nil
+}
+
+func decodeJSONFields(m map[string]interface{}) []Field {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]Field, len(keys))
+	for i, k := range keys {
+		fields[i] = decodeJSONField(k, m[k])
+	}
+	return fields
+}
+
+func decodeJSONField(k string, v interface{}) Field {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return Group(k, decodeJSONFields(v)...)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return Int64(k, n)
+		}
+		f, _ := v.Float64()
+		return Float(k, f)
+	case string:
+		return String(k, v)
+	case bool:
+		return Bool(k, v)
+	}
+	return Any(k, decodeJSONValue(v))
+}
+
+// decodeJSONValue converts the numbers within arrays.
+func decodeJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, el := range v {
+			v[i] = decodeJSONValue(el)
+		}
+	case map[string]interface{}:
+		for k, el := range v {
+			v[k] = decodeJSONValue(el)
+		}
+	}
+	return v
+}
+
+// MarshalJSON encodes the field as {"key": ..., "value": ...} whatever
+// its type.
+func (f Field) MarshalJSON() ([]byte, error) {
+	v := f.Interface()
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	return json.Marshal(struct {
+		Key   string      `json:"key"`
+		Value interface{} `json:"value"`
+	}{f.Key, v})
+}
+
+// orderedFields marshals fields as a JSON object in insertion order, with
+// the values of Entry.FieldMap.
+type orderedFields []Field
+
+func (fs orderedFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+next:
+	for i, f := range fs {
+		for _, later := range fs[i+1:] {
+			if later.Key == f.Key {
+				continue next
+			}
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		k, err := json.Marshal(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		var v interface{}
+		if f.Type == GroupType {
+			members, _ := f.Value.([]Field)
+			v = orderedFields(members)
+		} else {
+			v = fieldMap(fs[i : i+1])[f.Key]
+		}
+		b, err := marshalJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON marshals v keeping <, > and & readable, as JSONEncoder does.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,6 @@
+//go:build logger_minimal
+
+package logger
+
+// jsonEncoder returns nil: the logger_minimal build has no JSONEncoder.
+func jsonEncoder() Encoder { return nil }
@@ -0,0 +1,81 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	e := &Entry{
+		Time:    testTime,
+		Level:   Warn,
+		Name:    "app.db",
+		Message: "slow <query>",
+		Fields:  []Field{Int("ms", 1200), Err(errors.New("timeout")), Group("req", String("id", "r1"))},
+	}
+	var buf bytes.Buffer
+	if err := (JSONEncoder{}).Encode(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2024-03-01T12:30:45Z","level":"WARN","name":"app.db","msg":"slow <query>","fields":{"error":"timeout","ms":1200,"req":{"id":"r1"}}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	buf.Reset()
+	(JSONEncoder{}).Encode(&buf, &Entry{Time: testTime, Level: Info, Message: "plain"})
+	if got, want := buf.String(), `{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":"plain"}`+"\n"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	in := &Entry{
+		Time:    testTime.Add(123 * time.Microsecond),
+		Level:   Notice,
+		Name:    "app",
+		Message: "hello",
+		Fields: []Field{
+			String("s", "x"), Int("n", 7), Float("f", 1.5), Bool("b", true),
+			Group("g", Int("a", 1)), Any("list", []int{1, 2}),
+		},
+	}
+	var buf bytes.Buffer
+	if err := (JSONEncoder{}).Encode(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecodeJSON(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Time.Equal(in.Time) || out.Level != Notice || out.Name != "app" || out.Message != "hello" {
+		t.Errorf("decoded %+v", out)
+	}
+	var again bytes.Buffer
+	(JSONEncoder{}).Encode(&again, out)
+	if again.String() != buf.String() {
+		t.Errorf("round trip\n got %s\nwant %s", again.String(), buf.String())
+	}
+	if f := out.Fields[4]; f.Key != "n" || f.Interface() != int64(7) {
+		t.Errorf("field n = %#v", f)
+	}
+
+	for _, bad := range []string{`{"time":"x","level":"INFO","msg":"m"}`, `{"time":"2024-03-01T12:30:45Z","level":"INFO"}`, `{"level":"LOUD"}`, `text`} {
+		if _, err := DecodeJSON([]byte(bad)); err == nil {
+			t.Errorf("DecodeJSON(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestWithEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(JSONEncoder{}))
+	l.Log(Info, "hello", Int("n", 1))
+	if got, want := buf.String(), `{"time":"2024-03-01T12:30:45Z","level":"INFO","name":"test","msg":"hello","fields":{"n":1}}`+"\n"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
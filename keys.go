@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ReservedKeyPrefix is put before a field key colliding with a
+	// reserved key, e.g. "fields.level".
+	ReservedKeyPrefix = "fields."
+
+	KeyCollisionErrFmt = "Entry %q has colliding field keys: %s"
+)
+
+// ReservedKeys are the keys the encoders write for the time, level, logger
+// name and message of entries, and the object JSON nests fields in.
+var ReservedKeys = []string{"time", "ts", "level", "name", "logger", "msg", "fields"}
+
+// KeyPolicy selects what happens to fields whose key is one of
+// ReservedKeys or repeats the key of another field. The policy applies to
+// the fields of the entry before it is encoded, so every encoder writes
+// the same keys.
+type KeyPolicy int
+
+const (
+	// KeepKeys leaves fields as they are: JSON keeps the last of duplicate
+	// keys and text and logfmt write all of them, next to the built-ins.
+	KeepKeys KeyPolicy = iota
+	// PrefixKeys prefixes reserved keys with ReservedKeyPrefix and
+	// numbers duplicates from the second on, e.g. "id", "id_2".
+	PrefixKeys
+	// OverwriteKeys keeps the last field of duplicate keys, and lets a
+	// reserved field replace the built-in it names: a string msg replaces
+	// the message, a string name or logger the logger name, a LogLevel or
+	// level name the level and a time.Time time or ts the time. Reserved
+	// fields that cannot are prefixed.
+	OverwriteKeys
+	// ErrorKeys reports colliding keys to the error handler, then prefixes
+	// them like PrefixKeys; entries are never dropped for their keys.
+	ErrorKeys
+)
+
+// WithKeyPolicy sets the policy for field keys that collide with the
+// built-in keys or with each other; the default is KeepKeys. It runs after
+// the processors, on their fields too.
+func WithKeyPolicy(p KeyPolicy) Option {
+	return func(l *CustomLogger) {
+		l.keyPolicy = p
+	}
+}
+
+func reservedKey(k string) bool {
+	for _, r := range ReservedKeys {
+		if k == r {
+			return true
+		}
+	}
+	return false
+}
+
+// applyKeyPolicy resolves the key collisions of e.
+func (l *CustomLogger) applyKeyPolicy(e *Entry) {
+	if l.keyPolicy == OverwriteKeys {
+		overwriteKeys(e)
+		return
+	}
+	var collisions []string
+	// Going backwards leaves the keys before i untouched for counting.
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		k := e.Fields[i].Key
+		n := 1
+		for _, f := range e.Fields[:i] {
+			if f.Key == k {
+				n++
+			}
+		}
+		key := k
+		if reservedKey(k) {
+			key = ReservedKeyPrefix + k
+		}
+		if n > 1 {
+			key += "_" + strconv.Itoa(n)
+		}
+		if key != k {
+			e.Fields[i].Key = key
+			collisions = append(collisions, k)
+		}
+	}
+	if l.keyPolicy == ErrorKeys && len(collisions) > 0 {
+		slices.Reverse(collisions)
+		l.errorHandler(fmt.Errorf(KeyCollisionErrFmt, e.Message, strings.Join(collisions, ", ")))
+	}
+}
+
+// overwriteKeys keeps the last field of every key and moves reserved
+// fields into the entry where their value fits.
+func overwriteKeys(e *Entry) {
+	kept := e.Fields[:0]
+next:
+	for i, f := range e.Fields {
+		for _, later := range e.Fields[i+1:] {
+			if later.Key == f.Key {
+				continue next
+			}
+		}
+		if reservedKey(f.Key) {
+			if overwriteBuiltin(e, f) {
+				continue
+			}
+			f.Key = ReservedKeyPrefix + f.Key
+		}
+		kept = append(kept, f)
+	}
+	e.Fields = kept
+}
+
+func overwriteBuiltin(e *Entry, f Field) bool {
+	switch v := f.Interface().(type) {
+	case string:
+		switch f.Key {
+		case "msg":
+			e.Message = v
+			return true
+		case "name", "logger":
+			e.Name = v
+			return true
+		case "level":
+			if level, ok := ParseLevel(v); ok {
+				e.Level = level
+				return true
+			}
+		}
+	case LogLevel:
+		if f.Key == "level" {
+			e.Level = v
+			return true
+		}
+	case time.Time:
+		if f.Key == "time" || f.Key == "ts" {
+			e.Time = v
+			return true
+		}
+	}
+	return false
+}
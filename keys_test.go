@@ -0,0 +1,51 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyPolicyPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	var errs []error
+	l := newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}), WithKeyPolicy(ErrorKeys),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	l.Log(Info, "hit", String("level", "x"), Int("id", 1), Int("id", 2), String("msg", "a"), String("msg", "b"))
+	e, err := DecodeLogfmt(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Message != "hit" || e.Level != Info {
+		t.Errorf("built-ins overwritten: %+v", e)
+	}
+	var keys []string
+	for _, f := range e.Fields {
+		keys = append(keys, f.Key)
+	}
+	if got := strings.Join(keys, ","); got != "fields.level,id,id_2,fields.msg,fields.msg_2" {
+		t.Errorf("keys = %s", got)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "level, id, msg, msg") {
+		t.Errorf("errors = %v", errs)
+	}
+}
+
+func TestKeyPolicyOverwrite(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(JSONEncoder{}), WithKeyPolicy(OverwriteKeys))
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.Log(Info, "hit", String("msg", "replaced"), Any("level", Warn), Time("time", at),
+		Int("id", 1), Int("id", 2), Int("fields", 3))
+	e, err := DecodeJSON(bytes.TrimSpace(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := e.FieldMap()
+	if e.Message != "replaced" || e.Level != Warn || !e.Time.Equal(at) || len(m) != 2 || m["id"] != int64(2) || m["fields.fields"] != int64(3) {
+		t.Errorf("entry = %+v", e)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"os"
+	"strings"
+)
+
+// KubernetesKey is the group holding the pod metadata fields.
+const KubernetesKey = "k8s"
+
+// Environment variables read by KubernetesFields. Expose them to the
+// container through the Downward API, e.g.
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+const (
+	PodNameEnv       = "POD_NAME"
+	PodNamespaceEnv  = "POD_NAMESPACE"
+	NodeNameEnv      = "NODE_NAME"
+	ContainerNameEnv = "CONTAINER_NAME"
+)
+
+// serviceAccountNamespace is mounted into pods that use a service account.
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesFields returns a k8s group with the namespace, pod, node and
+// container of the current pod, or nil outside Kubernetes. Without the
+// Downward API variables the namespace comes from the service account and
+// the pod name from the hostname.
+func KubernetesFields() []Field {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+	namespace := os.Getenv(PodNamespaceEnv)
+	if namespace == "" {
+		if b, err := os.ReadFile(serviceAccountNamespace); err == nil {
+			namespace = strings.TrimSpace(string(b))
+		}
+	}
+	pod := os.Getenv(PodNameEnv)
+	if pod == "" {
+		pod, _ = os.Hostname()
+	}
+
+	var fields []Field
+	for _, f := range []Field{
+		String("namespace", namespace),
+		String("pod", pod),
+		String("node", os.Getenv(NodeNameEnv)),
+		String("container", os.Getenv(ContainerNameEnv)),
+	} {
+		if f.String != "" {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return []Field{Group(KubernetesKey, fields...)}
+}
+
+// WithKubernetes attaches KubernetesFields, read once, to every entry.
+func WithKubernetes() Option {
+	fields := KubernetesFields()
+	return func(l *CustomLogger) {
+		if fields != nil {
+			l.processors = append(l.processors, StaticFields(fields...))
+		}
+	}
+}
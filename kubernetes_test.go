@@ -0,0 +1,37 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesFields(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if f := KubernetesFields(); f != nil {
+		t.Errorf("fields outside Kubernetes: %+v", f)
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv(PodNamespaceEnv, "prod")
+	t.Setenv(PodNameEnv, "")
+	t.Setenv(NodeNameEnv, "node-1")
+	t.Setenv(ContainerNameEnv, "")
+	host, _ := os.Hostname()
+	want := []Field{Group(KubernetesKey, String("namespace", "prod"), String("pod", host), String("node", "node-1"))}
+	if got := KubernetesFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	t.Setenv(PodNameEnv, "api-7f9c")
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithKubernetes())
+	l.Info("ready")
+	if !strings.Contains(buf.String(), "k8s.namespace=prod k8s.pod=api-7f9c k8s.node=node-1") {
+		t.Errorf("log %q", buf.String())
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import "time"
+
+// Signed, Unsigned and Floating are the numeric constraints accepted by the
+// generic field constructors.
+type (
+	Signed interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64
+	}
+	Unsigned interface {
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+	}
+	Floating interface {
+		~float32 | ~float64
+	}
+)
+
+// KV returns a typed field for v. Scalars, durations and times are
+// captured without reflection or boxing; other values are kept as Any.
+func KV[T any](key string, v T) Field {
+	switch x := any(v).(type) {
+	case string:
+		return String(key, x)
+	case int:
+		return Int(key, x)
+	case int8:
+		return Int64(key, int64(x))
+	case int16:
+		return Int64(key, int64(x))
+	case int32:
+		return Int64(key, int64(x))
+	case int64:
+		return Int64(key, x)
+	case uint:
+		return UintKV(key, x)
+	case uint8:
+		return Int64(key, int64(x))
+	case uint16:
+		return Int64(key, int64(x))
+	case uint32:
+		return Int64(key, int64(x))
+	case uint64:
+		return UintKV(key, x)
+	case float32:
+		return Float(key, float64(x))
+	case float64:
+		return Float(key, x)
+	case bool:
+		return Bool(key, x)
+	case time.Duration:
+		return Duration(key, x)
+	case time.Time:
+		return Time(key, x)
+	default:
+		return Field{Key: key, Value: v}
+	}
+}
+
+// StringKV returns a string field for any string type, such as a named
+// enum type.
+func StringKV[T ~string](key string, v T) Field {
+	return String(key, string(v))
+}
+
+// IntKV returns an integer field for any signed integer type.
+func IntKV[T Signed](key string, v T) Field {
+	return Int64(key, int64(v))
+}
+
+// UintKV returns an integer field for unsigned values; values above
+// math.MaxInt64 are kept as Any so they are not truncated.
+func UintKV[T Unsigned](key string, v T) Field {
+	if uint64(v) > 1<<63-1 {
+		return Field{Key: key, Value: uint64(v)}
+	}
+	return Int64(key, int64(v))
+}
+
+// FloatKV returns a float field for any float type.
+func FloatKV[T Floating](key string, v T) Field {
+	return Float(key, float64(v))
+}
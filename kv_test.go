@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type kvState string
+
+func TestKV(t *testing.T) {
+	tests := []struct {
+		got, want Field
+	}{
+		{KV("s", "x"), String("s", "x")},
+		{KV("i", 7), Int("i", 7)},
+		{KV("i8", int8(-3)), Int64("i8", -3)},
+		{KV("i32", int32(9)), Int64("i32", 9)},
+		{KV("u8", uint8(200)), Int64("u8", 200)},
+		{KV("u64", uint64(5)), Int64("u64", 5)},
+		{KV("f32", float32(0.5)), Float("f32", 0.5)},
+		{KV("b", true), Bool("b", true)},
+		{KV("d", time.Second), Duration("d", time.Second)},
+		{KV("t", testTime), Time("t", testTime)},
+		{KV("state", kvState("open")), Field{Key: "state", Value: kvState("open")}},
+		{StringKV("state", kvState("open")), String("state", "open")},
+		{IntKV("d", 3*time.Millisecond), Int64("d", 3e6)},
+		{UintKV("u", uint16(8)), Int64("u", 8)},
+		{UintKV("max", uint64(math.MaxUint64)), Field{Key: "max", Value: uint64(math.MaxUint64)}},
+		{FloatKV("f", float32(1.5)), Float("f", 1.5)},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.want.Key, tt.got, tt.want)
+		}
+	}
+}
+
+func TestKVAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	var f Field
+	if n := testing.AllocsPerRun(100, func() { f = KV("n", 42) }); n != 0 {
+		t.Errorf("KV allocates %v times", n)
+	}
+	_ = f
+}
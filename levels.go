@@ -0,0 +1,250 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// levelRegistry holds the runtime minimum levels shared by a logger and the
+// named loggers derived from it. Every change bumps gen so that per-logger
+// caches can tell their memoized level is stale.
+type levelRegistry struct {
+	mu        sync.RWMutex
+	base      LogLevel
+	overrides map[string]LogLevel
+	// floor and quiet only apply while floorSet and quietSet are true, so
+	// custom levels below Debug are not clamped by default.
+	floor    LogLevel
+	floorSet bool
+	quiet    LogLevel
+	quietSet bool
+	gen      atomic.Uint64
+	// verbosity is the highest V level written, see CustomLogger.V.
+	verbosity atomic.Int32
+}
+
+func newLevelRegistry(base LogLevel) *levelRegistry {
+	r := &levelRegistry{
+		base:      base,
+		overrides: make(map[string]LogLevel),
+	}
+	r.gen.Store(1)
+	return r
+}
+
+// levelCache memoizes the effective level of one logger together with the
+// registry generation it was computed for, packed as gen<<16 | level, so the
+// level check on the hot path is a pair of atomic loads.
+type levelCache struct {
+	v atomic.Uint64
+}
+
+func (c *levelCache) get(r *levelRegistry, module string, pin *LogLevel) LogLevel {
+	gen := r.gen.Load()
+	if v := c.v.Load(); v>>16 == gen {
+		return LogLevel(int16(uint16(v)))
+	}
+	lvl := r.resolve(module, pin)
+	c.v.Store(gen<<16 | uint64(uint16(int16(lvl))))
+	return lvl
+}
+
+// level returns the minimum level for the named module. Modules inherit the
+// level of their closest dotted ancestor with an override, so a setting for
+// "app.http" applies to "app.http.client" unless that has one of its own.
+func (r *levelRegistry) level(module string) LogLevel {
+	return r.resolve(module, nil)
+}
+
+// resolve returns the minimum level for the named module, or pin instead
+// of the configured levels if set. The floor and quiet mode apply to both.
+func (r *levelRegistry) resolve(module string, pin *LogLevel) LogLevel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lvl := r.base
+	if pin != nil {
+		lvl, module = *pin, ""
+	}
+	for module != "" {
+		if o, ok := r.overrides[module]; ok {
+			lvl = o
+			break
+		}
+		i := strings.LastIndexByte(module, '.')
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	if r.floorSet && lvl < r.floor {
+		lvl = r.floor
+	}
+	if r.quietSet && lvl < r.quiet {
+		lvl = r.quiet
+	}
+	return lvl
+}
+
+// snapshot returns a copy of the explicit overrides.
+func (r *levelRegistry) snapshot() map[string]LogLevel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	levels := make(map[string]LogLevel, len(r.overrides))
+	for module, lvl := range r.overrides {
+		levels[module] = lvl
+	}
+	return levels
+}
+
+func (r *levelRegistry) setBase(level LogLevel) {
+	r.mu.Lock()
+	r.base = level
+	r.gen.Add(1)
+	r.mu.Unlock()
+}
+
+// setFloor sets a level below which nothing is logged regardless of the
+// configured levels, used to degrade logging temporarily.
+// A nil level removes the floor.
+func (r *levelRegistry) setFloor(level *LogLevel) {
+	r.mu.Lock()
+	r.floor, r.floorSet = 0, level != nil
+	if level != nil {
+		r.floor = *level
+	}
+	r.gen.Add(1)
+	r.mu.Unlock()
+}
+
+// setQuiet sets the quiet-mode level, which like the floor overrides the
+// configured levels but is controlled by the application.
+// A nil level ends quiet mode.
+func (r *levelRegistry) setQuiet(level *LogLevel) {
+	r.mu.Lock()
+	r.quiet, r.quietSet = 0, level != nil
+	if level != nil {
+		r.quiet = *level
+	}
+	r.gen.Add(1)
+	r.mu.Unlock()
+}
+
+func (r *levelRegistry) set(module string, level LogLevel) {
+	r.mu.Lock()
+	r.overrides[module] = level
+	r.gen.Add(1)
+	r.mu.Unlock()
+}
+
+func (r *levelRegistry) clear(module string) {
+	r.mu.Lock()
+	delete(r.overrides, module)
+	r.gen.Add(1)
+	r.mu.Unlock()
+}
+
+// joinName joins a parent and child logger name with a dot.
+func joinName(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// Named returns a child logger for the given module. The child shares the
+// output, processors and level settings of its parent; its module name is
+// the parent's module name joined with name by a dot.
+func (l *CustomLogger) Named(name string) *CustomLogger {
+	child := *l
+	child.name = joinName(l.name, name)
+	child.module = joinName(l.module, name)
+	child.levelCache = new(levelCache)
+	return &child
+}
+
+// Group returns a child logger that nests the fields of every call under
+// name, so subsystems sharing a logger cannot collide on keys. Fields added
+// by processors stay at the top level. Groups of groups nest further.
+func (l *CustomLogger) Group(name string) *CustomLogger {
+	child := *l
+	child.groups = append(l.groups[:len(l.groups):len(l.groups)], name)
+	return &child
+}
+
+// With returns a child logger that adds fields to every entry, before the
+// fields of the call. On a grouped logger the fields are nested in its
+// groups.
+func (l *CustomLogger) With(fields ...Field) *CustomLogger {
+	if len(fields) == 0 {
+		return l
+	}
+	if len(l.groups) > 0 {
+		fields = groupFields(l.groups, fields)
+	}
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	return &child
+}
+
+// Module returns the module name used for per-module level overrides.
+// The root logger has an empty module name.
+func (l *CustomLogger) Module() string {
+	return l.module
+}
+
+// SetLevel changes the minimum level for every module without an override.
+func (l *CustomLogger) SetLevel(level LogLevel) {
+	l.levels.setBase(level)
+}
+
+// SetModuleLevel overrides the minimum level for the named module and its
+// descendants, e.g. SetModuleLevel("db", Debug) while everything else stays
+// at Info.
+func (l *CustomLogger) SetModuleLevel(module string, level LogLevel) {
+	l.levels.set(module, level)
+}
+
+// ClearModuleLevel removes the override for the named module, which then
+// inherits from its ancestors again.
+func (l *CustomLogger) ClearModuleLevel(module string) {
+	l.levels.clear(module)
+}
+
+// EffectiveLevel returns the minimum level that applies to the named module
+// after inheritance.
+func (l *CustomLogger) EffectiveLevel(module string) LogLevel {
+	return l.levels.level(module)
+}
+
+// ModuleLevels returns the explicitly configured module overrides.
+func (l *CustomLogger) ModuleLevels() map[string]LogLevel {
+	return l.levels.snapshot()
+}
+
+// Level returns the minimum level currently in effect for this logger.
+func (l *CustomLogger) Level() LogLevel {
+	return l.levelCache.get(l.levels, l.module, l.levelPin)
+}
+
+// AtLevel returns a child logger with its own minimum level, unaffected by
+// the base and module levels, e.g. to trace a single request at Debug while
+// the service logs at Info. The disk guard floor and quiet mode still
+// apply. Children of the child keep the level.
+func (l *CustomLogger) AtLevel(level LogLevel) *CustomLogger {
+	child := *l
+	child.levelPin = &level
+	child.levelCache = new(levelCache)
+	return &child
+}
+
+func (l *CustomLogger) enabled(level LogLevel) bool {
+	return l.levelEnabled(level) && (l.traceGate == nil || l.traceAllows(nil, level))
+}
+
+// levelEnabled reports whether level passes the level settings alone.
+func (l *CustomLogger) levelEnabled(level LogLevel) bool {
+	return l.levelCache.get(l.levels, l.module, l.levelPin) <= level
+}
@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestModuleLevels(t *testing.T) {
+	r := newLevelRegistry(Info)
+	r.set("app.http", Debug)
+	r.set("app.http.client", Error)
+	r.set("db", Warn)
+
+	tests := []struct {
+		module string
+		want   LogLevel
+	}{
+		{"", Info},
+		{"app", Info},
+		{"app.http", Debug},
+		{"app.http.server", Debug},
+		{"app.http.client", Error},
+		{"app.http.client.pool", Error},
+		{"app.httpx", Info},
+		{"db.read", Warn},
+	}
+	for _, tt := range tests {
+		if got := r.level(tt.module); got != tt.want {
+			t.Errorf("level(%q) = %s, want %s", tt.module, got, tt.want)
+		}
+	}
+
+	r.clear("app.http.client")
+	if got := r.level("app.http.client"); got != Debug {
+		t.Errorf("after clear, level = %s, want DEBUG", got)
+	}
+}
+
+func TestFloorAndQuiet(t *testing.T) {
+	lvl := func(l LogLevel) *LogLevel { return &l }
+	tests := []struct {
+		name         string
+		base         LogLevel
+		floor, quiet *LogLevel
+		want         LogLevel
+	}{
+		{"none", Debug, nil, nil, Debug},
+		{"floor", Debug, lvl(Warn), nil, Warn},
+		{"floor below base", Error, lvl(Warn), nil, Error},
+		{"quiet", Info, nil, lvl(Error), Error},
+		{"quiet and floor", Debug, lvl(Warn), lvl(Critical), Critical},
+		{"floor at debug", LogLevel(-4), lvl(Debug), nil, Debug},
+		{"silent", Debug, nil, lvl(Silent), Silent},
+	}
+	for _, tt := range tests {
+		r := newLevelRegistry(tt.base)
+		r.setFloor(tt.floor)
+		r.setQuiet(tt.quiet)
+		if got := r.level("x"); got != tt.want {
+			t.Errorf("%s: level = %s, want %s", tt.name, got, tt.want)
+		}
+		r.setFloor(nil)
+		r.setQuiet(nil)
+		if got := r.level("x"); got != tt.base {
+			t.Errorf("%s: unset level = %s, want %s", tt.name, got, tt.base)
+		}
+	}
+}
+
+func TestLevelCache(t *testing.T) {
+	l := newTestLogger(t, Info, nil)
+	child := l.Named("db")
+	if child.enabled(Debug) {
+		t.Fatal("Debug enabled before override")
+	}
+	l.SetModuleLevel(child.Module(), Debug)
+	if !child.enabled(Debug) {
+		t.Error("override not seen through the cache")
+	}
+	l.SetQuiet(Silent)
+	if child.enabled(Emergency) || !l.Quiet() {
+		t.Error("quiet mode not applied")
+	}
+	l.ClearQuiet()
+	if !child.enabled(Debug) {
+		t.Error("quiet mode not cleared")
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const trace LogLevel = -100
+	// Levels are process-wide, so only the first run registers it.
+	if _, ok := lookupLevel(trace); !ok {
+		if err := RegisterLevel(trace, "TESTTRACE", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		level   LogLevel
+		name    string
+		wantErr bool
+	}{
+		{trace, "OTHER", true},
+		{Info, "INFO2", true},
+		{-101, "", true},
+		{math.MinInt16 - 1, "LOW", true},
+		{Silent, "SILENT", true},
+		{math.MaxInt16 + 5, "HIGH", true},
+	}
+	for _, tt := range tests {
+		if err := RegisterLevel(tt.level, tt.name, ""); (err != nil) != tt.wantErr {
+			t.Errorf("RegisterLevel(%d, %q) error = %v, want error %v", tt.level, tt.name, err, tt.wantErr)
+		}
+	}
+
+	if got, ok := ParseLevel("testtrace"); !ok || got != trace {
+		t.Errorf("ParseLevel(testtrace) = %s, %v", got, ok)
+	}
+	if got := trace.String(); got != "TESTTRACE" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, ok := ParseLevel("nope"); ok {
+		t.Error("ParseLevel accepted an unknown name")
+	}
+
+	r := newLevelRegistry(trace)
+	c := new(levelCache)
+	if got := c.get(r, "", nil); got != trace {
+		t.Errorf("cached level = %s, want %s", got, trace)
+	}
+}
+
+func TestAtLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	d := l.AtLevel(Debug).Named("db")
+	d.Debug("traced")
+	l.Debug("hidden")
+	l.SetLevel(Error)
+	d.Info("still traced")
+	if s := buf.String(); !strings.Contains(s, "traced") || !strings.Contains(s, "still traced") || strings.Contains(s, "hidden") {
+		t.Errorf("output = %q", s)
+	}
+	if d.Level() != Debug || l.Level() != Error {
+		t.Errorf("levels %v, %v", d.Level(), l.Level())
+	}
+}
+
+func TestAtLevelFloorAndQuiet(t *testing.T) {
+	l := newTestLogger(t, Info, nil)
+	d := l.AtLevel(Debug)
+	warn := Warn
+	l.levels.setFloor(&warn)
+	if d.enabled(Info) || !d.enabled(Warn) || d.Level() != Warn {
+		t.Errorf("pinned level %s ignores the disk guard floor", d.Level())
+	}
+	l.levels.setFloor(nil)
+	l.SetQuiet(Silent)
+	if d.enabled(Emergency) {
+		t.Error("pinned level ignores quiet mode")
+	}
+	l.ClearQuiet()
+	if !d.enabled(Debug) {
+		t.Error("pinned level not restored")
+	}
+}
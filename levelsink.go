@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// LevelSink passes the entries at or above a minimum level to a sink, so
+// the outputs of one logger can have thresholds of their own, e.g. the
+// console at Debug, a file at Info and Loki at Warn. The logger level is
+// checked first: an output cannot receive entries the logger drops.
+type LevelSink struct {
+	wrappedSink
+	level atomic.Int32
+}
+
+// NewLevelSink creates a LevelSink in front of sink.
+func NewLevelSink(sink Sink, level LogLevel) *LevelSink {
+	s := &LevelSink{wrappedSink: wrappedSink{sink: sink}}
+	s.level.Store(int32(level))
+	return s
+}
+
+// Level returns the minimum level written.
+func (s *LevelSink) Level() LogLevel {
+	return LogLevel(s.level.Load())
+}
+
+// SetLevel changes the minimum level at runtime.
+func (s *LevelSink) SetLevel(level LogLevel) {
+	s.level.Store(int32(level))
+}
+
+// WriteEntry implements Sink.
+func (s *LevelSink) WriteEntry(e *Entry) error {
+	if e.Level < s.Level() {
+		return nil
+	}
+	return s.write(e)
+}
+
+// WriteBlock implements BlockWriter, writing the entries at or above the
+// level as one block.
+func (s *LevelSink) WriteBlock(entries []*Entry) error {
+	min := s.Level()
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if e.Level >= min {
+			kept = append(kept, e)
+		}
+	}
+	return s.writeBlock(kept)
+}
+
+// wrappedSink forwards flushing, closing, stats and health to a sink that
+// a filter writes a subset of the entries to.
+type wrappedSink struct {
+	sink   Sink
+	health healthTracker
+}
+
+func (s *wrappedSink) write(e *Entry) error {
+	err := s.sink.WriteEntry(e)
+	s.health.record(time.Now(), err)
+	return err
+}
+
+func (s *wrappedSink) writeBlock(entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	err := writeBlock(s.sink, entries)
+	s.health.record(time.Now(), err)
+	return err
+}
+
+// Flush flushes the wrapped sink.
+func (s *wrappedSink) Flush() error {
+	return flushSink(s.sink)
+}
+
+// Close closes the wrapped sink, or flushes it if it cannot be closed.
+func (s *wrappedSink) Close() error {
+	if c, ok := s.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return flushSink(s.sink)
+}
+
+// Stats implements StatsReporter for the wrapped sink.
+func (s *wrappedSink) Stats() Stats {
+	if r, ok := s.sink.(StatsReporter); ok {
+		return r.Stats()
+	}
+	return Stats{}
+}
+
+// Health implements HealthReporter for the wrapped sink. Filtered entries
+// are not writes and leave it unchanged.
+func (s *wrappedSink) Health() Health {
+	if r, ok := s.sink.(HealthReporter); ok {
+		return r.Health()
+	}
+	return s.health.health(s.sink)
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevelSink(t *testing.T) {
+	file := &countSink{}
+	loki := &countSink{}
+	var buf strings.Builder
+	l := newTestLogger(t, Debug, &buf, WithSinks(NewLevelSink(file, Info), NewLevelSink(loki, Warn)))
+	l.Debug("d")
+	l.Info("i")
+	l.Warn("w")
+	l.Error("e")
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Errorf("console wrote %d lines, want 4", n)
+	}
+	if n := file.n.Load(); n != 3 {
+		t.Errorf("file got %d entries, want 3", n)
+	}
+	if n := loki.n.Load(); n != 2 {
+		t.Errorf("loki got %d entries, want 2", n)
+	}
+
+	s := l.sinks[2].(*LevelSink)
+	s.SetLevel(Error)
+	l.Warn("w")
+	if n := loki.n.Load(); n != 2 || s.Level() != Error {
+		t.Errorf("after SetLevel(Error): loki got %d entries", n)
+	}
+}
+
+func TestLevelSinkBlock(t *testing.T) {
+	dst := &countSink{keep: true}
+	s := NewLevelSink(dst, Warn)
+	err := s.WriteBlock([]*Entry{{Level: Info, Message: "a"}, {Level: Error, Message: "b"}, {Level: Debug}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.entries) != 1 || dst.entries[0].Message != "b" {
+		t.Errorf("block wrote %v", dst.entries)
+	}
+}
+
+func TestLevelSinkHealth(t *testing.T) {
+	s := NewLevelSink(failSink{}, Warn)
+	s.WriteEntry(&Entry{Level: Info})
+	if h := s.Health(); !h.Healthy() {
+		t.Errorf("filtered entry changed health: %+v", h)
+	}
+	if err := s.WriteEntry(&Entry{Level: Error}); err == nil {
+		t.Fatal("want the wrapped sink's error")
+	}
+	if h := s.Health(); h.ConsecutiveFailures != 1 || h.Sink != "logger.failSink" {
+		t.Errorf("health = %+v", h)
+	}
+
+	q := NewAsyncSink(&countSink{}, AsyncConfig{})
+	if err := NewLevelSink(q, Info).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.WriteEntry(&Entry{}); !errors.Is(err, ErrSinkClosed) {
+		t.Errorf("Close did not close the wrapped sink: %v", err)
+	}
+}
+
+func TestWithOutputLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	extra := &countSink{}
+	l, err := New(Debug, "app", path, WithOutputLevel(Warn), WithSinks(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("quiet")
+	l.Warn("loud")
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "quiet") || !strings.Contains(string(b), "loud") {
+		t.Errorf("file = %q", b)
+	}
+	if n := extra.n.Load(); n != 2 {
+		t.Errorf("extra sink got %d entries, want 2", n)
+	}
+}
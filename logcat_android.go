@@ -0,0 +1,131 @@
+//go:build android
+
+package logger
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultLogcatSocket is the socket logd reads log records from.
+	DefaultLogcatSocket = "/dev/socket/logdw"
+	// DefaultLogcatTag tags entries without a logger name.
+	DefaultLogcatTag = "GoLog"
+
+	// logcatMaxPayload is LOGGER_ENTRY_MAX_PAYLOAD: logd drops larger
+	// records, so messages are cut to fit.
+	logcatMaxPayload = 4068
+	logcatMain       = 0
+)
+
+// Android log priorities.
+const (
+	logcatDebug = 3 + iota
+	logcatInfo
+	logcatWarn
+	logcatError
+	logcatFatal
+)
+
+// LogcatConfig configures a LogcatSink.
+type LogcatConfig struct {
+	// Tag returns the tag of an entry; nil uses the logger name, or
+	// DefaultLogcatTag for entries without one. Tags longer than 23 bytes
+	// are cut by Android releases before 8.0.
+	Tag func(e *Entry) string
+	// Socket is the logd socket; empty selects DefaultLogcatSocket.
+	Socket string
+}
+
+// LogcatSink writes entries to the Android log, so gomobile apps show up
+// in logcat and Android Studio. It speaks logd's socket protocol, as
+// liblog does, and needs no cgo. Debug maps to the D priority, Info and
+// Notice to I, Warn to W, Error to E and Critical and above to F. The
+// message and fields are written; logcat adds the time and level.
+type LogcatSink struct {
+	cfg  LogcatConfig
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewLogcatSink connects to logd.
+func NewLogcatSink(cfg LogcatConfig) (*LogcatSink, error) {
+	if cfg.Tag == nil {
+		cfg.Tag = func(e *Entry) string {
+			if e.Name == "" {
+				return DefaultLogcatTag
+			}
+			return e.Name
+		}
+	}
+	if cfg.Socket == "" {
+		cfg.Socket = DefaultLogcatSocket
+	}
+	conn, err := net.Dial("unixgram", cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+	return &LogcatSink{cfg: cfg, conn: conn}, nil
+}
+
+// WriteEntry implements Sink.
+func (s *LogcatSink) WriteEntry(e *Entry) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	buf.Write(appendLogcatRecord(buf.AvailableBuffer(), s.cfg.Tag(e), e, syscall.Gettid(), time.Now()))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.Write(buf.Bytes())
+	return wrapWriteErr(err)
+}
+
+// appendLogcatRecord appends the record logd expects: a header of log
+// buffer, thread ID and time, then the priority, the tag and the message,
+// both NUL terminated.
+func appendLogcatRecord(b []byte, tag string, e *Entry, tid int, now time.Time) []byte {
+	b = append(b, logcatMain)
+	b = binary.LittleEndian.AppendUint16(b, uint16(tid))
+	b = binary.LittleEndian.AppendUint32(b, uint32(now.Unix()))
+	b = binary.LittleEndian.AppendUint32(b, uint32(now.Nanosecond()))
+	start := len(b)
+	b = append(b, logcatPriority(e.Level))
+	b = append(append(b, tag...), 0)
+
+	msg := GetBuffer()
+	defer PutBuffer(msg)
+	msg.WriteString(e.Message)
+	appendFields(msg, e.Fields)
+	text := msg.Bytes()
+	if room := logcatMaxPayload - (len(b) - start) - 1; len(text) > room {
+		if room < 0 {
+			room = 0
+		}
+		text = text[:room]
+	}
+	return append(append(b, text...), 0)
+}
+
+// logcatPriority maps a level to an Android log priority.
+func logcatPriority(level LogLevel) byte {
+	switch {
+	case level <= Debug:
+		return logcatDebug
+	case level < Warn:
+		return logcatInfo
+	case level < Error:
+		return logcatWarn
+	case level < Critical:
+		return logcatError
+	}
+	return logcatFatal
+}
+
+// Close closes the socket.
+func (s *LogcatSink) Close() error {
+	return s.conn.Close()
+}
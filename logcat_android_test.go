@@ -0,0 +1,58 @@
+//go:build android
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogcatSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logdw")
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s, err := NewLogcatSink(LogcatConfig{Socket: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.WriteEntry(&Entry{Level: Warn, Name: "db", Message: "slow", Fields: []Field{Int("ms", 250)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, 8192)
+	n, err := ln.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = b[:n]
+	if b[0] != logcatMain || b[11] != logcatWarn {
+		t.Errorf("header = %v", b[:12])
+	}
+	if want := "db\x00slow ms=250\x00"; string(b[12:]) != want {
+		t.Errorf("payload = %q, want %q", b[12:], want)
+	}
+}
+
+func TestLogcatRecordLimit(t *testing.T) {
+	now := time.Unix(1700000000, 5)
+	b := appendLogcatRecord(nil, "app", &Entry{Level: Error, Message: strings.Repeat("x", 10000)}, 42, now)
+	if len(b)-11 != logcatMaxPayload || b[len(b)-1] != 0 {
+		t.Errorf("payload is %d bytes", len(b)-11)
+	}
+	if binary.LittleEndian.Uint16(b[1:]) != 42 || binary.LittleEndian.Uint32(b[3:]) != 1700000000 {
+		t.Errorf("header = %v", b[:11])
+	}
+	if !bytes.HasPrefix(b[11:], []byte{logcatError, 'a', 'p', 'p', 0}) {
+		t.Errorf("tag = %q", b[11:16])
+	}
+}
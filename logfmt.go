@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DecodeLogfmtErrFmt = "Invalid logfmt log entry: %w"
+
+var errNoPairs = errors.New("not a key=value list")
+
+// LogfmtEncoder writes entries as logfmt lines:
+//
+//	time=2024-03-01T12:30:45Z level=info logger=app msg="disk low" free=3
+//
+// Levels are lower case, group members are written as "group.key" and
+// values are quoted when they contain spaces, quotes or equals signs.
+type LogfmtEncoder struct {
+	// Order is the order of the fields, insertion order by default.
+	Order FieldOrder
+}
+
+// Encode implements Encoder.
+func (l LogfmtEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	buf.WriteString("time=")
+	buf.Write(e.Time.AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
+	buf.WriteString(" level=")
+	appendTextString(buf, strings.ToLower(e.Level.String()))
+	if e.Name != "" {
+		buf.WriteString(" logger=")
+		appendTextString(buf, e.Name)
+	}
+	buf.WriteString(" msg=")
+	if e.Message == "" {
+		buf.WriteString(`""`)
+	} else {
+		appendTextString(buf, e.Message)
+	}
+	if l.Order == SortedOrder {
+		appendFields(buf, sortedFields(e.Fields))
+	} else {
+		appendFields(buf, e.Fields)
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// DecodeLogfmt parses a line written by LogfmtEncoder back into an entry.
+// The keys time, level, logger and msg fill the entry; the other pairs
+// become fields in line order, with dotted keys kept flat. Values that
+// read as integers, floats or booleans are typed accordingly.
+func DecodeLogfmt(line []byte) (*Entry, error) {
+	pairs, ok := parsePairs(string(bytes.TrimRight(line, "\r\n")))
+	if !ok {
+		return nil, fmt.Errorf(DecodeLogfmtErrFmt, errNoPairs)
+	}
+	e := new(Entry)
+	var hasMsg bool
+	for _, p := range pairs {
+		switch p.key {
+		case "time", "ts":
+			t, err := time.Parse(time.RFC3339Nano, p.val)
+			if err != nil {
+				return nil, fmt.Errorf(DecodeLogfmtErrFmt, err)
+			}
+			e.Time = t
+		case "level":
+			lvl, ok := ParseLevel(p.val)
+			if !ok {
+				return nil, fmt.Errorf(DecodeLogfmtErrFmt, fmt.Errorf("unknown level %q", p.val))
+			}
+			e.Level = lvl
+		case "logger":
+			e.Name = p.val
+		case "msg":
+			e.Message, hasMsg = p.val, true
+		default:
+			e.Fields = append(e.Fields, p.field())
+		}
+	}
+	if !hasMsg {
+		return nil, fmt.Errorf(DecodeLogfmtErrFmt, errNoMessage)
+	}
+	return e, nil
+}
+
+// textPair is a key=value pair read back from text or logfmt output.
+type textPair struct {
+	key, val string
+	quoted   bool
+}
+
+// field types the value like the encoders wrote it; quoted values stay
+// strings.
+func (p textPair) field() Field {
+	if !p.quoted {
+		if n, err := strconv.ParseInt(p.val, 10, 64); err == nil {
+			return Int64(p.key, n)
+		}
+		if f, err := strconv.ParseFloat(p.val, 64); err == nil {
+			return Float(p.key, f)
+		}
+		if b, err := strconv.ParseBool(p.val); err == nil && (p.val == "true" || p.val == "false") {
+			return Bool(p.key, b)
+		}
+	}
+	return String(p.key, p.val)
+}
+
+// parsePairs reads s as a list of key=value pairs separated by spaces,
+// values optionally Go quoted, and reports whether all of s was one.
+func parsePairs(s string) ([]textPair, bool) {
+	var pairs []textPair
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return pairs, len(pairs) > 0
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \"") {
+			return nil, false
+		}
+		p := textPair{key: s[:eq]}
+		s = s[eq+1:]
+		if strings.HasPrefix(s, `"`) {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			p.val, _ = strconv.Unquote(q)
+			p.quoted = true
+			s = s[len(q):]
+			if s != "" && s[0] != ' ' {
+				return nil, false
+			}
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			p.val = s[:end]
+			if strings.ContainsAny(p.val, "=\"") {
+				return nil, false
+			}
+			s = s[end:]
+		}
+		pairs = append(pairs, p)
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	e := &Entry{
+		Time: testTime, Level: Warn, Name: "app", Message: "disk low",
+		Fields: []Field{String("path", "/var log"), Int("free", 3), Group("http", Int("status", 200))},
+	}
+	var buf bytes.Buffer
+	(LogfmtEncoder{}).Encode(&buf, e)
+	want := `time=2024-03-01T12:30:45Z level=warn logger=app msg="disk low" path="/var log" free=3 http.status=200` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+
+	out, err := DecodeLogfmt(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	(LogfmtEncoder{}).Encode(&again, out)
+	if again.String() != want {
+		t.Errorf("round trip %s", again.String())
+	}
+	if f := out.Fields[1]; f.Interface() != int64(3) {
+		t.Errorf("free = %#v", f)
+	}
+	for _, bad := range []string{"just text", `msg="x`, "level=info", "time=x msg=y", `a=b"c msg=x`} {
+		if _, err := DecodeLogfmt([]byte(bad)); err == nil {
+			t.Errorf("DecodeLogfmt(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		line   string
+		name   string
+		level  LogLevel
+		msg    string
+		fields string
+	}{
+		{"app WARN : 2024/03/01 12:30:45 disk low path=/var free=3", "app", Warn, "disk low", " path=/var free=3"},
+		{"app.db CRIT : 2024/03/01 12:30:45 a=b is not a pair here x", "app.db", Critical, "a=b is not a pair here x", ""},
+		{" NOTICE: 2024/03/01 12:30:45 quoted q=\"a b\" ok=true", "", Notice, "quoted", ` q="a b" ok=true`},
+		{"app\x1b[31m ERROR: \x1b[0m2024/03/01 12:30:45 colored", "app", Error, "colored", ""},
+		{"app INFO : 2024/03/01 12:30:45  only=fields", "app", Info, "", " only=fields"},
+	}
+	for _, tt := range tests {
+		e, err := DecodeText([]byte(tt.line), time.UTC)
+		if err != nil {
+			t.Errorf("DecodeText(%q): %v", tt.line, err)
+			continue
+		}
+		var fields bytes.Buffer
+		appendFields(&fields, e.Fields)
+		if e.Name != tt.name || e.Level != tt.level || e.Message != tt.msg || fields.String() != tt.fields || !e.Time.Equal(testTime) {
+			t.Errorf("DecodeText(%q) = %q %s %q %q", tt.line, e.Name, e.Level, e.Message, fields.String())
+		}
+	}
+	if _, err := DecodeText([]byte("goroutine 1 [running]:"), time.UTC); err == nil {
+		t.Error("continuation line decoded")
+	}
+}
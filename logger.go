@@ -22,93 +22,490 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	OpenLogErrFmt = "Failed to open log file: %s"
 
-	DebugPrefix = " DEBUG: "
-	InfoPrefix  = " INFO : "
-	WarnPrefix  = " WARN : "
-	ErrorPrefix = " ERROR: "
+	DebugPrefix     = " DEBUG: "
+	InfoPrefix      = " INFO : "
+	NoticePrefix    = " NOTICE: "
+	WarnPrefix      = " WARN : "
+	ErrorPrefix     = " ERROR: "
+	CriticalPrefix  = " CRIT : "
+	AlertPrefix     = " ALERT: "
+	EmergencyPrefix = " EMERG: "
 
-	FileModeRW = 0666
+	FileModeRW  = 0666
+	DirModeRWX  = 0755
+	MkdirErrFmt = "Failed to create log directory: %s"
+
+	LevelParseErrFmt = "Unknown log level %q"
 )
 
 type LogLevel int
 
+// The levels follow the syslog severities in ascending order of urgency.
+//
+// Adding Notice renumbered the original levels: Warn was 2 and Error 3.
+// Numbers stored by older versions must be converted with LegacyLevel
+// before they are passed to New or SetLevel; encode levels by name, as
+// MarshalText does, to be independent of the numbering.
 const (
 	Debug LogLevel = iota
 	Info
+	Notice
 	Warn
 	Error
+	Critical
+	Alert
+	Emergency
 )
 
+// String returns the upper-case level name, e.g. "INFO".
+func (l LogLevel) String() string {
+	if s, ok := builtinLevelName(l); ok {
+		return s
+	}
+	if info, ok := lookupLevel(l); ok {
+		return info.name
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// MarshalText encodes the level by name, so JSON output stays meaningful
+// if levels are renumbered.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// legacyLevels maps the numbers of the original four levels.
+var legacyLevels = []LogLevel{Debug, Info, Warn, Error}
+
+// LegacyLevel converts a level number from before Notice was added, 0 to
+// 3 for Debug, Info, Warn and Error. Other numbers are returned unchanged.
+func LegacyLevel(n int) LogLevel {
+	if n >= 0 && n < len(legacyLevels) {
+		return legacyLevels[n]
+	}
+	return LogLevel(n)
+}
+
+// UnmarshalText parses a level name as returned by String. Numbers in
+// "LEVEL(n)" are read like UnmarshalJSON reads them, see LegacyLevel.
+func (l *LogLevel) UnmarshalText(b []byte) error {
+	s := string(b)
+	if lvl, ok := ParseLevel(s); ok {
+		*l = lvl
+		return nil
+	}
+	if n, ok := strings.CutPrefix(s, "LEVEL("); ok {
+		if v, err := strconv.Atoi(strings.TrimSuffix(n, ")")); err == nil {
+			*l = LegacyLevel(v)
+			return nil
+		}
+	}
+	return fmt.Errorf(LevelParseErrFmt, s)
+}
+
+// UnmarshalJSON accepts a level name or, for entries written when levels
+// were encoded as numbers, a number converted with LegacyLevel. The levels
+// added later, such as Notice, can only be given by name in JSON.
+func (l *LogLevel) UnmarshalJSON(b []byte) error {
+	if n, err := strconv.Atoi(string(b)); err == nil {
+		*l = LegacyLevel(n)
+		return nil
+	}
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return fmt.Errorf(LevelParseErrFmt, b)
+	}
+	return l.UnmarshalText([]byte(s))
+}
+
+// builtinLevels lists the predefined levels in ascending severity.
+var builtinLevels = []LogLevel{Debug, Info, Notice, Warn, Error, Critical, Alert, Emergency}
+
+func builtinLevelName(l LogLevel) (string, bool) {
+	switch l {
+	case Debug:
+		return "DEBUG", true
+	case Info:
+		return "INFO", true
+	case Notice:
+		return "NOTICE", true
+	case Warn:
+		return "WARN", true
+	case Error:
+		return "ERROR", true
+	case Critical:
+		return "CRITICAL", true
+	case Alert:
+		return "ALERT", true
+	case Emergency:
+		return "EMERGENCY", true
+	}
+	return "", false
+}
+
 // Logger defines the interface for logging
 type Logger interface {
 	Debug(v ...interface{})
 	Info(v ...interface{})
+	Notice(v ...interface{})
 	Warn(v ...interface{})
 	Error(format string, v ...interface{})
+	Critical(format string, v ...interface{})
+	Alert(format string, v ...interface{})
+	Emergency(format string, v ...interface{})
 	Fatalf(format string, v ...interface{})
 }
 
 // CustomLogger implements the Logger interface
 type CustomLogger struct {
-	logger   *log.Logger
-	logLevel LogLevel
-	name     string
+	sinks        []Sink
+	health       []*healthTracker
+	levels       *levelRegistry
+	name         string
+	module       string
+	groups       []string
+	fields       []Field
+	processors   []Processor
+	extractors   []ContextExtractor
+	errorHandler ErrorHandler
+	clock        Clock
+	placeholders map[string]string
+	keyPolicy    KeyPolicy
+	counters     *counters
+	sites        *callSites
+	levelCache   *levelCache
+	levelPin     *LogLevel
+	traceGate    *TraceSamplingConfig
+	signalCfg    *SignalConfig
+	signals      *signalHandler
+	trace        traceState
+	async        *AsyncConfig
+	buffering    *BufferConfig
+	recorder     *RecorderConfig
+	fileConfig   FileConfig
+	text         TextEncoder
+	encoder      Encoder
+	outputLevel  *LogLevel
+	outputFilter Matcher
+	outputs      []Output
+	routes       []Route
+	schemas      []schemaRule
+	file         *File
+	backend      io.WriteCloser
+	buffered     *BufferedWriter
+	closed       *atomic.Bool
+	chain        *ChainConfig
+	diskGuardCfg *DiskGuardConfig
+	diskGuard    *diskGuard
+	sampler      *sampler
+	auditCfg     *AuditConfig
+	audit        *channel
+	eventCfg     *EventConfig
+	events       *channel
 }
 
 // New creates a new CustomLogger. If the file path is provided, it attempts to use it as the log output.
-func New(logLevel LogLevel, name, filePath string) (*CustomLogger, error) {
-	var output *os.File
+// The path may contain {host} and {pid} placeholders, see OpenFile.
+func New(logLevel LogLevel, name, filePath string, opts ...Option) (*CustomLogger, error) {
+	l := &CustomLogger{
+		levels:       newLevelRegistry(logLevel),
+		levelCache:   new(levelCache),
+		name:         name,
+		errorHandler: DefaultErrorHandler,
+		clock:        time.Now,
+		counters:     new(counters),
+		sites:        new(callSites),
+		closed:       new(atomic.Bool),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.counters.start = l.clock()
+	handler := l.errorHandler
+	l.errorHandler = func(err error) {
+		l.counters.recordError(l.clock(), err)
+		handler(err)
+	}
+
+	var output io.Writer
 	var err error
 
-	if filePath != "" {
-		output, err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, FileModeRW)
+	if l.backend != nil {
+		if filePath != "" {
+			return nil, ErrWriterWithPath
+		}
+		output = l.backend
+	} else if filePath != "" {
+		if l.fileConfig.ErrorHandler == nil {
+			l.fileConfig.ErrorHandler = l.errorHandler
+		}
+		if h := l.fileConfig.Header; h != nil {
+			if l.chain != nil {
+				return nil, ErrHeaderWithChain
+			}
+			if h.Encoder == nil {
+				cfg := *h
+				cfg.Encoder = l.outputEncoder()
+				l.fileConfig.Header = &cfg
+			}
+		}
+		var f *File
+		f, err = OpenFile(filePath, l.fileConfig)
 		if err != nil {
-			return nil, fmt.Errorf(OpenLogErrFmt, err)
+			return nil, err
 		}
+		output = f
+		l.file = f
 	} else {
 		output = os.Stdout
 	}
 
-	return &CustomLogger{
-		logger:   log.New(output, "", log.Ldate|log.Ltime),
-		logLevel: logLevel,
-		name:     name,
-	}, nil
+	w := output
+	if l.buffering != nil {
+		l.buffered = NewBufferedWriter(output, *l.buffering)
+		w = l.buffered
+	}
+	if l.chain != nil {
+		cfg := *l.chain
+		if l.file != nil && cfg.PrevHash == nil {
+			cfg.PrevHash, cfg.PrevSeq, err = resumeFileChain(l.file)
+			if err != nil {
+				if l.buffered != nil {
+					l.buffered.Close()
+				}
+				l.file.Close()
+				return nil, fmt.Errorf(OpenLogErrFmt, err)
+			}
+		}
+		w = NewHashChainWriter(w, cfg)
+	}
+	var out Sink = l.outputSink(w)
+	if l.outputLevel != nil {
+		out = NewLevelSink(out, *l.outputLevel)
+	}
+	if l.outputFilter != nil {
+		out = NewFilterSink(out, l.outputFilter)
+	}
+	l.sinks = append([]Sink{out}, l.sinks...)
+	for _, o := range l.outputs {
+		s, err := l.openOutput(o)
+		if err != nil {
+			l.closeAll()
+			return nil, err
+		}
+		l.sinks = append(l.sinks, s)
+	}
+	if len(l.routes) > 0 {
+		out, routed, err := l.openRoutes(l.sinks[0])
+		if err != nil {
+			l.closeAll()
+			return nil, err
+		}
+		l.sinks[0] = out
+		l.sinks = append(l.sinks, routed...)
+	}
+
+	if l.recorder != nil {
+		for i, s := range l.sinks {
+			l.sinks[i] = NewFlightRecorder(s, *l.recorder)
+		}
+	}
+
+	if l.async != nil {
+		cfg := *l.async
+		if cfg.ErrorHandler == nil {
+			cfg.ErrorHandler = l.errorHandler
+		}
+		for i, s := range l.sinks {
+			l.sinks[i] = NewAsyncSink(s, cfg)
+		}
+	}
+
+	l.health = make([]*healthTracker, len(l.sinks))
+	for i := range l.health {
+		l.health[i] = new(healthTracker)
+	}
+
+	if l.file != nil && l.diskGuardCfg != nil {
+		l.diskGuard = startDiskGuard(l, l.file, *l.diskGuardCfg)
+	}
+
+	if l.auditCfg != nil {
+		cfg := *l.auditCfg
+		if l.audit, err = l.openChannel(cfg.Path, cfg.File, cfg.Encoder, cfg.Sinks); err != nil {
+			l.Close(context.Background())
+			return nil, err
+		}
+	}
+	if l.eventCfg != nil {
+		cfg := *l.eventCfg
+		if l.events, err = l.openChannel(cfg.Path, cfg.File, cfg.Encoder, cfg.Sinks); err != nil {
+			l.Close(context.Background())
+			return nil, err
+		}
+	}
+	if l.signalCfg != nil {
+		l.signals = startSignalHandler(l, *l.signalCfg)
+	}
+
+	return l, nil
+}
+
+// outputSink returns the sink of the default output, counting the bytes
+// written in Stats.
+func (l *CustomLogger) outputSink(w io.Writer) *WriterSink {
+	s := NewWriterSink(w, l.outputEncoder())
+	s.bytes = &l.counters.bytes
+	return s
+}
+
+// outputEncoder returns the encoder of the default output.
+func (l *CustomLogger) outputEncoder() Encoder {
+	if l.encoder != nil {
+		return l.encoder
+	}
+	return l.text
+}
+
+// log runs the processors over a pooled entry and writes it to every sink.
+// Sinks that keep the entry beyond WriteEntry must Clone it.
+func (l *CustomLogger) log(level LogLevel, msg string, fields ...Field) {
+	if l.closed.Load() {
+		l.counters.closed.Add(1)
+		return
+	}
+	if l.sampler != nil && !l.sampler.allow(level, l.clock()) {
+		l.counters.suppressed.Add(1)
+		return
+	}
+	var violation string
+	if l.schemas != nil {
+		var ok bool
+		if violation, ok = l.validate(msg, fields); !ok {
+			return
+		}
+	}
+	e := l.entry(level, msg, fields)
+	if violation != "" {
+		e.AddFields(String(SchemaViolationKey, violation))
+	}
+	l.write(e)
+	putEntry(e)
+}
+
+// entry returns a pooled entry with the processors applied and LogValuer
+// fields resolved.
+func (l *CustomLogger) entry(level LogLevel, msg string, fields []Field) *Entry {
+	e := getEntry()
+	e.Time = l.clock()
+	e.Level = level
+	e.Name = l.name
+	e.Message = strings.TrimSuffix(msg, "\n")
+	e.Fields = append(e.Fields, l.fields...)
+	if len(l.groups) > 0 && len(fields) > 0 {
+		e.Fields = append(e.Fields, groupFields(l.groups, fields)...)
+	} else {
+		e.Fields = append(e.Fields, fields...)
+	}
+
+	for _, p := range l.processors {
+		p.Process(e)
+	}
+	resolveFields(e.Fields)
+	if l.keyPolicy != KeepKeys {
+		l.applyKeyPolicy(e)
+	}
+	if l.placeholders != nil {
+		l.applyPlaceholders(e)
+	}
+	return e
+}
+
+// write hands e to every sink, recording the outcome. A sink closed by a
+// concurrent Close discards the entry like a call after Close would.
+func (l *CustomLogger) write(e *Entry) {
+	l.counters.countLevel(e.Level)
+	for i, s := range l.sinks {
+		err := s.WriteEntry(e)
+		if err == ErrSinkClosed {
+			l.counters.closed.Add(1)
+			continue
+		}
+		l.health[i].record(e.Time, err)
+		if err != nil {
+			l.counters.failed.Add(1)
+			l.errorHandler(err)
+		}
+	}
 }
 
 func (l *CustomLogger) Debug(v ...interface{}) {
-	if l.logLevel <= Debug {
-		l.logger.SetPrefix(l.name + DebugPrefix)
-		l.logger.Println(v...)
+	if l.enabled(Debug) {
+		l.log(Debug, fmt.Sprintln(v...))
 	}
 }
 
 func (l *CustomLogger) Info(v ...interface{}) {
-	if l.logLevel <= Info {
-		l.logger.SetPrefix(l.name + InfoPrefix)
-		l.logger.Println(v...)
+	if l.enabled(Info) {
+		l.log(Info, fmt.Sprintln(v...))
+	}
+}
+
+func (l *CustomLogger) Notice(v ...interface{}) {
+	if l.enabled(Notice) {
+		l.log(Notice, fmt.Sprintln(v...))
 	}
 }
 
 func (l *CustomLogger) Warn(v ...interface{}) {
-	if l.logLevel <= Warn {
-		l.logger.SetPrefix(l.name + WarnPrefix)
-		l.logger.Println(v...)
+	if l.enabled(Warn) {
+		l.log(Warn, fmt.Sprintln(v...))
 	}
 }
 
 func (l *CustomLogger) Error(format string, v ...interface{}) {
-	if l.logLevel <= Error {
-		l.logger.SetPrefix(l.name + ErrorPrefix)
-		l.logger.Printf(format, v...)
+	if l.enabled(Error) {
+		l.log(Error, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *CustomLogger) Critical(format string, v ...interface{}) {
+	if l.enabled(Critical) {
+		l.log(Critical, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *CustomLogger) Alert(format string, v ...interface{}) {
+	if l.enabled(Alert) {
+		l.log(Alert, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *CustomLogger) Emergency(format string, v ...interface{}) {
+	if l.enabled(Emergency) {
+		l.log(Emergency, fmt.Sprintf(format, v...))
+	}
+}
+
+// Log writes msg with fields at the given level, e.g.
+//
+//	log.Log(logger.Info, "request served", logger.String("path", p), logger.Int("status", 200))
+func (l *CustomLogger) Log(level LogLevel, msg string, fields ...Field) {
+	if l.enabled(level) {
+		l.log(level, msg, fields...)
 	}
 }
 
@@ -128,5 +525,4 @@ var _ Logger = (*CustomLogger)(nil)
 	Note:
 
 	Add write to log file in fatal
-	Add logger file rotation
 */
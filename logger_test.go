@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+
+// newTestLogger returns a logger writing text to w instead of stdout.
+func newTestLogger(t testing.TB, level LogLevel, w io.Writer, opts ...Option) *CustomLogger {
+	t.Helper()
+	opts = append([]Option{WithClock(func() time.Time { return testTime })}, opts...)
+	l, err := New(level, "test", "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.sinks[0] = l.outputSink(w)
+	return l
+}
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level LogLevel
+		log   func(l *CustomLogger)
+		want  bool
+	}{
+		{Info, func(l *CustomLogger) { l.Debug("x") }, false},
+		{Info, func(l *CustomLogger) { l.Info("x") }, true},
+		{Warn, func(l *CustomLogger) { l.Notice("x") }, false},
+		{Warn, func(l *CustomLogger) { l.Warn("x") }, true},
+		{Error, func(l *CustomLogger) { l.Error("%s", "x") }, true},
+		{Emergency, func(l *CustomLogger) { l.Alert("%s", "x") }, false},
+		{Emergency, func(l *CustomLogger) { l.Emergency("%s", "x") }, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		tt.log(newTestLogger(t, tt.level, &buf))
+		if got := buf.Len() > 0; got != tt.want {
+			t.Errorf("level %s: wrote %v, want %v (%q)", tt.level, got, tt.want, buf.String())
+		}
+	}
+}
+
+func TestLoggerText(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf)
+	l.Log(Warn, "disk low", String("path", "/var"), Int("free", 3))
+	l.Group("http").Log(Info, "served", Int("status", 200))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{"disk low path=/var free=3", "served http.status=200"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "test") || !strings.HasSuffix(line, want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want[i])
+		}
+	}
+}
+
+func TestLoggerAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	l := newTestLogger(t, Info, io.Discard)
+	tests := []struct {
+		name string
+		max  float64
+		log  func()
+	}{
+		{"disabled", 0, func() { l.Debug("hidden") }},
+		{"disabled fields", 0, func() { l.Log(Debug, "hidden", Int("n", 1)) }},
+		{"plain", 1, func() { l.Info("hello") }},
+		{"log", 1, func() { l.Log(Info, "hello") }},
+		{"builder disabled", 0, func() { l.DebugE().Str("s", "v").Int("n", 1).Msg("hidden") }},
+		{"builder", 1, func() { l.InfoE().Str("s", "v").Int("n", 1).Msg("hello") }},
+	}
+	for _, tt := range tests {
+		if n := testing.AllocsPerRun(100, tt.log); n > tt.max {
+			t.Errorf("%s: %v allocs, want at most %v", tt.name, n, tt.max)
+		}
+	}
+}
+
+func TestLegacyLevel(t *testing.T) {
+	tests := []struct {
+		n    int
+		want LogLevel
+	}{
+		{0, Debug}, {1, Info}, {2, Warn}, {3, Error}, {4, LogLevel(4)}, {-1, LogLevel(-1)},
+	}
+	for _, tt := range tests {
+		if got := LegacyLevel(tt.n); got != tt.want {
+			t.Errorf("LegacyLevel(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLevelUnmarshal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LogLevel
+		wantErr bool
+	}{
+		{`"INFO"`, Info, false},
+		{`"notice"`, Notice, false},
+		{`2`, Warn, false},
+		{`"LEVEL(3)"`, Error, false},
+		{`"LEVEL(7)"`, LogLevel(7), false},
+		{`"verbose"`, 0, true},
+		{`true`, 0, true},
+	}
+	for _, tt := range tests {
+		var got LogLevel
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLevelTextRoundTrip(t *testing.T) {
+	for _, lvl := range builtinLevels {
+		b, err := lvl.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got LogLevel
+		if err := got.UnmarshalText(b); err != nil || got != lvl {
+			t.Errorf("round trip of %s = %s, %v", lvl, got, err)
+		}
+	}
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	l := newTestLogger(b, Info, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug("hidden")
+	}
+}
+
+func BenchmarkPlain(b *testing.B) {
+	l := newTestLogger(b, Info, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello")
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	l := newTestLogger(b, Info, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Log(Info, "hello")
+	}
+}
+
+func BenchmarkFields(b *testing.B) {
+	l := newTestLogger(b, Info, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Log(Info, "served", String("path", "/"), Int("status", 200), Bool("cached", true))
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	l := newTestLogger(b, Info, io.Discard)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Log(Info, "hello", Int("n", 1))
+		}
+	})
+}
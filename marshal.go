@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxFieldDepth bounds the nesting of structs, maps and slices expanded
+	// from a field value; deeper values are rendered with fmt.
+	MaxFieldDepth = 5
+	// MaxFieldElements bounds the entries kept per map, slice or struct.
+	MaxFieldElements = 100
+	// TruncatedKey records how many entries were left out of a map or
+	// struct; slices end with a TruncatedFmt element instead.
+	TruncatedKey = "_truncated"
+	TruncatedFmt = "...%d more"
+)
+
+// jsonMarshaler is json.Marshaler, declared here so that the
+// logger_minimal build leaves encoding/json out.
+type jsonMarshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*jsonMarshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	logValuerType     = reflect.TypeOf((*LogValuer)(nil)).Elem()
+)
+
+// isComposite reports whether v is a struct, map or slice that
+// expandValue turns into nested maps and slices.
+func isComposite(v interface{}) bool {
+	switch v.(type) {
+	case nil, []byte, time.Time, error, fmt.Stringer, jsonMarshaler, encoding.TextMarshaler, LogValuer:
+		return false
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// expandValue converts structs, maps and slices into map[string]interface{}
+// and []interface{} trees within MaxFieldDepth and MaxFieldElements.
+// Struct fields follow their json tags. Other values are returned as is,
+// with LogValuers resolved and errors replaced by their message.
+func expandValue(v interface{}) interface{} {
+	return expand(reflect.ValueOf(v), 0)
+}
+
+func expand(rv reflect.Value, depth int) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Pointer && selfDescribing(rv.Type()) {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.CanInterface() {
+		switch x := rv.Interface().(type) {
+		case LogValuer:
+			return expand(reflect.ValueOf(resolveLogValue(x)), depth)
+		case error:
+			return x.Error()
+		case time.Time, []byte, jsonMarshaler:
+			return x
+		case encoding.TextMarshaler:
+			if b, err := x.MarshalText(); err == nil {
+				return string(b)
+			}
+		case fmt.Stringer:
+			if k := rv.Kind(); k == reflect.Struct || k == reflect.Map || k == reflect.Slice {
+				return x.String()
+			}
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if depth >= MaxFieldDepth {
+			return fmt.Sprint(rv.Interface())
+		}
+	default:
+		return rv.Interface()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return expandStruct(rv, depth)
+	case reflect.Map:
+		return expandMap(rv, depth)
+	default:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		n := rv.Len()
+		out := make([]interface{}, 0, min(n, MaxFieldElements+1))
+		for i := 0; i < n && i < MaxFieldElements; i++ {
+			out = append(out, expand(rv.Index(i), depth+1))
+		}
+		if n > MaxFieldElements {
+			out = append(out, fmt.Sprintf(TruncatedFmt, n-MaxFieldElements))
+		}
+		return out
+	}
+}
+
+// selfDescribing reports whether t renders itself, so a pointer of type t
+// must not be dereferenced before its methods are used.
+func selfDescribing(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		t.Implements(errorType) || t.Implements(logValuerType)
+}
+
+func expandStruct(rv reflect.Value, depth int) map[string]interface{} {
+	t := rv.Type()
+	out := make(map[string]interface{}, t.NumField())
+	skipped := 0
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			tagName, opts, _ := strings.Cut(tag, ",")
+			if tagName != "" {
+				name = tagName
+			}
+			omitEmpty = strings.Contains(opts, "omitempty")
+		}
+		fv := rv.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if len(out) >= MaxFieldElements {
+			skipped++
+			continue
+		}
+		out[name] = expand(fv, depth+1)
+	}
+	if skipped > 0 {
+		out[TruncatedKey] = skipped
+	}
+	return out
+}
+
+func expandMap(rv reflect.Value, depth int) map[string]interface{} {
+	if rv.IsNil() {
+		return nil
+	}
+	keys := make([]string, 0, rv.Len())
+	values := make(map[string]reflect.Value, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k := fmt.Sprint(iter.Key().Interface())
+		keys = append(keys, k)
+		values[k] = iter.Value()
+	}
+	// Sort so that truncation keeps the same entries every time.
+	sort.Strings(keys)
+
+	out := make(map[string]interface{}, min(len(keys), MaxFieldElements+1))
+	for i, k := range keys {
+		if i == MaxFieldElements {
+			out[TruncatedKey] = len(keys) - MaxFieldElements
+			break
+		}
+		out[k] = expand(values[k], depth+1)
+	}
+	return out
+}
+
+// appendFlattened writes an expanded value as " key.path=value" pairs,
+// with slice elements keyed by index and map keys in sorted order.
+func appendFlattened(buf *bytes.Buffer, key string, v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			appendFlattened(buf, key+"."+k, x[k])
+		}
+	case []interface{}:
+		for i, el := range x {
+			appendFlattened(buf, key+"."+strconv.Itoa(i), el)
+		}
+	default:
+		buf.WriteByte(' ')
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		appendTextValue(buf, v)
+	}
+}
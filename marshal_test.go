@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type marshalAddr struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type marshalUser struct {
+	Name     string       `json:"name"`
+	Password string       `json:"-"`
+	Addr     *marshalAddr `json:"addr"`
+	Tags     []string
+	Err      error `json:"err"`
+	internal int
+}
+
+type marshalSecret struct{ value string }
+
+func (*marshalSecret) LogValue() interface{} { return "hidden" }
+
+type marshalNode struct {
+	Next *marshalNode `json:"next"`
+}
+
+func TestExpandValue(t *testing.T) {
+	u := marshalUser{Name: "ann", Password: "pw", Addr: &marshalAddr{City: "Oslo"}, Tags: []string{"a"}, Err: errors.New("locked"), internal: 1}
+	want := map[string]interface{}{
+		"name": "ann",
+		"addr": map[string]interface{}{"city": "Oslo"},
+		"Tags": []interface{}{"a"},
+		"err":  "locked",
+	}
+	if got := expandValue(&u); !reflect.DeepEqual(got, want) {
+		t.Errorf("struct: %#v", got)
+	}
+
+	// Pointer receivers are used before the pointer is followed.
+	if got := expandValue([]interface{}{&marshalSecret{"pw"}}); !reflect.DeepEqual(got, []interface{}{"hidden"}) {
+		t.Errorf("LogValuer: %#v", got)
+	}
+
+	m := make(map[int]int)
+	s := make([]int, MaxFieldElements+3)
+	for i := 0; i < MaxFieldElements+2; i++ {
+		m[i] = i
+	}
+	if got := expandValue(m).(map[string]interface{}); len(got) != MaxFieldElements+1 || got[TruncatedKey] != 2 || got["0"] != 0 {
+		t.Errorf("map: %d entries, truncated %v", len(got), got[TruncatedKey])
+	}
+	if got := expandValue(s).([]interface{}); len(got) != MaxFieldElements+1 || got[MaxFieldElements] != fmt.Sprintf(TruncatedFmt, 3) {
+		t.Errorf("slice: %d elements, last %v", len(got), got[len(got)-1])
+	}
+
+	// Nesting beyond MaxFieldDepth is rendered with fmt.
+	var n *marshalNode
+	for i := 0; i < MaxFieldDepth+2; i++ {
+		n = &marshalNode{Next: n}
+	}
+	v := expandValue(n)
+	for i := 0; i < MaxFieldDepth; i++ {
+		v = v.(map[string]interface{})["next"]
+	}
+	if _, ok := v.(string); !ok {
+		t.Errorf("value at depth %d is %T", MaxFieldDepth, v)
+	}
+	if expandValue([]int(nil)) != nil || expandValue((*marshalAddr)(nil)) != nil {
+		t.Error("nil values expanded")
+	}
+}
+
+func TestIsComposite(t *testing.T) {
+	for _, v := range []interface{}{marshalAddr{}, &marshalAddr{}, map[string]int{}, []int{}, [2]int{}} {
+		if !isComposite(v) {
+			t.Errorf("%T is not composite", v)
+		}
+	}
+	for _, v := range []interface{}{nil, 1, "s", []byte("b"), testTime, errors.New("e"), secretToken("t")} {
+		if isComposite(v) {
+			t.Errorf("%T is composite", v)
+		}
+	}
+}
+
+func TestFlattenedFields(t *testing.T) {
+	var buf bytes.Buffer
+	appendFields(&buf, []Field{Any("user", marshalUser{Name: "ann", Addr: &marshalAddr{City: "Oslo"}, Tags: []string{"a", "b"}})})
+	got := buf.String()
+	for _, want := range []string{" user.name=ann", " user.addr.city=Oslo", " user.Tags.0=a", " user.Tags.1=b"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q lacks %q", got, want)
+		}
+	}
+	if strings.Index(got, "user.Tags") > strings.Index(got, "user.addr") {
+		t.Errorf("%q is not in key order", got)
+	}
+}
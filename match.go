@@ -0,0 +1,325 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const MatchSyntaxErrFmt = "Invalid filter at offset %d: %s"
+
+// Matcher reports whether an entry is selected by a filter.
+type Matcher func(e *Entry) bool
+
+// ParseMatcher compiles a filter expression such as
+//
+//	level>=warn && user_id=42
+//	name=app.db && (msg~"time(d )?out" || !retry)
+//
+// Comparisons are key op value with the operators = != < <= > >= and ~,
+// a regular expression match. The keys level, name, msg and time refer to
+// the entry itself; any other key names a field, with dots reaching into
+// groups ("http.status"). Levels compare by rank, times as RFC 3339,
+// numbers and durations numerically and anything else as text. A bare key
+// tests that the field is present. Terms combine with &&, || and !, and
+// parentheses; && binds tighter than ||. Values with spaces or operator
+// characters are written as Go quoted strings.
+func ParseMatcher(expr string) (Matcher, error) {
+	p := &matchParser{src: expr}
+	m, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skip(); p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return m, nil
+}
+
+type matchParser struct {
+	src string
+	pos int
+}
+
+func (p *matchParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(MatchSyntaxErrFmt, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *matchParser) skip() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes tok if it comes next.
+func (p *matchParser) accept(tok string) bool {
+	p.skip()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *matchParser) or() (Matcher, error) {
+	m, err := p.and()
+	for err == nil && p.accept("||") {
+		var r Matcher
+		if r, err = p.and(); err == nil {
+			l := m
+			m = func(e *Entry) bool { return l(e) || r(e) }
+		}
+	}
+	return m, err
+}
+
+func (p *matchParser) and() (Matcher, error) {
+	m, err := p.unary()
+	for err == nil && p.accept("&&") {
+		var r Matcher
+		if r, err = p.unary(); err == nil {
+			l := m
+			m = func(e *Entry) bool { return l(e) && r(e) }
+		}
+	}
+	return m, err
+}
+
+func (p *matchParser) unary() (Matcher, error) {
+	switch {
+	case p.accept("!"):
+		m, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *Entry) bool { return !m(e) }, nil
+	case p.accept("("):
+		m, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("missing )")
+		}
+		return m, nil
+	}
+	return p.comparison()
+}
+
+// matchOps lists the operators, longest first so <= is not read as <.
+var matchOps = []string{"!=", "<=", ">=", "=", "<", ">", "~"}
+
+func (p *matchParser) comparison() (Matcher, error) {
+	key, err := p.word(false)
+	if err != nil {
+		return nil, err
+	}
+	op := ""
+	for _, o := range matchOps {
+		if p.accept(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return func(e *Entry) bool {
+			_, ok := entryValue(e, key)
+			return ok
+		}, nil
+	}
+	val, err := p.word(true)
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(key, op, val)
+}
+
+// word reads a bare word or, if quoted is allowed, a quoted string.
+func (p *matchParser) word(quoted bool) (string, error) {
+	p.skip()
+	start := p.pos
+	if quoted && p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '`') {
+		q, err := strconv.QuotedPrefix(p.src[p.pos:])
+		if err != nil {
+			return "", p.errorf("bad quoted string")
+		}
+		p.pos += len(q)
+		s, _ := strconv.Unquote(q)
+		return s, nil
+	}
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t()&|!=<>~\"", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.pos == len(p.src) {
+			return "", p.errorf("unexpected end")
+		}
+		return "", p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return p.src[start:p.pos], nil
+}
+
+func newComparison(key, op, val string) (Matcher, error) {
+	if op == "~" {
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return nil, fmt.Errorf(MatchSyntaxErrFmt, 0, err)
+		}
+		return func(e *Entry) bool {
+			v, ok := entryValue(e, key)
+			return ok && re.MatchString(valueString(v))
+		}, nil
+	}
+	if key == "level" {
+		lvl, ok := ParseLevel(val)
+		if !ok {
+			return nil, fmt.Errorf(MatchSyntaxErrFmt, 0, fmt.Sprintf("unknown level %q", val))
+		}
+		return func(e *Entry) bool { return compareOrder(int(e.Level), int(lvl), op) }, nil
+	}
+	return func(e *Entry) bool {
+		v, ok := entryValue(e, key)
+		return ok && compareValue(v, op, val)
+	}, nil
+}
+
+// entryValue returns the value key refers to, see ParseMatcher.
+func entryValue(e *Entry, key string) (interface{}, bool) {
+	switch key {
+	case "level":
+		return e.Level, true
+	case "name":
+		return e.Name, true
+	case "msg":
+		return e.Message, true
+	case "time":
+		return e.Time, true
+	}
+	return lookupField(e.Fields, key)
+}
+
+// lookupField finds key among fields, the last one winning like in
+// FieldMap, descending into groups for dotted keys.
+func lookupField(fields []Field, key string) (interface{}, bool) {
+	if f, ok := findField(fields, key); ok {
+		return f.Interface(), true
+	}
+	return nil, false
+}
+
+// findField returns the field lookupField takes the value of.
+func findField(fields []Field, key string) (Field, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i], true
+		}
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Type == GroupType && strings.HasPrefix(key, f.Key+".") {
+			members, _ := f.Value.([]Field)
+			if m, ok := findField(members, key[len(f.Key)+1:]); ok {
+				return m, true
+			}
+		}
+	}
+	return Field{}, false
+}
+
+// compareValue compares v with the literal val by the type of v.
+func compareValue(v interface{}, op, val string) bool {
+	switch v := v.(type) {
+	case time.Time:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return false
+		}
+		return compareOrder(v.Compare(t), 0, op)
+	case time.Duration:
+		if d, err := time.ParseDuration(val); err == nil {
+			return compareOrder(cmpFloat(float64(v), float64(d)), 0, op)
+		}
+	case LogLevel:
+		if lvl, ok := ParseLevel(val); ok {
+			return compareOrder(int(v), int(lvl), op)
+		}
+	}
+	if f, ok := toFloat(v); ok {
+		if n, err := strconv.ParseFloat(val, 64); err == nil {
+			return compareOrder(cmpFloat(f, n), 0, op)
+		}
+	}
+	return compareOrder(strings.Compare(valueString(v), val), 0, op)
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareOrder(a, b int, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
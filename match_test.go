@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMatcher(t *testing.T) {
+	e := &Entry{
+		Time:    testTime,
+		Level:   Warn,
+		Name:    "app.db",
+		Message: "query timed out",
+		Fields: []Field{
+			Int("user_id", 42), Duration("took", 1500*time.Millisecond), Bool("retry", false),
+			Err(errors.New("deadline exceeded")), Group("http", Int("status", 503), String("path", "/a b")),
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"level>=warn", true},
+		{"level>warn", false},
+		{"user_id=42 && level>=warn", true},
+		{"user_id=41 || name=app.db", true},
+		{"user_id!=42", false},
+		{"user_id>=40.5", true},
+		{`msg~"time(d )?out"`, true},
+		{"msg~^query$", false},
+		{"took>1s && took<2s", true},
+		{"retry=false", true},
+		{"!retry", false},
+		{"!missing", true},
+		{"error~deadline", true},
+		{"http.status>=500", true},
+		{`http.path="/a b"`, true},
+		{"time>=2024-03-01T12:00:00Z && time<2024-03-01T13:00:00Z", true},
+		{"name=app && level=warn", false},
+		{"!(name=app.db) || (user_id=42 && http.status=503)", true},
+		{"a || b && c", false},
+		{"user_id || b && c", true},
+	}
+	for _, tt := range tests {
+		m, err := ParseMatcher(tt.expr)
+		if err != nil {
+			t.Errorf("ParseMatcher(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := m(e); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "a &&", "(a", "a b", "level>loud", `msg~"("`, "a=", `a="x`, "&&"} {
+		if _, err := ParseMatcher(bad); err == nil {
+			t.Errorf("ParseMatcher(%q) succeeded", bad)
+		}
+	}
+}
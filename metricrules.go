@@ -0,0 +1,171 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetricRuleErrFmt reports an invalid metric rule.
+const MetricRuleErrFmt = "Invalid metric rule %q: %s"
+
+// DefaultMetricBuckets are the histogram bounds used when a rule sets
+// none, suiting durations in seconds.
+var DefaultMetricBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// MetricRule derives a metric from the entries matching a filter: a
+// counter of them, or with Field a histogram of that field's values.
+type MetricRule struct {
+	// Name is the metric name, a Prometheus identifier.
+	Name string
+	// Help describes the metric.
+	Help string
+	// Match selects the entries; nil selects all. See ParseMatcher.
+	Match Matcher
+	// Field names the numeric field observed by a histogram, with dots for
+	// groups; empty for a counter. Durations are observed in seconds.
+	Field string
+	// Buckets are the ascending upper bounds of the histogram;
+	// DefaultMetricBuckets if empty.
+	Buckets []float64
+}
+
+// metric is the live state of a rule.
+type metric struct {
+	rule   MetricRule
+	count  uint64
+	sum    float64
+	counts []uint64 // per bucket, not cumulative
+}
+
+// MetricsSink turns entries into metrics by its rules and exposes them in
+// the Prometheus text format, when served or through the logger's
+// StatsHandler, and as JSON for expvar. It writes nothing itself; add it
+// with WithSinks.
+type MetricsSink struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+// NewMetricsSink returns a MetricsSink with the given rules. Names must be
+// valid and unique, and histogram buckets ascending.
+func NewMetricsSink(rules ...MetricRule) (*MetricsSink, error) {
+	s := new(MetricsSink)
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if !metricName.MatchString(r.Name) {
+			return nil, fmt.Errorf(MetricRuleErrFmt, r.Name, "invalid name")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf(MetricRuleErrFmt, r.Name, "duplicate name")
+		}
+		seen[r.Name] = true
+		m := &metric{rule: r}
+		if r.Field != "" {
+			if len(r.Buckets) == 0 {
+				m.rule.Buckets = DefaultMetricBuckets
+			}
+			if !sort.Float64sAreSorted(m.rule.Buckets) {
+				return nil, fmt.Errorf(MetricRuleErrFmt, r.Name, "buckets not ascending")
+			}
+			m.counts = make([]uint64, len(m.rule.Buckets))
+		}
+		s.metrics = append(s.metrics, m)
+	}
+	return s, nil
+}
+
+// WriteEntry implements Sink. Entries lacking a histogram's field, or with
+// a value that is not a number, are not observed.
+func (s *MetricsSink) WriteEntry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.metrics {
+		if m.rule.Match != nil && !m.rule.Match(e) {
+			continue
+		}
+		if m.rule.Field == "" {
+			m.count++
+			continue
+		}
+		v, ok := lookupField(e.Fields, m.rule.Field)
+		if !ok {
+			continue
+		}
+		x, ok := metricValue(v)
+		if !ok {
+			continue
+		}
+		m.count++
+		m.sum += x
+		if i := sort.SearchFloat64s(m.rule.Buckets, x); i < len(m.counts) {
+			m.counts[i]++
+		}
+	}
+	return nil
+}
+
+// metricValue returns v as a number, durations in seconds.
+func metricValue(v interface{}) (float64, bool) {
+	if d, ok := v.(time.Duration); ok {
+		return d.Seconds(), true
+	}
+	x, ok := toFloat(v)
+	return x, ok && !math.IsNaN(x)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (s *MetricsSink) WritePrometheus(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.metrics {
+		r := m.rule
+		if r.Help != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", r.Name, r.Help)
+		}
+		if r.Field == "" {
+			fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", r.Name, r.Name, m.count)
+			continue
+		}
+		fmt.Fprintf(w, "# TYPE %s histogram\n", r.Name)
+		var cum uint64
+		for i, le := range r.Buckets {
+			cum += m.counts[i]
+			fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", r.Name, le, cum)
+		}
+		fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %g\n%s_count %d\n", r.Name, m.count, r.Name, m.sum, r.Name, m.count)
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (s *MetricsSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.WritePrometheus(w)
+}
+
+// String returns the metrics as JSON, making the sink an expvar.Var:
+// counters as numbers, histograms as objects with count and sum.
+func (s *MetricsSink) String() string {
+	s.mu.Lock()
+	out := make(map[string]interface{}, len(s.metrics))
+	for _, m := range s.metrics {
+		if m.rule.Field == "" {
+			out[m.rule.Name] = m.count
+			continue
+		}
+		out[m.rule.Name] = map[string]interface{}{"count": m.count, "sum": m.sum}
+	}
+	s.mu.Unlock()
+	b, _ := json.Marshal(out)
+	return string(b)
+}
@@ -0,0 +1,61 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsSink(t *testing.T) {
+	miss, err := ParseMatcher(`msg~"cache miss"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMetricsSink(
+		MetricRule{Name: "cache_misses_total", Help: "Cache misses.", Match: miss},
+		MetricRule{Name: "request_seconds", Field: "http.took", Buckets: []float64{0.1, 1}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newTestLogger(t, Info, &bytes.Buffer{}, WithSinks(m))
+	l.Info("cache miss for user")
+	l.Info("cache hit")
+	l.Log(Info, "cache miss", Group("http", Duration("took", 50*time.Millisecond)))
+	l.Group("http").Log(Info, "served", Duration("took", 2*time.Second))
+	l.Group("http").Log(Info, "served", String("took", "n/a"))
+
+	rec := httptest.NewRecorder()
+	l.StatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		"# HELP cache_misses_total Cache misses.\n# TYPE cache_misses_total counter\ncache_misses_total 2\n",
+		"# TYPE request_seconds histogram\n" +
+			`request_seconds_bucket{le="0.1"} 1` + "\n" +
+			`request_seconds_bucket{le="1"} 1` + "\n" +
+			`request_seconds_bucket{le="+Inf"} 2` + "\n" +
+			"request_seconds_sum 2.05\nrequest_seconds_count 2\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("missing %q in\n%s", want, rec.Body.String())
+		}
+	}
+	if got, want := m.String(), `{"cache_misses_total":2,"request_seconds":{"count":2,"sum":2.05}}`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+func TestMetricRuleErrors(t *testing.T) {
+	for _, rules := range [][]MetricRule{
+		{{Name: "bad name"}},
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "h", Field: "x", Buckets: []float64{2, 1}}},
+	} {
+		if _, err := NewMetricsSink(rules...); err == nil {
+			t.Errorf("NewMetricsSink(%+v) succeeded", rules)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// StatsVar is the logger's Stats as an expvar.Var; publish it with
+//
+//	expvar.Publish("logger", log.StatsVar())
+//
+// The package does not import expvar itself, which would register
+// /debug/vars on http.DefaultServeMux for every program using it.
+type StatsVar struct {
+	l *CustomLogger
+}
+
+// StatsVar returns an expvar.Var reporting the logger's Stats.
+func (l *CustomLogger) StatsVar() StatsVar {
+	return StatsVar{l: l}
+}
+
+// String returns the Stats as JSON.
+func (v StatsVar) String() string {
+	b, _ := json.Marshal(v.l.Stats())
+	return string(b)
+}
+
+// PrometheusWriter is implemented by sinks with metrics of their own,
+// which StatsHandler serves after the logger's.
+type PrometheusWriter interface {
+	WritePrometheus(w io.Writer)
+}
+
+// StatsHandler serves the logger's Stats in the Prometheus text format,
+// as counters named log_entries_<kind>_total, log_entries_total by level
+// and log_bytes_total, and gauges for the uptime, queue depth and time of
+// the last error, for scraping without a client library. The metrics of
+// sinks implementing PrometheusWriter follow.
+func (l *CustomLogger) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := l.Stats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, c := range []struct {
+			kind, help string
+			n          uint64
+		}{
+			{"dropped", "Entries discarded because a buffer was full.", st.Dropped},
+			{"failed", "Entries that could not be encoded or written.", st.Failed},
+			{"suppressed", "Entries filtered out by sampling or rate limiting.", st.Suppressed},
+			{"closed", "Entries logged after the logger was closed.", st.Closed},
+		} {
+			fmt.Fprintf(w, "# HELP log_entries_%s_total %s\n# TYPE log_entries_%s_total counter\nlog_entries_%s_total %d\n",
+				c.kind, c.help, c.kind, c.kind, c.n)
+		}
+
+		levels := make([]LogLevel, 0, len(st.Levels))
+		for lvl := range st.Levels {
+			levels = append(levels, lvl)
+		}
+		sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+		fmt.Fprint(w, "# HELP log_entries_total Entries logged by level.\n# TYPE log_entries_total counter\n")
+		for _, lvl := range levels {
+			fmt.Fprintf(w, "log_entries_total{level=%q} %d\n", strings.ToLower(lvl.String()), st.Levels[lvl])
+		}
+		fmt.Fprintf(w, "# HELP log_bytes_total Bytes written to the default output.\n# TYPE log_bytes_total counter\nlog_bytes_total %d\n", st.Bytes)
+		fmt.Fprintf(w, "# HELP log_uptime_seconds Time since the logger was created.\n# TYPE log_uptime_seconds gauge\nlog_uptime_seconds %g\n", st.Uptime.Seconds())
+		fmt.Fprintf(w, "# HELP log_queue_depth Entries buffered by sinks but not yet written.\n# TYPE log_queue_depth gauge\nlog_queue_depth %d\n", st.QueueDepth)
+		if !st.LastErrorTime.IsZero() {
+			fmt.Fprintf(w, "# HELP log_last_error_timestamp_seconds Time of the last error.\n# TYPE log_last_error_timestamp_seconds gauge\nlog_last_error_timestamp_seconds %d\n", st.LastErrorTime.Unix())
+		}
+		for _, s := range l.sinks {
+			if p, ok := s.(PrometheusWriter); ok {
+				p.WritePrometheus(w)
+			}
+		}
+	})
+}
@@ -0,0 +1,34 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatsHandler(t *testing.T) {
+	l := newTestLogger(t, Info, &bytes.Buffer{})
+	l.Info("a")
+	l.Warn("b")
+
+	rec := httptest.NewRecorder()
+	l.StatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"log_entries_failed_total 0\n",
+		`log_entries_total{level="info"} 1` + "\n",
+		`log_entries_total{level="warn"} 1` + "\n",
+		"log_uptime_seconds 0\n",
+		"log_queue_depth 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "log_last_error") {
+		t.Errorf("last error reported without an error:\n%s", body)
+	}
+}
@@ -0,0 +1,149 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+)
+
+const (
+	// RequestIDKey is the field holding the ID of an HTTP request.
+	RequestIDKey = "request_id"
+	// DefaultRequestIDHeader carries request IDs in and out.
+	DefaultRequestIDHeader = "X-Request-ID"
+	// maxRequestIDLen bounds the length of a trusted incoming request ID.
+	maxRequestIDLen = 128
+)
+
+// MiddlewareConfig configures Middleware.
+type MiddlewareConfig struct {
+	// RequestIDHeader is the request and response header holding the
+	// request ID, DefaultRequestIDHeader if empty.
+	RequestIDHeader string
+	// TrustRequestID reuses a well-formed ID sent by the client or an
+	// upstream proxy instead of generating one.
+	TrustRequestID bool
+	// NewID generates request IDs, NewRequestID if nil.
+	NewID func() string
+	// TraceHeaders adds trace_id and parent_id fields from incoming
+	// traceparent or B3 headers, see TraceFromHeaders, and stores the
+	// trace in the request context and its sampling decision in the
+	// request logger, see WithTraceSampling.
+	TraceHeaders bool
+	// AccessLog, if set, receives an access log line per request in
+	// AccessLogFormat, see AccessLog.
+	AccessLog       io.Writer
+	AccessLogFormat AccessLogFormat
+	// DebugTokens are values of DebugHeader that log the request at
+	// Debug whatever the level of l, marked with DebugRequestKey, to trace
+	// a problem request in production.
+	DebugTokens []string
+	// DebugKey, if set, also accepts the signed, expiring tokens made by
+	// DebugToken as values of DebugHeader.
+	DebugKey []byte
+	// DebugHeader is the request header holding a debug token,
+	// DefaultDebugHeader if empty.
+	DebugHeader string
+}
+
+// Middleware returns HTTP middleware that gives every request an ID, sets
+// it on the response header and stores a child of l carrying it as the
+// request_id field in the request context, see FromContext. With
+// TraceHeaders the trace fields of the request are added too, with
+// AccessLog an access log line is written per request. A request carrying
+// an accepted debug token is logged at Debug, see DebugTokens.
+func Middleware(l *CustomLogger, cfg MiddlewareConfig) func(http.Handler) http.Handler {
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if cfg.NewID == nil {
+		cfg.NewID = NewRequestID
+	}
+	if cfg.DebugHeader == "" {
+		cfg.DebugHeader = DefaultDebugHeader
+	}
+	debug := len(cfg.DebugTokens) > 0 || cfg.DebugKey != nil
+	var access *accessLogger
+	if cfg.AccessLog != nil {
+		access = newAccessLogger(cfg.AccessLog, cfg.AccessLogFormat, l.clock)
+	}
+	return func(next http.Handler) http.Handler {
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			if cfg.TrustRequestID {
+				id = r.Header.Get(cfg.RequestIDHeader)
+			}
+			if !validRequestID(id) {
+				id = cfg.NewID()
+			}
+			w.Header().Set(cfg.RequestIDHeader, id)
+			fields := []Field{String(RequestIDKey, id)}
+			ctx, rl := r.Context(), l
+			if cfg.TraceHeaders {
+				if t, ok := TraceFromHeaders(r.Header); ok {
+					fields = append(fields, t.Fields()...)
+					ctx, rl = NewTraceContext(ctx, t), rl.TraceSampled(t.Sampled)
+				}
+			}
+			if debug && cfg.debugRequested(r, l.clock()) {
+				rl = rl.AtLevel(Debug).TraceSampled(true)
+				fields = append(fields, Bool(DebugRequestKey, true))
+			}
+			rl = rl.With(fields...)
+			next.ServeHTTP(w, r.WithContext(NewContext(ctx, rl)))
+		})
+		if access != nil {
+			return access.middleware(h)
+		}
+		return h
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII, so clients cannot
+// inject line breaks or huge values into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Recoverer returns HTTP middleware that recovers panics in handlers, logs
+// the value, stack, method, path and remote address at Error level through
+// the request logger (see FromContext, so it pairs with Middleware when
+// mounted inside it) and replies 500 if nothing was written yet.
+// http.ErrAbortHandler is re-panicked so the server aborts the response
+// as intended.
+func Recoverer(l *CustomLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				FromContext(r.Context(), l).log(Error, fmt.Sprintf(PanicFmt, v),
+					String(StackKey, string(debug.Stack())),
+					String("method", r.Method),
+					String("path", r.URL.Path),
+					String("remote_addr", r.RemoteAddr))
+				if sw.status == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	h := func(cfg MiddlewareConfig) http.Handler {
+		return Middleware(l, cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context(), nil).Info("handled")
+		}))
+	}
+	tests := []struct {
+		name   string
+		cfg    MiddlewareConfig
+		header string
+		want   string
+	}{
+		{"generated", MiddlewareConfig{NewID: func() string { return "new" }}, "client", "new"},
+		{"trusted", MiddlewareConfig{TrustRequestID: true}, "client-1", "client-1"},
+		{"malformed", MiddlewareConfig{TrustRequestID: true, NewID: func() string { return "new" }}, "a\nb", "new"},
+		{"too long", MiddlewareConfig{TrustRequestID: true, NewID: func() string { return "new" }}, strings.Repeat("x", maxRequestIDLen+1), "new"},
+		{"custom header", MiddlewareConfig{RequestIDHeader: "X-Correlation-ID", TrustRequestID: true}, "c-7", "c-7"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		header := tt.cfg.RequestIDHeader
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(header, tt.header)
+		w := httptest.NewRecorder()
+		h(tt.cfg).ServeHTTP(w, r)
+		if got := w.Header().Get(header); got != tt.want {
+			t.Errorf("%s: response ID %q, want %q", tt.name, got, tt.want)
+		}
+		if !strings.Contains(buf.String(), "request_id="+tt.want) {
+			t.Errorf("%s: log %q lacks the ID", tt.name, buf.String())
+		}
+	}
+
+	// Without a configured generator IDs are random.
+	w := httptest.NewRecorder()
+	h(MiddlewareConfig{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if id := w.Header().Get(DefaultRequestIDHeader); len(id) != 16 {
+		t.Errorf("generated ID %q", id)
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	h := Middleware(l, MiddlewareConfig{NewID: func() string { return "rid" }})(
+		Recoverer(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pay", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	out := buf.String()
+	for _, want := range []string{"ERROR", "panic: boom", "request_id=rid", "method=POST", "path=/pay", "stack="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q lacks %q", out, want)
+		}
+	}
+}
+
+func TestRecovererWritten(t *testing.T) {
+	l := newTestLogger(t, Info, new(bytes.Buffer))
+	h := Recoverer(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want the status already sent", w.Code)
+	}
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want ErrAbortHandler", v)
+		}
+	}()
+	Recoverer(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,27 @@
+//go:build logger_minimal
+
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMinimalBuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, cfg := range []FileConfig{
+		{Rotation: RotationConfig{MaxSize: 1 << 20}},
+		{Rotation: RotationConfig{Symlink: true}},
+		{Archive: ArchiveConfig{Compress: true}},
+	} {
+		if _, err := OpenFile(path, cfg); err != ErrRotationCompiledOut {
+			t.Errorf("OpenFile(%+v) = %v", cfg, err)
+		}
+	}
+	if _, err := flagEncoder("json"); err == nil {
+		t.Error("json format accepted")
+	}
+	if _, ok := structuredEncoder().(LogfmtEncoder); !ok {
+		t.Errorf("structured encoder %T", structuredEncoder())
+	}
+}
@@ -0,0 +1,437 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMongoCappedSize is the capped collection size used when
+	// MongoConfig.CappedSize is zero.
+	DefaultMongoCappedSize = 256 << 20
+	// DefaultDialTimeout bounds connection setup for network senders.
+	DefaultDialTimeout = 10 * time.Second
+
+	MongoErrFmt     = "MongoDB: %s"
+	MongoAuthErrFmt = "MongoDB authentication failed: %s"
+)
+
+const (
+	mongoOpMsg        = 2013
+	mongoMaxBatch     = 1000
+	mongoMaxReply     = 48 << 20
+	mongoNamespaceErr = 48
+)
+
+// MongoConfig configures a MongoSender.
+type MongoConfig struct {
+	// Addr is the host:port of a mongod or mongos, e.g. "localhost:27017".
+	Addr       string
+	Database   string
+	Collection string
+	// CappedSize bounds the collection in bytes; MongoDB discards the oldest
+	// documents once it is full. CappedMax optionally bounds the count.
+	CappedSize int64
+	CappedMax  int64
+	// Username and Password enable SCRAM-SHA-256 authentication against
+	// AuthSource, which defaults to "admin".
+	Username   string
+	Password   string
+	AuthSource string
+	// TLS, if set, is used to secure the connection.
+	TLS         *tls.Config
+	DialTimeout time.Duration
+}
+
+// MongoInserter inserts documents into one collection. It lets a MongoDB
+// driver do the delivery instead of MongoSender, e.g. with the official
+// driver:
+//
+//	type inserter struct{ c *mongo.Collection }
+//
+//	func (i inserter) InsertDocuments(ctx context.Context, docs []map[string]interface{}) error {
+//		many := make([]interface{}, len(docs))
+//		for j, d := range docs {
+//			many[j] = d
+//		}
+//		_, err := i.c.InsertMany(ctx, many, options.InsertMany().SetOrdered(false))
+//		return err
+//	}
+type MongoInserter interface {
+	InsertDocuments(ctx context.Context, docs []map[string]interface{}) error
+}
+
+// MongoInserterSender is a BatchSender writing the documents of MongoSender
+// through a MongoInserter.
+type MongoInserterSender struct {
+	ins MongoInserter
+}
+
+// NewMongoInserterSender returns a sender delivering through ins.
+func NewMongoInserterSender(ins MongoInserter) *MongoInserterSender {
+	return &MongoInserterSender{ins: ins}
+}
+
+// SendBatch implements BatchSender.
+func (s *MongoInserterSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	docs := make([]map[string]interface{}, len(batch))
+	for i, e := range batch {
+		docs[i] = map[string]interface{}{
+			"ts":     e.Time,
+			"level":  e.Level.String(),
+			"name":   e.Name,
+			"msg":    e.Message,
+			"fields": e.FieldMap(),
+		}
+	}
+	return s.ins.InsertDocuments(ctx, docs)
+}
+
+// MongoSender is a BatchSender that inserts entries as documents
+// {ts, level, name, msg, fields} into a capped collection, giving central
+// logs with size-bounded retention. It speaks the MongoDB wire protocol
+// directly and reconnects after a failure.
+type MongoSender struct {
+	cfg MongoConfig
+
+	mu    sync.Mutex
+	conn  net.Conn
+	reqID int32
+}
+
+// NewMongoSender connects, authenticates and creates the capped collection
+// if it does not exist yet.
+func NewMongoSender(ctx context.Context, cfg MongoConfig) (*MongoSender, error) {
+	if cfg.CappedSize <= 0 {
+		cfg.CappedSize = DefaultMongoCappedSize
+	}
+	if cfg.AuthSource == "" {
+		cfg.AuthSource = "admin"
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	s := &MongoSender{cfg: cfg}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.connectLocked(ctx); err != nil {
+		return nil, err
+	}
+	create := bsonDoc{
+		{"create", cfg.Collection},
+		{"capped", true},
+		{"size", cfg.CappedSize},
+	}
+	if cfg.CappedMax > 0 {
+		create = append(create, bsonElem{"max", cfg.CappedMax})
+	}
+	create = append(create, bsonElem{"$db", cfg.Database})
+	if _, err := s.commandLocked(ctx, create); err != nil && !isMongoCode(err, mongoNamespaceErr) {
+		s.closeLocked()
+		return nil, err
+	}
+	return s, nil
+}
+
+// SendBatch implements BatchSender.
+func (s *MongoSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	for start := 0; start < len(batch); start += mongoMaxBatch {
+		end := start + mongoMaxBatch
+		if end > len(batch) {
+			end = len(batch)
+		}
+		docs := make([]bsonDoc, 0, end-start)
+		for _, e := range batch[start:end] {
+			docs = append(docs, mongoDocument(e))
+		}
+		reply, err := s.commandLocked(ctx, bsonDoc{
+			{"insert", s.cfg.Collection},
+			{"documents", docs},
+			{"ordered", false},
+			{"$db", s.cfg.Database},
+		})
+		if err != nil {
+			return err
+		}
+		if we, ok := reply["writeErrors"].([]interface{}); ok && len(we) > 0 {
+			return fmt.Errorf(MongoErrFmt, mongoErrMsg(we[0]))
+		}
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *MongoSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *MongoSender) closeLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func mongoDocument(e *Entry) bsonDoc {
+	return bsonDoc{
+		{"ts", e.Time},
+		{"level", e.Level.String()},
+		{"name", e.Name},
+		{"msg", e.Message},
+		{"fields", e.FieldMap()},
+	}
+}
+
+func (s *MongoSender) connectLocked(ctx context.Context) error {
+	conn, err := dialNetwork(ctx, s.cfg.Addr, s.cfg.DialTimeout, s.cfg.TLS)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	if s.cfg.Username != "" {
+		if err := s.authLocked(ctx); err != nil {
+			s.closeLocked()
+			return err
+		}
+	}
+	return nil
+}
+
+// commandLocked runs cmd as an OP_MSG and returns the reply. Transport
+// failures drop the connection so that the next call reconnects.
+func (s *MongoSender) commandLocked(ctx context.Context, cmd bsonDoc) (map[string]interface{}, error) {
+	if s.conn == nil {
+		return nil, net.ErrClosed
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(dl)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+
+	s.reqID++
+	msg := make([]byte, 16, 256)
+	binary.LittleEndian.PutUint32(msg[4:], uint32(s.reqID))
+	binary.LittleEndian.PutUint32(msg[12:], mongoOpMsg)
+	msg = append(msg, 0, 0, 0, 0, 0)
+	msg = appendBSON(msg, cmd)
+	binary.LittleEndian.PutUint32(msg, uint32(len(msg)))
+
+	if _, err := s.conn.Write(msg); err != nil {
+		s.closeLocked()
+		return nil, err
+	}
+	reply, err := s.readReplyLocked()
+	if err != nil {
+		s.closeLocked()
+		return nil, err
+	}
+	if ok, _ := mongoNumber(reply["ok"]); ok != 1 {
+		code, _ := mongoNumber(reply["code"])
+		return reply, &mongoError{code: int(code), msg: mongoErrMsg(reply)}
+	}
+	return reply, nil
+}
+
+func (s *MongoSender) readReplyLocked() (map[string]interface{}, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(s.conn, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := int(binary.LittleEndian.Uint32(hdr[:]))
+	if n < 21 || n > mongoMaxReply || binary.LittleEndian.Uint32(hdr[12:]) != mongoOpMsg {
+		return nil, fmt.Errorf(MongoErrFmt, "unexpected reply")
+	}
+	body := make([]byte, n-16)
+	if _, err := io.ReadFull(s.conn, body); err != nil {
+		return nil, err
+	}
+	// flagBits, then a kind 0 section holding the reply document.
+	if body[4] != 0 {
+		return nil, fmt.Errorf(MongoErrFmt, "unexpected reply section")
+	}
+	doc := body[5:]
+	if dl := int(binary.LittleEndian.Uint32(doc)); dl <= len(doc) {
+		doc = doc[:dl]
+	}
+	return decodeBSON(doc)
+}
+
+// authLocked performs SCRAM-SHA-256 (RFC 7677) with the configured
+// credentials. Passwords are used as given, without SASLprep.
+func (s *MongoSender) authLocked(ctx context.Context) error {
+	var raw [24]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return err
+	}
+	nonce := base64.StdEncoding.EncodeToString(raw[:])
+	user := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s.cfg.Username)
+	clientFirst := "n=" + user + ",r=" + nonce
+
+	reply, err := s.commandLocked(ctx, bsonDoc{
+		{"saslStart", int32(1)},
+		{"mechanism", "SCRAM-SHA-256"},
+		{"payload", bsonBinary("n,," + clientFirst)},
+		{"options", bsonDoc{{"skipEmptyExchange", true}}},
+		{"$db", s.cfg.AuthSource},
+	})
+	if err != nil {
+		return fmt.Errorf(MongoAuthErrFmt, err)
+	}
+	final, want, err := scramClientFinal(s.cfg.Password, clientFirst, string(mongoPayload(reply)))
+	if err != nil {
+		return err
+	}
+
+	reply, err = s.commandLocked(ctx, bsonDoc{
+		{"saslContinue", int32(1)},
+		{"conversationId", reply["conversationId"]},
+		{"payload", bsonBinary(final)},
+		{"$db", s.cfg.AuthSource},
+	})
+	if err != nil {
+		return fmt.Errorf(MongoAuthErrFmt, err)
+	}
+	if scramAttrs(string(mongoPayload(reply)))["v"] != want {
+		return fmt.Errorf(MongoAuthErrFmt, "server signature mismatch")
+	}
+	for done, _ := reply["done"].(bool); !done; done, _ = reply["done"].(bool) {
+		reply, err = s.commandLocked(ctx, bsonDoc{
+			{"saslContinue", int32(1)},
+			{"conversationId", reply["conversationId"]},
+			{"payload", bsonBinary{}},
+			{"$db", s.cfg.AuthSource},
+		})
+		if err != nil {
+			return fmt.Errorf(MongoAuthErrFmt, err)
+		}
+	}
+	return nil
+}
+
+// scramClientFinal answers the server-first message of a SCRAM-SHA-256
+// exchange begun with clientFirst (without the GS2 header). It returns the
+// client-final message and the server signature expected in reply.
+func scramClientFinal(password, clientFirst, serverFirst string) (final, serverSig string, err error) {
+	attrs := scramAttrs(serverFirst)
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	iter, _ := strconv.Atoi(attrs["i"])
+	nonce := scramAttrs(clientFirst)["r"]
+	if err != nil || iter <= 0 || nonce == "" || !strings.HasPrefix(attrs["r"], nonce) {
+		return "", "", fmt.Errorf(MongoAuthErrFmt, "invalid server challenge")
+	}
+
+	salted := pbkdf2SHA256([]byte(password), salt, iter)
+	clientKey := hmacSHA256(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + attrs["r"]
+	authMsg := clientFirst + "," + serverFirst + "," + withoutProof
+	proof := hmacSHA256(storedKey[:], authMsg)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	serverKey := hmacSHA256(salted, "Server Key")
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof),
+		base64.StdEncoding.EncodeToString(hmacSHA256(serverKey, authMsg)), nil
+}
+
+// pbkdf2SHA256 derives a 32-byte key as specified by RFC 8018.
+func pbkdf2SHA256(password, salt []byte, iter int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+	out := append([]byte(nil), u...)
+	for n := 1; n < iter; n++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for i := range out {
+			out[i] ^= u[i]
+		}
+	}
+	return out
+}
+
+func scramAttrs(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(msg, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+func mongoPayload(reply map[string]interface{}) []byte {
+	p, _ := reply["payload"].(bsonBinary)
+	return p
+}
+
+type mongoError struct {
+	code int
+	msg  string
+}
+
+func (e *mongoError) Error() string {
+	return fmt.Sprintf(MongoErrFmt, e.msg)
+}
+
+func isMongoCode(err error, code int) bool {
+	var me *mongoError
+	return errors.As(err, &me) && me.code == code
+}
+
+func mongoErrMsg(v interface{}) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		if s, ok := m["errmsg"].(string); ok {
+			return s
+		}
+	}
+	return "command failed"
+}
+
+func mongoNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,94 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSCRAMClientFinal(t *testing.T) {
+	// The example exchange of RFC 7677, section 3.
+	const (
+		clientFirst = "n=user,r=rOprNGfwEbeRWgbNEkqO"
+		serverFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+		wantFinal   = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+		wantSig     = "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+	)
+	final, sig, err := scramClientFinal("pencil", clientFirst, serverFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final != wantFinal || sig != wantSig {
+		t.Errorf("got %q, %q", final, sig)
+	}
+
+	bad := []string{
+		"r=other,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOx,s=!!,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOx,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=0",
+		"",
+	}
+	for _, s := range bad {
+		if _, _, err := scramClientFinal("pencil", clientFirst, s); err == nil {
+			t.Errorf("accepted server-first %q", s)
+		}
+	}
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256 with a 32 byte key.
+	tests := []struct {
+		password, salt string
+		iter           int
+		want           string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iter)); got != tt.want {
+			t.Errorf("pbkdf2(%q, %q, %d) = %s", tt.password, tt.salt, tt.iter, got)
+		}
+	}
+}
+
+type recordInserter struct {
+	docs []map[string]interface{}
+}
+
+func (r *recordInserter) InsertDocuments(ctx context.Context, docs []map[string]interface{}) error {
+	r.docs = append(r.docs, docs...)
+	return nil
+}
+
+func TestMongoDocuments(t *testing.T) {
+	e := &Entry{Time: testTime, Level: Warn, Name: "db", Message: "slow", Fields: []Field{Int("ms", 250)}}
+
+	ins := new(recordInserter)
+	if err := NewMongoInserterSender(ins).SendBatch(context.Background(), []*Entry{e}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeBSON(appendBSON(nil, mongoDocument(e)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []map[string]interface{}{ins.docs[0], got} {
+		if doc["level"] != "WARN" || doc["name"] != "db" || doc["msg"] != "slow" {
+			t.Errorf("document = %v", doc)
+		}
+		fields, _ := doc["fields"].(map[string]interface{})
+		if n, ok := mongoNumber(fields["ms"]); !ok || n != 250 {
+			t.Errorf("fields = %v", doc["fields"])
+		}
+	}
+}
+
+func TestSCRAMAttrs(t *testing.T) {
+	got := scramAttrs("r=abc,s=c2FsdA==,i=10,junk")
+	if got["r"] != "abc" || got["s"] != "c2FsdA==" || got["i"] != "10" || len(got) != 3 {
+		t.Errorf("got %v", got)
+	}
+}
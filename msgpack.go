@@ -0,0 +1,497 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+const DecodeMsgpackErrFmt = "Invalid MessagePack log entry: %w"
+
+var errMsgpack = errors.New("malformed msgpack value")
+
+// msgpackMaxDepth bounds the nesting of decoded values.
+const msgpackMaxDepth = 64
+
+// MsgpackEncoder writes entries as MessagePack maps with the keys of
+// JSONEncoder: time (a timestamp extension), level (by name), name, msg
+// and fields. The maps are self-delimiting, so a file or stream of them
+// needs no separators; they are typically a third smaller than JSON and
+// cheaper to write.
+type MsgpackEncoder struct{}
+
+// Encode implements Encoder.
+func (MsgpackEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	n := 3
+	if e.Name != "" {
+		n++
+	}
+	if len(e.Fields) > 0 {
+		n++
+	}
+	b := appendMsgpackMapHeader(buf.AvailableBuffer(), n)
+	b = appendMsgpackTime(appendMsgpackString(b, "time"), e.Time)
+	b = appendMsgpackString(appendMsgpackString(b, "level"), e.Level.String())
+	if e.Name != "" {
+		b = appendMsgpackString(appendMsgpackString(b, "name"), e.Name)
+	}
+	b = appendMsgpackString(appendMsgpackString(b, "msg"), e.Message)
+	if len(e.Fields) > 0 {
+		b = appendMsgpack(appendMsgpackString(b, "fields"), e.FieldMap())
+	}
+	buf.Write(b)
+	return nil
+}
+
+// appendMsgpackTime appends t as the timestamp extension (type -1), in the
+// shortest of its three forms.
+func appendMsgpackTime(b []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case nsec == 0 && sec >= 0 && sec <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd6, 0xff), uint32(sec))
+	case sec >= 0 && sec < 1<<34:
+		return binary.BigEndian.AppendUint64(append(b, 0xd7, 0xff), nsec<<34|uint64(sec))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc7, 12, 0xff), uint32(nsec))
+		return binary.BigEndian.AppendUint64(b, uint64(sec))
+	}
+}
+
+// DecodeMsgpack decodes the entry written by MsgpackEncoder at the start of
+// b and returns the number of bytes it took. The fields come back sorted by
+// key, maps as groups, integers as int64 and the time in UTC. Truncated
+// input is an error wrapping io.ErrUnexpectedEOF and reports zero bytes; a
+// complete value that is no entry reports its length, so a stream can move
+// past it.
+func DecodeMsgpack(b []byte) (*Entry, int, error) {
+	v, rest, err := msgpackValue(b, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf(DecodeMsgpackErrFmt, err)
+	}
+	n := len(b) - len(rest)
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, n, fmt.Errorf(DecodeMsgpackErrFmt, errMsgpack)
+	}
+	msg, ok := doc["msg"].(string)
+	if !ok {
+		return nil, n, fmt.Errorf(DecodeMsgpackErrFmt, errNoMessage)
+	}
+	t, ok := doc["time"].(time.Time)
+	if !ok {
+		return nil, n, fmt.Errorf(DecodeMsgpackErrFmt, errNoTime)
+	}
+	e := &Entry{Time: t, Message: msg}
+	if s, ok := doc["level"].(string); ok {
+		if err := e.Level.UnmarshalText([]byte(s)); err != nil {
+			return nil, n, fmt.Errorf(DecodeMsgpackErrFmt, err)
+		}
+	}
+	e.Name, _ = doc["name"].(string)
+	if fields, ok := doc["fields"].(map[string]interface{}); ok {
+		e.Fields = decodeMsgpackFields(fields)
+	}
+	return e, n, nil
+}
+
+var errNoTime = errors.New("no time key")
+
+func decodeMsgpackFields(m map[string]interface{}) []Field {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]Field, len(keys))
+	for i, k := range keys {
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			fields[i] = Group(k, decodeMsgpackFields(v)...)
+		case int64:
+			fields[i] = Int64(k, v)
+		case float64:
+			fields[i] = Float(k, v)
+		case string:
+			fields[i] = String(k, v)
+		case bool:
+			fields[i] = Bool(k, v)
+		case time.Time:
+			fields[i] = Time(k, v)
+		default:
+			fields[i] = Any(k, v)
+		}
+	}
+	return fields
+}
+
+// msgpackValue decodes the value at the start of b: maps with string keys
+// as map[string]interface{}, arrays as []interface{}, integers as int64
+// (uint64 beyond its range), bin as []byte and timestamps as time.Time.
+func msgpackValue(b []byte, depth int) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	if depth > msgpackMaxDepth {
+		return nil, nil, errMsgpack
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), b[1:], nil
+	case c >= 0xe0:
+		return int64(int8(c)), b[1:], nil
+	case c&0xf0 == 0x80:
+		return msgpackMap(b[1:], int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return msgpackArray(b[1:], int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return msgpackBytes(b[1:], int(c&0x1f), true)
+	}
+	hdr, ok := msgpackHeaderSize[c]
+	switch {
+	case c == 0xc0:
+		return nil, b[1:], nil
+	case c == 0xc2 || c == 0xc3:
+		return c == 0xc3, b[1:], nil
+	case !ok:
+		return nil, nil, errMsgpack
+	case len(b) < 1+hdr:
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	h, rest := b[1:1+hdr], b[1+hdr:]
+	switch c {
+	case 0xc4, 0xc5, 0xc6:
+		return msgpackBytes(rest, int(msgpackUint(h)), false)
+	case 0xd9, 0xda, 0xdb:
+		return msgpackBytes(rest, int(msgpackUint(h)), true)
+	case 0xdc, 0xdd:
+		return msgpackArray(rest, int(msgpackUint(h)), depth)
+	case 0xde, 0xdf:
+		return msgpackMap(rest, int(msgpackUint(h)), depth)
+	case 0xca:
+		return float64(math.Float32frombits(uint32(msgpackUint(h)))), rest, nil
+	case 0xcb:
+		return math.Float64frombits(msgpackUint(h)), rest, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		if u := msgpackUint(h); u > math.MaxInt64 {
+			return u, rest, nil
+		}
+		return int64(msgpackUint(h)), rest, nil
+	case 0xd0:
+		return int64(int8(h[0])), rest, nil
+	case 0xd1:
+		return int64(int16(msgpackUint(h))), rest, nil
+	case 0xd2:
+		return int64(int32(msgpackUint(h))), rest, nil
+	case 0xd3:
+		return int64(msgpackUint(h)), rest, nil
+	case 0xc7, 0xc8, 0xc9:
+		// ext 8, 16 and 32: the length, then the type.
+		n := int(msgpackUint(h[:hdr-1]))
+		if len(rest) < n {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return msgpackExt(int8(h[hdr-1]), rest[:n], rest[n:])
+	default:
+		// fixext 1 to 16: the type, then the data.
+		return msgpackExt(int8(h[0]), h[1:], rest)
+	}
+}
+
+// msgpackHeaderSize is the size of what follows the type byte of the types
+// without the length in it: their length, value, or extension type and data.
+var msgpackHeaderSize = map[byte]int{
+	0xc4: 1, 0xc5: 2, 0xc6: 4, 0xc7: 2, 0xc8: 3, 0xc9: 5,
+	0xca: 4, 0xcb: 8, 0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8,
+	0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8,
+	0xd4: 2, 0xd5: 3, 0xd6: 5, 0xd7: 9, 0xd8: 17,
+	0xd9: 1, 0xda: 2, 0xdb: 4, 0xdc: 2, 0xdd: 4, 0xde: 2, 0xdf: 4,
+}
+
+// msgpackUint decodes a big-endian unsigned integer of up to 8 bytes.
+func msgpackUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u
+}
+
+func msgpackBytes(b []byte, n int, str bool) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	if str {
+		return string(b[:n]), b[n:], nil
+	}
+	return append([]byte(nil), b[:n]...), b[n:], nil
+}
+
+// msgpackArray decodes n elements. Every element takes at least a byte, so
+// a count beyond the input is truncation rather than an allocation.
+func msgpackArray(b []byte, n, depth int) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	a := make([]interface{}, n)
+	for i := range a {
+		var err error
+		if a[i], b, err = msgpackValue(b, depth+1); err != nil {
+			return nil, nil, err
+		}
+	}
+	return a, b, nil
+}
+
+func msgpackMap(b []byte, n, depth int) (interface{}, []byte, error) {
+	if len(b) < 2*n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := msgpackValue(b, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, nil, errMsgpack
+		}
+		if m[key], b, err = msgpackValue(rest, depth+1); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, b, nil
+}
+
+// msgpackExt decodes the timestamp extension; other extensions are kept
+// as their data.
+func msgpackExt(typ int8, data, rest []byte) (interface{}, []byte, error) {
+	if typ != -1 {
+		return append([]byte(nil), data...), rest, nil
+	}
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(msgpackUint(data)), 0).UTC(), rest, nil
+	case 8:
+		u := msgpackUint(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), rest, nil
+	case 12:
+		return time.Unix(int64(msgpackUint(data[4:])), int64(msgpackUint(data[:4]))).UTC(), rest, nil
+	}
+	return nil, nil, errMsgpack
+}
+
+// appendMsgpack appends the MessagePack encoding of v. Types without a
+// MessagePack counterpart are stored as their fmt representation.
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if x {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(x))
+	case int8:
+		return appendMsgpackInt(b, int64(x))
+	case int16:
+		return appendMsgpackInt(b, int64(x))
+	case int32:
+		return appendMsgpackInt(b, int64(x))
+	case int64:
+		return appendMsgpackInt(b, x)
+	case uint:
+		return appendMsgpackUint(b, uint64(x))
+	case uint8:
+		return appendMsgpackUint(b, uint64(x))
+	case uint16:
+		return appendMsgpackUint(b, uint64(x))
+	case uint32:
+		return appendMsgpackUint(b, uint64(x))
+	case uint64:
+		return appendMsgpackUint(b, x)
+	case float32:
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(x))
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(x))
+	case string:
+		return appendMsgpackString(b, x)
+	case []byte:
+		switch n := len(x); {
+		case n <= math.MaxUint8:
+			b = append(b, 0xc4, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+		}
+		return append(b, x...)
+	case time.Time:
+		return appendMsgpackString(b, x.Format(time.RFC3339Nano))
+	case time.Duration:
+		return appendMsgpackString(b, x.String())
+	case []interface{}:
+		b = appendMsgpackArrayHeader(b, len(x))
+		for _, el := range x {
+			b = appendMsgpack(b, el)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgpackMapHeader(b, len(x))
+		for _, k := range keys {
+			b = appendMsgpackString(b, k)
+			b = appendMsgpack(b, x[k])
+		}
+		return b
+	case error:
+		return appendMsgpackString(b, x.Error())
+	case fmt.Stringer:
+		return appendMsgpackString(b, x.String())
+	default:
+		return appendMsgpackString(b, fmt.Sprint(v))
+	}
+}
+
+func appendMsgpackInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// decodeMsgpackStringMap decodes a map whose keys and values are strings,
+// as used by protocol replies; other values are an error.
+func decodeMsgpackStringMap(b []byte) (map[string]string, error) {
+	n, b, err := msgpackMapLen(b)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		var k, v string
+		if k, b, err = msgpackString(b); err != nil {
+			return nil, err
+		}
+		if v, b, err = msgpackString(b); err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func msgpackMapLen(b []byte) (int, []byte, error) {
+	switch {
+	case len(b) >= 1 && b[0]&0xf0 == 0x80:
+		return int(b[0] & 0x0f), b[1:], nil
+	case len(b) >= 3 && b[0] == 0xde:
+		return int(binary.BigEndian.Uint16(b[1:])), b[3:], nil
+	case len(b) >= 5 && b[0] == 0xdf:
+		return int(binary.BigEndian.Uint32(b[1:])), b[5:], nil
+	}
+	return 0, nil, errMsgpack
+}
+
+// msgpackString decodes a str or bin value.
+func msgpackString(b []byte) (string, []byte, error) {
+	if len(b) == 0 {
+		return "", nil, errMsgpack
+	}
+	var n, hdr int
+	switch c := b[0]; {
+	case c&0xe0 == 0xa0:
+		n, hdr = int(c&0x1f), 1
+	case (c == 0xd9 || c == 0xc4) && len(b) >= 2:
+		n, hdr = int(b[1]), 2
+	case (c == 0xda || c == 0xc5) && len(b) >= 3:
+		n, hdr = int(binary.BigEndian.Uint16(b[1:])), 3
+	case (c == 0xdb || c == 0xc6) && len(b) >= 5:
+		n, hdr = int(binary.BigEndian.Uint32(b[1:])), 5
+	default:
+		return "", nil, errMsgpack
+	}
+	if len(b) < hdr+n {
+		return "", nil, errMsgpack
+	}
+	return string(b[hdr : hdr+n]), b[hdr+n:], nil
+}
@@ -0,0 +1,183 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendMsgpack(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0xcc, 0x80}},
+		{256, []byte{0xcd, 0x01, 0x00}},
+		{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{uint64(math.MaxUint64), []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{-1, []byte{0xff}},
+		{-32, []byte{0xe0}},
+		{-33, []byte{0xd0, 0xdf}},
+		{-200, []byte{0xd1, 0xff, 0x38}},
+		{int64(math.MinInt64), []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0, 0}},
+		{"", []byte{0xa0}},
+		{"hi", []byte{0xa2, 'h', 'i'}},
+		{[]byte{1, 2}, []byte{0xc4, 2, 1, 2}},
+		{[]interface{}{1, "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{map[string]interface{}{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{errors.New("x"), []byte{0xa1, 'x'}},
+		{time.Second, []byte{0xa2, '1', 's'}},
+	}
+	for _, tt := range tests {
+		if got := appendMsgpack(nil, tt.in); !bytes.Equal(got, tt.want) {
+			t.Errorf("appendMsgpack(%#v) = % x, want % x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMsgpackHeaders(t *testing.T) {
+	tests := []struct {
+		n    int
+		str  byte
+		arr  byte
+		mapH byte
+	}{
+		{0, 0xa0, 0x90, 0x80},
+		{15, 0xaf, 0x9f, 0x8f},
+		{16, 0xb0, 0xdc, 0xde},
+		{31, 0xbf, 0xdc, 0xde},
+		{32, 0xd9, 0xdc, 0xde},
+		{256, 0xda, 0xdc, 0xde},
+		{70000, 0xdb, 0xdd, 0xdf},
+	}
+	for _, tt := range tests {
+		if got := appendMsgpackString(nil, strings.Repeat("x", tt.n))[0]; got != tt.str {
+			t.Errorf("string of %d: header %#x, want %#x", tt.n, got, tt.str)
+		}
+		if got := appendMsgpackArrayHeader(nil, tt.n)[0]; got != tt.arr {
+			t.Errorf("array of %d: header %#x, want %#x", tt.n, got, tt.arr)
+		}
+		if got := appendMsgpackMapHeader(nil, tt.n)[0]; got != tt.mapH {
+			t.Errorf("map of %d: header %#x, want %#x", tt.n, got, tt.mapH)
+		}
+	}
+}
+
+func TestDecodeMsgpackStringMap(t *testing.T) {
+	long := strings.Repeat("v", 300)
+	b := appendMsgpack(nil, map[string]interface{}{"ack": "abc", "long": long})
+	m, err := decodeMsgpackStringMap(b)
+	if err != nil || m["ack"] != "abc" || m["long"] != long {
+		t.Fatalf("got %v, %v", m, err)
+	}
+
+	bad := [][]byte{
+		nil,
+		{0x91, 0xa0},
+		{0x81, 0xa3, 'a'},
+		{0x81, 0xa1, 'a'},
+		{0x81, 0xa1, 'a', 0x01},
+	}
+	for _, b := range bad {
+		if _, err := decodeMsgpackStringMap(b); err == nil {
+			t.Errorf("decoded % x", b)
+		}
+	}
+}
+
+func TestMsgpackTime(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want []byte
+	}{
+		{time.Unix(1, 0), []byte{0xd6, 0xff, 0, 0, 0, 1}},
+		{time.Unix(1, 1), []byte{0xd7, 0xff, 0, 0, 0, 0x04, 0, 0, 0, 1}},
+		{time.Unix(-1, 0), []byte{0xc7, 12, 0xff, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		b := appendMsgpackTime(nil, tt.t)
+		if !bytes.Equal(b, tt.want) {
+			t.Errorf("appendMsgpackTime(%v) = % x, want % x", tt.t, b, tt.want)
+		}
+		if v, rest, err := msgpackValue(b, 0); err != nil || len(rest) > 0 || !v.(time.Time).Equal(tt.t) {
+			t.Errorf("decoded %v, %v", v, err)
+		}
+	}
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	e := &Entry{Time: testTime.Add(123 * time.Microsecond), Level: Warn, Name: "app", Message: "disk low",
+		Fields: []Field{Int("free", 3), Bool("ok", true), Group("req", String("id", "r1"), Float("ms", 1.5)), Any("tags", []string{"a", "b"})}}
+	var buf bytes.Buffer
+	if err := (MsgpackEncoder{}).Encode(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	var js bytes.Buffer
+	(JSONEncoder{}).Encode(&js, e)
+	if buf.Len() >= js.Len() {
+		t.Errorf("MessagePack %d bytes, JSON %d", buf.Len(), js.Len())
+	}
+	buf.WriteString("next")
+
+	got, n, err := DecodeMsgpack(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != buf.Len()-4 {
+		t.Errorf("took %d of %d bytes", n, buf.Len()-4)
+	}
+	if !got.Time.Equal(e.Time) || got.Level != Warn || got.Name != "app" || got.Message != "disk low" {
+		t.Errorf("decoded %+v", got)
+	}
+	var want, back bytes.Buffer
+	(LogfmtEncoder{}).Encode(&want, e)
+	(LogfmtEncoder{}).Encode(&back, got)
+	if back.String() != want.String() {
+		t.Errorf("fields:\n got %s\nwant %s", back.String(), want.String())
+	}
+}
+
+func TestDecodeMsgpack(t *testing.T) {
+	var buf bytes.Buffer
+	(MsgpackEncoder{}).Encode(&buf, &Entry{Time: testTime, Level: Info, Message: "m"})
+	whole := buf.Bytes()
+	for i := 0; i < len(whole); i++ {
+		if _, n, err := DecodeMsgpack(whole[:i]); !errors.Is(err, io.ErrUnexpectedEOF) || n != 0 {
+			t.Errorf("%d of %d bytes: %d, %v", i, len(whole), n, err)
+		}
+	}
+
+	notEntries := [][]byte{
+		appendMsgpack(nil, "m"),
+		appendMsgpack(nil, map[string]interface{}{"msg": "no time"}),
+		appendMsgpack(nil, map[string]interface{}{"time": "2024-03-01T12:30:45Z", "msg": "string time"}),
+	}
+	for _, b := range notEntries {
+		if _, n, err := DecodeMsgpack(b); err == nil || n != len(b) {
+			t.Errorf("% x: %d, %v", b, n, err)
+		}
+	}
+	if _, n, err := DecodeMsgpack([]byte{0xc1}); err == nil || errors.Is(err, io.ErrUnexpectedEOF) || n != 0 {
+		t.Errorf("never used type: %d, %v", n, err)
+	}
+	deep := append(bytes.Repeat([]byte{0x91}, msgpackMaxDepth+2), 0xc0)
+	if _, _, err := DecodeMsgpack(deep); err == nil {
+		t.Error("decoded nesting beyond the limit")
+	}
+	if _, _, err := DecodeMsgpack([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("huge array count: %v", err)
+	}
+}
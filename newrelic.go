@@ -0,0 +1,137 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const (
+	// DefaultNewRelicURL is the US endpoint of the New Relic Log API; EU
+	// accounts use https://log-api.eu.newrelic.com/log/v1.
+	DefaultNewRelicURL = "https://log-api.newrelic.com/log/v1"
+
+	newRelicMaxBatch = 1000
+)
+
+// NewRelicConfig configures a NewRelicSender.
+type NewRelicConfig struct {
+	// LicenseKey authenticates with the Log API.
+	LicenseKey string
+	// URL is the Log API endpoint; empty selects DefaultNewRelicURL.
+	URL string
+	// EntityGUID and EntityName link the logs to an APM entity; an
+	// entity.guid field overrides the GUID per entry.
+	EntityGUID string
+	EntityName string
+	// Host defaults to os.Hostname.
+	Host string
+	// Attributes are common to every entry, e.g. an environment.
+	Attributes map[string]interface{}
+	// Compression, if set, compresses request bodies, e.g. Gzip.
+	Compression Compressor
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// NewRelicSender is a BatchSender posting entries to the New Relic Log API.
+// The trace_id and parent_id fields are sent as trace.id and span.id, so
+// New Relic links the logs in context of the traces.
+type NewRelicSender struct {
+	cfg    NewRelicConfig
+	common map[string]interface{}
+}
+
+// NewNewRelicSender creates a NewRelicSender.
+func NewNewRelicSender(cfg NewRelicConfig) *NewRelicSender {
+	if cfg.URL == "" {
+		cfg.URL = DefaultNewRelicURL
+	}
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	common := make(map[string]interface{}, len(cfg.Attributes)+3)
+	for k, v := range cfg.Attributes {
+		common[k] = v
+	}
+	for k, v := range map[string]string{"hostname": cfg.Host, "entity.guid": cfg.EntityGUID, "entity.name": cfg.EntityName} {
+		if v != "" {
+			common[k] = v
+		}
+	}
+	return &NewRelicSender{cfg: cfg, common: common}
+}
+
+// newRelicLog is one entry of a Log API request.
+type newRelicLog struct {
+	Timestamp  int64                  `json:"timestamp"`
+	Message    string                 `json:"message"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// newRelicPayload is the body of a Log API request.
+type newRelicPayload struct {
+	Common struct {
+		Attributes map[string]interface{} `json:"attributes,omitempty"`
+	} `json:"common"`
+	Logs []newRelicLog `json:"logs"`
+}
+
+// SendBatch implements BatchSender.
+func (s *NewRelicSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	for start := 0; start < len(batch); start += newRelicMaxBatch {
+		end := start + newRelicMaxBatch
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := s.send(ctx, batch[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NewRelicSender) send(ctx context.Context, batch []*Entry) error {
+	var p newRelicPayload
+	p.Common.Attributes = s.common
+	p.Logs = make([]newRelicLog, 0, len(batch))
+	for _, e := range batch {
+		attrs := e.FieldMap()
+		for k, to := range map[string]string{TraceIDKey: "trace.id", ParentIDKey: "span.id"} {
+			if v, ok := attrs[k]; ok {
+				attrs[to] = v
+				delete(attrs, k)
+			}
+		}
+		if e.Name != "" {
+			attrs["logger.name"] = e.Name
+		}
+		attrs["level"] = datadogStatus(e.Level)
+		p.Logs = append(p.Logs, newRelicLog{
+			Timestamp:  e.Time.UnixMilli(),
+			Message:    e.Message,
+			Attributes: attrs,
+		})
+	}
+
+	body, err := json.Marshal([]newRelicPayload{p})
+	if err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+	req, err := newPostRequest(ctx, s.cfg.URL, body, s.cfg.Compression)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.LicenseKey != "" {
+		req.Header.Set("X-License-Key", s.cfg.LicenseKey)
+	}
+	return doHTTP(s.cfg.Client, req)
+}
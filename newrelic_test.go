@@ -0,0 +1,45 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewRelicSender(t *testing.T) {
+	var got []newRelicPayload
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("X-License-Key")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := NewNewRelicSender(NewRelicConfig{LicenseKey: "lic", URL: srv.URL, EntityGUID: "guid1", Host: "h1"})
+	batch := []*Entry{{
+		Time: testTime, Level: Error, Name: "api", Message: "failed",
+		Fields: []Field{String(TraceIDKey, "t1"), String(ParentIDKey, "s1"), Int("status", 500)},
+	}}
+	if err := s.SendBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if key != "lic" || len(got) != 1 || len(got[0].Logs) != 1 {
+		t.Fatalf("key %q, payload %+v", key, got)
+	}
+	common := got[0].Common.Attributes
+	if common["entity.guid"] != "guid1" || common["hostname"] != "h1" {
+		t.Errorf("common = %v", common)
+	}
+	log := got[0].Logs[0]
+	if log.Timestamp != testTime.UnixMilli() || log.Message != "failed" || log.Attributes["trace.id"] != "t1" ||
+		log.Attributes["span.id"] != "s1" || log.Attributes["level"] != "error" || log.Attributes["status"] != 500.0 {
+		t.Errorf("log = %+v", log)
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultOffloadThreshold is the field size from which WithOffload
+	// writes a field to a side file.
+	DefaultOffloadThreshold = 64 << 10
+
+	OffloadErrFmt = "Failed to offload field %s: %w"
+)
+
+// OffloadConfig configures WithOffload.
+type OffloadConfig struct {
+	// Dir holds the side files; it is created if need be.
+	Dir string
+	// Threshold is the size in bytes from which a field is offloaded;
+	// zero selects DefaultOffloadThreshold.
+	Threshold int
+	// Keys limits offloading to the fields of these keys; nil considers
+	// every string and []byte field.
+	Keys []string
+}
+
+// WithOffload writes string and []byte fields larger than the threshold,
+// e.g. request body dumps, to side files in a directory and logs a
+// reference in their place: a group with the path under "file", the
+// SHA-256 of the content under "sha256" and its size under "size". The log
+// stream stays lean while the data is kept. Files are named by their hash,
+// so repeated content is stored once. The file is written during the log
+// call; if writing fails the field is logged as it is and the error goes
+// to the error handler.
+func WithOffload(cfg OffloadConfig) Option {
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultOffloadThreshold
+	}
+	var keys map[string]bool
+	if cfg.Keys != nil {
+		keys = make(map[string]bool, len(cfg.Keys))
+		for _, k := range cfg.Keys {
+			keys[k] = true
+		}
+	}
+	return func(l *CustomLogger) {
+		l.processors = append(l.processors, ProcessorFunc(func(e *Entry) {
+			for i, f := range e.Fields {
+				if keys != nil && !keys[f.Key] {
+					continue
+				}
+				var b []byte
+				switch {
+				case f.Type == StringType && len(f.String) >= cfg.Threshold:
+					b = []byte(f.String)
+				case f.Type == AnyType:
+					if v, ok := f.Value.([]byte); ok && len(v) >= cfg.Threshold {
+						b = v
+					}
+				}
+				if b == nil {
+					continue
+				}
+				ref, err := offload(cfg.Dir, f.Key, b)
+				if err != nil {
+					l.errorHandler(err)
+					continue
+				}
+				e.Fields[i] = ref
+			}
+		}))
+	}
+}
+
+// offload writes b to its side file in dir and returns the reference
+// field.
+func offload(dir, key string, b []byte) (Field, error) {
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+	path := filepath.Join(dir, hash+".blob")
+	if _, err := os.Stat(path); err != nil {
+		if err := os.MkdirAll(dir, DirModeRWX); err != nil {
+			return Field{}, fmt.Errorf(OffloadErrFmt, key, err)
+		}
+		// Writing to a temporary file first never leaves a partial blob
+		// under the name of its hash.
+		tmp, err := os.CreateTemp(dir, hash+".*.tmp")
+		if err != nil {
+			return Field{}, fmt.Errorf(OffloadErrFmt, key, err)
+		}
+		_, err = tmp.Write(b)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return Field{}, fmt.Errorf(OffloadErrFmt, key, err)
+		}
+	}
+	return Group(key, String("file", path), String("sha256", hash), Int("size", len(b))), nil
+}
@@ -0,0 +1,60 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithOffload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blobs")
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(JSONEncoder{}), WithOffload(OffloadConfig{Dir: dir, Threshold: 16}))
+	body := strings.Repeat("x", 32)
+	l.Log(Info, "dump", String("body", body), Any("raw", []byte(body)), String("small", "tiny"))
+
+	e, err := DecodeJSON(bytes.TrimSpace(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	hash := hex.EncodeToString(sum[:])
+	for _, k := range []string{"body", "raw"} {
+		path, _ := lookupField(e.Fields, k+".file")
+		if h, _ := lookupField(e.Fields, k+".sha256"); h != hash {
+			t.Errorf("%s.sha256 = %v", k, h)
+		}
+		if n, _ := lookupField(e.Fields, k+".size"); n != int64(32) {
+			t.Errorf("%s.size = %v", k, n)
+		}
+		b, err := os.ReadFile(path.(string))
+		if err != nil || string(b) != body {
+			t.Errorf("%s: side file %q (%v)", k, b, err)
+		}
+	}
+	if v := e.FieldMap()["small"]; v != "tiny" {
+		t.Errorf("small = %v", v)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got %d side files, want 1", len(files))
+	}
+}
+
+func TestWithOffloadError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+	var buf bytes.Buffer
+	var errs []error
+	l := newTestLogger(t, Info, &buf, WithOffload(OffloadConfig{Dir: filepath.Join(file, "blobs"), Threshold: 4}),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	l.Log(Info, "dump", String("body", "kept in line"))
+	if !strings.Contains(buf.String(), `body="kept in line"`) || len(errs) != 1 {
+		t.Errorf("output = %q, errors = %v", buf.String(), errs)
+	}
+}
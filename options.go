@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"os"
+	"time"
+)
+
+// Option configures a CustomLogger.
+type Option func(*CustomLogger)
+
+// WithProcessors adds processors that run, in order, on every entry.
+func WithProcessors(processors ...Processor) Option {
+	return func(l *CustomLogger) {
+		l.processors = append(l.processors, processors...)
+	}
+}
+
+// WithSinks adds sinks that receive every entry in addition to the default
+// file or stdout output.
+func WithSinks(sinks ...Sink) Option {
+	return func(l *CustomLogger) {
+		l.sinks = append(l.sinks, sinks...)
+	}
+}
+
+// WithErrorHandler sets the callback invoked when encoding or a sink write
+// fails. A nil handler discards the errors.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(l *CustomLogger) {
+		if h == nil {
+			h = func(error) {}
+		}
+		l.errorHandler = h
+	}
+}
+
+// WithAsync makes every sink of the logger asynchronous, see AsyncSink. With
+// a policy other than BlockWhenFull logging never blocks the caller.
+func WithAsync(cfg AsyncConfig) Option {
+	return func(l *CustomLogger) {
+		l.async = &cfg
+	}
+}
+
+// WithBufferedOutput coalesces writes to the log file or stdout into larger
+// writes, see BufferedWriter.
+func WithBufferedOutput(cfg BufferConfig) Option {
+	return func(l *CustomLogger) {
+		l.buffering = &cfg
+	}
+}
+
+// WithFlightRecorder keeps low-level entries in memory and writes them only
+// when an error is logged, see FlightRecorder. The logger level must allow
+// the entries to be recorded, e.g. Debug.
+func WithFlightRecorder(cfg RecorderConfig) Option {
+	return func(l *CustomLogger) {
+		l.recorder = &cfg
+	}
+}
+
+// WithSyncPolicy sets how often the log file is fsynced, trading throughput
+// for durability.
+func WithSyncPolicy(p SyncPolicy) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Sync = p
+	}
+}
+
+// WithDiskGuard monitors free space on the log file's filesystem and
+// degrades logging while it is below the threshold instead of filling the
+// disk.
+func WithDiskGuard(cfg DiskGuardConfig) Option {
+	return func(l *CustomLogger) {
+		l.diskGuardCfg = &cfg
+	}
+}
+
+// WithRotation rotates the log file by size or age, naming the archives
+// after cfg.ArchiveTemplate.
+func WithRotation(cfg RotationConfig) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Rotation = cfg
+	}
+}
+
+// WithReopenCheck makes the log file check at most once per interval that
+// it still exists at its path, recreating it after an external rm or mv.
+func WithReopenCheck(interval time.Duration) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.ReopenCheck = interval
+	}
+}
+
+// WithFileLock locks the log file around every write, for worker processes
+// appending to and rotating the same file, see FileConfig.Lock.
+func WithFileLock() Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Lock = true
+	}
+}
+
+// WithFileMode sets the permission of a newly created log file, e.g. 0640.
+func WithFileMode(mode os.FileMode) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Mode = mode
+	}
+}
+
+// WithCreateDirs creates missing parent directories of the log path with
+// the given permission; zero selects DirModeRWX.
+func WithCreateDirs(dirMode os.FileMode) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.CreateDirs = true
+		l.fileConfig.DirMode = dirMode
+	}
+}
+
+// WithArchive compresses and uploads rotated log files, see ArchiveConfig.
+// It has no effect without WithRotation.
+func WithArchive(cfg ArchiveConfig) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.Archive = cfg
+	}
+}
+
+// WithEncryption encrypts the log file at rest with AES-GCM using a 16, 24
+// or 32 byte key. Read it back with DecryptTo or cmd/logdecrypt.
+func WithEncryption(key []byte) Option {
+	return func(l *CustomLogger) {
+		l.fileConfig.EncryptionKey = key
+	}
+}
+
+// WithAuditChain makes the log file tamper-evident by hash chaining every
+// line, see HashChainWriter. An existing file's chain is continued.
+func WithAuditChain(cfg ChainConfig) Option {
+	return func(l *CustomLogger) {
+		l.chain = &cfg
+	}
+}
+
+// Clock returns the current time for entry timestamps.
+type Clock func() time.Time
+
+// WithClock sets the time source for entry timestamps, e.g. a fixed or
+// simulated clock in tests. A nil clock selects time.Now.
+func WithClock(c Clock) Option {
+	return func(l *CustomLogger) {
+		if c == nil {
+			c = time.Now
+		}
+		l.clock = c
+	}
+}
+
+// WithGoroutineID tags every entry with the calling goroutine's ID, see
+// GoroutineID.
+func WithGoroutineID() Option {
+	return WithProcessors(GoroutineID())
+}
+
+// WithVerbosity sets the initial verbosity for CustomLogger.V.
+func WithVerbosity(n int) Option {
+	return func(l *CustomLogger) {
+		l.levels.verbosity.Store(int32(n))
+	}
+}
+
+// WithLevelPrefixes overrides the level prefixes of the default output,
+// see TextEncoder.Prefixes.
+func WithLevelPrefixes(prefixes map[LogLevel]string) Option {
+	return func(l *CustomLogger) {
+		l.text.Prefixes = prefixes
+	}
+}
+
+// WithColors colors the level prefixes of the default output, typically
+// when it is a terminal. A nil map selects DefaultColors.
+func WithColors(colors map[LogLevel]string) Option {
+	return func(l *CustomLogger) {
+		if colors == nil {
+			colors = DefaultColors
+		}
+		l.text.Colors = colors
+	}
+}
+
+// WithEncoder sets the encoder of the default output, such as JSONEncoder
+// for machine-read files. WithLevelPrefixes and WithColors only apply to
+// the default text encoder.
+func WithEncoder(enc Encoder) Option {
+	return func(l *CustomLogger) {
+		l.encoder = enc
+	}
+}
+
+// WithOutputLevel sets a minimum level for the default output on top of
+// the logger level, so it can be quieter than the sinks added by
+// WithSinks; wrap those in a LevelSink to give them levels of their own.
+func WithOutputLevel(level LogLevel) Option {
+	return func(l *CustomLogger) {
+		l.outputLevel = &level
+	}
+}
+
+// WithOutputFilter writes only the entries selected by match to the
+// default output, e.g. to leave errors to an output of their own.
+func WithOutputFilter(match Matcher) Option {
+	return func(l *CustomLogger) {
+		l.outputFilter = match
+	}
+}
+
+// WithContextFields adds extractors whose fields are attached to entries
+// written through LogContext and Ctx, so IDs carried by a context reach
+// the logs without being passed by hand.
+func WithContextFields(extractors ...ContextExtractor) Option {
+	return func(l *CustomLogger) {
+		l.extractors = append(l.extractors, extractors...)
+	}
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"sort"
+)
+
+// FieldOrder selects the order JSONEncoder and LogfmtEncoder write fields
+// in. The built-in keys always come first.
+type FieldOrder int
+
+const (
+	// DefaultOrder is the order of the encoder: JSON sorts the fields by
+	// key and logfmt writes them in insertion order.
+	DefaultOrder FieldOrder = iota
+	// SortedOrder writes the fields sorted by key, group members sorted
+	// within their group.
+	SortedOrder
+	// InsertionOrder writes the fields in the order they were added, with
+	// JSON placing a duplicate key where it last occurs.
+	InsertionOrder
+)
+
+// sortedFields returns a copy of fields sorted by key, recursively; fields
+// of the same key keep their order.
+func sortedFields(fields []Field) []Field {
+	sorted := append([]Field(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	for i, f := range sorted {
+		if f.Type == GroupType {
+			members, _ := f.Value.([]Field)
+			sorted[i].Value = sortedFields(members)
+		}
+	}
+	return sorted
+}
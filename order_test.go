@@ -0,0 +1,33 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFieldOrder(t *testing.T) {
+	e := &Entry{Time: testTime, Level: Info, Message: "m", Fields: []Field{
+		String("zone", "a<b"), Int("id", 1), Group("req", Int("size", 2), String("path", "/")),
+		Any("err", errors.New("boom")), Int("id", 3),
+	}}
+	for _, tt := range []struct {
+		enc  Encoder
+		want string
+	}{
+		{JSONEncoder{}, `"fields":{"err":"boom","id":3,"req":{"path":"/","size":2},"zone":"a<b"}}`},
+		{JSONEncoder{Order: InsertionOrder}, `"fields":{"zone":"a<b","req":{"size":2,"path":"/"},"err":"boom","id":3}}`},
+		{LogfmtEncoder{}, `msg=m zone=a<b id=1 req.size=2 req.path=/ err=boom id=3`},
+		{LogfmtEncoder{Order: SortedOrder}, `msg=m err=boom id=1 id=3 req.path=/ req.size=2 zone=a<b`},
+	} {
+		var buf bytes.Buffer
+		if err := tt.enc.Encode(&buf, e); err != nil {
+			t.Fatal(err)
+		}
+		if got := bytes.TrimSpace(buf.Bytes()); !bytes.HasSuffix(got, []byte(tt.want)) {
+			t.Errorf("%T%+v: got %s, want suffix %s", tt.enc, tt.enc, got, tt.want)
+		}
+	}
+}
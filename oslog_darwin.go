@@ -0,0 +1,103 @@
+//go:build darwin && cgo
+
+package logger
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+// os_log_with_type is a macro, so it is wrapped for cgo. The message is
+// public: entries are logged to be read.
+static void logger_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// DefaultOSLogCategory is the category of entries without a logger name.
+const DefaultOSLogCategory = "default"
+
+// OSLogConfig configures an OSLogSink.
+type OSLogConfig struct {
+	// Subsystem identifies the program in reverse DNS notation, e.g.
+	// "com.example.agent", for filtering in Console.app and log(1).
+	Subsystem string
+	// Category returns the category of an entry; nil uses the logger name,
+	// or DefaultOSLogCategory for entries without one.
+	Category func(e *Entry) string
+}
+
+// OSLogSink writes entries to Apple's unified logging system, so daemons
+// on macOS show up in Console.app and `log stream --subsystem`. The time,
+// process and level are recorded by os_log itself, so only the message and
+// fields are written. Debug maps to the debug type, Info to info, Notice
+// and Warn to default, Error to error and Critical and above to fault.
+// It needs cgo.
+type OSLogSink struct {
+	cfg OSLogConfig
+
+	mu   sync.Mutex
+	logs map[string]C.os_log_t
+}
+
+// NewOSLogSink creates an OSLogSink.
+func NewOSLogSink(cfg OSLogConfig) *OSLogSink {
+	if cfg.Category == nil {
+		cfg.Category = func(e *Entry) string {
+			if e.Name == "" {
+				return DefaultOSLogCategory
+			}
+			return e.Name
+		}
+	}
+	return &OSLogSink{cfg: cfg, logs: make(map[string]C.os_log_t)}
+}
+
+// WriteEntry implements Sink.
+func (s *OSLogSink) WriteEntry(e *Entry) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	buf.WriteString(e.Message)
+	appendFields(buf, e.Fields)
+	buf.WriteByte(0)
+
+	log := s.log(s.cfg.Category(e))
+	C.logger_os_log(log, osLogType(e.Level), (*C.char)(unsafe.Pointer(&buf.Bytes()[0])))
+	return nil
+}
+
+// log returns the handle of a category, created once: handles are meant
+// to be kept for the life of the process.
+func (s *OSLogSink) log(category string) C.os_log_t {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if log, ok := s.logs[category]; ok {
+		return log
+	}
+	sub, cat := C.CString(s.cfg.Subsystem), C.CString(category)
+	defer C.free(unsafe.Pointer(sub))
+	defer C.free(unsafe.Pointer(cat))
+	log := C.os_log_create(sub, cat)
+	s.logs[category] = log
+	return log
+}
+
+// osLogType maps a level to an os_log type.
+func osLogType(level LogLevel) C.os_log_type_t {
+	switch {
+	case level <= Debug:
+		return C.OS_LOG_TYPE_DEBUG
+	case level < Notice:
+		return C.OS_LOG_TYPE_INFO
+	case level < Error:
+		return C.OS_LOG_TYPE_DEFAULT
+	case level < Critical:
+		return C.OS_LOG_TYPE_ERROR
+	}
+	return C.OS_LOG_TYPE_FAULT
+}
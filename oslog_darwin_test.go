@@ -0,0 +1,24 @@
+//go:build darwin && cgo
+
+package logger
+
+import "testing"
+
+func TestOSLogSink(t *testing.T) {
+	s := NewOSLogSink(OSLogConfig{Subsystem: "com.peter-bird.logger.test"})
+	for _, e := range []*Entry{
+		{Level: Debug, Message: "probe"},
+		{Level: Warn, Name: "app.db", Message: "slow", Fields: []Field{Int("ms", 250)}},
+		{Level: Emergency, Message: "down"},
+	} {
+		if err := s.WriteEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(s.logs) != 2 {
+		t.Errorf("%d categories, want 2", len(s.logs))
+	}
+	if osLogType(Notice) != osLogType(Warn) || osLogType(Critical) == osLogType(Error) {
+		t.Error("unexpected level mapping")
+	}
+}
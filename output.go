@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Output configures an output of its own, see WithOutput.
+type Output struct {
+	// Path is a log file, opened with File; empty writes to Writer.
+	Path string
+	// File configures the log file at Path.
+	File FileConfig
+	// Writer receives the entries when Path is empty; nil selects
+	// os.Stdout.
+	Writer io.Writer
+	// Encoder encodes the entries; nil selects a TextEncoder.
+	Encoder Encoder
+	// Level is the minimum level written, see LevelSink; zero writes every
+	// entry the logger does.
+	Level LogLevel
+}
+
+// WithOutput adds an output with its own encoder and level next to the
+// default one, e.g. colored text on the console, JSON in a file and GELF
+// to a writer, all from the same logger. Files opened for an output are
+// closed with the logger.
+func WithOutput(o Output) Option {
+	return func(l *CustomLogger) {
+		l.outputs = append(l.outputs, o)
+	}
+}
+
+// openOutput opens the sink of an output.
+func (l *CustomLogger) openOutput(o Output) (Sink, error) {
+	enc := o.Encoder
+	if enc == nil {
+		enc = TextEncoder{}
+	}
+	var s Sink
+	switch {
+	case o.Path != "":
+		cfg := o.File
+		if cfg.ErrorHandler == nil {
+			cfg.ErrorHandler = l.errorHandler
+		}
+		f, err := OpenFile(o.Path, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s = &fileSink{WriterSink: NewWriterSink(f, enc), file: f}
+	case o.Writer != nil:
+		s = NewWriterSink(o.Writer, enc)
+	default:
+		s = NewWriterSink(os.Stdout, enc)
+	}
+	if o.Level != 0 {
+		s = NewLevelSink(s, o.Level)
+	}
+	return s, nil
+}
+
+// fileSink is a WriterSink owning the log file it writes to.
+type fileSink struct {
+	*WriterSink
+	file *File
+}
+
+// Flush syncs the file to stable storage, as CustomLogger.Flush does for
+// the default output.
+func (s *fileSink) Flush() error {
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *fileSink) Close() error {
+	return errors.Join(s.WriterSink.Flush(), s.file.Close())
+}
+
+// Stats implements StatsReporter with the counters of the file.
+func (s *fileSink) Stats() Stats {
+	return s.file.Stats()
+}
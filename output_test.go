@@ -0,0 +1,50 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	var console, graylog bytes.Buffer
+	l := newTestLogger(t, Debug, io.Discard,
+		WithOutput(Output{Writer: &console, Encoder: TextEncoder{Colors: DefaultColors}}),
+		WithOutput(Output{Path: path, Encoder: JSONEncoder{}, Level: Info}),
+		WithOutput(Output{Writer: &graylog, Encoder: NewGELFEncoder("web-1"), Level: Warn}),
+	)
+	l.Debug("probe")
+	l.Warn("disk low")
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := console.String(); !strings.Contains(s, DefaultColors[Debug]) || strings.Count(s, "\n") != 2 {
+		t.Errorf("console = %q", s)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := DecodeJSON(bytes.TrimSpace(b))
+	if err != nil || e.Message != "disk low" {
+		t.Errorf("file = %q (%v)", b, err)
+	}
+	if s := graylog.String(); !strings.Contains(s, `"host":"web-1"`) || strings.Contains(s, "probe") {
+		t.Errorf("gelf = %q", s)
+	}
+}
+
+func TestWithOutputOpenError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "app.log")
+	if _, err := New(Info, "app", "", WithOutput(Output{Path: path})); err == nil {
+		t.Error("want an error for an output in a missing directory")
+	}
+}
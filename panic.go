@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+const (
+	PanicFmt      = "panic: %v"
+	PanicExitCode = 2
+)
+
+// LogPanic writes the panic value and stack at Error level, regardless of
+// the configured level, and flushes every sink so buffered entries are not
+// lost with the process.
+func (l *CustomLogger) LogPanic(v interface{}, stack []byte) {
+	l.log(Error, fmt.Sprintf(PanicFmt, v), Field{Key: StackKey, Value: string(stack)})
+	if err := l.Flush(); err != nil {
+		l.errorHandler(err)
+	}
+}
+
+// RecoverAndLog logs a panic with its stack and buffered entries and then
+// re-panics. It must be deferred directly:
+//
+//	defer logger.RecoverAndLog(log)
+func RecoverAndLog(l *CustomLogger) {
+	if v := recover(); v != nil {
+		l.LogPanic(v, debug.Stack())
+		panic(v)
+	}
+}
+
+// RecoverAndExit is like RecoverAndLog but exits the process with
+// PanicExitCode instead of re-panicking.
+func RecoverAndExit(l *CustomLogger) {
+	if v := recover(); v != nil {
+		l.LogPanic(v, debug.Stack())
+		os.Exit(PanicExitCode)
+	}
+}
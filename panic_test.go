@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRecoverAndLog(t *testing.T) {
+	dst := &countSink{keep: true}
+	l, err := New(Emergency, "test", "", WithOutputLevel(Emergency+1), WithSinks(dst), WithAsync(AsyncConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("recovered %v, want the panic passed on", v)
+			}
+		}()
+		defer RecoverAndLog(l)
+		panic("boom")
+	}()
+
+	// The entry is logged below the logger level and flushed out of the
+	// asynchronous queue.
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	if len(dst.entries) != 1 {
+		t.Fatalf("%d entries written", len(dst.entries))
+	}
+	e := dst.entries[0]
+	stack, _ := lookupField(e.Fields, StackKey)
+	if e.Level != Error || e.Message != "panic: boom" || !strings.Contains(stack.(string), "TestRecoverAndLog") {
+		t.Errorf("entry %s %q, stack %q", e.Level, e.Message, stack)
+	}
+}
@@ -0,0 +1,369 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultParquetRowGroup is the number of entries per row group.
+	DefaultParquetRowGroup = 64 << 10
+
+	ParquetWriteErrFmt = "Failed to write Parquet file: %w"
+
+	parquetMagic = "PAR1"
+)
+
+// ErrParquetClosed is returned by a ParquetWriter written to after Close.
+var ErrParquetClosed = errors.New("parquet writer is closed")
+
+// ParquetConfig configures a ParquetWriter.
+type ParquetConfig struct {
+	// RowGroup is the number of entries buffered and written per row
+	// group; zero selects DefaultParquetRowGroup.
+	RowGroup int
+	// Gzip compresses the column pages, at a CPU cost; DuckDB, Athena and
+	// Spark all read it.
+	Gzip bool
+}
+
+// Parquet physical types, repetition and converted types, page types,
+// encodings and codecs of the Parquet format specification.
+const (
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+	parquetJSON            = 19
+
+	parquetDataPage = 0
+	parquetPlain    = 0
+	parquetRLE      = 3
+
+	parquetUncompressed = 0
+	parquetGzip         = 2
+)
+
+// parquetColumn describes a column of the entry schema.
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+}
+
+// parquetColumns is the schema: every column is required, with an empty
+// name and "{}" fields standing in for none, so pages need no levels.
+var parquetColumns = []parquetColumn{
+	{"ts", parquetInt64, parquetTimestampMicros},
+	{"level", parquetByteArray, parquetUTF8},
+	{"name", parquetByteArray, parquetUTF8},
+	{"message", parquetByteArray, parquetUTF8},
+	{"fields", parquetByteArray, parquetJSON},
+}
+
+// parquetChunk is the metadata of a written column chunk.
+type parquetChunk struct {
+	offset, size, compressed int64
+}
+
+// parquetGroup is the metadata of a written row group.
+type parquetGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// ParquetWriter writes entries as a Parquet file with the columns ts
+// (timestamp in microseconds), level, name, message and fields (the
+// fields as a JSON object), so archived logs can be queried in place by
+// DuckDB, Athena or Spark. Entries are buffered per row group; Close
+// writes the last one and the footer, without which the file is
+// unreadable.
+type ParquetWriter struct {
+	w      io.Writer
+	cfg    ParquetConfig
+	off    int64
+	cols   [5]bytes.Buffer // PLAIN encoded values of the pending rows
+	rows   int
+	groups []parquetGroup
+	err    error
+	closed bool
+}
+
+// NewParquetWriter returns a ParquetWriter writing to w. Nothing is
+// written before the first row group fills or Close is called.
+func NewParquetWriter(w io.Writer, cfg ParquetConfig) *ParquetWriter {
+	if cfg.RowGroup <= 0 {
+		cfg.RowGroup = DefaultParquetRowGroup
+	}
+	return &ParquetWriter{w: w, cfg: cfg}
+}
+
+// WriteEntry implements Sink, buffering e in the current row group.
+func (p *ParquetWriter) WriteEntry(e *Entry) error {
+	if p.closed {
+		return ErrParquetClosed
+	}
+	if p.err != nil {
+		return p.err
+	}
+	fields := []byte("{}")
+	if len(e.Fields) > 0 {
+		var err error
+		if fields, err = json.Marshal(e.FieldMap()); err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+	}
+	binary.Write(&p.cols[0], binary.LittleEndian, e.Time.UnixMicro())
+	parquetAppendBytes(&p.cols[1], []byte(e.Level.String()))
+	parquetAppendBytes(&p.cols[2], []byte(e.Name))
+	parquetAppendBytes(&p.cols[3], []byte(e.Message))
+	parquetAppendBytes(&p.cols[4], fields)
+	if p.rows++; p.rows == p.cfg.RowGroup {
+		return p.flushGroup()
+	}
+	return nil
+}
+
+// parquetAppendBytes appends a PLAIN encoded BYTE_ARRAY value.
+func parquetAppendBytes(buf *bytes.Buffer, b []byte) {
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(b)))
+	buf.Write(n[:])
+	buf.Write(b)
+}
+
+// write writes b, keeping the offset and the first error.
+func (p *ParquetWriter) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.off += int64(n)
+	if err != nil {
+		p.err = fmt.Errorf(ParquetWriteErrFmt, err)
+	}
+}
+
+// flushGroup writes the pending rows as a row group of one data page per
+// column.
+func (p *ParquetWriter) flushGroup() error {
+	if p.rows == 0 || p.err != nil {
+		return p.err
+	}
+	if p.off == 0 {
+		p.write([]byte(parquetMagic))
+	}
+	g := parquetGroup{rows: int64(p.rows)}
+	for i := range p.cols {
+		values := p.cols[i].Bytes()
+		page := values
+		if p.cfg.Gzip {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(values)
+			zw.Close()
+			page = buf.Bytes()
+		}
+		var t thriftWriter
+		t.i32(1, parquetDataPage)
+		t.i32(2, int32(len(values)))
+		t.i32(3, int32(len(page)))
+		t.structBegin(5)
+		t.i32(1, int32(p.rows))
+		t.i32(2, parquetPlain)
+		t.i32(3, parquetRLE)
+		t.i32(4, parquetRLE)
+		t.structEnd()
+		t.stop()
+
+		chunk := parquetChunk{offset: p.off}
+		p.write(t.buf.Bytes())
+		p.write(page)
+		chunk.size = int64(t.buf.Len() + len(values))
+		chunk.compressed = int64(t.buf.Len() + len(page))
+		g.chunks = append(g.chunks, chunk)
+		p.cols[i].Reset()
+	}
+	p.groups = append(p.groups, g)
+	p.rows = 0
+	return p.err
+}
+
+// Close writes the pending rows and the footer. It does not close the
+// underlying writer.
+func (p *ParquetWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	if err := p.flushGroup(); err != nil {
+		return err
+	}
+	p.closed = true
+	if p.off == 0 {
+		p.write([]byte(parquetMagic))
+	}
+
+	codec := int32(parquetUncompressed)
+	if p.cfg.Gzip {
+		codec = parquetGzip
+	}
+	var rows int64
+	for _, g := range p.groups {
+		rows += g.rows
+	}
+	var t thriftWriter
+	t.i32(1, 1)
+	t.listBegin(2, thriftStruct, len(parquetColumns)+1)
+	t.elemBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(parquetColumns)))
+	t.structEnd()
+	for _, c := range parquetColumns {
+		t.elemBegin()
+		t.i32(1, c.typ)
+		t.i32(3, parquetRequired)
+		t.binary(4, c.name)
+		t.i32(6, c.converted)
+		t.structEnd()
+	}
+	t.i64(3, rows)
+	t.listBegin(4, thriftStruct, len(p.groups))
+	for _, g := range p.groups {
+		t.elemBegin()
+		t.listBegin(1, thriftStruct, len(g.chunks))
+		var total int64
+		for i, c := range g.chunks {
+			total += c.size
+			t.elemBegin()
+			t.i64(2, c.offset)
+			t.structBegin(3)
+			t.i32(1, parquetColumns[i].typ)
+			t.listBegin(2, thriftI32, 2)
+			t.varint(parquetPlain)
+			t.varint(parquetRLE)
+			t.listBegin(3, thriftBinary, 1)
+			t.rawBinary(parquetColumns[i].name)
+			t.i32(4, codec)
+			t.i64(5, g.rows)
+			t.i64(6, c.size)
+			t.i64(7, c.compressed)
+			t.i64(9, c.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, total)
+		t.i64(3, g.rows)
+		t.structEnd()
+	}
+	t.binary(6, "peter-bird.com/logger")
+	t.stop()
+
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(t.buf.Len()))
+	p.write(t.buf.Bytes())
+	p.write(n[:])
+	p.write([]byte(parquetMagic))
+	return p.err
+}
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol, as far as the
+// Parquet footer and page headers need it.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // per open struct, the id of the last field written
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if len(t.last) == 0 {
+		t.last = append(t.last, 0)
+	}
+	last := &t.last[len(t.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		t.buf.WriteByte(byte(d)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes v zigzag encoded, as i16, i32 and i64 are.
+func (t *thriftWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.rawBinary(s)
+}
+
+// rawBinary writes a string without a field header, as a list element.
+func (t *thriftWriter) rawBinary(s string) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], uint64(len(s)))])
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) listBegin(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elem)
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], uint64(n))])
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+// elemBegin opens a struct that is a list element.
+func (t *thriftWriter) elemBegin() {
+	if len(t.last) == 0 {
+		t.last = append(t.last, 0)
+	}
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+// stop ends the top-level struct.
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
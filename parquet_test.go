@@ -0,0 +1,183 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes the Thrift compact protocol into nested maps of
+// field id to value, enough to check the Parquet metadata written.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) byte() byte {
+	c := r.b[r.pos]
+	r.pos++
+	return c
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.b[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		h := r.byte()
+		n, elem := int(h>>4), h&0x0f
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case thriftStruct:
+		return r.strct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) strct() map[int16]interface{} {
+	m := make(map[int16]interface{})
+	var last int16
+	for {
+		h := r.byte()
+		if h == 0 {
+			return m
+		}
+		typ := h & 0x0f
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.varint())
+		}
+		m[id] = r.value(typ)
+		last = id
+	}
+}
+
+// readParquet returns the footer of a Parquet file and the values of its
+// columns, decoded from the PLAIN pages.
+func readParquet(t *testing.T, b []byte) (map[int16]interface{}, [][]interface{}) {
+	t.Helper()
+	if string(b[:4]) != parquetMagic || string(b[len(b)-4:]) != parquetMagic {
+		t.Fatalf("missing magic")
+	}
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := (&thriftReader{b: b[len(b)-8-n : len(b)-8]}).strct()
+
+	cols := make([][]interface{}, len(parquetColumns))
+	for _, g := range footer[4].([]interface{}) {
+		for i, c := range g.(map[int16]interface{})[1].([]interface{}) {
+			meta := c.(map[int16]interface{})[3].(map[int16]interface{})
+			r := &thriftReader{b: b, pos: int(meta[9].(int64))}
+			page := r.strct()
+			data := b[r.pos : r.pos+int(page[3].(int64))]
+			if meta[4].(int64) == parquetGzip {
+				zr, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if data, err = io.ReadAll(zr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(data) != int(page[2].(int64)) {
+				t.Fatalf("page size %d, header says %d", len(data), page[2])
+			}
+			for len(data) > 0 {
+				if parquetColumns[i].typ == parquetInt64 {
+					cols[i] = append(cols[i], int64(binary.LittleEndian.Uint64(data)))
+					data = data[8:]
+					continue
+				}
+				l := binary.LittleEndian.Uint32(data)
+				cols[i] = append(cols[i], string(data[4:4+l]))
+				data = data[4+l:]
+			}
+		}
+	}
+	return footer, cols
+}
+
+func TestParquetWriter(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		p := NewParquetWriter(&buf, ParquetConfig{RowGroup: 2, Gzip: compress})
+		entries := []*Entry{
+			{Time: testTime, Level: Info, Name: "app", Message: "started"},
+			{Time: testTime.Add(time.Microsecond), Level: Error, Message: "failed", Fields: []Field{Int("code", 7)}},
+			{Time: testTime.Add(time.Second), Level: Warn, Name: "db", Message: "slow"},
+		}
+		for _, e := range entries {
+			if err := p.WriteEntry(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		footer, cols := readParquet(t, buf.Bytes())
+		if footer[3].(int64) != 3 || len(footer[4].([]interface{})) != 2 {
+			t.Errorf("gzip %v: num_rows %v, %d row groups", compress, footer[3], len(footer[4].([]interface{})))
+		}
+		schema := footer[2].([]interface{})
+		if len(schema) != 6 || schema[1].(map[int16]interface{})[4] != "ts" {
+			t.Errorf("gzip %v: schema = %v", compress, schema)
+		}
+		want := [][]interface{}{
+			{testTime.UnixMicro(), testTime.UnixMicro() + 1, testTime.Add(time.Second).UnixMicro()},
+			{"INFO", "ERROR", "WARN"},
+			{"app", "", "db"},
+			{"started", "failed", "slow"},
+			{"{}", `{"code":7}`, "{}"},
+		}
+		for i := range want {
+			for j := range want[i] {
+				if j >= len(cols[i]) || cols[i][j] != want[i][j] {
+					t.Errorf("gzip %v: column %s = %v, want %v", compress, parquetColumns[i].name, cols[i], want[i])
+					break
+				}
+			}
+		}
+		if err := p.WriteEntry(entries[0]); err != ErrParquetClosed {
+			t.Errorf("write after Close = %v", err)
+		}
+	}
+}
+
+func TestParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewParquetWriter(&buf, ParquetConfig{}).Close(); err != nil {
+		t.Fatal(err)
+	}
+	footer, _ := readParquet(t, buf.Bytes())
+	if footer[3].(int64) != 0 {
+		t.Errorf("num_rows = %v", footer[3])
+	}
+}
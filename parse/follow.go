@@ -0,0 +1,228 @@
+//go:build !logger_minimal
+
+package parse
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+// DefaultPollInterval is how often a followed file is checked for new
+// data, rotation and truncation.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// FollowConfig configures Follow.
+type FollowConfig struct {
+	Config
+	// Poll is the interval of the checks for new data; zero selects
+	// DefaultPollInterval.
+	Poll time.Duration
+	// FromStart reads the file from its beginning instead of from its
+	// current end.
+	FromStart bool
+	// Offset, if set, starts reading the file there instead, as saved
+	// from Position before a restart; a file shorter than Offset is read
+	// from its beginning.
+	Offset int64
+}
+
+// Position is the place in the followed files up to which entries were
+// returned, see Follower.Position.
+type Position struct {
+	// File is the file read, which may no longer be at the followed path
+	// after a rotation.
+	File os.FileInfo
+	// Offset is the byte offset in File.
+	Offset int64
+}
+
+// Follower yields the entries appended to a file, see Follow.
+type Follower struct {
+	r *Reader
+	t *tailer
+}
+
+// Follow watches the file at path like tail -F and yields the entries
+// written to it from now on:
+//
+//	f := parse.Follow(ctx, "/var/log/app.log", parse.FollowConfig{})
+//	defer f.Close()
+//	for {
+//		e, err := f.Next()
+//		if err != nil {
+//			return err // ctx.Err() once ctx is done
+//		}
+//		...
+//	}
+//
+// The file need not exist yet. When it is replaced, as rotation by rename
+// does, the rest of the old file is read before the new one is followed
+// from its start; when it is truncated in place, as copytruncate does, it
+// is read again from the start.
+func Follow(ctx context.Context, path string, cfg FollowConfig) *Follower {
+	if cfg.Poll <= 0 {
+		cfg.Poll = DefaultPollInterval
+	}
+	t := &tailer{ctx: ctx, path: path, poll: cfg.Poll, fromStart: cfg.FromStart, offset: cfg.Offset}
+	return &Follower{r: NewReader(t, cfg.Config), t: t}
+}
+
+// Next blocks until the next entry is written and returns it, or returns
+// the error of ctx once it is done. An entry is handed out as soon as the
+// writer pauses after it, without waiting for the line that follows.
+func (f *Follower) Next() (*logger.Entry, error) {
+	return f.r.Next()
+}
+
+// Skipped returns the number of lines skipped so far, see Reader.Skipped.
+func (f *Follower) Skipped() int {
+	return f.r.Skipped()
+}
+
+// Position returns where the entries returned so far end, for a checkpoint
+// to resume from with FollowConfig.Offset. File is nil before the file was
+// opened.
+func (f *Follower) Position() Position {
+	return f.t.position(f.r.Offset())
+}
+
+// Close closes the followed file.
+func (f *Follower) Close() error {
+	if f.t.f != nil {
+		return f.t.f.Close()
+	}
+	return nil
+}
+
+// tailer reads a file across rotations, returning errIdle once each time
+// it runs out of data before waiting for more.
+type tailer struct {
+	ctx       context.Context
+	path      string
+	poll      time.Duration
+	fromStart bool
+	offset    int64
+	f         *os.File
+	fi        os.FileInfo
+	idle      bool
+	// pos counts the bytes returned across files and segments records
+	// where in them each file opened or truncated was entered.
+	pos      int64
+	segments []segment
+}
+
+type segment struct {
+	pos int64
+	off int64
+	fi  os.FileInfo
+}
+
+func (t *tailer) Read(p []byte) (int, error) {
+	for {
+		if err := t.ctx.Err(); err != nil {
+			return 0, err
+		}
+		if t.f == nil && !t.open() {
+			if err := t.wait(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		n, err := t.f.Read(p)
+		if n > 0 {
+			t.idle = false
+			t.pos += int64(n)
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if t.rotated() {
+			continue
+		}
+		if !t.idle {
+			t.idle = true
+			return 0, errIdle
+		}
+		if err := t.wait(); err != nil {
+			return 0, err
+		}
+		t.idle = false
+	}
+}
+
+// open opens the file, at offset or its end the first time unless
+// fromStart is set; files appearing later are read from the start.
+func (t *tailer) open() bool {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return false
+	}
+	var off int64
+	switch {
+	case t.offset > 0:
+		if t.offset <= fi.Size() {
+			off, _ = f.Seek(t.offset, io.SeekStart)
+		}
+	case !t.fromStart:
+		off, _ = f.Seek(0, io.SeekEnd)
+	}
+	t.f, t.fi, t.fromStart, t.offset = f, fi, true, 0
+	t.segments = append(t.segments, segment{pos: t.pos, off: off, fi: fi})
+	return true
+}
+
+// rotated checks, at the end of the open file, whether the path now names
+// another file or the file shrank, and prepares reading it.
+func (t *tailer) rotated() bool {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		return false
+	}
+	if !os.SameFile(fi, t.fi) {
+		t.f.Close()
+		t.f = nil
+		return true
+	}
+	if pos, err := t.f.Seek(0, io.SeekCurrent); err == nil && fi.Size() < pos {
+		t.f.Seek(0, io.SeekStart)
+		t.segments = append(t.segments, segment{pos: t.pos, fi: t.fi})
+		return true
+	}
+	return false
+}
+
+// position maps pos, a count of the bytes returned, to the file and offset
+// they came from, forgetting the segments before.
+func (t *tailer) position(pos int64) Position {
+	i := len(t.segments) - 1
+	for i > 0 && t.segments[i].pos > pos {
+		i--
+	}
+	if i < 0 {
+		return Position{}
+	}
+	t.segments = t.segments[i:]
+	s := t.segments[0]
+	return Position{File: s.fi, Offset: s.off + pos - s.pos}
+}
+
+func (t *tailer) wait() error {
+	timer := time.NewTimer(t.poll)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
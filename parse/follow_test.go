@@ -0,0 +1,131 @@
+//go:build !logger_minimal
+
+package parse
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func jsonLine(msg string) string {
+	return fmt.Sprintf(`{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":%q}`+"\n", msg)
+}
+
+func appendTo(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(s)
+	f.Close()
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendTo(t, path, jsonLine("old"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := Follow(ctx, path, FollowConfig{Poll: 5 * time.Millisecond})
+	defer f.Close()
+	next := func(want string) {
+		t.Helper()
+		e, err := f.Next()
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", want, err)
+		}
+		if e.Message != want {
+			t.Fatalf("got %q, want %q", e.Message, want)
+		}
+	}
+
+	// Let the follower open the file at its end before appending.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		appendTo(t, path, jsonLine("one"))
+	}()
+	next("one")
+
+	// Rotation by rename: the rest of the old file comes first.
+	appendTo(t, path, jsonLine("two"))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendTo(t, path, jsonLine("three"))
+	next("two")
+	next("three")
+
+	// Truncation in place.
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	appendTo(t, path, jsonLine("four"))
+	next("four")
+
+	cancel()
+	if _, err := f.Next(); err != context.Canceled {
+		t.Errorf("after cancel: %v", err)
+	}
+}
+
+func TestFollowFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := Follow(ctx, path, FollowConfig{Poll: 5 * time.Millisecond, FromStart: true})
+	defer f.Close()
+	// The file does not exist yet; the text entry is handed out once the
+	// writer pauses, with its continuation line.
+	appendTo(t, path, "app ERROR: 2024/03/01 12:30:45 panic: boom\ngoroutine 1 [running]:\n")
+	e, err := f.Next()
+	if err != nil || e.Message != "panic: boom\ngoroutine 1 [running]:" {
+		t.Fatalf("Next = %v, %v", e, err)
+	}
+}
+
+func TestFollowPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendTo(t, path, jsonLine("one")+jsonLine("two"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := Follow(ctx, path, FollowConfig{Poll: 5 * time.Millisecond, FromStart: true})
+	if e, err := f.Next(); err != nil || e.Message != "one" {
+		t.Fatalf("Next = %v, %v", e, err)
+	}
+	pos := f.Position()
+	f.Close()
+	fi, _ := os.Stat(path)
+	if !os.SameFile(pos.File, fi) || pos.Offset != int64(len(jsonLine("one"))) {
+		t.Fatalf("Position = %+v", pos)
+	}
+
+	// A follower resuming at the position starts with the next entry.
+	appendTo(t, path, jsonLine("three"))
+	f = Follow(ctx, path, FollowConfig{Poll: 5 * time.Millisecond, Offset: pos.Offset})
+	defer f.Close()
+	for _, want := range []string{"two", "three"} {
+		if e, err := f.Next(); err != nil || e.Message != want {
+			t.Fatalf("Next = %v, %v, want %q", e, err, want)
+		}
+	}
+	if pos := f.Position(); pos.Offset != fi.Size()+int64(len(jsonLine("three"))) {
+		t.Errorf("Position = %+v", pos)
+	}
+
+	// After a rotation the position moves to the new file.
+	os.Rename(path, path+".1")
+	appendTo(t, path, jsonLine("four"))
+	if e, err := f.Next(); err != nil || e.Message != "four" {
+		t.Fatalf("Next = %v, %v", e, err)
+	}
+	fi, _ = os.Stat(path)
+	if pos := f.Position(); !os.SameFile(pos.File, fi) || pos.Offset != fi.Size() {
+		t.Errorf("after rotation: %+v", pos)
+	}
+}
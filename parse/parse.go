@@ -0,0 +1,357 @@
+//go:build !logger_minimal
+
+// Package parse reads the output of the logger package back into entries,
+// for tools and tests that consume logs programmatically. It understands
+// the text, logfmt, JSON, GELF and MessagePack formats and recovers from
+// the damage real log files carry: lines cut short by a crash, JSON and
+// GELF lines glued to the partial line before them, multi-line text
+// messages and oversized lines.
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+// DefaultMaxLine bounds the length of a line; longer lines are skipped.
+const DefaultMaxLine = 1 << 20
+
+// Format selects how lines are read.
+type Format int
+
+const (
+	// Auto detects the format of every line, or MessagePack for the
+	// whole input if it starts with a map.
+	Auto Format = iota
+	Text
+	Logfmt
+	JSON
+	GELF
+	// Msgpack reads the entries of logger.MsgpackEncoder, which are not
+	// lines but follow each other directly.
+	Msgpack
+)
+
+// ErrNoEntry is returned by Line for a line that is no entry.
+var ErrNoEntry = errors.New("line is no log entry")
+
+// Config configures a Reader.
+type Config struct {
+	// Format is the format of the input, Auto if zero.
+	Format Format
+	// Location is the time zone of text timestamps, which carry none;
+	// nil means time.Local.
+	Location *time.Location
+	// MaxLine is the longest line read, or MessagePack entry; zero
+	// selects DefaultMaxLine.
+	MaxLine int
+}
+
+// Reader reads entries from log output.
+type Reader struct {
+	r       *bufio.Reader
+	cfg     Config
+	pending *logger.Entry
+	// pendingText marks a pending text entry, which takes the lines that
+	// are no entries after it as further message lines.
+	pendingText bool
+	skipped     int
+	err         error
+	// partial holds the start of a line interrupted by errIdle.
+	partial    []byte
+	discarding bool
+	// detected is set once Auto looked at the first byte of the input.
+	detected bool
+	// packed holds the MessagePack input not yet decoded.
+	packed []byte
+	chunk  []byte
+	// resyncing is set while bytes are dropped up to a map start, which
+	// counts as one skip however many reads it takes.
+	resyncing bool
+	// read counts the input bytes consumed, lineStart is where the line
+	// being read starts and offset is where the entry after the last one
+	// returned starts.
+	read      int64
+	lineStart int64
+	offset    int64
+}
+
+// errIdle is returned by a source that has no data for now but may have
+// later, see Follow. The Reader then hands out the entry it holds back.
+var errIdle = errors.New("no data yet")
+
+// NewReader returns a Reader of the entries in r.
+func NewReader(r io.Reader, cfg Config) *Reader {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.MaxLine <= 0 {
+		cfg.MaxLine = DefaultMaxLine
+	}
+	return &Reader{r: bufio.NewReaderSize(r, 64<<10), cfg: cfg}
+}
+
+// Next returns the next entry, or io.EOF after the last one. Lines that are
+// no entry are skipped and counted, except after a text entry, whose
+// message continues on them: the text format writes multi-line messages
+// such as stack traces as they are.
+func (r *Reader) Next() (*logger.Entry, error) {
+	for {
+		if r.cfg.Format == Auto && !r.detected {
+			if b, err := r.r.Peek(1); err == nil {
+				r.detected = true
+				if isMsgpackMap(b[0]) {
+					r.cfg.Format = Msgpack
+				}
+			}
+		}
+		if r.cfg.Format == Msgpack {
+			e, err := r.nextMsgpack()
+			if err == errIdle {
+				continue
+			}
+			r.offset = r.read - int64(len(r.packed))
+			return e, err
+		}
+		line, err := r.readLine()
+		if err == errIdle {
+			if e := r.pending; e != nil {
+				r.pending, r.offset = nil, r.lineStart
+				return e, nil
+			}
+			continue
+		}
+		if err != nil {
+			if e := r.pending; e != nil {
+				r.pending, r.offset = nil, r.read
+				return e, nil
+			}
+			return nil, err
+		}
+		e, text := r.parse(line)
+		if e == nil {
+			if r.pending != nil && r.pendingText {
+				r.pending.Message += "\n" + string(line)
+			} else if len(bytes.TrimSpace(line)) > 0 {
+				r.skipped++
+			}
+			continue
+		}
+		prev := r.pending
+		r.pending, r.pendingText = e, text
+		if prev != nil {
+			r.offset = r.lineStart
+			return prev, nil
+		}
+	}
+}
+
+// Offset returns the number of input bytes making up the entries returned
+// so far, and the lines skipped among them: reading the input again from
+// this offset yields the entries after them. It is where a reader resumes
+// after a restart, see FollowConfig.Offset.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Skipped returns the number of lines skipped so far.
+func (r *Reader) Skipped() int {
+	return r.skipped
+}
+
+// isMsgpackMap reports whether c starts a MessagePack map. These bytes
+// never start a line of text, being no ASCII and no UTF-8 lead byte.
+func isMsgpackMap(c byte) bool {
+	return c&0xf0 == 0x80 || c == 0xde || c == 0xdf
+}
+
+// nextMsgpack decodes the next MessagePack entry. A complete value that is
+// no entry is skipped; after bytes that are no value, or an entry larger
+// than MaxLine, reading resumes at the next map.
+func (r *Reader) nextMsgpack() (*logger.Entry, error) {
+	for {
+		if len(r.packed) > 0 {
+			e, n, err := logger.DecodeMsgpack(r.packed)
+			switch {
+			case err == nil:
+				r.packed, r.resyncing = r.packed[n:], false
+				return e, nil
+			case n > 0:
+				r.packed, r.resyncing = r.packed[n:], false
+				r.skipped++
+				continue
+			case !errors.Is(err, io.ErrUnexpectedEOF) || len(r.packed) > r.cfg.MaxLine:
+				r.resync()
+				continue
+			}
+		}
+		if r.err != nil {
+			if len(r.packed) > 0 && !r.resyncing {
+				r.skipped++
+			}
+			r.packed = nil
+			return nil, r.err
+		}
+		if r.chunk == nil {
+			r.chunk = make([]byte, 32<<10)
+		}
+		n, err := r.r.Read(r.chunk)
+		r.packed = append(r.packed, r.chunk[:n]...)
+		r.read += int64(n)
+		if err == errIdle {
+			return nil, err
+		}
+		if err != nil {
+			r.err = err
+		}
+	}
+}
+
+// resync drops the input up to the next map start.
+func (r *Reader) resync() {
+	i := 1
+	for i < len(r.packed) && !isMsgpackMap(r.packed[i]) {
+		i++
+	}
+	r.packed = r.packed[i:]
+	if !r.resyncing {
+		r.resyncing = true
+		r.skipped++
+	}
+}
+
+// readLine returns the next line without its line break, skipping lines
+// longer than MaxLine. The last line may lack a line break.
+func (r *Reader) readLine() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	line, tooLong := r.partial, r.discarding
+	if line == nil && !tooLong {
+		r.lineStart = r.read
+	}
+	r.partial, r.discarding = nil, false
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		r.read += int64(len(chunk))
+		if !tooLong {
+			if len(line)+len(chunk) > r.cfg.MaxLine+1 {
+				tooLong, line = true, nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == errIdle {
+			r.partial, r.discarding = line, tooLong
+			return nil, err
+		}
+		if err != nil {
+			r.err = err
+			if len(line) == 0 || tooLong {
+				if tooLong {
+					r.skipped++
+				}
+				return nil, err
+			}
+		}
+		if tooLong {
+			r.skipped++
+			tooLong, r.lineStart = false, r.read
+			continue
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// parse reads a line in the configured format, recovering documents glued
+// to a partial line, which counts as skipped, and reports whether it was a
+// text entry.
+func (r *Reader) parse(line []byte) (*logger.Entry, bool) {
+	e, text, err := parseLine(line, r.cfg)
+	if err == nil {
+		return e, text
+	}
+	// A writer that crashed mid-line leaves a partial line that the next
+	// entry continues on. Try the last entry start within the line.
+	if r.cfg.Format == Auto || r.cfg.Format == JSON || r.cfg.Format == GELF {
+		for i := bytes.LastIndexByte(line, '{'); i > 0; i = bytes.LastIndexByte(line[:i], '{') {
+			if e, text, err := parseLine(line[i:], r.cfg); err == nil {
+				r.skipped++
+				return e, text
+			}
+		}
+	}
+	return nil, false
+}
+
+// Line parses a single line in the format of cfg.
+func Line(line []byte, cfg Config) (*logger.Entry, error) {
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	e, _, err := parseLine(line, cfg)
+	return e, err
+}
+
+func parseLine(line []byte, cfg Config) (*logger.Entry, bool, error) {
+	var e *logger.Entry
+	var err error
+	text := false
+	switch cfg.Format {
+	case Text:
+		e, err = logger.DecodeText(line, cfg.Location)
+		text = true
+	case Logfmt:
+		e, err = logger.DecodeLogfmt(line)
+	case JSON:
+		e, err = logger.DecodeJSON(line)
+	case GELF:
+		e, err = logger.DecodeGELF(line)
+	case Msgpack:
+		e, _, err = logger.DecodeMsgpack(line)
+	default:
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+			err = ErrNoEntry
+		case trimmed[0] == '{' && bytes.Contains(trimmed, []byte(`"short_message"`)):
+			e, err = logger.DecodeGELF(trimmed)
+		case trimmed[0] == '{':
+			e, err = logger.DecodeJSON(trimmed)
+		default:
+			if e, err = logger.DecodeText(line, cfg.Location); err == nil {
+				text = true
+			} else {
+				e, err = logger.DecodeLogfmt(line)
+			}
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return e, text, nil
+}
+
+// All reads every entry of r.
+func All(r io.Reader, cfg Config) ([]*logger.Entry, error) {
+	pr := NewReader(r, cfg)
+	var entries []*logger.Entry
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, e)
+	}
+}
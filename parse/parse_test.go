@@ -0,0 +1,129 @@
+//go:build !logger_minimal
+
+package parse
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+func TestReader(t *testing.T) {
+	input := "app ERROR: 2024/03/01 12:30:45 panic: boom\n" +
+		"goroutine 1 [running]:\n" +
+		"main.main()\n" +
+		`{"time":"2024-03-01T12:30:46Z","level":"INFO","msg":"json"}` + "\n" +
+		"stray line\n" +
+		`{"time":"2024-03-01T12:30:47Z","level":"IN{"time":"2024-03-01T12:30:48Z","level":"WARN","msg":"glued"}` + "\n" +
+		`time=2024-03-01T12:30:49Z level=info msg=logfmt n=1` + "\n" +
+		`{"time":"2024-03-01T12:30:50Z","level":"INFO","msg":"cut`
+	r := NewReader(strings.NewReader(input), Config{Location: time.UTC})
+	var msgs []string
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, e.Message)
+	}
+	want := []string{"panic: boom\ngoroutine 1 [running]:\nmain.main()", "json", "glued", "logfmt"}
+	if strings.Join(msgs, "|") != strings.Join(want, "|") {
+		t.Errorf("messages %q, want %q", msgs, want)
+	}
+	// The stray line, the partial line before "glued" and the cut line.
+	if r.Skipped() != 3 {
+		t.Errorf("Skipped = %d, want 3", r.Skipped())
+	}
+}
+
+func TestReaderFormat(t *testing.T) {
+	// In JSON mode text lines are no entries.
+	input := "app INFO : 2024/03/01 12:30:45 text\n" + `{"time":"2024-03-01T12:30:46Z","level":"INFO","msg":"json"}` + "\n"
+	entries, err := All(strings.NewReader(input), Config{Format: JSON})
+	if err != nil || len(entries) != 1 || entries[0].Message != "json" {
+		t.Errorf("All = %v, %v", entries, err)
+	}
+}
+
+func TestReaderLongLine(t *testing.T) {
+	input := `{"time":"2024-03-01T12:30:45Z","level":"INFO","msg":"` + strings.Repeat("x", 200) + `"}` + "\n" +
+		`{"time":"2024-03-01T12:30:46Z","level":"INFO","msg":"short"}` + "\n"
+	r := NewReader(strings.NewReader(input), Config{MaxLine: 100})
+	e, err := r.Next()
+	if err != nil || e.Message != "short" {
+		t.Fatalf("Next = %v, %v", e, err)
+	}
+	if _, err := r.Next(); err != io.EOF || r.Skipped() != 1 {
+		t.Errorf("after: %v, skipped %d", err, r.Skipped())
+	}
+}
+
+func TestLine(t *testing.T) {
+	var buf bytes.Buffer
+	(logger.JSONEncoder{}).Encode(&buf, &logger.Entry{Level: logger.Warn, Name: "app", Message: "written", Fields: []logger.Field{logger.Int("n", 2)}})
+	e, err := Line(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), Config{})
+	if err != nil || e.Message != "written" || e.Level != logger.Warn || e.Name != "app" {
+		t.Errorf("Line = %+v, %v", e, err)
+	}
+	if _, err := Line([]byte("   "), Config{}); err == nil {
+		t.Error("blank line parsed")
+	}
+}
+
+func TestReaderMsgpack(t *testing.T) {
+	var buf bytes.Buffer
+	enc := logger.MsgpackEncoder{}
+	at := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	enc.Encode(&buf, &logger.Entry{Time: at, Level: logger.Info, Name: "app", Message: "one", Fields: []logger.Field{logger.Int("n", 1)}})
+	buf.Write([]byte{0xc1, 0xc1})                 // no value
+	buf.Write([]byte{0x81, 0xa1, 'k', 0xa1, 'v'}) // a map, but no entry
+	enc.Encode(&buf, &logger.Entry{Time: at, Level: logger.Warn, Message: "two"})
+	enc.Encode(&buf, &logger.Entry{Time: at, Level: logger.Error, Message: "cut"})
+	buf.Truncate(buf.Len() - 2)
+
+	// Auto detects MessagePack from the first byte.
+	r := NewReader(iotest.OneByteReader(&buf), Config{})
+	var msgs []string
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, e.Message)
+	}
+	if strings.Join(msgs, "|") != "one|two" {
+		t.Errorf("messages %q", msgs)
+	}
+	if r.Skipped() != 3 {
+		t.Errorf("Skipped = %d, want 3", r.Skipped())
+	}
+}
+
+func TestReaderOffset(t *testing.T) {
+	one := "app ERROR: 2024/03/01 12:30:45 panic: boom\ngoroutine 1 [running]:\n"
+	two := `{"time":"2024-03-01T12:30:46Z","level":"INFO","msg":"two"}` + "\nstray line\n"
+	three := `{"time":"2024-03-01T12:30:47Z","level":"INFO","msg":"three"}`
+	r := NewReader(strings.NewReader(one+two+three), Config{Location: time.UTC})
+	for _, want := range []int{len(one), len(one + two), len(one + two + three)} {
+		if e, err := r.Next(); err != nil || r.Offset() != int64(want) {
+			t.Fatalf("%v, %v: offset %d, want %d", e, err, r.Offset(), want)
+		}
+	}
+
+	// Reading again from an offset yields the rest.
+	rest, err := All(strings.NewReader(two+three), Config{})
+	if err != nil || len(rest) != 2 || rest[0].Message != "two" {
+		t.Errorf("rest = %v, %v", rest, err)
+	}
+}
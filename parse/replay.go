@@ -0,0 +1,77 @@
+//go:build !logger_minimal
+
+package parse
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+// ReplayErrFmt reports the entry at which Replay stopped.
+const ReplayErrFmt = "%s: entry %d: %w"
+
+// ReplayConfig configures Replay.
+type ReplayConfig struct {
+	Config
+	// Since and Until, if set, limit the entries replayed to those from
+	// Since and before Until, e.g. to the span of an outage.
+	Since time.Time
+	Until time.Time
+}
+
+// Replay reads the log archived at path and writes its entries to sink
+// with their original timestamps, to backfill a new aggregator or to
+// recover the entries an outage lost:
+//
+//	n, err := parse.Replay("app-2024-03-01.log.gz", sink, parse.ReplayConfig{})
+//
+// Archives compressed by ArchiveConfig.Compress (.gz) are read
+// decompressed. Replay stops at the first error of sink and returns the
+// number of entries written; a sink buffering them is flushed at the end.
+func Replay(path string, sink logger.Sink, cfg ReplayConfig) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	pr := NewReader(r, cfg.Config)
+	n := 0
+	for {
+		e, err := pr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", path, err)
+		}
+		if (!cfg.Since.IsZero() && e.Time.Before(cfg.Since)) || (!cfg.Until.IsZero() && !e.Time.Before(cfg.Until)) {
+			continue
+		}
+		if err := sink.WriteEntry(e); err != nil {
+			return n, fmt.Errorf(ReplayErrFmt, path, n+1, err)
+		}
+		n++
+	}
+	if fl, ok := sink.(logger.Flusher); ok {
+		if err := fl.Flush(); err != nil {
+			return n, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return n, nil
+}
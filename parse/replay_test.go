@@ -0,0 +1,56 @@
+//go:build !logger_minimal
+
+package parse
+
+import (
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"peter-bird.com/logger"
+)
+
+type replaySink struct {
+	entries []*logger.Entry
+	fail    int
+}
+
+func (s *replaySink) WriteEntry(e *logger.Entry) error {
+	if s.fail > 0 && len(s.entries) == s.fail {
+		return errors.New("collector down")
+	}
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app-2024-03-01.log.gz")
+	f, _ := os.Create(path)
+	zw := gzip.NewWriter(f)
+	for i, msg := range []string{"before", "one", "two", "after"} {
+		at := time.Date(2024, 3, 1, 12, i, 0, 0, time.UTC).Format(time.RFC3339)
+		zw.Write([]byte(`{"time":"` + at + `","level":"INFO","msg":"` + msg + `"}` + "\n"))
+	}
+	zw.Close()
+	f.Close()
+
+	s := &replaySink{}
+	cfg := ReplayConfig{Since: time.Date(2024, 3, 1, 12, 1, 0, 0, time.UTC), Until: time.Date(2024, 3, 1, 12, 3, 0, 0, time.UTC)}
+	n, err := Replay(path, s, cfg)
+	if err != nil || n != 2 || s.entries[0].Message != "one" || s.entries[1].Message != "two" {
+		t.Fatalf("Replay = %d, %v: %v", n, err, s.entries)
+	}
+	if want := time.Date(2024, 3, 1, 12, 1, 0, 0, time.UTC); !s.entries[0].Time.Equal(want) {
+		t.Errorf("time %v, want %v", s.entries[0].Time, want)
+	}
+
+	// A failing sink stops the replay at the entry it rejected.
+	n, err = Replay(path, &replaySink{fail: 1}, ReplayConfig{})
+	if n != 1 || err == nil || !strings.Contains(err.Error(), "entry 2: collector down") {
+		t.Errorf("Replay = %d, %v", n, err)
+	}
+}
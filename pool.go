@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxPooledBufferSize is the largest buffer kept for reuse; bigger buffers
+// produced by unusually large entries are left to the garbage collector.
+const MaxPooledBufferSize = 64 * 1024
+
+// PoolStats describes the use of the shared encoding buffer pool.
+type PoolStats struct {
+	// Gets is the number of buffers handed out.
+	Gets uint64
+	// Allocs is the number of buffers that had to be newly allocated.
+	Allocs uint64
+	// Discarded is the number of buffers dropped for exceeding
+	// MaxPooledBufferSize.
+	Discarded uint64
+}
+
+var (
+	bufferGets      atomic.Uint64
+	bufferAllocs    atomic.Uint64
+	bufferDiscarded atomic.Uint64
+
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			bufferAllocs.Add(1)
+			return new(bytes.Buffer)
+		},
+	}
+)
+
+// GetBuffer returns an empty buffer from the pool shared by all encoders and
+// sinks. Return it with PutBuffer when done.
+func GetBuffer() *bytes.Buffer {
+	bufferGets.Add(1)
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns buf to the pool. buf must not be used afterwards.
+func PutBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > MaxPooledBufferSize {
+		bufferDiscarded.Add(1)
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// BufferPoolStats returns usage counters of the shared buffer pool, useful
+// for tuning MaxPooledBufferSize against the entry sizes seen in practice.
+func BufferPoolStats() PoolStats {
+	return PoolStats{
+		Gets:      bufferGets.Load(),
+		Allocs:    bufferAllocs.Load(),
+		Discarded: bufferDiscarded.Load(),
+	}
+}
@@ -0,0 +1,25 @@
+package logger
+
+import "os"
+
+// Development returns a logger for local work: colored text on stdout at
+// Debug, with the caller of every entry. opts are applied after the
+// preset's and can override it.
+func Development(name string, opts ...Option) (*CustomLogger, error) {
+	preset := []Option{WithColors(DefaultColors), WithCaller()}
+	return New(Debug, name, "", append(preset, opts...)...)
+}
+
+// Production returns a logger for services: JSON at Info with adaptive
+// sampling, entries below Error on stdout and the others on stderr, where
+// process supervisors tend to look for failures. opts are applied after
+// the preset's and can override it.
+func Production(name string, opts ...Option) (*CustomLogger, error) {
+	preset := []Option{
+		WithEncoder(structuredEncoder()),
+		WithAdaptiveSampling(SamplerConfig{}),
+		WithOutputFilter(func(e *Entry) bool { return e.Level < Error }),
+		WithOutput(Output{Writer: os.Stderr, Encoder: structuredEncoder(), Level: Error}),
+	}
+	return New(Info, name, "", append(preset, opts...)...)
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDevelopment(t *testing.T) {
+	w := &rotatingWriter{}
+	l, err := Development("app", WithWriter(w))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("probe")
+	l.Close(context.Background())
+	s := w.files[0].String()
+	if !strings.Contains(s, DefaultColors[Debug]) || !strings.Contains(s, "/preset_test.go:") {
+		t.Errorf("output = %q", s)
+	}
+}
+
+func TestProduction(t *testing.T) {
+	w := &rotatingWriter{}
+	l, err := Production("app", WithWriter(w))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stderr, ok := l.sinks[1].(*LevelSink); !ok || stderr.Level() != Error {
+		t.Errorf("sinks[1] = %T, want a LevelSink at Error", l.sinks[1])
+	}
+	l.sinks = l.sinks[:1]
+	l.Debug("probe")
+	l.Info("started")
+	l.Error("failed")
+	l.Close(context.Background())
+	s, started := w.files[0].String(), `"msg":"started"`
+	if jsonEncoder() == nil {
+		started = "msg=started"
+	}
+	if !strings.Contains(s, started) || strings.Contains(s, "probe") || strings.Contains(s, "failed") {
+		t.Errorf("stdout = %q", s)
+	}
+	if l.sampler == nil {
+		t.Error("no sampling")
+	}
+}
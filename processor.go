@@ -0,0 +1,31 @@
+package logger
+
+// Processor modifies an entry before it is written.
+type Processor interface {
+	Process(e *Entry)
+}
+
+// ProcessorFunc adapts an ordinary function to the Processor interface.
+type ProcessorFunc func(e *Entry)
+
+// Process calls f(e).
+func (f ProcessorFunc) Process(e *Entry) {
+	f(e)
+}
+
+// StaticFields returns a processor that attaches the same fields to every
+// entry, e.g. environment, region or deployment.
+func StaticFields(fields ...Field) Processor {
+	static := append([]Field(nil), fields...)
+	return ProcessorFunc(func(e *Entry) {
+		e.AddFields(static...)
+	})
+}
+
+// DynamicFields returns a processor that calls fn for every entry and
+// attaches the fields it returns.
+func DynamicFields(fn func(e *Entry) []Field) Processor {
+	return ProcessorFunc(func(e *Entry) {
+		e.AddFields(fn(e)...)
+	})
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProcessors(t *testing.T) {
+	static := []Field{String("env", "prod")}
+	var seen []string
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithProcessors(
+		StaticFields(static...),
+		DynamicFields(func(e *Entry) []Field {
+			seen = append(seen, e.Message)
+			return []Field{Int("len", len(e.Message))}
+		}),
+	))
+	static[0] = String("env", "changed")
+	l.Info("started")
+	l.Debug("skipped")
+	l.Log(Warn, "slow", String("path", "/a"))
+
+	// Processors run in order, after the entry's own fields, and only for
+	// entries that are written.
+	want := []string{"started env=prod len=7", "slow path=/a env=prod len=4"}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %q", buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want[i])
+		}
+	}
+	if strings.Join(seen, ",") != "started,slow" {
+		t.Errorf("DynamicFields saw %q", seen)
+	}
+}
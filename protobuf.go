@@ -0,0 +1,272 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const DecodeProtobufErrFmt = "Invalid protobuf log record: %w"
+
+// ProtobufSchema is the protobuf definition of the records written by
+// ProtobufEncoder, for generating the code of consumers.
+//
+//go:embed logger.proto
+var ProtobufSchema string
+
+var errProtobuf = errors.New("malformed protobuf message")
+
+// Protobuf wire types.
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoLen    = 2
+	protoI32    = 5
+)
+
+// ProtobufEncoder writes entries as length-prefixed protobuf records of
+// the schema ProtobufSchema, for consumers that want typed records that
+// can evolve. Field values keep their type; values without a protobuf
+// counterpart are encoded as JSON. Invalid UTF-8 in strings is replaced,
+// as protobuf strings must be valid.
+type ProtobufEncoder struct{}
+
+// Encode implements Encoder.
+func (ProtobufEncoder) Encode(buf *bytes.Buffer, e *Entry) error {
+	var msg []byte
+	msg = protoTag(msg, 1, protoI64)
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(e.Time.UnixNano()))
+	msg = protoString(msg, 2, e.Level.String())
+	if e.Name != "" {
+		msg = protoString(msg, 3, e.Name)
+	}
+	if e.Message != "" {
+		msg = protoString(msg, 4, e.Message)
+	}
+	msg, err := protoFields(msg, 5, e.Fields)
+	if err != nil {
+		return err
+	}
+	buf.Write(binary.AppendUvarint(buf.AvailableBuffer(), uint64(len(msg))))
+	buf.Write(msg)
+	return nil
+}
+
+func protoTag(b []byte, num int, typ byte) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+func protoBytes(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(protoTag(b, num, protoLen), uint64(len(v)))
+	return append(b, v...)
+}
+
+func protoString(b []byte, num int, s string) []byte {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	b = binary.AppendUvarint(protoTag(b, num, protoLen), uint64(len(s)))
+	return append(b, s...)
+}
+
+// protoFields appends each field as an embedded Field message numbered
+// num.
+func protoFields(b []byte, num int, fields []Field) ([]byte, error) {
+	for _, f := range fields {
+		msg, err := protoField(nil, f)
+		if err != nil {
+			return nil, err
+		}
+		b = protoBytes(b, num, msg)
+	}
+	return b, nil
+}
+
+// protoField encodes the Field message of f.
+func protoField(b []byte, f Field) ([]byte, error) {
+	b = protoString(b, 1, f.Key)
+	switch f.Type {
+	case StringType:
+		return protoString(b, 2, f.String), nil
+	case Int64Type:
+		return binary.AppendVarint(protoTag(b, 3, protoVarint), f.Integer), nil
+	case Float64Type:
+		return binary.LittleEndian.AppendUint64(protoTag(b, 4, protoI64), uint64(f.Integer)), nil
+	case BoolType:
+		return binary.AppendUvarint(protoTag(b, 5, protoVarint), uint64(f.Integer)), nil
+	case DurationType:
+		return binary.AppendVarint(protoTag(b, 6, protoVarint), f.Integer), nil
+	case TimeType:
+		return binary.LittleEndian.AppendUint64(protoTag(b, 7, protoI64), uint64(f.Integer)), nil
+	case GroupType:
+		members, _ := f.Value.([]Field)
+		group, err := protoFields(nil, 1, members)
+		if err != nil {
+			return nil, err
+		}
+		return protoBytes(b, 8, group), nil
+	}
+	switch v := f.Value.(type) {
+	case []byte:
+		return protoBytes(b, 9, v), nil
+	case error:
+		return protoString(b, 2, v.Error()), nil
+	}
+	js, err := json.Marshal(fieldMap([]Field{f})[f.Key])
+	if err != nil {
+		return nil, fmt.Errorf(EncodeErrFmt, err)
+	}
+	return protoString(b, 10, string(js)), nil
+}
+
+// DecodeProtobuf decodes the length-prefixed record written by
+// ProtobufEncoder at the start of b and returns the number of bytes it
+// took. Fields of numbers it does not know are skipped; JSON values come
+// back as DecodeJSON returns them and times in UTC. Truncated input is an
+// error wrapping io.ErrUnexpectedEOF and reports zero bytes.
+func DecodeProtobuf(b []byte) (*Entry, int, error) {
+	size, n := binary.Uvarint(b)
+	switch {
+	case n == 0:
+		return nil, 0, fmt.Errorf(DecodeProtobufErrFmt, io.ErrUnexpectedEOF)
+	case n < 0:
+		return nil, 0, fmt.Errorf(DecodeProtobufErrFmt, errProtobuf)
+	case uint64(len(b)-n) < size:
+		return nil, 0, fmt.Errorf(DecodeProtobufErrFmt, io.ErrUnexpectedEOF)
+	}
+	total := n + int(size)
+	e := &Entry{Time: time.Unix(0, 0).UTC()}
+	err := protoMessage(b[n:total], func(num int, typ byte, v uint64, data []byte) error {
+		switch {
+		case num == 1 && typ == protoI64:
+			e.Time = time.Unix(0, int64(v)).UTC()
+		case num == 2 && typ == protoLen:
+			return e.Level.UnmarshalText(data)
+		case num == 3 && typ == protoLen:
+			e.Name = string(data)
+		case num == 4 && typ == protoLen:
+			e.Message = string(data)
+		case num == 5 && typ == protoLen:
+			f, err := decodeProtoField(data, 0)
+			if err != nil {
+				return err
+			}
+			e.Fields = append(e.Fields, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, total, fmt.Errorf(DecodeProtobufErrFmt, err)
+	}
+	return e, total, nil
+}
+
+func decodeProtoField(b []byte, depth int) (Field, error) {
+	if depth > msgpackMaxDepth {
+		return Field{}, errProtobuf
+	}
+	var f Field
+	err := protoMessage(b, func(num int, typ byte, v uint64, data []byte) error {
+		switch {
+		case num == 1 && typ == protoLen:
+			f.Key = string(data)
+		case num == 2 && typ == protoLen:
+			f = String(f.Key, string(data))
+		case num == 3 && typ == protoVarint:
+			f = Int64(f.Key, protoZigzag(v))
+		case num == 4 && typ == protoI64:
+			f = Float(f.Key, math.Float64frombits(v))
+		case num == 5 && typ == protoVarint:
+			f = Bool(f.Key, v != 0)
+		case num == 6 && typ == protoVarint:
+			f = Duration(f.Key, time.Duration(protoZigzag(v)))
+		case num == 7 && typ == protoI64:
+			f = Time(f.Key, time.Unix(0, int64(v)).UTC())
+		case num == 8 && typ == protoLen:
+			var members []Field
+			err := protoMessage(data, func(num int, typ byte, _ uint64, data []byte) error {
+				if num != 1 || typ != protoLen {
+					return nil
+				}
+				m, err := decodeProtoField(data, depth+1)
+				members = append(members, m)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			f = Group(f.Key, members...)
+		case num == 9 && typ == protoLen:
+			f = Any(f.Key, append([]byte(nil), data...))
+		case num == 10 && typ == protoLen:
+			var v interface{}
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			f = decodeJSONField(f.Key, v)
+		}
+		return nil
+	})
+	return f, err
+}
+
+// protoZigzag decodes a sint64.
+func protoZigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// protoMessage calls fn with each field of the message b: its number, wire
+// type and the value of varint, i64 and i32 fields or the data of
+// length-delimited ones.
+func protoMessage(b []byte, fn func(num int, typ byte, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 || tag>>3 == 0 {
+			return errProtobuf
+		}
+		b = b[n:]
+		var v uint64
+		var data []byte
+		switch typ := byte(tag & 7); typ {
+		case protoVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return errProtobuf
+			}
+			b = b[n:]
+		case protoI64:
+			if len(b) < 8 {
+				return errProtobuf
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case protoI32:
+			if len(b) < 4 {
+				return errProtobuf
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case protoLen:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errProtobuf
+			}
+			data, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return errProtobuf
+		}
+		if err := fn(int(tag>>3), byte(tag&7), v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProtobufEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := &Entry{Time: time.Unix(0, 1), Level: Info, Message: "hi", Fields: []Field{Int("n", -1)}}
+	if err := (ProtobufEncoder{}).Encode(&buf, e); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		26,
+		0x09, 1, 0, 0, 0, 0, 0, 0, 0, // time_unix_nano
+		0x12, 4, 'I', 'N', 'F', 'O', // level
+		0x22, 2, 'h', 'i', // message
+		0x2a, 5, 0x0a, 1, 'n', 0x18, 1, // fields: key, int_value zigzag
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("encoded % x\n         want % x", buf.Bytes(), want)
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	e := &Entry{Time: testTime.Add(123), Level: Critical, Name: "app", Message: "disk low",
+		Fields: []Field{
+			String("s", "x"),
+			Int("i", 1<<40),
+			Float("f", 1.5),
+			Bool("b", true),
+			Duration("d", -time.Second),
+			Time("t", testTime),
+			Group("g", String("id", "r1"), Group("inner", Int("n", 2))),
+			Any("raw", []byte{0, 1}),
+			Err(errors.New("boom")),
+			Any("tags", []string{"a", "b"}),
+		}}
+	var buf bytes.Buffer
+	enc := ProtobufEncoder{}
+	enc.Encode(&buf, e)
+	enc.Encode(&buf, &Entry{Time: testTime, Level: Debug})
+
+	got, n, err := DecodeProtobuf(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Time.Equal(e.Time) || got.Level != Critical || got.Name != "app" || got.Message != "disk low" {
+		t.Errorf("decoded %+v", got)
+	}
+	var want, back bytes.Buffer
+	(JSONEncoder{}).Encode(&want, e)
+	(JSONEncoder{}).Encode(&back, got)
+	if back.String() != strings.Replace(want.String(), testTime.Add(123).Format(time.RFC3339Nano), got.Time.Format(time.RFC3339Nano), 1) {
+		t.Errorf("round trip:\n got %s\nwant %s", back.String(), want.String())
+	}
+	if got.Fields[4].Type != DurationType || got.Fields[5].Type != TimeType {
+		t.Errorf("types of %v", got.Fields)
+	}
+
+	second, m, err := DecodeProtobuf(buf.Bytes()[n:])
+	if err != nil || second.Level != Debug || second.Message != "" || n+m != buf.Len() {
+		t.Errorf("second record %+v, %d, %v", second, m, err)
+	}
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	var buf bytes.Buffer
+	(ProtobufEncoder{}).Encode(&buf, &Entry{Time: testTime, Level: Warn, Message: "m"})
+	whole := buf.Bytes()
+	for i := 0; i < len(whole); i++ {
+		if _, n, err := DecodeProtobuf(whole[:i]); !errors.Is(err, io.ErrUnexpectedEOF) || n != 0 {
+			t.Errorf("%d of %d bytes: %d, %v", i, len(whole), n, err)
+		}
+	}
+
+	// A field added by a later version is skipped.
+	msg := append(append([]byte(nil), whole[1:]...), 0x78, 0x05, 0x85, 0x01, 1, 2, 3, 4)
+	msg = append([]byte{byte(len(msg))}, msg...)
+	if e, n, err := DecodeProtobuf(msg); err != nil || e.Message != "m" || n != len(msg) {
+		t.Errorf("with unknown fields: %+v, %d, %v", e, n, err)
+	}
+
+	if _, _, err := DecodeProtobuf([]byte{2, 0x0b, 0}); err == nil {
+		t.Error("decoded a group wire type")
+	}
+}
+
+func TestProtobufInvalidUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	(ProtobufEncoder{}).Encode(&buf, &Entry{Time: testTime, Message: "a\xffb"})
+	e, _, err := DecodeProtobuf(buf.Bytes())
+	if err != nil || e.Message != "a�b" {
+		t.Errorf("decoded %q, %v", e.Message, err)
+	}
+}
+
+func TestProtobufSchema(t *testing.T) {
+	for _, s := range []string{"message Entry", "message Field", "json_value = 10"} {
+		if !strings.Contains(ProtobufSchema, s) {
+			t.Errorf("schema lacks %q", s)
+		}
+	}
+}
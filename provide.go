@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds the Close run by the ProvideLogger cleanup.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// Config describes a logger, for building one in a dependency injection
+// container.
+type Config struct {
+	Name string
+	// Level is the minimum level; Debug if zero.
+	Level LogLevel
+	// File is the log file; empty writes to stdout.
+	File string
+	// Format is text, json or logfmt; text if empty.
+	Format string
+	// Options are applied after those of the settings above.
+	Options []Option
+	// ShutdownTimeout bounds the Close of the ProvideLogger cleanup; zero
+	// selects DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// New creates the logger.
+func (c Config) New() (*CustomLogger, error) {
+	enc, err := flagEncoder(c.Format)
+	if err != nil {
+		return nil, err
+	}
+	opts := c.Options
+	if enc != nil {
+		opts = append([]Option{WithEncoder(enc)}, opts...)
+	}
+	return New(c.Level, c.Name, c.File, opts...)
+}
+
+// ProvideLogger is a provider in the form google/wire expects, creating the
+// logger with a cleanup that flushes and closes it:
+//
+//	wire.Build(logger.ProvideLogger, wire.Bind(new(logger.Logger), new(*logger.CustomLogger)), ...)
+//
+// With uber-go/fx, Close fits fx.Hook.OnStop as is:
+//
+//	fx.Provide(func(lc fx.Lifecycle, cfg logger.Config) (*logger.CustomLogger, error) {
+//		l, err := cfg.New()
+//		if err == nil {
+//			lc.Append(fx.Hook{OnStop: l.Close})
+//		}
+//		return l, err
+//	})
+//
+// Neither library is a dependency of this package.
+func ProvideLogger(cfg Config) (*CustomLogger, func(), error) {
+	l, err := cfg.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := l.Close(ctx); err != nil {
+			l.errorHandler(err)
+		}
+	}
+	return l, cleanup, nil
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProvideLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, cleanup, err := ProvideLogger(Config{Name: "app", Level: Info, File: path, Format: "logfmt",
+		Options: []Option{WithBufferedOutput(BufferConfig{})}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ Logger = l
+	l.Info("started")
+	cleanup()
+	b, _ := os.ReadFile(path)
+	if s := string(b); !strings.Contains(s, "msg=started") {
+		t.Errorf("file = %q, want the buffered entry written by cleanup", s)
+	}
+	if !l.closed.Load() {
+		t.Error("cleanup did not close the logger")
+	}
+
+	if _, _, err := ProvideLogger(Config{Format: "yaml"}); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+}
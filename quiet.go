@@ -0,0 +1,29 @@
+package logger
+
+import "math"
+
+// Silent, passed to SetQuiet, suppresses every entry.
+const Silent LogLevel = math.MaxInt16
+
+// SetQuiet suppresses entries below level for this logger and every logger
+// sharing its levels, whatever the base and module levels say, until
+// ClearQuiet is called. It suits CLI tools honouring a --quiet flag:
+//
+//	if *quiet {
+//		log.SetQuiet(logger.Error)
+//	}
+func (l *CustomLogger) SetQuiet(level LogLevel) {
+	l.levels.setQuiet(&level)
+}
+
+// ClearQuiet ends quiet mode; the configured levels apply again.
+func (l *CustomLogger) ClearQuiet() {
+	l.levels.setQuiet(nil)
+}
+
+// Quiet reports whether quiet mode is on.
+func (l *CustomLogger) Quiet() bool {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+	return l.levels.quietSet
+}
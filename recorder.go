@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+const DefaultRecorderSize = 256
+
+// RecorderConfig configures a FlightRecorder.
+type RecorderConfig struct {
+	// Size is the number of entries kept in memory; zero selects
+	// DefaultRecorderSize.
+	Size int
+	// BufferBelow is the level below which entries are only recorded; zero
+	// means Info, so Debug entries are buffered.
+	BufferBelow LogLevel
+	// Trigger is the level at which the recorded entries are written out
+	// ahead of the triggering entry; zero means Error.
+	Trigger LogLevel
+	// ExplicitLevels uses BufferBelow and Trigger as given, so Debug (zero)
+	// can be selected for either.
+	ExplicitLevels bool
+}
+
+// FlightRecorder keeps low-level entries in a bounded in-memory ring instead
+// of writing them. When an entry at the trigger level arrives, the recorded
+// context is written to the wrapped sink just before it, so full debug
+// detail is only paid for when something actually goes wrong.
+type FlightRecorder struct {
+	mu      sync.Mutex
+	sink    Sink
+	cfg     RecorderConfig
+	entries []*Entry
+	next    int
+	full    bool
+	health  healthTracker
+}
+
+// NewFlightRecorder creates a FlightRecorder in front of sink.
+func NewFlightRecorder(sink Sink, cfg RecorderConfig) *FlightRecorder {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultRecorderSize
+	}
+	if cfg.BufferBelow == 0 && !cfg.ExplicitLevels {
+		cfg.BufferBelow = Info
+	}
+	if cfg.Trigger == 0 && !cfg.ExplicitLevels {
+		cfg.Trigger = Error
+	}
+	return &FlightRecorder{
+		sink:    sink,
+		cfg:     cfg,
+		entries: make([]*Entry, cfg.Size),
+	}
+}
+
+// WriteEntry implements Sink.
+func (r *FlightRecorder) WriteEntry(e *Entry) error {
+	if e.Level < r.cfg.BufferBelow {
+		r.mu.Lock()
+		r.entries[r.next] = e.Clone()
+		r.next = (r.next + 1) % len(r.entries)
+		if r.next == 0 {
+			r.full = true
+		}
+		r.mu.Unlock()
+		return nil
+	}
+
+	if e.Level >= r.cfg.Trigger {
+		if err := r.Dump(); err != nil {
+			return err
+		}
+	}
+	return r.write(e)
+}
+
+// write writes e to the wrapped sink, tracking its health.
+func (r *FlightRecorder) write(e *Entry) error {
+	err := r.sink.WriteEntry(e)
+	r.health.record(time.Now(), err)
+	return err
+}
+
+// Dump writes the recorded entries, oldest first, to the wrapped sink and
+// clears the ring.
+func (r *FlightRecorder) Dump() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, n := 0, r.next
+	if r.full {
+		start, n = r.next, len(r.entries)
+	}
+
+	var err error
+	for i := 0; i < n; i++ {
+		j := (start + i) % len(r.entries)
+		if werr := r.write(r.entries[j]); werr != nil && err == nil {
+			err = werr
+		}
+		r.entries[j] = nil
+	}
+	r.next, r.full = 0, false
+	return err
+}
+
+// Flush flushes the wrapped sink. Recorded entries stay in memory; use Dump
+// to write them.
+func (r *FlightRecorder) Flush() error {
+	return flushSink(r.sink)
+}
+
+// Close closes the wrapped sink, or flushes it if it cannot be closed.
+// Recorded entries are discarded.
+func (r *FlightRecorder) Close() error {
+	r.mu.Lock()
+	clear(r.entries)
+	r.next, r.full = 0, false
+	r.mu.Unlock()
+	if c, ok := r.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return flushSink(r.sink)
+}
+
+// Stats implements StatsReporter for the wrapped sink.
+func (r *FlightRecorder) Stats() Stats {
+	if sr, ok := r.sink.(StatsReporter); ok {
+		return sr.Stats()
+	}
+	return Stats{}
+}
+
+// Health implements HealthReporter for the wrapped sink. Entries only
+// recorded are not writes and leave it unchanged.
+func (r *FlightRecorder) Health() Health {
+	if hr, ok := r.sink.(HealthReporter); ok {
+		return hr.Health()
+	}
+	return r.health.health(r.sink)
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// closeSink records whether it was closed and fails its writes with err.
+type closeSink struct {
+	countSink
+	err    error
+	closed bool
+}
+
+func (s *closeSink) WriteEntry(e *Entry) error {
+	s.countSink.WriteEntry(e)
+	return s.err
+}
+
+func (s *closeSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestFlightRecorder(t *testing.T) {
+	dst := &countSink{keep: true}
+	r := NewFlightRecorder(dst, RecorderConfig{Size: 2})
+	for _, msg := range []string{"d1", "d2", "d3"} {
+		r.WriteEntry(&Entry{Level: Debug, Message: msg})
+	}
+	r.WriteEntry(&Entry{Level: Info, Message: "info"})
+	r.WriteEntry(&Entry{Level: Error, Message: "boom"})
+	r.WriteEntry(&Entry{Level: Error, Message: "again"})
+
+	// The newest debug entries come out, oldest first, ahead of the first
+	// error; the second error finds the ring empty.
+	var msgs []string
+	for _, e := range dst.entries {
+		msgs = append(msgs, e.Message)
+	}
+	if got := strings.Join(msgs, ","); got != "info,d2,d3,boom,again" {
+		t.Errorf("wrote %s", got)
+	}
+
+	// WithFlightRecorder puts a recorder in front of every sink.
+	dst = &countSink{keep: true}
+	l, err := New(Debug, "test", "", WithOutputLevel(Emergency+1), WithSinks(dst), WithFlightRecorder(RecorderConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("detail")
+	if n := dst.n.Load(); n != 0 {
+		t.Fatalf("%d entries written before the error", n)
+	}
+	l.Error("failed")
+	if len(dst.entries) != 2 || dst.entries[0].Message != "detail" {
+		t.Errorf("wrote %v", dst.entries)
+	}
+}
+
+func TestFlightRecorderClose(t *testing.T) {
+	dst := &closeSink{err: errors.New("down")}
+	r := NewFlightRecorder(dst, RecorderConfig{})
+	r.WriteEntry(&Entry{Level: Debug})
+	r.WriteEntry(&Entry{Level: Info})
+	if h := r.Health(); h.Healthy() || h.LastError != dst.err || h.Sink != "*logger.closeSink" {
+		t.Errorf("Health = %+v, want the failed write", h)
+	}
+	if err := r.Close(); err != nil || !dst.closed {
+		t.Errorf("Close = %v, closed %v", err, dst.closed)
+	}
+	if n := dst.n.Load(); n != 1 {
+		t.Errorf("wrote %d entries, want only the Info one", n)
+	}
+
+	batch := NewBatchSink(&recordSender{err: errors.New("down")}, BatchConfig{ErrorHandler: func(error) {}})
+	r = NewFlightRecorder(batch, RecorderConfig{})
+	r.WriteEntry(&Entry{Level: Info})
+	batch.Flush()
+	if got := r.Stats().Failed; got != 1 {
+		t.Errorf("Stats().Failed = %d, want the BatchSink's 1", got)
+	}
+	if err := r.Close(); err == nil {
+		t.Error("Close did not report the failed batch")
+	}
+}
@@ -0,0 +1,227 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultStreamMaxLen is the approximate stream length kept when
+// RedisConfig.MaxLen is zero.
+const DefaultStreamMaxLen = 100000
+
+const RedisErrFmt = "Redis: %s"
+
+// RedisConfig configures a RedisStreamSender.
+type RedisConfig struct {
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// Username and Password are sent with AUTH when Password is set.
+	Username string
+	Password string
+	DB       int
+	// Stream is the key entries are appended to.
+	Stream string
+	// MaxLen caps the stream with approximate trimming (MAXLEN ~).
+	MaxLen      int64
+	TLS         *tls.Config
+	DialTimeout time.Duration
+}
+
+// RedisStreamSender is a BatchSender that XADDs entries to a Redis stream
+// with the fields ts, level, name, msg and fields (JSON) so lightweight
+// consumers can tail logs with XREAD. A batch is sent as one pipeline.
+type RedisStreamSender struct {
+	cfg RedisConfig
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewRedisStreamSender returns a sender for cfg. The connection is made on
+// first use and re-established after a failure.
+func NewRedisStreamSender(cfg RedisConfig) *RedisStreamSender {
+	if cfg.MaxLen <= 0 {
+		cfg.MaxLen = DefaultStreamMaxLen
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	return &RedisStreamSender{cfg: cfg}
+}
+
+// SendBatch implements BatchSender.
+func (s *RedisStreamSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connectLocked(ctx); err != nil {
+			return err
+		}
+	}
+	// Encode every entry before writing so a failure cannot leave part of
+	// the batch in the pipeline.
+	fields := make([]string, len(batch))
+	for i, e := range batch {
+		b, err := json.Marshal(e.FieldMap())
+		if err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		fields[i] = string(b)
+	}
+	s.setDeadline(ctx)
+
+	maxLen := strconv.FormatInt(s.cfg.MaxLen, 10)
+	for i, e := range batch {
+		writeRESP(s.w, "XADD", s.cfg.Stream, "MAXLEN", "~", maxLen, "*",
+			"ts", e.Time.UTC().Format(time.RFC3339Nano),
+			"level", e.Level.String(),
+			"name", e.Name,
+			"msg", e.Message,
+			"fields", fields[i])
+	}
+	return s.roundTripLocked(len(batch))
+}
+
+// Close closes the connection.
+func (s *RedisStreamSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *RedisStreamSender) closeLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *RedisStreamSender) setDeadline(ctx context.Context) {
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(dl)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+}
+
+func (s *RedisStreamSender) connectLocked(ctx context.Context) error {
+	conn, err := dialNetwork(ctx, s.cfg.Addr, s.cfg.DialTimeout, s.cfg.TLS)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.r = bufio.NewReader(conn)
+	s.w = bufio.NewWriter(conn)
+	s.setDeadline(ctx)
+
+	n := 0
+	if s.cfg.Password != "" {
+		if s.cfg.Username != "" {
+			writeRESP(s.w, "AUTH", s.cfg.Username, s.cfg.Password)
+		} else {
+			writeRESP(s.w, "AUTH", s.cfg.Password)
+		}
+		n++
+	}
+	if s.cfg.DB != 0 {
+		writeRESP(s.w, "SELECT", strconv.Itoa(s.cfg.DB))
+		n++
+	}
+	if err := s.roundTripLocked(n); err != nil {
+		// A rejected AUTH or SELECT must not leave a usable connection.
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// roundTripLocked flushes the pipelined commands and reads n replies,
+// returning the first error reply. Transport failures drop the connection.
+func (s *RedisStreamSender) roundTripLocked(n int) error {
+	if err := s.w.Flush(); err != nil {
+		s.closeLocked()
+		return err
+	}
+	var first error
+	for i := 0; i < n; i++ {
+		msg, err := readRESP(s.r)
+		if err != nil {
+			s.closeLocked()
+			return err
+		}
+		if msg != "" && first == nil {
+			first = fmt.Errorf(RedisErrFmt, msg)
+		}
+	}
+	return first
+}
+
+// writeRESP writes a command as an array of bulk strings.
+func writeRESP(w *bufio.Writer, args ...string) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(len(args)))
+	w.WriteString("\r\n")
+	for _, a := range args {
+		w.WriteByte('$')
+		w.WriteString(strconv.Itoa(len(a)))
+		w.WriteString("\r\n")
+		w.WriteString(a)
+		w.WriteString("\r\n")
+	}
+}
+
+// readRESP consumes one reply and returns its message if it is an error.
+func readRESP(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 3 {
+		return "", fmt.Errorf(RedisErrFmt, "malformed reply")
+	}
+	body := line[1 : len(line)-2]
+	switch line[0] {
+	case '+', ':':
+		return "", nil
+	case '-':
+		return body, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return "", fmt.Errorf(RedisErrFmt, "malformed reply")
+		}
+		if n >= 0 {
+			if _, err := r.Discard(n + 2); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return "", fmt.Errorf(RedisErrFmt, "malformed reply")
+		}
+		for i := 0; i < n; i++ {
+			if _, err := readRESP(r); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf(RedisErrFmt, "unsupported reply type")
+	}
+}
@@ -0,0 +1,222 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server answering with reply for each command.
+type fakeRedis struct {
+	ln    net.Listener
+	reply func(args []string) string
+
+	mu       sync.Mutex
+	commands [][]string
+	conns    int
+}
+
+func newFakeRedis(t *testing.T, reply func(args []string) string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, reply: reply}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns++
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args)
+		f.mu.Unlock()
+		if _, err := conn.Write([]byte(f.reply(args))); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) names() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, len(f.commands))
+	for i, c := range f.commands {
+		names[i] = c[0]
+	}
+	return names
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		in      string
+		msg     string
+		wantErr bool
+	}{
+		{"+OK\r\n", "", false},
+		{":12\r\n", "", false},
+		{"$5\r\nhello\r\n", "", false},
+		{"$-1\r\n", "", false},
+		{"*2\r\n$1\r\na\r\n:1\r\n", "", false},
+		{"-ERR wrong type\r\n", "ERR wrong type", false},
+		{"$x\r\n", "", true},
+		{"!3\r\nerr\r\n", "", true},
+		{"+\n", "", true},
+		{"$5\r\nhel", "", true},
+	}
+	for _, tt := range tests {
+		r := bufio.NewReader(strings.NewReader(tt.in))
+		msg, err := readRESP(r)
+		if (err != nil) != tt.wantErr || msg != tt.msg {
+			t.Errorf("readRESP(%q) = %q, %v", tt.in, msg, err)
+		}
+		if !tt.wantErr && r.Buffered() != 0 {
+			t.Errorf("readRESP(%q) left %d bytes", tt.in, r.Buffered())
+		}
+	}
+}
+
+func TestWriteRESP(t *testing.T) {
+	var b strings.Builder
+	w := bufio.NewWriter(&b)
+	writeRESP(w, "XADD", "s", "")
+	w.Flush()
+	if want := "*3\r\n$4\r\nXADD\r\n$1\r\ns\r\n$0\r\n\r\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestRedisStreamSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RedisConfig
+		reply   func(args []string) string
+		wantErr bool
+		want    []string
+	}{
+		{
+			name:  "plain",
+			reply: func([]string) string { return "$3\r\n1-0\r\n" },
+			want:  []string{"XADD", "XADD"},
+		},
+		{
+			name:  "auth and select",
+			cfg:   RedisConfig{Username: "u", Password: "p", DB: 2},
+			reply: func([]string) string { return "+OK\r\n" },
+			want:  []string{"AUTH", "SELECT", "XADD", "XADD"},
+		},
+		{
+			name: "auth rejected",
+			cfg:  RedisConfig{Password: "p"},
+			reply: func(args []string) string {
+				if args[0] == "AUTH" {
+					return "-WRONGPASS invalid password\r\n"
+				}
+				return "+OK\r\n"
+			},
+			wantErr: true,
+			want:    []string{"AUTH"},
+		},
+		{
+			name: "error reply",
+			reply: func(args []string) string {
+				if args[len(args)-3] == "bad" {
+					return "-ERR rejected\r\n"
+				}
+				return "$3\r\n1-0\r\n"
+			},
+			wantErr: true,
+			want:    []string{"XADD", "XADD"},
+		},
+	}
+	for _, tt := range tests {
+		srv := newFakeRedis(t, tt.reply)
+		tt.cfg.Addr = srv.ln.Addr().String()
+		tt.cfg.Stream = "logs"
+		s := NewRedisStreamSender(tt.cfg)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		batch := []*Entry{
+			{Time: testTime, Level: Info, Name: "a", Message: "bad"},
+			{Time: testTime, Level: Warn, Message: "two", Fields: []Field{Int("n", 2)}},
+		}
+		err := s.SendBatch(ctx, batch)
+		cancel()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if got := srv.names(); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: commands %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.wantErr && strings.HasPrefix(tt.name, "auth") && s.conn != nil {
+			t.Errorf("%s: connection kept after a rejected AUTH", tt.name)
+		}
+		s.Close()
+	}
+}
+
+func TestRedisStreamSenderFields(t *testing.T) {
+	srv := newFakeRedis(t, func([]string) string { return "$3\r\n1-0\r\n" })
+	s := NewRedisStreamSender(RedisConfig{Addr: srv.ln.Addr().String(), Stream: "logs", MaxLen: 10})
+	defer s.Close()
+	e := &Entry{Time: testTime, Level: Error, Name: "db", Message: "failed", Fields: []Field{String("q", "x")}}
+	if err := s.SendBatch(context.Background(), []*Entry{e}); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	got := strings.Join(srv.commands[0], " ")
+	srv.mu.Unlock()
+	want := `XADD logs MAXLEN ~ 10 * ts 2024-03-01T12:30:45Z level ERROR name db msg failed fields {"q":"x"}`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
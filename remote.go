@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultSendTimeout bounds a single delivery made by a SenderSink.
+const DefaultSendTimeout = 10 * time.Second
+
+// BatchSender delivers batches of entries to a remote destination such as
+// an HTTP collector, a TCP endpoint or a message broker.
+type BatchSender interface {
+	SendBatch(ctx context.Context, batch []*Entry) error
+}
+
+// Acknowledger is implemented by senders that can tell whether a nil error
+// from SendBatch means the destination confirmed the batch, as needed for
+// at-least-once delivery. Senders that do not implement it are taken to
+// confirm: the HTTP senders only return nil on a 2xx response, for one.
+type Acknowledger interface {
+	Acknowledges() bool
+}
+
+// ErrNoAcks is reported by a DiskQueue, or a BatchSink configured for
+// at-least-once delivery, with a sender that does not acknowledge batches.
+var ErrNoAcks = errors.New("sender does not acknowledge batches, delivery is not confirmed")
+
+// acknowledges reports whether sender confirms the batches it returns nil
+// for.
+func acknowledges(sender BatchSender) bool {
+	if a, ok := sender.(Acknowledger); ok {
+		return a.Acknowledges()
+	}
+	return true
+}
+
+// BatchSenderFunc adapts an ordinary function to the BatchSender interface.
+type BatchSenderFunc func(ctx context.Context, batch []*Entry) error
+
+// SendBatch calls f(ctx, batch).
+func (f BatchSenderFunc) SendBatch(ctx context.Context, batch []*Entry) error {
+	return f(ctx, batch)
+}
+
+// SenderSink is a Sink that delivers every entry synchronously through a
+// BatchSender as a batch of one.
+type SenderSink struct {
+	sender  BatchSender
+	timeout time.Duration
+}
+
+// NewSenderSink creates a SenderSink. A non-positive timeout selects
+// DefaultSendTimeout.
+func NewSenderSink(sender BatchSender, timeout time.Duration) *SenderSink {
+	if timeout <= 0 {
+		timeout = DefaultSendTimeout
+	}
+	return &SenderSink{sender: sender, timeout: timeout}
+}
+
+// WriteEntry implements Sink.
+func (s *SenderSink) WriteEntry(e *Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.sender.SendBatch(ctx, []*Entry{e})
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const ReopenNoticeFmt = "Log file %s was removed or replaced, reopened it"
+
+// checkMovedLocked reopens the log file if another process deleted or
+// renamed it, so writes do not keep going to an unlinked inode. It runs at
+// most once per FileConfig.ReopenCheck. lf.mu must be held.
+func (lf *File) checkMovedLocked() {
+	now := time.Now()
+	if now.Sub(lf.lastCheck) < lf.cfg.ReopenCheck {
+		return
+	}
+	lf.lastCheck = now
+
+	if lf.cfg.Rotation.Symlink {
+		if _, err := os.Lstat(lf.path); errors.Is(err, os.ErrNotExist) {
+			if err := updateSymlink(lf.path, lf.current); err != nil {
+				lf.cfg.ErrorHandler(fmt.Errorf(OpenLogErrFmt, err))
+			}
+		}
+	}
+
+	onDisk, err := os.Stat(lf.current)
+	if err == nil {
+		open, serr := lf.f.Stat()
+		if serr != nil || os.SameFile(onDisk, open) {
+			return
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	old := lf.f
+	if err := lf.reopenLocked(); err != nil {
+		lf.cfg.ErrorHandler(fmt.Errorf(OpenLogErrFmt, err))
+		return
+	}
+	old.Close()
+	lf.cfg.ErrorHandler(fmt.Errorf(ReopenNoticeFmt, lf.current))
+}
+
+// reopenLocked opens a fresh file at the current path. lf.mu must be held.
+func (lf *File) reopenLocked() error {
+	f, err := lf.openPath(lf.current)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := lf.setFile(f, lf.current, info.Size()); err != nil {
+		f.Close()
+		return err
+	}
+	lf.size = info.Size()
+	lf.writeHeaderLocked()
+	if lf.cfg.Rotation.Symlink {
+		return updateSymlink(lf.path, lf.current)
+	}
+	return nil
+}
+
+// lockLocked takes the advisory lock of FileConfig.Lock. If another
+// process rotated or removed the file meanwhile, it moves on to the file
+// now at the path, and it refreshes the size, which every process adds
+// to. lf.mu must be held.
+func (lf *File) lockLocked() error {
+	for {
+		if err := lockFile(lf.f); err != nil {
+			return fmt.Errorf(FileLockErrFmt, err)
+		}
+		open, err := lf.f.Stat()
+		if err != nil {
+			return nil
+		}
+		onDisk, err := os.Stat(lf.current)
+		moved := err == nil && !os.SameFile(onDisk, open) || errors.Is(err, os.ErrNotExist)
+		if !moved {
+			lf.size = open.Size()
+			return nil
+		}
+		unlockFile(lf.f)
+		old := lf.f
+		if err := lf.reopenLocked(); err != nil {
+			return fmt.Errorf(OpenLogErrFmt, err)
+		}
+		old.Close()
+		lf.opened = time.Now()
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReopenCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("open files cannot be moved on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	var notices []string
+	f, err := OpenFile(path, FileConfig{ReopenCheck: time.Nanosecond, ErrorHandler: func(err error) {
+		notices = append(notices, err.Error())
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write([]byte("one\n"))
+
+	// A file moved away, e.g. by logrotate, keeps what it had.
+	os.Rename(path, path+".1")
+	f.Write([]byte("two\n"))
+	// A deleted file is recreated.
+	os.Remove(path)
+	f.Write([]byte("three\n"))
+
+	if b, _ := os.ReadFile(path + ".1"); string(b) != "one\n" {
+		t.Errorf("moved file %q", b)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "three\n" {
+		t.Errorf("file %q", b)
+	}
+	if len(notices) != 2 || !strings.Contains(notices[0], "removed or replaced") {
+		t.Errorf("notices %q", notices)
+	}
+
+	// Without ReopenCheck writes go on to the moved file.
+	g, err := OpenFile(path, FileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	os.Rename(path, path+".2")
+	g.Write([]byte("four\n"))
+	if b, _ := os.ReadFile(path + ".2"); string(b) != "three\nfour\n" {
+		t.Errorf("unchecked file %q", b)
+	}
+}
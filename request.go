@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequestMaxEntries bounds the entries buffered for one request.
+	DefaultRequestMaxEntries = 1000
+	// RequestSummaryMessage is the message of a request summary entry.
+	RequestSummaryMessage = "request completed"
+)
+
+var requestIDEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv").WithPadding(base32.NoPadding)
+
+// NewRequestID returns a random 16 character ID carrying 80 bits, enough
+// that IDs do not collide in practice.
+func NewRequestID() string {
+	var b [10]byte
+	rand.Read(b[:])
+	return requestIDEncoding.EncodeToString(b[:])
+}
+
+// RequestMode selects how a RequestLogger emits its entries.
+type RequestMode int
+
+const (
+	// RequestBlock writes the buffered entries as one contiguous block.
+	RequestBlock RequestMode = iota
+	// RequestSummary writes a single entry whose "events" field lists the
+	// buffered entries.
+	RequestSummary
+)
+
+// RequestLogger buffers the entries of one request and emits them together
+// when End is called, so logs of concurrent requests do not interleave.
+// Levels are checked and processors applied when an entry is logged.
+type RequestLogger struct {
+	l      *CustomLogger
+	mode   RequestMode
+	fields []Field
+	start  time.Time
+
+	mu      sync.Mutex
+	entries []*Entry
+	// events holds the call fields of each entry for the summary.
+	events [][]Field
+	ended  bool
+}
+
+// Request starts a request-scoped logger whose entries carry fields.
+func (l *CustomLogger) Request(mode RequestMode, fields ...Field) *RequestLogger {
+	return &RequestLogger{
+		l:      l,
+		mode:   mode,
+		fields: append([]Field(nil), fields...),
+		start:  l.clock(),
+	}
+}
+
+func (r *RequestLogger) log(level LogLevel, msg string, fields ...Field) {
+	if !r.l.enabled(level) {
+		return
+	}
+	var event []Field
+	if r.mode == RequestSummary && len(fields) > 0 {
+		event = append([]Field(nil), fields...)
+		resolveFields(event)
+	}
+	e := r.l.entry(level, msg, append(r.fields[:len(r.fields):len(r.fields)], fields...))
+	c := e.Clone()
+	putEntry(e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ended || len(r.entries) >= DefaultRequestMaxEntries {
+		r.l.counters.dropped.Add(1)
+		return
+	}
+	r.entries = append(r.entries, c)
+	r.events = append(r.events, event)
+}
+
+func (r *RequestLogger) Debug(v ...interface{}) { r.log(Debug, fmt.Sprintln(v...)) }
+func (r *RequestLogger) Info(v ...interface{})  { r.log(Info, fmt.Sprintln(v...)) }
+func (r *RequestLogger) Warn(v ...interface{})  { r.log(Warn, fmt.Sprintln(v...)) }
+
+func (r *RequestLogger) Notice(v ...interface{}) { r.log(Notice, fmt.Sprintln(v...)) }
+
+func (r *RequestLogger) Error(format string, v ...interface{}) {
+	r.log(Error, fmt.Sprintf(format, v...))
+}
+
+func (r *RequestLogger) Critical(format string, v ...interface{}) {
+	r.log(Critical, fmt.Sprintf(format, v...))
+}
+
+func (r *RequestLogger) Alert(format string, v ...interface{}) {
+	r.log(Alert, fmt.Sprintf(format, v...))
+}
+
+func (r *RequestLogger) Emergency(format string, v ...interface{}) {
+	r.log(Emergency, fmt.Sprintf(format, v...))
+}
+
+// Log buffers msg with fields at the given level.
+func (r *RequestLogger) Log(level LogLevel, msg string, fields ...Field) {
+	r.log(level, msg, fields...)
+}
+
+// Fatalf emits the buffered entries and then behaves like
+// CustomLogger.Fatalf.
+func (r *RequestLogger) Fatalf(format string, v ...interface{}) {
+	r.End()
+	r.l.Flush()
+	fmt.Fprintln(os.Stderr, fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+// End emits the buffered entries; extra fields are added to the summary
+// entry in RequestSummary mode. Later calls and entries are ignored.
+func (r *RequestLogger) End(fields ...Field) {
+	r.mu.Lock()
+	if r.ended {
+		r.mu.Unlock()
+		return
+	}
+	r.ended = true
+	entries, events := r.entries, r.events
+	r.entries, r.events = nil, nil
+	r.mu.Unlock()
+
+	if r.l.closed.Load() {
+		r.l.counters.closed.Add(uint64(len(entries)))
+		return
+	}
+	if r.mode == RequestSummary {
+		r.summarize(entries, events, fields)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	for i, s := range r.l.sinks {
+		err := writeBlock(s, entries)
+		r.l.health[i].record(time.Now(), err)
+		if err != nil {
+			r.l.counters.failed.Add(1)
+			r.l.errorHandler(err)
+		}
+	}
+}
+
+// summarize writes one entry at the highest buffered level, Info if none.
+func (r *RequestLogger) summarize(entries []*Entry, calls [][]Field, extra []Field) {
+	level := Info
+	events := make([]interface{}, len(entries))
+	for i, e := range entries {
+		if e.Level > level {
+			level = e.Level
+		}
+		ev := map[string]interface{}{
+			"time":  e.Time.Format(time.RFC3339Nano),
+			"level": e.Level.String(),
+			"msg":   e.Message,
+		}
+		if len(calls[i]) > 0 {
+			ev["fields"] = fieldMap(calls[i])
+		}
+		events[i] = ev
+	}
+
+	fields := append(r.fields[:len(r.fields):len(r.fields)],
+		Field{Key: DurationKey, Value: r.l.clock().Sub(r.start)},
+		Field{Key: "events", Value: events})
+	e := r.l.entry(level, RequestSummaryMessage, append(fields, extra...))
+	r.l.write(e)
+	putEntry(e)
+}
+
+// Ensure RequestLogger implements Logger
+var _ Logger = (*RequestLogger)(nil)
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRequestTestLogger(t *testing.T, dst Sink) *CustomLogger {
+	t.Helper()
+	now := testTime
+	l, err := New(Info, "test", "", WithOutputLevel(Emergency+1), WithSinks(dst),
+		WithClock(func() time.Time { now = now.Add(time.Second); return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close(context.Background()) })
+	return l
+}
+
+func messages(entries []*Entry) string {
+	msgs := make([]string, len(entries))
+	for i, e := range entries {
+		msgs[i] = strings.TrimSpace(e.Message)
+	}
+	return strings.Join(msgs, "|")
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 16 || a == b || strings.Trim(a, "0123456789abcdefghijklmnopqrstuv") != "" {
+		t.Errorf("IDs %q, %q", a, b)
+	}
+}
+
+func TestRequestBlock(t *testing.T) {
+	dst := &countSink{keep: true}
+	l := newRequestTestLogger(t, dst)
+	r := l.Request(RequestBlock, String("request_id", "r1"))
+	r.Info("start")
+	r.Debug("skipped")
+	l.Info("other")
+	r.Log(Warn, "slow", Int("ms", 900))
+	if messages(dst.entries) != "other" {
+		t.Fatalf("wrote %q before End", messages(dst.entries))
+	}
+	r.End()
+	r.Info("late")
+	r.End()
+	if got := messages(dst.entries); got != "other|start|slow" {
+		t.Errorf("wrote %q", got)
+	}
+	if e := dst.entries[2]; len(e.Fields) != 2 || e.Fields[0].Key != "request_id" || e.Fields[1].Key != "ms" {
+		t.Errorf("fields %+v", e.Fields)
+	}
+	if st := l.Stats(); st.Dropped != 1 {
+		t.Errorf("stats %+v", st)
+	}
+}
+
+func TestRequestSummary(t *testing.T) {
+	dst := &countSink{keep: true}
+	l := newRequestTestLogger(t, dst)
+	r := l.Request(RequestSummary, String("request_id", "r1"))
+	r.Info("start")
+	r.Error("failed: %s", "timeout")
+	r.End(Int("status", 504))
+	if len(dst.entries) != 1 {
+		t.Fatalf("wrote %q", messages(dst.entries))
+	}
+	e := dst.entries[0]
+	if e.Level != Error || e.Message != RequestSummaryMessage {
+		t.Errorf("summary %v %q", e.Level, e.Message)
+	}
+	m := e.FieldMap()
+	if m["request_id"] != "r1" || m["status"] != int64(504) || m[DurationKey] == nil {
+		t.Errorf("fields %v", m)
+	}
+	events, _ := m["events"].([]interface{})
+	if len(events) != 2 {
+		t.Fatalf("events %v", m["events"])
+	}
+	if ev := events[1].(map[string]interface{}); ev["level"] != "ERROR" || ev["msg"] != "failed: timeout" {
+		t.Errorf("event %v", ev)
+	}
+
+	// An empty request still reports its completion.
+	dst.entries = nil
+	l.Request(RequestSummary).End()
+	if len(dst.entries) != 1 || dst.entries[0].Level != Info {
+		t.Errorf("wrote %q", messages(dst.entries))
+	}
+}
+
+func TestRequestMaxEntries(t *testing.T) {
+	dst := &countSink{keep: true}
+	l := newRequestTestLogger(t, dst)
+	r := l.Request(RequestBlock)
+	for i := 0; i < DefaultRequestMaxEntries+2; i++ {
+		r.Info("entry")
+	}
+	r.End()
+	if len(dst.entries) != DefaultRequestMaxEntries || l.Stats().Dropped != 2 {
+		t.Errorf("wrote %d, stats %+v", len(dst.entries), l.Stats())
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes exponential backoff with jitter for remote delivery.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is applied to the delay after every attempt.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1).
+	Jitter float64
+}
+
+// DefaultRetryPolicy is suitable for most HTTP and TCP collectors.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that RetryPolicy.Do returns it without retrying,
+// e.g. for a request the collector rejected as malformed.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Backoff returns the delay to wait after the given failed attempt,
+// counting from 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(mult, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// Do calls fn until it succeeds, returns a permanent error, the attempts are
+// exhausted or ctx is done. The last error is returned.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil || IsPermanent(err) || attempt >= attempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retrySender retries a BatchSender according to a RetryPolicy.
+type retrySender struct {
+	sender BatchSender
+	policy RetryPolicy
+}
+
+// WithRetry wraps sender so every batch is retried according to policy.
+func WithRetry(sender BatchSender, policy RetryPolicy) BatchSender {
+	return &retrySender{sender: sender, policy: policy}
+}
+
+// Acknowledges implements Acknowledger for the wrapped sender.
+func (s *retrySender) Acknowledges() bool {
+	return acknowledges(s.sender)
+}
+
+// SendBatch implements BatchSender.
+func (s *retrySender) SendBatch(ctx context.Context, batch []*Entry) error {
+	return s.policy.Do(ctx, func(ctx context.Context) error {
+		return s.sender.SendBatch(ctx, batch)
+	})
+}
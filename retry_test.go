@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second} {
+		if d := p.Backoff(attempt); d != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, d, want)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.Backoff(2); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("jittered Backoff(2) = %v", d)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	calls := 0
+	fail := func(err error, times int) BatchSender {
+		calls = 0
+		return BatchSenderFunc(func(context.Context, []*Entry) error {
+			if calls++; calls <= times {
+				return err
+			}
+			return nil
+		})
+	}
+	if err := WithRetry(fail(io.ErrUnexpectedEOF, 2), p).SendBatch(context.Background(), nil); err != nil || calls != 3 {
+		t.Errorf("transient failures: %v after %d calls", err, calls)
+	}
+	if err := WithRetry(fail(io.ErrUnexpectedEOF, 5), p).SendBatch(context.Background(), nil); err != io.ErrUnexpectedEOF || calls != 3 {
+		t.Errorf("exhausted: %v after %d calls", err, calls)
+	}
+	err := WithRetry(fail(Permanent(io.ErrUnexpectedEOF), 5), p).SendBatch(context.Background(), nil)
+	if !IsPermanent(err) || !errors.Is(err, io.ErrUnexpectedEOF) || calls != 1 {
+		t.Errorf("permanent: %v after %d calls", err, calls)
+	}
+
+	// A done context ends the backoff.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.InitialBackoff = time.Hour
+	if err := WithRetry(fail(io.ErrUnexpectedEOF, 5), p).SendBatch(ctx, nil); err != io.ErrUnexpectedEOF || calls != 1 {
+		t.Errorf("canceled: %v after %d calls", err, calls)
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+}
+
+func TestSenderSink(t *testing.T) {
+	var deadline bool
+	s := NewSenderSink(BatchSenderFunc(func(ctx context.Context, batch []*Entry) error {
+		_, deadline = ctx.Deadline()
+		if len(batch) != 1 || batch[0].Message != "m" {
+			t.Errorf("batch %v", batch)
+		}
+		return nil
+	}), 0)
+	if err := s.WriteEntry(&Entry{Message: "m"}); err != nil || !deadline {
+		t.Errorf("WriteEntry = %v, deadline %v", err, deadline)
+	}
+}
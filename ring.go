@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLinePad separates hot atomics so producers and the consumer do not
+// contend on the same cache line.
+type cacheLinePad [64]byte
+
+type ringSlot struct {
+	seq   atomic.Uint64
+	entry *Entry
+}
+
+// ring is a bounded lock-free queue after Dmitry Vyukov's design. Any number
+// of goroutines may push; pop is also safe for concurrent use, which lets
+// producers evict the oldest entry when the ring is full.
+type ring struct {
+	slots []ringSlot
+	mask  uint64
+	_     cacheLinePad
+	head  atomic.Uint64
+	_     cacheLinePad
+	tail  atomic.Uint64
+	_     cacheLinePad
+}
+
+// newRing creates a ring holding at least size entries, rounded up to a
+// power of two.
+func newRing(size int) *ring {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	r := &ring{
+		slots: make([]ringSlot, n),
+		mask:  uint64(n - 1),
+	}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	return r
+}
+
+// push adds e and reports false if the ring is full.
+func (r *ring) push(e *Entry) bool {
+	for {
+		pos := r.tail.Load()
+		slot := &r.slots[pos&r.mask]
+		diff := int64(slot.seq.Load()) - int64(pos)
+		switch {
+		case diff == 0:
+			if r.tail.CompareAndSwap(pos, pos+1) {
+				slot.entry = e
+				slot.seq.Store(pos + 1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+	}
+}
+
+// pop removes the oldest entry and reports false if the ring is empty.
+func (r *ring) pop() (*Entry, bool) {
+	for {
+		pos := r.head.Load()
+		slot := &r.slots[pos&r.mask]
+		diff := int64(slot.seq.Load()) - int64(pos+1)
+		switch {
+		case diff == 0:
+			if r.head.CompareAndSwap(pos, pos+1) {
+				e := slot.entry
+				slot.entry = nil
+				slot.seq.Store(pos + r.mask + 1)
+				return e, true
+			}
+		case diff < 0:
+			return nil, false
+		}
+	}
+}
+
+// len returns the approximate number of queued entries.
+func (r *ring) len() int {
+	n := int64(r.tail.Load()) - int64(r.head.Load())
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// levelQueue is a bounded FIFO queue that can give up its lowest-level
+// entry, for DropLowestLevel. It takes a lock, unlike ring.
+type levelQueue struct {
+	mu      sync.Mutex
+	entries []*Entry // circular, n entries from head
+	head, n int
+}
+
+func newLevelQueue(size int) *levelQueue {
+	return &levelQueue{entries: make([]*Entry, size)}
+}
+
+// at returns the index of the i-th queued entry.
+func (q *levelQueue) at(i int) int {
+	return (q.head + i) % len(q.entries)
+}
+
+func (q *levelQueue) push(e *Entry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.n == len(q.entries) {
+		return false
+	}
+	q.entries[q.at(q.n)] = e
+	q.n++
+	return true
+}
+
+func (q *levelQueue) pop() (*Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.n == 0 {
+		return nil, false
+	}
+	e := q.entries[q.head]
+	q.entries[q.head] = nil
+	q.head = q.at(1)
+	q.n--
+	return e, true
+}
+
+func (q *levelQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// evictLowest removes the oldest of the lowest-level entries, if its level
+// is at most max.
+func (q *levelQueue) evictLowest(max LogLevel) (*Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	low := -1
+	for i := 0; i < q.n; i++ {
+		if e := q.entries[q.at(i)]; e.Level <= max && (low < 0 || e.Level < q.entries[q.at(low)].Level) {
+			low = i
+		}
+	}
+	if low < 0 {
+		return nil, false
+	}
+	e := q.entries[q.at(low)]
+	for i := low; i < q.n-1; i++ {
+		q.entries[q.at(i)] = q.entries[q.at(i+1)]
+	}
+	q.entries[q.at(q.n-1)] = nil
+	q.n--
+	return e, true
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRingSize(t *testing.T) {
+	tests := []struct{ size, want int }{
+		{0, 1}, {1, 1}, {2, 2}, {3, 4}, {1000, 1024}, {1024, 1024},
+	}
+	for _, tt := range tests {
+		if got := len(newRing(tt.size).slots); got != tt.want {
+			t.Errorf("newRing(%d) has %d slots, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestRingOrder(t *testing.T) {
+	r := newRing(4)
+	if _, ok := r.pop(); ok {
+		t.Fatal("pop from empty ring succeeded")
+	}
+	entries := make([]*Entry, 4)
+	// Wrap around the ring several times.
+	for round := 0; round < 3; round++ {
+		for i := range entries {
+			entries[i] = &Entry{Message: string(rune('a' + i))}
+			if !r.push(entries[i]) {
+				t.Fatalf("round %d: push %d failed", round, i)
+			}
+		}
+		if r.push(&Entry{}) {
+			t.Fatalf("round %d: push into full ring succeeded", round)
+		}
+		if n := r.len(); n != 4 {
+			t.Fatalf("round %d: len = %d", round, n)
+		}
+		for i := range entries {
+			e, ok := r.pop()
+			if !ok || e != entries[i] {
+				t.Fatalf("round %d: pop %d = %v, %v", round, i, e, ok)
+			}
+		}
+	}
+}
+
+func TestRingConcurrent(t *testing.T) {
+	const producers, perProducer = 8, 1000
+	r := newRing(64)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				for !r.push(&Entry{}) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	done := make(chan int)
+	go func() {
+		n := 0
+		for n < producers*perProducer {
+			if _, ok := r.pop(); ok {
+				n++
+			} else {
+				runtime.Gosched()
+			}
+		}
+		done <- n
+	}()
+	wg.Wait()
+	if n := <-done; n != producers*perProducer {
+		t.Errorf("popped %d entries", n)
+	}
+}
+
+func BenchmarkRing(b *testing.B) {
+	r := newRing(1024)
+	e := new(Entry)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if !r.push(e) {
+				r.pop()
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultArchiveTemplate = "{name}-{date}-{seq}{ext}"
+
+	RotateErrFmt      = "Failed to rotate log file: %w"
+	ArchiveNameErrFmt = "No unused archive name for %s with template %q"
+)
+
+// checkRotation accepts every FileConfig; the logger_minimal build
+// rejects rotation and archiving.
+func checkRotation(FileConfig) error { return nil }
+
+// archiveName returns the first unused archive path for the file at path.
+func archiveName(path, tmpl string, now time.Time) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultArchiveTemplate
+	}
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	for seq := 1; seq < 10000; seq++ {
+		archive := filepath.Join(dir, expandTemplate(tmpl,
+			"{name}", name,
+			"{ext}", ext,
+			"{date}", now.Format("2006-01-02"),
+			"{time}", now.Format("150405"),
+			"{seq}", strconv.Itoa(seq),
+		))
+		if _, err := os.Lstat(archive); errors.Is(err, os.ErrNotExist) {
+			return archive, nil
+		}
+		if !strings.Contains(tmpl, "{seq}") {
+			break
+		}
+	}
+	return "", fmt.Errorf(ArchiveNameErrFmt, path, tmpl)
+}
+
+// latestArchive returns the most recently modified archive of the file at
+// path, compressed or not, other than exclude; "" if there is none.
+func latestArchive(path, tmpl, exclude string) string {
+	archives := listArchives(path, tmpl, exclude)
+	if len(archives) == 0 {
+		return ""
+	}
+	return archives[len(archives)-1]
+}
+
+// listArchives returns the archives of the file at path, compressed or
+// not, other than exclude, oldest first.
+func listArchives(path, tmpl, exclude string) []string {
+	if tmpl == "" {
+		tmpl = DefaultArchiveTemplate
+	}
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	pattern := strings.NewReplacer(
+		"{name}", strings.TrimSuffix(base, ext),
+		"{ext}", ext,
+		"{date}", "*", "{time}", "*", "{seq}", "*", "{host}", "*", "{pid}", "*",
+	).Replace(tmpl)
+	pattern = filepath.Join(dir, pattern)
+
+	var archives []string
+	mod := make(map[string]time.Time)
+	for _, p := range []string{pattern, pattern + ".gz"} {
+		matches, _ := filepath.Glob(p)
+		for _, m := range matches {
+			info, err := os.Lstat(m)
+			if m == exclude || m == path || err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			archives = append(archives, m)
+			mod[m] = info.ModTime()
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return mod[archives[i]].Before(mod[archives[j]])
+	})
+	return archives
+}
+
+// shouldRotate reports whether writing n more bytes requires a rotation.
+// lf.mu must be held.
+func (lf *File) shouldRotate(n int) bool {
+	r := lf.cfg.Rotation
+	if r.MaxSize > 0 && lf.size > 0 && lf.size+int64(n) > r.MaxSize {
+		return true
+	}
+	return r.Interval > 0 && time.Since(lf.opened) >= r.Interval
+}
+
+// Rotate closes the current file, moves it to its archive name and opens a
+// new one at the original path.
+func (lf *File) Rotate() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	return lf.rotateLocked()
+}
+
+func (lf *File) rotateLocked() error {
+	if err := lf.finishLocked(); err != nil {
+		lf.cfg.ErrorHandler(fmt.Errorf(RotateErrFmt, err))
+	}
+	if err := lf.syncLocked(); err != nil {
+		lf.cfg.ErrorHandler(err)
+	}
+
+	if lf.cfg.Rotation.Symlink {
+		old, oldPath := lf.f, lf.current
+		if err := lf.openLocked(); err != nil {
+			return fmt.Errorf(RotateErrFmt, err)
+		}
+		if err := old.Close(); err != nil {
+			lf.cfg.ErrorHandler(fmt.Errorf(RotateErrFmt, err))
+		}
+		lf.archived(oldPath)
+		return nil
+	}
+
+	archive, err := archiveName(lf.path, lf.cfg.Rotation.ArchiveTemplate, time.Now())
+	if err != nil {
+		return fmt.Errorf(RotateErrFmt, err)
+	}
+	if lf.cfg.Lock {
+		// Rename while holding the lock, so that another process does not
+		// rotate the file to the same archive name. Windows cannot rename
+		// an open file and renames it after closing as usual.
+		if err := os.Rename(lf.path, archive); err == nil {
+			if err := lf.f.Close(); err != nil {
+				lf.cfg.ErrorHandler(fmt.Errorf(RotateErrFmt, err))
+			}
+			if err := lf.openLocked(); err != nil {
+				return fmt.Errorf(RotateErrFmt, err)
+			}
+			lf.archived(archive)
+			return nil
+		}
+	}
+	if err := lf.f.Close(); err != nil {
+		lf.cfg.ErrorHandler(fmt.Errorf(RotateErrFmt, err))
+	}
+	if err := os.Rename(lf.path, archive); err != nil {
+		// Keep logging to the old file rather than losing entries.
+		if oerr := lf.openLocked(); oerr != nil {
+			return fmt.Errorf(RotateErrFmt, oerr)
+		}
+		return fmt.Errorf(RotateErrFmt, err)
+	}
+	if err := lf.openLocked(); err != nil {
+		return fmt.Errorf(RotateErrFmt, err)
+	}
+	lf.archived(archive)
+	return nil
+}
+
+// archived hands a finished archive to the archiver, if any, without
+// waiting for it.
+func (lf *File) archived(path string) {
+	if lf.archiver != nil {
+		lf.archiver.add(path)
+	}
+}
+
+// nextLinkedFile picks the file to write in symlink mode. On the first open
+// an existing link is followed so a restart keeps appending to the same
+// file; a regular file left at the link path is archived first.
+func (lf *File) nextLinkedFile() (string, error) {
+	if lf.current == "" {
+		if target, err := os.Readlink(lf.path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(lf.path), target)
+			}
+			return target, nil
+		}
+		if info, err := os.Lstat(lf.path); err == nil && info.Mode().IsRegular() {
+			archive, err := archiveName(lf.path, lf.cfg.Rotation.ArchiveTemplate, time.Now())
+			if err != nil {
+				return "", err
+			}
+			if err := os.Rename(lf.path, archive); err != nil {
+				return "", err
+			}
+		}
+	}
+	return archiveName(lf.path, lf.cfg.Rotation.ArchiveTemplate, time.Now())
+}
+
+// updateSymlink atomically points link at target, relative to the link's
+// directory so that archive templates with subdirectories resolve.
+func updateSymlink(link, target string) error {
+	rel, err := filepath.Rel(filepath.Dir(link), target)
+	if err != nil {
+		rel = target
+	}
+	tmp := link + ".link"
+	os.Remove(tmp)
+	if err := os.Symlink(rel, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build logger_minimal
+
+package logger
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrRotationCompiledOut is returned by OpenFile for a FileConfig with
+// rotation, symlink rotation or archiving, and by File.Rotate, in builds
+// with the logger_minimal tag. LowDiskPrune reports it on every check.
+var ErrRotationCompiledOut = errors.New("log rotation is not part of the logger_minimal build")
+
+// checkRotation rejects the rotation and archiving settings.
+func checkRotation(cfg FileConfig) error {
+	if cfg.Rotation.enabled() || cfg.Rotation.Symlink || cfg.Archive.enabled() {
+		return ErrRotationCompiledOut
+	}
+	return nil
+}
+
+// Rotate returns ErrRotationCompiledOut.
+func (lf *File) Rotate() error {
+	return ErrRotationCompiledOut
+}
+
+func (lf *File) shouldRotate(int) bool { return false }
+
+func (lf *File) rotateLocked() error { return ErrRotationCompiledOut }
+
+func (lf *File) nextLinkedFile() (string, error) { return lf.path, nil }
+
+func updateSymlink(link, target string) error { return nil }
+
+func latestArchive(path, tmpl, exclude string) string { return "" }
+
+func listArchives(path, tmpl, exclude string) []string { return nil }
+
+type archiver struct{}
+
+func startArchiver(ArchiveConfig, os.FileMode, ErrorHandler) *archiver { return nil }
+
+func (a *archiver) close() {}
@@ -0,0 +1,72 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRotateFile(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(Info, "app", filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("first")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Close(context.Background())
+	if paths, _ := filepath.Glob(filepath.Join(dir, "*")); len(paths) != 2 {
+		t.Errorf("files after Rotate: %v", paths)
+	}
+}
+
+func TestRotateSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	// A plain file left at the path is archived rather than replaced.
+	os.WriteFile(path, []byte("old\n"), 0o644)
+	cfg := FileConfig{Rotation: RotationConfig{Symlink: true}}
+	f, err := OpenFile(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("one\n"))
+	first, err := os.Readlink(path)
+	if err != nil || filepath.IsAbs(first) || filepath.Join(dir, first) != f.CurrentPath() {
+		t.Fatalf("link %q (%v), current %q", first, err, f.CurrentPath())
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("two\n"))
+	f.Close()
+	if b, _ := os.ReadFile(filepath.Join(dir, first)); string(b) != "one\n" {
+		t.Errorf("first file %q", b)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "two\n" {
+		t.Errorf("file behind the link %q", b)
+	}
+
+	// Reopening appends to the file the link points at.
+	second, _ := os.Readlink(path)
+	if f, err = OpenFile(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("three\n"))
+	f.Close()
+	if link, _ := os.Readlink(path); link != second {
+		t.Errorf("link moved from %q to %q", second, link)
+	}
+	if paths, _ := filepath.Glob(filepath.Join(dir, "app-*.log")); len(paths) != 3 {
+		t.Errorf("files %v, want the old file and two linked ones", paths)
+	}
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+const ParseRoutesErrFmt = "Invalid route %q: want names=path"
+
+// FilterSink passes the entries selected by a Matcher to a sink, e.g. one
+// compiled by ParseMatcher or NameMatcher.
+type FilterSink struct {
+	wrappedSink
+	match Matcher
+}
+
+// NewFilterSink creates a FilterSink in front of sink.
+func NewFilterSink(sink Sink, match Matcher) *FilterSink {
+	return &FilterSink{wrappedSink: wrappedSink{sink: sink}, match: match}
+}
+
+// WriteEntry implements Sink.
+func (s *FilterSink) WriteEntry(e *Entry) error {
+	if !s.match(e) {
+		return nil
+	}
+	return s.write(e)
+}
+
+// WriteBlock implements BlockWriter, writing the selected entries as one
+// block.
+func (s *FilterSink) WriteBlock(entries []*Entry) error {
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if s.match(e) {
+			kept = append(kept, e)
+		}
+	}
+	return s.writeBlock(kept)
+}
+
+// NameMatcher selects the entries whose logger name matches one of the
+// patterns. A pattern ending in ".*" matches the name before it and every
+// name below, so "app.db.*" selects app.db and app.db.pool; "*" matches
+// every name; any other pattern matches only itself. Entry names start
+// with the root logger's name.
+func NameMatcher(patterns ...string) Matcher {
+	return func(e *Entry) bool {
+		for _, p := range patterns {
+			if matchName(p, e.Name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func matchName(pattern, name string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return name == prefix || strings.HasPrefix(name, prefix+".")
+	}
+	return name == pattern
+}
+
+// Route sends the entries of some loggers to an output of their own
+// instead of the default output, see WithRoutes.
+type Route struct {
+	// Names are NameMatcher patterns of the routed loggers.
+	Names []string
+	// Output is where the routed entries go. A nil Encoder selects the
+	// encoder of the default output.
+	Output Output
+}
+
+// WithRoutes sends the entries of the named loggers to dedicated outputs,
+// e.g. "app.db.*" to db.log and "app.http.access" to access.log, while
+// every other entry goes to the default output. An entry goes to the first
+// route that matches. Sinks added by WithSinks and WithOutput still
+// receive every entry.
+func WithRoutes(routes ...Route) Option {
+	return func(l *CustomLogger) {
+		l.routes = append(l.routes, routes...)
+	}
+}
+
+// ParseRoutes parses routes written as comma separated names=path pairs,
+// with several names joined by "|", e.g.
+//
+//	app.db.*|app.cache.*=db.log,app.http.access=access.log
+//
+// for configuration files and environment variables.
+func ParseRoutes(spec string) ([]Route, error) {
+	var routes []Route
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		names, path, ok := strings.Cut(part, "=")
+		names, path = strings.TrimSpace(names), strings.TrimSpace(path)
+		if !ok || names == "" || path == "" {
+			return nil, fmt.Errorf(ParseRoutesErrFmt, part)
+		}
+		r := Route{Output: Output{Path: path}}
+		for _, n := range strings.Split(names, "|") {
+			if n = strings.TrimSpace(n); n == "" {
+				return nil, fmt.Errorf(ParseRoutesErrFmt, part)
+			}
+			r.Names = append(r.Names, n)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// openRoutes opens the outputs of the routes and returns their sinks, and
+// out filtered to the entries no route takes.
+func (l *CustomLogger) openRoutes(out Sink) (Sink, []Sink, error) {
+	var sinks []Sink
+	var taken []Matcher
+	for _, r := range l.routes {
+		o := r.Output
+		if o.Encoder == nil {
+			o.Encoder = l.outputEncoder()
+		}
+		s, err := l.openOutput(o)
+		if err != nil {
+			for _, s := range sinks {
+				s.(*FilterSink).Close()
+			}
+			return nil, nil, err
+		}
+		// Earlier routes take precedence, so each one skips what they took.
+		match, prev := NameMatcher(r.Names...), append([]Matcher(nil), taken...)
+		sinks = append(sinks, NewFilterSink(s, func(e *Entry) bool {
+			return match(e) && !anyMatch(prev, e)
+		}))
+		taken = append(taken, match)
+	}
+	return NewFilterSink(out, func(e *Entry) bool { return !anyMatch(taken, e) }), sinks, nil
+}
+
+func anyMatch(ms []Matcher, e *Entry) bool {
+	for _, m := range ms {
+		if m(e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNameMatcher(t *testing.T) {
+	m := NameMatcher("app.db.*", "app.http.access")
+	for name, want := range map[string]bool{
+		"app.db":          true,
+		"app.db.pool":     true,
+		"app.dbx":         false,
+		"app.http.access": true,
+		"app.http":        false,
+		"":                false,
+	} {
+		if got := m(&Entry{Name: name}); got != want {
+			t.Errorf("%q: got %v, want %v", name, got, want)
+		}
+	}
+	if !NameMatcher("*")(&Entry{}) {
+		t.Error("* did not match")
+	}
+}
+
+func TestWithRoutes(t *testing.T) {
+	dir := t.TempDir()
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	routes, err := ParseRoutes("app.db.*=" + filepath.Join(dir, "db.log") +
+		", app.http.access | app.db.slow=" + filepath.Join(dir, "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := &countSink{}
+	l, err := New(Info, "app", filepath.Join(dir, "app.log"), WithEncoder(JSONEncoder{}), WithRoutes(routes...), WithSinks(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("started")
+	l.Named("db").Info("query")
+	l.Named("db").Named("slow").Info("slow query")
+	l.Named("http").Named("access").Info("GET /")
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := read("app.log"); !strings.Contains(s, "started") || strings.Count(s, "\n") != 1 {
+		t.Errorf("app.log = %q", s)
+	}
+	if s := read("db.log"); !strings.Contains(s, `"msg":"query"`) || !strings.Contains(s, "slow query") {
+		t.Errorf("db.log = %q", s)
+	}
+	if s := read("access.log"); !strings.Contains(s, "GET /") || strings.Contains(s, "slow") {
+		t.Errorf("access.log = %q", s)
+	}
+	if n := extra.n.Load(); n != 4 {
+		t.Errorf("extra sink got %d entries, want 4", n)
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes("a.*|b=x.log,,c=y.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Route{
+		{Names: []string{"a.*", "b"}, Output: Output{Path: "x.log"}},
+		{Names: []string{"c"}, Output: Output{Path: "y.log"}},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("routes = %+v", routes)
+	}
+	for _, bad := range []string{"a.log", "=a.log", "a=", "a||b=x.log"} {
+		if _, err := ParseRoutes(bad); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
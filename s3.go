@@ -0,0 +1,202 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const S3StatusErrFmt = "S3 upload of %s failed with status %s: %s"
+
+// S3Config configures an S3Uploader.
+type S3Config struct {
+	// Bucket receives the archives.
+	Bucket string
+	// Region of the bucket, e.g. "eu-west-1".
+	Region string
+	// Prefix is prepended to the archive file name to form the object key,
+	// e.g. "logs/api/".
+	Prefix string
+	// Endpoint overrides the AWS endpoint for S3 compatible stores such as
+	// MinIO; objects are then addressed path-style.
+	Endpoint string
+	// AccessKeyID, SecretAccessKey and SessionToken default to the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	// environment variables.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// ServerSideEncryption is "AES256" or "aws:kms"; empty uses the bucket
+	// default.
+	ServerSideEncryption string
+	// KMSKeyID selects the key for "aws:kms" encryption.
+	KMSKeyID string
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// S3Uploader uploads log archives to an S3 bucket with a signed PUT request,
+// without depending on the AWS SDK. Objects are limited to the 5 GiB of a
+// single PUT.
+type S3Uploader struct {
+	cfg S3Config
+}
+
+// NewS3Uploader creates an S3Uploader, filling missing credentials from the
+// environment.
+func NewS3Uploader(cfg S3Config) *S3Uploader {
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &S3Uploader{cfg: cfg}
+}
+
+// Upload implements Uploader.
+func (u *S3Uploader) Upload(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := u.cfg.Prefix + filepath.Base(path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.objectURL(key), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if u.cfg.ServerSideEncryption != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption", u.cfg.ServerSideEncryption)
+		if u.cfg.KMSKeyID != "" {
+			req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", u.cfg.KMSKeyID)
+		}
+	}
+	signV4(req, hex.EncodeToString(h.Sum(nil)), "s3", u.cfg.Region,
+		u.cfg.AccessKeyID, u.cfg.SecretAccessKey, u.cfg.SessionToken, time.Now())
+
+	resp, err := u.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf(S3StatusErrFmt, key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (u *S3Uploader) objectURL(key string) string {
+	if u.cfg.Endpoint != "" {
+		return strings.TrimSuffix(u.cfg.Endpoint, "/") + "/" + u.cfg.Bucket + "/" + s3EscapePath(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.cfg.Bucket, u.cfg.Region, s3EscapePath(key))
+}
+
+// s3EscapePath URI-encodes every key segment as required by SigV4: all
+// bytes except the RFC 3986 unreserved characters are percent-encoded.
+func s3EscapePath(key string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// signV4 adds AWS Signature Version 4 headers to req, signing every header
+// set so far.
+func signV4(req *http.Request, payloadHash, service, region, accessKey, secretKey, token string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
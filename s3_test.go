@@ -0,0 +1,37 @@
+//go:build !logger_minimal
+
+package logger
+
+import "testing"
+
+func TestS3EscapePath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"logs/app.log.gz", "logs/app.log.gz"},
+		{"a b/c+d", "a%20b/c%2Bd"},
+		{"x=1&y=2", "x%3D1%26y%3D2"},
+		{"~user_-.", "~user_-."},
+		{"ü", "%C3%BC"},
+		{"a*b(c)!'", "a%2Ab%28c%29%21%27"},
+		{"50%", "50%25"},
+	}
+	for _, tt := range tests {
+		if got := s3EscapePath(tt.in); got != tt.want {
+			t.Errorf("s3EscapePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	tests := []struct {
+		cfg  S3Config
+		want string
+	}{
+		{S3Config{Bucket: "b", Region: "eu-west-1"}, "https://b.s3.eu-west-1.amazonaws.com/k%20y"},
+		{S3Config{Bucket: "b", Endpoint: "http://minio:9000/"}, "http://minio:9000/b/k%20y"},
+	}
+	for _, tt := range tests {
+		if got := NewS3Uploader(tt.cfg).objectURL("k y"); got != tt.want {
+			t.Errorf("objectURL = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSampleTarget is the entries per window written in full.
+	DefaultSampleTarget = 1000
+	// DefaultSampleWindow is the interval over which the rate is measured.
+	DefaultSampleWindow = time.Second
+)
+
+// SamplerConfig configures adaptive sampling, see WithAdaptiveSampling.
+type SamplerConfig struct {
+	// Target is the number of entries per Window written before sampling
+	// starts; zero selects DefaultSampleTarget.
+	Target int
+	// Window is the interval over which the entry rate is measured; zero
+	// selects DefaultSampleWindow.
+	Window time.Duration
+	// Exempt entries, at or above this level, are never sampled; zero
+	// means Error.
+	Exempt LogLevel
+	// ExplicitLevels uses Exempt as given, so Debug (zero) exempts every
+	// level and turns sampling off.
+	ExplicitLevels bool
+}
+
+// sampler keeps the first target entries of each window and then one in
+// every n, where n follows a moving average of the rate of the previous
+// windows, so it tightens as volume climbs and relaxes as it drops. Within
+// a window, n also grows with the count so far, bounding a sudden spike
+// before the average catches up.
+type sampler struct {
+	target int
+	window time.Duration
+	exempt LogLevel
+
+	mu    sync.Mutex
+	start time.Time
+	count int     // entries seen in the current window
+	rate  float64 // smoothed entries per window
+	every int     // factor derived from rate
+	next  int     // count at which the next entry past target is kept
+}
+
+func newSampler(cfg SamplerConfig) *sampler {
+	if cfg.Target <= 0 {
+		cfg.Target = DefaultSampleTarget
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultSampleWindow
+	}
+	if cfg.Exempt == 0 && !cfg.ExplicitLevels {
+		cfg.Exempt = Error
+	}
+	return &sampler{target: cfg.Target, window: cfg.Window, exempt: cfg.Exempt, every: 1}
+}
+
+// allow reports whether an entry at level, logged at now, is written.
+func (s *sampler) allow(level LogLevel, now time.Time) bool {
+	if level >= s.exempt {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elapsed := now.Sub(s.start); elapsed >= s.window || elapsed < 0 {
+		s.advance(now, elapsed)
+	}
+	s.count++
+	if s.count <= s.target || s.count >= s.next {
+		s.next = s.count + s.factorLocked()
+		return true
+	}
+	return false
+}
+
+// factorLocked returns the current factor, the larger of the one from the
+// smoothed rate and the one from the count of this window.
+func (s *sampler) factorLocked() int {
+	if n := s.count / s.target; n > s.every {
+		return n
+	}
+	return s.every
+}
+
+// advance starts a new window, folding the count of the last one into the
+// smoothed rate. Windows that passed without entries count as empty.
+func (s *sampler) advance(now time.Time, elapsed time.Duration) {
+	if s.start.IsZero() || elapsed < 0 {
+		s.rate = 0
+	} else {
+		s.rate = (s.rate + float64(s.count)) / 2
+		for idle := elapsed/s.window - 1; idle > 0 && s.rate > 0; idle-- {
+			s.rate /= 2
+		}
+	}
+	s.every = 1
+	if s.rate > float64(s.target) {
+		// At the recent rate, about target more entries get through.
+		s.every = int(math.Ceil(s.rate / float64(s.target)))
+	}
+	s.start, s.count, s.next = now, 0, 0
+}
+
+// factor returns the current sampling factor.
+func (s *sampler) factor() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.factorLocked()
+}
+
+// WithAdaptiveSampling bounds log throughput under load. Each window the
+// first cfg.Target entries are written. Past that, one in n is written,
+// where n grows with the recent entry rate and shrinks as it falls, so
+// throughput stays near twice the target however high the volume climbs.
+// Entries at or above cfg.Exempt are never sampled; the others count as
+// suppressed in Stats.
+func WithAdaptiveSampling(cfg SamplerConfig) Option {
+	return func(l *CustomLogger) {
+		l.sampler = newSampler(cfg)
+	}
+}
+
+// SampleFactor returns the current adaptive sampling factor: 1 if every
+// entry is written, n if one in n past the target is.
+func (l *CustomLogger) SampleFactor() int {
+	if l.sampler == nil {
+		return 1
+	}
+	return l.sampler.factor()
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSampling(t *testing.T) {
+	var buf bytes.Buffer
+	now := testTime
+	l := newTestLogger(t, Info, &buf,
+		WithClock(func() time.Time { return now }),
+		WithAdaptiveSampling(SamplerConfig{Target: 10}))
+	burst := func(n int) int {
+		buf.Reset()
+		for i := 0; i < n; i++ {
+			l.Info("tick")
+		}
+		return strings.Count(buf.String(), "\n")
+	}
+
+	first := burst(100)
+	if first <= 10 || first >= 50 {
+		t.Errorf("first window of 100 wrote %d", first)
+	}
+	if f := l.SampleFactor(); f != 10 {
+		t.Errorf("factor after 100 = %d, want 10", f)
+	}
+	now = now.Add(time.Second)
+	second := burst(100)
+	if second >= first {
+		t.Errorf("second window wrote %d, want fewer than %d", second, first)
+	}
+	if st := l.Stats(); st.Suppressed != uint64(200-first-second) {
+		t.Errorf("Suppressed = %d, want %d", st.Suppressed, 200-first-second)
+	}
+
+	buf.Reset()
+	for i := 0; i < 20; i++ {
+		l.Error("failed")
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 20 {
+		t.Errorf("wrote %d of 20 errors", n)
+	}
+
+	// Quiet windows relax the sampling again.
+	now = now.Add(10 * time.Second)
+	if n := burst(5); n != 5 || l.SampleFactor() != 1 {
+		t.Errorf("after a quiet period wrote %d of 5, factor %d", n, l.SampleFactor())
+	}
+}
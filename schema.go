@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// SchemaViolationKey is the field flagging an entry that violates the
+	// schema of its logger.
+	SchemaViolationKey = "schema_violation"
+
+	SchemaErrFmt = "Entry %q of logger %s violates its schema: %s"
+)
+
+// Schema describes the fields the entries of a logger carry, see
+// WithSchema. Keys are dotted for the members of groups.
+type Schema struct {
+	// Required are the keys every entry must have.
+	Required []string
+	// Types are the types of the fields of these keys when present, e.g.
+	// Int64Type for "status"; Int, Int64 and Any of an int all give
+	// Int64Type.
+	Types map[string]FieldType
+	// Allowed, if not nil, lists the top-level keys entries may have
+	// besides Required and Types; any other key is a violation.
+	Allowed []string
+	// Reject drops the entries violating the schema and reports them to
+	// the error handler, counted as failed, instead of flagging them.
+	Reject bool
+}
+
+// WithSchema validates the fields of the entries logged by the loggers
+// whose name matches pattern, see NameMatcher, so field names drifting
+// from what dashboards and alerts query are caught in development and
+// tests. The fields of the call and of With are checked, not those added
+// by processors. A violating entry is flagged with a SchemaViolationKey
+// field describing the violations, or dropped with Schema.Reject. The
+// first matching schema applies.
+func WithSchema(pattern string, s Schema) Option {
+	return func(l *CustomLogger) {
+		allowed := map[string]bool(nil)
+		if s.Allowed != nil {
+			allowed = make(map[string]bool)
+			for _, keys := range [][]string{s.Allowed, s.Required} {
+				for _, k := range keys {
+					allowed[topKey(k)] = true
+				}
+			}
+			for k := range s.Types {
+				allowed[topKey(k)] = true
+			}
+		}
+		l.schemas = append(l.schemas, schemaRule{pattern: pattern, schema: s, allowed: allowed})
+	}
+}
+
+type schemaRule struct {
+	pattern string
+	schema  Schema
+	allowed map[string]bool
+}
+
+// validate checks the fields of a call against the schema of the logger.
+// It returns the violations found and false if the entry is rejected.
+func (l *CustomLogger) validate(msg string, fields []Field) (string, bool) {
+	var rule *schemaRule
+	for i := range l.schemas {
+		if matchName(l.schemas[i].pattern, l.name) {
+			rule = &l.schemas[i]
+			break
+		}
+	}
+	if rule == nil {
+		return "", true
+	}
+	all := l.fields
+	if len(fields) > 0 {
+		call := fields
+		if len(l.groups) > 0 {
+			call = groupFields(l.groups, fields)
+		}
+		all = append(all[:len(all):len(all)], call...)
+	}
+	violations := rule.check(all)
+	if len(violations) == 0 {
+		return "", true
+	}
+	desc := strings.Join(violations, "; ")
+	if rule.schema.Reject {
+		l.counters.failed.Add(1)
+		l.errorHandler(fmt.Errorf(SchemaErrFmt, strings.TrimSuffix(msg, "\n"), l.name, desc))
+		return "", false
+	}
+	return desc, true
+}
+
+// check returns the violations of fields, sorted.
+func (r *schemaRule) check(fields []Field) []string {
+	var violations []string
+	for _, k := range r.schema.Required {
+		if _, ok := findField(fields, k); !ok {
+			violations = append(violations, "missing "+k)
+		}
+	}
+	for k, typ := range r.schema.Types {
+		if f, ok := findField(fields, k); ok && f.Type != typ {
+			violations = append(violations, fmt.Sprintf("%s is %s, want %s", k, f.Type, typ))
+		}
+	}
+	if r.allowed != nil {
+		for _, f := range fields {
+			if !r.allowed[f.Key] {
+				violations = append(violations, "unexpected "+f.Key)
+			}
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+// topKey returns the top-level key of a dotted key.
+func topKey(k string) string {
+	top, _, _ := strings.Cut(k, ".")
+	return top
+}
@@ -0,0 +1,56 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWithSchema(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithEncoder(JSONEncoder{}), WithSchema("test.http", Schema{
+		Required: []string{"method", "status"},
+		Types:    map[string]FieldType{"status": Int64Type, "req.bytes": Int64Type},
+		Allowed:  []string{"path", "req"},
+	}))
+	h := l.Named("http")
+	h.With(String("method", "GET")).Log(Info, "ok", Int("status", 200), Group("req", Int("bytes", 10)))
+	h.Log(Info, "drift", String("status", "200"), String("uri", "/"), Group("req", String("bytes", "10")))
+	l.Log(Info, "other logger", String("uri", "/"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	if strings.Contains(lines[0], SchemaViolationKey) || strings.Contains(lines[2], SchemaViolationKey) {
+		t.Errorf("valid entry flagged: %q", buf.String())
+	}
+	e, err := DecodeJSON([]byte(lines[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "missing method; req.bytes is string, want int64; status is string, want int64; unexpected uri"
+	if got := e.FieldMap()[SchemaViolationKey]; got != want {
+		t.Errorf("violation = %v, want %s", got, want)
+	}
+}
+
+func TestWithSchemaReject(t *testing.T) {
+	var buf bytes.Buffer
+	var errs []error
+	l := newTestLogger(t, Info, &buf, WithErrorHandler(func(err error) { errs = append(errs, err) }),
+		WithSchema("*", Schema{Required: []string{"user"}, Reject: true}))
+	l.Log(Info, "login", String("user", "alice"))
+	l.Log(Info, "anonymous")
+	if s := buf.String(); !strings.Contains(s, "login") || strings.Contains(s, "anonymous") {
+		t.Errorf("output = %q", s)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "missing user") {
+		t.Errorf("errors = %v", errs)
+	}
+	if n := l.Stats().Failed; n != 1 {
+		t.Errorf("Failed = %d", n)
+	}
+}
@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// SecretMask replaces the credentials found by SecretDetector.
+const SecretMask = "REDACTED"
+
+// DefaultSecretPrefixes are the prefixes of well known API tokens: AWS
+// access keys, GitHub, GitLab and Slack tokens and Stripe live keys.
+var DefaultSecretPrefixes = []string{
+	"AKIA", "ASIA", "ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_",
+	"glpat-", "xoxb-", "xoxp-", "xoxa-", "sk_live_", "rk_live_",
+}
+
+// SecretConfig configures SecretDetector.
+type SecretConfig struct {
+	// Prefixes mark a word at least 8 characters longer as a token; nil
+	// selects DefaultSecretPrefixes.
+	Prefixes []string
+	// MinLength is the length from which a word is checked for entropy;
+	// zero selects 20.
+	MinLength int
+	// MinEntropy is the Shannon entropy, in bits per character, from which
+	// a long word mixing upper case letters, lower case letters and digits
+	// is taken for a credential; zero selects 3.5.
+	MinEntropy float64
+	// Patterns are masked in addition to what the heuristics find.
+	Patterns []*regexp.Regexp
+	// Allow lists the keys of fields that are never masked, e.g. a request
+	// ID or a content hash that would pass for a credential.
+	Allow []string
+	// Mask replaces the credentials; empty selects SecretMask.
+	Mask string
+}
+
+// SecretDetector returns a processor that masks likely credentials in the
+// message and the string fields of entries: words starting with a known
+// token prefix, the credential after "Bearer" or "Basic", long words of
+// high entropy and matches of the configured patterns. The heuristics
+// trade some false positives for not leaking keys nobody configured a
+// pattern for; all lower case hex, such as UUIDs and hashes, is left alone.
+func SecretDetector(cfg SecretConfig) Processor {
+	if cfg.Prefixes == nil {
+		cfg.Prefixes = DefaultSecretPrefixes
+	}
+	if cfg.MinLength == 0 {
+		cfg.MinLength = 20
+	}
+	if cfg.MinEntropy == 0 {
+		cfg.MinEntropy = 3.5
+	}
+	if cfg.Mask == "" {
+		cfg.Mask = SecretMask
+	}
+	d := &secretDetector{cfg: cfg, allow: make(map[string]bool, len(cfg.Allow))}
+	for _, k := range cfg.Allow {
+		d.allow[k] = true
+	}
+	return ProcessorFunc(func(e *Entry) {
+		if s, ok := d.mask(e.Message); ok {
+			e.Message = s
+		}
+		d.maskFields(e.Fields)
+	})
+}
+
+// WithSecretDetection masks likely credentials in every entry, see
+// SecretDetector.
+func WithSecretDetection(cfg SecretConfig) Option {
+	return WithProcessors(SecretDetector(cfg))
+}
+
+type secretDetector struct {
+	cfg   SecretConfig
+	allow map[string]bool
+}
+
+// maskFields masks fields in place. Groups may be shared with the caller
+// or the logger, so a group that changes is copied.
+func (d *secretDetector) maskFields(fields []Field) bool {
+	changed := false
+	for i := range fields {
+		f := &fields[i]
+		if d.allow[f.Key] {
+			continue
+		}
+		switch f.Type {
+		case StringType:
+			if s, ok := d.mask(f.String); ok {
+				f.String, changed = s, true
+			}
+		case GroupType:
+			members, _ := f.Value.([]Field)
+			members = append([]Field(nil), members...)
+			if d.maskFields(members) {
+				f.Value, changed = members, true
+			}
+		case AnyType:
+			var s string
+			switch v := f.Value.(type) {
+			case string:
+				s = v
+			case error:
+				s = v.Error()
+			default:
+				continue
+			}
+			if s, ok := d.mask(s); ok {
+				*f, changed = String(f.Key, s), true
+			}
+		}
+	}
+	return changed
+}
+
+// mask returns s with the credentials it holds masked, and whether there
+// were any.
+func (d *secretDetector) mask(s string) (string, bool) {
+	changed := false
+	for _, re := range d.cfg.Patterns {
+		if re.MatchString(s) {
+			s, changed = re.ReplaceAllString(s, d.cfg.Mask), true
+		}
+	}
+	var b strings.Builder
+	last, prev := 0, ""
+	for i := 0; i < len(s); {
+		if !isTokenByte(s[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isTokenByte(s[j]) {
+			j++
+		}
+		word := s[i:j]
+		afterScheme := (strings.EqualFold(prev, "Bearer") || strings.EqualFold(prev, "Basic")) &&
+			strings.TrimLeft(s[i-1:i], " ") == ""
+		if afterScheme {
+			// The credential runs to the next space, e.g. a whole JWT.
+			for j < len(s) && s[j] != ' ' {
+				j++
+			}
+		}
+		if afterScheme || d.isSecret(word) {
+			b.WriteString(s[last:i])
+			b.WriteString(d.cfg.Mask)
+			last, changed = j, true
+		}
+		prev, i = word, j
+	}
+	if last == 0 {
+		return s, changed
+	}
+	b.WriteString(s[last:])
+	return b.String(), true
+}
+
+func (d *secretDetector) isSecret(word string) bool {
+	for _, p := range d.cfg.Prefixes {
+		if len(word) >= len(p)+8 && strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	if len(word) < d.cfg.MinLength {
+		return false
+	}
+	var upper, lower, digit bool
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && entropy(word) >= d.cfg.MinEntropy
+}
+
+// isTokenByte reports whether c can be part of a token: base64, base64url
+// and the separators of token prefixes.
+func isTokenByte(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+		c == '+' || c == '/' || c == '_' || c == '-'
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	h, n := 0.0, float64(len(s))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
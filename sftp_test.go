@@ -0,0 +1,114 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSFTPValidate(t *testing.T) {
+	tests := []struct {
+		host, user string
+		ok         bool
+	}{
+		{"backup.example.com", "logs", true},
+		{"10.0.0.5", "", true},
+		{"", "logs", false},
+		{"-oProxyCommand=sh", "", false},
+		{"host", "-x", false},
+		{"a@b", "", false},
+		{"host name", "", false},
+		{"host", "us er", false},
+		{"host\n", "", false},
+	}
+	for _, tt := range tests {
+		u := NewSFTPUploader(SFTPConfig{Host: tt.host, User: tt.user})
+		if err := u.validate(); (err == nil) != tt.ok {
+			t.Errorf("validate(%q, %q) = %v", tt.host, tt.user, err)
+		}
+	}
+}
+
+func TestSFTPArgs(t *testing.T) {
+	u := NewSFTPUploader(SFTPConfig{Host: "h", User: "u", IdentityFile: "/k", Options: []string{"ConnectTimeout=5"}})
+	args := strings.Join(u.args(), " ")
+	for _, want := range []string{"-b - -P 22 ", "-i /k -o IdentitiesOnly=yes", "-o ConnectTimeout=5", "-- u@h"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q lack %q", args, want)
+		}
+	}
+	if !strings.HasSuffix(args, " -- u@h") {
+		t.Errorf("args %q do not end with the target", args)
+	}
+}
+
+func TestSFTPParents(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"/", nil},
+		{"logs", []string{"logs"}},
+		{"/srv/logs/app/", []string{"/srv", "/srv/logs", "/srv/logs/app"}},
+		{"a/b", []string{"a", "a/b"}},
+	}
+	for _, tt := range tests {
+		if got := sftpParents(tt.in); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("sftpParents(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSFTPQuote(t *testing.T) {
+	if got := sftpQuote(`a "b"\c`); got != `"a \"b\"\\c"` {
+		t.Errorf("got %s", got)
+	}
+}
+
+// TestSFTPUpload runs Upload against a stand-in sftp binary that records
+// its arguments and batch file.
+func TestSFTPUpload(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "js" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	bin := filepath.Join(dir, "sftp")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\ncat >> " + out + "\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	u := NewSFTPUploader(SFTPConfig{Host: "h", RemoteDir: "/srv/logs", Binary: bin})
+	if err := u.Upload(context.Background(), "/var/log/app-1.log.gz"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(out)
+	want := []string{
+		`-mkdir "/srv"`,
+		`-mkdir "/srv/logs"`,
+		`put "/var/log/app-1.log.gz" "/srv/logs/app-1.log.gz.part"`,
+		`-rm "/srv/logs/app-1.log.gz"`,
+		`rename "/srv/logs/app-1.log.gz.part" "/srv/logs/app-1.log.gz"`,
+	}
+	lines := strings.Split(strings.TrimSpace(string(got)), "\n")
+	if len(lines) != len(want)+1 || !strings.HasSuffix(lines[0], "-- h") {
+		t.Fatalf("sftp got:\n%s", got)
+	}
+	for i, w := range want {
+		if lines[i+1] != w {
+			t.Errorf("batch line %d = %q, want %q", i, lines[i+1], w)
+		}
+	}
+
+	bad := NewSFTPUploader(SFTPConfig{Host: "-oProxyCommand=x", Binary: bin})
+	if err := bad.Upload(context.Background(), "/x"); err == nil {
+		t.Error("uploaded to an option-like host")
+	}
+}
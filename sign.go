@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	SignatureExt = ".sig"
+
+	SignErrFmt = "Failed to sign log archive %s: %w"
+)
+
+// ErrBadSignature is returned by VerifyArchive when the signature does not
+// match the archive.
+var ErrBadSignature = errors.New("log archive signature mismatch")
+
+// hashFile returns the SHA-512 digest of the file at path.
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// SignArchive signs the file at path with Ed25519ph over its SHA-512 digest
+// and writes the base64 signature to path+SignatureExt, returning that path.
+// The signature file gets the permissions of the archive.
+func SignArchive(path string, key ed25519.PrivateKey) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return signArchive(path, key, info.Mode().Perm())
+}
+
+// signArchive is SignArchive creating the signature file with mode.
+func signArchive(path string, key ed25519.PrivateKey, mode os.FileMode) (string, error) {
+	digest, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.Sign(nil, digest, &ed25519.Options{Hash: crypto.SHA512})
+	if err != nil {
+		return "", err
+	}
+
+	sigPath := path + SignatureExt
+	data := base64.StdEncoding.EncodeToString(sig) + "\n"
+	if err := os.WriteFile(sigPath, []byte(data), mode); err != nil {
+		return "", err
+	}
+	return sigPath, nil
+}
+
+// VerifyArchive checks the detached signature written by SignArchive. An
+// empty sigPath selects path+SignatureExt.
+func VerifyArchive(path, sigPath string, pub ed25519.PublicKey) error {
+	if sigPath == "" {
+		sigPath = path + SignatureExt
+	}
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadSignature, err)
+	}
+
+	digest, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(pub, digest, sig, &ed25519.Options{Hash: crypto.SHA512}); err != nil {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSignArchive(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	path := filepath.Join(t.TempDir(), "app-1.log")
+	os.WriteFile(path, []byte("one\ntwo\n"), 0o600)
+	sigPath, err := SignArchive(path, key)
+	if err != nil || sigPath != path+SignatureExt {
+		t.Fatalf("SignArchive = %q, %v", sigPath, err)
+	}
+	if info, _ := os.Stat(sigPath); runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("signature mode %v", info.Mode())
+	}
+	if err := VerifyArchive(path, "", pub); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if err := VerifyArchive(path, sigPath, other); err != ErrBadSignature {
+		t.Errorf("other key: %v", err)
+	}
+	os.WriteFile(path, []byte("one\ntwo!\n"), 0o600)
+	if err := VerifyArchive(path, "", pub); err != ErrBadSignature {
+		t.Errorf("tampered archive: %v", err)
+	}
+	os.WriteFile(sigPath, []byte("not base64"), 0o600)
+	if err := VerifyArchive(path, "", pub); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("garbled signature: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultSignalFlushTimeout bounds the flush on a termination signal when
+// SignalConfig.Timeout is zero.
+const DefaultSignalFlushTimeout = 5 * time.Second
+
+// SignalFlushErrFmt reports a flush on a termination signal that failed or
+// ran out of time.
+const SignalFlushErrFmt = "Flush on %s: %w"
+
+// ErrSignalFlushTimeout is reported when the flush on a termination signal
+// does not finish within SignalConfig.Timeout.
+var ErrSignalFlushTimeout = errors.New("flush timed out")
+
+// SignalConfig configures WithSignalFlush.
+type SignalConfig struct {
+	// Signals are the signals handled; nil selects os.Interrupt and
+	// syscall.SIGTERM.
+	Signals []os.Signal
+	// Timeout bounds the flush; zero selects DefaultSignalFlushTimeout.
+	Timeout time.Duration
+}
+
+// WithSignalFlush flushes the logger, see Flush, when the process receives
+// a termination signal, and then lets the signal take its default effect,
+// so the last entries before a pod is stopped reach their destination.
+// It is meant for programs that do not handle these signals themselves;
+// those call Flush or Close in their own shutdown instead. Close stops the
+// handler.
+func WithSignalFlush(cfg SignalConfig) Option {
+	return func(l *CustomLogger) {
+		l.signalCfg = &cfg
+	}
+}
+
+// signalHandler flushes a logger on the configured signals.
+type signalHandler struct {
+	l       *CustomLogger
+	timeout time.Duration
+	ch      chan os.Signal
+	stop    chan struct{}
+	// raise delivers the signal again once the handler is reset.
+	raise func(os.Signal)
+}
+
+func startSignalHandler(l *CustomLogger, cfg SignalConfig) *signalHandler {
+	if cfg.Signals == nil {
+		cfg.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSignalFlushTimeout
+	}
+	h := &signalHandler{
+		l:       l,
+		timeout: cfg.Timeout,
+		ch:      make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+		raise:   raiseSignal,
+	}
+	signal.Notify(h.ch, cfg.Signals...)
+	go h.run()
+	return h
+}
+
+func (h *signalHandler) run() {
+	select {
+	case sig := <-h.ch:
+		h.handle(sig)
+	case <-h.stop:
+	}
+}
+
+// handle flushes within the timeout, then stops catching the signals and
+// raises sig again.
+func (h *signalHandler) handle(sig os.Signal) {
+	done := make(chan error, 1)
+	go func() { done <- h.l.Flush() }()
+	timer := time.NewTimer(h.timeout)
+	select {
+	case err := <-done:
+		if err != nil {
+			h.l.errorHandler(fmt.Errorf(SignalFlushErrFmt, sig, err))
+		}
+	case <-timer.C:
+		h.l.errorHandler(fmt.Errorf(SignalFlushErrFmt, sig, ErrSignalFlushTimeout))
+	}
+	timer.Stop()
+	signal.Stop(h.ch)
+	h.raise(sig)
+}
+
+func (h *signalHandler) close() {
+	signal.Stop(h.ch)
+	close(h.stop)
+}
+
+// raiseSignal sends sig to the process now that it is no longer caught,
+// exiting if the platform cannot.
+func raiseSignal(sig os.Signal) {
+	signal.Reset(sig)
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
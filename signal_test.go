@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalFlush(t *testing.T) {
+	sender := &recordSender{}
+	batch := NewBatchSink(sender, BatchConfig{FlushInterval: time.Hour})
+	l := newTestLogger(t, Info, io.Discard, WithSinks(batch), WithSignalFlush(SignalConfig{Signals: []os.Signal{syscall.SIGTERM}}))
+	defer l.Close(context.Background())
+	raised := make(chan os.Signal, 1)
+	l.signals.raise = func(sig os.Signal) { raised <- sig }
+
+	l.Info("last words")
+	l.signals.ch <- syscall.SIGTERM
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGTERM || sender.total.Load() != 1 {
+			t.Errorf("raised %v with %d entries sent", sig, sender.total.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not handled")
+	}
+}
+
+// stuckSink blocks flushes until it is closed.
+type stuckSink chan struct{}
+
+func (s stuckSink) WriteEntry(*Entry) error { return nil }
+func (s stuckSink) Flush() error            { <-s; return nil }
+
+func TestSignalFlushTimeout(t *testing.T) {
+	var errs []error
+	stuck := make(stuckSink)
+	defer close(stuck)
+	l := newTestLogger(t, Info, io.Discard, WithSinks(stuck),
+		WithSignalFlush(SignalConfig{Signals: []os.Signal{syscall.SIGTERM}, Timeout: 10 * time.Millisecond}),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	var raised os.Signal
+	l.signals.raise = func(sig os.Signal) { raised = sig }
+	l.signals.handle(os.Interrupt)
+	if raised != os.Interrupt || len(errs) != 1 || !errors.Is(errs[0], ErrSignalFlushTimeout) {
+		t.Errorf("raised %v, errors %v", raised, errs)
+	}
+	l.signals.close()
+}
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	EncodeErrFmt = "Failed to encode log entry: %w"
+	WriteErrFmt  = "Failed to write log entry: %w"
+)
+
+// Sink is a destination for log entries. The entry passed to WriteEntry is
+// only valid for the duration of the call; sinks that keep it must Clone it.
+type Sink interface {
+	WriteEntry(e *Entry) error
+}
+
+// Flusher is implemented by sinks and writers that buffer data and can be
+// asked to write it out immediately.
+type Flusher interface {
+	Flush() error
+}
+
+// flushSink flushes s if it buffers anything.
+func flushSink(s Sink) error {
+	if f, ok := s.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// BlockWriter is implemented by sinks that can write several entries as one
+// contiguous block that concurrent writes cannot interleave.
+type BlockWriter interface {
+	WriteBlock(entries []*Entry) error
+}
+
+// writeBlock writes entries as a block if s supports it and one by one
+// otherwise.
+func writeBlock(s Sink, entries []*Entry) error {
+	if b, ok := s.(BlockWriter); ok {
+		return b.WriteBlock(entries)
+	}
+	var errs []error
+	for _, e := range entries {
+		if err := s.WriteEntry(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrorHandler is called whenever an entry cannot be encoded or written.
+type ErrorHandler func(err error)
+
+// ErrSinkClosed is returned by sinks written to after Close.
+var ErrSinkClosed = errors.New("sink is closed")
+
+// UndeliveredErrFmt is the error returned by Close of the asynchronous and
+// batching sinks if entries were dropped or failed to be written.
+const UndeliveredErrFmt = "Sink closed with %d entries dropped and %d failed: %w"
+
+// ErrUndelivered is wrapped by the Close error of a sink that lost entries
+// without a write error, e.g. to a full queue.
+var ErrUndelivered = errors.New("entries were not delivered")
+
+// undelivered returns the Close error of a sink with the given counters,
+// wrapping its last write error, or nil if nothing was lost.
+func undelivered(st Stats, last error) error {
+	if st.Dropped == 0 && st.Failed == 0 {
+		return nil
+	}
+	if last == nil {
+		last = ErrUndelivered
+	}
+	return fmt.Errorf(UndeliveredErrFmt, st.Dropped, st.Failed, last)
+}
+
+// DefaultErrorHandler writes a notice about the failure to stderr.
+func DefaultErrorHandler(err error) {
+	fmt.Fprintf(os.Stderr, "logger: %s\n", err)
+}
+
+// WriterSink encodes entries and writes them to an io.Writer.
+type WriterSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc Encoder
+	// bytes, if set, counts the bytes written.
+	bytes *atomic.Uint64
+}
+
+// NewWriterSink creates a sink writing entries encoded by enc to w.
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	return &WriterSink{w: w, enc: enc}
+}
+
+// WriteEntry implements Sink.
+func (s *WriterSink) WriteEntry(e *Entry) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+
+	if err := s.enc.Encode(buf, e); err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+
+	s.mu.Lock()
+	n, err := s.w.Write(buf.Bytes())
+	s.mu.Unlock()
+	if s.bytes != nil {
+		s.bytes.Add(uint64(n))
+	}
+	if err != nil {
+		return fmt.Errorf(WriteErrFmt, err)
+	}
+	return nil
+}
+
+// WriteBlock implements BlockWriter with a single write.
+func (s *WriterSink) WriteBlock(entries []*Entry) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+
+	for _, e := range entries {
+		if err := s.enc.Encode(buf, e); err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+	}
+
+	s.mu.Lock()
+	n, err := s.w.Write(buf.Bytes())
+	s.mu.Unlock()
+	if s.bytes != nil {
+		s.bytes.Add(uint64(n))
+	}
+	if err != nil {
+		return fmt.Errorf(WriteErrFmt, err)
+	}
+	return nil
+}
+
+// Flush flushes the underlying writer if it buffers data.
+func (s *WriterSink) Flush() error {
+	if f, ok := s.w.(Flusher); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return f.Flush()
+	}
+	return nil
+}
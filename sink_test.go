@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, io.ErrShortWrite }
+
+var errEncode = errors.New("cannot encode")
+
+type failEncoder struct{}
+
+func (failEncoder) Encode(*bytes.Buffer, *Entry) error { return errEncode }
+
+func TestErrorHandler(t *testing.T) {
+	var errs []error
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf,
+		WithSinks(NewWriterSink(failWriter{}, TextEncoder{}), NewWriterSink(io.Discard, failEncoder{})),
+		WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	l.Info("kept")
+
+	// The failing sinks do not keep the entry from the others.
+	if buf.Len() == 0 {
+		t.Error("entry not written to the working sink")
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], io.ErrShortWrite) || !errors.Is(errs[1], errEncode) {
+		t.Fatalf("errors %v", errs)
+	}
+	if errs[0].Error() != "Failed to write log entry: short write" || errs[1].Error() != "Failed to encode log entry: cannot encode" {
+		t.Errorf("errors %q, %q", errs[0], errs[1])
+	}
+
+	// A nil handler discards the errors.
+	l = newTestLogger(t, Info, &buf, WithSinks(NewWriterSink(failWriter{}, TextEncoder{})), WithErrorHandler(nil))
+	l.Info("dropped")
+	if st := l.Stats(); st.Failed != 1 {
+		t.Errorf("stats %+v", st)
+	}
+}
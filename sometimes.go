@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Limited is a logger handle returned by Once and Every that either writes
+// or discards the entries of one call site. Discarded entries count as
+// suppressed in Stats.
+type Limited struct {
+	l  *CustomLogger
+	ok bool
+}
+
+// callSites counts how often each Once or Every call site was reached.
+type callSites struct {
+	m sync.Map // pc -> *atomic.Uint64
+}
+
+func (c *callSites) hit(pc uintptr) uint64 {
+	v, ok := c.m.Load(pc)
+	if !ok {
+		v, _ = c.m.LoadOrStore(pc, new(atomic.Uint64))
+	}
+	return v.(*atomic.Uint64).Add(1)
+}
+
+// Once returns a handle that writes only the first time its call site is
+// reached, e.g. l.Once().Warn("config file missing, using defaults").
+//
+//go:noinline
+func (l *CustomLogger) Once() Limited {
+	return l.every(1, true)
+}
+
+// Every returns a handle that writes the first and then every n-th time
+// its call site is reached, for status output from hot loops:
+//
+//	l.Every(1000).Debug("processed", i)
+//
+//go:noinline
+func (l *CustomLogger) Every(n int) Limited {
+	return l.every(n, false)
+}
+
+// every keys on the caller's return address; Once and Every must not be
+// inlined or two call sites on one line would share a counter.
+func (l *CustomLogger) every(n int, once bool) Limited {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	count := l.sites.hit(pcs[0])
+	ok := count == 1
+	if !once {
+		ok = n <= 1 || (count-1)%uint64(n) == 0
+	}
+	return Limited{l: l, ok: ok}
+}
+
+func (s Limited) write(level LogLevel, msg string, fields ...Field) {
+	if !s.l.enabled(level) {
+		return
+	}
+	if !s.ok {
+		s.l.counters.suppressed.Add(1)
+		return
+	}
+	s.l.log(level, msg, fields...)
+}
+
+func (s Limited) Debug(v ...interface{})  { s.write(Debug, fmt.Sprintln(v...)) }
+func (s Limited) Info(v ...interface{})   { s.write(Info, fmt.Sprintln(v...)) }
+func (s Limited) Notice(v ...interface{}) { s.write(Notice, fmt.Sprintln(v...)) }
+func (s Limited) Warn(v ...interface{})   { s.write(Warn, fmt.Sprintln(v...)) }
+
+func (s Limited) Error(format string, v ...interface{}) {
+	s.write(Error, fmt.Sprintf(format, v...))
+}
+
+func (s Limited) Critical(format string, v ...interface{}) {
+	s.write(Critical, fmt.Sprintf(format, v...))
+}
+
+func (s Limited) Alert(format string, v ...interface{}) {
+	s.write(Alert, fmt.Sprintf(format, v...))
+}
+
+func (s Limited) Emergency(format string, v ...interface{}) {
+	s.write(Emergency, fmt.Sprintf(format, v...))
+}
+
+// Fatalf is never suppressed.
+func (s Limited) Fatalf(format string, v ...interface{}) {
+	s.l.Fatalf(format, v...)
+}
+
+// Log writes msg with fields at the given level if the handle allows it.
+func (s Limited) Log(level LogLevel, msg string, fields ...Field) {
+	s.write(level, msg, fields...)
+}
+
+// Ensure Limited implements Logger
+var _ Logger = Limited{}
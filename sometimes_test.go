@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOnceEvery(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	for i := 0; i < 7; i++ {
+		l.Once().Warn("once")
+		l.Every(3).Info("every", i)
+		l.Every(3).Debug("disabled")
+	}
+	// Two call sites on one line keep their own counters.
+	first, second := l.Once(), l.Once()
+	first.Info("first")
+	second.Info("second")
+
+	out := buf.String()
+	if n := strings.Count(out, "once"); n != 1 {
+		t.Errorf("Once wrote %d times", n)
+	}
+	for _, want := range []string{"every 0", "every 3", "every 6", "first", "second"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q lacks %q", out, want)
+		}
+	}
+	if n := strings.Count(out, "every"); n != 3 {
+		t.Errorf("Every(3) wrote %d of 7 times", n)
+	}
+	// Disabled levels are not counted as suppressed.
+	if st := l.Stats(); st.Suppressed != 6+4 {
+		t.Errorf("stats %+v", st)
+	}
+}
@@ -0,0 +1,150 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const SQLTableErrFmt = "Invalid log table name %q"
+
+var tableNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// sqlDialect captures the differences between SQL databases.
+type sqlDialect struct {
+	// schema creates the table; %[1]s is replaced by the table name and
+	// %[2]s by the name without schema, for naming indexes.
+	schema []string
+	// placeholder returns the bind parameter for the n-th argument from 1.
+	placeholder func(n int) string
+	// fieldsCast is appended to the fields placeholder, e.g. "::jsonb".
+	fieldsCast string
+	// maxRows bounds the rows of one INSERT statement.
+	maxRows int
+}
+
+// SQLSender is a BatchSender that inserts entries into a table with the
+// columns ts, level, name, message and fields (JSON). It works with any
+// database/sql driver the application registers.
+type SQLSender struct {
+	db      *sql.DB
+	table   string
+	dialect sqlDialect
+}
+
+func newSQLSender(ctx context.Context, db *sql.DB, table string, d sqlDialect) (*SQLSender, error) {
+	if !tableNameRE.MatchString(table) {
+		return nil, fmt.Errorf(SQLTableErrFmt, table)
+	}
+	base := table
+	if i := strings.IndexByte(table, '.'); i >= 0 {
+		base = table[i+1:]
+	}
+	for _, stmt := range d.schema {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(stmt, table, base)); err != nil {
+			return nil, err
+		}
+	}
+	return &SQLSender{db: db, table: table, dialect: d}, nil
+}
+
+// SendBatch implements BatchSender.
+func (s *SQLSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(batch); start += s.dialect.maxRows {
+		end := start + s.dialect.maxRows
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := s.insert(ctx, tx, batch[start:end]); err != nil {
+			return errors.Join(err, tx.Rollback())
+		}
+	}
+	return tx.Commit()
+}
+
+// insert writes rows with a single multi-row INSERT.
+func (s *SQLSender) insert(ctx context.Context, tx *sql.Tx, rows []*Entry) error {
+	query := make([]byte, 0, 64+len(rows)*32)
+	query = append(query, "INSERT INTO "+s.table+" (ts, level, name, message, fields) VALUES "...)
+	args := make([]interface{}, 0, len(rows)*5)
+
+	for i, e := range rows {
+		fields, err := json.Marshal(e.FieldMap())
+		if err != nil {
+			return fmt.Errorf(EncodeErrFmt, err)
+		}
+		if i > 0 {
+			query = append(query, ',')
+		}
+		n := len(args)
+		query = append(query, '(')
+		for c := 1; c <= 5; c++ {
+			if c > 1 {
+				query = append(query, ',')
+			}
+			query = append(query, s.dialect.placeholder(n+c)...)
+		}
+		query = append(query, s.dialect.fieldsCast...)
+		query = append(query, ')')
+		args = append(args, e.Time.UTC().Format(time.RFC3339Nano), e.Level.String(), e.Name, e.Message, string(fields))
+	}
+
+	_, err := tx.ExecContext(ctx, string(query), args...)
+	return err
+}
+
+// NewSQLiteSender creates the table if missing and returns a sender for a
+// SQLite database opened by the application with a driver of its choice,
+// enabling ad-hoc SQL over recent logs in single-binary deployments.
+// Timestamps are stored as RFC 3339 text.
+func NewSQLiteSender(ctx context.Context, db *sql.DB, table string) (*SQLSender, error) {
+	return newSQLSender(ctx, db, table, sqlDialect{
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS %[1]s (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				ts TEXT NOT NULL,
+				level TEXT NOT NULL,
+				name TEXT NOT NULL,
+				message TEXT NOT NULL,
+				fields TEXT NOT NULL
+			)`,
+		},
+		placeholder: func(int) string { return "?" },
+		maxRows:     150,
+	})
+}
+
+// NewPostgresSender creates the table if missing and returns a sender for a
+// PostgreSQL database opened by the application, e.g. with pgx's stdlib
+// driver. Entries are written with multi-row INSERTs, fields go to a JSONB
+// column and ts is indexed. Wrap it in a BatchSink to batch entries.
+func NewPostgresSender(ctx context.Context, db *sql.DB, table string) (*SQLSender, error) {
+	return newSQLSender(ctx, db, table, sqlDialect{
+		schema: []string{
+			`CREATE TABLE IF NOT EXISTS %[1]s (
+				id BIGSERIAL PRIMARY KEY,
+				ts TIMESTAMPTZ NOT NULL,
+				level TEXT NOT NULL,
+				name TEXT NOT NULL,
+				message TEXT NOT NULL,
+				fields JSONB NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS %[2]s_ts_idx ON %[1]s (ts)`,
+		},
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		fieldsCast:  "::jsonb",
+		maxRows:     1000,
+	})
+}
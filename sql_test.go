@@ -0,0 +1,125 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordDB is a database/sql driver recording the statements executed,
+// failing those containing fail.
+type recordDB struct {
+	execs     []string
+	args      [][]driver.Value
+	commits   int
+	rollbacks int
+	fail      string
+}
+
+var errExec = errors.New("exec failed")
+
+func (db *recordDB) Connect(context.Context) (driver.Conn, error) { return recordConn{db}, nil }
+func (db *recordDB) Driver() driver.Driver                        { return nil }
+
+type recordConn struct{ db *recordDB }
+
+func (c recordConn) Prepare(query string) (driver.Stmt, error) { return recordStmt{c.db, query}, nil }
+func (c recordConn) Close() error                              { return nil }
+func (c recordConn) Begin() (driver.Tx, error)                 { return recordTx{c.db}, nil }
+
+type recordStmt struct {
+	db    *recordDB
+	query string
+}
+
+func (s recordStmt) Close() error  { return nil }
+func (s recordStmt) NumInput() int { return -1 }
+func (s recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.execs = append(s.db.execs, s.query)
+	s.db.args = append(s.db.args, args)
+	if s.db.fail != "" && strings.Contains(s.query, s.db.fail) {
+		return nil, errExec
+	}
+	return driver.RowsAffected(len(args) / 5), nil
+}
+func (s recordStmt) Query([]driver.Value) (driver.Rows, error) { return nil, driver.ErrSkip }
+
+type recordTx struct{ db *recordDB }
+
+func (tx recordTx) Commit() error   { tx.db.commits++; return nil }
+func (tx recordTx) Rollback() error { tx.db.rollbacks++; return nil }
+
+func TestSQLiteSender(t *testing.T) {
+	rec := &recordDB{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+	if _, err := NewSQLiteSender(context.Background(), db, "logs; DROP TABLE users"); err == nil {
+		t.Error("accepted an invalid table name")
+	}
+	s, err := NewSQLiteSender(context.Background(), db, "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.execs) != 1 || !strings.Contains(rec.execs[0], "CREATE TABLE IF NOT EXISTS logs (") {
+		t.Fatalf("schema %q", rec.execs)
+	}
+
+	// A batch beyond the row limit of one INSERT goes in one transaction.
+	batch := make([]*Entry, 200)
+	for i := range batch {
+		batch[i] = &Entry{Time: testTime, Level: Warn, Name: "api", Message: "slow", Fields: []Field{Int("ms", 250)}}
+	}
+	rec.execs, rec.args = nil, nil
+	if err := s.SendBatch(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.execs) != 2 || len(rec.args[0]) != 150*5 || len(rec.args[1]) != 50*5 || rec.commits != 1 {
+		t.Fatalf("%d inserts, %d commits", len(rec.execs), rec.commits)
+	}
+	if !strings.HasPrefix(rec.execs[1], "INSERT INTO logs (ts, level, name, message, fields) VALUES (?,?,?,?,?),(?,") {
+		t.Errorf("insert %.80q", rec.execs[1])
+	}
+	want := []driver.Value{"2024-03-01T12:30:45Z", "WARN", "api", "slow", `{"ms":250}`}
+	for i, v := range want {
+		if rec.args[0][i] != v {
+			t.Errorf("argument %d = %v, want %v", i, rec.args[0][i], v)
+		}
+	}
+
+	rec.fail = "INSERT"
+	if err := s.SendBatch(context.Background(), batch[:1]); !errors.Is(err, errExec) || rec.rollbacks != 1 {
+		t.Errorf("failed insert: %v, %d rollbacks", err, rec.rollbacks)
+	}
+}
+
+func TestPostgresSender(t *testing.T) {
+	rec := &recordDB{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+	s, err := NewPostgresSender(context.Background(), db, "app.logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The index is named after the table without its schema.
+	if len(rec.execs) != 2 || !strings.Contains(rec.execs[0], "fields JSONB NOT NULL") ||
+		rec.execs[1] != "CREATE INDEX IF NOT EXISTS logs_ts_idx ON app.logs (ts)" {
+		t.Fatalf("schema %q", rec.execs)
+	}
+	rec.execs = nil
+	e := &Entry{Time: testTime, Level: Info, Message: "m"}
+	if err := s.SendBatch(context.Background(), []*Entry{e, e}); err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO app.logs (ts, level, name, message, fields) VALUES ($1,$2,$3,$4,$5::jsonb),($6,$7,$8,$9,$10::jsonb)"
+	if len(rec.execs) != 1 || rec.execs[0] != want {
+		t.Errorf("insert %q", rec.execs)
+	}
+	if fields := rec.args[len(rec.args)-1][4]; fields != "{}" {
+		t.Errorf("fields of an entry without any %v", fields)
+	}
+}
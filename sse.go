@@ -0,0 +1,115 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultSSEPing is the interval of the comments keeping idle event
+	// streams alive through proxies.
+	DefaultSSEPing = 30 * time.Second
+	// SSERetry is the reconnection delay suggested to clients.
+	SSERetry = 3 * time.Second
+)
+
+// SSEHandler returns a handler streaming the entries of b as Server-Sent
+// Events of type "entry" whose data is the entry as JSON, see JSONEncoder,
+// and whose ID is its sequence number. A new stream starts with the kept
+// history; a reconnecting EventSource sends Last-Event-ID and resumes after
+// it, without gaps as long as the missed entries are still kept. The
+// last_id query parameter does the same for clients that cannot set
+// headers. The level and name query parameters filter the stream like
+// StreamFilter:
+//
+//	const src = new EventSource("/logs/events?level=warn")
+//	src.addEventListener("entry", e => show(JSON.parse(e.data)))
+func (b *Broadcaster) SSEHandler() http.Handler {
+	return http.HandlerFunc(b.serveSSE)
+}
+
+func (b *Broadcaster) serveSSE(w http.ResponseWriter, r *http.Request) {
+	filter, ok := filterFromQuery(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf(StreamLevelErrFmt, r.URL.Query().Get("level")), http.StatusBadRequest)
+		return
+	}
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("last_id")
+	}
+	var since uint64
+	if last != "" {
+		n, err := strconv.ParseUint(last, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	// Keep nginx from buffering the stream.
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	past, entries, cancel := b.SubscribeSince(filter, since)
+	defer cancel()
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	fmt.Fprintf(buf, "retry: %d\n\n", SSERetry.Milliseconds())
+	for _, se := range past {
+		appendSSEEvent(buf, se)
+	}
+	if !writeSSE(w, rc, buf.Bytes()) {
+		return
+	}
+	ping := time.NewTicker(DefaultSSEPing)
+	defer ping.Stop()
+	for {
+		buf.Reset()
+		select {
+		case se, ok := <-entries:
+			if !ok {
+				return
+			}
+			appendSSEEvent(buf, se)
+		case <-ping.C:
+			buf.WriteString(": ping\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if !writeSSE(w, rc, buf.Bytes()) {
+			return
+		}
+	}
+}
+
+// appendSSEEvent appends se as an event. The JSON encoding escapes line
+// breaks, so the document fits on one data line.
+func appendSSEEvent(buf *bytes.Buffer, se StreamEntry) {
+	sub := GetBuffer()
+	defer PutBuffer(sub)
+	if (JSONEncoder{}).Encode(sub, se.Entry) != nil {
+		return
+	}
+	buf.WriteString("id: ")
+	buf.WriteString(strconv.FormatUint(se.Seq, 10))
+	buf.WriteString("\nevent: entry\ndata: ")
+	buf.Write(sub.Bytes()[:sub.Len()-1])
+	buf.WriteString("\n\n")
+}
+
+func writeSSE(w http.ResponseWriter, rc *http.ResponseController, p []byte) bool {
+	if _, err := w.Write(p); err != nil {
+		return false
+	}
+	return rc.Flush() == nil
+}
@@ -0,0 +1,120 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcasterHistory(t *testing.T) {
+	b := NewBroadcaster(BroadcastConfig{History: 3})
+	for i, lvl := range []LogLevel{Info, Warn, Info, Warn, Error} {
+		b.WriteEntry(&Entry{Level: lvl, Message: string(rune('a' + i))})
+	}
+	got := func(ses []StreamEntry) string {
+		var s []string
+		for _, se := range ses {
+			s = append(s, se.Entry.Message)
+		}
+		return strings.Join(s, ",")
+	}
+	all := AllEntries
+	if s := got(b.Recent(all, 10)); s != "c,d,e" {
+		t.Errorf("Recent = %s, want c,d,e", s)
+	}
+	if s := got(b.Recent(all, 2)); s != "d,e" {
+		t.Errorf("Recent(2) = %s, want d,e", s)
+	}
+	if s := got(b.Recent(StreamFilter{Level: Warn}, 10)); s != "d,e" {
+		t.Errorf("Recent(warn) = %s, want d,e", s)
+	}
+	past, ch, cancel := b.SubscribeSince(all, 3)
+	if s := got(past); s != "d,e" || past[0].Seq != 4 {
+		t.Errorf("SubscribeSince(3) = %s", s)
+	}
+	b.WriteEntry(&Entry{Level: Info, Message: "f"})
+	if se := <-ch; se.Seq != 6 || se.Entry.Message != "f" {
+		t.Errorf("live entry = %d %s", se.Seq, se.Entry.Message)
+	}
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("channel open after cancel")
+	}
+}
+
+// readSSEEvent reads the next event, skipping comments and the retry hint.
+func readSSEEvent(t *testing.T, r *bufio.Reader) (id, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = line[4:]
+		case strings.HasPrefix(line, "data: "):
+			data = line[6:]
+		case line == "" && data != "":
+			return id, data
+		}
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	b := NewBroadcaster(BroadcastConfig{})
+	l := newTestLogger(t, Debug, io.Discard, WithSinks(b))
+	l.Info("before one")
+	l.Info("before two")
+	srv := httptest.NewServer(b.SSEHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?level=info", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	id, data := readSSEEvent(t, r)
+	if id != "2" || !strings.Contains(data, `"msg":"before two"`) {
+		t.Errorf("replayed event %s %s", id, data)
+	}
+	for b.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	l.Debug("filtered")
+	l.Log(Warn, "live", String("k", "v"))
+	id, data = readSSEEvent(t, r)
+	var doc map[string]interface{}
+	if id != "4" || json.Unmarshal([]byte(data), &doc) != nil || doc["msg"] != "live" {
+		t.Errorf("live event %s %s", id, data)
+	}
+}
+
+func TestSSEHandlerRejects(t *testing.T) {
+	h := NewBroadcaster(BroadcastConfig{}).SSEHandler()
+	for _, target := range []string{"/?level=loud", "/?last_id=x"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", target, w.Code)
+		}
+	}
+}
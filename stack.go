@@ -0,0 +1,118 @@
+package logger
+
+import "strings"
+
+const (
+	// StackKey is the field holding a stack trace, as added by LogPanic,
+	// Recoverer and jobs.
+	StackKey = "stack"
+
+	// stackElided ends a stack trace cut at StackConfig.MaxFrames, as the
+	// runtime does for deep stacks.
+	stackElided = "...additional frames elided..."
+)
+
+// StackConfig configures how stack traces are written, see WithStackConfig.
+type StackConfig struct {
+	// MaxFrames caps the frames written after filtering; zero writes all
+	// of them.
+	MaxFrames int
+	// SkipRuntime drops the frames of the runtime package and of the
+	// panic call, which precede the frame that panicked.
+	SkipRuntime bool
+	// SkipVendor drops the frames of files in vendor directories and the
+	// module cache.
+	SkipVendor bool
+	// SkipFuncs drops the frames of functions starting with one of the
+	// prefixes, e.g. "net/http." or "github.com/some/framework.".
+	SkipFuncs []string
+	// TrimPaths are removed from the start of file paths, e.g. the
+	// checkout directory of the build.
+	TrimPaths []string
+	// TrimOffsets drops the " +0x1d" program counter offsets.
+	TrimOffsets bool
+}
+
+// WithStackConfig rewrites the stack traces of StackKey fields according to
+// cfg, so traces stay readable and compact. Traces keep the format of
+// runtime/debug.Stack, which Fingerprint reads.
+func WithStackConfig(cfg StackConfig) Option {
+	return WithProcessors(ProcessorFunc(func(e *Entry) {
+		for i, f := range e.Fields {
+			if f.Key != StackKey {
+				continue
+			}
+			if s, ok := f.Interface().(string); ok {
+				e.Fields[i] = String(StackKey, cfg.Trim(s))
+			}
+		}
+	}))
+}
+
+// Trim rewrites a stack trace printed by runtime/debug.Stack: its header,
+// then a function line and a tab indented file line per frame.
+func (c StackConfig) Trim(stack string) string {
+	lines := strings.Split(strings.TrimSuffix(stack, "\n"), "\n")
+	var b strings.Builder
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "goroutine ") {
+		b.WriteString(lines[0])
+		b.WriteByte('\n')
+		lines = lines[1:]
+	}
+	frames := 0
+	for i := 0; i < len(lines); i++ {
+		fn, file := lines[i], ""
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			file = lines[i+1][1:]
+			i++
+		}
+		if c.skip(fn, file) {
+			continue
+		}
+		if c.MaxFrames > 0 && frames == c.MaxFrames {
+			b.WriteString(stackElided)
+			b.WriteByte('\n')
+			break
+		}
+		frames++
+		b.WriteString(fn)
+		b.WriteByte('\n')
+		if file != "" {
+			b.WriteByte('\t')
+			b.WriteString(c.trimFile(file))
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func (c StackConfig) skip(fn, file string) bool {
+	if c.SkipRuntime && (strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "runtime/debug.") || strings.HasPrefix(fn, "panic(")) {
+		return true
+	}
+	if c.SkipVendor && (strings.Contains(file, "/vendor/") || strings.Contains(file, "/pkg/mod/")) {
+		return true
+	}
+	// "created by" lines name the function that started the goroutine.
+	fn = strings.TrimPrefix(fn, "created by ")
+	for _, p := range c.SkipFuncs {
+		if strings.HasPrefix(fn, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c StackConfig) trimFile(file string) string {
+	if c.TrimOffsets {
+		if i := strings.LastIndex(file, " +0x"); i >= 0 {
+			file = file[:i]
+		}
+	}
+	for _, p := range c.TrimPaths {
+		if strings.HasPrefix(file, p) {
+			return file[len(p):]
+		}
+	}
+	return file
+}
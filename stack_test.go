@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testStack = `goroutine 7 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:24 +0x5e
+panic({0x4a8f60, 0x52cd50})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+example.com/app/db.(*Pool).Get(...)
+	/build/src/app/db/pool.go:42 +0x1d
+example.com/app/vendor/lib.Call()
+	/build/src/app/vendor/lib/call.go:9 +0x11
+example.com/app/http.serve()
+	/build/src/app/http/serve.go:17 +0x24
+net/http.HandlerFunc.ServeHTTP(0xc000010000?, {0x5a2f60?, 0xc00001c000?}, 0xc000018000?)
+	/usr/local/go/src/net/http/server.go:2166 +0x29
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3285 +0x4b4
+`
+
+func TestStackConfigTrim(t *testing.T) {
+	cfg := StackConfig{
+		MaxFrames:   2,
+		SkipRuntime: true,
+		SkipVendor:  true,
+		TrimPaths:   []string{"/build/src/"},
+		TrimOffsets: true,
+	}
+	want := `goroutine 7 [running]:
+example.com/app/db.(*Pool).Get(...)
+	app/db/pool.go:42
+example.com/app/http.serve()
+	app/http/serve.go:17
+...additional frames elided...
+`
+	if got := cfg.Trim(testStack); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got := StackConfig{SkipFuncs: []string{"net/http."}}.Trim(testStack)
+	if strings.Contains(got, "net/http") || !strings.Contains(got, "panic.go:770 +0x132") {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestWithStackConfig(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf, WithStackConfig(StackConfig{SkipRuntime: true, MaxFrames: 1}))
+	l.LogPanic("boom", []byte(testStack))
+	s := buf.String()
+	if strings.Contains(s, "runtime") || !strings.Contains(s, "pool.go:42") || strings.Contains(s, "serve.go") {
+		t.Errorf("output = %q", s)
+	}
+}
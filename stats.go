@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the work of a logger: the entries logged per level and
+// the bytes written, the entries that did not make it to their
+// destination, and the latest error.
+type Stats struct {
+	// Levels counts the entries logged at each level, after level
+	// filtering, sampling and rate limiting.
+	Levels map[LogLevel]uint64
+	// Bytes is the number of bytes written to the default output, the
+	// log file or stdout.
+	Bytes uint64
+	// Dropped entries were discarded because a buffer or queue was full,
+	// or because the disk guard suspended the log file.
+	Dropped uint64
+	// Failed entries could not be encoded or written, or were rejected by
+	// a schema.
+	Failed uint64
+	// Suppressed entries were filtered out by sampling or rate limiting.
+	Suppressed uint64
+	// Closed entries were logged after Close.
+	Closed uint64
+	// LastError and LastErrorTime describe the latest error passed to
+	// the error handler; empty if there was none.
+	LastError     string
+	LastErrorTime time.Time
+	// Uptime is the time since the logger was created.
+	Uptime time.Duration
+	// QueueDepth is the number of entries buffered by sinks but not yet
+	// written, see Health.
+	QueueDepth int
+}
+
+// StatsReporter is implemented by sinks that keep their own counters, such
+// as buffering sinks that drop entries. Their stats are included in
+// CustomLogger.Stats.
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// counters holds the live stats shared by a logger and its named children.
+type counters struct {
+	dropped    atomic.Uint64
+	failed     atomic.Uint64
+	suppressed atomic.Uint64
+	closed     atomic.Uint64
+	bytes      atomic.Uint64
+	start      time.Time
+
+	// levels counts the built-in levels by value; custom levels are
+	// counted in custom, under mu.
+	levels [Emergency + 1]atomic.Uint64
+	mu     sync.Mutex
+	custom map[LogLevel]uint64
+	// lastErr and lastErrTime are guarded by mu.
+	lastErr     string
+	lastErrTime time.Time
+}
+
+// countLevel counts an entry logged at level.
+func (c *counters) countLevel(level LogLevel) {
+	if level >= Debug && level <= Emergency {
+		c.levels[level].Add(1)
+		return
+	}
+	c.mu.Lock()
+	if c.custom == nil {
+		c.custom = make(map[LogLevel]uint64)
+	}
+	c.custom[level]++
+	c.mu.Unlock()
+}
+
+// recordError keeps err as the latest error.
+func (c *counters) recordError(now time.Time, err error) {
+	c.mu.Lock()
+	c.lastErr, c.lastErrTime = err.Error(), now
+	c.mu.Unlock()
+}
+
+func (c *counters) snapshot(now time.Time) Stats {
+	st := Stats{
+		Levels:     make(map[LogLevel]uint64),
+		Bytes:      c.bytes.Load(),
+		Dropped:    c.dropped.Load(),
+		Failed:     c.failed.Load(),
+		Suppressed: c.suppressed.Load(),
+		Closed:     c.closed.Load(),
+		Uptime:     now.Sub(c.start),
+	}
+	for lvl := range c.levels {
+		if n := c.levels[lvl].Load(); n > 0 {
+			st.Levels[LogLevel(lvl)] = n
+		}
+	}
+	c.mu.Lock()
+	for lvl, n := range c.custom {
+		st.Levels[lvl] = n
+	}
+	st.LastError, st.LastErrorTime = c.lastErr, c.lastErrTime
+	c.mu.Unlock()
+	return st
+}
+
+// add adds the counts of a sink's stats.
+func (s *Stats) add(o Stats) {
+	s.Bytes += o.Bytes
+	s.Dropped += o.Dropped
+	s.Failed += o.Failed
+	s.Suppressed += o.Suppressed
+	s.Closed += o.Closed
+}
+
+// Stats returns the entry counts, bytes written, latest error, uptime and
+// queue depth of the logger and its sinks.
+func (l *CustomLogger) Stats() Stats {
+	st := l.counters.snapshot(l.clock())
+	if l.file != nil {
+		st.add(l.file.Stats())
+	}
+	for _, s := range l.sinks {
+		if r, ok := s.(StatsReporter); ok {
+			st.add(r.Stats())
+		}
+		if r, ok := s.(HealthReporter); ok {
+			st.QueueDepth += r.Health().QueueDepth
+		}
+	}
+	return st
+}
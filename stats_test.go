@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failSink fails every write.
+type failSink struct{}
+
+func (failSink) WriteEntry(*Entry) error { return errors.New("sink down") }
+func (failSink) Flush() error            { return nil }
+func (failSink) Close() error            { return nil }
+
+func TestStats(t *testing.T) {
+	var buf bytes.Buffer
+	now := testTime
+	l := newTestLogger(t, Debug, &buf,
+		WithClock(func() time.Time { return now }),
+		WithSinks(failSink{}),
+		WithErrorHandler(func(error) {}))
+	now = now.Add(time.Minute)
+	l.Info("a")
+	l.Info("b")
+	l.Named("db").Log(Error, "c")
+	l.Debug("hidden")
+
+	st := l.Stats()
+	want := map[LogLevel]uint64{Debug: 1, Info: 2, Error: 1}
+	if len(st.Levels) != len(want) {
+		t.Errorf("Levels = %v, want %v", st.Levels, want)
+	}
+	for lvl, n := range want {
+		if st.Levels[lvl] != n {
+			t.Errorf("Levels[%s] = %d, want %d", lvl, st.Levels[lvl], n)
+		}
+	}
+	if st.Bytes != uint64(buf.Len()) {
+		t.Errorf("Bytes = %d, want %d", st.Bytes, buf.Len())
+	}
+	if st.Failed != 4 || st.LastError != "sink down" || !st.LastErrorTime.Equal(now) {
+		t.Errorf("Failed = %d, last error %q at %v", st.Failed, st.LastError, st.LastErrorTime)
+	}
+	if st.Uptime != time.Minute {
+		t.Errorf("Uptime = %v, want 1m", st.Uptime)
+	}
+}
@@ -0,0 +1,203 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStatsDAddr is the address of a local StatsD agent.
+	DefaultStatsDAddr = "127.0.0.1:8125"
+	// DefaultStatsDPrefix starts every metric name.
+	DefaultStatsDPrefix = "log."
+	// DefaultStatsDInterval is the time between reports.
+	DefaultStatsDInterval = 10 * time.Second
+
+	StatsDErrFmt = "Failed to report to StatsD: %w"
+
+	// statsdMaxPacket keeps datagrams within a typical MTU.
+	statsdMaxPacket = 1432
+)
+
+// StatsDConfig configures a StatsDReporter.
+type StatsDConfig struct {
+	// Addr is the UDP address of the agent; empty selects
+	// DefaultStatsDAddr.
+	Addr string
+	// Prefix starts every metric name; empty selects DefaultStatsDPrefix.
+	Prefix string
+	// Interval is the time between reports; zero selects
+	// DefaultStatsDInterval.
+	Interval time.Duration
+	// DogStatsD reports the level as a tag, entries:3|c|#level:info,
+	// rather than in the name, entries.info:3|c.
+	DogStatsD bool
+	// Tags are added to every metric in DogStatsD mode, e.g. "env:prod".
+	Tags []string
+}
+
+// StatsDReporter periodically sends the logger's Stats to a StatsD agent,
+// for environments without Prometheus scraping: the entries per level and
+// the bytes, failed, dropped, suppressed and closed entries as counters of
+// the change since the last report, and the queue depth as a gauge.
+type StatsDReporter struct {
+	l    *CustomLogger
+	cfg  StatsDConfig
+	conn net.Conn
+	tags string
+
+	mu   sync.Mutex
+	last Stats
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartStatsD starts reporting l's Stats as cfg says until Close.
+func StartStatsD(l *CustomLogger, cfg StatsDConfig) (*StatsDReporter, error) {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultStatsDAddr
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultStatsDPrefix
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultStatsDInterval
+	}
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf(StatsDErrFmt, err)
+	}
+	r := &StatsDReporter{
+		l:    l,
+		cfg:  cfg,
+		conn: conn,
+		tags: strings.Join(cfg.Tags, ","),
+		last: Stats{Levels: map[LogLevel]uint64{}},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+func (r *StatsDReporter) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Report(); err != nil {
+				r.l.errorHandler(err)
+			}
+		}
+	}
+}
+
+// Report sends the metrics now.
+func (r *StatsDReporter) Report() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.l.Stats()
+	var lines []string
+	levels := make([]LogLevel, 0, len(st.Levels))
+	for lvl := range st.Levels {
+		levels = append(levels, lvl)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	for _, lvl := range levels {
+		if n := st.Levels[lvl] - r.last.Levels[lvl]; n > 0 {
+			name := strings.ToLower(lvl.String())
+			if r.cfg.DogStatsD {
+				lines = append(lines, r.metric("entries", n, "c", "level:"+name))
+			} else {
+				lines = append(lines, r.metric("entries."+name, n, "c", ""))
+			}
+		}
+	}
+	for _, c := range []struct {
+		name      string
+		now, last uint64
+	}{
+		{"bytes", st.Bytes, r.last.Bytes},
+		{"failed", st.Failed, r.last.Failed},
+		{"dropped", st.Dropped, r.last.Dropped},
+		{"suppressed", st.Suppressed, r.last.Suppressed},
+		{"closed", st.Closed, r.last.Closed},
+	} {
+		if c.now > c.last {
+			lines = append(lines, r.metric(c.name, c.now-c.last, "c", ""))
+		}
+	}
+	lines = append(lines, r.metric("queue_depth", uint64(st.QueueDepth), "g", ""))
+	r.last = st
+	return r.send(lines)
+}
+
+// metric formats one metric line with the configured and extra tags.
+func (r *StatsDReporter) metric(name string, v uint64, kind, tag string) string {
+	line := fmt.Sprintf("%s%s:%d|%s", r.cfg.Prefix, name, v, kind)
+	if !r.cfg.DogStatsD {
+		return line
+	}
+	tags := r.tags
+	if tag != "" {
+		if tags != "" {
+			tag += ","
+		}
+		tags = tag + tags
+	}
+	if tags != "" {
+		line += "|#" + tags
+	}
+	return line
+}
+
+// send writes the lines in as few datagrams as fit.
+func (r *StatsDReporter) send(lines []string) error {
+	var buf bytes.Buffer
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		_, err := r.conn.Write(buf.Bytes())
+		buf.Reset()
+		if err != nil {
+			return fmt.Errorf(StatsDErrFmt, err)
+		}
+		return nil
+	}
+	for _, line := range lines {
+		if buf.Len() > 0 && buf.Len()+1+len(line) > statsdMaxPacket {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+	return flush()
+}
+
+// Close stops the reporter after a final report.
+func (r *StatsDReporter) Close() error {
+	close(r.stop)
+	<-r.done
+	err := r.Report()
+	if cerr := r.conn.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf(StatsDErrFmt, cerr)
+	}
+	return err
+}
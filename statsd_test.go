@@ -0,0 +1,68 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsDReporter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	read := func() string {
+		t.Helper()
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, statsdMaxPacket)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	l := newTestLogger(t, Info, &bytes.Buffer{})
+	r, err := StartStatsD(l, StatsDConfig{Addr: pc.LocalAddr().String(), Interval: time.Hour, DogStatsD: true, Tags: []string{"env:test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("a")
+	l.Info("b")
+	l.Warn("c")
+	if err := r.Report(); err != nil {
+		t.Fatal(err)
+	}
+	got := read()
+	for _, want := range []string{
+		"log.entries:2|c|#level:info,env:test\n",
+		"log.entries:1|c|#level:warn,env:test\n",
+		"log.queue_depth:0|g|#env:test",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+
+	// Only the change since the last report is sent.
+	l.Warn("d")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got = read()
+	if !strings.HasPrefix(got, "log.entries:1|c|#level:warn,env:test\n") || strings.Contains(got, "level:info") {
+		t.Errorf("second report = %q", got)
+	}
+}
+
+func TestStatsDPlain(t *testing.T) {
+	r := &StatsDReporter{cfg: StatsDConfig{Prefix: "app."}}
+	if got := r.metric("entries.error", 3, "c", "level:error"); got != "app.entries.error:3|c" {
+		t.Errorf("metric = %q", got)
+	}
+}
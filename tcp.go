@@ -0,0 +1,106 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// TCPConfig configures a TCPSender.
+type TCPConfig struct {
+	// Addr is the host:port of the collector.
+	Addr string
+	// Encoder frames each entry; nil selects TextEncoder, whose lines are
+	// newline delimited.
+	Encoder Encoder
+	// Compression, if set, compresses every batch into its own member, so
+	// a gzip stream reader on the other end sees one continuous stream.
+	Compression Compressor
+	// TLS, if set, is used to secure the connection.
+	TLS         *tls.Config
+	DialTimeout time.Duration
+}
+
+// TCPSender is a BatchSender writing encoded entries to a TCP stream, as
+// accepted by most log collectors' raw TCP inputs. The connection is made
+// on first use and re-established after a failure.
+type TCPSender struct {
+	cfg TCPConfig
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewTCPSender returns a sender for cfg.
+func NewTCPSender(cfg TCPConfig) *TCPSender {
+	if cfg.Encoder == nil {
+		cfg.Encoder = TextEncoder{}
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultDialTimeout
+	}
+	return &TCPSender{cfg: cfg}
+}
+
+// Acknowledges implements Acknowledger: a batch written to the socket may
+// still be lost with the connection.
+func (s *TCPSender) Acknowledges() bool {
+	return false
+}
+
+// SendBatch implements BatchSender.
+func (s *TCPSender) SendBatch(ctx context.Context, batch []*Entry) error {
+	var buf bytes.Buffer
+	for _, e := range batch {
+		if err := s.cfg.Encoder.Encode(&buf, e); err != nil {
+			return err
+		}
+	}
+	payload := buf.Bytes()
+	if s.cfg.Compression != nil {
+		var z bytes.Buffer
+		if err := s.cfg.Compression.Compress(&z, payload); err != nil {
+			return fmt.Errorf(CompressPayloadErrFmt, err)
+		}
+		payload = z.Bytes()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := dialNetwork(ctx, s.cfg.Addr, s.cfg.DialTimeout, s.cfg.TLS)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(dl)
+	} else {
+		s.conn.SetWriteDeadline(time.Time{})
+	}
+	if _, err := s.conn.Write(payload); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return wrapWriteErr(err)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *TCPSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
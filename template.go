@@ -0,0 +1,97 @@
+package logger
+
+import "strings"
+
+// TemplateKey is the field holding the raw template of an entry logged by
+// Logt, so entries of the same event can be grouped whatever the values.
+const TemplateKey = "template"
+
+// Logt writes an entry whose message is a template with named placeholders,
+// e.g.
+//
+//	log.Infot("user {user} logged in from {ip}", logger.String("user", u), logger.String("ip", ip))
+//
+// A placeholder is replaced by the field of that name, looked up in fields
+// and then in the fields of the logger; dotted names reach into groups.
+// Unknown placeholders are kept as they are and "{{" and "}}" write a
+// literal brace. The fields are kept, so structured encoders still carry
+// them next to the rendered message, together with the template under
+// TemplateKey.
+func (l *CustomLogger) Logt(level LogLevel, template string, fields ...Field) {
+	if !l.enabled(level) {
+		return
+	}
+	msg := renderTemplate(template, fields, l.fields)
+	l.log(level, msg, append(fields[:len(fields):len(fields)], String(TemplateKey, template))...)
+}
+
+// Debugt logs a template at Debug, see Logt.
+func (l *CustomLogger) Debugt(template string, fields ...Field) {
+	l.Logt(Debug, template, fields...)
+}
+
+// Infot logs a template at Info, see Logt.
+func (l *CustomLogger) Infot(template string, fields ...Field) {
+	l.Logt(Info, template, fields...)
+}
+
+// Noticet logs a template at Notice, see Logt.
+func (l *CustomLogger) Noticet(template string, fields ...Field) {
+	l.Logt(Notice, template, fields...)
+}
+
+// Warnt logs a template at Warn, see Logt.
+func (l *CustomLogger) Warnt(template string, fields ...Field) {
+	l.Logt(Warn, template, fields...)
+}
+
+// Errort logs a template at Error, see Logt.
+func (l *CustomLogger) Errort(template string, fields ...Field) {
+	l.Logt(Error, template, fields...)
+}
+
+// renderTemplate replaces the placeholders of template with the values of
+// the named fields.
+func renderTemplate(template string, fields ...[]Field) string {
+	if !strings.ContainsAny(template, "{}") {
+		return template
+	}
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(template[i+1:], '}')
+		if end < 0 {
+			b.WriteString(template[i:])
+			break
+		}
+		name := template[i+1 : i+1+end]
+		if v, ok := lookupTemplate(name, fields); ok {
+			b.WriteString(valueString(v))
+		} else {
+			b.WriteString(template[i : i+2+end])
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+func lookupTemplate(name string, fields [][]Field) (interface{}, bool) {
+	if name == "" {
+		return nil, false
+	}
+	for _, fs := range fields {
+		if v, ok := lookupField(fs, name); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
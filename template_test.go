@@ -0,0 +1,45 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	fields := []Field{String("user", "alice"), Int("n", 3), Group("req", String("ip", "10.0.0.1"))}
+	for tmpl, want := range map[string]string{
+		"user {user} logged in from {req.ip}": "user alice logged in from 10.0.0.1",
+		"{n} retries":                         "3 retries",
+		"{missing} and {}":                    "{missing} and {}",
+		"{{user}} {user":                      "{user} {user",
+		"plain":                               "plain",
+	} {
+		if got := renderTemplate(tmpl, fields); got != want {
+			t.Errorf("%q: got %q, want %q", tmpl, got, want)
+		}
+	}
+}
+
+func TestInfot(t *testing.T) {
+	var text, js bytes.Buffer
+	l := newTestLogger(t, Info, &text).With(String("host", "web-1"))
+	l.Infot("user {user} logged in on {host}", String("user", "alice"))
+	if s := text.String(); !strings.Contains(s, "user alice logged in on web-1") {
+		t.Errorf("text = %q", s)
+	}
+
+	l = newTestLogger(t, Info, &js, WithEncoder(JSONEncoder{}))
+	l.Debugt("dropped {user}", String("user", "bob"))
+	l.Warnt("user {user} locked out", String("user", "alice"))
+	e, err := DecodeJSON(bytes.TrimSpace(js.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := e.FieldMap()
+	if e.Message != "user alice locked out" || m["user"] != "alice" || m[TemplateKey] != "user {user} locked out" {
+		t.Errorf("entry = %+v", e)
+	}
+}
@@ -0,0 +1,231 @@
+package logger
+
+import (
+	"container/list"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// TenantKey is the field holding the tenant ID on the entries of a
+	// tenant logger when TenantConfig.Key is empty.
+	TenantKey = "tenant"
+	// DefaultMaxTenants is the number of tenants a TenantRegistry keeps
+	// when TenantConfig.MaxTenants is zero.
+	DefaultMaxTenants = 1000
+)
+
+// TenantConfig configures a TenantRegistry.
+type TenantConfig struct {
+	// Key is the field holding the tenant ID; empty selects TenantKey.
+	Key string
+	// Fields, if set, returns further fields for the entries of a tenant,
+	// e.g. its plan or region.
+	Fields func(tenant string) []Field
+	// Open, if set, opens the output of a tenant, e.g. a file or bucket of
+	// its own, when the tenant first logs. Its entries then go there
+	// instead of to the outputs of the parent logger. An error is passed
+	// to the error handler and Open is tried again by the next entry.
+	Open func(tenant string) (Sink, error)
+	// Shared also writes the entries of tenants with an output of their
+	// own to the outputs of the parent logger.
+	Shared bool
+	// MaxTenants bounds the tenants kept at once; beyond it the least
+	// recently used one is forgotten and its output closed, to be opened
+	// again should it log again. Zero selects DefaultMaxTenants.
+	MaxTenants int
+}
+
+// TenantRegistry hands out loggers derived from a parent logger per tenant,
+// for services that must keep the logs of their customers apart:
+//
+//	tenants := logger.NewTenantRegistry(log, logger.TenantConfig{
+//		Open: func(id string) (logger.Sink, error) {
+//			f, err := logger.OpenFile("/var/log/tenants/"+id+".log", logger.FileConfig{})
+//			if err != nil {
+//				return nil, err
+//			}
+//			return logger.NewWriterSink(f, logger.JSONEncoder{}), nil
+//		},
+//	})
+//	defer tenants.Close()
+//	tenants.Get(customerID).Info("invoice sent")
+//
+// Tenant loggers share the level settings and processors of the parent.
+// Close the registry rather than a tenant logger, which would close the
+// outputs of the parent. A TenantRegistry is safe for concurrent use.
+type TenantRegistry struct {
+	parent  *CustomLogger
+	cfg     TenantConfig
+	mu      sync.Mutex
+	tenants map[string]*list.Element
+	lru     *list.List
+}
+
+// tenant is a tenant kept by a registry. mu guards the output, which is
+// opened lazily and closed when the tenant is evicted.
+type tenant struct {
+	id     string
+	log    *CustomLogger
+	mu     sync.Mutex
+	sink   Sink
+	closed bool
+}
+
+// NewTenantRegistry returns a registry of tenant loggers derived from
+// parent.
+func NewTenantRegistry(parent *CustomLogger, cfg TenantConfig) *TenantRegistry {
+	if cfg.Key == "" {
+		cfg.Key = TenantKey
+	}
+	if cfg.MaxTenants <= 0 {
+		cfg.MaxTenants = DefaultMaxTenants
+	}
+	return &TenantRegistry{parent: parent, cfg: cfg, tenants: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Get returns the logger of the tenant with the given ID, creating it on
+// first use.
+func (r *TenantRegistry) Get(id string) *CustomLogger {
+	return r.tenant(id).log
+}
+
+// Len returns the number of tenants kept.
+func (r *TenantRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lru.Len()
+}
+
+// tenant returns the tenant with the given ID, marking it used and
+// evicting the least recently used ones beyond MaxTenants.
+func (r *TenantRegistry) tenant(id string) *tenant {
+	r.mu.Lock()
+	if el, ok := r.tenants[id]; ok {
+		r.lru.MoveToFront(el)
+		t := el.Value.(*tenant)
+		r.mu.Unlock()
+		return t
+	}
+	t := &tenant{id: id, log: r.derive(id)}
+	r.tenants[id] = r.lru.PushFront(t)
+	var evicted []*tenant
+	for r.lru.Len() > r.cfg.MaxTenants {
+		old := r.lru.Remove(r.lru.Back()).(*tenant)
+		delete(r.tenants, old.id)
+		evicted = append(evicted, old)
+	}
+	r.mu.Unlock()
+
+	for _, old := range evicted {
+		if err := old.close(); err != nil {
+			r.parent.errorHandler(err)
+		}
+	}
+	return t
+}
+
+// derive returns the logger of a tenant: the parent with the tenant
+// fields, writing to the tenant output if there is one.
+func (r *TenantRegistry) derive(id string) *CustomLogger {
+	fields := []Field{String(r.cfg.Key, id)}
+	if r.cfg.Fields != nil {
+		fields = append(fields, r.cfg.Fields(id)...)
+	}
+	child := *r.parent.With(fields...)
+	if r.cfg.Open != nil {
+		if r.cfg.Shared {
+			child.sinks = append(child.sinks[:len(child.sinks):len(child.sinks)], &tenantSink{r: r, id: id})
+			child.health = append(child.health[:len(child.health):len(child.health)], new(healthTracker))
+		} else {
+			child.sinks = []Sink{&tenantSink{r: r, id: id}}
+			child.health = []*healthTracker{new(healthTracker)}
+		}
+	}
+	return &child
+}
+
+// Flush flushes the open tenant outputs.
+func (r *TenantRegistry) Flush() error {
+	var errs []error
+	for _, t := range r.all() {
+		t.mu.Lock()
+		if t.sink != nil {
+			errs = append(errs, flushSink(t.sink))
+		}
+		t.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the open tenant outputs and forgets all tenants. The
+// loggers handed out reopen their outputs when used again.
+func (r *TenantRegistry) Close() error {
+	ts := r.all()
+	r.mu.Lock()
+	r.tenants = make(map[string]*list.Element)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	var errs []error
+	for _, t := range ts {
+		errs = append(errs, t.close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *TenantRegistry) all() []*tenant {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts := make([]*tenant, 0, r.lru.Len())
+	for el := r.lru.Front(); el != nil; el = el.Next() {
+		ts = append(ts, el.Value.(*tenant))
+	}
+	return ts
+}
+
+// write writes e to the output of the tenant, opening it if needed.
+func (t *tenant) write(open func(string) (Sink, error), e *Entry) (ok bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false, nil
+	}
+	if t.sink == nil {
+		if t.sink, err = open(t.id); err != nil {
+			t.sink = nil
+			return true, err
+		}
+	}
+	return true, t.sink.WriteEntry(e)
+}
+
+func (t *tenant) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if c, ok := t.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	if t.sink != nil {
+		return flushSink(t.sink)
+	}
+	return nil
+}
+
+// tenantSink writes to the output of a tenant, which the registry may have
+// evicted since the logger was handed out.
+type tenantSink struct {
+	r  *TenantRegistry
+	id string
+}
+
+// WriteEntry implements Sink.
+func (s *tenantSink) WriteEntry(e *Entry) error {
+	for {
+		if ok, err := s.r.tenant(s.id).write(s.r.cfg.Open, e); ok {
+			return err
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// tenantOutputs records the entries of every tenant and the outputs
+// closed.
+type tenantOutputs struct {
+	mu     sync.Mutex
+	bufs   map[string]*bytes.Buffer
+	opened []string
+	closed []string
+}
+
+type closingSink struct {
+	*WriterSink
+	o  *tenantOutputs
+	id string
+}
+
+func (s closingSink) Close() error {
+	s.o.mu.Lock()
+	s.o.closed = append(s.o.closed, s.id)
+	s.o.mu.Unlock()
+	return nil
+}
+
+func (o *tenantOutputs) open(id string) (Sink, error) {
+	if id == "bad" {
+		return nil, errors.New("no bucket")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.opened = append(o.opened, id)
+	if o.bufs[id] == nil {
+		o.bufs[id] = new(bytes.Buffer)
+	}
+	return closingSink{NewWriterSink(o.bufs[id], LogfmtEncoder{}), o, id}, nil
+}
+
+func TestTenantRegistry(t *testing.T) {
+	var shared bytes.Buffer
+	var errs []error
+	parent := newTestLogger(t, Info, &shared, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	o := &tenantOutputs{bufs: map[string]*bytes.Buffer{}}
+	r := NewTenantRegistry(parent, TenantConfig{
+		Open:       o.open,
+		Fields:     func(id string) []Field { return []Field{String("plan", "pro")} },
+		MaxTenants: 2,
+	})
+
+	acme := r.Get("acme")
+	acme.Info("invoice sent")
+	r.Get("globex").Info("hello")
+	if r.Get("acme") != acme {
+		t.Error("acme created again")
+	}
+	if !strings.Contains(o.bufs["acme"].String(), `msg="invoice sent" tenant=acme plan=pro`) || shared.Len() != 0 {
+		t.Errorf("acme %q, shared %q", o.bufs["acme"].String(), shared.String())
+	}
+
+	// A third tenant evicts globex, the least recently used; its logger
+	// reopens the output when used again, evicting acme.
+	globex := r.Get("globex")
+	r.Get("acme")
+	r.Get("initech")
+	if r.Len() != 2 || strings.Join(o.closed, ",") != "globex" {
+		t.Fatalf("%d tenants, closed %v", r.Len(), o.closed)
+	}
+	globex.Info("back")
+	if strings.Count(o.bufs["globex"].String(), "\n") != 2 || strings.Join(o.opened, ",") != "acme,globex,globex" {
+		t.Errorf("globex %q, opened %v", o.bufs["globex"].String(), o.opened)
+	}
+
+	r.Get("bad").Info("lost")
+	if len(errs) != 1 {
+		t.Errorf("errors %v", errs)
+	}
+	if err := r.Close(); err != nil || r.Len() != 0 || len(o.closed) != 3 {
+		t.Errorf("Close = %v, %d tenants, closed %v", err, r.Len(), o.closed)
+	}
+}
+
+func TestTenantRegistryShared(t *testing.T) {
+	var shared bytes.Buffer
+	parent := newTestLogger(t, Info, &shared)
+	o := &tenantOutputs{bufs: map[string]*bytes.Buffer{}}
+	r := NewTenantRegistry(parent, TenantConfig{Open: o.open, Shared: true})
+	r.Get("acme").Info("both")
+	if !strings.Contains(shared.String(), "tenant=acme") || o.bufs["acme"].Len() == 0 {
+		t.Errorf("shared %q, acme %q", shared.String(), o.bufs["acme"].String())
+	}
+
+	// Without Open tenant entries go to the parent's outputs.
+	shared.Reset()
+	NewTenantRegistry(parent, TenantConfig{Key: "customer"}).Get("acme").Info("fields only")
+	if !strings.Contains(shared.String(), "customer=acme") {
+		t.Errorf("shared %q", shared.String())
+	}
+}
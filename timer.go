@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// OperationKey is the field naming the operation of a Timer.
+	OperationKey = "op"
+	// DurationKey is the field holding a Timer's elapsed time.
+	DurationKey = "duration"
+)
+
+// Timer logs the start and outcome of one operation with its elapsed time.
+// Only the first Done, Fail or Stop call is logged.
+type Timer struct {
+	l      *CustomLogger
+	fields []Field
+	start  time.Time
+	done   atomic.Bool
+}
+
+// Timed logs "<op> started" at Debug and returns a Timer for the operation:
+//
+//	defer l.Timed("indexing").Done()
+//
+// or, reporting failure through a named error result:
+//
+//	defer l.Timed("indexing").Stop(&err)
+func (l *CustomLogger) Timed(op string, fields ...Field) *Timer {
+	t := &Timer{
+		l:      l,
+		fields: append([]Field{String(OperationKey, op)}, fields...),
+		start:  l.clock(),
+	}
+	if l.enabled(Debug) {
+		l.log(Debug, op+" started", t.fields...)
+	}
+	return t
+}
+
+// Elapsed returns the time since the operation started.
+func (t *Timer) Elapsed() time.Duration {
+	return t.l.clock().Sub(t.start)
+}
+
+// Done logs "<op> completed" at Info.
+func (t *Timer) Done(fields ...Field) {
+	t.finish(Info, "completed", nil, fields)
+}
+
+// Fail logs "<op> failed" at Error with err.
+func (t *Timer) Fail(err error, fields ...Field) {
+	t.finish(Error, "failed", err, fields)
+}
+
+// Stop calls Fail if *errp is a non-nil error and Done otherwise.
+func (t *Timer) Stop(errp *error, fields ...Field) {
+	if errp != nil && *errp != nil {
+		t.Fail(*errp, fields...)
+		return
+	}
+	t.Done(fields...)
+}
+
+func (t *Timer) finish(level LogLevel, outcome string, err error, extra []Field) {
+	if t.done.Swap(true) || !t.l.enabled(level) {
+		return
+	}
+	fields := append(t.fields[:len(t.fields):len(t.fields)], Duration(DurationKey, t.Elapsed()))
+	if err != nil {
+		fields = append(fields, Err(err))
+	}
+	t.l.log(level, t.fields[0].String+" "+outcome, append(fields, extra...)...)
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	dst := &countSink{keep: true}
+	now := testTime
+	l, err := New(Debug, "test", "", WithOutputLevel(Emergency+1), WithSinks(dst),
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+
+	timer := l.Timed("index", String("db", "main"))
+	now = now.Add(time.Second)
+	timer.Done(Int("docs", 3))
+	timer.Fail(errors.New("late"))
+	if len(dst.entries) != 2 {
+		t.Fatalf("wrote %q", messages(dst.entries))
+	}
+	start, done := dst.entries[0], dst.entries[1]
+	if start.Level != Debug || start.Message != "index started" || len(start.Fields) != 2 {
+		t.Errorf("start %v %q %+v", start.Level, start.Message, start.Fields)
+	}
+	m := done.FieldMap()
+	if done.Level != Info || done.Message != "index completed" || m[OperationKey] != "index" || m["db"] != "main" ||
+		m[DurationKey] != time.Second || m["docs"] != int64(3) {
+		t.Errorf("done %v %q %v", done.Level, done.Message, m)
+	}
+
+	// Stop reports a named error result.
+	dst.entries = nil
+	run := func() (err error) {
+		defer l.Timed("sync").Stop(&err)
+		return errors.New("unreachable")
+	}
+	run()
+	if len(dst.entries) != 2 {
+		t.Fatalf("wrote %q", messages(dst.entries))
+	}
+	if e := dst.entries[1]; e.Level != Error || e.Message != "sync failed" || !strings.Contains(e.FieldMap()["error"].(string), "unreachable") {
+		t.Errorf("failure %v %q %v", e.Level, e.Message, e.FieldMap())
+	}
+}
@@ -0,0 +1,128 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const TLSConfigErrFmt = "Invalid TLS configuration: %w"
+
+// TLSConfig describes TLS settings shared by the network senders. Build
+// it into a *tls.Config for the TCP-based senders, or into an *http.Client
+// for the HTTP ones.
+type TLSConfig struct {
+	// CAFile is a PEM bundle of trusted roots; empty uses the system pool.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key for mutual
+	// TLS. They are reloaded when CertFile changes, so certificates
+	// rotated on disk are picked up without a restart.
+	CertFile string
+	KeyFile  string
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+	// ServerName overrides the name verified against the certificate.
+	ServerName string
+	// InsecureSkipVerify disables verification; for testing only.
+	InsecureSkipVerify bool
+}
+
+// Config loads the files and returns the resulting *tls.Config.
+func (c TLSConfig) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         c.MinVersion,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf(TLSConfigErrFmt, err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(TLSConfigErrFmt, errors.New("no certificates in "+c.CAFile))
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(TLSConfigErrFmt, err)
+		}
+		r := &certReloader{certFile: c.CertFile, keyFile: c.KeyFile, cert: &cert}
+		if fi, err := os.Stat(c.CertFile); err == nil {
+			r.modTime = fi.ModTime()
+		}
+		cfg.GetClientCertificate = r.get
+	}
+	return cfg, nil
+}
+
+// certReloader serves a client certificate, reloading it when the
+// certificate file's modification time changes.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (r *certReloader) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fi, err := os.Stat(r.certFile)
+	if err != nil || fi.ModTime().Equal(r.modTime) {
+		return r.cert, nil
+	}
+	// Keep the old certificate if the new pair is not complete yet.
+	if cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile); err == nil {
+		r.cert = &cert
+		r.modTime = fi.ModTime()
+	}
+	return r.cert, nil
+}
+
+// HTTPClient returns an *http.Client using the TLS settings, for the
+// Client field of the HTTP senders.
+func (c TLSConfig) HTTPClient() (*http.Client, error) {
+	cfg, err := c.Config()
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = cfg
+	return &http.Client{Transport: tr}, nil
+}
+
+// dialNetwork connects to addr over TCP, with TLS when cfg is non-nil.
+func dialNetwork(ctx context.Context, addr string, timeout time.Duration, cfg *tls.Config) (net.Conn, error) {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil || cfg == nil {
+		return conn, err
+	}
+	cfg = cfg.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	tc := tls.Client(conn, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tc, nil
+}
@@ -0,0 +1,185 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate written to certFile and keyFile in PEM.
+type testCert struct {
+	cert              *x509.Certificate
+	key               *ecdsa.PrivateKey
+	certFile, keyFile string
+}
+
+// newTestCert creates a certificate for 127.0.0.1 named cn, signed by
+// parent or self-signed as a CA when parent is nil.
+func newTestCert(t *testing.T, dir, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testCert{key: key, certFile: filepath.Join(dir, cn+".pem"), keyFile: filepath.Join(dir, cn+"-key.pem")}
+	c.cert, _ = x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return c
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	server := newTestCert(t, dir, "server", ca)
+
+	cfg, err := TLSConfig{CAFile: ca.certFile}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 || cfg.GetClientCertificate != nil {
+		t.Errorf("config %+v", cfg)
+	}
+	if _, err := (TLSConfig{CAFile: server.keyFile}).Config(); err == nil {
+		t.Error("accepted a CA file without certificates")
+	}
+	if _, err := (TLSConfig{CertFile: server.certFile}).Config(); err == nil {
+		t.Error("accepted a certificate without its key")
+	}
+
+	// dialNetwork verifies the server against the CA.
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{server.tlsCert()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := dialNetwork(context.Background(), ln.Addr().String(), time.Second, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if conn, err := dialNetwork(context.Background(), ln.Addr().String(), time.Second, &tls.Config{}); err == nil {
+		conn.Close()
+		t.Error("untrusted server accepted")
+	}
+
+	// HTTPClient does the same for the HTTP senders.
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{server.tlsCert()}}
+	srv.StartTLS()
+	defer srv.Close()
+	client, err := TLSConfig{CAFile: ca.certFile}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestTLSCertReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	server := newTestCert(t, dir, "server", ca)
+	client := newTestCert(t, dir, "client", ca)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	subjects := make(chan string, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subjects <- r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{server.tlsCert()}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cfg, err := TLSConfig{CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := srv.Client()
+	c.Transport.(*http.Transport).TLSClientConfig = cfg
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := <-subjects; got != "client" {
+		t.Fatalf("client certificate %q", got)
+	}
+
+	// A certificate rotated on disk is served by the existing config.
+	rotated := newTestCert(t, t.TempDir(), "rotated", ca)
+	for _, f := range [][2]string{{rotated.certFile, client.certFile}, {rotated.keyFile, client.keyFile}} {
+		b, _ := os.ReadFile(f[0])
+		os.WriteFile(f[1], b, 0o600)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(client.certFile, later, later)
+	cert, err := cfg.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf, _ := x509.ParseCertificate(cert.Certificate[0]); leaf.Subject.CommonName != "rotated" {
+		t.Errorf("reloaded certificate %q", leaf.Subject.CommonName)
+	}
+
+	// A half-written pair keeps the previous certificate.
+	os.WriteFile(client.keyFile, []byte("partial"), 0o600)
+	later = later.Add(time.Minute)
+	os.Chtimes(client.certFile, later, later)
+	if cert2, _ := cfg.GetClientCertificate(nil); cert2 != cert {
+		t.Error("certificate replaced by an incomplete pair")
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceIDKey is the field holding the trace ID of a request.
+	TraceIDKey = "trace_id"
+	// ParentIDKey is the field holding the caller's span ID.
+	ParentIDKey = "parent_id"
+)
+
+// TraceContext is the trace position propagated by an incoming request.
+type TraceContext struct {
+	TraceID  string
+	ParentID string
+	Sampled  bool
+}
+
+// Fields returns the trace_id and parent_id fields of t.
+func (t TraceContext) Fields() []Field {
+	return []Field{String(TraceIDKey, t.TraceID), String(ParentIDKey, t.ParentID)}
+}
+
+type traceKey struct{}
+
+// NewTraceContext returns a copy of ctx carrying t, see TraceFromContext.
+func NewTraceContext(ctx context.Context, t TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFromContext returns the trace stored in ctx by NewTraceContext.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	t, ok := ctx.Value(traceKey{}).(TraceContext)
+	return t, ok
+}
+
+func contextTraceSampled(ctx context.Context) (sampled, ok bool) {
+	t, ok := TraceFromContext(ctx)
+	return t.Sampled, ok
+}
+
+// TraceFromHeaders reads a W3C traceparent header, falling back to B3 in
+// its single (b3) or multi header (X-B3-*) form. IDs are returned in lower
+// case; ok is false if no valid header is present.
+func TraceFromHeaders(h http.Header) (t TraceContext, ok bool) {
+	if t, ok = ParseTraceparent(h.Get("traceparent")); ok {
+		return t, true
+	}
+	if t, ok = ParseB3(h.Get("b3")); ok {
+		return t, true
+	}
+	t = TraceContext{
+		TraceID:  strings.ToLower(h.Get("X-B3-TraceId")),
+		ParentID: strings.ToLower(h.Get("X-B3-SpanId")),
+	}
+	if !validB3TraceID(t.TraceID) || !validHexID(t.ParentID, 16) {
+		return TraceContext{}, false
+	}
+	s := h.Get("X-B3-Sampled")
+	t.Sampled = s == "1" || s == "true" || h.Get("X-B3-Flags") == "1"
+	return t, true
+}
+
+// ParseTraceparent parses a W3C Trace Context traceparent header of the
+// form version-traceid-parentid-flags.
+func ParseTraceparent(s string) (TraceContext, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceContext{}, false
+	}
+	version, flags := s[:2], s[53:55]
+	if !isHex(version) || version == "ff" || !isHex(flags) {
+		return TraceContext{}, false
+	}
+	// Version 00 has exactly four parts; later versions may append more.
+	if len(s) > 55 && (version == "00" || s[55] != '-') {
+		return TraceContext{}, false
+	}
+	t := TraceContext{TraceID: s[3:35], ParentID: s[36:52]}
+	if !validHexID(t.TraceID, 32) || !validHexID(t.ParentID, 16) {
+		return TraceContext{}, false
+	}
+	t.Sampled = hexNibble(flags[1])&1 == 1
+	return t, true
+}
+
+// ParseB3 parses the single header B3 form
+// traceid-spanid[-sampling[-parentspanid]]. A bare sampling state such as
+// "0" carries no IDs and is rejected.
+func ParseB3(s string) (TraceContext, bool) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return TraceContext{}, false
+	}
+	t := TraceContext{TraceID: parts[0], ParentID: parts[1]}
+	if !validB3TraceID(t.TraceID) || !validHexID(t.ParentID, 16) {
+		return TraceContext{}, false
+	}
+	if len(parts) > 2 {
+		t.Sampled = parts[2] == "1" || parts[2] == "d"
+	}
+	return t, true
+}
+
+func validB3TraceID(s string) bool {
+	return validHexID(s, 16) || validHexID(s, 32)
+}
+
+// validHexID reports whether s is n hex digits, not all zero.
+func validHexID(s string, n int) bool {
+	return len(s) == n && isHex(s) && strings.Trim(s, "0") != ""
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if hexNibble(s[i]) > 15 {
+			return false
+		}
+	}
+	return true
+}
+
+// hexNibble returns the value of a lower case hex digit, or 16.
+func hexNibble(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return 16
+}
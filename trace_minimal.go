@@ -0,0 +1,10 @@
+//go:build logger_minimal
+
+package logger
+
+import "context"
+
+// contextTraceSampled finds no trace without the HTTP integration.
+func contextTraceSampled(ctx context.Context) (sampled, ok bool) {
+	return false, false
+}
@@ -0,0 +1,120 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testTraceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		in      string
+		ok      bool
+		sampled bool
+	}{
+		{"00-" + testTraceID + "-" + testParentID + "-01", true, true},
+		{"00-" + testTraceID + "-" + testParentID + "-00", true, false},
+		{" 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01 ", true, true},
+		{"01-" + testTraceID + "-" + testParentID + "-01-extra", true, true},
+		{"00-" + testTraceID + "-" + testParentID + "-01-extra", false, false},
+		{"ff-" + testTraceID + "-" + testParentID + "-01", false, false},
+		{"00-00000000000000000000000000000000-" + testParentID + "-01", false, false},
+		{"00-" + testTraceID + "-0000000000000000-01", false, false},
+		{"00-" + testTraceID + "-" + testParentID + "-0g", false, false},
+		{"00-" + testTraceID + "x" + testParentID + "-01", false, false},
+		{"00-" + testTraceID[1:] + "-" + testParentID + "-01", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTraceparent(tt.in)
+		if ok != tt.ok {
+			t.Errorf("ParseTraceparent(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && (got.TraceID != testTraceID || got.ParentID != testParentID || got.Sampled != tt.sampled) {
+			t.Errorf("ParseTraceparent(%q) = %+v", tt.in, got)
+		}
+	}
+}
+
+func TestParseB3(t *testing.T) {
+	tests := []struct {
+		in      string
+		ok      bool
+		trace   string
+		sampled bool
+	}{
+		{testTraceID + "-" + testParentID, true, testTraceID, false},
+		{testTraceID + "-" + testParentID + "-1", true, testTraceID, true},
+		{testTraceID + "-" + testParentID + "-d-" + testParentID, true, testTraceID, true},
+		{"a3ce929d0e0e4736-" + testParentID + "-0", true, "a3ce929d0e0e4736", false},
+		{"0", false, "", false},
+		{testTraceID, false, "", false},
+		{testTraceID + "-" + testParentID + "-1-" + testParentID + "-x", false, "", false},
+		{"xyz-" + testParentID, false, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseB3(tt.in)
+		if ok != tt.ok {
+			t.Errorf("ParseB3(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			continue
+		}
+		if ok && (got.TraceID != tt.trace || got.ParentID != testParentID || got.Sampled != tt.sampled) {
+			t.Errorf("ParseB3(%q) = %+v", tt.in, got)
+		}
+	}
+}
+
+func TestTraceFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		ok      bool
+		sampled bool
+	}{
+		{"traceparent", map[string]string{"traceparent": "00-" + testTraceID + "-" + testParentID + "-01"}, true, true},
+		{"b3", map[string]string{"b3": testTraceID + "-" + testParentID + "-1"}, true, true},
+		{"multi", map[string]string{"X-B3-TraceId": testTraceID, "X-B3-SpanId": testParentID, "X-B3-Sampled": "1"}, true, true},
+		{"multi debug", map[string]string{"X-B3-TraceId": testTraceID, "X-B3-SpanId": testParentID, "X-B3-Flags": "1"}, true, true},
+		{"invalid falls back", map[string]string{"traceparent": "junk", "b3": testTraceID + "-" + testParentID}, true, false},
+		{"none", nil, false, false},
+	}
+	for _, tt := range tests {
+		h := make(http.Header)
+		for k, v := range tt.headers {
+			h.Set(k, v)
+		}
+		got, ok := TraceFromHeaders(h)
+		if ok != tt.ok || (ok && (got.TraceID != testTraceID || got.Sampled != tt.sampled)) {
+			t.Errorf("%s: got %+v, %v", tt.name, got, ok)
+		}
+	}
+}
+
+func TestMiddlewareTraceSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf, WithTraceSampling(TraceSamplingConfig{}))
+	h := Middleware(l, MiddlewareConfig{TraceHeaders: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context(), l).Debug("request detail")
+		l.LogContext(r.Context(), Debug, "context detail")
+	}))
+	// Sampled requests log both entries, others neither.
+	for flags, want := range map[string]int{"01": 2, "00": 0} {
+		buf.Reset()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("traceparent", "00-"+testTraceID+"-"+testParentID+"-"+flags)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if got := strings.Count(buf.String(), "detail"); got != want {
+			t.Errorf("flags %s: output %q", flags, buf.String())
+		}
+	}
+}
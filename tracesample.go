@@ -0,0 +1,85 @@
+package logger
+
+import "context"
+
+// TraceSamplingConfig configures WithTraceSampling.
+type TraceSamplingConfig struct {
+	// MaxLevel is the most urgent level written only for sampled traces;
+	// the zero value gates Debug and the custom levels below it.
+	MaxLevel LogLevel
+	// RequireTrace also drops the gated entries of loggers without a
+	// sampling decision, such as those of background work. By default
+	// they follow the level settings alone.
+	RequireTrace bool
+	// Sampled, if set, returns the sampling decision carried by a context
+	// for LogContext, e.g. from an OpenTelemetry span:
+	//
+	//	func(ctx context.Context) (bool, bool) {
+	//		sc := trace.SpanContextFromContext(ctx)
+	//		return sc.IsSampled(), sc.IsValid()
+	//	}
+	//
+	// nil reads the TraceContext stored by NewTraceContext, as Middleware
+	// does with TraceHeaders.
+	Sampled func(ctx context.Context) (sampled, ok bool)
+}
+
+// traceState is a logger's knowledge of the sampling decision of its
+// trace.
+type traceState uint8
+
+const (
+	traceUnknown traceState = iota
+	traceSampled
+	traceDropped
+)
+
+// WithTraceSampling writes verbose entries only for the traces that are
+// kept, so Debug logs line up with the traces sampled: entries up to
+// cfg.MaxLevel are dropped on loggers whose trace was not sampled, see
+// TraceSampled, and for LogContext calls whose context carries such a
+// trace.
+func WithTraceSampling(cfg TraceSamplingConfig) Option {
+	return func(l *CustomLogger) {
+		l.traceGate = &cfg
+	}
+}
+
+// TraceSampled returns a child logger recording whether its trace was
+// sampled, for WithTraceSampling. Middleware marks the request logger
+// itself when TraceHeaders is set.
+func (l *CustomLogger) TraceSampled(sampled bool) *CustomLogger {
+	child := *l
+	child.trace = traceDropped
+	if sampled {
+		child.trace = traceSampled
+	}
+	return &child
+}
+
+// traceAllows reports whether the trace gate lets an entry at level pass,
+// with the sampling decision of ctx if the logger has none.
+func (l *CustomLogger) traceAllows(ctx context.Context, level LogLevel) bool {
+	if l.traceGate == nil || level > l.traceGate.MaxLevel {
+		return true
+	}
+	state := l.trace
+	if state == traceUnknown && ctx != nil {
+		sampled, ok := false, false
+		if l.traceGate.Sampled != nil {
+			sampled, ok = l.traceGate.Sampled(ctx)
+		} else {
+			sampled, ok = contextTraceSampled(ctx)
+		}
+		if ok {
+			return sampled
+		}
+	}
+	switch state {
+	case traceSampled:
+		return true
+	case traceDropped:
+		return false
+	}
+	return !l.traceGate.RequireTrace
+}
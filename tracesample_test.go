@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type sampledKey struct{}
+
+func TestTraceSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf, WithTraceSampling(TraceSamplingConfig{
+		Sampled: func(ctx context.Context) (bool, bool) {
+			s, ok := ctx.Value(sampledKey{}).(bool)
+			return s, ok
+		},
+	}))
+	l.TraceSampled(true).Debug("kept")
+	l.TraceSampled(false).Debug("dropped")
+	l.TraceSampled(false).Info("info passes")
+	l.Debug("untraced")
+	l.LogContext(context.WithValue(context.Background(), sampledKey{}, false), Debug, "dropped by context")
+	l.LogContext(context.WithValue(context.Background(), sampledKey{}, true), Debug, "kept by context")
+	out := buf.String()
+	for _, s := range []string{"kept", "info passes", "untraced", "kept by context"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q missing from %q", s, out)
+		}
+	}
+	if strings.Contains(out, "dropped") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestTraceSamplingRequireTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf, WithTraceSampling(TraceSamplingConfig{RequireTrace: true}))
+	l.Debug("untraced")
+	l.Named("db").TraceSampled(true).Debug("traced")
+	if s := buf.String(); strings.Contains(s, "untraced") || !strings.Contains(s, "traced") {
+		t.Errorf("output = %q", s)
+	}
+}
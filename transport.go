@@ -0,0 +1,224 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultRedactedHeaders are the headers Transport never logs in the
+	// clear.
+	DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	// DefaultRedactedParams are the query parameters Transport masks in
+	// logged URLs.
+	DefaultRedactedParams = []string{"access_token", "api_key", "key", "password", "sig", "signature", "token"}
+)
+
+// TransportConfig configures Transport.
+type TransportConfig struct {
+	// Base performs the requests; nil selects http.DefaultTransport.
+	Base http.RoundTripper
+	// Level logs requests answered below 400, Debug if zero. Failed requests
+	// and 4xx and 5xx responses are logged at Warn.
+	Level LogLevel
+	// Retry retries requests failing in transport or answered 429, 502,
+	// 503 or 504, when their body can be sent again; a zero MaxAttempts
+	// sends every request once.
+	Retry RetryPolicy
+	// Headers are the request and response headers logged, e.g.
+	// "Content-Type" or "Retry-After".
+	Headers []string
+	// RedactHeaders are logged as SecretMask; nil selects
+	// DefaultRedactedHeaders.
+	RedactHeaders []string
+	// RedactParams are the query parameters masked in the logged URL; nil
+	// selects DefaultRedactedParams.
+	RedactParams []string
+	// Body logs up to Body bytes of the request and response bodies; zero
+	// logs none. Responses are read that far ahead.
+	Body int
+	// RedactBody, if set, rewrites the logged bodies, e.g. masking fields
+	// of a JSON document.
+	RedactBody func(b []byte) []byte
+}
+
+// Transport returns an http.RoundTripper logging every outbound request
+// with its method, redacted URL, status, duration and attempts, so HTTP
+// clients get the visibility Middleware gives servers. Requests log
+// through the logger in their context, see FromContext, so a client call
+// made while serving a request carries its request ID.
+func Transport(l *CustomLogger, cfg TransportConfig) http.RoundTripper {
+	if cfg.Base == nil {
+		cfg.Base = http.DefaultTransport
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = DefaultRedactedHeaders
+	}
+	if cfg.RedactParams == nil {
+		cfg.RedactParams = DefaultRedactedParams
+	}
+	t := &loggingTransport{l: l, cfg: cfg, redact: map[string]bool{}, params: map[string]bool{}}
+	for _, h := range cfg.RedactHeaders {
+		t.redact[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, p := range cfg.RedactParams {
+		t.params[strings.ToLower(p)] = true
+	}
+	return t
+}
+
+type loggingTransport struct {
+	l      *CustomLogger
+	cfg    TransportConfig
+	redact map[string]bool
+	params map[string]bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := FromContext(req.Context(), t.l)
+	start := l.clock()
+	resp, attempts, err := t.send(req)
+	duration := l.clock().Sub(start)
+
+	level := t.cfg.Level
+	if err != nil || resp.StatusCode >= 400 {
+		level = Warn
+	}
+	if !l.enabled(level) {
+		return resp, err
+	}
+	fields := []Field{
+		String("method", req.Method),
+		String("url", t.redactURL(req.URL)),
+		Duration("duration", duration),
+		Int("attempts", attempts),
+	}
+	if err != nil {
+		fields = append(fields, Err(err))
+	} else {
+		fields = append(fields, Int("status", resp.StatusCode))
+	}
+	if h := t.headers(req.Header); len(h) > 0 {
+		fields = append(fields, Group("request_headers", h...))
+	}
+	if t.cfg.Body > 0 && req.GetBody != nil {
+		if body, gerr := req.GetBody(); gerr == nil {
+			b, _ := io.ReadAll(io.LimitReader(body, int64(t.cfg.Body)))
+			body.Close()
+			fields = append(fields, String("request_body", t.redactBody(b)))
+		}
+	}
+	if resp != nil {
+		if h := t.headers(resp.Header); len(h) > 0 {
+			fields = append(fields, Group("response_headers", h...))
+		}
+		if t.cfg.Body > 0 {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, int64(t.cfg.Body)))
+			resp.Body = readCloser{io.MultiReader(bytes.NewReader(b), resp.Body), resp.Body}
+			fields = append(fields, String("response_body", t.redactBody(b)))
+		}
+	}
+	l.log(level, "outbound request", fields...)
+	return resp, err
+}
+
+// send sends req, retrying it according to the policy, and returns the
+// last response or error with the number of attempts made.
+func (t *loggingTransport) send(req *http.Request) (*http.Response, int, error) {
+	attempts := t.cfg.Retry.MaxAttempts
+	if attempts < 1 || req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := t.cfg.Base.RoundTrip(req)
+		if attempt >= attempts || !retryable(resp, err) {
+			return resp, attempt, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(t.cfg.Retry.Backoff(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, attempt, req.Context().Err()
+		case <-timer.C:
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, attempt, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// headers returns the configured headers of h as fields, redacted.
+func (t *loggingTransport) headers(h http.Header) []Field {
+	var fields []Field
+	for _, name := range t.cfg.Headers {
+		name = http.CanonicalHeaderKey(name)
+		v, ok := h[name]
+		if !ok {
+			continue
+		}
+		s := strings.Join(v, ", ")
+		if t.redact[name] {
+			s = SecretMask
+		}
+		fields = append(fields, String(name, s))
+	}
+	return fields
+}
+
+// redactURL returns u without user info and with the redacted query
+// parameters masked.
+func (t *loggingTransport) redactURL(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		c.User = url.User(SecretMask)
+	}
+	if c.RawQuery != "" {
+		q := c.Query()
+		for k := range q {
+			if t.params[strings.ToLower(k)] {
+				q[k] = []string{SecretMask}
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+func (t *loggingTransport) redactBody(b []byte) string {
+	if t.cfg.RedactBody != nil {
+		b = t.cfg.RedactBody(b)
+	}
+	return string(b)
+}
+
+// readCloser reads from a Reader and closes a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,83 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, _ := io.ReadAll(r.Body); string(b) != `{"q":1}` {
+			t.Errorf("body = %q", b)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf, WithEncoder(JSONEncoder{}))
+	client := &http.Client{Transport: Transport(l, TransportConfig{
+		Retry:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		Headers: []string{"Authorization", "content-type"},
+		Body:    64,
+	})}
+	req, _ := http.NewRequest("POST", srv.URL+"/search?token=s3cret&page=2", strings.NewReader(`{"q":1}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"ok":true}` {
+		t.Errorf("response body = %q", body)
+	}
+
+	s := buf.String()
+	if strings.Contains(s, "s3cret") {
+		t.Errorf("secret logged: %s", s)
+	}
+	e, err := DecodeJSON(bytes.TrimSpace(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := e.FieldMap()
+	if e.Level != Debug || m["method"] != "POST" || m["status"] != int64(200) || m["attempts"] != int64(2) ||
+		m["request_body"] != `{"q":1}` || m["response_body"] != `{"ok":true}` {
+		t.Errorf("entry = %+v", e)
+	}
+	if u, _ := m["url"].(string); !strings.Contains(u, "page=2") || !strings.Contains(u, "token="+SecretMask) {
+		t.Errorf("url = %v", m["url"])
+	}
+	if !strings.Contains(s, `"Authorization":"`+SecretMask+`"`) || !strings.Contains(s, `"Content-Type":"application/json"`) {
+		t.Errorf("headers not logged: %s", s)
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Info, &buf)
+	client := &http.Client{Transport: Transport(l, TransportConfig{})}
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Fatal("want an error")
+	}
+	if s := buf.String(); !strings.Contains(s, "WARN") || !strings.Contains(s, "attempts=1") || !strings.Contains(s, "error=") {
+		t.Errorf("log = %q", s)
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import "fmt"
+
+// maxLogValueDepth bounds how many LogValuers are resolved in a chain.
+const maxLogValueDepth = 100
+
+// LogValuePanicFmt replaces the value of a LogValuer that panicked.
+const LogValuePanicFmt = "!PANIC: %v"
+
+// LogValuer is implemented by types that control their own log
+// representation, for example to redact secrets:
+//
+//	func (t Token) LogValue() interface{} { return "REDACTED" }
+//
+// Field values implementing it are resolved once, after the processors
+// ran, so every sink and encoder sees the replacement. The result may
+// itself be a LogValuer.
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// resolveFields replaces LogValuer field values by their log values.
+func resolveFields(fields []Field) {
+	for i := range fields {
+		if fields[i].Type == GroupType {
+			group, _ := fields[i].Value.([]Field)
+			resolveFields(group)
+			continue
+		}
+		if fields[i].Type != AnyType {
+			continue
+		}
+		if v, ok := fields[i].Value.(LogValuer); ok {
+			fields[i] = Any(fields[i].Key, resolveLogValue(v))
+		}
+	}
+}
+
+func resolveLogValue(v LogValuer) (val interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			val = fmt.Sprintf(LogValuePanicFmt, r)
+		}
+	}()
+	for i := 0; i < maxLogValueDepth; i++ {
+		val = v.LogValue()
+		next, ok := val.(LogValuer)
+		if !ok {
+			return val
+		}
+		v = next
+	}
+	return val
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type secretToken string
+
+func (secretToken) LogValue() interface{} { return "REDACTED" }
+
+// userRef resolves to another LogValuer.
+type userRef int
+
+func (u userRef) LogValue() interface{} { return secretToken("u") }
+
+type panicValuer struct{}
+
+func (panicValuer) LogValue() interface{} { panic("broken") }
+
+// loopValuer resolves to itself forever.
+type loopValuer struct{}
+
+func (v loopValuer) LogValue() interface{} { return v }
+
+func TestLogValuer(t *testing.T) {
+	var buf bytes.Buffer
+	// Processors still see the original value.
+	var seen interface{}
+	l := newTestLogger(t, Info, &buf, WithEncoder(LogfmtEncoder{}),
+		WithProcessors(ProcessorFunc(func(e *Entry) { seen = e.Fields[0].Value })))
+	l.Log(Info, "login", Any("token", secretToken("s3cr3t")), Group("user", Any("ref", userRef(1))),
+		Any("bad", panicValuer{}), Any("loop", loopValuer{}))
+	out := buf.String()
+	for _, want := range []string{"token=REDACTED", "user.ref=REDACTED", `bad="!PANIC: broken"`, "loop="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q lacks %s", out, want)
+		}
+	}
+	if seen != secretToken("s3cr3t") {
+		t.Errorf("processor saw %v", seen)
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("%q leaks the token", out)
+	}
+}
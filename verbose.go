@@ -0,0 +1,65 @@
+package logger
+
+import "fmt"
+
+// VerbosityKey is the field recording the V level of an entry.
+const VerbosityKey = "v"
+
+// Verbose is a handle returned by CustomLogger.V. Its methods write Debug
+// entries only when the logger's verbosity is at least the handle's level
+// and Debug is enabled; otherwise they do nothing.
+type Verbose struct {
+	l       *CustomLogger
+	level   int
+	enabled bool
+}
+
+// V returns a handle for verbosity level n, for klog-style gradation of
+// debug output:
+//
+//	log.V(2).Info("cache miss", key)
+//	if v := log.V(4); v.Enabled() {
+//		v.Info(expensiveDump())
+//	}
+func (l *CustomLogger) V(n int) Verbose {
+	return Verbose{
+		l:       l,
+		level:   n,
+		enabled: n <= int(l.levels.verbosity.Load()) && l.enabled(Debug),
+	}
+}
+
+// SetVerbosity sets the highest V level that is written, for this logger
+// and every logger sharing its levels. The default is 0.
+func (l *CustomLogger) SetVerbosity(n int) {
+	l.levels.verbosity.Store(int32(n))
+}
+
+// Verbosity returns the highest V level that is written.
+func (l *CustomLogger) Verbosity() int {
+	return int(l.levels.verbosity.Load())
+}
+
+// Enabled reports whether entries at this V level are written.
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+func (v Verbose) Info(args ...interface{}) {
+	if v.enabled {
+		v.l.log(Debug, fmt.Sprintln(args...), Int(VerbosityKey, v.level))
+	}
+}
+
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.enabled {
+		v.l.log(Debug, fmt.Sprintf(format, args...), Int(VerbosityKey, v.level))
+	}
+}
+
+// Log writes msg with fields, like CustomLogger.Log at Debug level.
+func (v Verbose) Log(msg string, fields ...Field) {
+	if v.enabled {
+		v.l.log(Debug, msg, append(fields[:len(fields):len(fields)], Int(VerbosityKey, v.level))...)
+	}
+}
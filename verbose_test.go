@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVerbose(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(t, Debug, &buf)
+	l.SetVerbosity(2)
+	l.V(2).Infof("cache %s", "miss")
+	l.V(3).Info("hidden")
+	if l.V(3).Enabled() || !l.V(2).Enabled() {
+		t.Error("Enabled does not follow the verbosity")
+	}
+	if s := buf.String(); !strings.Contains(s, "cache miss") || !strings.Contains(s, "v=2") || strings.Contains(s, "hidden") {
+		t.Errorf("output = %q", s)
+	}
+
+	l.SetLevel(Info)
+	if l.V(0).Enabled() {
+		t.Error("V enabled with Debug disabled")
+	}
+}
+
+func TestVerboseLogFields(t *testing.T) {
+	l := newTestLogger(t, Debug, &bytes.Buffer{})
+	l.SetVerbosity(1)
+	backing := make([]Field, 2, 3)
+	backing[0] = String("a", "1")
+	fields := backing[:1]
+	l.V(1).Log("m", fields...)
+	if backing[1].Key != "" {
+		t.Errorf("Log wrote %q into the caller's fields", backing[1].Key)
+	}
+}
@@ -0,0 +1,189 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+const (
+	// DefaultWebhookTemplate posts {"text": "..."}, which Slack, Mattermost
+	// and Rocket.Chat incoming webhooks accept.
+	DefaultWebhookTemplate = `{"text": {{json .Text}}}`
+	// DefaultWebhookTimeout bounds one webhook request.
+	DefaultWebhookTimeout = 10 * time.Second
+
+	WebhookTemplateErrFmt = "Invalid webhook template: %w"
+	WebhookPayloadErrFmt  = "Webhook template produced invalid JSON: %s"
+)
+
+// WebhookConfig configures a WebhookSink.
+type WebhookConfig struct {
+	// URL receives a POST per entry.
+	URL string
+	// Template renders the JSON payload with text/template over a
+	// WebhookData; the json function encodes a value as JSON. Empty
+	// selects DefaultWebhookTemplate.
+	Template string
+	// MinLevel is the lowest level posted; zero means Error.
+	MinLevel LogLevel
+	// ExplicitLevels uses MinLevel as given, so Debug (zero) can be
+	// selected.
+	ExplicitLevels bool
+	// Rate limits the posts to Rate per Per, spent in bursts of up to Rate;
+	// zero Rate is unlimited and zero Per means a minute. Entries over the
+	// limit count as suppressed.
+	Rate int
+	Per  time.Duration
+	// Header is sent with every request, e.g. an Authorization token.
+	Header http.Header
+	// Timeout bounds a request; zero selects DefaultWebhookTimeout.
+	Timeout time.Duration
+	// Client is the HTTP client used; nil selects http.DefaultClient.
+	Client *http.Client
+}
+
+// WebhookData is what a webhook template renders.
+type WebhookData struct {
+	Time    time.Time
+	Level   string
+	Name    string
+	Message string
+	// Text is "LEVEL name: message", or "LEVEL: message" without a name.
+	Text   string
+	Fields map[string]interface{}
+}
+
+// WebhookSink posts entries to an HTTP endpoint as JSON rendered from a
+// template, so chat and alerting systems can be reached without a
+// dedicated integration. Posting is synchronous; wrap the sink in an
+// AsyncSink to keep it off the logging path.
+type WebhookSink struct {
+	cfg        WebhookConfig
+	tmpl       *template.Template
+	limit      *rateLimiter
+	suppressed atomic.Uint64
+}
+
+// NewWebhookSink returns a WebhookSink, or an error if the template does
+// not parse.
+func NewWebhookSink(cfg WebhookConfig) (*WebhookSink, error) {
+	if cfg.Template == "" {
+		cfg.Template = DefaultWebhookTemplate
+	}
+	if cfg.MinLevel == 0 && !cfg.ExplicitLevels {
+		cfg.MinLevel = Error
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultWebhookTimeout
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf(WebhookTemplateErrFmt, err)
+	}
+	s := &WebhookSink{cfg: cfg, tmpl: tmpl}
+	if cfg.Rate > 0 {
+		if cfg.Per <= 0 {
+			cfg.Per = time.Minute
+		}
+		s.limit = newRateLimiter(cfg.Rate, cfg.Per)
+	}
+	return s, nil
+}
+
+// WriteEntry implements Sink.
+func (s *WebhookSink) WriteEntry(e *Entry) error {
+	if e.Level < s.cfg.MinLevel {
+		return nil
+	}
+	if s.limit != nil && !s.limit.allow(e.Time) {
+		s.suppressed.Add(1)
+		return nil
+	}
+	data := WebhookData{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Name:    e.Name,
+		Message: e.Message,
+		Fields:  e.FieldMap(),
+	}
+	data.Text = data.Level + ": " + e.Message
+	if e.Name != "" {
+		data.Text = data.Level + " " + e.Name + ": " + e.Message
+	}
+	var body bytes.Buffer
+	if err := s.tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf(EncodeErrFmt, err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return fmt.Errorf(WebhookPayloadErrFmt, strings.TrimSpace(body.String()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	req, err := newPostRequest(ctx, s.cfg.URL, body.Bytes(), nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.cfg.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doHTTP(s.cfg.Client, req)
+}
+
+// Stats implements StatsReporter, counting rate limited entries as
+// suppressed.
+func (s *WebhookSink) Stats() Stats {
+	return Stats{Suppressed: s.suppressed.Load()}
+}
+
+// rateLimiter is a token bucket of n tokens refilled evenly over per.
+type rateLimiter struct {
+	mu     sync.Mutex
+	n      float64
+	per    time.Duration
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(n int, per time.Duration) *rateLimiter {
+	return &rateLimiter{n: float64(n), per: per, tokens: float64(n)}
+}
+
+// allow takes a token at now if one is left.
+func (r *rateLimiter) allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.last.IsZero() && now.After(r.last) {
+		r.tokens += r.n * float64(now.Sub(r.last)) / float64(r.per)
+		if r.tokens > r.n {
+			r.tokens = r.n
+		}
+	}
+	if r.last.IsZero() || now.After(r.last) {
+		r.last = now
+	}
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
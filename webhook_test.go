@@ -0,0 +1,71 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Header.Get("X-Token")+" "+string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, err := NewWebhookSink(WebhookConfig{
+		URL:      srv.URL,
+		Template: `{"title": {{json .Message}}, "sev": {{json .Level}}, "order": {{json (index .Fields "order")}}}`,
+		Rate:     2,
+		Per:      time.Minute,
+		Header:   http.Header{"X-Token": {"t"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := testTime
+	l := newTestLogger(t, Info, &bytes.Buffer{}, WithClock(func() time.Time { return now }), WithSinks(s))
+	l.Info("ignored")
+	for i := 0; i < 3; i++ {
+		l.Log(Error, `charge "failed"`, String("order", "o1"))
+	}
+	now = now.Add(30 * time.Second)
+	l.Log(Critical, "refilled")
+
+	want := []string{
+		`t {"title": "charge \"failed\"", "sev": "ERROR", "order": "o1"}`,
+		`t {"title": "charge \"failed\"", "sev": "ERROR", "order": "o1"}`,
+		`t {"title": "refilled", "sev": "CRITICAL", "order": null}`,
+	}
+	if strings.Join(bodies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("posted\n%s\nwant\n%s", strings.Join(bodies, "\n"), strings.Join(want, "\n"))
+	}
+	if got := l.Stats().Suppressed; got != 1 {
+		t.Errorf("Suppressed = %d, want 1", got)
+	}
+}
+
+func TestWebhookTemplateErrors(t *testing.T) {
+	if _, err := NewWebhookSink(WebhookConfig{Template: "{{"}); err == nil {
+		t.Error("accepted a broken template")
+	}
+	s, err := NewWebhookSink(WebhookConfig{URL: "http://127.0.0.1:1", Template: `{"text": {{.Message}}}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.WriteEntry(&Entry{Level: Error, Message: "not json"})
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("WriteEntry = %v, want an invalid JSON error", err)
+	}
+}
@@ -0,0 +1,235 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWebSocketPing is the interval of the pings keeping idle
+	// streams alive through proxies.
+	DefaultWebSocketPing = 30 * time.Second
+	// DefaultWebSocketWriteTimeout bounds the write of one frame, so a
+	// stuck client cannot hold its subscription forever.
+	DefaultWebSocketWriteTimeout = 10 * time.Second
+
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// maxWebSocketFrame bounds the frames read from clients, which only
+	// send control frames.
+	maxWebSocketFrame = 4096
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xa
+
+	wsCloseGoingAway = 1001
+	wsCloseTooBig    = 1009
+)
+
+// WebSocketHandler returns a handler that upgrades requests to WebSocket
+// and streams the entries of b as JSON text messages, one entry per
+// message, see JSONEncoder. The level and name query parameters filter the
+// stream like StreamFilter:
+//
+//	ws://host/logs?level=warn&name=app.db
+//
+// Messages from the client other than pings and close are ignored.
+func (b *Broadcaster) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(b.serveWebSocket)
+}
+
+func (b *Broadcaster) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, ok := filterFromQuery(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf(StreamLevelErrFmt, r.URL.Query().Get("level")), http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "WebSocket upgrade required", http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+		return
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Time{})
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	rw.WriteString(websocketAccept(key))
+	rw.WriteString("\r\n\r\n")
+	if rw.Flush() != nil {
+		return
+	}
+
+	entries, cancel := b.Subscribe(filter)
+	defer cancel()
+	ws := &wsConn{conn: conn, w: rw.Writer}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ws.readLoop(rw.Reader)
+	}()
+	ping := time.NewTicker(DefaultWebSocketPing)
+	defer ping.Stop()
+
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	for {
+		select {
+		case se, ok := <-entries:
+			if !ok {
+				ws.close(wsCloseGoingAway)
+				return
+			}
+			buf.Reset()
+			if (JSONEncoder{}).Encode(buf, se.Entry) != nil {
+				continue
+			}
+			if ws.write(wsOpText, buf.Bytes()[:buf.Len()-1]) != nil {
+				return
+			}
+		case <-ping.C:
+			if ws.write(wsOpPing, nil) != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept value for key.
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerHasToken reports whether the comma separated header contains
+// token, ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn writes server frames; the stream loop and the read loop share it.
+type wsConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// write sends one unmasked final frame.
+func (c *wsConn) write(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var hdr [10]byte
+	hdr[0] = 0x80 | op
+	n := 2
+	switch l := len(payload); {
+	case l < 126:
+		hdr[1] = byte(l)
+	case l <= 0xffff:
+		hdr[1] = 126
+		binary.BigEndian.PutUint16(hdr[2:], uint16(l))
+		n = 4
+	default:
+		hdr[1] = 127
+		binary.BigEndian.PutUint64(hdr[2:], uint64(l))
+		n = 10
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(DefaultWebSocketWriteTimeout))
+	c.w.Write(hdr[:n])
+	c.w.Write(payload)
+	return c.w.Flush()
+}
+
+// close sends a close frame with code.
+func (c *wsConn) close(code uint16) {
+	var p [2]byte
+	binary.BigEndian.PutUint16(p[:], code)
+	c.write(wsOpClose, p[:])
+}
+
+// readLoop answers pings and returns when the client closes the stream,
+// sends an oversized frame or the connection fails.
+func (c *wsConn) readLoop(r *bufio.Reader) {
+	var hdr [14]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:2]); err != nil {
+			return
+		}
+		op := hdr[0] & 0x0f
+		masked := hdr[1]&0x80 != 0
+		size := uint64(hdr[1] & 0x7f)
+		switch size {
+		case 126:
+			if _, err := io.ReadFull(r, hdr[2:4]); err != nil {
+				return
+			}
+			size = uint64(binary.BigEndian.Uint16(hdr[2:4]))
+		case 127:
+			if _, err := io.ReadFull(r, hdr[2:10]); err != nil {
+				return
+			}
+			size = binary.BigEndian.Uint64(hdr[2:10])
+		}
+		if size > maxWebSocketFrame {
+			c.close(wsCloseTooBig)
+			return
+		}
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(r, mask[:]); err != nil {
+				return
+			}
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+		switch op {
+		case wsOpClose:
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.write(wsOpClose, payload)
+			return
+		case wsOpPing:
+			if c.write(wsOpPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+//go:build !logger_minimal
+
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamFilter(t *testing.T) {
+	f := StreamFilter{Level: Warn, Name: "app.db"}
+	tests := []struct {
+		level LogLevel
+		name  string
+		want  bool
+	}{
+		{Warn, "app.db", true},
+		{Error, "app.db.pool", true},
+		{Info, "app.db", false},
+		{Warn, "app.dbx", false},
+		{Warn, "app", false},
+	}
+	for _, tt := range tests {
+		if got := f.Match(&Entry{Level: tt.level, Name: tt.name}); got != tt.want {
+			t.Errorf("Match(%s, %q) = %v, want %v", tt.level, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// The example of RFC 6455, section 1.3.
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("accept = %s", got)
+	}
+}
+
+// writeClientFrame writes a masked client frame.
+func writeClientFrame(w io.Writer, op byte, payload []byte) {
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{0x80 | op, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	w.Write(frame)
+}
+
+// readServerFrame reads an unmasked server frame.
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	size := int(hdr[1] & 0x7f)
+	if size == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		size = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return hdr[0] & 0x0f, payload
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	b := NewBroadcaster(BroadcastConfig{})
+	l := newTestLogger(t, Debug, io.Discard, WithSinks(b))
+	srv := httptest.NewServer(b.WebSocketHandler())
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /?level=warn HTTP/1.1\r\nHost: x\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Upgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake: %s %v", resp.Status, resp.Header)
+	}
+	for b.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	l.Info("hidden")
+	l.Log(Warn, "disk low", Int("free", 3))
+	op, payload := readServerFrame(t, r)
+	var msg map[string]interface{}
+	if op != wsOpText || json.Unmarshal(payload, &msg) != nil || msg["msg"] != "disk low" || msg["level"] != "WARN" {
+		t.Fatalf("frame %x %s", op, payload)
+	}
+
+	writeClientFrame(conn, wsOpPing, []byte("hi"))
+	if op, payload := readServerFrame(t, r); op != wsOpPong || string(payload) != "hi" {
+		t.Errorf("pong = %x %q", op, payload)
+	}
+	writeClientFrame(conn, wsOpClose, []byte{0x03, 0xe8})
+	if op, _ := readServerFrame(t, r); op != wsOpClose {
+		t.Errorf("close reply = %x", op)
+	}
+	for i := 0; b.Subscribers() != 0; i++ {
+		if i > 1000 {
+			t.Fatal("subscription not ended")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWebSocketHandlerRejects(t *testing.T) {
+	h := NewBroadcaster(BroadcastConfig{}).WebSocketHandler()
+	tests := []struct {
+		target  string
+		version string
+		want    int
+	}{
+		{"/", "", http.StatusBadRequest},
+		{"/?level=loud", "13", http.StatusBadRequest},
+		{"/", "8", http.StatusUpgradeRequired},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.version != "" {
+			r.Header.Set("Connection", "Upgrade")
+			r.Header.Set("Upgrade", "websocket")
+			r.Header.Set("Sec-WebSocket-Key", "k")
+			r.Header.Set("Sec-WebSocket-Version", tt.version)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s v%q: status %d, want %d", tt.target, tt.version, w.Code, tt.want)
+		}
+	}
+}